@@ -0,0 +1,50 @@
+// Package audit exports a record of the API calls made to the Waypoint
+// server to external systems such as a SIEM.
+//
+// Every gRPC call to the server produces an Event once it completes,
+// including calls that were rejected by authentication. Events are
+// encoded in one of the supported formats (JSON lines, CEF, or RFC 5424
+// syslog) and written by an Exporter to one or more sinks: a TCP or UDP
+// address, or a local file that is rotated by size. Exporting is
+// asynchronous so that a slow or unavailable sink never blocks API calls;
+// if the buffer fills up, events are dropped and the drop is logged.
+package audit
+
+import (
+	"time"
+)
+
+// Event is a single audited API call.
+type Event struct {
+	// Time is when the call started.
+	Time time.Time `json:"time"`
+
+	// Endpoint is the name of the gRPC method, such as "QueueJob".
+	Endpoint string `json:"endpoint"`
+
+	// Effects are the effects of the endpoint, such as "mutable".
+	Effects []string `json:"effects,omitempty"`
+
+	// Identity identifies the caller from their token, such as
+	// "user:waypoint" or "runner:arn:aws:iam::123:role/runner". This is
+	// empty if the caller didn't send a valid token.
+	Identity string `json:"identity,omitempty"`
+
+	// PeerAddr is the network address of the caller.
+	PeerAddr string `json:"peer_addr,omitempty"`
+
+	// Code is the gRPC status code of the result, such as "OK" or
+	// "PermissionDenied".
+	Code string `json:"code"`
+
+	// Error is the error message if the call failed.
+	Error string `json:"error,omitempty"`
+
+	// Duration is how long the call took.
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// Success returns true if the call succeeded.
+func (e *Event) Success() bool {
+	return e.Code == "OK"
+}
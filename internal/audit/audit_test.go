@@ -0,0 +1,191 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent() *Event {
+	return &Event{
+		Time:     time.Date(2020, 10, 15, 12, 0, 0, 0, time.UTC),
+		Endpoint: "QueueJob",
+		Effects:  []string{"mutable"},
+		Identity: "user:waypoint",
+		PeerAddr: "10.0.0.1:5000",
+		Code:     "PermissionDenied",
+		Error:    "no = access|here\nreally",
+		Duration: 15 * time.Millisecond,
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	require := require.New(t)
+
+	f, err := ParseFormat("CEF")
+	require.NoError(err)
+	require.Equal(FormatCEF, f)
+
+	_, err = ParseFormat("xml")
+	require.Error(err)
+}
+
+func TestFormatter_json(t *testing.T) {
+	require := require.New(t)
+
+	f := &Formatter{Format: FormatJSON}
+	data, err := f.Encode(testEvent())
+	require.NoError(err)
+	require.True(strings.HasSuffix(string(data), "\n"))
+	require.Equal(1, strings.Count(string(data), "\n"))
+
+	var e Event
+	require.NoError(json.Unmarshal(data, &e))
+	require.Equal(*testEvent(), e)
+}
+
+func TestFormatter_cef(t *testing.T) {
+	require := require.New(t)
+
+	f := &Formatter{Format: FormatCEF, Version: "v0.1|x"}
+	data, err := f.Encode(testEvent())
+	require.NoError(err)
+	require.Equal(
+		`CEF:0|HashiCorp|Waypoint|v0.1\|x|QueueJob|QueueJob|7|`+
+			`rt=1602763200000 act=QueueJob outcome=failure reason=PermissionDenied `+
+			`suser=user:waypoint src=10.0.0.1 cs1Label=effects cs1=mutable `+
+			`cn1Label=durationMs cn1=15 msg=no \= access|here\nreally`+"\n",
+		string(data))
+}
+
+func TestFormatter_syslog(t *testing.T) {
+	require := require.New(t)
+
+	e := testEvent()
+	f := &Formatter{Format: FormatSyslog, Hostname: "host"}
+	data, err := f.Encode(e)
+	require.NoError(err)
+	require.True(strings.HasPrefix(string(data),
+		"<132>1 2020-10-15T12:00:00.000000Z host waypoint "), string(data))
+
+	e.Code = "OK"
+	data, err = f.Encode(e)
+	require.NoError(err)
+	require.True(strings.HasPrefix(string(data), "<134>1 "))
+
+	// The message is the JSON event
+	idx := strings.Index(string(data), " audit - ")
+	require.True(idx > 0)
+	var decoded Event
+	require.NoError(json.Unmarshal(data[idx+len(" audit - "):], &decoded))
+	require.Equal("QueueJob", decoded.Endpoint)
+}
+
+func TestFileSink_rotate(t *testing.T) {
+	require := require.New(t)
+
+	td, err := ioutil.TempDir("", "waypoint-audit")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	path := filepath.Join(td, "audit.log")
+	s, err := NewFileSink(path, 10, 2)
+	require.NoError(err)
+	defer s.Close()
+
+	for _, v := range []string{"aaaaaa\n", "bbbbbb\n", "cccccc\n", "dddddd\n"} {
+		_, err := s.Write([]byte(v))
+		require.NoError(err)
+	}
+
+	read := func(p string) string {
+		data, err := ioutil.ReadFile(p)
+		require.NoError(err)
+		return string(data)
+	}
+	require.Equal("dddddd\n", read(path))
+	require.Equal("cccccc\n", read(path+".1"))
+	require.Equal("bbbbbb\n", read(path+".2"))
+	_, err = os.Stat(path + ".3")
+	require.True(os.IsNotExist(err))
+
+	// Reopening appends
+	require.NoError(s.Close())
+	s, err = NewFileSink(path, 100, 2)
+	require.NoError(err)
+	_, err = s.Write([]byte("eeeeee\n"))
+	require.NoError(err)
+	require.Equal("dddddd\neeeeee\n", read(path))
+}
+
+func TestNewNetSink(t *testing.T) {
+	require := require.New(t)
+
+	s, err := NewNetSink("udp://127.0.0.1:514")
+	require.NoError(err)
+	require.Equal("udp", s.network)
+
+	s, err = NewNetSink("127.0.0.1:514")
+	require.NoError(err)
+	require.Equal("tcp", s.network)
+
+	_, err = NewNetSink("http://127.0.0.1:514")
+	require.Error(err)
+
+	_, err = NewNetSink("tcp://127.0.0.1")
+	require.Error(err)
+}
+
+func TestExporter_tcp(t *testing.T) {
+	require := require.New(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(err)
+	defer ln.Close()
+
+	linesCh := make(chan string, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			linesCh <- scanner.Text()
+		}
+	}()
+
+	sink, err := NewNetSink("tcp://" + ln.Addr().String())
+	require.NoError(err)
+
+	exp := NewExporter(hclog.L(), &Formatter{Format: FormatJSON}, 0, sink)
+	exp.Export(testEvent())
+	exp.Export(testEvent())
+	require.NoError(exp.Close())
+
+	// Export after close is ignored
+	exp.Export(testEvent())
+
+	for i := 0; i < 2; i++ {
+		select {
+		case line := <-linesCh:
+			var e Event
+			require.NoError(json.Unmarshal([]byte(line), &e))
+			require.Equal("QueueJob", e.Endpoint)
+
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+	}
+}
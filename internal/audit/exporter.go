@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"io"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// DefaultBufferSize is the default number of events buffered by an
+// Exporter before events are dropped.
+const DefaultBufferSize = 1024
+
+// Exporter asynchronously encodes events and writes them to sinks. It is
+// safe for concurrent use.
+type Exporter struct {
+	formatter *Formatter
+	sinks     []io.WriteCloser
+	log       hclog.Logger
+
+	eventCh chan *Event
+	doneCh  chan struct{}
+
+	lock    sync.Mutex
+	closed  bool
+	dropped uint64
+}
+
+// NewExporter starts an exporter that writes events encoded with the
+// formatter to each sink. If bufSize is zero, DefaultBufferSize is used.
+// The exporter must be closed with Close to flush buffered events.
+func NewExporter(
+	log hclog.Logger,
+	formatter *Formatter,
+	bufSize int,
+	sinks ...io.WriteCloser,
+) *Exporter {
+	if bufSize <= 0 {
+		bufSize = DefaultBufferSize
+	}
+
+	e := &Exporter{
+		formatter: formatter,
+		sinks:     sinks,
+		log:       log,
+		eventCh:   make(chan *Event, bufSize),
+		doneCh:    make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// Export queues the event to be exported. This never blocks. If the buffer
+// is full, the event is dropped.
+func (e *Exporter) Export(event *Event) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.closed {
+		return
+	}
+
+	select {
+	case e.eventCh <- event:
+	default:
+		e.dropped++
+		if e.dropped == 1 || e.dropped%1000 == 0 {
+			e.log.Warn("audit buffer is full, dropping events", "dropped", e.dropped)
+		}
+	}
+}
+
+// Dropped returns the number of events dropped because the buffer was full.
+func (e *Exporter) Dropped() uint64 {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.dropped
+}
+
+// Close writes any buffered events and closes the sinks.
+func (e *Exporter) Close() error {
+	e.lock.Lock()
+	if e.closed {
+		e.lock.Unlock()
+		return nil
+	}
+	e.closed = true
+	close(e.eventCh)
+	e.lock.Unlock()
+
+	<-e.doneCh
+
+	var result error
+	for _, s := range e.sinks {
+		if err := s.Close(); err != nil && result == nil {
+			result = err
+		}
+	}
+
+	return result
+}
+
+func (e *Exporter) run() {
+	defer close(e.doneCh)
+
+	for event := range e.eventCh {
+		data, err := e.formatter.Encode(event)
+		if err != nil {
+			e.log.Warn("error encoding audit event", "err", err)
+			continue
+		}
+
+		for _, s := range e.sinks {
+			if _, err := s.Write(data); err != nil {
+				e.log.Warn("error writing audit event", "err", err)
+			}
+		}
+	}
+}
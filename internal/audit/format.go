@@ -0,0 +1,202 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is the encoding of exported events.
+type Format string
+
+const (
+	// FormatJSON encodes each event as a JSON object on a single line.
+	FormatJSON Format = "json"
+
+	// FormatCEF encodes each event in the ArcSight Common Event Format.
+	FormatCEF Format = "cef"
+
+	// FormatSyslog encodes each event as an RFC 5424 syslog message with
+	// the JSON encoding of the event as the message.
+	FormatSyslog Format = "syslog"
+)
+
+// Formats are all the supported formats.
+var Formats = []Format{FormatJSON, FormatCEF, FormatSyslog}
+
+// ParseFormat parses the name of a format.
+func ParseFormat(v string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == strings.ToLower(v) {
+			return f, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown audit format %q, must be one of json, cef, syslog", v)
+}
+
+// syslogFacility is the syslog facility for events, local0.
+const syslogFacility = 16
+
+// Formatter encodes events. Each encoded event ends in a newline.
+type Formatter struct {
+	// Format is the format to encode events in.
+	Format Format
+
+	// Version is the version of the Waypoint server. This is included in
+	// the CEF header.
+	Version string
+
+	// Hostname is the hostname included in syslog messages. This defaults
+	// to the hostname of the machine.
+	Hostname string
+}
+
+// Encode encodes the event.
+func (f *Formatter) Encode(e *Event) ([]byte, error) {
+	switch f.Format {
+	case FormatJSON:
+		return f.encodeJSON(e)
+	case FormatCEF:
+		return f.encodeCEF(e), nil
+	case FormatSyslog:
+		return f.encodeSyslog(e)
+	default:
+		return nil, fmt.Errorf("unknown audit format %q", f.Format)
+	}
+}
+
+func (f *Formatter) encodeJSON(e *Event) ([]byte, error) {
+	v := *e
+	v.Time = v.Time.UTC()
+	result, err := json.Marshal(&v)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(result, '\n'), nil
+}
+
+func (f *Formatter) encodeCEF(e *Event) []byte {
+	// Severity is 0-10. Failed authentication is the most interesting
+	// event for security teams so it gets the highest severity.
+	severity := 3
+	outcome := "success"
+	if !e.Success() {
+		outcome = "failure"
+		severity = 5
+		if e.Code == "Unauthenticated" || e.Code == "PermissionDenied" {
+			severity = 7
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("CEF:0|HashiCorp|Waypoint|")
+	b.WriteString(cefHeaderEscape(f.Version))
+	b.WriteString("|")
+	b.WriteString(cefHeaderEscape(e.Endpoint))
+	b.WriteString("|")
+	b.WriteString(cefHeaderEscape(e.Endpoint))
+	b.WriteString("|")
+	b.WriteString(strconv.Itoa(severity))
+	b.WriteString("|")
+
+	ext := [][2]string{
+		{"rt", strconv.FormatInt(e.Time.UnixNano()/int64(time.Millisecond), 10)},
+		{"act", e.Endpoint},
+		{"outcome", outcome},
+		{"reason", e.Code},
+	}
+	if e.Identity != "" {
+		ext = append(ext, [2]string{"suser", e.Identity})
+	}
+	if host := peerHost(e.PeerAddr); host != "" {
+		ext = append(ext, [2]string{"src", host})
+	}
+	if len(e.Effects) > 0 {
+		ext = append(ext,
+			[2]string{"cs1Label", "effects"},
+			[2]string{"cs1", strings.Join(e.Effects, ",")})
+	}
+	ext = append(ext,
+		[2]string{"cn1Label", "durationMs"},
+		[2]string{"cn1", strconv.FormatInt(int64(e.Duration/time.Millisecond), 10)})
+	if e.Error != "" {
+		ext = append(ext, [2]string{"msg", e.Error})
+	}
+
+	for i, kv := range ext {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(kv[0])
+		b.WriteString("=")
+		b.WriteString(cefExtensionEscape(kv[1]))
+	}
+
+	b.WriteString("\n")
+	return []byte(b.String())
+}
+
+func (f *Formatter) encodeSyslog(e *Event) ([]byte, error) {
+	msg, err := f.encodeJSON(e)
+	if err != nil {
+		return nil, err
+	}
+
+	severity := 6 // informational
+	if !e.Success() {
+		severity = 4 // warning
+	}
+
+	hostname := f.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+	if hostname == "" {
+		hostname = "-"
+	}
+
+	header := fmt.Sprintf("<%d>1 %s %s waypoint %d audit - ",
+		syslogFacility*8+severity,
+		e.Time.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		hostname,
+		os.Getpid(),
+	)
+
+	return append([]byte(header), msg...), nil
+}
+
+// cefHeaderEscape escapes a CEF header field.
+func cefHeaderEscape(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `|`, `\|`)
+	return stripNewlines(v)
+}
+
+// cefExtensionEscape escapes a CEF extension value.
+func cefExtensionEscape(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `=`, `\=`)
+	v = strings.ReplaceAll(v, "\r", `\r`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	return v
+}
+
+func stripNewlines(v string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(v)
+}
+
+// peerHost returns the host part of a network address.
+func peerHost(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	return host
+}
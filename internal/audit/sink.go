@@ -0,0 +1,201 @@
+package audit
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sinkTimeout is the timeout for connecting and writing to network sinks.
+const sinkTimeout = 5 * time.Second
+
+// NetSink writes events to a TCP or UDP address. The connection is opened
+// on the first write and reopened on the next write after an error, so an
+// unavailable collector only loses the events sent while it was down.
+// Events are separated by newlines.
+type NetSink struct {
+	network string
+	addr    string
+
+	lock sync.Mutex
+	conn net.Conn
+}
+
+// NewNetSink returns a sink for an address in the format "tcp://host:port"
+// or "udp://host:port". If the scheme is omitted, TCP is used.
+func NewNetSink(address string) (*NetSink, error) {
+	network := "tcp"
+	addr := address
+	if idx := strings.Index(address, "://"); idx >= 0 {
+		network = address[:idx]
+		addr = address[idx+3:]
+	}
+
+	switch network {
+	case "tcp", "udp":
+	default:
+		return nil, fmt.Errorf("audit address %q must use tcp or udp", address)
+	}
+
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, fmt.Errorf("invalid audit address %q: %s", address, err)
+	}
+
+	return &NetSink{network: network, addr: addr}, nil
+}
+
+// Write implements io.Writer. Each call writes one event.
+func (s *NetSink) Write(p []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.network, s.addr, sinkTimeout)
+		if err != nil {
+			return 0, err
+		}
+
+		s.conn = conn
+	}
+
+	s.conn.SetWriteDeadline(time.Now().Add(sinkTimeout))
+	n, err := s.conn.Write(p)
+	if err != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+
+	return n, err
+}
+
+// Close closes the connection.
+func (s *NetSink) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// FileSink writes events to a file. When the file would exceed the maximum
+// size, it is renamed to "PATH.1", existing backups are shifted up by one,
+// and a new file is started. Backups beyond the maximum count are removed.
+type FileSink struct {
+	path       string
+	maxBytes   int64
+	maxBackups int
+
+	lock sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// NewFileSink opens the file at path for appending. If maxBytes is zero,
+// the file is never rotated.
+func NewFileSink(path string, maxBytes int64, maxBackups int) (*FileSink, error) {
+	s := &FileSink{path: path, maxBytes: maxBytes, maxBackups: maxBackups}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Write implements io.Writer. Each call writes one event so that events
+// are never split across files.
+func (s *FileSink) Write(p []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.f == nil {
+		return 0, os.ErrClosed
+	}
+
+	if s.maxBytes > 0 && s.size > 0 && s.size+int64(len(p)) > s.maxBytes {
+		if err := s.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := s.f.Write(p)
+	s.size += int64(n)
+	return n, err
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.f == nil {
+		return nil
+	}
+
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
+
+// open opens the file. This must be called with the lock held.
+func (s *FileSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	s.f = f
+	s.size = fi.Size()
+	return nil
+}
+
+// rotate moves the current file to the first backup and opens a new file.
+// This must be called with the lock held.
+func (s *FileSink) rotate() error {
+	if err := s.f.Close(); err != nil {
+		return err
+	}
+	s.f = nil
+
+	if s.maxBackups <= 0 {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		return s.open()
+	}
+
+	// Remove the oldest backup and shift the rest up by one.
+	oldest := s.backupPath(s.maxBackups)
+	if err := os.Remove(oldest); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := s.maxBackups - 1; i >= 1; i-- {
+		err := os.Rename(s.backupPath(i), s.backupPath(i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(s.path, s.backupPath(1)); err != nil {
+		return err
+	}
+
+	return s.open()
+}
+
+func (s *FileSink) backupPath(n int) string {
+	return fmt.Sprintf("%s.%d", s.path, n)
+}
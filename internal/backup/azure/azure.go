@@ -0,0 +1,300 @@
+// Package azure uploads backups to Azure Blob Storage using its REST API
+// authorized with the storage account key.
+package azure
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/waypoint/internal/backup"
+)
+
+const (
+	// apiVersion is the version of the REST API that we use.
+	apiVersion = "2019-12-12"
+
+	// blockSize is the size of the blocks that blobs are uploaded in.
+	blockSize = 4 * 1024 * 1024 // 4 MB
+)
+
+// Store uploads backups to an Azure Blob Storage container.
+type Store struct {
+	// Client is the HTTP client used for requests.
+	Client *http.Client
+
+	// Account and Key are the name and base64-encoded access key of the
+	// storage account.
+	Account string
+	Key     string
+
+	// Endpoint is the address of the blob service. This defaults to
+	// "https://<account>.blob.core.windows.net".
+	Endpoint string
+
+	// Container is the container that backups are uploaded to.
+	Container string
+}
+
+// New returns a Store for the container of the storage account. The
+// account key must be set in the AZURE_STORAGE_KEY environment variable.
+func New(account, key, container string) (*Store, error) {
+	if account == "" {
+		return nil, fmt.Errorf("the storage account must be set")
+	}
+	if _, err := base64.StdEncoding.DecodeString(key); err != nil || key == "" {
+		return nil, fmt.Errorf("a valid storage account key must be set")
+	}
+
+	return &Store{Account: account, Key: key, Container: container}, nil
+}
+
+// Name implements backup.Store
+func (s *Store) Name() string { return "azure" }
+
+// Put implements backup.Store. The data is uploaded in blocks that aren't
+// visible until they're committed as the blob once they're all uploaded.
+func (s *Store) Put(ctx context.Context, name string, r io.Reader) error {
+	u := s.blobURL(name)
+	buf := make([]byte, blockSize)
+
+	var blocks []string
+	for {
+		n, err := io.ReadFull(r, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return err
+		}
+
+		// Block IDs must all be the same length.
+		id := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%08d", len(blocks))))
+		q := url.Values{}
+		q.Set("comp", "block")
+		q.Set("blockid", id)
+		if err := s.do(ctx, "PUT", u+"?"+q.Encode(), buf[:n], nil); err != nil {
+			return err
+		}
+		blocks = append(blocks, id)
+
+		if err == io.ErrUnexpectedEOF {
+			break
+		}
+	}
+
+	var list bytes.Buffer
+	list.WriteString(xml.Header)
+	list.WriteString("<BlockList>")
+	for _, id := range blocks {
+		fmt.Fprintf(&list, "<Latest>%s</Latest>", id)
+	}
+	list.WriteString("</BlockList>")
+
+	return s.do(ctx, "PUT", u+"?comp=blocklist", list.Bytes(), nil)
+}
+
+// List implements backup.Store
+func (s *Store) List(ctx context.Context, prefix string) ([]*backup.Object, error) {
+	var result []*backup.Object
+	var marker string
+	for {
+		q := url.Values{}
+		q.Set("restype", "container")
+		q.Set("comp", "list")
+		q.Set("prefix", prefix)
+		if marker != "" {
+			q.Set("marker", marker)
+		}
+
+		var page struct {
+			Blobs []struct {
+				Name       string `xml:"Name"`
+				Properties struct {
+					LastModified  string `xml:"Last-Modified"`
+					ContentLength int64  `xml:"Content-Length"`
+				} `xml:"Properties"`
+			} `xml:"Blobs>Blob"`
+			NextMarker string `xml:"NextMarker"`
+		}
+		if err := s.do(ctx, "GET", s.containerURL()+"?"+q.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+
+		for _, blob := range page.Blobs {
+			modified, err := http.ParseTime(blob.Properties.LastModified)
+			if err != nil {
+				return nil, fmt.Errorf("invalid modified time of blob %q: %s", blob.Name, err)
+			}
+
+			result = append(result, &backup.Object{
+				Name:     blob.Name,
+				Size:     blob.Properties.ContentLength,
+				Modified: modified,
+			})
+		}
+
+		if page.NextMarker == "" {
+			return result, nil
+		}
+		marker = page.NextMarker
+	}
+}
+
+// Delete implements backup.Store
+func (s *Store) Delete(ctx context.Context, name string) error {
+	err := s.do(ctx, "DELETE", s.blobURL(name), nil, nil)
+	if e, ok := err.(*apiError); ok && e.StatusCode == http.StatusNotFound {
+		err = nil
+	}
+
+	return err
+}
+
+func (s *Store) containerURL() string {
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = "https://" + s.Account + ".blob.core.windows.net"
+	}
+
+	return strings.TrimSuffix(endpoint, "/") + "/" + url.PathEscape(s.Container)
+}
+
+func (s *Store) blobURL(name string) string {
+	// Blob names can have slashes which are kept as path separators.
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+
+	return s.containerURL() + "/" + strings.Join(parts, "/")
+}
+
+// do sends an authorized request with the body and decodes the XML
+// response into out if it is set.
+func (s *Store) do(ctx context.Context, method, u string, body []byte, out interface{}) error {
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(body))
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", apiVersion)
+	if err := s.sign(req); err != nil {
+		return err
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &apiError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return xml.NewDecoder(resp.Body).Decode(out)
+}
+
+// sign sets the Authorization header of the request using the Shared Key
+// scheme.
+func (s *Store) sign(req *http.Request) error {
+	key, err := base64.StdEncoding.DecodeString(s.Key)
+	if err != nil {
+		return fmt.Errorf("invalid storage account key: %s", err)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign(s.Account, req)))
+	req.Header.Set("Authorization", fmt.Sprintf("SharedKey %s:%s",
+		s.Account, base64.StdEncoding.EncodeToString(mac.Sum(nil))))
+	return nil
+}
+
+// stringToSign returns the string that is signed to authorize the request
+// with the Shared Key scheme.
+func stringToSign(account string, req *http.Request) string {
+	// The content length is empty rather than zero if there's no body.
+	var length string
+	if req.ContentLength > 0 {
+		length = strconv.FormatInt(req.ContentLength, 10)
+	}
+
+	h := req.Header
+	lines := []string{
+		req.Method,
+		h.Get("Content-Encoding"),
+		h.Get("Content-Language"),
+		length,
+		h.Get("Content-MD5"),
+		h.Get("Content-Type"),
+		"", // Date, we use x-ms-date instead
+		h.Get("If-Modified-Since"),
+		h.Get("If-Match"),
+		h.Get("If-None-Match"),
+		h.Get("If-Unmodified-Since"),
+		h.Get("Range"),
+	}
+
+	// The x-ms- headers, sorted.
+	var names []string
+	for name := range h {
+		if name = strings.ToLower(name); strings.HasPrefix(name, "x-ms-") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		lines = append(lines, name+":"+strings.TrimSpace(h.Get(name)))
+	}
+
+	// The resource followed by the query parameters, sorted.
+	resource := "/" + account + req.URL.EscapedPath()
+	query := req.URL.Query()
+	var params []string
+	for name := range query {
+		params = append(params, name)
+	}
+	sort.Strings(params)
+	for _, name := range params {
+		values := query[name]
+		sort.Strings(values)
+		resource += "\n" + strings.ToLower(name) + ":" + strings.Join(values, ",")
+	}
+	lines = append(lines, resource)
+
+	return strings.Join(lines, "\n")
+}
+
+// apiError is an error response from the API.
+type apiError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("blob storage request failed with status %d: %s", e.StatusCode, e.Body)
+}
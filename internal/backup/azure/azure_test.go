@@ -0,0 +1,159 @@
+package azure
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	key := base64.StdEncoding.EncodeToString([]byte("secret"))
+	srv := newTestServer(t, "acct", key)
+	defer srv.Close()
+
+	s, err := New("acct", key, "backups")
+	require.NoError(err)
+	s.Endpoint = srv.URL
+
+	// Upload a blob of a few blocks
+	data := bytes.Repeat([]byte("x"), 2*blockSize+10)
+	require.NoError(s.Put(ctx, "dir/a.snap", bytes.NewReader(data)))
+	require.NoError(s.Put(ctx, "dir/b.snap", strings.NewReader("small")))
+	require.NoError(s.Put(ctx, "other", strings.NewReader("other")))
+
+	objs, err := s.List(ctx, "dir/")
+	require.NoError(err)
+	require.Len(objs, 2)
+	require.Equal("dir/a.snap", objs[0].Name)
+	require.Equal(int64(len(data)), objs[0].Size)
+	require.Equal("dir/b.snap", objs[1].Name)
+	require.Equal(int64(5), objs[1].Size)
+
+	require.NoError(s.Delete(ctx, "dir/a.snap"))
+	require.NoError(s.Delete(ctx, "dir/a.snap"))
+	objs, err = s.List(ctx, "dir/")
+	require.NoError(err)
+	require.Len(objs, 1)
+
+	// A wrong key is rejected
+	s.Key = base64.StdEncoding.EncodeToString([]byte("wrong"))
+	_, err = s.List(ctx, "")
+	require.Error(err)
+	require.Contains(err.Error(), "403")
+}
+
+// testServer is a fake of the blob service for a single container.
+type testServer struct {
+	*httptest.Server
+
+	t       *testing.T
+	account string
+	key     []byte
+
+	lock   sync.Mutex
+	blocks map[string][]byte
+	blobs  map[string][]byte
+}
+
+func newTestServer(t *testing.T, account, key string) *testServer {
+	k, err := base64.StdEncoding.DecodeString(key)
+	require.NoError(t, err)
+
+	s := &testServer{
+		t:       t,
+		account: account,
+		key:     k,
+		blocks:  map[string][]byte{},
+		blobs:   map[string][]byte{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+func (s *testServer) handle(w http.ResponseWriter, r *http.Request) {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(stringToSign(s.account, r)))
+	expected := fmt.Sprintf("SharedKey %s:%s",
+		s.account, base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	if r.Header.Get("Authorization") != expected {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	require.NoError(s.t, err)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	name := strings.TrimPrefix(r.URL.Path, "/backups/")
+	q := r.URL.Query()
+	switch {
+	case r.Method == "PUT" && q.Get("comp") == "block":
+		s.blocks[name+"/"+q.Get("blockid")] = body
+		w.WriteHeader(http.StatusCreated)
+
+	case r.Method == "PUT" && q.Get("comp") == "blocklist":
+		var list struct {
+			Latest []string `xml:"Latest"`
+		}
+		require.NoError(s.t, xml.Unmarshal(body, &list))
+
+		var blob []byte
+		for _, id := range list.Latest {
+			blob = append(blob, s.blocks[name+"/"+id]...)
+		}
+		s.blobs[name] = blob
+		w.WriteHeader(http.StatusCreated)
+
+	case r.Method == "GET" && q.Get("comp") == "list":
+		fmt.Fprint(w, "<EnumerationResults><Blobs>")
+		for _, name := range sortedKeys(s.blobs) {
+			if !strings.HasPrefix(name, q.Get("prefix")) {
+				continue
+			}
+
+			fmt.Fprintf(w, "<Blob><Name>%s</Name><Properties>"+
+				"<Last-Modified>%s</Last-Modified><Content-Length>%d</Content-Length>"+
+				"</Properties></Blob>", name, time.Now().UTC().Format(http.TimeFormat), len(s.blobs[name]))
+		}
+		fmt.Fprint(w, "</Blobs><NextMarker /></EnumerationResults>")
+
+	case r.Method == "DELETE":
+		if _, ok := s.blobs[name]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(s.blobs, name)
+		w.WriteHeader(http.StatusAccepted)
+
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func sortedKeys(m map[string][]byte) []string {
+	var result []string
+	for k := range m {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
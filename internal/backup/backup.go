@@ -0,0 +1,43 @@
+// Package backup uploads snapshots of the server state to remote object
+// storage such as S3, Google Cloud Storage, or Azure Blob Storage so that
+// the state can be recovered if the server and its disk are lost.
+//
+// Store implementations are in subpackages so that the server only
+// depends on the client libraries of the store in use.
+package backup
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Store is remote object storage that backups are uploaded to.
+type Store interface {
+	// Name is the type of store, such as "s3".
+	Name() string
+
+	// Put uploads the data read from r until EOF as the object with the
+	// given name. The size isn't known in advance so the data must be
+	// streamed. The object must not be visible until it is complete.
+	Put(ctx context.Context, name string, r io.Reader) error
+
+	// List returns the objects whose names start with prefix.
+	List(ctx context.Context, prefix string) ([]*Object, error)
+
+	// Delete deletes the object with the given name. This should not
+	// return an error if the object doesn't exist.
+	Delete(ctx context.Context, name string) error
+}
+
+// Object is an object in a Store.
+type Object struct {
+	// Name is the full name of the object.
+	Name string
+
+	// Size is the size of the object in bytes.
+	Size int64
+
+	// Modified is the time the object was last modified.
+	Modified time.Time
+}
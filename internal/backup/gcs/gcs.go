@@ -0,0 +1,178 @@
+// Package gcs uploads backups to Google Cloud Storage using its JSON API.
+package gcs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/oauth2/google"
+
+	"github.com/hashicorp/waypoint/internal/backup"
+)
+
+// DefaultEndpoint is the address of the Cloud Storage API.
+const DefaultEndpoint = "https://storage.googleapis.com"
+
+// Store uploads backups to a Cloud Storage bucket.
+type Store struct {
+	// Client is the HTTP client used for requests. This must authenticate
+	// the requests.
+	Client *http.Client
+
+	// Endpoint is the address of the API. This defaults to
+	// DefaultEndpoint.
+	Endpoint string
+
+	// Bucket is the bucket that backups are uploaded to.
+	Bucket string
+}
+
+// New returns a Store for the bucket using the application default
+// credentials, such as the GOOGLE_APPLICATION_CREDENTIALS environment
+// variable or the service account of the instance.
+func New(ctx context.Context, bucket string) (*Store, error) {
+	client, err := google.DefaultClient(ctx,
+		"https://www.googleapis.com/auth/devstorage.read_write")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{Client: client, Bucket: bucket}, nil
+}
+
+// Name implements backup.Store
+func (s *Store) Name() string { return "gcs" }
+
+// Put implements backup.Store. The object is only created once the whole
+// upload is received.
+func (s *Store) Put(ctx context.Context, name string, r io.Reader) error {
+	q := url.Values{}
+	q.Set("uploadType", "media")
+	q.Set("name", name)
+	u := s.endpoint() + "/upload/storage/v1/b/" + url.PathEscape(s.Bucket) + "/o?" + q.Encode()
+
+	req, err := http.NewRequest("POST", u, ioutil.NopCloser(r))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	return s.do(ctx, req, nil)
+}
+
+// List implements backup.Store
+func (s *Store) List(ctx context.Context, prefix string) ([]*backup.Object, error) {
+	var result []*backup.Object
+	var token string
+	for {
+		q := url.Values{}
+		q.Set("prefix", prefix)
+		if token != "" {
+			q.Set("pageToken", token)
+		}
+		u := s.endpoint() + "/storage/v1/b/" + url.PathEscape(s.Bucket) + "/o?" + q.Encode()
+
+		req, err := http.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Items []struct {
+				Name    string    `json:"name"`
+				Size    string    `json:"size"`
+				Updated time.Time `json:"updated"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := s.do(ctx, req, &page); err != nil {
+			return nil, err
+		}
+
+		for _, item := range page.Items {
+			// Sizes are strings since they're 64-bit integers.
+			size, err := strconv.ParseInt(item.Size, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid size of object %q: %s", item.Name, err)
+			}
+
+			result = append(result, &backup.Object{
+				Name:     item.Name,
+				Size:     size,
+				Modified: item.Updated,
+			})
+		}
+
+		if page.NextPageToken == "" {
+			return result, nil
+		}
+		token = page.NextPageToken
+	}
+}
+
+// Delete implements backup.Store
+func (s *Store) Delete(ctx context.Context, name string) error {
+	u := s.endpoint() + "/storage/v1/b/" + url.PathEscape(s.Bucket) + "/o/" + url.PathEscape(name)
+	req, err := http.NewRequest("DELETE", u, nil)
+	if err != nil {
+		return err
+	}
+
+	err = s.do(ctx, req, nil)
+	if e, ok := err.(*apiError); ok && e.StatusCode == http.StatusNotFound {
+		err = nil
+	}
+
+	return err
+}
+
+func (s *Store) endpoint() string {
+	if s.Endpoint != "" {
+		return s.Endpoint
+	}
+
+	return DefaultEndpoint
+}
+
+// do sends the request and decodes the JSON response into out if it is
+// set.
+func (s *Store) do(ctx context.Context, req *http.Request, out interface{}) error {
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &apiError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// apiError is an error response from the API.
+type apiError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("cloud storage request failed with status %d: %s", e.StatusCode, e.Body)
+}
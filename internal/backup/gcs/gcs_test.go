@@ -0,0 +1,102 @@
+package gcs
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// A fake of the API for a single bucket
+	var lock sync.Mutex
+	objects := map[string][]byte{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/upload/storage/v1/b/backups/o":
+			require.Equal("media", r.URL.Query().Get("uploadType"))
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(err)
+			objects[r.URL.Query().Get("name")] = body
+			w.Write([]byte("{}"))
+
+		case r.Method == "GET" && r.URL.Path == "/storage/v1/b/backups/o":
+			var names []string
+			for name := range objects {
+				if strings.HasPrefix(name, r.URL.Query().Get("prefix")) {
+					names = append(names, name)
+				}
+			}
+			sort.Strings(names)
+
+			// Return one object per page to test paging.
+			start := 0
+			if token := r.URL.Query().Get("pageToken"); token != "" {
+				start, _ = strconv.Atoi(token)
+			}
+
+			var page struct {
+				Items         []map[string]string `json:"items"`
+				NextPageToken string              `json:"nextPageToken,omitempty"`
+			}
+			if start < len(names) {
+				name := names[start]
+				page.Items = append(page.Items, map[string]string{
+					"name":    name,
+					"size":    strconv.Itoa(len(objects[name])),
+					"updated": time.Now().Format(time.RFC3339),
+				})
+				if start+1 < len(names) {
+					page.NextPageToken = strconv.Itoa(start + 1)
+				}
+			}
+			json.NewEncoder(w).Encode(page)
+
+		case r.Method == "DELETE":
+			name := strings.TrimPrefix(r.URL.Path, "/storage/v1/b/backups/o/")
+			if _, ok := objects[name]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			delete(objects, name)
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	s := &Store{Endpoint: srv.URL, Bucket: "backups"}
+	require.NoError(s.Put(ctx, "dir/a.snap", strings.NewReader("hello")))
+	require.NoError(s.Put(ctx, "dir/b.snap", strings.NewReader("hi")))
+	require.NoError(s.Put(ctx, "other", strings.NewReader("other")))
+
+	objs, err := s.List(ctx, "dir/")
+	require.NoError(err)
+	require.Len(objs, 2)
+	require.Equal("dir/a.snap", objs[0].Name)
+	require.Equal(int64(5), objs[0].Size)
+	require.Equal("dir/b.snap", objs[1].Name)
+
+	require.NoError(s.Delete(ctx, "dir/a.snap"))
+	require.NoError(s.Delete(ctx, "dir/a.snap"))
+	objs, err = s.List(ctx, "dir/")
+	require.NoError(err)
+	require.Len(objs, 1)
+}
@@ -0,0 +1,98 @@
+// Package s3 uploads backups to Amazon S3 or storage with an S3-compatible
+// API such as MinIO.
+package s3
+
+import (
+	"context"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+
+	"github.com/hashicorp/waypoint/internal/backup"
+)
+
+// Store uploads backups to an S3 bucket.
+type Store struct {
+	// Client is the S3 API client.
+	Client *s3.S3
+
+	// Bucket is the bucket that backups are uploaded to.
+	Bucket string
+}
+
+// New returns a Store for the bucket using the standard AWS credentials
+// chain, such as the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
+// environment variables or an instance role. If endpoint is set, it is
+// the address of S3-compatible storage to use instead of AWS.
+func New(bucket, region, endpoint string) (*Store, error) {
+	cfg := aws.NewConfig()
+	if region != "" {
+		cfg = cfg.WithRegion(region)
+	}
+	if endpoint != "" {
+		// S3-compatible storage generally doesn't support bucket
+		// subdomains so we put the bucket in the path.
+		cfg = cfg.WithEndpoint(endpoint).WithS3ForcePathStyle(true)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *cfg,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{Client: s3.New(sess), Bucket: bucket}, nil
+}
+
+// Name implements backup.Store
+func (s *Store) Name() string { return "s3" }
+
+// Put implements backup.Store. Large backups are uploaded in parts and
+// S3 only creates the object once every part is uploaded.
+func (s *Store) Put(ctx context.Context, name string, r io.Reader) error {
+	_, err := s3manager.NewUploaderWithClient(s.Client).UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(name),
+		Body:   r,
+	})
+	return err
+}
+
+// List implements backup.Store
+func (s *Store) List(ctx context.Context, prefix string) ([]*backup.Object, error) {
+	var result []*backup.Object
+	err := s.Client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.Bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, last bool) bool {
+		for _, obj := range page.Contents {
+			result = append(result, &backup.Object{
+				Name:     aws.StringValue(obj.Key),
+				Size:     aws.Int64Value(obj.Size),
+				Modified: aws.TimeValue(obj.LastModified),
+			})
+		}
+
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Delete implements backup.Store. S3 doesn't return an error for objects
+// that don't exist.
+func (s *Store) Delete(ctx context.Context, name string) error {
+	_, err := s.Client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(name),
+	})
+	return err
+}
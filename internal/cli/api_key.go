@@ -0,0 +1,299 @@
+package cli
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+type APIKeyCreateCommand struct {
+	*baseCommand
+
+	flagName  string
+	flagRoles []string
+}
+
+func (c *APIKeyCreateCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	resp, err := c.project.Client().CreateAPIKey(c.Ctx, &pb.CreateAPIKeyRequest{
+		Name:  c.flagName,
+		Roles: c.flagRoles,
+	})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.ui.Output("API key %s created. The token below is only shown once.",
+		resp.ApiKey.Id, terminal.WithSuccessStyle())
+	c.ui.Output(resp.Token)
+	return 0
+}
+
+func (c *APIKeyCreateCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.StringVar(&flag.StringVar{
+			Name:   "name",
+			Target: &c.flagName,
+			Usage:  "Description of what the API key is used for.",
+		})
+
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:   "role",
+			Target: &c.flagRoles,
+			Usage: "Role of the API key, such as \"sre\". Roles restrict who can " +
+				"approve jobs for protected workspaces. Only the default user can set " +
+				"roles, keys of other users have the roles of the user. This can be " +
+				"specified multiple times.",
+		})
+	})
+}
+
+func (c *APIKeyCreateCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *APIKeyCreateCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *APIKeyCreateCommand) Synopsis() string {
+	return "Create a new API key."
+}
+
+func (c *APIKeyCreateCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint api-key create [options]
+
+  Create a new API key for the current user and output its token.
+
+  API keys are long-lived tokens meant for automation. The server tracks
+  when each key was last used and how many calls it made so that stale
+  keys can be found and deleted.
+
+` + c.Flags().Help())
+}
+
+type APIKeyListCommand struct {
+	*baseCommand
+
+	flagUser string
+}
+
+func (c *APIKeyListCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	resp, err := c.project.Client().ListAPIKeys(c.Ctx, &pb.ListAPIKeysRequest{
+		User: c.flagUser,
+	})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	table := terminal.NewTable("ID", "User", "Name", "Roles", "Created", "Last Used", "Calls")
+	for _, key := range resp.ApiKeys {
+		var created, lastUsed, calls string
+		if t, err := ptypes.Timestamp(key.CreatedAt); err == nil {
+			created = humanize.Time(t)
+		}
+
+		lastUsed = "never"
+		calls = "0"
+		if key.Usage != nil {
+			if t, err := ptypes.Timestamp(key.Usage.LastUsedAt); err == nil {
+				lastUsed = humanize.Time(t)
+			}
+			calls = strconv.FormatUint(key.Usage.CallCount, 10)
+		}
+
+		table.Rich([]string{
+			key.Id,
+			key.User,
+			key.Name,
+			strings.Join(key.Roles, ","),
+			created,
+			lastUsed,
+			calls,
+		}, nil)
+	}
+
+	c.ui.Table(table)
+	return 0
+}
+
+func (c *APIKeyListCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.StringVar(&flag.StringVar{
+			Name:   "user",
+			Target: &c.flagUser,
+			Usage:  "Only list the API keys for this user.",
+		})
+	})
+}
+
+func (c *APIKeyListCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *APIKeyListCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *APIKeyListCommand) Synopsis() string {
+	return "List API keys and their usage."
+}
+
+func (c *APIKeyListCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint api-key list [options]
+
+  List API keys and their usage.
+
+  This shows when each key was last used and the number of calls made
+  with it, which can be used to find keys that are no longer in use.
+
+` + c.Flags().Help())
+}
+
+type APIKeyRotateCommand struct {
+	*baseCommand
+}
+
+func (c *APIKeyRotateCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	if len(c.args) == 0 {
+		c.ui.Output("API key ID required for rotation", terminal.WithErrorStyle())
+		return 1
+	}
+
+	resp, err := c.project.Client().RotateAPIKey(c.Ctx, &pb.RotateAPIKeyRequest{
+		Id: c.args[0],
+	})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.ui.Output("API key %s rotated. The previous token is no longer valid.",
+		resp.ApiKey.Id, terminal.WithSuccessStyle())
+	c.ui.Output(resp.Token)
+	return 0
+}
+
+func (c *APIKeyRotateCommand) Flags() *flag.Sets {
+	return c.flagSet(0, nil)
+}
+
+func (c *APIKeyRotateCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *APIKeyRotateCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *APIKeyRotateCommand) Synopsis() string {
+	return "Issue a new token for an API key."
+}
+
+func (c *APIKeyRotateCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint api-key rotate ID
+
+  Issue a new token for an API key and output it.
+
+  The previous token for the key stops working immediately. The usage
+  of the key is kept.
+
+`)
+}
+
+type APIKeyDeleteCommand struct {
+	*baseCommand
+}
+
+func (c *APIKeyDeleteCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	if len(c.args) == 0 {
+		c.ui.Output("API key ID required for deletion", terminal.WithErrorStyle())
+		return 1
+	}
+
+	_, err := c.project.Client().DeleteAPIKey(c.Ctx, &pb.DeleteAPIKeyRequest{
+		Id: c.args[0],
+	})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.ui.Output("API key deleted.", terminal.WithSuccessStyle())
+	return 0
+}
+
+func (c *APIKeyDeleteCommand) Flags() *flag.Sets {
+	return c.flagSet(0, nil)
+}
+
+func (c *APIKeyDeleteCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *APIKeyDeleteCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *APIKeyDeleteCommand) Synopsis() string {
+	return "Delete an API key."
+}
+
+func (c *APIKeyDeleteCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint api-key delete ID
+
+  Delete an API key. Its token stops working immediately.
+
+`)
+}
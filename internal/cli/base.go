@@ -3,10 +3,13 @@ package cli
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"math"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/adrg/xdg"
 	"github.com/hashicorp/go-hclog"
@@ -80,6 +83,39 @@ type baseCommand struct {
 	// flagRemoteSource are the remote data source overrides for jobs.
 	flagRemoteSource map[string]string
 
+	// flagFederationServer is the downstream server to forward jobs to.
+	flagFederationServer string
+
+	// flagPriority is the priority of queued jobs.
+	flagPriority int
+
+	// flagRunnerLabels are the labels of the runners to target.
+	flagRunnerLabels map[string]string
+
+	// flagRunnerPool is the pool of the runners to target.
+	flagRunnerPool string
+
+	// flagRunnerAffinity and flagRunnerAffinityWait are the runner
+	// affinity of queued jobs.
+	flagRunnerAffinity     []string
+	flagRunnerAffinityWait time.Duration
+
+	// flagRunnerPlatform is the platform that queued jobs must run on.
+	flagRunnerPlatform string
+
+	// flagRunnerTags are the resource tags that the runners of queued
+	// jobs must have.
+	flagRunnerTags []string
+
+	// flagRunnerRegion and flagRunnerZone are the locality that queued
+	// jobs prefer.
+	flagRunnerRegion string
+	flagRunnerZone   string
+
+	// flagCPU and flagMemory are the resources that queued jobs require.
+	flagCPU    int64
+	flagMemory int64
+
 	// flagApp is the app to target.
 	flagApp string
 
@@ -250,6 +286,22 @@ func (c *baseCommand) Init(opts ...Option) error {
 		}
 	}
 
+	// Forwarded jobs run on the downstream server's runners.
+	if c.flagFederationServer != "" && !c.flagRemote {
+		err := errors.New(
+			"The `-federation-server` flag requires the `-remote` flag since\n" +
+				"forwarded operations run on the runners of the downstream server.")
+		c.logError(c.Log, "", err)
+		return err
+	}
+
+	if c.flagPriority < math.MinInt32 || c.flagPriority > math.MaxInt32 {
+		err := fmt.Errorf("The `-priority` flag must be between %d and %d.",
+			math.MinInt32, math.MaxInt32)
+		c.logError(c.Log, "", err)
+		return err
+	}
+
 	// If this is a single app mode then make sure that we only have
 	// one app or that we have an app target.
 	if baseCfg.AppTargetRequired {
@@ -387,6 +439,94 @@ func (c *baseCommand) flagSet(bit flagSetBit, f func(*flag.Sets)) *flag.Sets {
 				"This is specified to the data source type being used in your configuration. " +
 				"This is used for example to set a specific Git ref to run against.",
 		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "federation-server",
+			Target: &c.flagFederationServer,
+			Usage: "Forward the operation to the downstream server with this name, " +
+				"such as a regional server. This requires -remote.",
+		})
+
+		f.IntVar(&flag.IntVar{
+			Name:    "priority",
+			Target:  &c.flagPriority,
+			Default: 0,
+			Usage: "Priority of the operation in the job queue. Jobs with a higher " +
+				"priority run before queued jobs with a lower priority. This may be negative.",
+		})
+
+		f.StringMapVar(&flag.StringMapVar{
+			Name:   "runner-label",
+			Target: &c.flagRunnerLabels,
+			Usage: "Only run the operation on remote runners with this label. " +
+				"Can be specified multiple times.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "runner-pool",
+			Target: &c.flagRunnerPool,
+			Usage: "Only run the operation on remote runners in this pool. " +
+				"This can't be used with -runner-label.",
+		})
+
+		f.EnumVar(&flag.EnumVar{
+			Name:   "runner-affinity",
+			Target: &c.flagRunnerAffinity,
+			Values: []string{"last-runner", "avoid-app"},
+			Usage: "Prefer the remote runner that last ran this operation for the " +
+				"app (last-runner), or avoid remote runners that are running other " +
+				"operations for the app (avoid-app). Can be specified multiple times.",
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:   "runner-affinity-wait",
+			Target: &c.flagRunnerAffinityWait,
+			Usage: "Time the operation waits for a remote runner it prefers, or for " +
+				"a remote runner in its region or zone, before it can run on any " +
+				"remote runner. Defaults to 30s.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "runner-platform",
+			Target: &c.flagRunnerPlatform,
+			Usage: "Platform of the remote runner that the operation must run on " +
+				"as os/arch, such as \"linux/amd64\", or only the os such as \"linux\".",
+		})
+
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:   "runner-tag",
+			Target: &c.flagRunnerTags,
+			Usage: "Resource tag that the remote runner of the operation must have, " +
+				"such as \"gpu\". Can be specified multiple times.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "runner-region",
+			Target: &c.flagRunnerRegion,
+			Usage: "Region that the operation prefers to run in, such as the region " +
+				"of the deployment target. Remote runners in the region are preferred.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "runner-zone",
+			Target: &c.flagRunnerZone,
+			Usage: "Zone that the operation prefers to run in. Remote runners in " +
+				"the zone are preferred over others in the region.",
+		})
+
+		f.Int64Var(&flag.Int64Var{
+			Name:   "cpu",
+			Target: &c.flagCPU,
+			Usage: "CPU in millicores that the operation requires, where 1000 is " +
+				"one core. Only remote runners with enough free CPU run the operation.",
+		})
+
+		f.Int64Var(&flag.Int64Var{
+			Name:   "memory",
+			Target: &c.flagMemory,
+			Usage: "Memory in megabytes that the operation requires. Only remote " +
+				"runners with enough free memory run the operation.",
+		})
 	}
 
 	if bit&flagSetConnection != 0 {
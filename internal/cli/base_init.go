@@ -8,6 +8,7 @@ import (
 	"github.com/hashicorp/waypoint/internal/clicontext"
 	clientpkg "github.com/hashicorp/waypoint/internal/client"
 	configpkg "github.com/hashicorp/waypoint/internal/config"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
 	"github.com/hashicorp/waypoint/internal/serverclient"
 )
 
@@ -91,11 +92,64 @@ func (c *baseCommand) initClient() (*clientpkg.Project, error) {
 		clientpkg.WithWorkspaceRef(c.refWorkspace),
 		clientpkg.WithLabels(c.flagLabels),
 		clientpkg.WithSourceOverrides(c.flagRemoteSource),
+		clientpkg.WithFederationServer(c.flagFederationServer),
+		clientpkg.WithPriority(int32(c.flagPriority)),
+		clientpkg.WithRunnerLabels(c.flagRunnerLabels),
+		clientpkg.WithRunnerPool(c.flagRunnerPool),
+		clientpkg.WithResources(c.flagCPU, c.flagMemory),
 	}
 	if !c.flagRemote {
 		opts = append(opts, clientpkg.WithLocal())
 	}
 
+	if len(c.flagRunnerAffinity) > 0 {
+		affinity := &pb.Job_Affinity{}
+		for _, v := range c.flagRunnerAffinity {
+			switch v {
+			case "last-runner":
+				affinity.PreferLastRunner = true
+			case "avoid-app":
+				affinity.AvoidAppRunners = true
+			}
+		}
+		if c.flagRunnerAffinityWait > 0 {
+			affinity.Wait = c.flagRunnerAffinityWait.String()
+		}
+
+		opts = append(opts, clientpkg.WithAffinity(affinity))
+	}
+
+	// The local runner runs on this machine so only remote jobs can
+	// require a platform.
+	if c.flagRemote && c.flagRunnerPlatform != "" {
+		opts = append(opts, clientpkg.WithPlatform(c.flagRunnerPlatform))
+	}
+	if c.flagRemote && len(c.flagRunnerTags) > 0 {
+		opts = append(opts, clientpkg.WithRequiredTags(c.flagRunnerTags))
+	}
+	if c.flagRemote && (c.flagRunnerRegion != "" || c.flagRunnerZone != "") {
+		locality := &pb.Job_Locality{
+			Region: c.flagRunnerRegion,
+			Zone:   c.flagRunnerZone,
+		}
+		if c.flagRunnerAffinityWait > 0 {
+			locality.Wait = c.flagRunnerAffinityWait.String()
+		}
+
+		opts = append(opts, clientpkg.WithLocality(locality))
+	}
+
+	// Remote jobs are only run by runners that have the plugins of the
+	// project. The local runner has the plugins that are installed.
+	if c.flagRemote && c.cfg != nil {
+		var plugins []string
+		for _, p := range c.cfg.Plugins() {
+			plugins = append(plugins, p.Name)
+		}
+
+		opts = append(opts, clientpkg.WithPlugins(plugins))
+	}
+
 	if c.ui != nil {
 		opts = append(opts, clientpkg.WithUI(c.ui))
 	}
@@ -109,10 +109,15 @@ func (c *ConfigGetCommand) Run(args []string) int {
 			app = scope.Application.Application
 		}
 
+		value := v.Value
+		if v.Sensitive {
+			value = "<sensitive>"
+		}
+
 		table.Rich([]string{
 			app,
 			v.Name,
-			v.Value,
+			value,
 		}, []string{
 			"",
 			terminal.Green,
@@ -15,6 +15,8 @@ import (
 
 type ConfigSetCommand struct {
 	*baseCommand
+
+	flagSensitive bool
 }
 
 func (c *ConfigSetCommand) Run(args []string) int {
@@ -60,8 +62,9 @@ func (c *ConfigSetCommand) Run(args []string) int {
 		}
 
 		configVar := &pb.ConfigVar{
-			Name:  arg[:idx],
-			Value: arg[idx+1:],
+			Name:      arg[:idx],
+			Value:     arg[idx+1:],
+			Sensitive: c.flagSensitive,
 		}
 
 		if c.flagApp == "" {
@@ -90,7 +93,16 @@ func (c *ConfigSetCommand) Run(args []string) int {
 }
 
 func (c *ConfigSetCommand) Flags() *flag.Sets {
-	return c.flagSet(0, nil)
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.BoolVar(&flag.BoolVar{
+			Name:   "sensitive",
+			Target: &c.flagSensitive,
+			Usage: "Mark the variables as sensitive. Sensitive values are encrypted " +
+				"and are never shown when reading config. This requires the server " +
+				"to have config encryption configured.",
+		})
+	})
 }
 
 func (c *ConfigSetCommand) AutocompleteArgs() complete.Predictor {
@@ -115,5 +127,10 @@ Usage: waypoint config set <name>=<value>
   This will scope the variable to the entire project by default.
   Specify the "-app" flag to set a config variable for a specific app.
 
+  Specify the "-sensitive" flag for secrets such as passwords. These values
+  are encrypted on the server and are only decrypted when delivered to
+  deployments. Sensitive values of at least 4 characters are masked
+  wherever they appear in job output, results, and job details.
+
 `)
 }
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"io"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+type DeploymentLogsCommand struct {
+	*baseCommand
+
+	flagFollow bool
+}
+
+func (c *DeploymentLogsCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	if len(c.args) != 1 {
+		c.ui.Output("A single deployment ID is required.\n\n%s", c.Help(),
+			terminal.WithErrorStyle())
+		return 1
+	}
+
+	stream, err := c.project.Client().GetDeploymentLogStream(c.Ctx, &pb.GetDeploymentLogStreamRequest{
+		DeploymentId: c.args[0],
+		Follow:       c.flagFollow,
+	})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if clierrors.IsCanceled(err) {
+				break
+			}
+
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return 1
+		}
+
+		for _, entry := range resp.Entries {
+			// Job output is marked with "job" and instance logs with the
+			// last six characters of the instance ID, like "waypoint logs".
+			var source, msg string
+			switch {
+			case entry.GetJob() != nil:
+				source = "job"
+				msg = deploymentLogsJobText(entry.GetJob())
+
+			case entry.GetInstance() != nil:
+				source = entry.GetInstance().InstanceId
+				if len(source) > 6 {
+					source = source[len(source)-6:]
+				}
+				msg = entry.GetInstance().GetEntry().GetLine()
+			}
+
+			msg = strings.TrimRight(msg, "\n")
+			if msg == "" {
+				continue
+			}
+
+			var ts string
+			if entry.Timestamp != nil {
+				if t, err := ptypes.Timestamp(entry.Timestamp); err == nil {
+					ts = t.Format("2006-01-02T15:04:05.000Z07:00") + " "
+				}
+			}
+
+			header := headerColor.Sprintf("%s%s: ", ts, source)
+			for _, part := range strings.Split(msg, "\n") {
+				c.ui.Output(header + part)
+			}
+		}
+	}
+
+	return 0
+}
+
+// deploymentLogsJobText returns the text of an event of the output of a job.
+// Events without text such as tables are skipped.
+func deploymentLogsJobText(ev *pb.GetJobStreamResponse_Terminal_Event) string {
+	switch e := ev.Event.(type) {
+	case *pb.GetJobStreamResponse_Terminal_Event_Line_:
+		return e.Line.Msg
+
+	case *pb.GetJobStreamResponse_Terminal_Event_Raw_:
+		return string(e.Raw.Data)
+
+	case *pb.GetJobStreamResponse_Terminal_Event_Status_:
+		return e.Status.Msg
+
+	default:
+		return ""
+	}
+}
+
+func (c *DeploymentLogsCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.BoolVar(&flag.BoolVar{
+			Name:    "follow",
+			Aliases: []string{"f"},
+			Target:  &c.flagFollow,
+			Usage:   "Keep streaming new output and logs as they're written.",
+		})
+	})
+}
+
+func (c *DeploymentLogsCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *DeploymentLogsCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *DeploymentLogsCommand) Synopsis() string {
+	return "Show the job output and application logs of a deployment."
+}
+
+func (c *DeploymentLogsCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint deployment logs [options] DEPLOYMENT_ID
+
+  Show the output of the job that created a deployment merged with the
+  logs of the instances of the deployment in the order they were written.
+  This is useful to debug a failed rollout in one place.
+
+  Job output is marked with "job" and instance logs with the last six
+  characters of the instance ID. Only the job output and logs that the
+  server still keeps are shown.
+
+` + c.Flags().Help())
+}
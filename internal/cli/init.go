@@ -304,9 +304,11 @@ func (c *InitCommand) validateProject() bool {
 
 		resp, err := client.UpsertProject(c.Ctx, &pb.UpsertProjectRequest{
 			Project: &pb.Project{
-				Name:          ref.Project,
-				RemoteEnabled: c.cfg.Runner.Enabled,
-				DataSource:    ds,
+				Name:             ref.Project,
+				RemoteEnabled:    c.cfg.Runner.Enabled,
+				DataSource:       ds,
+				TerraformOutputs: terraformOutputsProto(c.cfg.TerraformOutputs),
+				Notifications:    notificationsProto(c.cfg.Notifications),
 			},
 		})
 		if err != nil {
@@ -345,6 +347,67 @@ func (c *InitCommand) validateProject() bool {
 	return true
 }
 
+// terraformOutputsProto converts the terraform_outputs configuration to
+// the project protobuf representation.
+func terraformOutputsProto(cfgs []*configpkg.TerraformOutputs) []*pb.Project_TerraformOutputs {
+	var result []*pb.Project_TerraformOutputs
+	for _, cfg := range cfgs {
+		tf := &pb.Project_TerraformOutputs{
+			Name:   cfg.Name,
+			Prefix: cfg.Prefix,
+		}
+
+		if cfg.StateURL != "" {
+			tf.Source = &pb.Project_TerraformOutputs_State_{
+				State: &pb.Project_TerraformOutputs_State{
+					Url: cfg.StateURL,
+				},
+			}
+		} else {
+			tf.Source = &pb.Project_TerraformOutputs_Cloud_{
+				Cloud: &pb.Project_TerraformOutputs_Cloud{
+					Address:      cfg.Address,
+					Organization: cfg.Organization,
+					Workspace:    cfg.Workspace,
+				},
+			}
+		}
+
+		result = append(result, tf)
+	}
+
+	return result
+}
+
+// notificationsProto converts the notification configuration to the
+// project protobuf representation.
+func notificationsProto(cfgs []*configpkg.Notification) []*pb.Project_Notification {
+	var result []*pb.Project_Notification
+	for _, cfg := range cfgs {
+		n := &pb.Project_Notification{
+			Name:       cfg.Name,
+			Provider:   pb.Project_Notification_SLACK,
+			WebhookUrl: cfg.WebhookURL,
+			Channel:    cfg.Channel,
+			Template:   cfg.Template,
+			Throttle:   cfg.Throttle,
+		}
+		if cfg.Provider == "teams" {
+			n.Provider = pb.Project_Notification_TEAMS
+		}
+
+		// Events are validated when the config is loaded.
+		for _, e := range cfg.Events {
+			n.Events = append(n.Events, pb.Project_Notification_Event(
+				pb.Project_Notification_Event_value[strings.ToUpper(e)]))
+		}
+
+		result = append(result, n)
+	}
+
+	return result
+}
+
 func (c *InitCommand) validatePlugins() bool {
 	sg := c.ui.StepGroup()
 	defer sg.Wait()
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+type JobApproveCommand struct {
+	*baseCommand
+}
+
+func (c *JobApproveCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	if len(c.args) == 0 {
+		c.ui.Output("Job ID required for approval", terminal.WithErrorStyle())
+		return 1
+	}
+
+	job, err := c.project.Client().ApproveJob(c.Ctx, &pb.ApproveJobRequest{
+		JobId: c.args[0],
+	})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	required := job.ApprovalRequired.GetApprovals()
+	if uint32(len(job.Approvals)) < required {
+		c.ui.Output("Job %s approved. %d of %d required approvals received.",
+			job.Id, len(job.Approvals), required, terminal.WithSuccessStyle())
+		return 0
+	}
+
+	c.ui.Output("Job %s approved. It has all required approvals and will run.",
+		job.Id, terminal.WithSuccessStyle())
+	return 0
+}
+
+func (c *JobApproveCommand) Flags() *flag.Sets {
+	return c.flagSet(0, nil)
+}
+
+func (c *JobApproveCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *JobApproveCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *JobApproveCommand) Synopsis() string {
+	return "Approve a job for a protected workspace."
+}
+
+func (c *JobApproveCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint job approve [options] JOB-ID
+
+  Approve a queued deploy or release job for a protected workspace.
+
+  The job runs once it has the number of approvals required by the
+  workspace policy. Each user can approve a job once, and the user that
+  queued the job can't approve it. Approvals are recorded on the job and
+  in the server audit log.
+
+` + c.Flags().Help())
+}
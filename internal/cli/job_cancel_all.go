@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+type JobCancelAllCommand struct {
+	*baseCommand
+}
+
+func (c *JobCancelAllCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+	); err != nil {
+		return 1
+	}
+
+	// Cancel the jobs of the project unless an app is targeted
+	ref := &pb.Ref_Application{
+		Project:     c.project.Ref().Project,
+		Application: c.flagApp,
+	}
+
+	resp, err := c.project.Client().CancelJobs(c.Ctx, &pb.CancelJobsRequest{
+		Application: ref,
+		Workspace:   c.refWorkspace,
+	})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	if len(resp.JobIds) == 0 {
+		c.ui.Output("No queued or running jobs to cancel.")
+		return 0
+	}
+
+	for _, id := range resp.JobIds {
+		c.ui.Output(id)
+	}
+	c.ui.Output("Cancelled %d job(s). Running jobs stop once their runners cancel them.",
+		len(resp.JobIds), terminal.WithSuccessStyle())
+	return 0
+}
+
+func (c *JobCancelAllCommand) Flags() *flag.Sets {
+	return c.flagSet(0, nil)
+}
+
+func (c *JobCancelAllCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *JobCancelAllCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *JobCancelAllCommand) Synopsis() string {
+	return "Cancel all queued and running jobs for a project or app."
+}
+
+func (c *JobCancelAllCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint job cancel-all [options]
+
+  Cancel all queued and running jobs for the project in the workspace.
+
+  If -app is set, only the jobs of that app are cancelled. Running jobs
+  stop once their runners cancel them.
+
+` + c.Flags().Help())
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"io"
+	"os"
+
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+type JobExportLogsCommand struct {
+	*baseCommand
+}
+
+func (c *JobExportLogsCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	if len(c.args) < 2 {
+		c.ui.Output("Output path and at least one job ID required.\n\n%s", c.Help(),
+			terminal.WithErrorStyle())
+		return 1
+	}
+	path := c.args[0]
+
+	stream, err := c.project.Client().ExportJobLogs(c.Ctx, &pb.ExportJobLogsRequest{
+		JobIds: c.args[1:],
+	})
+	if err == nil {
+		// The first message is always Open. Waiting for it before creating
+		// the file means errors such as a job not existing don't leave an
+		// empty file behind.
+		_, err = stream.Recv()
+	}
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+	defer f.Close()
+
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			if chunk, ok := resp.Event.(*pb.ExportJobLogsResponse_Chunk); ok {
+				_, err = f.Write(chunk.Chunk)
+			}
+		}
+		if err != nil {
+			f.Close()
+			os.Remove(path)
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return 1
+		}
+	}
+
+	if err := f.Close(); err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.ui.Output("The output of %d job(s) was exported to %s", len(c.args)-1, path,
+		terminal.WithSuccessStyle())
+	return 0
+}
+
+func (c *JobExportLogsCommand) Flags() *flag.Sets {
+	return c.flagSet(0, nil)
+}
+
+func (c *JobExportLogsCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *JobExportLogsCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *JobExportLogsCommand) Synopsis() string {
+	return "Download the output of jobs as a tar.gz archive."
+}
+
+func (c *JobExportLogsCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint job export-logs [options] PATH JOB_ID...
+
+  Download the output of one or more jobs to a tar.gz archive at PATH,
+  such as to attach it to an incident ticket.
+
+  The archive has a directory for each job with its output as a text log,
+  output.log, and its terminal events as JSON lines, events.jsonl. Only
+  the output that the server still keeps is included.
+
+` + c.Flags().Help())
+}
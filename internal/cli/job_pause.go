@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+type JobPauseCommand struct {
+	*baseCommand
+}
+
+func (c *JobPauseCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+	); err != nil {
+		return 1
+	}
+
+	_, err := c.project.Client().PauseJobQueue(c.Ctx, &pb.JobQueuePause{
+		Project:     c.project.Ref().Project,
+		Application: c.flagApp,
+		Workspace:   c.refWorkspace.Workspace,
+	})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.ui.Output("Job queue paused. Queued jobs won't run until it is resumed "+
+		"with `waypoint job resume`.", terminal.WithSuccessStyle())
+	return 0
+}
+
+func (c *JobPauseCommand) Flags() *flag.Sets {
+	return c.flagSet(0, nil)
+}
+
+func (c *JobPauseCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *JobPauseCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *JobPauseCommand) Synopsis() string {
+	return "Pause running queued jobs for a project or app."
+}
+
+func (c *JobPauseCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint job pause [options]
+
+  Pause running queued jobs for the project in the workspace.
+
+  If -app is set, only the jobs of that app are paused. Queued jobs stay
+  queued and jobs that are already running aren't affected. Resume the
+  queue with "waypoint job resume".
+
+` + c.Flags().Help())
+}
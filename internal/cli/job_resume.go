@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+type JobResumeCommand struct {
+	*baseCommand
+}
+
+func (c *JobResumeCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+	); err != nil {
+		return 1
+	}
+
+	_, err := c.project.Client().ResumeJobQueue(c.Ctx, &pb.JobQueuePause{
+		Project:     c.project.Ref().Project,
+		Application: c.flagApp,
+		Workspace:   c.refWorkspace.Workspace,
+	})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.ui.Output("Job queue resumed.", terminal.WithSuccessStyle())
+	return 0
+}
+
+func (c *JobResumeCommand) Flags() *flag.Sets {
+	return c.flagSet(0, nil)
+}
+
+func (c *JobResumeCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *JobResumeCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *JobResumeCommand) Synopsis() string {
+	return "Resume running queued jobs paused with job pause."
+}
+
+func (c *JobResumeCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint job resume [options]
+
+  Resume running queued jobs for the project in the workspace that were
+  paused with "waypoint job pause".
+
+  The -app flag must match the one the queue was paused with.
+
+` + c.Flags().Help())
+}
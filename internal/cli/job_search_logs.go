@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+type JobSearchLogsCommand struct {
+	*baseCommand
+
+	flagRegex      bool
+	flagIgnoreCase bool
+	flagSince      time.Duration
+	flagLevel      string
+	flagLimit      int
+}
+
+func (c *JobSearchLogsCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+	); err != nil {
+		return 1
+	}
+
+	if len(c.args) != 1 {
+		c.ui.Output("A single query is required.\n\n"+c.Help(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	req := &pb.SearchJobLogsRequest{
+		Query:      c.args[0],
+		Regex:      c.flagRegex,
+		IgnoreCase: c.flagIgnoreCase,
+		Limit:      uint32(c.flagLimit),
+
+		// Search the jobs of the project unless an app is targeted
+		Application: &pb.Ref_Application{
+			Project:     c.project.Ref().Project,
+			Application: c.flagApp,
+		},
+		Workspace: c.refWorkspace,
+	}
+
+	if c.flagLevel != "" {
+		level, ok := pb.GetJobStreamResponse_Terminal_Event_Level_value[strings.ToUpper(c.flagLevel)]
+		if !ok || level == 0 {
+			c.ui.Output("Invalid level %q. Valid levels are trace, debug, info, warn, and error.",
+				c.flagLevel, terminal.WithErrorStyle())
+			return 1
+		}
+
+		req.Level = pb.GetJobStreamResponse_Terminal_Event_Level(level)
+	}
+
+	if c.flagSince > 0 {
+		var err error
+		req.StartTime, err = ptypes.TimestampProto(time.Now().Add(-c.flagSince))
+		if err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return 1
+		}
+	}
+
+	resp, err := c.project.Client().SearchJobLogs(c.Ctx, req)
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	if len(resp.Matches) == 0 {
+		c.ui.Output("No matching job output.")
+		return 0
+	}
+
+	table := terminal.NewTable("Job ID", "App", "Workspace", "Time", "Output")
+	for _, m := range resp.Matches {
+		// Prefer when the server received the event since the clock of
+		// the runner may be skewed.
+		var logged string
+		ts := m.Event.GetReceiveTime()
+		if ts == nil {
+			ts = m.Event.GetTimestamp()
+		}
+		if t, err := ptypes.Timestamp(ts); err == nil {
+			logged = humanize.Time(t)
+		}
+
+		table.Rich([]string{
+			m.JobId,
+			m.Application.GetApplication(),
+			m.Workspace.GetWorkspace(),
+			logged,
+			strings.TrimSpace(m.Message),
+		}, nil)
+	}
+
+	c.ui.Table(table)
+	if resp.Truncated {
+		c.ui.Output("Only the first %d matches are shown. Narrow the search or raise -limit.",
+			len(resp.Matches), terminal.WithWarningStyle())
+	}
+
+	return 0
+}
+
+func (c *JobSearchLogsCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.BoolVar(&flag.BoolVar{
+			Name:   "regex",
+			Target: &c.flagRegex,
+			Usage:  "Treat the query as a regular expression.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "ignore-case",
+			Target: &c.flagIgnoreCase,
+			Usage:  "Match the query case insensitively.",
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:   "since",
+			Target: &c.flagSince,
+			Usage:  "Only search output logged within this long, such as 24h.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "level",
+			Target: &c.flagLevel,
+			Usage: "Only search output at this level or more severe: trace, " +
+				"debug, info, warn, or error.",
+		})
+
+		f.IntVar(&flag.IntVar{
+			Name:    "limit",
+			Target:  &c.flagLimit,
+			Usage:   "Maximum number of matches to show, up to 1000.",
+			Default: 100,
+		})
+	})
+}
+
+func (c *JobSearchLogsCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *JobSearchLogsCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *JobSearchLogsCommand) Synopsis() string {
+	return "Search the output of the jobs of a project or app."
+}
+
+func (c *JobSearchLogsCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint job search-logs [options] QUERY
+
+  Search the output of the jobs of the project in the workspace for lines
+  that contain QUERY, such as an error message, to find which operation
+  logged it.
+
+  If -app is set, only the jobs of that app are searched. Only the output
+  that the server still keeps is searched.
+
+` + c.Flags().Help())
+}
@@ -232,6 +232,12 @@ func Commands(
 			}, nil
 		},
 
+		"deployment logs": func() (cli.Command, error) {
+			return &DeploymentLogsCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+
 		"release": func() (cli.Command, error) {
 			return &ReleaseCreateCommand{
 				baseCommand: baseCommand,
@@ -259,11 +265,31 @@ func Commands(
 				baseCommand: baseCommand,
 			}, nil
 		},
+		"server compact": func() (cli.Command, error) {
+			return &ServerCompactCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"server fsck": func() (cli.Command, error) {
+			return &ServerFsckCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
 		"server config-set": func() (cli.Command, error) {
 			return &ServerConfigSetCommand{
 				baseCommand: baseCommand,
 			}, nil
 		},
+		"server load-test": func() (cli.Command, error) {
+			return &ServerLoadTestCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"server telemetry": func() (cli.Command, error) {
+			return &ServerTelemetryCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
 
 		"plugin": func() (cli.Command, error) {
 			return &PluginCommand{
@@ -321,6 +347,104 @@ func Commands(
 			}, nil
 		},
 
+		"api-key": func() (cli.Command, error) {
+			return &helpCommand{
+				SynopsisText: helpText["api-key"][0],
+				HelpText:     helpText["api-key"][1],
+			}, nil
+		},
+		"api-key create": func() (cli.Command, error) {
+			return &APIKeyCreateCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"api-key list": func() (cli.Command, error) {
+			return &APIKeyListCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"api-key rotate": func() (cli.Command, error) {
+			return &APIKeyRotateCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"api-key delete": func() (cli.Command, error) {
+			return &APIKeyDeleteCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+
+		"job": func() (cli.Command, error) {
+			return &helpCommand{
+				SynopsisText: helpText["job"][0],
+				HelpText:     helpText["job"][1],
+			}, nil
+		},
+		"job approve": func() (cli.Command, error) {
+			return &JobApproveCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"job cancel-all": func() (cli.Command, error) {
+			return &JobCancelAllCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"job export-logs": func() (cli.Command, error) {
+			return &JobExportLogsCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"job pause": func() (cli.Command, error) {
+			return &JobPauseCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"job resume": func() (cli.Command, error) {
+			return &JobResumeCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"job search-logs": func() (cli.Command, error) {
+			return &JobSearchLogsCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+
+		"workspace": func() (cli.Command, error) {
+			return &helpCommand{
+				SynopsisText: helpText["workspace"][0],
+				HelpText:     helpText["workspace"][1],
+			}, nil
+		},
+		"workspace policy": func() (cli.Command, error) {
+			return &WorkspacePolicyCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"workspace protect": func() (cli.Command, error) {
+			return &WorkspaceProtectCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+
+		"project": func() (cli.Command, error) {
+			return &helpCommand{
+				SynopsisText: helpText["project"][0],
+				HelpText:     helpText["project"][1],
+			}, nil
+		},
+		"project export": func() (cli.Command, error) {
+			return &ProjectExportCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"project import": func() (cli.Command, error) {
+			return &ProjectImportCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+
 		"runner": func() (cli.Command, error) {
 			return &helpCommand{
 				SynopsisText: helpText["runner"][0],
@@ -332,6 +456,83 @@ func Commands(
 				baseCommand: baseCommand,
 			}, nil
 		},
+		"runner approve": func() (cli.Command, error) {
+			return &RunnerApproveCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"runner drain": func() (cli.Command, error) {
+			return &RunnerDrainCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"runner install": func() (cli.Command, error) {
+			return &RunnerInstallCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"runner list": func() (cli.Command, error) {
+			return &RunnerListCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"runner token": func() (cli.Command, error) {
+			return &helpCommand{
+				SynopsisText: helpText["runner token"][0],
+				HelpText:     helpText["runner token"][1],
+			}, nil
+		},
+		"runner token create": func() (cli.Command, error) {
+			return &RunnerTokenCreateCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"runner token list": func() (cli.Command, error) {
+			return &RunnerTokenListCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"runner token rotate": func() (cli.Command, error) {
+			return &RunnerTokenRotateCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"runner token revoke": func() (cli.Command, error) {
+			return &RunnerTokenRevokeCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"runner uninstall": func() (cli.Command, error) {
+			return &RunnerUninstallCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"runner undrain": func() (cli.Command, error) {
+			return &RunnerUndrainCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"runner upgrade": func() (cli.Command, error) {
+			return &RunnerUpgradeCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"runner version": func() (cli.Command, error) {
+			return &helpCommand{
+				SynopsisText: helpText["runner version"][0],
+				HelpText:     helpText["runner version"][1],
+			}, nil
+		},
+		"runner version set": func() (cli.Command, error) {
+			return &RunnerVersionSetCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"runner version status": func() (cli.Command, error) {
+			return &RunnerVersionStatusCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
 
 		"context": func() (cli.Command, error) {
 			return &helpCommand{
@@ -582,6 +783,17 @@ func helpCommandsSection(
 }
 
 var helpText = map[string][2]string{
+	"api-key": {
+		"Manage API keys for automation",
+		`
+Manage API keys for automation.
+
+API keys are long-lived tokens that belong to a user and are meant for
+automation such as CI pipelines. The server records when each key was last
+used and how many calls it made so stale keys can be found and deleted.
+`,
+	},
+
 	"artifact": {
 		"Artifact and build management",
 		`
@@ -642,6 +854,29 @@ For more information see: https://waypointproject.io/docs/url
 `,
 	},
 
+	"job": {
+		"Job management",
+		`
+Job management.
+
+Every operation such as a build or deploy is run as a job by the server.
+Deploys and releases to protected workspaces must be approved before they
+run. All the queued and running jobs of a project or app can be cancelled
+at once, and queued jobs can be paused while investigating a problem. The
+output of past jobs can be searched to find which operation logged an error.
+`,
+	},
+
+	"project": {
+		"Project management",
+		`
+Project management.
+
+Projects can be exported with their history and imported into another
+server, for example to move a project from a shared server to a dedicated one.
+`,
+	},
+
 	"runner": {
 		"Runner management",
 		`
@@ -653,6 +888,28 @@ entire section.
 `,
 	},
 
+	"runner token": {
+		"Manage the tokens of individual runners",
+		`
+Manage the tokens of individual runners.
+
+A runner token can only register the runner it was created for. Each
+runner having its own token means a token can be rotated or revoked
+without affecting other runners.
+`,
+	},
+
+	"runner version": {
+		"Publish the version that runners should run",
+		`
+Publish the version that runners should run and follow its rollout.
+
+Runners started with an update command update themselves to the published
+version once they finish their operations, so a fleet of runners can be
+kept on the same version without upgrading each one by hand.
+`,
+	},
+
 	"server": {
 		"Server management",
 		`
@@ -673,6 +930,16 @@ Authenticate and invite collaborators.
 
 Tokens are the primary form of authentication to Waypoint. Everyone who
 accesses a Waypoint server requires a token.
+`,
+	},
+
+	"workspace": {
+		"Workspace management",
+		`
+Workspace management.
+
+Workspaces such as "production" can be protected so that deploys and
+releases require approval from one or more users before they run.
 `,
 	},
 }
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"io"
+	"os"
+
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+type ProjectExportCommand struct {
+	*baseCommand
+
+	flagSensitive bool
+}
+
+func (c *ProjectExportCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	if len(c.args) != 2 {
+		c.ui.Output("Project name and output path required.\n\n%s", c.Help(),
+			terminal.WithErrorStyle())
+		return 1
+	}
+
+	stream, err := c.project.Client().ExportProject(c.Ctx, &pb.ExportProjectRequest{
+		Project:                &pb.Ref_Project{Project: c.args[0]},
+		IncludeSensitiveConfig: c.flagSensitive,
+	})
+	if err == nil {
+		// The first message is always Open. Waiting for it before creating
+		// the file means errors such as the project not existing don't
+		// leave an empty file behind.
+		_, err = stream.Recv()
+	}
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	f, err := os.Create(c.args[1])
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+	defer f.Close()
+
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			if chunk, ok := resp.Event.(*pb.ExportProjectResponse_Chunk); ok {
+				_, err = f.Write(chunk.Chunk)
+			}
+		}
+		if err != nil {
+			f.Close()
+			os.Remove(c.args[1])
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return 1
+		}
+	}
+
+	if err := f.Close(); err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.ui.Output("Project %q exported to %s", c.args[0], c.args[1],
+		terminal.WithSuccessStyle())
+	if c.flagSensitive {
+		c.ui.Output("The export contains the values of sensitive config "+
+			"variables in plaintext.", terminal.WithWarningStyle())
+	}
+
+	return 0
+}
+
+func (c *ProjectExportCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.BoolVar(&flag.BoolVar{
+			Name:   "include-sensitive",
+			Target: &c.flagSensitive,
+			Usage: "Export sensitive config variables with their values in " +
+				"plaintext. Otherwise they aren't exported.",
+		})
+	})
+}
+
+func (c *ProjectExportCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ProjectExportCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *ProjectExportCommand) Synopsis() string {
+	return "Export a project and its history to a file."
+}
+
+func (c *ProjectExportCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint project export [options] PROJECT PATH
+
+  Export a project and its history to a file.
+
+  The export includes the project, its config, its builds, pushed
+  artifacts, deployments, and releases, and the metadata of its completed
+  jobs. Import it into another server with "waypoint project import".
+
+` + c.Flags().Help())
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"io"
+	"os"
+
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+type ProjectImportCommand struct {
+	*baseCommand
+
+	flagName string
+}
+
+func (c *ProjectImportCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	if len(c.args) != 1 {
+		c.ui.Output("Path to a project export required.\n\n%s", c.Help(),
+			terminal.WithErrorStyle())
+		return 1
+	}
+
+	f, err := os.Open(c.args[0])
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+	defer f.Close()
+
+	resp, err := c.importProject(f)
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.ui.Output("Project %q imported with %d records", resp.Project.Project, resp.Records,
+		terminal.WithSuccessStyle())
+	return 0
+}
+
+func (c *ProjectImportCommand) importProject(r io.Reader) (*pb.ImportProjectResponse, error) {
+	stream, err := c.project.Client().ImportProject(c.Ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := stream.Send(&pb.ImportProjectRequest{
+		Event: &pb.ImportProjectRequest_Open_{
+			Open: &pb.ImportProjectRequest_Open{Name: c.flagName},
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 1024*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if err := stream.Send(&pb.ImportProjectRequest{
+				Event: &pb.ImportProjectRequest_Chunk{Chunk: buf[:n]},
+			}); err != nil {
+				return nil, err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return stream.CloseAndRecv()
+}
+
+func (c *ProjectImportCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.StringVar(&flag.StringVar{
+			Name:   "name",
+			Target: &c.flagName,
+			Usage:  "Import the project with this name instead of its exported name.",
+		})
+	})
+}
+
+func (c *ProjectImportCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*")
+}
+
+func (c *ProjectImportCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *ProjectImportCommand) Synopsis() string {
+	return "Import a project exported from another server."
+}
+
+func (c *ProjectImportCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint project import [options] PATH
+
+  Import a project exported with "waypoint project export".
+
+  The project must not already exist on this server. Builds, pushed
+  artifacts, deployments, releases, and jobs are given new IDs but keep
+  their sequence numbers.
+
+` + c.Flags().Help())
+}
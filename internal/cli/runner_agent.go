@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
 	"io/ioutil"
 
 	"github.com/hashicorp/go-hclog"
@@ -9,12 +11,33 @@ import (
 	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
 	"github.com/hashicorp/waypoint/internal/pkg/flag"
 	runnerpkg "github.com/hashicorp/waypoint/internal/runner"
+	"github.com/hashicorp/waypoint/internal/runnerauth"
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
 	"github.com/hashicorp/waypoint/internal/serverclient"
 )
 
 type RunnerAgentCommand struct {
 	*baseCommand
+
+	flagAuthMethod   string
+	flagAuthAudience string
+	flagId           string
+	flagByIdOnly     bool
+	flagLabels       map[string]string
+	flagPool         string
+	flagProfile      string
+	flagConfigDir    string
+	flagCPU          int64
+	flagMemory       int64
+	flagMaxJobs      int
+	flagOperations   []string
+	flagTags         []string
+	flagRegion       string
+	flagZone         string
+	flagCost         float64
+	flagOneShot      bool
+	flagUpdate       string
+	flagSigningKey   string
 }
 
 func (c *RunnerAgentCommand) Run(args []string) int {
@@ -33,10 +56,41 @@ func (c *RunnerAgentCommand) Run(args []string) int {
 
 	// Connect to the server
 	log.Info("sourcing credentials and connecting to the Waypoint server")
-	conn, err := serverclient.Connect(ctx,
+	connectOpts := []serverclient.ConnectOption{
 		serverclient.FromContext(c.contextStorage, ""),
 		serverclient.FromEnv(),
-	)
+	}
+
+	// If we're using a cloud identity, we login with a separate connection
+	// that doesn't require auth and use the runner token for everything else.
+	if provider := c.authProvider(); provider != nil {
+		log.Info("logging in with cloud identity", "method", c.flagAuthMethod)
+		loginConn, err := serverclient.Connect(ctx, connectOpts...)
+		if err != nil {
+			c.ui.Output(
+				"Error connecting to the Waypoint server: %s", err.Error(),
+				terminal.WithErrorStyle(),
+			)
+			return 1
+		}
+		defer loginConn.Close()
+
+		creds := &runnerauth.Credentials{
+			Client:   pb.NewWaypointClient(loginConn),
+			Provider: provider,
+		}
+		if _, err := creds.Token(ctx); err != nil {
+			c.ui.Output(
+				"Error logging in to the Waypoint server: %s", err.Error(),
+				terminal.WithErrorStyle(),
+			)
+			return 1
+		}
+
+		connectOpts = append(connectOpts, serverclient.Credentials(creds))
+	}
+
+	conn, err := serverclient.Connect(ctx, connectOpts...)
 	if err != nil {
 		c.ui.Output(
 			"Error connecting to the Waypoint server: %s", err.Error(),
@@ -82,10 +136,67 @@ func (c *RunnerAgentCommand) Run(args []string) int {
 
 	// Create our runner
 	log.Info("initializing the runner")
-	runner, err := runnerpkg.New(
+	runnerOpts := []runnerpkg.Option{
 		runnerpkg.WithClient(client),
 		runnerpkg.WithLogger(log.Named("runner")),
-	)
+	}
+	if c.flagId != "" {
+		runnerOpts = append(runnerOpts, runnerpkg.WithId(c.flagId))
+	}
+	if c.flagByIdOnly {
+		runnerOpts = append(runnerOpts, runnerpkg.ByIdOnly())
+	}
+	if len(c.flagLabels) > 0 {
+		runnerOpts = append(runnerOpts, runnerpkg.WithLabels(c.flagLabels))
+	}
+	if c.flagPool != "" {
+		runnerOpts = append(runnerOpts, runnerpkg.WithPool(c.flagPool))
+	}
+	if c.flagProfile != "" {
+		runnerOpts = append(runnerOpts, runnerpkg.WithProfile(c.flagProfile))
+	}
+	if c.flagConfigDir != "" {
+		runnerOpts = append(runnerOpts, runnerpkg.WithConfigDir(c.flagConfigDir))
+	}
+	if c.flagCPU > 0 || c.flagMemory > 0 {
+		runnerOpts = append(runnerOpts, runnerpkg.WithCapacity(c.flagCPU, c.flagMemory))
+	}
+
+	if len(c.flagOperations) > 0 {
+		runnerOpts = append(runnerOpts, runnerpkg.WithOperations(c.flagOperations))
+	}
+	if len(c.flagTags) > 0 {
+		runnerOpts = append(runnerOpts, runnerpkg.WithResourceTags(c.flagTags))
+	}
+	if c.flagRegion != "" || c.flagZone != "" {
+		runnerOpts = append(runnerOpts, runnerpkg.WithLocality(c.flagRegion, c.flagZone))
+	}
+	if c.flagCost > 0 {
+		runnerOpts = append(runnerOpts, runnerpkg.WithCost(c.flagCost))
+	}
+	if c.flagUpdate != "" {
+		runnerOpts = append(runnerOpts, runnerpkg.WithUpdateCommand(c.flagUpdate))
+	}
+	if c.flagSigningKey != "" {
+		key, err := base64.StdEncoding.DecodeString(c.flagSigningKey)
+		if err != nil {
+			c.ui.Output(
+				"Error decoding the job signing key: %s", err.Error(),
+				terminal.WithErrorStyle(),
+			)
+			return 1
+		}
+
+		runnerOpts = append(runnerOpts, runnerpkg.WithJobSigningKey(ed25519.PublicKey(key)))
+	}
+
+	// A one-shot runner runs a single job so it only accepts one at once.
+	maxJobs := c.flagMaxJobs
+	if maxJobs < 1 || c.flagOneShot {
+		maxJobs = 1
+	}
+	runnerOpts = append(runnerOpts, runnerpkg.WithMaxJobs(uint32(maxJobs)))
+	runner, err := runnerpkg.New(runnerOpts...)
 	if err != nil {
 		c.ui.Output(
 			"Error initializing the runner: %s", err.Error(),
@@ -101,34 +212,221 @@ func (c *RunnerAgentCommand) Run(args []string) int {
 		return 1
 	}
 
-	// Accept jobs in goroutine so that we can interrupt it.
-	go func() {
-		for {
-			if err := runner.Accept(ctx); err != nil {
+	// Accept jobs in goroutines so that we can interrupt them. We accept
+	// up to the maximum number of jobs at once. A one-shot runner exits
+	// after its first job.
+	doneCh := make(chan error, 1)
+	for i := 0; i < maxJobs; i++ {
+		go func() {
+			for {
+				err := runner.Accept(ctx)
 				if err == runnerpkg.ErrClosed {
 					return
 				}
+				if err != nil {
+					log.Error("error running job", "err", err)
+				}
 
-				log.Error("error running job", "err", err)
+				if c.flagOneShot {
+					doneCh <- err
+					return
+				}
 			}
-		}
-	}()
+		}()
+	}
 
 	// Wait for end
-	<-ctx.Done()
+	exitCode := 0
+	select {
+	case <-ctx.Done():
+		log.Info("quit request received, gracefully stopping runner")
+
+	case err := <-doneCh:
+		log.Info("job complete, stopping one-shot runner")
+		if err != nil {
+			exitCode = 1
+		}
+
+	case <-runner.Updated():
+		log.Info("runner updated, stopping so that it restarts with the new version")
+	}
 
 	// Gracefully close
-	log.Info("quit request received, gracefully stopping runner")
 	if err := runner.Close(); err != nil {
 		log.Error("error stopping runner", "err", err)
 		return 1
 	}
 
-	return 0
+	return exitCode
+}
+
+// authProvider returns the cloud identity provider for the auth method,
+// or nil if the runner uses a static token.
+func (c *RunnerAgentCommand) authProvider() runnerauth.Provider {
+	switch c.flagAuthMethod {
+	case "aws-iam":
+		return &runnerauth.AWSProvider{Audience: c.flagAuthAudience}
+
+	case "gcp":
+		return &runnerauth.GCPProvider{Audience: c.flagAuthAudience}
+
+	default:
+		return nil
+	}
 }
 
 func (c *RunnerAgentCommand) Flags() *flag.Sets {
-	return c.flagSet(0, nil)
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.EnumSingleVar(&flag.EnumSingleVar{
+			Name:    "auth-method",
+			Target:  &c.flagAuthMethod,
+			Values:  []string{"token", "aws-iam", "gcp"},
+			Default: "token",
+			Usage: "How to authenticate with the server. \"token\" uses the " +
+				"WAYPOINT_SERVER_TOKEN token. \"aws-iam\" and \"gcp\" login with " +
+				"the cloud identity of the machine the runner is on.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "auth-audience",
+			Target:  &c.flagAuthAudience,
+			Default: runnerauth.DefaultAudience,
+			Usage: "Audience to request cloud identity documents for. This " +
+				"must match the audience configured on the server.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "id",
+			Target: &c.flagId,
+			Usage: "ID of the runner. If this isn't set, a random ID is " +
+				"generated. This is used by runners started by the server " +
+				"for a specific job.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "by-id-only",
+			Target: &c.flagByIdOnly,
+			Usage:  "Only accept jobs that target this runner by ID.",
+		})
+
+		f.StringMapVar(&flag.StringMapVar{
+			Name:   "label",
+			Target: &c.flagLabels,
+			Usage: "Labels for the runner, such as \"arch=arm64\". Operations " +
+				"can target runners with specific labels. Can be specified multiple times.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "pool",
+			Target: &c.flagPool,
+			Usage: "Name of the runner pool to join. Operations can target the " +
+				"runners of a pool. A runner that logs in with a cloud identity " +
+				"mapped to a pool can only join that pool.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "profile",
+			Target: &c.flagProfile,
+			Usage: "Name of the runner profile the runner was launched with. " +
+				"Only jobs that target the profile are assigned to the runner.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "config-dir",
+			Target: &c.flagConfigDir,
+			Usage: "Directory that the runner config files from the server are " +
+				"written in, such as cloud credentials or docker auth. If this " +
+				"isn't set, a temporary directory is used.",
+		})
+
+		f.Int64Var(&flag.Int64Var{
+			Name:   "cpu",
+			Target: &c.flagCPU,
+			Usage: "CPU in millicores that the runner has for operations, where 1000 " +
+				"is one core. Operations that require more aren't run on this runner.",
+		})
+
+		f.Int64Var(&flag.Int64Var{
+			Name:   "memory",
+			Target: &c.flagMemory,
+			Usage: "Memory in megabytes that the runner has for operations. " +
+				"Operations that require more aren't run on this runner.",
+		})
+
+		f.IntVar(&flag.IntVar{
+			Name:   "max-jobs",
+			Target: &c.flagMaxJobs,
+			Usage: "Most operations the runner runs at once. The server doesn't " +
+				"assign the runner more operations while it is running this many.",
+			Default: 1,
+		})
+
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:   "operation",
+			Target: &c.flagOperations,
+			Usage: "Operation type the runner runs, such as \"build\" or \"deploy\". " +
+				"The runner is only assigned operations of these types. Can be specified " +
+				"multiple times. If not set, the runner runs every type.",
+		})
+
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:   "tag",
+			Target: &c.flagTags,
+			Usage: "Resource tag describing specialized hardware the runner has, " +
+				"such as \"gpu\" or \"kvm\". Operations that require tags only " +
+				"run on runners that have all of them. Can be specified multiple times.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "region",
+			Target: &c.flagRegion,
+			Usage: "Region the runner runs in, such as \"us-east-1\". Operations " +
+				"that prefer the region run on runners in it first.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "zone",
+			Target: &c.flagZone,
+			Usage: "Zone the runner runs in, such as \"us-east-1a\". Operations " +
+				"that prefer the zone run on runners in it first.",
+		})
+
+		f.Float64Var(&flag.Float64Var{
+			Name:   "cost",
+			Target: &c.flagCost,
+			Usage: "Relative cost of running operations on the runner, such as 1 " +
+				"for spot capacity and 3 for on-demand capacity. Servers that prefer " +
+				"cheap runners assign operations to cheaper runners first.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "update-command",
+			Target: &c.flagUpdate,
+			Usage: "Command that updates the runner when the server publishes a " +
+				"new runner version, such as a script that installs the version " +
+				"in WAYPOINT_RUNNER_VERSION. The runner finishes its operations, " +
+				"runs the command with the system shell, and exits so that it " +
+				"can be restarted with the new version.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "job-signing-key",
+			Target: &c.flagSigningKey,
+			EnvVar: "WAYPOINT_RUNNER_JOB_SIGNING_KEY",
+			Usage: "Base64-encoded public key of the server that job assignments " +
+				"must be signed with. The server logs this key when it starts. " +
+				"Assignments that aren't signed with it are rejected. If this " +
+				"isn't set, job assignments aren't verified.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "one-shot",
+			Target: &c.flagOneShot,
+			Usage: "Exit after running a single job. The exit code is non-zero " +
+				"if the job couldn't be run.",
+		})
+	})
 }
 
 func (c *RunnerAgentCommand) AutocompleteArgs() complete.Predictor {
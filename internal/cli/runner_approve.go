@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+type RunnerApproveCommand struct {
+	*baseCommand
+}
+
+func (c *RunnerApproveCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	if len(c.args) == 0 {
+		c.ui.Output("Runner ID required to approve", terminal.WithErrorStyle())
+		return 1
+	}
+
+	_, err := c.project.Client().ApproveRunner(c.Ctx, &pb.ApproveRunnerRequest{
+		RunnerId: c.args[0],
+	})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.ui.Output("Runner %s approved. It can be assigned jobs.", c.args[0], terminal.WithSuccessStyle())
+	return 0
+}
+
+func (c *RunnerApproveCommand) Flags() *flag.Sets {
+	return c.flagSet(0, nil)
+}
+
+func (c *RunnerApproveCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *RunnerApproveCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *RunnerApproveCommand) Synopsis() string {
+	return "Approve a runner so that it can be assigned jobs."
+}
+
+func (c *RunnerApproveCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint runner approve [options] RUNNER-ID
+
+  Approve a runner so that it can be assigned jobs.
+
+  If the server is started with -runner-approval, new runners are pending
+  until they're approved. Pending runners are listed by
+  "waypoint runner list -state=pending". A runner that isn't registered
+  yet is approved for when it registers, and approved runners stay
+  approved when they register again.
+
+` + c.Flags().Help())
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+type RunnerDrainCommand struct {
+	*baseCommand
+}
+
+func (c *RunnerDrainCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	if len(c.args) == 0 {
+		c.ui.Output("Runner ID required to drain", terminal.WithErrorStyle())
+		return 1
+	}
+
+	runner, err := c.project.Client().DrainRunner(c.Ctx, &pb.DrainRunnerRequest{
+		RunnerId: c.args[0],
+	})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.ui.Output("Runner %s drained. It finishes the jobs it's running and isn't assigned new jobs.", runner.Id, terminal.WithSuccessStyle())
+	return 0
+}
+
+func (c *RunnerDrainCommand) Flags() *flag.Sets {
+	return c.flagSet(0, nil)
+}
+
+func (c *RunnerDrainCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *RunnerDrainCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *RunnerDrainCommand) Synopsis() string {
+	return "Stop assigning new jobs to a runner."
+}
+
+func (c *RunnerDrainCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint runner drain [options] RUNNER-ID
+
+  Stop assigning new jobs to a runner so that it can be stopped for
+  maintenance. The runner finishes the jobs it is already running.
+
+  The runner is drained until "waypoint runner undrain" is run or the
+  runner registers again, such as when it restarts.
+
+` + c.Flags().Help())
+}
@@ -0,0 +1,509 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/posener/complete"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	"github.com/hashicorp/waypoint/internal/runnerinstall"
+	runnerecs "github.com/hashicorp/waypoint/internal/runnerinstall/ecs"
+	runnerk8s "github.com/hashicorp/waypoint/internal/runnerinstall/k8s"
+	runnernomad "github.com/hashicorp/waypoint/internal/runnerinstall/nomad"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/serverclient"
+)
+
+// defaultRunnerImage is the image installed runners run if none is given.
+const defaultRunnerImage = "hashicorp/waypoint:latest"
+
+type RunnerInstallCommand struct {
+	*baseCommand
+
+	flagPlatform   string
+	flagId         string
+	flagImage      string
+	flagConfig     map[string]string
+	flagServerAddr string
+	flagLabels     map[string]string
+	flagPool       string
+	flagTags       []string
+	flagMaxJobs    int
+}
+
+func (c *RunnerInstallCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	installer, err := runnerInstaller(c.flagPlatform, c.flagConfig)
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	client := c.project.Client()
+	if c.flagId != "" {
+		_, err := client.GetRunnerInstallation(c.Ctx, &pb.GetRunnerInstallationRequest{
+			RunnerId: c.flagId,
+		})
+		if err == nil {
+			c.ui.Output(
+				"Runner %s is already installed. Use \"waypoint runner upgrade\" to upgrade it.",
+				c.flagId, terminal.WithErrorStyle())
+			return 1
+		}
+		if status.Code(err) != codes.NotFound {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return 1
+		}
+	}
+
+	// The runner gets its own token so that it can be rotated or revoked
+	// without affecting other runners.
+	tokenResp, err := client.CreateRunnerToken(c.Ctx, &pb.CreateRunnerTokenRequest{
+		RunnerId: c.flagId,
+	})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+	id := tokenResp.RunnerToken.RunnerId
+
+	inst := &pb.RunnerInstallation{
+		RunnerId: id,
+		Platform: c.flagPlatform,
+		Image:    c.flagImage,
+		Args:     c.agentArgs(),
+		Config:   c.flagConfig,
+	}
+
+	c.ui.Output("Installing runner %s into %s...", id, c.flagPlatform, terminal.WithHeaderStyle())
+	err = installer.Install(c.Ctx, runnerInstallRunner(c.baseCommand, c.flagServerAddr, inst, tokenResp.Token))
+	if err != nil {
+		c.ui.Output("Error installing the runner: %s", clierrors.Humanize(err), terminal.WithErrorStyle())
+
+		// The token isn't used by anything else so we don't leave it behind.
+		if _, rerr := client.RevokeRunnerToken(c.Ctx, &pb.RevokeRunnerTokenRequest{
+			RunnerId: id,
+		}); rerr != nil {
+			c.ui.Output("Error revoking the runner token: %s", clierrors.Humanize(rerr), terminal.WithErrorStyle())
+		}
+
+		return 1
+	}
+
+	if _, err := client.SetRunnerInstallation(c.Ctx, &pb.SetRunnerInstallationRequest{
+		Installation: inst,
+	}); err != nil {
+		c.ui.Output(
+			"The runner was installed but recording the installation failed: %s",
+			clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.ui.Output("Runner %s installed.", id, terminal.WithSuccessStyle())
+	return 0
+}
+
+// agentArgs returns the arguments of the runner agent command for the
+// runner options of the flags.
+func (c *RunnerInstallCommand) agentArgs() []string {
+	args := []string{"runner", "agent"}
+
+	keys := make([]string, 0, len(c.flagLabels))
+	for k := range c.flagLabels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "-label="+k+"="+c.flagLabels[k])
+	}
+
+	if c.flagPool != "" {
+		args = append(args, "-pool="+c.flagPool)
+	}
+	for _, tag := range c.flagTags {
+		args = append(args, "-tag="+tag)
+	}
+	if c.flagMaxJobs > 0 {
+		args = append(args, "-max-jobs="+strconv.Itoa(c.flagMaxJobs))
+	}
+
+	return args
+}
+
+func (c *RunnerInstallCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.EnumSingleVar(&flag.EnumSingleVar{
+			Name:   "platform",
+			Target: &c.flagPlatform,
+			Values: runnerinstall.Platforms,
+			Usage:  "Platform to install the runner into.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "id",
+			Target: &c.flagId,
+			Usage:  "ID of the runner. If this isn't set, a random ID is generated.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "image",
+			Target:  &c.flagImage,
+			Default: defaultRunnerImage,
+			Usage:   "Container image of the runner.",
+		})
+
+		f.StringMapVar(&flag.StringMapVar{
+			Name:   "config",
+			Target: &c.flagConfig,
+			Usage: "Platform-specific option, such as \"namespace=runners\" for " +
+				"Kubernetes or \"subnets=subnet-a,subnet-b\" for ECS. These are " +
+				"recorded and reused when the runner is upgraded. Can be specified " +
+				"multiple times.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "server-addr",
+			Target: &c.flagServerAddr,
+			Usage: "Address of the server that the runner connects to. If this " +
+				"isn't set, the address the CLI uses is used. Set this if the " +
+				"server has a different address inside the platform.",
+		})
+
+		f.StringMapVar(&flag.StringMapVar{
+			Name:   "label",
+			Target: &c.flagLabels,
+			Usage: "Labels for the runner, such as \"arch=arm64\". Can be " +
+				"specified multiple times.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "pool",
+			Target: &c.flagPool,
+			Usage:  "Name of the runner pool the runner joins.",
+		})
+
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:   "tag",
+			Target: &c.flagTags,
+			Usage: "Resource tag describing specialized hardware the runner has, " +
+				"such as \"gpu\". Can be specified multiple times.",
+		})
+
+		f.IntVar(&flag.IntVar{
+			Name:   "max-jobs",
+			Target: &c.flagMaxJobs,
+			Usage:  "Most operations the runner runs at once.",
+		})
+	})
+}
+
+func (c *RunnerInstallCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *RunnerInstallCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *RunnerInstallCommand) Synopsis() string {
+	return "Install a runner into a platform."
+}
+
+func (c *RunnerInstallCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint runner install [options]
+
+  Install a long running runner into Kubernetes, Nomad, or ECS.
+
+  The runner gets its own token that can only register it, and the
+  installation is recorded in the server so that the runner can be
+  upgraded with "waypoint runner upgrade" or removed with
+  "waypoint runner uninstall" later.
+
+  The credentials for the platform are read the same way as the
+  platform's own CLI, such as from the kubeconfig file for Kubernetes,
+  NOMAD_ADDR and NOMAD_TOKEN for Nomad, or the AWS credential chain
+  for ECS.
+
+` + c.Flags().Help())
+}
+
+type RunnerUpgradeCommand struct {
+	*baseCommand
+
+	flagImage      string
+	flagServerAddr string
+}
+
+func (c *RunnerUpgradeCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	if len(c.args) == 0 {
+		c.ui.Output("Runner ID required for upgrade", terminal.WithErrorStyle())
+		return 1
+	}
+
+	client := c.project.Client()
+	inst, err := client.GetRunnerInstallation(c.Ctx, &pb.GetRunnerInstallationRequest{
+		RunnerId: c.args[0],
+	})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+	if c.flagImage != "" {
+		inst.Image = c.flagImage
+	}
+
+	installer, err := runnerInstaller(inst.Platform, inst.Config)
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	// Tokens are only shown once, so the runner gets a new one. The old
+	// token stops working, which is fine since the runner is restarted.
+	tokenResp, err := client.RotateRunnerToken(c.Ctx, &pb.RotateRunnerTokenRequest{
+		RunnerId: inst.RunnerId,
+	})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.ui.Output("Upgrading runner %s in %s...", inst.RunnerId, inst.Platform, terminal.WithHeaderStyle())
+	err = installer.Install(c.Ctx, runnerInstallRunner(c.baseCommand, c.flagServerAddr, inst, tokenResp.Token))
+	if err != nil {
+		c.ui.Output("Error upgrading the runner: %s", clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	if _, err := client.SetRunnerInstallation(c.Ctx, &pb.SetRunnerInstallationRequest{
+		Installation: inst,
+	}); err != nil {
+		c.ui.Output(
+			"The runner was upgraded but recording the installation failed: %s",
+			clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.ui.Output("Runner %s upgraded to %s.", inst.RunnerId, inst.Image, terminal.WithSuccessStyle())
+	return 0
+}
+
+func (c *RunnerUpgradeCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.StringVar(&flag.StringVar{
+			Name:   "image",
+			Target: &c.flagImage,
+			Usage: "Container image to upgrade the runner to. If this isn't set, " +
+				"the runner is reinstalled with its current image.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "server-addr",
+			Target: &c.flagServerAddr,
+			Usage: "Address of the server that the runner connects to. If this " +
+				"isn't set, the address the CLI uses is used.",
+		})
+	})
+}
+
+func (c *RunnerUpgradeCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *RunnerUpgradeCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *RunnerUpgradeCommand) Synopsis() string {
+	return "Upgrade an installed runner."
+}
+
+func (c *RunnerUpgradeCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint runner upgrade [options] ID
+
+  Upgrade a runner installed with "waypoint runner install".
+
+  The runner is reinstalled with the options it was installed with and a
+  new token, which restarts it. The jobs it is running are handled like
+  any other runner that disconnects.
+
+` + c.Flags().Help())
+}
+
+type RunnerUninstallCommand struct {
+	*baseCommand
+}
+
+func (c *RunnerUninstallCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	if len(c.args) == 0 {
+		c.ui.Output("Runner ID required for uninstall", terminal.WithErrorStyle())
+		return 1
+	}
+
+	client := c.project.Client()
+	inst, err := client.GetRunnerInstallation(c.Ctx, &pb.GetRunnerInstallationRequest{
+		RunnerId: c.args[0],
+	})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	installer, err := runnerInstaller(inst.Platform, inst.Config)
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.ui.Output("Uninstalling runner %s from %s...", inst.RunnerId, inst.Platform, terminal.WithHeaderStyle())
+	if err := installer.Uninstall(c.Ctx, inst.RunnerId); err != nil {
+		c.ui.Output("Error uninstalling the runner: %s", clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	_, err = client.RevokeRunnerToken(c.Ctx, &pb.RevokeRunnerTokenRequest{
+		RunnerId: inst.RunnerId,
+	})
+	if err != nil && status.Code(err) != codes.NotFound {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	if _, err := client.DeleteRunnerInstallation(c.Ctx, &pb.DeleteRunnerInstallationRequest{
+		RunnerId: inst.RunnerId,
+	}); err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.ui.Output("Runner %s uninstalled.", inst.RunnerId, terminal.WithSuccessStyle())
+	return 0
+}
+
+func (c *RunnerUninstallCommand) Flags() *flag.Sets {
+	return c.flagSet(0, nil)
+}
+
+func (c *RunnerUninstallCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *RunnerUninstallCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *RunnerUninstallCommand) Synopsis() string {
+	return "Uninstall an installed runner."
+}
+
+func (c *RunnerUninstallCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint runner uninstall ID
+
+  Uninstall a runner installed with "waypoint runner install".
+
+  The runner is removed from its platform, its token is revoked, and the
+  record of the installation is deleted.
+
+`)
+}
+
+// runnerInstaller returns the installer for the given platform.
+func runnerInstaller(platform string, config map[string]string) (runnerinstall.Installer, error) {
+	if config == nil {
+		config = map[string]string{}
+	}
+
+	switch platform {
+	case "ecs":
+		return runnerecs.New(config)
+
+	case "kubernetes":
+		return runnerk8s.New(config)
+
+	case "nomad":
+		return runnernomad.New(config)
+
+	case "":
+		return nil, fmt.Errorf("-platform must be set to one of: %s",
+			strings.Join(runnerinstall.Platforms, ", "))
+
+	default:
+		return nil, fmt.Errorf("runners can't be installed into %q", platform)
+	}
+}
+
+// runnerInstallRunner returns the runner to install for an installation.
+// The runner connects to the server the same way as the CLI unless addr
+// is set.
+func runnerInstallRunner(
+	c *baseCommand,
+	addr string,
+	inst *pb.RunnerInstallation,
+	token string,
+) *runnerinstall.Runner {
+	server := c.clientContext.Server
+	if addr == "" {
+		addr = server.Address
+	}
+
+	env := map[string]string{
+		serverclient.EnvServerAddr:  addr,
+		serverclient.EnvServerToken: token,
+	}
+	if server.Tls {
+		env[serverclient.EnvServerTls] = "1"
+	}
+	if server.TlsSkipVerify {
+		env[serverclient.EnvServerTlsSkipVerify] = "1"
+	}
+
+	// The ID is always last so that it matches the token even if the
+	// recorded args set one.
+	args := append([]string{}, inst.Args...)
+	args = append(args, "-id="+inst.RunnerId)
+
+	return &runnerinstall.Runner{
+		Id:    inst.RunnerId,
+		Image: inst.Image,
+		Args:  args,
+		Env:   env,
+	}
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+type RunnerListCommand struct {
+	*baseCommand
+
+	flagStates []string
+	flagLabels map[string]string
+	flagPool   string
+	flagTags   []string
+}
+
+func (c *RunnerListCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	req := &pb.ListRunnersRequest{
+		Labels: c.flagLabels,
+		Pool:   c.flagPool,
+		Tags:   c.flagTags,
+	}
+	for _, st := range c.flagStates {
+		req.States = append(req.States,
+			pb.RunnerStatus_State(pb.RunnerStatus_State_value[strings.ToUpper(st)]))
+	}
+
+	table := terminal.NewTable("ID", "State", "Pool", "Labels", "Tags", "Jobs", "Last Heartbeat")
+	for {
+		resp, err := c.project.Client().ListRunners(c.Ctx, req)
+		if err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return 1
+		}
+
+		for _, r := range resp.Runners {
+			var labels []string
+			for k, v := range r.Runner.Labels {
+				labels = append(labels, k+"="+v)
+			}
+			sort.Strings(labels)
+
+			heartbeat := "never"
+			if t, err := ptypes.Timestamp(r.LastHeartbeatTime); err == nil {
+				heartbeat = humanize.Time(t)
+			}
+
+			table.Rich([]string{
+				r.Runner.Id,
+				strings.ToLower(r.State.String()),
+				r.Runner.Pool,
+				strings.Join(labels, ","),
+				strings.Join(r.Runner.ResourceTags, ","),
+				strconv.Itoa(len(r.JobIds)),
+				heartbeat,
+			}, nil)
+		}
+
+		if resp.NextPageToken == "" {
+			break
+		}
+		req.PageToken = resp.NextPageToken
+	}
+
+	c.ui.Table(table)
+	return 0
+}
+
+func (c *RunnerListCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.EnumVar(&flag.EnumVar{
+			Name:   "state",
+			Target: &c.flagStates,
+			Values: []string{"idle", "busy", "full", "draining", "pending"},
+			Usage:  "Only list the runners in the given states.",
+		})
+
+		f.StringMapVar(&flag.StringMapVar{
+			Name:   "label",
+			Target: &c.flagLabels,
+			Usage: "Only list the runners that have the label, such as " +
+				"\"arch=arm64\". Can be specified multiple times.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "pool",
+			Target: &c.flagPool,
+			Usage:  "Only list the runners in the given runner pool.",
+		})
+
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:   "tag",
+			Target: &c.flagTags,
+			Usage: "Only list the runners that have the resource tag, such as " +
+				"\"gpu\". Can be specified multiple times.",
+		})
+	})
+}
+
+func (c *RunnerListCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *RunnerListCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *RunnerListCommand) Synopsis() string {
+	return "List the registered runners."
+}
+
+func (c *RunnerListCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint runner list [options]
+
+  List the registered runners.
+
+  This shows the state of each runner, the number of jobs it's running,
+  and when it last sent a heartbeat. Drained runners are listed in the
+  "draining" state and runners waiting to be approved in the "pending"
+  state.
+
+` + c.Flags().Help())
+}
@@ -0,0 +1,265 @@
+package cli
+
+import (
+	"github.com/dustin/go-humanize"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+type RunnerTokenCreateCommand struct {
+	*baseCommand
+
+	flagId string
+}
+
+func (c *RunnerTokenCreateCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	resp, err := c.project.Client().CreateRunnerToken(c.Ctx, &pb.CreateRunnerTokenRequest{
+		RunnerId: c.flagId,
+	})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.ui.Output("Token created for runner %s. The token below is only shown once.",
+		resp.RunnerToken.RunnerId, terminal.WithSuccessStyle())
+	c.ui.Output(resp.Token)
+	return 0
+}
+
+func (c *RunnerTokenCreateCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.StringVar(&flag.StringVar{
+			Name:   "id",
+			Target: &c.flagId,
+			Usage: "ID of the runner to create the token for. If this isn't set, " +
+				"a random ID is generated. The runner must be started with this ID.",
+		})
+	})
+}
+
+func (c *RunnerTokenCreateCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *RunnerTokenCreateCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *RunnerTokenCreateCommand) Synopsis() string {
+	return "Create a token for a single runner."
+}
+
+func (c *RunnerTokenCreateCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint runner token create [options]
+
+  Create a token for a single runner and output it.
+
+  The token can only register the runner with the given ID, so it can be
+  rotated or revoked without affecting other runners. Start the runner
+  with "waypoint runner agent -id" set to the ID of the token and the
+  WAYPOINT_SERVER_TOKEN environment variable set to the token.
+
+` + c.Flags().Help())
+}
+
+type RunnerTokenListCommand struct {
+	*baseCommand
+}
+
+func (c *RunnerTokenListCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	resp, err := c.project.Client().ListRunnerTokens(c.Ctx, &empty.Empty{})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	table := terminal.NewTable("Runner ID", "Created", "Rotated")
+	for _, t := range resp.RunnerTokens {
+		var created, rotated string
+		if ts, err := ptypes.Timestamp(t.CreatedAt); err == nil {
+			created = humanize.Time(ts)
+		}
+		if ts, err := ptypes.Timestamp(t.RotatedAt); err == nil {
+			rotated = humanize.Time(ts)
+		}
+
+		table.Rich([]string{
+			t.RunnerId,
+			created,
+			rotated,
+		}, nil)
+	}
+
+	c.ui.Table(table)
+	return 0
+}
+
+func (c *RunnerTokenListCommand) Flags() *flag.Sets {
+	return c.flagSet(0, nil)
+}
+
+func (c *RunnerTokenListCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *RunnerTokenListCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *RunnerTokenListCommand) Synopsis() string {
+	return "List the runners that have a token."
+}
+
+func (c *RunnerTokenListCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint runner token list
+
+  List the runners that have a token and when it was last rotated.
+
+`)
+}
+
+type RunnerTokenRotateCommand struct {
+	*baseCommand
+}
+
+func (c *RunnerTokenRotateCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	if len(c.args) == 0 {
+		c.ui.Output("Runner ID required for rotation", terminal.WithErrorStyle())
+		return 1
+	}
+
+	resp, err := c.project.Client().RotateRunnerToken(c.Ctx, &pb.RotateRunnerTokenRequest{
+		RunnerId: c.args[0],
+	})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.ui.Output("Token rotated for runner %s. The previous token is no longer valid.",
+		resp.RunnerToken.RunnerId, terminal.WithSuccessStyle())
+	c.ui.Output(resp.Token)
+	return 0
+}
+
+func (c *RunnerTokenRotateCommand) Flags() *flag.Sets {
+	return c.flagSet(0, nil)
+}
+
+func (c *RunnerTokenRotateCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *RunnerTokenRotateCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *RunnerTokenRotateCommand) Synopsis() string {
+	return "Issue a new token for a runner."
+}
+
+func (c *RunnerTokenRotateCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint runner token rotate ID
+
+  Issue a new token for a runner and output it.
+
+  The previous token stops working immediately. The runner stays
+  registered, but it needs the new token for its next requests, such as
+  when it asks for its next job.
+
+`)
+}
+
+type RunnerTokenRevokeCommand struct {
+	*baseCommand
+}
+
+func (c *RunnerTokenRevokeCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	if len(c.args) == 0 {
+		c.ui.Output("Runner ID required for revocation", terminal.WithErrorStyle())
+		return 1
+	}
+
+	_, err := c.project.Client().RevokeRunnerToken(c.Ctx, &pb.RevokeRunnerTokenRequest{
+		RunnerId: c.args[0],
+	})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.ui.Output("Runner token revoked.", terminal.WithSuccessStyle())
+	return 0
+}
+
+func (c *RunnerTokenRevokeCommand) Flags() *flag.Sets {
+	return c.flagSet(0, nil)
+}
+
+func (c *RunnerTokenRevokeCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *RunnerTokenRevokeCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *RunnerTokenRevokeCommand) Synopsis() string {
+	return "Revoke the token of a runner."
+}
+
+func (c *RunnerTokenRevokeCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint runner token revoke ID
+
+  Revoke the token of a runner. The token stops working immediately.
+
+`)
+}
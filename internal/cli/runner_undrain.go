@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+type RunnerUndrainCommand struct {
+	*baseCommand
+}
+
+func (c *RunnerUndrainCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	if len(c.args) == 0 {
+		c.ui.Output("Runner ID required to undrain", terminal.WithErrorStyle())
+		return 1
+	}
+
+	runner, err := c.project.Client().UndrainRunner(c.Ctx, &pb.DrainRunnerRequest{
+		RunnerId: c.args[0],
+	})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.ui.Output("Runner %s undrained. It is assigned jobs again.", runner.Id, terminal.WithSuccessStyle())
+	return 0
+}
+
+func (c *RunnerUndrainCommand) Flags() *flag.Sets {
+	return c.flagSet(0, nil)
+}
+
+func (c *RunnerUndrainCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *RunnerUndrainCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *RunnerUndrainCommand) Synopsis() string {
+	return "Resume assigning jobs to a drained runner."
+}
+
+func (c *RunnerUndrainCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint runner undrain [options] RUNNER-ID
+
+  Resume assigning jobs to a runner that was drained with
+  "waypoint runner drain".
+
+` + c.Flags().Help())
+}
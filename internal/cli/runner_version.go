@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+type RunnerVersionSetCommand struct {
+	*baseCommand
+
+	flagClear bool
+}
+
+func (c *RunnerVersionSetCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	var version string
+	switch {
+	case c.flagClear && len(c.args) == 0:
+
+	case !c.flagClear && len(c.args) == 1:
+		version = c.args[0]
+
+	default:
+		c.ui.Output("A single version or -clear is required.\n\n"+c.Help(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	_, err := c.project.Client().SetRunnerVersion(c.Ctx, &pb.SetRunnerVersionRequest{
+		Version: version,
+	})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	if version == "" {
+		c.ui.Output("Runner version cleared.", terminal.WithSuccessStyle())
+		return 0
+	}
+
+	c.ui.Output("Runner version %s published. Follow the rollout with "+
+		"\"waypoint runner version status\".", version, terminal.WithSuccessStyle())
+	return 0
+}
+
+func (c *RunnerVersionSetCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.BoolVar(&flag.BoolVar{
+			Name:   "clear",
+			Target: &c.flagClear,
+			Usage:  "Clear the published version to stop the rollout.",
+		})
+	})
+}
+
+func (c *RunnerVersionSetCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *RunnerVersionSetCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *RunnerVersionSetCommand) Synopsis() string {
+	return "Publish the version that runners should run."
+}
+
+func (c *RunnerVersionSetCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint runner version set [options] VERSION
+
+  Publish the version that runners should run.
+
+  Runners of another version that were started with
+  "waypoint runner agent -update-command" finish their operations, run
+  the command, and restart. Other runners are listed as pending until
+  they're upgraded some other way, such as with "waypoint runner upgrade".
+
+` + c.Flags().Help())
+}
+
+type RunnerVersionStatusCommand struct {
+	*baseCommand
+}
+
+func (c *RunnerVersionStatusCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	resp, err := c.project.Client().GetRunnerVersion(c.Ctx, &empty.Empty{})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	if resp.Version == "" {
+		c.ui.Output("No runner version is published.")
+		return 0
+	}
+
+	c.ui.Output("Published runner version: %s", resp.Version, terminal.WithHeaderStyle())
+	if len(resp.Updates) == 0 {
+		c.ui.Output("No runners are registered.")
+		return 0
+	}
+
+	table := terminal.NewTable("Runner ID", "State", "From", "Updated", "Error")
+	for _, u := range resp.Updates {
+		var updated string
+		if ts, err := ptypes.Timestamp(u.UpdateTime); err == nil {
+			updated = humanize.Time(ts)
+		}
+
+		var colors []string
+		switch u.State {
+		case pb.RunnerUpdate_UPDATED:
+			colors = []string{"", terminal.Green, "", "", ""}
+
+		case pb.RunnerUpdate_FAILED:
+			colors = []string{"", terminal.Red, "", "", ""}
+		}
+
+		table.Rich([]string{
+			u.RunnerId,
+			strings.ToLower(u.State.String()),
+			u.FromVersion,
+			updated,
+			u.Error,
+		}, colors)
+	}
+
+	c.ui.Table(table)
+	return 0
+}
+
+func (c *RunnerVersionStatusCommand) Flags() *flag.Sets {
+	return c.flagSet(0, nil)
+}
+
+func (c *RunnerVersionStatusCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *RunnerVersionStatusCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *RunnerVersionStatusCommand) Synopsis() string {
+	return "Show the rollout of the published runner version."
+}
+
+func (c *RunnerVersionStatusCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint runner version status
+
+  Show the published runner version and the state of its rollout on
+  each runner: pending, updating, updated, or failed.
+
+`)
+}
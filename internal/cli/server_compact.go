@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"github.com/dustin/go-humanize"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+)
+
+type ServerCompactCommand struct {
+	*baseCommand
+}
+
+func (c *ServerCompactCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	sg := c.ui.StepGroup()
+	defer sg.Wait()
+	step := sg.Add("Compacting the server database...")
+	defer step.Abort()
+
+	resp, err := c.project.Client().CompactDatabase(c.Ctx, &empty.Empty{})
+	if err != nil {
+		step.Update("Failed to compact the server database")
+		step.Status(terminal.StatusError)
+		step.Done()
+
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	step.Update("Compacted the server database from %s to %s",
+		humanize.Bytes(uint64(resp.SizeBefore)),
+		humanize.Bytes(uint64(resp.SizeAfter)))
+	step.Done()
+	return 0
+}
+
+func (c *ServerCompactCommand) Flags() *flag.Sets {
+	return c.flagSet(0, nil)
+}
+
+func (c *ServerCompactCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ServerCompactCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *ServerCompactCommand) Synopsis() string {
+	return "Compact the server database to reclaim free space."
+}
+
+func (c *ServerCompactCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint server compact [options]
+
+  Compact the server database to reclaim free space.
+
+  The database file never shrinks on its own, even after data is deleted.
+  This rewrites it without the free space. Requests to the server wait
+  while the database is compacted. The server can also compact the
+  database in the background with the -compact-interval flag of
+  "waypoint server run".
+
+`)
+}
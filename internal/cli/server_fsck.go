@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+type ServerFsckCommand struct {
+	*baseCommand
+
+	flagRepair bool
+}
+
+func (c *ServerFsckCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	sg := c.ui.StepGroup()
+	step := sg.Add("Verifying the server state...")
+
+	resp, err := c.project.Client().VerifyState(c.Ctx, &pb.VerifyStateRequest{
+		Repair: c.flagRepair,
+	})
+	if err != nil {
+		step.Update("Failed to verify the server state")
+		step.Status(terminal.StatusError)
+		step.Done()
+		sg.Wait()
+
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	var unrepaired int
+	for _, issue := range resp.Issues {
+		if !issue.Repaired {
+			unrepaired++
+		}
+	}
+
+	switch {
+	case len(resp.Issues) == 0:
+		step.Update("The server state has no issues")
+
+	case unrepaired == 0:
+		step.Update("Repaired %d issue(s) in the server state", len(resp.Issues))
+
+	default:
+		step.Update("Found %d issue(s) in the server state", len(resp.Issues))
+		step.Status(terminal.StatusWarn)
+	}
+	step.Done()
+	sg.Wait()
+
+	if len(resp.Issues) == 0 {
+		return 0
+	}
+
+	table := terminal.NewTable("Job", "Issue", "Repaired", "Detail")
+	for _, issue := range resp.Issues {
+		repaired := "no"
+		if issue.Repaired {
+			repaired = "yes"
+		}
+
+		table.Rich([]string{
+			issue.JobId,
+			strings.ToLower(strings.Replace(issue.Type.String(), "_", " ", -1)),
+			repaired,
+			issue.Detail,
+		}, nil)
+	}
+	c.ui.Table(table)
+
+	if unrepaired > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+func (c *ServerFsckCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.BoolVar(&flag.BoolVar{
+			Name:   "repair",
+			Target: &c.flagRepair,
+			Usage: "Repair the issues that are found. Issues that can't be " +
+				"repaired safely, such as a job that is in a different state " +
+				"in memory than on disk, are only reported.",
+		})
+	})
+}
+
+func (c *ServerFsckCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ServerFsckCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *ServerFsckCommand) Synopsis() string {
+	return "Check the server state for inconsistencies."
+}
+
+func (c *ServerFsckCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint server fsck [options]
+
+  Check the server state for inconsistencies.
+
+  The server stores jobs on disk and indexes them in memory. This checks
+  that every job is indexed and every index has a job, that the timestamps
+  of jobs are valid, and that queued jobs don't target a runner that no
+  longer exists. Jobs created in the last minute are not checked for
+  issues that are expected while they are being created.
+
+  With -repair, the issues that can be repaired safely are. Jobs that
+  target a runner that no longer exists are cancelled. Scheduling waits
+  while the state is checked. This exits with a non-zero status if there
+  are issues that weren't repaired.
+
+`)
+}
@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	"github.com/hashicorp/waypoint/internal/server/singleprocess/loadtest"
+)
+
+type ServerLoadTestCommand struct {
+	*baseCommand
+
+	config loadtest.Config
+}
+
+func (c *ServerLoadTestCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+		WithClient(false),
+	); err != nil {
+		return 1
+	}
+
+	c.config.Log = c.Log.Named("loadtest")
+
+	sg := c.ui.StepGroup()
+	step := sg.Add("Running scheduler load test with %d runners and %d jobs...",
+		c.config.Runners, c.config.Jobs)
+	defer step.Abort()
+
+	result, err := loadtest.Run(c.Ctx, &c.config)
+	if err != nil {
+		c.ui.Output(
+			"Error running load test: %s", err.Error(),
+			terminal.WithErrorStyle(),
+		)
+		return 1
+	}
+	step.Update("Load test complete")
+	step.Done()
+	sg.Wait()
+
+	c.ui.Output("")
+	c.ui.Output("Results:", terminal.WithHeaderStyle())
+	c.ui.Output("")
+	c.ui.NamedValues([]terminal.NamedValue{
+		{Name: "Jobs Queued", Value: fmt.Sprintf("%d", result.Queued)},
+		{Name: "Jobs Completed", Value: fmt.Sprintf("%d", result.Completed)},
+		{Name: "Runner Churn", Value: fmt.Sprintf("%d", result.RunnerChurn)},
+		{Name: "Duration", Value: result.Duration.Round(time.Millisecond).String()},
+		{Name: "Throughput", Value: fmt.Sprintf("%.2f jobs/sec", result.Throughput)},
+	})
+
+	c.ui.Output("")
+	c.ui.Output("Assignment Latency:", terminal.WithHeaderStyle())
+	c.ui.Output("")
+	c.ui.NamedValues([]terminal.NamedValue{
+		{Name: "p50", Value: result.Latency.P50.String()},
+		{Name: "p90", Value: result.Latency.P90.String()},
+		{Name: "p99", Value: result.Latency.P99.String()},
+		{Name: "max", Value: result.Latency.Max.String()},
+	})
+
+	if result.Completed < result.Queued || result.Completed < c.config.Jobs {
+		c.ui.Output("")
+		c.ui.Output(
+			"Not all jobs completed before the timeout. Results are partial.",
+			terminal.WithWarningStyle(),
+		)
+	}
+
+	return 0
+}
+
+func (c *ServerLoadTestCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.IntVar(&flag.IntVar{
+			Name:    "runners",
+			Target:  &c.config.Runners,
+			Usage:   "Number of simulated runners.",
+			Default: 10,
+		})
+
+		f.IntVar(&flag.IntVar{
+			Name:    "jobs",
+			Target:  &c.config.Jobs,
+			Usage:   "Total number of jobs to queue.",
+			Default: 1000,
+		})
+
+		f.Float64Var(&flag.Float64Var{
+			Name:   "rate",
+			Target: &c.config.Rate,
+			Usage: "Number of jobs to queue per second. If this is zero, jobs are " +
+				"queued as fast as possible.",
+			Default: 0,
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:    "job-duration",
+			Target:  &c.config.JobDuration,
+			Usage:   "Simulated time that each job runs for once it is assigned.",
+			Default: 0,
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:   "runner-churn",
+			Target: &c.config.RunnerChurn,
+			Usage: "How long each runner stays registered before deregistering and " +
+				"registering again. If this is zero, runners never churn.",
+			Default: 0,
+		})
+
+		f.IntVar(&flag.IntVar{
+			Name:    "targeted-percent",
+			Target:  &c.config.TargetedPercent,
+			Usage:   "Percentage of jobs that target a specific runner by ID.",
+			Default: 0,
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:    "timeout",
+			Target:  &c.config.Timeout,
+			Usage:   "Maximum time to run the load test for.",
+			Default: 5 * time.Minute,
+		})
+	})
+}
+
+func (c *ServerLoadTestCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ServerLoadTestCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *ServerLoadTestCommand) Synopsis() string {
+	return "Measure scheduler throughput with synthetic load"
+}
+
+func (c *ServerLoadTestCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint server load-test [options]
+
+  Measure job scheduler throughput and latency with synthetic load.
+
+  This runs simulated runners and jobs against a temporary, in-process
+  state store and reports assignment throughput and latency percentiles.
+  It does not connect to or modify any running server. The results do not
+  include network overhead so they are an upper bound on what a single
+  server can schedule, which is useful for capacity planning.
+
+` + c.Flags().Help())
+}
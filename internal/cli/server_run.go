@@ -2,6 +2,7 @@ package cli
 
 import (
 	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -14,6 +15,8 @@ import (
 	"io/ioutil"
 	"math/big"
 	"net"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -27,10 +30,23 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/audit"
+	"github.com/hashicorp/waypoint/internal/backup"
+	azurebackup "github.com/hashicorp/waypoint/internal/backup/azure"
+	gcsbackup "github.com/hashicorp/waypoint/internal/backup/gcs"
+	s3backup "github.com/hashicorp/waypoint/internal/backup/s3"
+	"github.com/hashicorp/waypoint/internal/dispatch"
+	"github.com/hashicorp/waypoint/internal/dispatch/k8s"
+	"github.com/hashicorp/waypoint/internal/dispatch/nomad"
 	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	"github.com/hashicorp/waypoint/internal/pkg/tlsconfig"
+	"github.com/hashicorp/waypoint/internal/runnerauth"
 	"github.com/hashicorp/waypoint/internal/server"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
 	"github.com/hashicorp/waypoint/internal/server/singleprocess"
 	"github.com/hashicorp/waypoint/internal/serverconfig"
+	"github.com/hashicorp/waypoint/internal/telemetry"
+	"github.com/hashicorp/waypoint/internal/version"
 )
 
 const tosStatement = `
@@ -60,12 +76,20 @@ type ServerRunCommand struct {
 
 	config        serverconfig.Config
 	flagDisableUI bool
+	flagMetrics   bool
 	flagURLInmem  bool
 
+	// flagRunnerGroupWeights are the weights of the runner groups for
+	// the weighted job scheduler, which are parsed into the config.
+	flagRunnerGroupWeights map[string]string
+
 	flagAdvertiseAddr          string
 	flagAdvertiseTLSEnabled    bool
 	flagAdvertiseTLSSkipVerify bool
 	flagAcceptTOS              bool
+
+	// TLS settings that are applied to both the gRPC and HTTP listeners.
+	flagTLS serverconfig.Listener
 }
 
 func (c *ServerRunCommand) Run(args []string) int {
@@ -82,6 +106,15 @@ func (c *ServerRunCommand) Run(args []string) int {
 		return 1
 	}
 
+	// Apply our TLS settings to both listeners
+	for _, ln := range []*serverconfig.Listener{&c.config.GRPC, &c.config.HTTP} {
+		ln.TLSCertFile = c.flagTLS.TLSCertFile
+		ln.TLSKeyFile = c.flagTLS.TLSKeyFile
+		ln.TLSMinVersion = c.flagTLS.TLSMinVersion
+		ln.TLSCipherSuites = c.flagTLS.TLSCipherSuites
+		ln.TLSReloadInterval = c.flagTLS.TLSReloadInterval
+	}
+
 	if c.config.URL.Enabled &&
 		c.config.URL.ControlAddress == DefaultURLControlAddress &&
 		!c.flagAcceptTOS {
@@ -141,6 +174,36 @@ func (c *ServerRunCommand) Run(args []string) int {
 		}
 	}
 
+	// Runner group weights are flags of strings so we parse them.
+	if len(c.flagRunnerGroupWeights) > 0 {
+		weights := map[string]int{}
+		for group, v := range c.flagRunnerGroupWeights {
+			w, err := strconv.Atoi(v)
+			if err != nil {
+				c.ui.Output(
+					"Invalid weight for runner group %q: %s", group, v,
+					terminal.WithErrorStyle(),
+				)
+				return 1
+			}
+
+			weights[group] = w
+		}
+
+		c.config.RunnerGroups.Weights = weights
+	}
+
+	// Config encryption is only enabled if a key management service is set.
+	if ce := c.config.ConfigEncryption; ce != nil &&
+		ce.AWSKMSKeyId == "" && ce.VaultAddress == "" {
+		c.config.ConfigEncryption = nil
+	}
+
+	// Runner login is only enabled if there are identities allowed.
+	if ra := c.config.RunnerAuth; ra != nil && len(ra.AWSIAM) == 0 && len(ra.GCP) == 0 {
+		c.config.RunnerAuth = nil
+	}
+
 	// Set any server config
 	c.config.CEBConfig = &serverconfig.CEBConfig{
 		Addr:          c.flagAdvertiseAddr,
@@ -148,13 +211,70 @@ func (c *ServerRunCommand) Run(args []string) int {
 		TLSSkipVerify: c.flagAdvertiseTLSSkipVerify,
 	}
 
+	// Dispatch is only enabled if a scheduler is set.
+	var scheduler dispatch.Scheduler
+	if d := c.config.Dispatch; d != nil && d.Scheduler != "" {
+		scheduler, err = dispatchScheduler(d)
+		if err != nil {
+			c.ui.Output(
+				"Error configuring the dispatch scheduler: %s", err.Error(),
+				terminal.WithErrorStyle(),
+			)
+			return 1
+		}
+	} else {
+		c.config.Dispatch = nil
+	}
+
+	// Backups are only enabled if a store is set.
+	var backupStore backup.Store
+	if b := c.config.Backup; b != nil && b.Store != "" {
+		backupStore, err = newBackupStore(c.Ctx, b)
+		if err != nil {
+			c.ui.Output(
+				"Error configuring the backup store: %s", err.Error(),
+				terminal.WithErrorStyle(),
+			)
+			return 1
+		}
+	} else {
+		c.config.Backup = nil
+	}
+
+	// If the state is replicated, we stop serving if we lose leadership.
+	ctx, cancel := context.WithCancel(c.Ctx)
+	defer cancel()
+	if c.config.Raft.ID != "" {
+		c.ui.Output("Waiting to become the raft leader...")
+	}
+
 	// Create our server
-	impl, err := singleprocess.New(
+	implOpts := []singleprocess.Option{
 		singleprocess.WithDB(db),
 		singleprocess.WithConfig(&c.config),
 		singleprocess.WithLogger(log.Named("singleprocess")),
 		singleprocess.WithAcceptURLTerms(c.flagAcceptTOS),
-	)
+		singleprocess.WithDispatchScheduler(scheduler),
+		singleprocess.WithBackupStore(backupStore),
+		singleprocess.WithContext(ctx),
+		singleprocess.WithRaftLeaderLost(cancel),
+	}
+
+	// Followers serve reads on the gRPC listener until they're the leader.
+	// This is called before New returns, so followerStop is set by then.
+	var followerStop func()
+	if c.config.Raft.ID != "" && c.config.Raft.FollowerReads {
+		implOpts = append(implOpts, singleprocess.WithRaftFollower(
+			func(impl pb.WaypointServer) {
+				followerStop = c.serveFollower(ctx, log.Named("follower"), impl)
+			},
+		))
+	}
+
+	impl, err := singleprocess.New(implOpts...)
+	if followerStop != nil {
+		followerStop()
+	}
 
 	if err != nil {
 		c.ui.Output(
@@ -186,7 +306,7 @@ func (c *ServerRunCommand) Run(args []string) int {
 	defer httpLn.Close()
 
 	options := []server.Option{
-		server.WithContext(c.Ctx),
+		server.WithContext(ctx),
 		server.WithLogger(log),
 		server.WithGRPC(ln),
 		server.WithHTTP(httpLn),
@@ -198,6 +318,23 @@ func (c *ServerRunCommand) Run(args []string) int {
 		auth = true
 	}
 
+	auditExp, err := c.auditExporter(log.Named("audit"))
+	if err != nil {
+		c.ui.Output(
+			"Error configuring audit export: %s", err.Error(),
+			terminal.WithErrorStyle(),
+		)
+		return 1
+	}
+	if auditExp != nil {
+		defer auditExp.Close()
+		options = append(options, server.WithAudit(auditExp))
+	}
+
+	if c.flagMetrics {
+		options = append(options, server.WithMetrics(true))
+	}
+
 	ui := true
 	if !c.flagDisableUI {
 		options = append(options, server.WithBrowserUI(true))
@@ -231,6 +368,52 @@ func (c *ServerRunCommand) Run(args []string) int {
 
 		values = append(values, terminal.NamedValue{Name: "URL Service", Value: value})
 	}
+	if c.config.Telemetry.Enabled {
+		values = append(values, terminal.NamedValue{Name: "Telemetry", Value: c.config.Telemetry.Endpoint})
+	}
+	if auditExp != nil {
+		var dests []string
+		for _, v := range []string{c.config.Audit.Address, c.config.Audit.Path} {
+			if v != "" {
+				dests = append(dests, v)
+			}
+		}
+
+		values = append(values, terminal.NamedValue{
+			Name:  "Audit Export",
+			Value: fmt.Sprintf("%s (%s)", strings.Join(dests, ", "), c.config.Audit.Format),
+		})
+	}
+	if len(c.config.Federation.Servers) > 0 {
+		values = append(values, terminal.NamedValue{
+			Name:  "Federation",
+			Value: strings.Join(c.config.Federation.Servers, ", "),
+		})
+	}
+	if c.config.Snapshot.Schedule != "" {
+		values = append(values, terminal.NamedValue{
+			Name:  "Snapshots",
+			Value: fmt.Sprintf("%s (%s)", c.config.Snapshot.Path, c.config.Snapshot.Schedule),
+		})
+	}
+	if b := c.config.Backup; b != nil {
+		values = append(values, terminal.NamedValue{
+			Name:  "Backups",
+			Value: fmt.Sprintf("%s %s/%s (%s)", b.Store, b.Bucket, b.Prefix, b.Schedule),
+		})
+	}
+	if c.config.Compact.Interval > 0 {
+		values = append(values, terminal.NamedValue{
+			Name:  "Compaction",
+			Value: c.config.Compact.Interval.String(),
+		})
+	}
+	if c.config.Raft.ID != "" {
+		values = append(values, terminal.NamedValue{
+			Name:  "Raft",
+			Value: fmt.Sprintf("%s (%s)", c.config.Raft.ID, c.config.Raft.Address),
+		})
+	}
 	c.ui.NamedValues(values)
 
 	// If we aren't bootstrapped, let the user know
@@ -296,6 +479,48 @@ func (c *ServerRunCommand) Flags() *flag.Sets {
 		if c.config.URL == nil {
 			c.config.URL = &serverconfig.URL{}
 		}
+		if c.config.ConfigEncryption == nil {
+			c.config.ConfigEncryption = &serverconfig.ConfigEncryption{}
+		}
+		if c.config.RunnerAuth == nil {
+			c.config.RunnerAuth = &serverconfig.RunnerAuth{}
+		}
+		if c.config.Telemetry == nil {
+			c.config.Telemetry = &serverconfig.Telemetry{}
+		}
+		if c.config.Audit == nil {
+			c.config.Audit = &serverconfig.Audit{}
+		}
+		if c.config.Dispatch == nil {
+			c.config.Dispatch = &serverconfig.Dispatch{}
+		}
+		if c.config.CommitStatus == nil {
+			c.config.CommitStatus = &serverconfig.CommitStatus{}
+		}
+		if c.config.Snapshot == nil {
+			c.config.Snapshot = &serverconfig.Snapshot{}
+		}
+		if c.config.Federation == nil {
+			c.config.Federation = &serverconfig.Federation{}
+		}
+		if c.config.JobTimeouts == nil {
+			c.config.JobTimeouts = &serverconfig.JobTimeouts{}
+		}
+		if c.config.RunnerGroups == nil {
+			c.config.RunnerGroups = &serverconfig.RunnerGroups{}
+		}
+		if c.config.Raft == nil {
+			c.config.Raft = &serverconfig.Raft{}
+		}
+		if c.config.Compact == nil {
+			c.config.Compact = &serverconfig.Compact{}
+		}
+		if c.config.Backup == nil {
+			c.config.Backup = &serverconfig.Backup{}
+		}
+		if c.config.JobOutputRetention == nil {
+			c.config.JobOutputRetention = &serverconfig.JobOutputRetention{}
+		}
 
 		f := set.NewSet("Command Options")
 		f.StringVar(&flag.StringVar{
@@ -319,6 +544,639 @@ func (c *ServerRunCommand) Flags() *flag.Sets {
 			Default: "127.0.0.1:9702",
 		})
 
+		f.StringVar(&flag.StringVar{
+			Name:   "tls-cert-file",
+			Target: &c.flagTLS.TLSCertFile,
+			Usage: "Path to a PEM-encoded TLS certificate for the gRPC and HTTP " +
+				"listeners. If this isn't set, a self-signed certificate is created.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "tls-key-file",
+			Target: &c.flagTLS.TLSKeyFile,
+			Usage:  "Path to the PEM-encoded private key for -tls-cert-file.",
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:   "tls-reload-interval",
+			Target: &c.flagTLS.TLSReloadInterval,
+			Usage: "How often to check the TLS certificate and key files for changes " +
+				"and reload them. If this is zero, they're only reloaded on SIGHUP.",
+			Default: 0,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "tls-min-version",
+			Target:  &c.flagTLS.TLSMinVersion,
+			Usage:   "Minimum TLS version that clients can connect with. One of 1.0, 1.1, 1.2, or 1.3.",
+			Default: "1.2",
+		})
+
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:   "tls-cipher-suites",
+			Target: &c.flagTLS.TLSCipherSuites,
+			Usage: "TLS cipher suites that clients can use for TLS 1.2 and earlier, " +
+				"such as TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. This can be specified " +
+				"multiple times. If this isn't set, Go's secure defaults are used.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "fips",
+			Target: &c.config.FIPS,
+			Usage: "Restrict TLS to FIPS-approved versions, cipher suites, and curves, " +
+				"and require TLS on all listeners. This is always enabled for FIPS builds.",
+			Default: false,
+		})
+
+		f.Int64Var(&flag.Int64Var{
+			Name:   "job-output-max-bytes",
+			Target: &c.config.JobOutputMaxBytes,
+			Usage: "Maximum total size in bytes of job output to keep in memory. " +
+				"Output of completed jobs is dropped, oldest first, to stay under " +
+				"this limit. Set to zero for no limit.",
+			Default: 512 * 1024 * 1024,
+		})
+
+		f.Int64Var(&flag.Int64Var{
+			Name:   "job-output-buffer-max-bytes",
+			Target: &c.config.JobOutputBufferMaxBytes,
+			Usage: "Maximum size in bytes of the output of a single job to keep in " +
+				"memory. When a job goes over this limit, the output kept so far is " +
+				"dropped and replaced by a warning. Set to zero for no limit.",
+			Default: 64 * 1024 * 1024,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "job-output-buffer-block",
+			Target: &c.config.JobOutputBufferBlock,
+			Usage: "When a job goes over -job-output-buffer-max-bytes, slow down " +
+				"the runner until the output kept so far is streamed to every " +
+				"client instead of dropping it.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "job-output-compress",
+			Target: &c.config.JobOutputCompress,
+			Usage: "Compress the job output kept in memory to reduce the memory " +
+				"used by verbose output such as container builds.",
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:   "job-output-max-age",
+			Target: &c.config.JobOutputRetention.MaxAge,
+			Usage: "Prune the persisted output of operations this long after they " +
+				"complete. The operations themselves are kept.",
+		})
+
+		f.Int64Var(&flag.Int64Var{
+			Name:   "job-output-max-size",
+			Target: &c.config.JobOutputRetention.MaxBytes,
+			Usage: "Maximum total size in bytes of persisted operation output. The " +
+				"output of the operations that completed longest ago is pruned to " +
+				"stay under this limit.",
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:   "job-output-prune-interval",
+			Target: &c.config.JobOutputRetention.Interval,
+			Usage: "Interval between pruning persisted operation output if " +
+				"-job-output-max-age or -job-output-max-size is set.",
+			Default: time.Hour,
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:   "job-ack-timeout",
+			Target: &c.config.JobTimeouts.Ack,
+			Usage: "Time a runner has to accept an operation once it is assigned " +
+				"before the operation is queued again.",
+			Default: 2 * time.Minute,
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:   "job-heartbeat-timeout",
+			Target: &c.config.JobTimeouts.Heartbeat,
+			Usage: "Time an operation can run without a heartbeat from its runner " +
+				"before it fails. Lower this to detect failed runners faster.",
+			Default: 2 * time.Minute,
+		})
+
+		f.IntVar(&flag.IntVar{
+			Name:   "job-max-nacks",
+			Target: &c.config.JobMaxNacks,
+			Usage: "Number of times an operation can be rejected by runners or not " +
+				"accepted in time before it moves to the dead-letter queue instead of " +
+				"being queued again.",
+			Default: 5,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "job-preemption",
+			Target: &c.config.JobPreemption,
+			Usage: "Allow an operation to cancel a running operation with a lower " +
+				"priority if no runner is free to run it. The cancelled operation " +
+				"is queued again.",
+			Default: false,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "job-scheduler",
+			Target: &c.config.JobScheduler,
+			Usage: "Strategy for choosing which queued operation a runner is assigned: " +
+				"\"priority\", \"fifo\", \"fair-share\", \"bin-packing\", or \"weighted\". " +
+				"The weighted strategy distributes operations across runner groups by " +
+				"the -runner-group-weight values.",
+			Default: "priority",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "job-prefer-cheap-runners",
+			Target: &c.config.JobPreferCheapRunners,
+			Usage: "Assign operations to the cheapest waiting runner that can run " +
+				"them, by the -cost that runners advertise, such as spot capacity " +
+				"over on-demand capacity.",
+			Default: false,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "runner-group-label",
+			Target: &c.config.RunnerGroups.Label,
+			Usage: "Runner label whose value is the group of a runner for the " +
+				"weighted job scheduler.",
+			Default: "group",
+		})
+
+		f.StringMapVar(&flag.StringMapVar{
+			Name:   "runner-group-weight",
+			Target: &c.flagRunnerGroupWeights,
+			Usage: "Weight of a runner group for the weighted job scheduler, such " +
+				"as \"spot=80\". Operations that target any runner are distributed " +
+				"across the groups in proportion to their weights. Can be specified " +
+				"multiple times.",
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:   "runner-group-wait",
+			Target: &c.config.RunnerGroups.Wait,
+			Usage: "Time an operation waits for a runner of the group it is " +
+				"reserved for by the weighted job scheduler before any runner can " +
+				"run it.",
+			Default: 30 * time.Second,
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:   "runner-heartbeat-timeout",
+			Target: &c.config.RunnerHeartbeatTimeout,
+			Usage: "Time a runner can go without a heartbeat before it is " +
+				"deregistered and the operations assigned to it that it hasn't " +
+				"accepted are queued again.",
+			Default: 1 * time.Minute,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "runner-approval",
+			Target: &c.config.RunnerApproval,
+			Usage: "Require new runners to be approved with \"waypoint runner approve\" " +
+				"before they're assigned operations. Runners that register with a " +
+				"token created for them are approved automatically.",
+			Default: false,
+		})
+
+		f.IntVar(&flag.IntVar{
+			Name:   "runner-assign-limit",
+			Target: &c.config.RunnerAssignLimit,
+			Usage: "Most operations assigned to each runner in the " +
+				"-runner-assign-interval, so a runner that fails operations " +
+				"immediately can't fail every queued operation. Zero is unlimited.",
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:    "runner-assign-interval",
+			Target:  &c.config.RunnerAssignInterval,
+			Usage:   "Interval of the -runner-assign-limit.",
+			Default: 1 * time.Minute,
+		})
+
+		f.EnumSingleVar(&flag.EnumSingleVar{
+			Name:   "runner-disconnect-policy",
+			Target: &c.config.RunnerDisconnectPolicy,
+			Values: []string{"fail", "requeue", "wait"},
+			Usage: "What happens to the running operations of a runner that " +
+				"disconnects. \"wait\" fails them if the runner doesn't reconnect " +
+				"within the -job-heartbeat-timeout, \"requeue\" queues them for " +
+				"another runner, and \"fail\" fails them immediately. Operations " +
+				"the runner hadn't accepted are always queued again immediately.",
+			Default: "wait",
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:   "job-default-expiry",
+			Target: &c.config.JobDefaultExpiry,
+			Usage: "Time an operation queued without an expiry can stay queued " +
+				"before it expires. Set to zero for no expiry.",
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:   "job-max-expiry",
+			Target: &c.config.JobMaxExpiry,
+			Usage: "Longest an operation can stay queued before it expires, even if " +
+				"it was queued with a later expiry. Set to zero for no limit.",
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:   "job-max-run",
+			Target: &c.config.JobTimeouts.MaxRun,
+			Usage: "Longest an operation can run before it is cancelled. Set to " +
+				"zero for no limit. Operations can override this when they're queued.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "config-encryption-aws-kms-key",
+			Target: &c.config.ConfigEncryption.AWSKMSKeyId,
+			Usage: "AWS KMS key ID, ARN, or alias used to protect the key that " +
+				"encrypts sensitive config variables.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "config-encryption-vault-addr",
+			Target: &c.config.ConfigEncryption.VaultAddress,
+			Usage: "Address of the Vault server whose transit secrets engine is used " +
+				"to protect the key that encrypts sensitive config variables. The " +
+				"Vault token is read from VAULT_TOKEN.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "config-encryption-vault-mount",
+			Target:  &c.config.ConfigEncryption.VaultMount,
+			Usage:   "Path the Vault transit secrets engine is mounted at.",
+			Default: "transit",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "config-encryption-vault-key",
+			Target: &c.config.ConfigEncryption.VaultKey,
+			Usage:  "Name of the Vault transit key.",
+		})
+
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:   "runner-auth-aws-iam",
+			Target: &c.config.RunnerAuth.AWSIAM,
+			Usage: "AWS IAM identity allowed to login as a runner, in the format " +
+				"ARN or ARN=POOL. The ARN may contain \"*\" wildcards and is matched " +
+				"against the caller ARN. This can be specified multiple times.",
+		})
+
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:   "runner-auth-gcp",
+			Target: &c.config.RunnerAuth.GCP,
+			Usage: "GCP service account allowed to login as a runner, in the format " +
+				"EMAIL or EMAIL=POOL. The email may contain \"*\" wildcards. This " +
+				"can be specified multiple times.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "runner-auth-audience",
+			Target:  &c.config.RunnerAuth.Audience,
+			Usage:   "Audience that runner identity documents must be issued for.",
+			Default: runnerauth.DefaultAudience,
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:    "runner-auth-token-ttl",
+			Target:  &c.config.RunnerAuth.TokenTTL,
+			Usage:   "How long tokens issued to runners that login with a cloud identity are valid.",
+			Default: time.Hour,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "telemetry",
+			Target: &c.config.Telemetry.Enabled,
+			Usage: "Send anonymous usage telemetry to -telemetry-endpoint. Run " +
+				"\"waypoint server telemetry\" to see exactly what is sent.",
+			Default: false,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "telemetry-endpoint",
+			Target: &c.config.Telemetry.Endpoint,
+			Usage:  "URL to send anonymous usage telemetry to if it is enabled.",
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:    "telemetry-interval",
+			Target:  &c.config.Telemetry.Interval,
+			Usage:   "Time between anonymous usage telemetry reports.",
+			Default: telemetry.DefaultInterval,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "audit-format",
+			Target:  &c.config.Audit.Format,
+			Usage:   "Format of exported audit events: json, cef, or syslog.",
+			Default: string(audit.FormatJSON),
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "audit-addr",
+			Target: &c.config.Audit.Address,
+			Usage: "Address to stream audit events to, such as \"tcp://siem:514\" " +
+				"or \"udp://siem:514\". Events are separated by newlines.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "audit-file",
+			Target: &c.config.Audit.Path,
+			Usage:  "Path of a file to write audit events to.",
+		})
+
+		f.Int64Var(&flag.Int64Var{
+			Name:    "audit-file-max-bytes",
+			Target:  &c.config.Audit.MaxBytes,
+			Usage:   "Size in bytes at which the audit file is rotated. If this is 0, it is never rotated.",
+			Default: 100 * 1024 * 1024,
+		})
+
+		f.IntVar(&flag.IntVar{
+			Name:    "audit-file-max-backups",
+			Target:  &c.config.Audit.MaxBackups,
+			Usage:   "Number of rotated audit files to keep.",
+			Default: 5,
+		})
+
+		f.EnumSingleVar(&flag.EnumSingleVar{
+			Name:   "dispatch-scheduler",
+			Target: &c.config.Dispatch.Scheduler,
+			Values: []string{"nomad", "kubernetes"},
+			Usage: "Run each job in a new Nomad job or Kubernetes Job rather " +
+				"than on registered runners. Jobs that target a specific " +
+				"runner, such as local CLI runners, are not affected.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "dispatch-namespace",
+			Target: &c.config.Dispatch.Namespace,
+			Usage:  "Nomad or Kubernetes namespace to run dispatched jobs in.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "dispatch-image",
+			Target: &c.config.Dispatch.Image,
+			Usage:  "Waypoint image to run dispatched jobs with.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "dispatch-addr",
+			Target: &c.config.Dispatch.Addr,
+			Usage: "Address that dispatched jobs connect to the server on. " +
+				"This is required if -dispatch-scheduler is set.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "dispatch-tls-disabled",
+			Target: &c.config.Dispatch.TLSDisabled,
+			Usage:  "Dispatched jobs connect to the server without TLS.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "dispatch-tls-skip-verify",
+			Target: &c.config.Dispatch.TLSSkipVerify,
+			Usage:  "Dispatched jobs don't verify the TLS certificate of the server.",
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:    "dispatch-token-ttl",
+			Target:  &c.config.Dispatch.TokenTTL,
+			Usage:   "How long the runner tokens given to dispatched jobs are valid.",
+			Default: 24 * time.Hour,
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:    "dispatch-poll-interval",
+			Target:  &c.config.Dispatch.PollInterval,
+			Usage:   "Time between checking the status of dispatched jobs.",
+			Default: 10 * time.Second,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "dispatch-on-demand",
+			Target: &c.config.Dispatch.OnDemand,
+			Usage: "Launch runners in the scheduler when queued jobs have no " +
+				"runner that can run them, rather than running each job in a new " +
+				"scheduler job. Launched runners run a single job.",
+		})
+
+		f.IntVar(&flag.IntVar{
+			Name:    "dispatch-max-runners",
+			Target:  &c.config.Dispatch.MaxRunners,
+			Usage:   "Maximum number of runners launched by -dispatch-on-demand at once.",
+			Default: 10,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "commit-status-github-address",
+			Target: &c.config.CommitStatus.GitHubAddress,
+			Usage:  "Address of GitHub Enterprise to report commit statuses to.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "commit-status-github-token",
+			Target: &c.config.CommitStatus.GitHubToken,
+			EnvVar: "GITHUB_TOKEN",
+			Usage: "GitHub token used to report the status of jobs for the " +
+				"commits they ran as commit statuses.",
+		})
+
+		f.Int64Var(&flag.Int64Var{
+			Name:   "commit-status-github-app-id",
+			Target: &c.config.CommitStatus.GitHubAppID,
+			Usage: "ID of a GitHub App used to report the status of jobs for " +
+				"the commits they ran as check runs. This takes priority over " +
+				"-commit-status-github-token.",
+		})
+
+		f.Int64Var(&flag.Int64Var{
+			Name:   "commit-status-github-app-installation-id",
+			Target: &c.config.CommitStatus.GitHubAppInstallationID,
+			Usage:  "Installation ID of the GitHub App.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "commit-status-github-app-key-file",
+			Target: &c.config.CommitStatus.GitHubAppKeyFile,
+			Usage:  "Path to the PEM-encoded private key of the GitHub App.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "commit-status-gitlab-address",
+			Target: &c.config.CommitStatus.GitLabAddress,
+			Usage:  "Address of a self-managed GitLab to report commit statuses to.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "commit-status-gitlab-token",
+			Target: &c.config.CommitStatus.GitLabToken,
+			EnvVar: "GITLAB_TOKEN",
+			Usage: "GitLab token with the api scope used to report the status " +
+				"of jobs for the commits they ran.",
+		})
+
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:   "federation-downstream",
+			Target: &c.config.Federation.Servers,
+			Usage: "Downstream server that operations can be forwarded to, in the " +
+				"format NAME=ADDRESS. Operations name the server with " +
+				"-federation-server. This can be specified multiple times.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "federation-token",
+			Target: &c.config.Federation.Token,
+			Usage:  "Token used to queue operations on the downstream servers.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "federation-tls-disabled",
+			Target: &c.config.Federation.TLSDisabled,
+			Usage:  "Connect to the downstream servers without TLS.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "federation-tls-skip-verify",
+			Target: &c.config.Federation.TLSSkipVerify,
+			Usage:  "Don't verify the TLS certificates of the downstream servers.",
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:    "federation-poll-interval",
+			Target:  &c.config.Federation.PollInterval,
+			Usage:   "Time between checking the status of forwarded operations.",
+			Default: 10 * time.Second,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "snapshot-schedule",
+			Target: &c.config.Snapshot.Schedule,
+			Usage: "Take snapshots of the server state on this schedule in " +
+				"cron format, such as \"0 * * * *\" or \"@daily\". Times are in UTC.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "snapshot-path",
+			Target: &c.config.Snapshot.Path,
+			Usage: "Directory to write scheduled snapshots to. This is " +
+				"required if -snapshot-schedule is set.",
+		})
+
+		f.IntVar(&flag.IntVar{
+			Name:    "snapshot-retain",
+			Target:  &c.config.Snapshot.Retain,
+			Usage:   "Number of scheduled snapshots to keep. Older snapshots are deleted.",
+			Default: 7,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "backup-store",
+			Target: &c.config.Backup.Store,
+			Usage: "Upload backups of the server state to this object storage: " +
+				"\"s3\", \"gcs\", or \"azure\". Credentials are read from the " +
+				"environment, such as AWS_ACCESS_KEY_ID, GOOGLE_APPLICATION_CREDENTIALS, " +
+				"or AZURE_STORAGE_KEY. Backups are only uploaded if this is set.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "backup-schedule",
+			Target: &c.config.Backup.Schedule,
+			Usage: "Upload backups on this schedule in cron format, such as " +
+				"\"@daily\". Times are in UTC.",
+			Default: "@daily",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "backup-bucket",
+			Target: &c.config.Backup.Bucket,
+			Usage:  "Bucket, or container for Azure, to upload backups to.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "backup-prefix",
+			Target: &c.config.Backup.Prefix,
+			Usage:  "Prefix of the names of backups in the bucket, such as \"waypoint/\".",
+		})
+
+		f.IntVar(&flag.IntVar{
+			Name:    "backup-retain",
+			Target:  &c.config.Backup.Retain,
+			Usage:   "Number of backups to keep. Older backups are deleted.",
+			Default: 7,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "backup-region",
+			Target: &c.config.Backup.Region,
+			Usage:  "AWS region of the S3 bucket.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "backup-endpoint",
+			Target: &c.config.Backup.Endpoint,
+			Usage:  "Address of S3-compatible storage to upload backups to instead of AWS.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "backup-account",
+			Target: &c.config.Backup.Account,
+			Usage:  "Azure storage account to upload backups to.",
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:   "compact-interval",
+			Target: &c.config.Compact.Interval,
+			Usage: "Check if the database should be compacted to reclaim free space " +
+				"on this interval. The database is only compacted in the background " +
+				"if this is set.",
+		})
+
+		f.Float64Var(&flag.Float64Var{
+			Name:   "compact-threshold",
+			Target: &c.config.Compact.Threshold,
+			Usage: "Fraction of the database file that must be free for it to be " +
+				"compacted in the background.",
+			Default: 0.5,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "raft-id",
+			Target: &c.config.Raft.ID,
+			Usage: "Unique ID of this server in a cluster of servers that replicate " +
+				"their state with raft. The state is only replicated if this is set.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "raft-address",
+			Target: &c.config.Raft.Address,
+			Usage: "Address to listen on for raft traffic. This must be reachable " +
+				"by the other servers.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "raft-dir",
+			Target: &c.config.Raft.Dir,
+			Usage:  "Directory to store the raft log and snapshots in.",
+		})
+
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:   "raft-peer",
+			Target: &c.config.Raft.Peers,
+			Usage: "Another server in the cluster in the format ID=ADDRESS. This is " +
+				"used to bootstrap the cluster. This can be specified multiple times.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "raft-follower-reads",
+			Target: &c.config.Raft.FollowerReads,
+			Usage: "Serve reads of jobs on the gRPC listener while this server is " +
+				"a follower. Only requests that set a maximum staleness are served.",
+		})
+
 		f.BoolVar(&flag.BoolVar{
 			Name:    "disable-ui",
 			Target:  &c.flagDisableUI,
@@ -326,6 +1184,16 @@ func (c *ServerRunCommand) Flags() *flag.Sets {
 			Default: false,
 		})
 
+		f.BoolVar(&flag.BoolVar{
+			Name:   "metrics",
+			Target: &c.flagMetrics,
+			Usage: "Serve DORA metrics for each project and workspace, and " +
+				"scheduler metrics such as job counts and database latency, in the " +
+				"Prometheus text format at /metrics on the HTTP address. If " +
+				"authentication is enabled, requests require a token in the " +
+				"Authorization header.",
+		})
+
 		f.BoolVar(&flag.BoolVar{
 			Name:    "url-enabled",
 			Target:  &c.config.URL.Enabled,
@@ -430,6 +1298,82 @@ Usage: waypoint server run [options]
 ` + c.Flags().Help())
 }
 
+// auditExporter returns the exporter for audit events. This returns nil
+// if audit export isn't configured.
+func (c *ServerRunCommand) auditExporter(log hclog.Logger) (*audit.Exporter, error) {
+	cfg := c.config.Audit
+	if cfg == nil || (cfg.Address == "" && cfg.Path == "") {
+		return nil, nil
+	}
+
+	format, err := audit.ParseFormat(cfg.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	var sinks []io.WriteCloser
+	if cfg.Address != "" {
+		sink, err := audit.NewNetSink(cfg.Address)
+		if err != nil {
+			return nil, err
+		}
+
+		sinks = append(sinks, sink)
+	}
+	if cfg.Path != "" {
+		sink, err := audit.NewFileSink(cfg.Path, cfg.MaxBytes, cfg.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+
+		sinks = append(sinks, sink)
+	}
+
+	return audit.NewExporter(log, &audit.Formatter{
+		Format:  format,
+		Version: version.GetVersion().VersionNumber(),
+	}, 0, sinks...), nil
+}
+
+// serveFollower serves the reads of a raft follower on the gRPC listener.
+// The returned function stops serving and closes the listener so that the
+// server can listen on it once it's the leader.
+func (c *ServerRunCommand) serveFollower(
+	ctx context.Context,
+	log hclog.Logger,
+	impl pb.WaypointServer,
+) func() {
+	ln, err := c.listenerForConfig(log, &c.config.GRPC)
+	if err != nil {
+		log.Error("error starting listener for follower reads", "err", err)
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		options := []server.Option{
+			server.WithContext(ctx),
+			server.WithLogger(log),
+			server.WithGRPC(ln),
+			server.WithImpl(impl),
+		}
+		if ac, ok := impl.(server.AuthChecker); ok {
+			options = append(options, server.WithAuthentication(ac))
+		}
+
+		log.Info("serving reads while this server is a raft follower")
+		server.Run(options...)
+	}()
+
+	return func() {
+		cancel()
+		<-doneCh
+		ln.Close()
+	}
+}
+
 func (c *ServerRunCommand) listenerForConfig(log hclog.Logger, cfg *serverconfig.Listener) (net.Listener, error) {
 	// Start our bare listener
 	log.Debug("starting listener", "addr", cfg.Addr)
@@ -438,69 +1382,89 @@ func (c *ServerRunCommand) listenerForConfig(log hclog.Logger, cfg *serverconfig
 		return nil, err
 	}
 
+	fips := c.config.FIPS || tlsconfig.FIPSBuild()
+
 	// If we have TLS disabled then we're done.
 	if cfg.TLSDisable {
+		if fips {
+			ln.Close()
+			return nil, fmt.Errorf("TLS can't be disabled in FIPS mode")
+		}
+
 		log.Warn("TLS is disabled for this listener")
 		return ln, nil
 	}
 
-	// If we don't have a cert then we self-sign.
-	var certPEM, keyPEM []byte
+	// Restrict the TLS versions and algorithms to our policy.
+	tlsConfig := &tls.Config{}
+	if err := tlsconfig.Apply(tlsConfig, &tlsconfig.Policy{
+		MinVersion:   cfg.TLSMinVersion,
+		CipherSuites: cfg.TLSCipherSuites,
+		FIPS:         fips,
+	}); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	if fips {
+		log.Info("FIPS mode enabled, TLS is restricted to FIPS-approved algorithms")
+	}
+
+	// If we have a cert, load it and reload it whenever it changes.
 	if cfg.TLSCertFile != "" {
-		certPEM, err = ioutil.ReadFile(cfg.TLSCertFile)
-		if err != nil {
-			return nil, err
-		}
-		keyPEM, err = ioutil.ReadFile(cfg.TLSKeyFile)
+		reloader, err := tlsconfig.NewCertReloader(cfg.TLSCertFile, cfg.TLSKeyFile)
 		if err != nil {
+			ln.Close()
 			return nil, err
 		}
+		go reloader.Watch(c.Ctx, cfg.TLSReloadInterval, log)
+		tlsConfig.GetCertificate = reloader.GetCertificate
 
 		log.Info("TLS certs loaded from specified files",
 			"cert", cfg.TLSCertFile,
 			"key", cfg.TLSKeyFile)
+		log.Info("listener is wrapped with TLS")
+		return tls.NewListener(ln, tlsConfig), nil
 	}
 
-	if certPEM == nil {
-		log.Info("TLS cert wasn't specified, a self-signed certificate will be created")
+	// If we don't have a cert then we self-sign.
+	log.Info("TLS cert wasn't specified, a self-signed certificate will be created")
 
-		priv, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
-		if err != nil {
-			return nil, err
-		}
+	priv, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
 
-		template := x509.Certificate{
-			SerialNumber: big.NewInt(1),
-			Subject: pkix.Name{
-				Organization: []string{"Waypoint"},
-			},
-			NotBefore:             time.Now(),
-			NotAfter:              time.Now().Add(time.Hour * 24 * 365 * 10),
-			KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
-			ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
-			BasicConstraintsValid: true,
-		}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			Organization: []string{"Waypoint"},
+		},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour * 24 * 365 * 10),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
 
-		derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, publicKey(priv), priv)
-		if err != nil {
-			return nil, err
-		}
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, publicKey(priv), priv)
+	if err != nil {
+		return nil, err
+	}
 
-		// Write the cert
-		var out bytes.Buffer
-		err = pem.Encode(&out, &pem.Block{Type: "CERTIFICATE", Bytes: derBytes})
-		if err != nil {
-			return nil, err
-		}
-		certPEM = out.Bytes()
+	// Write the cert
+	var out bytes.Buffer
+	err = pem.Encode(&out, &pem.Block{Type: "CERTIFICATE", Bytes: derBytes})
+	if err != nil {
+		return nil, err
+	}
+	certPEM := out.Bytes()
 
-		// Write the key
-		out = bytes.Buffer{}
-		if err := pem.Encode(&out, pemBlockForKey(priv)); err != nil {
-			return nil, err
-		}
-		keyPEM = out.Bytes()
+	// Write the key
+	out = bytes.Buffer{}
+	if err := pem.Encode(&out, pemBlockForKey(priv)); err != nil {
+		return nil, err
 	}
+	keyPEM := out.Bytes()
 
 	// Setup the TLS listener
 	cert, err := tls.X509KeyPair(certPEM, keyPEM)
@@ -510,9 +1474,50 @@ func (c *ServerRunCommand) listenerForConfig(log hclog.Logger, cfg *serverconfig
 	}
 
 	log.Info("listener is wrapped with TLS")
-	return tls.NewListener(ln, &tls.Config{
-		Certificates: []tls.Certificate{cert},
-	}), nil
+	tlsConfig.Certificates = []tls.Certificate{cert}
+	return tls.NewListener(ln, tlsConfig), nil
+}
+
+// dispatchScheduler returns the scheduler that jobs are dispatched to.
+// The scheduler clients are configured from the environment the same way
+// as the nomad and kubectl CLIs.
+func dispatchScheduler(cfg *serverconfig.Dispatch) (dispatch.Scheduler, error) {
+	switch cfg.Scheduler {
+	case "nomad":
+		s, err := nomad.New()
+		if err != nil {
+			return nil, err
+		}
+
+		s.Namespace = cfg.Namespace
+		return s, nil
+
+	case "kubernetes":
+		return k8s.New(cfg.Namespace)
+
+	default:
+		return nil, fmt.Errorf("unknown scheduler %q", cfg.Scheduler)
+	}
+}
+
+func newBackupStore(ctx context.Context, cfg *serverconfig.Backup) (backup.Store, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("a bucket must be set for backups")
+	}
+
+	switch cfg.Store {
+	case "s3":
+		return s3backup.New(cfg.Bucket, cfg.Region, cfg.Endpoint)
+
+	case "gcs":
+		return gcsbackup.New(ctx, cfg.Bucket)
+
+	case "azure":
+		return azurebackup.New(cfg.Account, os.Getenv("AZURE_STORAGE_KEY"), cfg.Bucket)
+
+	default:
+		return nil, fmt.Errorf("unknown backup store %q", cfg.Store)
+	}
 }
 
 func publicKey(priv interface{}) interface{} {
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+)
+
+type ServerTelemetryCommand struct {
+	*baseCommand
+}
+
+func (c *ServerTelemetryCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	resp, err := c.project.Client().GetTelemetryReport(c.Ctx, &empty.Empty{})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	if resp.Enabled {
+		c.ui.Output("Telemetry is enabled and sent to %s", resp.Endpoint,
+			terminal.WithInfoStyle())
+	} else {
+		c.ui.Output("Telemetry is disabled. The report below is never sent.",
+			terminal.WithInfoStyle())
+	}
+
+	// The report is sent compact, indent it so it is easier to read.
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(resp.Report), "", "  "); err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.ui.Output(buf.String())
+	return 0
+}
+
+func (c *ServerTelemetryCommand) Flags() *flag.Sets {
+	return c.flagSet(0, nil)
+}
+
+func (c *ServerTelemetryCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ServerTelemetryCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *ServerTelemetryCommand) Synopsis() string {
+	return "Show the anonymous usage telemetry collected by the server."
+}
+
+func (c *ServerTelemetryCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint server telemetry [options]
+
+  Show the anonymous usage telemetry collected by the server.
+
+  This outputs exactly what the server sends for the current period if
+  telemetry is enabled. Telemetry is disabled by default and can be enabled
+  with the -telemetry and -telemetry-endpoint flags of "waypoint server run".
+
+`)
+}
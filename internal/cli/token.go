@@ -16,6 +16,8 @@ type GetInviteCommand struct {
 	*baseCommand
 
 	duration time.Duration
+	user     string
+	roles    []string
 }
 
 func (c *GetInviteCommand) Run(args []string) int {
@@ -33,6 +35,8 @@ func (c *GetInviteCommand) Run(args []string) int {
 
 	resp, err := client.GenerateInviteToken(c.Ctx, &pb.InviteTokenRequest{
 		Duration: c.duration.String(),
+		User:     c.user,
+		Roles:    c.roles,
 	})
 	if err != nil {
 		c.project.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
@@ -52,6 +56,20 @@ func (c *GetInviteCommand) Flags() *flag.Sets {
 			Usage:   "How long the invite token will valid for, starting now.",
 			Default: 5 * time.Minute,
 		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "user",
+			Target: &c.user,
+			Usage: "User to invite. The token is for this user rather than the " +
+				"default user, and can't manage the server.",
+		})
+
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:   "role",
+			Target: &c.roles,
+			Usage: "Role of the invited user, such as \"sre\". Roles restrict who can " +
+				"approve jobs for protected workspaces. This can be specified multiple times.",
+		})
 	})
 }
 
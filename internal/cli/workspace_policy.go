@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+type WorkspacePolicyCommand struct {
+	*baseCommand
+}
+
+func (c *WorkspacePolicyCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	policy, err := c.project.Client().GetWorkspacePolicy(c.Ctx, &pb.GetWorkspacePolicyRequest{
+		Workspace: c.refWorkspace.Workspace,
+	})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.outputWorkspacePolicy(policy)
+	return 0
+}
+
+func (c *WorkspacePolicyCommand) Flags() *flag.Sets {
+	return c.flagSet(0, nil)
+}
+
+func (c *WorkspacePolicyCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *WorkspacePolicyCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *WorkspacePolicyCommand) Synopsis() string {
+	return "Show the protection policy of a workspace."
+}
+
+func (c *WorkspacePolicyCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint workspace policy [options]
+
+  Show the protection policy of the workspace set with "-workspace".
+
+` + c.Flags().Help())
+}
+
+func (c *baseCommand) outputWorkspacePolicy(policy *pb.WorkspacePolicy) {
+	if policy.RequiredApprovals == 0 {
+		c.ui.Output("Workspace %q is not protected.", policy.Workspace)
+		return
+	}
+
+	roles := "any user"
+	if len(policy.ApproverRoles) > 0 {
+		roles = strings.Join(policy.ApproverRoles, ", ")
+	}
+
+	c.ui.NamedValues([]terminal.NamedValue{
+		{Name: "Workspace", Value: policy.Workspace},
+		{Name: "Required Approvals", Value: strconv.FormatUint(uint64(policy.RequiredApprovals), 10)},
+		{Name: "Approver Roles", Value: roles},
+	})
+}
+
+type WorkspaceProtectCommand struct {
+	*baseCommand
+
+	flagApprovals int
+	flagRoles     []string
+}
+
+func (c *WorkspaceProtectCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	if c.flagApprovals < 0 {
+		c.ui.Output("-approvals must not be negative", terminal.WithErrorStyle())
+		return 1
+	}
+
+	policy, err := c.project.Client().SetWorkspacePolicy(c.Ctx, &pb.SetWorkspacePolicyRequest{
+		Policy: &pb.WorkspacePolicy{
+			Workspace:         c.refWorkspace.Workspace,
+			RequiredApprovals: uint32(c.flagApprovals),
+			ApproverRoles:     c.flagRoles,
+		},
+	})
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.outputWorkspacePolicy(policy)
+	return 0
+}
+
+func (c *WorkspaceProtectCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.IntVar(&flag.IntVar{
+			Name:    "approvals",
+			Target:  &c.flagApprovals,
+			Usage:   "Number of distinct users that must approve deploys and releases. Set to 0 to unprotect the workspace.",
+			Default: 1,
+		})
+
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:   "approver-role",
+			Target: &c.flagRoles,
+			Usage: "Role allowed to approve jobs. If this isn't set, any user can " +
+				"approve. This can be specified multiple times.",
+		})
+	})
+}
+
+func (c *WorkspaceProtectCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *WorkspaceProtectCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *WorkspaceProtectCommand) Synopsis() string {
+	return "Require approval for deploys and releases to a workspace."
+}
+
+func (c *WorkspaceProtectCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint workspace protect [options]
+
+  Require approval for deploys and releases to the workspace set with
+  "-workspace".
+
+  Deploy and release jobs queued for a protected workspace stay queued
+  until the required number of distinct users approve them with
+  "waypoint job approve". Approvers must use an API key with one of the
+  approver roles. Tokens that aren't API keys belong to the default user,
+  which has no roles.
+
+` + c.Flags().Help())
+}
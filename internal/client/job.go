@@ -33,6 +33,13 @@ func (c *Project) job() *pb.Job {
 			},
 		},
 		DataSourceOverrides: c.dataSourceOverrides,
+		Priority:            c.priority,
+		Resources:           c.resources,
+		Plugins:             c.plugins,
+		Affinity:            c.affinity,
+		Platform:            c.platform,
+		RequiredTags:        c.requiredTags,
+		Locality:            c.locality,
 
 		Operation: &pb.Job_Noop_{
 			Noop: &pb.Job_Noop{},
@@ -45,6 +52,10 @@ func (c *Project) job() *pb.Job {
 		job.DataSource = nil
 	}
 
+	if c.federationServer != "" {
+		job.Federation = &pb.Job_Federation{Server: c.federationServer}
+	}
+
 	return job
 }
 
@@ -295,6 +306,8 @@ func (c *Project) queueAndStreamJob(
 								Color: ent.Color,
 							})
 						}
+
+						tbl.Rows = append(tbl.Rows, trow)
 					}
 
 					ui.Table(tbl)
@@ -346,6 +359,11 @@ func (c *Project) queueAndStreamJob(
 					c.logger.Error("Unknown terminal event seen", "type", hclog.Fmt("%T", ev))
 				}
 			}
+		case *pb.GetJobStreamResponse_Progress:
+			// The steps are already shown with the terminal output.
+			log.Trace("job progress", "step", event.Progress.Step,
+				"percent", event.Progress.Percent)
+
 		case *pb.GetJobStreamResponse_State_:
 			// Stop any state event timers if we have any since the state
 			// has changed and we don't want to output that information anymore.
@@ -358,13 +376,48 @@ func (c *Project) queueAndStreamJob(
 			// the wait time ends up being long.
 			switch event.State.Current {
 			case pb.Job_QUEUED:
+				// Jobs for protected workspaces wait for approval. We tell
+				// the user right away since this won't resolve on its own.
+				if job := event.State.Job; job != nil && job.ApprovalRequired != nil &&
+					uint32(len(job.Approvals)) < job.ApprovalRequired.Approvals {
+					ui.Output("Operation requires %d approval(s) because the workspace is protected.",
+						job.ApprovalRequired.Approvals, terminal.WithHeaderStyle())
+					ui.Output("Approve it with: waypoint job approve %s", job.Id,
+						terminal.WithInfoStyle())
+					break
+				}
+
 				stateEventTimer = time.AfterFunc(stateEventPause, func() {
 					ui.Output("Operation is queued. Waiting for runner assignment...",
 						terminal.WithHeaderStyle())
+
+					// Show how many jobs are ahead of this one if we can.
+					// This is best effort so errors are only logged.
+					resp, err := c.client.GetJobQueuePosition(ctx, &pb.GetJobQueuePositionRequest{
+						JobId: queueResp.JobId,
+					})
+					if err != nil {
+						log.Debug("error getting the queue position", "err", err)
+					} else if resp.JobsAhead > 0 {
+						ui.Output("There are %d queued job(s) ahead of this one.", resp.JobsAhead,
+							terminal.WithInfoStyle())
+					}
 					ui.Output("If you interrupt this command, the job will still run in the background.",
 						terminal.WithInfoStyle())
 				})
 
+			case pb.Job_DEAD_LETTER:
+				// Dead-lettered jobs wait to be requeued, which won't
+				// happen on its own.
+				msg := "not accepted by runners too many times"
+				if job := event.State.Job; job != nil && job.Error != nil {
+					msg = job.Error.Message
+				}
+				ui.Output("Operation moved to the dead-letter queue: %s", msg,
+					terminal.WithErrorStyle())
+				ui.Output("It will run once it is requeued, or cancel it to stop waiting.",
+					terminal.WithInfoStyle())
+
 			case pb.Job_WAITING:
 				stateEventTimer = time.AfterFunc(stateEventPause, func() {
 					ui.Output("Operation is assigned to a runner. Waiting for start...",
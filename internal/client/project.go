@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"errors"
 
 	"github.com/hashicorp/go-hclog"
 
@@ -24,6 +25,14 @@ type Project struct {
 	runner              *pb.Ref_Runner
 	labels              map[string]string
 	dataSourceOverrides map[string]string
+	federationServer    string
+	priority            int32
+	resources           *pb.Resources
+	plugins             []string
+	affinity            *pb.Job_Affinity
+	platform            string
+	requiredTags        []string
+	locality            *pb.Job_Locality
 	cleanupFunc         func()
 
 	local bool
@@ -180,6 +189,122 @@ func WithSourceOverrides(m map[string]string) Option {
 	}
 }
 
+// WithFederationServer forwards queued jobs to the downstream server with
+// the given name. The server must be configured for federation.
+func WithFederationServer(name string) Option {
+	return func(c *Project, cfg *config) error {
+		c.federationServer = name
+		return nil
+	}
+}
+
+// WithPriority sets the priority of queued jobs. Jobs with a higher
+// priority are assigned to runners first.
+func WithPriority(p int32) Option {
+	return func(c *Project, cfg *config) error {
+		c.priority = p
+		return nil
+	}
+}
+
+// WithResources sets the CPU in millicores and memory in megabytes that
+// queued jobs require. Jobs are only run by runners with enough capacity.
+// Zero values require nothing.
+func WithResources(cpu, memory int64) Option {
+	return func(c *Project, cfg *config) error {
+		if cpu > 0 || memory > 0 {
+			c.resources = &pb.Resources{Cpu: cpu, Memory: memory}
+		}
+
+		return nil
+	}
+}
+
+// WithPlugins sets the names of the plugins that queued jobs use. Jobs
+// are only run by runners that have all of them.
+func WithPlugins(names []string) Option {
+	return func(c *Project, cfg *config) error {
+		c.plugins = names
+		return nil
+	}
+}
+
+// WithAffinity sets the runner affinity of queued jobs. If a is nil,
+// jobs can be assigned to any runner they target.
+func WithAffinity(a *pb.Job_Affinity) Option {
+	return func(c *Project, cfg *config) error {
+		c.affinity = a
+		return nil
+	}
+}
+
+// WithPlatform sets the platform that queued jobs must run on, such as
+// "linux/amd64". If this is empty, jobs can run on any platform.
+func WithPlatform(v string) Option {
+	return func(c *Project, cfg *config) error {
+		c.platform = v
+		return nil
+	}
+}
+
+// WithRequiredTags sets the resource tags that the runners of queued jobs
+// must have, such as "gpu". If no tags are given, jobs can run on any
+// runner.
+func WithRequiredTags(tags []string) Option {
+	return func(c *Project, cfg *config) error {
+		c.requiredTags = tags
+		return nil
+	}
+}
+
+// WithLocality sets the locality that queued jobs prefer to run in, such
+// as the region of the deployment target. If l is nil, jobs don't prefer
+// runners in any locality.
+func WithLocality(l *pb.Job_Locality) Option {
+	return func(c *Project, cfg *config) error {
+		c.locality = l
+		return nil
+	}
+}
+
+// WithRunnerLabels targets queued jobs at runners that have all of the
+// given labels. If no labels are given, jobs target any runner.
+func WithRunnerLabels(m map[string]string) Option {
+	return func(c *Project, cfg *config) error {
+		if len(m) == 0 {
+			return nil
+		}
+
+		c.runner = &pb.Ref_Runner{
+			Target: &pb.Ref_Runner_Labels{
+				Labels: &pb.Ref_RunnerLabels{Labels: m},
+			},
+		}
+		return nil
+	}
+}
+
+// WithRunnerPool targets queued jobs at the runners in the pool with the
+// given name. If the name is empty, this does nothing. This can't be used
+// with WithRunnerLabels.
+func WithRunnerPool(name string) Option {
+	return func(c *Project, cfg *config) error {
+		if name == "" {
+			return nil
+		}
+		if c.runner != nil {
+			return errors.New("jobs can't target both runner labels and a runner pool")
+		}
+
+		c.runner = &pb.Ref_Runner{
+			Target: &pb.Ref_Runner_Pool{
+				Pool: &pb.Ref_RunnerPool{Name: name},
+			},
+		}
+		return nil
+	}
+}
+
 // WithLocal puts the client in local exec mode. In this mode, the client
 // will spin up a per-operation runner locally and reference the local on-disk
 // data for all operations.
@@ -0,0 +1,171 @@
+// Package commitstatus reports the progress and result of jobs back to
+// the Git hosting service of the commit the job ran, as GitHub commit
+// statuses or check runs, or GitLab commit statuses. This lets pull
+// requests show whether the builds and deploys of their commits
+// succeeded.
+package commitstatus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// State is the state of a commit status.
+type State string
+
+const (
+	StatePending State = "pending"
+	StateSuccess State = "success"
+	StateFailure State = "failure"
+)
+
+// Status is a status to report for a commit.
+type Status struct {
+	// RepoURL is the clone URL of the repository, in either URL or SCP
+	// format, such as "git@github.com:hashicorp/waypoint.git".
+	RepoURL string
+
+	// Commit is the full SHA of the commit.
+	Commit string
+
+	State State
+
+	// Name identifies the status, such as "waypoint/web/deploy". Statuses
+	// with the same name replace each other.
+	Name string
+
+	// Description is a short description of the status.
+	Description string
+}
+
+// Provider reports statuses to a Git hosting service.
+type Provider interface {
+	// Host is the host name of the Git URLs of the service.
+	Host() string
+
+	// Report reports the status of a commit of the repository at the
+	// given path on the host, such as "hashicorp/waypoint".
+	Report(ctx context.Context, repo string, st *Status) error
+}
+
+// Reporter reports statuses to the provider for the repository.
+type Reporter struct {
+	Providers []Provider
+}
+
+// Report reports the status. This returns false if no provider supports
+// the repository.
+func (r *Reporter) Report(ctx context.Context, st *Status) (bool, error) {
+	host, repo, err := ParseRepoURL(st.RepoURL)
+	if err != nil {
+		return false, err
+	}
+
+	for _, p := range r.Providers {
+		if strings.EqualFold(p.Host(), host) {
+			return true, p.Report(ctx, repo, st)
+		}
+	}
+
+	return false, nil
+}
+
+// ParseRepoURL returns the host and repository path of a Git URL. The
+// URL can be a URL such as "https://github.com/hashicorp/waypoint.git" or
+// an SCP-like address such as "git@github.com:hashicorp/waypoint.git".
+func ParseRepoURL(raw string) (string, string, error) {
+	var host, path string
+	if strings.Contains(raw, "://") {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return "", "", err
+		}
+
+		host, path = u.Hostname(), u.Path
+	} else if idx := strings.Index(raw, ":"); idx > 0 {
+		host, path = raw[:idx], raw[idx+1:]
+		if at := strings.LastIndex(host, "@"); at >= 0 {
+			host = host[at+1:]
+		}
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if host == "" || path == "" {
+		return "", "", fmt.Errorf("unsupported repository URL: %s", raw)
+	}
+
+	return host, path, nil
+}
+
+// description truncates the description to the maximum length that
+// GitHub accepts.
+func description(s string) string {
+	const max = 140
+	if len(s) <= max {
+		return s
+	}
+
+	return s[:max-3] + "..."
+}
+
+// doJSON sends a request with a JSON body and decodes the JSON response
+// into out if it isn't nil.
+func doJSON(
+	ctx context.Context,
+	client *http.Client,
+	method, url string,
+	header http.Header,
+	in, out interface{},
+) error {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s returned status %d: %s",
+			method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
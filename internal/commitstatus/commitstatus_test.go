@@ -0,0 +1,180 @@
+package commitstatus
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRepoURL(t *testing.T) {
+	cases := []struct {
+		URL  string
+		Host string
+		Repo string
+	}{
+		{"https://github.com/hashicorp/waypoint.git", "github.com", "hashicorp/waypoint"},
+		{"https://github.com/hashicorp/waypoint", "github.com", "hashicorp/waypoint"},
+		{"git@github.com:hashicorp/waypoint.git", "github.com", "hashicorp/waypoint"},
+		{"ssh://git@gitlab.com:22/group/sub/project.git", "gitlab.com", "group/sub/project"},
+		{"/tmp/repo", "", ""},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.URL, func(t *testing.T) {
+			host, repo, err := ParseRepoURL(tt.URL)
+			if tt.Host == "" {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.Host, host)
+			require.Equal(t, tt.Repo, repo)
+		})
+	}
+}
+
+// testRequest is a request received by testServer.
+type testRequest struct {
+	Path   string
+	Header http.Header
+	Body   map[string]interface{}
+}
+
+func testServer(t *testing.T, reqs *[]testRequest) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := testRequest{Path: r.URL.EscapedPath(), Header: r.Header}
+		json.NewDecoder(r.Body).Decode(&req.Body)
+		*reqs = append(*reqs, req)
+
+		if strings.HasSuffix(r.URL.Path, "/access_tokens") {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"token":      "installation-token",
+				"expires_at": time.Now().Add(time.Hour),
+			})
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("{}"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGitHub_status(t *testing.T) {
+	require := require.New(t)
+
+	var reqs []testRequest
+	srv := testServer(t, &reqs)
+
+	r := &Reporter{Providers: []Provider{&GitHub{Address: srv.URL, Token: "secret"}}}
+	ok, err := r.Report(context.Background(), &Status{
+		RepoURL:     srv.URL + "/acme/web.git",
+		Commit:      "abc123",
+		State:       StateFailure,
+		Name:        "waypoint/web/deploy",
+		Description: strings.Repeat("x", 200),
+	})
+	require.NoError(err)
+	require.True(ok)
+
+	require.Len(reqs, 1)
+	require.Equal("/api/v3/repos/acme/web/statuses/abc123", reqs[0].Path)
+	require.Equal("token secret", reqs[0].Header.Get("Authorization"))
+	require.Equal("failure", reqs[0].Body["state"])
+	require.Equal("waypoint/web/deploy", reqs[0].Body["context"])
+	require.Len(reqs[0].Body["description"], 140)
+
+	// Other hosts aren't reported
+	ok, err = r.Report(context.Background(), &Status{
+		RepoURL: "https://example.com/acme/web.git",
+		Commit:  "abc123",
+	})
+	require.NoError(err)
+	require.False(ok)
+}
+
+func TestGitHub_checkRun(t *testing.T) {
+	require := require.New(t)
+
+	var reqs []testRequest
+	srv := testServer(t, &reqs)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+	parsed, err := ParseGitHubAppKey(keyPEM)
+	require.NoError(err)
+
+	gh := &GitHub{
+		Address: srv.URL,
+		App:     &GitHubApp{ID: 42, InstallationID: 7, PrivateKey: parsed},
+	}
+
+	for _, state := range []State{StatePending, StateSuccess} {
+		require.NoError(gh.Report(context.Background(), "acme/web", &Status{
+			Commit:      "abc123",
+			State:       state,
+			Name:        "waypoint/web/deploy",
+			Description: "deploying",
+		}))
+	}
+
+	// The installation token is created once and reused
+	require.Len(reqs, 3)
+	require.Equal("/api/v3/app/installations/7/access_tokens", reqs[0].Path)
+	require.Equal("/api/v3/repos/acme/web/check-runs", reqs[1].Path)
+	require.Equal("token installation-token", reqs[1].Header.Get("Authorization"))
+	require.Equal("in_progress", reqs[1].Body["status"])
+	require.Equal("completed", reqs[2].Body["status"])
+	require.Equal("success", reqs[2].Body["conclusion"])
+
+	// The JWT is signed by the App key
+	jwt := strings.TrimPrefix(reqs[0].Header.Get("Authorization"), "Bearer ")
+	parts := strings.Split(jwt, ".")
+	require.Len(parts, 3)
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(err)
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	require.NoError(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, sum[:], sig))
+
+	claims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(err)
+	require.Contains(string(claims), `"iss":42`)
+}
+
+func TestGitLab(t *testing.T) {
+	require := require.New(t)
+
+	var reqs []testRequest
+	srv := testServer(t, &reqs)
+
+	gl := &GitLab{Address: srv.URL, Token: "secret"}
+	require.NoError(gl.Report(context.Background(), "group/sub/web", &Status{
+		Commit: "abc123",
+		State:  StatePending,
+		Name:   "waypoint/web/build",
+	}))
+
+	require.Len(reqs, 1)
+	require.Equal("/api/v4/projects/group%2Fsub%2Fweb/statuses/abc123", reqs[0].Path)
+	require.Equal("secret", reqs[0].Header.Get("PRIVATE-TOKEN"))
+	require.Equal("running", reqs[0].Body["state"])
+}
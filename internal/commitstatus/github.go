@@ -0,0 +1,232 @@
+package commitstatus
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultGitHubAddress is the address of GitHub.
+const DefaultGitHubAddress = "https://github.com"
+
+// GitHub reports statuses to GitHub or GitHub Enterprise. If App is set,
+// statuses are reported as check runs. Otherwise they're reported as
+// commit statuses with Token.
+type GitHub struct {
+	// Address is the web address of GitHub Enterprise. This defaults to
+	// DefaultGitHubAddress.
+	Address string
+
+	// Token is a token with access to commit statuses of the repositories.
+	Token string
+
+	// App is a GitHub App installed for the repositories with
+	// permission to write checks.
+	App *GitHubApp
+
+	HTTPClient *http.Client
+}
+
+// GitHubApp is the credentials of a GitHub App installation.
+type GitHubApp struct {
+	ID             int64
+	InstallationID int64
+	PrivateKey     *rsa.PrivateKey
+
+	lock    sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// ParseGitHubAppKey parses the PEM-encoded private key of a GitHub App.
+func ParseGitHubAppKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("GitHub App private key is not PEM-encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	raw, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing GitHub App private key: %w", err)
+	}
+
+	key, ok := raw.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("GitHub App private key must be an RSA key")
+	}
+
+	return key, nil
+}
+
+// Host implements Provider
+func (g *GitHub) Host() string {
+	u, err := url.Parse(g.address())
+	if err != nil {
+		return ""
+	}
+
+	return u.Hostname()
+}
+
+// Report implements Provider
+func (g *GitHub) Report(ctx context.Context, repo string, st *Status) error {
+	if g.App != nil {
+		return g.checkRun(ctx, repo, st)
+	}
+
+	return g.status(ctx, repo, st)
+}
+
+// status creates a commit status.
+func (g *GitHub) status(ctx context.Context, repo string, st *Status) error {
+	header := http.Header{}
+	header.Set("Authorization", "token "+g.Token)
+	header.Set("Accept", "application/vnd.github.v3+json")
+
+	return doJSON(ctx, g.HTTPClient, "POST",
+		fmt.Sprintf("%s/repos/%s/statuses/%s", g.apiAddress(), repo, st.Commit),
+		header,
+		map[string]string{
+			"state":       string(st.State),
+			"context":     st.Name,
+			"description": description(st.Description),
+		},
+		nil,
+	)
+}
+
+// checkRun creates a check run. Check runs with the same name for a
+// commit are shown as a single check with the latest run.
+func (g *GitHub) checkRun(ctx context.Context, repo string, st *Status) error {
+	token, err := g.installationToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	body := map[string]interface{}{
+		"name":     st.Name,
+		"head_sha": st.Commit,
+		"output": map[string]string{
+			"title":   description(st.Description),
+			"summary": st.Description,
+		},
+	}
+	switch st.State {
+	case StatePending:
+		body["status"] = "in_progress"
+
+	default:
+		body["status"] = "completed"
+		body["conclusion"] = string(st.State)
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "token "+token)
+	header.Set("Accept", "application/vnd.github.v3+json")
+
+	return doJSON(ctx, g.HTTPClient, "POST",
+		fmt.Sprintf("%s/repos/%s/check-runs", g.apiAddress(), repo),
+		header, body, nil)
+}
+
+// installationToken returns a token for the App installation, creating
+// a new one if the previous token expires soon.
+func (g *GitHub) installationToken(ctx context.Context) (string, error) {
+	app := g.App
+	app.lock.Lock()
+	defer app.lock.Unlock()
+
+	if app.token != "" && time.Until(app.expires) > 5*time.Minute {
+		return app.token, nil
+	}
+
+	jwt, err := app.jwt(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+jwt)
+	header.Set("Accept", "application/vnd.github.v3+json")
+
+	var resp struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	err = doJSON(ctx, g.HTTPClient, "POST",
+		fmt.Sprintf("%s/app/installations/%d/access_tokens", g.apiAddress(), app.InstallationID),
+		header, nil, &resp)
+	if err != nil {
+		return "", fmt.Errorf("error creating GitHub App installation token: %w", err)
+	}
+
+	app.token = resp.Token
+	app.expires = resp.ExpiresAt
+	return app.token, nil
+}
+
+// jwt returns a JWT that authenticates as the App.
+func (app *GitHubApp) jwt(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+
+	// GitHub allows a maximum lifetime of 10 minutes. We backdate the
+	// token to allow for clock drift.
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": app.ID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	signed := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, app.PrivateKey, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+
+	return signed + "." + enc.EncodeToString(sig), nil
+}
+
+func (g *GitHub) address() string {
+	if g.Address == "" {
+		return DefaultGitHubAddress
+	}
+
+	return strings.TrimSuffix(g.Address, "/")
+}
+
+// apiAddress returns the API address. GitHub Enterprise serves the API
+// under the web address.
+func (g *GitHub) apiAddress() string {
+	addr := g.address()
+	if addr == DefaultGitHubAddress {
+		return "https://api.github.com"
+	}
+
+	return addr + "/api/v3"
+}
+
+var _ Provider = (*GitHub)(nil)
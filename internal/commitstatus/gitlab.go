@@ -0,0 +1,71 @@
+package commitstatus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultGitLabAddress is the address of GitLab.com.
+const DefaultGitLabAddress = "https://gitlab.com"
+
+// GitLab reports commit statuses to GitLab.
+type GitLab struct {
+	// Address is the address of a self-managed GitLab. This defaults to
+	// DefaultGitLabAddress.
+	Address string
+
+	// Token is an access token with the api scope.
+	Token string
+
+	HTTPClient *http.Client
+}
+
+// Host implements Provider
+func (g *GitLab) Host() string {
+	u, err := url.Parse(g.address())
+	if err != nil {
+		return ""
+	}
+
+	return u.Hostname()
+}
+
+// Report implements Provider
+func (g *GitLab) Report(ctx context.Context, repo string, st *Status) error {
+	// GitLab has its own names for states.
+	state := "running"
+	switch st.State {
+	case StateSuccess:
+		state = "success"
+	case StateFailure:
+		state = "failed"
+	}
+
+	header := http.Header{}
+	header.Set("PRIVATE-TOKEN", g.Token)
+
+	return doJSON(ctx, g.HTTPClient, "POST",
+		fmt.Sprintf("%s/api/v4/projects/%s/statuses/%s",
+			g.address(), url.PathEscape(repo), st.Commit),
+		header,
+		map[string]string{
+			"state":       state,
+			"name":        st.Name,
+			"description": description(st.Description),
+		},
+		nil,
+	)
+}
+
+func (g *GitLab) address() string {
+	if g.Address == "" {
+		return DefaultGitLabAddress
+	}
+
+	return strings.TrimSuffix(g.Address, "/")
+}
+
+var _ Provider = (*GitLab)(nil)
@@ -182,3 +182,24 @@ type testPluginBuildConfig struct {
 func (p *testPluginBuildConfig) Config() (interface{}, error) {
 	return &p.config, nil
 }
+
+func TestConfigApp_variables(t *testing.T) {
+	require := require.New(t)
+
+	cfg, err := Load(filepath.Join("testdata", "compare", "app_variables.hcl"), "")
+	require.NoError(err)
+
+	// Without the variables the app fails to load
+	_, err = cfg.App("bar", nil)
+	require.Error(err)
+
+	require.NoError(cfg.SetVariables(map[string]string{
+		"network_vpc_id": `"vpc-1234"`,
+		"subnets":        `["subnet-a","subnet-b"]`,
+	}))
+
+	app, err := cfg.App("bar", nil)
+	require.NoError(err)
+	require.Equal("vpc-1234", app.Labels["vpc"])
+	require.Equal("subnet-b", app.Labels["subnet"])
+}
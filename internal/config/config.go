@@ -7,6 +7,7 @@ import (
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsimple"
+	"github.com/zclconf/go-cty/cty"
 
 	"github.com/hashicorp/waypoint/internal/pkg/defaults"
 )
@@ -20,12 +21,14 @@ type Config struct {
 }
 
 type hclConfig struct {
-	Project string            `hcl:"project,attr"`
-	Runner  *Runner           `hcl:"runner,block" default:"{}"`
-	Labels  map[string]string `hcl:"labels,optional"`
-	Plugin  []*Plugin         `hcl:"plugin,block"`
-	Apps    []*hclApp         `hcl:"app,block"`
-	Body    hcl.Body          `hcl:",body"`
+	Project          string              `hcl:"project,attr"`
+	Runner           *Runner             `hcl:"runner,block" default:"{}"`
+	Labels           map[string]string   `hcl:"labels,optional"`
+	TerraformOutputs []*TerraformOutputs `hcl:"terraform_outputs,block"`
+	Notifications    []*Notification     `hcl:"notification,block"`
+	Plugin           []*Plugin           `hcl:"plugin,block"`
+	Apps             []*hclApp           `hcl:"app,block"`
+	Body             hcl.Body            `hcl:",body"`
 }
 
 // Runner is the configuration for supporting runners in this project.
@@ -81,6 +84,7 @@ func Load(path string, pwd string) (*Config, error) {
 	// Build our context
 	ctx := EvalContext(nil, pwd).NewChild()
 	addPathValue(ctx, pathData)
+	addVarValue(ctx, map[string]cty.Value{})
 
 	// Decode
 	var cfg hclConfig
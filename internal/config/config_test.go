@@ -46,6 +46,31 @@ func TestLoad_compare(t *testing.T) {
 				require.Equal(t, "HELLO", c.Project)
 			},
 		},
+
+		{
+			"terraform_outputs.hcl",
+			"",
+			func(t *testing.T, c *Config) {
+				require.Len(t, c.TerraformOutputs, 2)
+				require.Equal(t, "network", c.TerraformOutputs[0].Name)
+				require.Equal(t, "network-prod", c.TerraformOutputs[0].Workspace)
+				require.Equal(t, "https://example.com/db.tfstate", c.TerraformOutputs[1].StateURL)
+				require.NoError(t, c.Validate())
+			},
+		},
+
+		{
+			"notification.hcl",
+			"",
+			func(t *testing.T, c *Config) {
+				require.Len(t, c.Notifications, 2)
+				require.Equal(t, "slack", c.Notifications[0].Provider)
+				require.Equal(t, []string{"deploy_complete", "job_failed"}, c.Notifications[0].Events)
+				require.Equal(t, "5m", c.Notifications[0].Throttle)
+				require.Equal(t, "teams", c.Notifications[1].Provider)
+				require.NoError(t, c.Validate())
+			},
+		},
 	}
 
 	for _, tt := range cases {
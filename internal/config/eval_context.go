@@ -65,6 +65,15 @@ func addPathValue(ctx *hcl.EvalContext, v map[string]string) {
 	ctx.Variables["path"] = value
 }
 
+// addVarValue adds the "var" variable to the context.
+func addVarValue(ctx *hcl.EvalContext, v map[string]cty.Value) {
+	if ctx.Variables == nil {
+		ctx.Variables = map[string]cty.Value{}
+	}
+
+	ctx.Variables["var"] = cty.ObjectVal(v)
+}
+
 // finalizeContext should be called whenever an HCL context is being used
 // as the final call.
 func finalizeContext(ctx *hcl.EvalContext) *hcl.EvalContext {
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"text/template"
+	"time"
+)
+
+// NotificationEvents are the events that notifications can be sent for.
+var NotificationEvents = []string{
+	"job_failed",
+	"deploy_complete",
+	"approval_requested",
+}
+
+// Notification configures a Slack or Microsoft Teams channel that is
+// notified of events for this project, such as failed jobs. The server
+// sends the notifications.
+type Notification struct {
+	Name string `hcl:",label"`
+
+	// Provider is the chat service: "slack" or "teams".
+	Provider string `hcl:"provider,attr"`
+
+	// WebhookURL is the incoming webhook URL of the channel. Channel
+	// overrides the channel of Slack webhooks.
+	WebhookURL string `hcl:"webhook_url,attr"`
+	Channel    string `hcl:"channel,optional"`
+
+	// Events are the events to notify on. All events are notified if
+	// this isn't set. See NotificationEvents.
+	Events []string `hcl:"events,optional"`
+
+	// Template is a Go text/template for the message text.
+	Template string `hcl:"template,optional"`
+
+	// Throttle is the minimum duration between messages for the same
+	// event, app, and workspace, such as "5m".
+	Throttle string `hcl:"throttle,optional"`
+}
+
+func (n *Notification) validate() error {
+	name := fmt.Sprintf("notification[%s]", n.Name)
+
+	switch n.Provider {
+	case "slack":
+	case "teams":
+		if n.Channel != "" {
+			return fmt.Errorf("%s: channel is only supported by slack", name)
+		}
+
+	default:
+		return fmt.Errorf("%s: provider must be \"slack\" or \"teams\"", name)
+	}
+
+	u, err := url.Parse(n.WebhookURL)
+	if err != nil {
+		return fmt.Errorf("%s: webhook_url: %s", name, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%s: webhook_url must be an http or https URL", name)
+	}
+
+	for _, e := range n.Events {
+		if !notificationEvent(e) {
+			return fmt.Errorf("%s: unknown event %q", name, e)
+		}
+	}
+
+	if n.Template != "" {
+		if _, err := template.New(name).Parse(n.Template); err != nil {
+			return fmt.Errorf("%s: template: %s", name, err)
+		}
+	}
+
+	if n.Throttle != "" {
+		if _, err := time.ParseDuration(n.Throttle); err != nil {
+			return fmt.Errorf("%s: throttle: %s", name, err)
+		}
+	}
+
+	return nil
+}
+
+func notificationEvent(e string) bool {
+	for _, v := range NotificationEvents {
+		if v == e {
+			return true
+		}
+	}
+
+	return false
+}
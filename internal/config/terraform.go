@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// TerraformOutputs configures a Terraform Cloud workspace or Terraform
+// state file whose outputs are available to the configuration as
+// variables. The server reads the outputs each time a job is queued.
+type TerraformOutputs struct {
+	Name string `hcl:",label"`
+
+	// Prefix is prepended to each output name to determine the variable
+	// name, such as "network_" to use "var.network_vpc_id".
+	Prefix string `hcl:"prefix,optional"`
+
+	// Organization and Workspace are the Terraform Cloud workspace to
+	// read outputs from. Address can be set to use Terraform Enterprise.
+	Address      string `hcl:"address,optional"`
+	Organization string `hcl:"organization,optional"`
+	Workspace    string `hcl:"workspace,optional"`
+
+	// StateURL is the HTTP or HTTPS address of a state file to read
+	// outputs from. This is mutually exclusive with Workspace.
+	StateURL string `hcl:"state_url,optional"`
+}
+
+func (t *TerraformOutputs) validate() error {
+	name := fmt.Sprintf("terraform_outputs[%s]", t.Name)
+
+	switch {
+	case t.Workspace != "" && t.StateURL != "":
+		return fmt.Errorf("%s: only one of workspace or state_url can be set", name)
+
+	case t.Workspace != "":
+		if t.Organization == "" {
+			return fmt.Errorf("%s: organization is required with workspace", name)
+		}
+
+	case t.StateURL != "":
+		u, err := url.Parse(t.StateURL)
+		if err != nil {
+			return fmt.Errorf("%s: state_url: %s", name, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("%s: state_url must be an http or https URL", name)
+		}
+
+	default:
+		return fmt.Errorf("%s: one of workspace or state_url is required", name)
+	}
+
+	return nil
+}
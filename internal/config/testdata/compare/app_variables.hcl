@@ -0,0 +1,8 @@
+project = "foo"
+
+app "bar" {
+    labels = {
+        "vpc": var.network_vpc_id,
+        "subnet": var.subnets[1],
+    }
+}
@@ -0,0 +1,16 @@
+project = "foo"
+
+notification "deploys" {
+    provider    = "slack"
+    webhook_url = "https://hooks.slack.com/services/T000/B000/XXXX"
+    channel     = "#deploys"
+    events      = ["deploy_complete", "job_failed"]
+    template    = "{{.Application}} in {{.Workspace}}: {{.Event}}"
+    throttle    = "5m"
+}
+
+notification "approvals" {
+    provider    = "teams"
+    webhook_url = "https://example.webhook.office.com/webhookb2/xxxx"
+    events      = ["approval_requested"]
+}
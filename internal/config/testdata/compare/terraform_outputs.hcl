@@ -0,0 +1,11 @@
+project = "foo"
+
+terraform_outputs "network" {
+    organization = "acme"
+    workspace    = "network-prod"
+    prefix       = "network_"
+}
+
+terraform_outputs "db" {
+    state_url = "https://example.com/db.tfstate"
+}
@@ -16,11 +16,13 @@ import (
 // requires duplication between this struct and the other config structs
 // since we don't do any lazy loading here.
 type validateStruct struct {
-	Project string            `hcl:"project,attr"`
-	Runner  *Runner           `hcl:"runner,block" default:"{}"`
-	Labels  map[string]string `hcl:"labels,optional"`
-	Plugin  []*Plugin         `hcl:"plugin,block"`
-	Apps    []*validateApp    `hcl:"app,block"`
+	Project          string              `hcl:"project,attr"`
+	Runner           *Runner             `hcl:"runner,block" default:"{}"`
+	Labels           map[string]string   `hcl:"labels,optional"`
+	TerraformOutputs []*TerraformOutputs `hcl:"terraform_outputs,block"`
+	Notifications    []*Notification     `hcl:"notification,block"`
+	Plugin           []*Plugin           `hcl:"plugin,block"`
+	Apps             []*validateApp      `hcl:"app,block"`
 }
 
 type validateApp struct {
@@ -63,6 +65,34 @@ func (c *Config) Validate() error {
 		result = multierror.Append(result, errs...)
 	}
 
+	// Validate Terraform outputs
+	names := map[string]bool{}
+	for _, tf := range c.hclConfig.TerraformOutputs {
+		if names[tf.Name] {
+			result = multierror.Append(result, fmt.Errorf(
+				"terraform_outputs[%s]: defined more than once", tf.Name))
+		}
+		names[tf.Name] = true
+
+		if err := tf.validate(); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	// Validate notifications
+	names = map[string]bool{}
+	for _, n := range c.hclConfig.Notifications {
+		if names[n.Name] {
+			result = multierror.Append(result, fmt.Errorf(
+				"notification[%s]: defined more than once", n.Name))
+		}
+		names[n.Name] = true
+
+		if err := n.validate(); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
 	return result
 }
 
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// SetVariables sets the values available to the configuration as "var".
+// The values are JSON-encoded, such as the job variables resolved by the
+// server from Terraform outputs. This must be called before loading any
+// apps to have an effect.
+func (c *Config) SetVariables(vars map[string]string) error {
+	values := map[string]cty.Value{}
+	for k, raw := range vars {
+		ty, err := ctyjson.ImpliedType([]byte(raw))
+		if err != nil {
+			return fmt.Errorf("variable %q: %w", k, err)
+		}
+
+		v, err := ctyjson.Unmarshal([]byte(raw), ty)
+		if err != nil {
+			return fmt.Errorf("variable %q: %w", k, err)
+		}
+
+		values[k] = v
+	}
+
+	addVarValue(c.ctx, values)
+	return nil
+}
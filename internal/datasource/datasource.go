@@ -37,6 +37,13 @@ type Sourcer interface {
 	) (string, func() error, error)
 }
 
+// RefSourcer is implemented by sourcers that can determine the exact
+// revision of downloaded data, such as the Git commit.
+type RefSourcer interface {
+	// Ref returns the revision of the data that Get downloaded to dir.
+	Ref(ctx context.Context, dir string) (*pb.Job_DataSource_Ref, error)
+}
+
 var (
 	// FromString maps a string key to a source implementation.
 	FromString = map[string]func() Sourcer{
@@ -8,7 +8,11 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/golang/protobuf/ptypes"
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
@@ -134,9 +138,47 @@ func (s *GitSource) Get(
 	return result, closer, nil
 }
 
+func (s *GitSource) Ref(ctx context.Context, dir string) (*pb.Job_DataSource_Ref, error) {
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "log", "-1", "--format=%H %ct")
+	cmd.Dir = dir
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	cmd.Stdin = nil
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git log failed: %s", output.String())
+	}
+
+	parts := strings.Fields(output.String())
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("unexpected git log output: %s", output.String())
+	}
+
+	unix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commit time %q: %s", parts[1], err)
+	}
+	ts, err := ptypes.TimestampProto(time.Unix(unix, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.Job_DataSource_Ref{
+		Ref: &pb.Job_DataSource_Ref_Git{
+			Git: &pb.Job_Git_Ref{
+				Commit:    parts[0],
+				Timestamp: ts,
+			},
+		},
+	}, nil
+}
+
 type gitConfig struct {
 	Url  string `hcl:"url,attr"`
 	Path string `hcl:"path,optional"`
 }
 
-var _ Sourcer = (*GitSource)(nil)
+var (
+	_ Sourcer    = (*GitSource)(nil)
+	_ RefSourcer = (*GitSource)(nil)
+)
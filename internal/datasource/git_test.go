@@ -134,6 +134,12 @@ func TestGitSourceGet(t *testing.T) {
 	// Verify files
 	_, err = os.Stat(filepath.Join(dir, "waypoint.hcl"))
 	require.NoError(err)
+
+	// Verify the ref is the commit we checked out
+	ref, err := s.Ref(context.Background(), dir)
+	require.NoError(err)
+	require.Len(ref.GetGit().Commit, 40)
+	require.NotNil(ref.GetGit().Timestamp)
 }
 
 // testGitFixture MUST be called before TestRunner since TestRunner
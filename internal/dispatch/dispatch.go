@@ -0,0 +1,97 @@
+// Package dispatch runs jobs in external schedulers such as Nomad or
+// Kubernetes rather than on registered runners. The server submits a job
+// to the scheduler that starts a runner for that one job, and tracks the
+// status of the scheduler job so that jobs whose runner fails to start or
+// dies are marked as failed.
+//
+// Scheduler implementations are in subpackages so that the server only
+// depends on the client libraries of the scheduler in use.
+package dispatch
+
+import (
+	"context"
+)
+
+// Scheduler is an external scheduler that jobs can be dispatched to.
+type Scheduler interface {
+	// Name is the type of scheduler, such as "nomad". This is recorded
+	// with dispatched jobs.
+	Name() string
+
+	// Submit submits a task to run and returns the ID of the job in the
+	// scheduler. Submit must be idempotent for the same task name.
+	Submit(ctx context.Context, task *Task) (string, error)
+
+	// Status returns the status of a submitted job. If the job doesn't
+	// exist this should return StatusLost rather than an error.
+	Status(ctx context.Context, id string) (Status, error)
+
+	// Stop stops and removes a submitted job. This should not return an
+	// error if the job doesn't exist.
+	Stop(ctx context.Context, id string) error
+}
+
+// Task is the runner to start in the scheduler for a single job.
+type Task struct {
+	// Name is the name to use for the scheduler job. This is unique for
+	// each job and is a valid DNS label.
+	Name string
+
+	// JobId is the ID of the Waypoint job. This is only informational and
+	// may be added as metadata to the scheduler job.
+	JobId string
+
+	// Image is the container image to run.
+	Image string
+
+	// Args are the arguments to the container entrypoint.
+	Args []string
+
+	// Env are the environment variables to set. These include the runner
+	// token so they should be treated as secret.
+	Env map[string]string
+
+	// OS and Arch are the platform to run the task on, such as "linux"
+	// and "arm64". If these are empty, the scheduler chooses.
+	OS   string
+	Arch string
+
+	// Config are scheduler-specific options from a runner profile. Each
+	// scheduler documents the options it supports and ignores the rest.
+	Config map[string]string
+}
+
+// Status is the status of a job in an external scheduler.
+type Status string
+
+const (
+	// StatusPending is a job that hasn't started running yet.
+	StatusPending Status = "pending"
+
+	// StatusRunning is a job that is running.
+	StatusRunning Status = "running"
+
+	// StatusComplete is a job that exited successfully.
+	StatusComplete Status = "complete"
+
+	// StatusFailed is a job that failed or exited with an error.
+	StatusFailed Status = "failed"
+
+	// StatusLost is a job that the scheduler no longer knows about.
+	StatusLost Status = "lost"
+
+	// StatusStopped is a job that was stopped by the server after the
+	// Waypoint job completed. This is never returned by a Scheduler.
+	StatusStopped Status = "stopped"
+)
+
+// Done returns true if the scheduler job is no longer running.
+func (s Status) Done() bool {
+	switch s {
+	case StatusComplete, StatusFailed, StatusLost, StatusStopped:
+		return true
+
+	default:
+		return false
+	}
+}
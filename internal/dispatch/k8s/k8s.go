@@ -0,0 +1,197 @@
+// Package k8s dispatches jobs to Kubernetes as Jobs.
+package k8s
+
+import (
+	"context"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/hashicorp/waypoint/internal/dispatch"
+)
+
+// Scheduler dispatches jobs as Kubernetes Jobs. The environment of the
+// runner, including its token, is stored in a Secret with the same name
+// as the Job.
+type Scheduler struct {
+	// Client is the Kubernetes client.
+	Client kubernetes.Interface
+
+	// Namespace is the namespace that Jobs are created in.
+	Namespace string
+}
+
+// New returns a Scheduler using the default kubeconfig loading rules, or
+// the in-cluster configuration if the server runs in Kubernetes. If
+// namespace is empty, the namespace of the current context is used.
+func New(namespace string) (*Scheduler, error) {
+	config := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(),
+		&clientcmd.ConfigOverrides{},
+	)
+
+	if namespace == "" {
+		ns, _, err := config.Namespace()
+		if err != nil {
+			return nil, err
+		}
+
+		namespace = ns
+	}
+
+	clientconfig, err := config.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	clientset, err := kubernetes.NewForConfig(clientconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Scheduler{Client: clientset, Namespace: namespace}, nil
+}
+
+// Name implements dispatch.Scheduler
+func (s *Scheduler) Name() string { return "kubernetes" }
+
+// Submit implements dispatch.Scheduler
+func (s *Scheduler) Submit(ctx context.Context, task *dispatch.Task) (string, error) {
+	secret := &corev1.Secret{
+		ObjectMeta: s.objectMeta(task),
+		StringData: task.Env,
+	}
+	_, err := s.Client.CoreV1().Secrets(s.Namespace).Create(ctx, secret, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return "", err
+	}
+
+	_, err = s.Client.BatchV1().Jobs(s.Namespace).Create(ctx, s.job(task), metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return "", err
+	}
+
+	return task.Name, nil
+}
+
+// Status implements dispatch.Scheduler
+func (s *Scheduler) Status(ctx context.Context, id string) (dispatch.Status, error) {
+	job, err := s.Client.BatchV1().Jobs(s.Namespace).Get(ctx, id, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return dispatch.StatusLost, nil
+		}
+
+		return "", err
+	}
+
+	return jobStatus(&job.Status), nil
+}
+
+// Stop implements dispatch.Scheduler
+func (s *Scheduler) Stop(ctx context.Context, id string) error {
+	propagation := metav1.DeletePropagationBackground
+	err := s.Client.BatchV1().Jobs(s.Namespace).Delete(ctx, id, metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	err = s.Client.CoreV1().Secrets(s.Namespace).Delete(ctx, id, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Scheduler) objectMeta(task *dispatch.Task) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      task.Name,
+		Namespace: s.Namespace,
+		Labels: map[string]string{
+			"app.kubernetes.io/managed-by": "waypoint",
+		},
+		Annotations: map[string]string{
+			"waypoint.hashicorp.com/job-id": task.JobId,
+		},
+	}
+}
+
+// job returns the Kubernetes Job for the task. The pod isn't restarted
+// or retried since the Waypoint job is failed when the runner fails. The
+// "service_account" config option sets the service account of the pod.
+func (s *Scheduler) job(task *dispatch.Task) *batchv1.Job {
+	var nodeSelector map[string]string
+	if task.OS != "" || task.Arch != "" {
+		nodeSelector = map[string]string{}
+		if task.OS != "" {
+			nodeSelector["kubernetes.io/os"] = task.OS
+		}
+		if task.Arch != "" {
+			nodeSelector["kubernetes.io/arch"] = task.Arch
+		}
+	}
+
+	var backoffLimit int32
+	return &batchv1.Job{
+		ObjectMeta: s.objectMeta(task),
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					NodeSelector:       nodeSelector,
+					ServiceAccountName: task.Config["service_account"],
+					Containers: []corev1.Container{
+						{
+							Name:  "runner",
+							Image: task.Image,
+							Args:  task.Args,
+							EnvFrom: []corev1.EnvFromSource{
+								{
+									SecretRef: &corev1.SecretEnvSource{
+										LocalObjectReference: corev1.LocalObjectReference{
+											Name: task.Name,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// jobStatus maps the status of a Job to a status. The Job only ever has
+// one pod.
+func jobStatus(st *batchv1.JobStatus) dispatch.Status {
+	for _, c := range st.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			return dispatch.StatusFailed
+		}
+	}
+
+	switch {
+	case st.Failed > 0:
+		return dispatch.StatusFailed
+
+	case st.Succeeded > 0:
+		return dispatch.StatusComplete
+
+	case st.Active > 0:
+		return dispatch.StatusRunning
+
+	default:
+		return dispatch.StatusPending
+	}
+}
+
+var _ dispatch.Scheduler = (*Scheduler)(nil)
@@ -0,0 +1,77 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/hashicorp/waypoint/internal/dispatch"
+)
+
+func TestScheduler(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	client := fake.NewSimpleClientset()
+	s := &Scheduler{Client: client, Namespace: "waypoint"}
+
+	task := &dispatch.Task{
+		Name:  "waypoint-job-a",
+		JobId: "A",
+		Image: "hashicorp/waypoint:latest",
+		Args:  []string{"runner", "agent"},
+		Env:   map[string]string{"WAYPOINT_SERVER_TOKEN": "secret"},
+	}
+
+	id, err := s.Submit(ctx, task)
+	require.NoError(err)
+	require.Equal(task.Name, id)
+
+	// Submitting again is fine
+	_, err = s.Submit(ctx, task)
+	require.NoError(err)
+
+	// The token is in a secret, not the job
+	job, err := client.BatchV1().Jobs("waypoint").Get(ctx, id, metav1.GetOptions{})
+	require.NoError(err)
+	require.Empty(job.Spec.Template.Spec.Containers[0].Env)
+	secret, err := client.CoreV1().Secrets("waypoint").Get(ctx, id, metav1.GetOptions{})
+	require.NoError(err)
+	require.Equal("secret", secret.StringData["WAYPOINT_SERVER_TOKEN"])
+
+	st, err := s.Status(ctx, id)
+	require.NoError(err)
+	require.Equal(dispatch.StatusPending, st)
+
+	job.Status.Failed = 1
+	_, err = client.BatchV1().Jobs("waypoint").UpdateStatus(ctx, job, metav1.UpdateOptions{})
+	require.NoError(err)
+	st, err = s.Status(ctx, id)
+	require.NoError(err)
+	require.Equal(dispatch.StatusFailed, st)
+
+	require.NoError(s.Stop(ctx, id))
+	require.NoError(s.Stop(ctx, id))
+	st, err = s.Status(ctx, id)
+	require.NoError(err)
+	require.Equal(dispatch.StatusLost, st)
+}
+
+func TestSchedulerJob_profile(t *testing.T) {
+	require := require.New(t)
+
+	s := &Scheduler{Namespace: "waypoint"}
+	job := s.job(&dispatch.Task{
+		Name:   "waypoint-runner-a",
+		Image:  "hashicorp/waypoint:latest",
+		Arch:   "arm64",
+		Config: map[string]string{"service_account": "runner"},
+	})
+
+	spec := job.Spec.Template.Spec
+	require.Equal(map[string]string{"kubernetes.io/arch": "arm64"}, spec.NodeSelector)
+	require.Equal("runner", spec.ServiceAccountName)
+}
@@ -0,0 +1,164 @@
+// Package nomad dispatches jobs to Nomad as batch jobs.
+package nomad
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+
+	"github.com/hashicorp/waypoint/internal/dispatch"
+)
+
+// taskGroup is the name of the task group and task of the Nomad job.
+const taskGroup = "runner"
+
+// Scheduler dispatches jobs as Nomad batch jobs that run the runner with
+// the Docker driver.
+type Scheduler struct {
+	// Client is the Nomad API client.
+	Client *api.Client
+
+	// Region, Namespace, and Datacenters configure where jobs are run.
+	// Datacenters defaults to "dc1".
+	Region      string
+	Namespace   string
+	Datacenters []string
+}
+
+// New returns a Scheduler using a client configured with the standard
+// Nomad environment variables such as NOMAD_ADDR and NOMAD_TOKEN.
+func New() (*Scheduler, error) {
+	client, err := api.NewClient(api.DefaultConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Scheduler{Client: client}, nil
+}
+
+// Name implements dispatch.Scheduler
+func (s *Scheduler) Name() string { return "nomad" }
+
+// Submit implements dispatch.Scheduler
+func (s *Scheduler) Submit(ctx context.Context, task *dispatch.Task) (string, error) {
+	_, _, err := s.Client.Jobs().Register(s.job(task), s.writeOptions(ctx))
+	if err != nil {
+		return "", err
+	}
+
+	return task.Name, nil
+}
+
+// Status implements dispatch.Scheduler
+func (s *Scheduler) Status(ctx context.Context, id string) (dispatch.Status, error) {
+	summary, _, err := s.Client.Jobs().Summary(id, s.queryOptions(ctx))
+	if err != nil {
+		if strings.Contains(err.Error(), "404") {
+			return dispatch.StatusLost, nil
+		}
+
+		return "", err
+	}
+
+	tg, ok := summary.Summary[taskGroup]
+	if !ok {
+		return dispatch.StatusPending, nil
+	}
+
+	return groupStatus(tg), nil
+}
+
+// Stop implements dispatch.Scheduler
+func (s *Scheduler) Stop(ctx context.Context, id string) error {
+	_, _, err := s.Client.Jobs().Deregister(id, true, s.writeOptions(ctx))
+	if err != nil && strings.Contains(err.Error(), "404") {
+		err = nil
+	}
+
+	return err
+}
+
+// job returns the Nomad job for the task. The job runs once. Failures
+// aren't restarted or rescheduled since the Waypoint job is failed when
+// the runner fails. The "datacenters" config option overrides the
+// datacenters of the scheduler.
+func (s *Scheduler) job(task *dispatch.Task) *api.Job {
+	job := api.NewBatchJob(task.Name, task.Name, s.Region, 50)
+	if s.Namespace != "" {
+		job.Namespace = &s.Namespace
+	}
+	job.Datacenters = s.Datacenters
+	if v := task.Config["datacenters"]; v != "" {
+		job.Datacenters = strings.Split(v, ",")
+	}
+	if len(job.Datacenters) == 0 {
+		job.Datacenters = []string{"dc1"}
+	}
+	if task.OS != "" {
+		job.Constrain(api.NewConstraint("${attr.kernel.name}", "=", task.OS))
+	}
+	if task.Arch != "" {
+		job.Constrain(api.NewConstraint("${attr.cpu.arch}", "=", task.Arch))
+	}
+	job.Meta = map[string]string{
+		"waypoint.hashicorp.com/job-id": task.JobId,
+	}
+
+	attempts := 0
+	unlimited := false
+	mode := "fail"
+	tg := api.NewTaskGroup(taskGroup, 1)
+	tg.RestartPolicy = &api.RestartPolicy{
+		Attempts: &attempts,
+		Mode:     &mode,
+	}
+	tg.ReschedulePolicy = &api.ReschedulePolicy{
+		Attempts:  &attempts,
+		Unlimited: &unlimited,
+	}
+	job.AddTaskGroup(tg)
+
+	t := api.NewTask(taskGroup, "docker")
+	t.Config = map[string]interface{}{
+		"image": task.Image,
+		"args":  task.Args,
+	}
+	t.Env = task.Env
+	tg.AddTask(t)
+
+	return job
+}
+
+func (s *Scheduler) queryOptions(ctx context.Context) *api.QueryOptions {
+	q := &api.QueryOptions{Region: s.Region, Namespace: s.Namespace}
+	return q.WithContext(ctx)
+}
+
+func (s *Scheduler) writeOptions(ctx context.Context) *api.WriteOptions {
+	w := &api.WriteOptions{Region: s.Region, Namespace: s.Namespace}
+	return w.WithContext(ctx)
+}
+
+// groupStatus maps the allocation counts of the task group to a status.
+// The task group only ever has one allocation.
+func groupStatus(tg api.TaskGroupSummary) dispatch.Status {
+	switch {
+	case tg.Failed > 0:
+		return dispatch.StatusFailed
+
+	case tg.Lost > 0:
+		return dispatch.StatusLost
+
+	case tg.Complete > 0:
+		return dispatch.StatusComplete
+
+	case tg.Running > 0:
+		return dispatch.StatusRunning
+
+	default:
+		return dispatch.StatusPending
+	}
+}
+
+var _ dispatch.Scheduler = (*Scheduler)(nil)
@@ -0,0 +1,67 @@
+package nomad
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint/internal/dispatch"
+)
+
+func TestSchedulerJob(t *testing.T) {
+	require := require.New(t)
+
+	s := &Scheduler{Namespace: "ops"}
+	job := s.job(&dispatch.Task{
+		Name:  "waypoint-job-a",
+		JobId: "A",
+		Image: "hashicorp/waypoint:latest",
+		Args:  []string{"runner", "agent"},
+		Env:   map[string]string{"WAYPOINT_SERVER_TOKEN": "secret"},
+	})
+
+	require.Equal("waypoint-job-a", *job.ID)
+	require.Equal("batch", *job.Type)
+	require.Equal("ops", *job.Namespace)
+	require.Equal([]string{"dc1"}, job.Datacenters)
+	require.Len(job.TaskGroups, 1)
+	require.Equal(0, *job.TaskGroups[0].RestartPolicy.Attempts)
+	require.Equal("secret", job.TaskGroups[0].Tasks[0].Env["WAYPOINT_SERVER_TOKEN"])
+}
+
+func TestSchedulerJob_profile(t *testing.T) {
+	require := require.New(t)
+
+	s := &Scheduler{Datacenters: []string{"dc1"}}
+	job := s.job(&dispatch.Task{
+		Name:   "waypoint-runner-a",
+		Image:  "hashicorp/waypoint:latest",
+		OS:     "linux",
+		Arch:   "arm64",
+		Config: map[string]string{"datacenters": "us-east-1,us-east-2"},
+	})
+
+	require.Equal([]string{"us-east-1", "us-east-2"}, job.Datacenters)
+	require.Len(job.Constraints, 2)
+	require.Equal("${attr.cpu.arch}", job.Constraints[1].LTarget)
+	require.Equal("arm64", job.Constraints[1].RTarget)
+}
+
+func TestGroupStatus(t *testing.T) {
+	cases := []struct {
+		Summary  api.TaskGroupSummary
+		Expected dispatch.Status
+	}{
+		{api.TaskGroupSummary{Queued: 1}, dispatch.StatusPending},
+		{api.TaskGroupSummary{Starting: 1}, dispatch.StatusPending},
+		{api.TaskGroupSummary{Running: 1}, dispatch.StatusRunning},
+		{api.TaskGroupSummary{Complete: 1}, dispatch.StatusComplete},
+		{api.TaskGroupSummary{Failed: 1}, dispatch.StatusFailed},
+		{api.TaskGroupSummary{Lost: 1}, dispatch.StatusLost},
+	}
+
+	for _, tt := range cases {
+		require.Equal(t, tt.Expected, groupStatus(tt.Summary))
+	}
+}
@@ -0,0 +1,273 @@
+// Package notify posts messages about project events, such as failed jobs
+// and completed deploys, to chat services such as Slack and Microsoft
+// Teams. Notifications are configured per project.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// DefaultTemplates are the message templates used for each event if a
+// notification doesn't set a template.
+var DefaultTemplates = map[pb.Project_Notification_Event]string{
+	pb.Project_Notification_JOB_FAILED: `Waypoint {{.Operation}} job {{.JobId}} for ` +
+		`{{.Project}}/{{.Application}} in workspace {{.Workspace}} failed: {{.Error}}`,
+
+	pb.Project_Notification_DEPLOY_COMPLETE: `Waypoint deployed {{.Project}}/{{.Application}} ` +
+		`to workspace {{.Workspace}} (job {{.JobId}})`,
+
+	pb.Project_Notification_APPROVAL_REQUESTED: `Waypoint {{.Operation}} of ` +
+		`{{.Project}}/{{.Application}} to workspace {{.Workspace}} requires ` +
+		`{{.Approvals}} approvals. Approve it with "waypoint job approve {{.JobId}}".`,
+}
+
+// Message is the data for a single event. This is the data available to
+// message templates.
+type Message struct {
+	Event       pb.Project_Notification_Event
+	Project     string
+	Application string
+	Workspace   string
+	JobId       string
+
+	// Operation is the name of the job operation, such as "deploy".
+	Operation string
+
+	// Error is the error message of a failed job.
+	Error string
+
+	// Approvals is the number of approvals a job requires.
+	Approvals uint32
+
+	// Suppressed is the number of messages for the same event that were
+	// dropped by throttling since the last message was sent. This is set
+	// by Notify.
+	Suppressed int
+}
+
+// EventName returns the name of the event as used in the configuration,
+// such as "job_failed".
+func EventName(e pb.Project_Notification_Event) string {
+	return strings.ToLower(e.String())
+}
+
+// Notifier sends notifications. The zero value is ready to use.
+type Notifier struct {
+	// HTTPClient is used for all requests. This defaults to a client with
+	// a 10 second timeout.
+	HTTPClient *http.Client
+
+	// now returns the current time. This is only overridden in tests.
+	now func() time.Time
+
+	lock      sync.Mutex
+	throttled map[string]*throttle
+}
+
+// throttle tracks the messages sent for a single throttle key.
+type throttle struct {
+	last       time.Time
+	suppressed int
+}
+
+// Notify sends the message to each notification that is configured for
+// the event. All notifications are attempted even if one fails, and the
+// first error is returned.
+func (n *Notifier) Notify(
+	ctx context.Context,
+	configs []*pb.Project_Notification,
+	msg *Message,
+) error {
+	var result error
+	for _, cfg := range configs {
+		if !wantsEvent(cfg, msg.Event) {
+			continue
+		}
+
+		if err := n.notify(ctx, cfg, *msg); err != nil && result == nil {
+			result = fmt.Errorf("error sending notification %q: %w", cfg.Name, err)
+		}
+	}
+
+	return result
+}
+
+func (n *Notifier) notify(ctx context.Context, cfg *pb.Project_Notification, msg Message) error {
+	var err error
+	msg.Suppressed, err = n.throttle(cfg, &msg)
+	if err != nil {
+		return err
+	}
+	if msg.Suppressed < 0 {
+		return nil
+	}
+
+	text, err := Render(cfg.Template, &msg)
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	switch cfg.Provider {
+	case pb.Project_Notification_SLACK:
+		body, err = slackPayload(cfg, text)
+
+	case pb.Project_Notification_TEAMS:
+		body, err = teamsPayload(cfg, text)
+
+	default:
+		return fmt.Errorf("unknown notification provider: %s", cfg.Provider)
+	}
+	if err != nil {
+		return err
+	}
+
+	return n.post(ctx, cfg.WebhookUrl, body)
+}
+
+// throttle returns the number of messages suppressed since the last
+// message for this key, or -1 if this message should be suppressed.
+func (n *Notifier) throttle(cfg *pb.Project_Notification, msg *Message) (int, error) {
+	if cfg.Throttle == "" {
+		return 0, nil
+	}
+
+	dur, err := time.ParseDuration(cfg.Throttle)
+	if err != nil {
+		return 0, fmt.Errorf("invalid throttle: %w", err)
+	}
+
+	now := time.Now
+	if n.now != nil {
+		now = n.now
+	}
+
+	key := strings.Join([]string{
+		cfg.Name, msg.Project, msg.Application, msg.Workspace, msg.Event.String(),
+	}, "/")
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if n.throttled == nil {
+		n.throttled = map[string]*throttle{}
+	}
+
+	t, ok := n.throttled[key]
+	if !ok {
+		t = &throttle{}
+		n.throttled[key] = t
+	}
+
+	if ok && now().Sub(t.last) < dur {
+		t.suppressed++
+		return -1, nil
+	}
+
+	suppressed := t.suppressed
+	t.last = now()
+	t.suppressed = 0
+	return suppressed, nil
+}
+
+// Validate validates the notification configuration.
+func Validate(cfg *pb.Project_Notification) error {
+	u, err := url.Parse(cfg.WebhookUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("notification %q: webhook URL must be an http or https URL", cfg.Name)
+	}
+
+	if cfg.Throttle != "" {
+		if _, err := time.ParseDuration(cfg.Throttle); err != nil {
+			return fmt.Errorf("notification %q: invalid throttle: %s", cfg.Name, err)
+		}
+	}
+
+	if cfg.Template != "" {
+		// Render with an empty message so that unknown fields are caught.
+		if _, err := Render(cfg.Template, &Message{}); err != nil {
+			return fmt.Errorf("notification %q: %s", cfg.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Render renders the message text with the given template. If the
+// template is empty, the default template for the event is used.
+func Render(tpl string, msg *Message) (string, error) {
+	if tpl == "" {
+		tpl = DefaultTemplates[msg.Event]
+	}
+
+	t, err := template.New("notification").Option("missingkey=error").Parse(tpl)
+	if err != nil {
+		return "", fmt.Errorf("error parsing template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, msg); err != nil {
+		return "", fmt.Errorf("error rendering template: %w", err)
+	}
+
+	if msg.Suppressed > 0 {
+		fmt.Fprintf(&buf, " (%d similar notifications suppressed)", msg.Suppressed)
+	}
+
+	return buf.String(), nil
+}
+
+func (n *Notifier) post(ctx context.Context, url string, body []byte) error {
+	client := n.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// The webhook URL is a secret, so we never include it in errors.
+		return fmt.Errorf("error posting to webhook: %s", unwrapURLError(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned status %d: %s",
+			resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+// wantsEvent returns true if the notification is configured for the event.
+func wantsEvent(cfg *pb.Project_Notification, e pb.Project_Notification_Event) bool {
+	if len(cfg.Events) == 0 {
+		return true
+	}
+
+	for _, v := range cfg.Events {
+		if v == e {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,206 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func TestRender(t *testing.T) {
+	require := require.New(t)
+
+	msg := &Message{
+		Event:       pb.Project_Notification_JOB_FAILED,
+		Project:     "p",
+		Application: "a",
+		Workspace:   "default",
+		JobId:       "J1",
+		Operation:   "deploy",
+		Error:       "boom",
+	}
+
+	text, err := Render("", msg)
+	require.NoError(err)
+	require.Equal("Waypoint deploy job J1 for p/a in workspace default failed: boom", text)
+
+	text, err = Render("{{.Application}} broke", msg)
+	require.NoError(err)
+	require.Equal("a broke", text)
+
+	msg.Suppressed = 2
+	text, err = Render("{{.Application}} broke", msg)
+	require.NoError(err)
+	require.Equal("a broke (2 similar notifications suppressed)", text)
+
+	_, err = Render("{{.Nope}}", msg)
+	require.Error(err)
+}
+
+func TestNotifier(t *testing.T) {
+	ctx := context.Background()
+
+	var bodies []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &body))
+		bodies = append(bodies, body)
+	}))
+	defer srv.Close()
+
+	msg := &Message{
+		Event:       pb.Project_Notification_DEPLOY_COMPLETE,
+		Project:     "p",
+		Application: "a",
+		Workspace:   "default",
+		JobId:       "J1",
+	}
+
+	t.Run("providers", func(t *testing.T) {
+		require := require.New(t)
+		bodies = nil
+
+		var n Notifier
+		require.NoError(n.Notify(ctx, []*pb.Project_Notification{
+			{
+				Name:       "slack",
+				Provider:   pb.Project_Notification_SLACK,
+				WebhookUrl: srv.URL,
+				Channel:    "#deploys",
+				Template:   "deployed {{.Application}}",
+			},
+			{
+				Name:       "teams",
+				Provider:   pb.Project_Notification_TEAMS,
+				WebhookUrl: srv.URL,
+				Template:   "deployed {{.Application}}",
+			},
+		}, msg))
+
+		require.Len(bodies, 2)
+		require.Equal("deployed a", bodies[0]["text"])
+		require.Equal("#deploys", bodies[0]["channel"])
+		require.Equal("MessageCard", bodies[1]["@type"])
+		require.Equal("deployed a", bodies[1]["text"])
+	})
+
+	t.Run("events", func(t *testing.T) {
+		require := require.New(t)
+		bodies = nil
+
+		var n Notifier
+		require.NoError(n.Notify(ctx, []*pb.Project_Notification{
+			{
+				Name:       "failures",
+				WebhookUrl: srv.URL,
+				Events:     []pb.Project_Notification_Event{pb.Project_Notification_JOB_FAILED},
+			},
+		}, msg))
+		require.Empty(bodies)
+	})
+
+	t.Run("throttle", func(t *testing.T) {
+		require := require.New(t)
+		bodies = nil
+
+		now := time.Now()
+		n := &Notifier{now: func() time.Time { return now }}
+		configs := []*pb.Project_Notification{
+			{
+				Name:       "slack",
+				WebhookUrl: srv.URL,
+				Template:   "deployed",
+				Throttle:   "5m",
+			},
+		}
+
+		require.NoError(n.Notify(ctx, configs, msg))
+		require.NoError(n.Notify(ctx, configs, msg))
+		require.NoError(n.Notify(ctx, configs, msg))
+		require.Len(bodies, 1)
+
+		// Other apps aren't throttled
+		other := *msg
+		other.Application = "b"
+		require.NoError(n.Notify(ctx, configs, &other))
+		require.Len(bodies, 2)
+
+		now = now.Add(10 * time.Minute)
+		require.NoError(n.Notify(ctx, configs, msg))
+		require.Len(bodies, 3)
+		require.Equal("deployed (2 similar notifications suppressed)", bodies[2]["text"])
+	})
+
+	t.Run("errors don't include the webhook URL", func(t *testing.T) {
+		require := require.New(t)
+
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "invalid_token", http.StatusForbidden)
+		}))
+		defer failing.Close()
+
+		var n Notifier
+		err := n.Notify(ctx, []*pb.Project_Notification{
+			{Name: "slack", WebhookUrl: failing.URL + "/secret"},
+		}, msg)
+		require.Error(err)
+		require.Contains(err.Error(), "invalid_token")
+		require.NotContains(err.Error(), "secret")
+	})
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		Name string
+		Cfg  *pb.Project_Notification
+		Err  string
+	}{
+		{
+			"valid",
+			&pb.Project_Notification{
+				WebhookUrl: "https://hooks.slack.com/services/x",
+				Throttle:   "5m",
+				Template:   "{{.Application}} {{.Error}}",
+			},
+			"",
+		},
+		{
+			"missing URL",
+			&pb.Project_Notification{},
+			"webhook URL",
+		},
+		{
+			"invalid throttle",
+			&pb.Project_Notification{WebhookUrl: "https://example.com", Throttle: "soon"},
+			"throttle",
+		},
+		{
+			"unknown template field",
+			&pb.Project_Notification{WebhookUrl: "https://example.com", Template: "{{.Nope}}"},
+			"template",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := Validate(tt.Cfg)
+			if tt.Err == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.Err)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/url"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// slackPayload returns the body for a Slack incoming webhook.
+func slackPayload(cfg *pb.Project_Notification, text string) ([]byte, error) {
+	return json.Marshal(struct {
+		Text    string `json:"text"`
+		Channel string `json:"channel,omitempty"`
+	}{
+		Text:    text,
+		Channel: cfg.Channel,
+	})
+}
+
+// teamsPayload returns the body for a Microsoft Teams incoming webhook,
+// which accepts legacy message cards.
+func teamsPayload(cfg *pb.Project_Notification, text string) ([]byte, error) {
+	return json.Marshal(struct {
+		Type    string `json:"@type"`
+		Context string `json:"@context"`
+		Summary string `json:"summary"`
+		Text    string `json:"text"`
+	}{
+		Type:    "MessageCard",
+		Context: "https://schema.org/extensions",
+		Summary: "Waypoint",
+		Text:    text,
+	})
+}
+
+// unwrapURLError returns the underlying error of a *url.Error, which
+// includes the request URL in its message.
+func unwrapURLError(err error) error {
+	if uerr, ok := err.(*url.Error); ok {
+		return uerr.Err
+	}
+
+	return err
+}
@@ -0,0 +1,206 @@
+// Package cron parses cron-style schedules.
+//
+// Schedules are the standard five fields: minute, hour, day of month,
+// month, and day of week. Each field may be "*", a number, a range such as
+// "1-5", a list such as "1,15", and any of these followed by a step such as
+// "*/15". Days of the week are 0-6 starting with Sunday, and 7 is also
+// Sunday. The descriptors @hourly, @daily (or @midnight), @weekly,
+// @monthly, and @yearly (or @annually) are also supported.
+//
+// Like cron, if both the day of month and day of week are restricted then
+// a time matches if either matches.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron schedule.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// domStar and dowStar are true if the day of month or day of week
+	// fields are "*", which changes how days are matched.
+	domStar, dowStar bool
+}
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field is the valid range of a field.
+type field struct {
+	name     string
+	min, max uint
+}
+
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// Parse parses a cron schedule.
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@") {
+		v, ok := descriptors[spec]
+		if !ok {
+			return nil, fmt.Errorf("unknown schedule descriptor %q", spec)
+		}
+
+		spec = v
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf(
+			"schedule must have %d fields (minute, hour, day of month, month, "+
+				"day of week), got %d", len(fields), len(parts))
+	}
+
+	var bits [5]uint64
+	for i, p := range parts {
+		var err error
+		bits[i], err = parseField(p, fields[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Sunday may be either 0 or 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+		bits[4] &^= 1 << 7
+	}
+
+	return &Schedule{
+		minute:  bits[0],
+		hour:    bits[1],
+		dom:     bits[2],
+		month:   bits[3],
+		dow:     bits[4],
+		domStar: parts[2] == "*",
+		dowStar: parts[4] == "*",
+	}, nil
+}
+
+// parseField parses a single field into a bitset of the values it matches.
+func parseField(s string, f field) (uint64, error) {
+	var result uint64
+	for _, item := range strings.Split(s, ",") {
+		rangePart, step := item, uint(1)
+		if idx := strings.Index(item, "/"); idx >= 0 {
+			rangePart = item[:idx]
+			v, err := strconv.ParseUint(item[idx+1:], 10, 8)
+			if err != nil || v == 0 {
+				return 0, fmt.Errorf("invalid step in %s field: %q", f.name, item)
+			}
+
+			step = uint(v)
+		}
+
+		var lo, hi uint
+		switch {
+		case rangePart == "*":
+			lo, hi = f.min, f.max
+
+		case strings.Contains(rangePart, "-"):
+			idx := strings.Index(rangePart, "-")
+			var err error
+			if lo, err = parseValue(rangePart[:idx], f); err != nil {
+				return 0, err
+			}
+			if hi, err = parseValue(rangePart[idx+1:], f); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range in %s field: %q", f.name, item)
+			}
+
+		default:
+			v, err := parseValue(rangePart, f)
+			if err != nil {
+				return 0, err
+			}
+
+			// A single value with a step is a range to the max, like cron.
+			lo, hi = v, v
+			if step > 1 {
+				hi = f.max
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			result |= 1 << v
+		}
+	}
+
+	return result, nil
+}
+
+func parseValue(s string, f field) (uint, error) {
+	v, err := strconv.ParseUint(s, 10, 8)
+	if err != nil || uint(v) < f.min || uint(v) > f.max {
+		return 0, fmt.Errorf("%s must be a number from %d to %d, got %q",
+			f.name, f.min, f.max, s)
+	}
+
+	return uint(v), nil
+}
+
+// Next returns the first time after t that matches the schedule, in the
+// location of t. This returns the zero time if there is no matching time
+// within five years, such as for "0 0 30 2 *".
+func (s *Schedule) Next(t time.Time) time.Time {
+	// Start at the next whole minute
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+
+	return dom || dow
+}
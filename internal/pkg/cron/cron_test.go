@@ -0,0 +1,111 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_invalid(t *testing.T) {
+	cases := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@sometimes",
+	}
+
+	for _, tt := range cases {
+		t.Run(tt, func(t *testing.T) {
+			_, err := Parse(tt)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	start := time.Date(2020, time.October, 14, 10, 30, 15, 0, time.UTC)
+
+	cases := []struct {
+		Spec     string
+		Expected time.Time
+	}{
+		{
+			"* * * * *",
+			time.Date(2020, time.October, 14, 10, 31, 0, 0, time.UTC),
+		},
+
+		{
+			"*/15 * * * *",
+			time.Date(2020, time.October, 14, 10, 45, 0, 0, time.UTC),
+		},
+
+		{
+			"@hourly",
+			time.Date(2020, time.October, 14, 11, 0, 0, 0, time.UTC),
+		},
+
+		{
+			"@daily",
+			time.Date(2020, time.October, 15, 0, 0, 0, 0, time.UTC),
+		},
+
+		{
+			"30 2 * * *",
+			time.Date(2020, time.October, 15, 2, 30, 0, 0, time.UTC),
+		},
+
+		{
+			"0 9-17/4 * * *",
+			time.Date(2020, time.October, 14, 13, 0, 0, 0, time.UTC),
+		},
+
+		{
+			// Wednesday the 14th, so the next Sunday is the 18th
+			"0 0 * * 7",
+			time.Date(2020, time.October, 18, 0, 0, 0, 0, time.UTC),
+		},
+
+		{
+			"@monthly",
+			time.Date(2020, time.November, 1, 0, 0, 0, 0, time.UTC),
+		},
+
+		{
+			"0 0 1,15 * *",
+			time.Date(2020, time.October, 15, 0, 0, 0, 0, time.UTC),
+		},
+
+		{
+			// Either the 1st or a Friday
+			"0 0 1 * 5",
+			time.Date(2020, time.October, 16, 0, 0, 0, 0, time.UTC),
+		},
+
+		{
+			"0 0 29 2 *",
+			time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+		},
+
+		{
+			"0 0 30 2 *",
+			time.Time{},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Spec, func(t *testing.T) {
+			s, err := Parse(tt.Spec)
+			require.NoError(t, err)
+			require.Equal(t, tt.Expected, s.Next(start))
+		})
+	}
+}
@@ -0,0 +1,156 @@
+// Package redact masks sensitive values, such as the values of sensitive
+// config variables, in strings and protobuf messages.
+package redact
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Mask is the value that sensitive values are replaced with.
+const Mask = "<sensitive>"
+
+// MinLength is the minimum length of a value that is redacted. Shorter
+// values are ignored since masking them would mangle unrelated output.
+const MinLength = 4
+
+// Redactor replaces sensitive values with Mask. It is safe for concurrent
+// use. The zero value redacts nothing.
+type Redactor struct {
+	lock     sync.RWMutex
+	replacer *strings.Replacer
+}
+
+// SetValues replaces the set of sensitive values.
+func (r *Redactor) SetValues(values []string) {
+	// Sort longest first so that a value that contains another value
+	// is matched in full.
+	var sorted []string
+	seen := map[string]struct{}{}
+	for _, v := range values {
+		if len(v) < MinLength {
+			continue
+		}
+		if _, ok := seen[v]; ok {
+			continue
+		}
+
+		seen[v] = struct{}{}
+		sorted = append(sorted, v)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+
+		return sorted[i] < sorted[j]
+	})
+
+	var replacer *strings.Replacer
+	if len(sorted) > 0 {
+		oldnew := make([]string, 0, len(sorted)*2)
+		for _, v := range sorted {
+			oldnew = append(oldnew, v, Mask)
+		}
+
+		replacer = strings.NewReplacer(oldnew...)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.replacer = replacer
+}
+
+// Empty returns true if there are no sensitive values to redact.
+func (r *Redactor) Empty() bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.replacer == nil
+}
+
+// String returns v with all sensitive values masked.
+func (r *Redactor) String(v string) string {
+	r.lock.RLock()
+	replacer := r.replacer
+	r.lock.RUnlock()
+	if replacer == nil {
+		return v
+	}
+
+	return replacer.Replace(v)
+}
+
+// Message masks sensitive values in all the string fields of the message,
+// including nested messages, lists, and map values. The message is
+// modified in place, so callers should clone messages that are shared.
+// Bytes fields, including the contents of Any values, are not modified.
+func (r *Redactor) Message(m proto.Message) {
+	r.lock.RLock()
+	replacer := r.replacer
+	r.lock.RUnlock()
+	if replacer == nil || m == nil {
+		return
+	}
+
+	redactMessage(replacer, m.ProtoReflect())
+}
+
+func redactMessage(replacer *strings.Replacer, m protoreflect.Message) {
+	if !m.IsValid() {
+		return
+	}
+
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				if nv, ok := redactValue(replacer, fd, list.Get(i)); ok {
+					list.Set(i, nv)
+				}
+			}
+
+		case fd.IsMap():
+			mv := v.Map()
+			mv.Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+				if nv, ok := redactValue(replacer, fd.MapValue(), v); ok {
+					mv.Set(k, nv)
+				}
+
+				return true
+			})
+
+		default:
+			if nv, ok := redactValue(replacer, fd, v); ok {
+				m.Set(fd, nv)
+			}
+		}
+
+		return true
+	})
+}
+
+// redactValue redacts a single value. Messages are redacted in place. For
+// strings, this returns the new value and true if it changed.
+func redactValue(
+	replacer *strings.Replacer,
+	fd protoreflect.FieldDescriptor,
+	v protoreflect.Value,
+) (protoreflect.Value, bool) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		s := v.String()
+		if ns := replacer.Replace(s); ns != s {
+			return protoreflect.ValueOfString(ns), true
+		}
+
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		redactMessage(replacer, v.Message())
+	}
+
+	return v, false
+}
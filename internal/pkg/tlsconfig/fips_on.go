@@ -0,0 +1,6 @@
+// +build fips
+
+package tlsconfig
+
+// fipsBuild is true if the binary was built with the "fips" tag.
+const fipsBuild = true
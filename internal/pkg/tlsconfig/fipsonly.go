@@ -0,0 +1,7 @@
+// +build fips,boringcrypto
+
+package tlsconfig
+
+// When built with the BoringCrypto Go toolchain, this restricts all TLS
+// in the process to FIPS-approved settings using the validated module.
+import _ "crypto/tls/fipsonly"
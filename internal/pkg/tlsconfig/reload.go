@@ -0,0 +1,131 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// CertReloader loads a certificate and key from files and reloads them when
+// they change, so certificates can be rotated without restarting. Use
+// GetCertificate as the tls.Config.GetCertificate callback.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	lock    sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// NewCertReloader loads the certificate and key from the given files.
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetCertificate returns the current certificate. This has the signature
+// of tls.Config.GetCertificate.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.cert, nil
+}
+
+// Reload loads the certificate and key from disk. If loading fails, the
+// previous certificate is kept.
+func (r *CertReloader) Reload() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.cert = &cert
+	r.modTime = modTime
+	return nil
+}
+
+// Watch reloads the certificate when the process receives SIGHUP or, if
+// interval is greater than zero, when the files are modified. Files are
+// checked for modification every interval. This blocks until the context
+// is cancelled.
+func (r *CertReloader) Watch(ctx context.Context, interval time.Duration, log hclog.Logger) {
+	sighupCh := make(chan os.Signal, 1)
+	signal.Notify(sighupCh, syscall.SIGHUP)
+	defer signal.Stop(sighupCh)
+
+	var tickCh <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tickCh = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-sighupCh:
+			log.Info("SIGHUP received, reloading TLS certificate")
+
+		case <-tickCh:
+			modTime, err := r.latestModTime()
+			if err != nil {
+				log.Warn("error checking TLS certificate files", "err", err)
+				continue
+			}
+
+			r.lock.RLock()
+			changed := modTime.After(r.modTime)
+			r.lock.RUnlock()
+			if !changed {
+				continue
+			}
+
+			log.Info("TLS certificate files changed, reloading")
+		}
+
+		if err := r.Reload(); err != nil {
+			log.Error("error reloading TLS certificate, the previous certificate "+
+				"will continue to be used", "err", err)
+			continue
+		}
+
+		log.Info("TLS certificate reloaded", "cert", r.certFile, "key", r.keyFile)
+	}
+}
+
+// latestModTime returns the latest modification time of the cert and key.
+func (r *CertReloader) latestModTime() (time.Time, error) {
+	var result time.Time
+	for _, path := range []string{r.certFile, r.keyFile} {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return result, err
+		}
+
+		if fi.ModTime().After(result) {
+			result = fi.ModTime()
+		}
+	}
+
+	return result, nil
+}
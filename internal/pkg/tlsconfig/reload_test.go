@@ -0,0 +1,84 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertReloader(t *testing.T) {
+	require := require.New(t)
+
+	td, err := ioutil.TempDir("", "tlsconfig")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+	certFile := filepath.Join(td, "cert.pem")
+	keyFile := filepath.Join(td, "key.pem")
+
+	testWriteCert(t, certFile, keyFile, "first")
+	r, err := NewCertReloader(certFile, keyFile)
+	require.NoError(err)
+	require.Equal("first", testCertOrg(t, r))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Watch(ctx, 10*time.Millisecond, hclog.L())
+
+	// Write a new cert with a later modification time so the change is
+	// seen even on filesystems with coarse timestamps.
+	testWriteCert(t, certFile, keyFile, "second")
+	future := time.Now().Add(time.Minute)
+	require.NoError(os.Chtimes(certFile, future, future))
+	require.Eventually(func() bool {
+		return testCertOrg(t, r) == "second"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// A bad cert keeps the previous one
+	require.NoError(ioutil.WriteFile(certFile, []byte("nope"), 0600))
+	require.Error(r.Reload())
+	require.Equal("second", testCertOrg(t, r))
+}
+
+func testCertOrg(t *testing.T, r *CertReloader) string {
+	cert, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return parsed.Subject.Organization[0]
+}
+
+func testWriteCert(t *testing.T, certFile, keyFile, org string) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{Organization: []string{org}},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	require.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(priv)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(certFile,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, ioutil.WriteFile(keyFile,
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+}
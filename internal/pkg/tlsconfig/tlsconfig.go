@@ -0,0 +1,146 @@
+// Package tlsconfig builds TLS configurations from a policy of allowed
+// protocol versions and cipher suites, including a FIPS mode that only
+// allows FIPS-approved algorithms.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+// Policy is the TLS policy for a listener.
+type Policy struct {
+	// MinVersion is the minimum TLS version, such as "1.2". If this is
+	// empty, the minimum version is TLS 1.2.
+	MinVersion string
+
+	// CipherSuites are the names of the allowed cipher suites for TLS 1.2
+	// and earlier, such as "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256". If
+	// this is empty, Go's default secure cipher suites are used. TLS 1.3
+	// cipher suites are not configurable.
+	CipherSuites []string
+
+	// FIPS restricts TLS to FIPS-approved algorithms. See FIPSCipherSuites.
+	// This is always true if the binary was built with the "fips" tag.
+	FIPS bool
+}
+
+// versions are the TLS versions by name.
+var versions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// FIPSCipherSuites are the cipher suites allowed in FIPS mode. These use
+// ECDHE key exchange over NIST curves with AES-GCM. TLS 1.3 is disabled in
+// FIPS mode since its cipher suites can't be restricted and include
+// ChaCha20-Poly1305.
+var FIPSCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+// FIPSCurves are the elliptic curves allowed in FIPS mode.
+var FIPSCurves = []tls.CurveID{
+	tls.CurveP256,
+	tls.CurveP384,
+	tls.CurveP521,
+}
+
+// FIPSBuild returns true if the binary was built with the "fips" tag. FIPS
+// mode is always enabled for these builds.
+func FIPSBuild() bool {
+	return fipsBuild
+}
+
+// ParseVersion parses a TLS version such as "1.2".
+func ParseVersion(v string) (uint16, error) {
+	result, ok := versions[strings.TrimPrefix(strings.ToLower(v), "tls")]
+	if !ok {
+		return 0, fmt.Errorf("unknown TLS version %q, must be one of 1.0, 1.1, 1.2, 1.3", v)
+	}
+
+	return result, nil
+}
+
+// ParseCipherSuites parses cipher suite names. Only cipher suites that Go
+// considers secure are allowed.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	known := map[string]uint16{}
+	for _, cs := range tls.CipherSuites() {
+		known[cs.Name] = cs.ID
+	}
+
+	var result []uint16
+	for _, name := range names {
+		id, ok := known[strings.ToUpper(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure TLS cipher suite %q", name)
+		}
+
+		result = append(result, id)
+	}
+
+	return result, nil
+}
+
+// Apply applies the policy to the TLS configuration. This returns an error
+// if the policy is invalid, including if FIPS mode is enabled and the
+// policy allows algorithms that aren't FIPS-approved.
+func Apply(cfg *tls.Config, p *Policy) error {
+	fips := p.FIPS || fipsBuild
+
+	minVersion := uint16(tls.VersionTLS12)
+	if p.MinVersion != "" {
+		v, err := ParseVersion(p.MinVersion)
+		if err != nil {
+			return err
+		}
+
+		minVersion = v
+	}
+
+	suites, err := ParseCipherSuites(p.CipherSuites)
+	if err != nil {
+		return err
+	}
+
+	if fips {
+		if minVersion != tls.VersionTLS12 {
+			return fmt.Errorf("FIPS mode requires a minimum TLS version of 1.2")
+		}
+
+		for _, id := range suites {
+			if !fipsCipherSuite(id) {
+				return fmt.Errorf(
+					"TLS cipher suite %s is not allowed in FIPS mode", tls.CipherSuiteName(id))
+			}
+		}
+		if len(suites) == 0 {
+			suites = FIPSCipherSuites
+		}
+
+		cfg.MaxVersion = tls.VersionTLS12
+		cfg.CurvePreferences = FIPSCurves
+	}
+
+	cfg.MinVersion = minVersion
+	cfg.CipherSuites = suites
+	cfg.PreferServerCipherSuites = true
+	return nil
+}
+
+func fipsCipherSuite(id uint16) bool {
+	for _, v := range FIPSCipherSuites {
+		if v == id {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,64 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		if FIPSBuild() {
+			t.Skip("FIPS mode is always enabled in FIPS builds")
+		}
+
+		require := require.New(t)
+
+		var cfg tls.Config
+		require.NoError(Apply(&cfg, &Policy{}))
+		require.Equal(uint16(tls.VersionTLS12), cfg.MinVersion)
+		require.Empty(cfg.CipherSuites)
+	})
+
+	t.Run("min version and cipher suites", func(t *testing.T) {
+		if FIPSBuild() {
+			t.Skip("FIPS mode is always enabled in FIPS builds")
+		}
+
+		require := require.New(t)
+
+		var cfg tls.Config
+		require.NoError(Apply(&cfg, &Policy{
+			MinVersion:   "1.3",
+			CipherSuites: []string{"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256"},
+		}))
+		require.Equal(uint16(tls.VersionTLS13), cfg.MinVersion)
+		require.Equal([]uint16{tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256}, cfg.CipherSuites)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		require := require.New(t)
+
+		var cfg tls.Config
+		require.Error(Apply(&cfg, &Policy{MinVersion: "2.0"}))
+		require.Error(Apply(&cfg, &Policy{CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}}))
+	})
+
+	t.Run("FIPS", func(t *testing.T) {
+		require := require.New(t)
+
+		var cfg tls.Config
+		require.NoError(Apply(&cfg, &Policy{FIPS: true}))
+		require.Equal(FIPSCipherSuites, cfg.CipherSuites)
+		require.Equal(uint16(tls.VersionTLS12), cfg.MaxVersion)
+		require.Equal(FIPSCurves, cfg.CurvePreferences)
+
+		// Non-approved algorithms are rejected
+		require.Error(Apply(&cfg, &Policy{FIPS: true, MinVersion: "1.3"}))
+		require.Error(Apply(&cfg, &Policy{
+			FIPS:         true,
+			CipherSuites: []string{"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256"},
+		}))
+	})
+}
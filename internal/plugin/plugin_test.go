@@ -0,0 +1,22 @@
+package plugin
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint/internal/telemetry"
+)
+
+func TestBuiltins_telemetry(t *testing.T) {
+	// Every builtin must be known to telemetry so that its name is reported
+	// rather than being counted as a custom plugin.
+	var names []string
+	for k := range Builtins {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	require.Equal(t, names, telemetry.BuiltinPlugins)
+}
@@ -2,11 +2,13 @@ package runner
 
 import (
 	"context"
+	"crypto/ed25519"
 	"io"
 	"path/filepath"
 	"sync"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -80,7 +82,24 @@ func (r *Runner) accept(ctx context.Context, id string) error {
 			"expected job assignment, server sent %T",
 			resp.Event)
 	}
-	log = log.With("job_id", assignment.Assignment.Job.Id)
+
+	// Verify the assignment came from the server unaltered. If it fails,
+	// we report an error so the server requeues the job.
+	job, err := r.verifyAssignment(assignment.Assignment)
+	if err != nil {
+		log.Error("job assignment failed verification, rejecting", "err", err)
+		if err := client.Send(&pb.RunnerJobStreamRequest{
+			Event: &pb.RunnerJobStreamRequest_Error_{
+				Error: &pb.RunnerJobStreamRequest_Error{},
+			},
+		}); err != nil {
+			return err
+		}
+
+		return err
+	}
+
+	log = log.With("job_id", job.Id)
 	log.Info("job assignment received")
 
 	// We increment the waitgroup at this point since prior to this if we're
@@ -92,7 +111,7 @@ func (r *Runner) accept(ctx context.Context, id string) error {
 
 	// If this isn't the job we expected then we nack and error.
 	if id != "" {
-		if assignment.Assignment.Job.Id != id {
+		if job.Id != id {
 			log.Warn("unexpected job id for exact match, nacking")
 			if err := client.Send(&pb.RunnerJobStreamRequest{
 				Event: &pb.RunnerJobStreamRequest_Error_{
@@ -190,13 +209,23 @@ func (r *Runner) accept(ctx context.Context, id string) error {
 	}()
 
 	// We need to get our data source next prior to executing.
+	sendMutex.Lock()
+	if err := client.Send(&pb.RunnerJobStreamRequest{
+		Event: &pb.RunnerJobStreamRequest_Progress{
+			Progress: &pb.JobProgress{Step: "Downloading data"},
+		},
+	}); err != nil {
+		log.Warn("error sending job progress", "err", err)
+	}
+	sendMutex.Unlock()
+
 	var result *pb.Job_Result
-	wd, closer, err := r.downloadJobData(
+	wd, ref, closer, err := r.downloadJobData(
 		ctx,
 		log,
 		ui,
-		assignment.Assignment.Job.DataSource,
-		assignment.Assignment.Job.DataSourceOverrides,
+		job.DataSource,
+		job.DataSourceOverrides,
 	)
 	if err == nil {
 		log.Debug("job data downloaded (or local)", "pwd", wd)
@@ -216,11 +245,27 @@ func (r *Runner) accept(ctx context.Context, id string) error {
 				"data working directory should be absolute. This is a bug, please report it.")
 		}
 
+		// Let the server know the exact revision we're running.
+		if err == nil && ref != nil {
+			sendMutex.Lock()
+			serr := client.Send(&pb.RunnerJobStreamRequest{
+				Event: &pb.RunnerJobStreamRequest_Download_{
+					Download: &pb.RunnerJobStreamRequest_Download{
+						DataSourceRef: ref,
+					},
+				},
+			})
+			sendMutex.Unlock()
+			if serr != nil {
+				log.Warn("error sending data source ref", "err", serr)
+			}
+		}
+
 		if err == nil {
 			// Execute the job. We have to close the UI right afterwards to
 			// ensure that no more output is writting to the client.
 			log.Info("starting job execution")
-			result, err = r.executeJob(ctx, log, ui, assignment.Assignment.Job, wd)
+			result, err = r.executeJob(ctx, log, ui, job, wd)
 			if ui, ok := ui.(*runnerUI); ok {
 				ui.Close()
 			}
@@ -289,3 +334,41 @@ func (r *Runner) accept(ctx context.Context, id string) error {
 
 	return err
 }
+
+// verifyAssignment verifies the signature of a job assignment and returns
+// the job to execute, which is always decoded from the signed payload. If
+// a job signing key is pinned, unsigned jobs are rejected.
+func (r *Runner) verifyAssignment(a *pb.RunnerJobStreamResponse_JobAssignment) (*pb.Job, error) {
+	// If no key is pinned, we don't verify the signature. We warned about
+	// this when we registered.
+	if r.jobSigningKey != nil {
+		if len(a.JobPayload) == 0 || len(a.Signature) == 0 {
+			return nil, status.Errorf(codes.Aborted,
+				"server sent an unsigned job assignment")
+		}
+
+		if !ed25519.Verify(r.jobSigningKey, a.JobPayload, a.Signature) {
+			return nil, status.Errorf(codes.Aborted,
+				"job assignment signature is invalid")
+		}
+	}
+
+	var payload pb.RunnerJobStreamResponse_JobAssignment_Payload
+	if err := proto.Unmarshal(a.JobPayload, &payload); err != nil {
+		return nil, status.Errorf(codes.Aborted,
+			"job assignment payload is invalid: %s", err)
+	}
+	if payload.Job == nil || payload.Job.Id != payload.JobId {
+		return nil, status.Errorf(codes.Aborted,
+			"job assignment payload is invalid: job doesn't match %q", payload.JobId)
+	}
+
+	// The runner is signed with the job so that an assignment sent to
+	// another runner can't be replayed to us.
+	if payload.RunnerId != r.id {
+		return nil, status.Errorf(codes.Aborted,
+			"job assignment is for runner %q", payload.RunnerId)
+	}
+
+	return payload.Job, nil
+}
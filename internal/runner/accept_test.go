@@ -2,13 +2,19 @@ package runner
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
 	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
@@ -201,3 +207,117 @@ func testGitFixture(t *testing.T, n string) string {
 
 	return path
 }
+
+func TestRunnerAccept_jobSignatureInvalid(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// Pin a key that the server doesn't sign with
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(err)
+
+	// Setup our runner
+	client := singleprocess.TestServer(t)
+	runner := TestRunner(t, WithClient(client), WithJobSigningKey(pub))
+	require.NoError(runner.Start())
+
+	// Initialize our app
+	singleprocess.TestApp(t, client, serverptypes.TestJobNew(t, nil).Application)
+
+	// Queue a job
+	_, err = client.QueueJob(ctx, &pb.QueueJobRequest{
+		Job: serverptypes.TestJobNew(t, nil),
+	})
+	require.NoError(err)
+
+	// Accept should reject the job
+	err = runner.Accept(ctx)
+	require.Error(err)
+	require.Equal(codes.Aborted, status.Code(err))
+}
+
+func TestRunnerAccept_jobSigningKeyNotTrusted(t *testing.T) {
+	require := require.New(t)
+
+	// Setup our runner without a pinned key
+	client := singleprocess.TestServer(t)
+	runner := TestRunner(t, WithClient(client))
+	require.NoError(runner.Start())
+
+	// The key the server sent isn't used to verify jobs
+	require.Nil(runner.jobSigningKey)
+}
+
+func TestRunnerVerifyAssignment(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	job := serverptypes.TestJobNew(t, nil)
+	job.Id = "A"
+	payload, err := proto.Marshal(&pb.RunnerJobStreamResponse_JobAssignment_Payload{
+		RunnerId:   "R",
+		JobId:      "A",
+		AssignTime: ptypes.TimestampNow(),
+		Job:        job,
+	})
+	require.NoError(t, err)
+
+	t.Run("valid", func(t *testing.T) {
+		require := require.New(t)
+
+		r := &Runner{id: "R", jobSigningKey: pub}
+		result, err := r.verifyAssignment(&pb.RunnerJobStreamResponse_JobAssignment{
+			JobPayload: payload,
+			Signature:  ed25519.Sign(priv, payload),
+		})
+		require.NoError(err)
+		require.Equal("A", result.Id)
+	})
+
+	t.Run("altered", func(t *testing.T) {
+		require := require.New(t)
+
+		altered := append([]byte{}, payload...)
+		altered[len(altered)-1] ^= 1
+
+		r := &Runner{id: "R", jobSigningKey: pub}
+		_, err := r.verifyAssignment(&pb.RunnerJobStreamResponse_JobAssignment{
+			JobPayload: altered,
+			Signature:  ed25519.Sign(priv, payload),
+		})
+		require.Error(err)
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		require := require.New(t)
+
+		r := &Runner{id: "R", jobSigningKey: pub}
+		_, err := r.verifyAssignment(&pb.RunnerJobStreamResponse_JobAssignment{
+			JobPayload: payload,
+		})
+		require.Error(err)
+	})
+
+	t.Run("replayed to another runner", func(t *testing.T) {
+		require := require.New(t)
+
+		r := &Runner{id: "B", jobSigningKey: pub}
+		_, err := r.verifyAssignment(&pb.RunnerJobStreamResponse_JobAssignment{
+			JobPayload: payload,
+			Signature:  ed25519.Sign(priv, payload),
+		})
+		require.Error(err)
+		require.Equal(codes.Aborted, status.Code(err))
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		require := require.New(t)
+
+		r := &Runner{id: "R"}
+		result, err := r.verifyAssignment(&pb.RunnerJobStreamResponse_JobAssignment{
+			JobPayload: payload,
+		})
+		require.NoError(err)
+		require.Equal("A", result.Id)
+	})
+}
@@ -2,9 +2,13 @@ package runner
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
 )
@@ -92,6 +96,111 @@ func (r *Runner) handleConfig(c *pb.RunnerConfig) {
 			}
 		}
 	}
+
+	// Handle config file changes
+	{
+		// Versions of the files we already wrote so we only write the
+		// files that changed.
+		written := map[string]uint64{}
+		if old != nil {
+			for _, f := range old.ConfigFiles {
+				written[f.Path] = f.Version
+			}
+		}
+
+		// Remove the files that are no longer in the config
+		current := map[string]struct{}{}
+		for _, f := range c.ConfigFiles {
+			current[f.Path] = struct{}{}
+		}
+		for p := range written {
+			if _, ok := current[p]; ok {
+				continue
+			}
+
+			path, err := r.configFilePath(p)
+			if err != nil {
+				r.logger.Warn("error removing config file", "path", p, "err", err)
+				continue
+			}
+
+			r.logger.Info("removing config file", "path", path)
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				r.logger.Warn("error removing config file", "path", path, "err", err)
+			}
+		}
+
+		// Write the files that are new or changed
+		for _, f := range c.ConfigFiles {
+			if v, ok := written[f.Path]; ok && v == f.Version {
+				continue
+			}
+
+			if err := r.writeConfigFile(f); err != nil {
+				r.logger.Warn("error writing config file", "path", f.Path, "err", err)
+			}
+		}
+
+		if old == nil || old.ConfigVersion != c.ConfigVersion {
+			r.logger.Info("config files up to date",
+				"version", c.ConfigVersion, "files", len(c.ConfigFiles))
+		}
+	}
+
+	// Handle the published runner version
+	r.handleUpdate(c)
+}
+
+// configFilePath returns the local path of the config file with the given
+// path from the server. This returns an error if the path would be outside
+// of the config directory.
+func (r *Runner) configFilePath(p string) (string, error) {
+	dir := filepath.Clean(r.configDir)
+	path := filepath.Join(dir, filepath.FromSlash(p))
+	if !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+		return "", fmt.Errorf("config file path %q is outside of the config directory", p)
+	}
+
+	return path, nil
+}
+
+// writeConfigFile writes a config file from the server in the config
+// directory. The file is written to a temporary file first and renamed so
+// that jobs never read a partially written file.
+func (r *Runner) writeConfigFile(f *pb.RunnerConfigFile) error {
+	path, err := r.configFilePath(f.Path)
+	if err != nil {
+		return err
+	}
+
+	mode := os.FileMode(f.Mode).Perm()
+	if mode == 0 {
+		mode = 0600
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(f.Data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+
+	r.logger.Info("writing config file", "path", path, "version", f.Version)
+	return os.Rename(tmp.Name(), path)
 }
 
 func (r *Runner) recvConfig(
@@ -124,3 +233,35 @@ func (r *Runner) recvConfig(
 		ch <- resp.Config
 	}
 }
+
+// heartbeatConfig sends heartbeats on the config stream until ctx is done.
+// The server deregisters runners that stop sending them.
+func (r *Runner) heartbeatConfig(
+	ctx context.Context,
+	client pb.Waypoint_RunnerConfigClient,
+) {
+	log := r.logger.Named("config_heartbeat")
+	tick := time.NewTicker(heartbeatDuration)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-tick.C:
+		}
+
+		err := client.Send(&pb.RunnerConfigRequest{
+			Event: &pb.RunnerConfigRequest_Heartbeat_{
+				Heartbeat: &pb.RunnerConfigRequest_Heartbeat{},
+			},
+		})
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			log.Warn("error during heartbeat", "err", err)
+		}
+	}
+}
@@ -14,7 +14,9 @@ import (
 )
 
 // downloadJobData takes the data source of the given job, gets the data,
-// and returns the directory where the data is stored.
+// and returns the directory where the data is stored. If the exact
+// revision of the data is known, such as the Git commit, that is returned
+// too. Otherwise the ref is nil.
 //
 // This will also return a closer function that should be deferred to
 // clean up any resources created by this. Note that the directory isn't
@@ -26,9 +28,9 @@ func (r *Runner) downloadJobData(
 	ui terminal.UI,
 	source *pb.Job_DataSource,
 	overrides map[string]string,
-) (string, func() error, error) {
+) (string, *pb.Job_DataSource_Ref, func() error, error) {
 	if source == nil {
-		return "", nil, status.Errorf(codes.Internal,
+		return "", nil, nil, status.Errorf(codes.Internal,
 			"data source not set for job")
 	}
 
@@ -36,7 +38,7 @@ func (r *Runner) downloadJobData(
 	typ := reflect.TypeOf(source.Source)
 	factory, ok := datasource.FromType[typ]
 	if !ok {
-		return "", nil, status.Errorf(codes.FailedPrecondition,
+		return "", nil, nil, status.Errorf(codes.FailedPrecondition,
 			"invalid data source type: %s", typ.String())
 	}
 	sourcer := factory()
@@ -44,11 +46,27 @@ func (r *Runner) downloadJobData(
 	// Apply any overrides
 	if len(overrides) > 0 {
 		if err := sourcer.Override(source, overrides); err != nil {
-			return "", nil, status.Errorf(codes.FailedPrecondition,
+			return "", nil, nil, status.Errorf(codes.FailedPrecondition,
 				"error with data source overrides: %s", err)
 		}
 	}
 
 	// Get data
-	return sourcer.Get(ctx, log, ui, source, r.tempDir)
+	wd, closer, err := sourcer.Get(ctx, log, ui, source, r.tempDir)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	// Determine the revision. This is informational so we only warn if
+	// it fails.
+	var ref *pb.Job_DataSource_Ref
+	if rs, ok := sourcer.(datasource.RefSourcer); ok {
+		ref, err = rs.Ref(ctx, wd)
+		if err != nil {
+			log.Warn("error determining data source ref", "err", err)
+			ref = nil
+		}
+	}
+
+	return wd, ref, closer, nil
 }
@@ -43,6 +43,11 @@ func (r *Runner) executeJob(
 		return nil, err
 	}
 
+	// Make the variables resolved by the server available to the config
+	if err := cfg.SetVariables(job.Variables); err != nil {
+		return nil, err
+	}
+
 	// Setup our project data directory.
 	projDir, err := datadir.NewProject(".waypoint")
 	if err != nil {
@@ -1,8 +1,13 @@
 package runner
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
 	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
 	"sync"
 	"sync/atomic"
 
@@ -16,6 +21,7 @@ import (
 	"github.com/hashicorp/waypoint/internal/plugin"
 	"github.com/hashicorp/waypoint/internal/server"
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/version"
 )
 
 var ErrClosed = errors.New("runner is closed")
@@ -59,6 +65,24 @@ type Runner struct {
 	config      *pb.RunnerConfig
 	originalEnv []*pb.ConfigVar
 
+	// configDir is the directory that the runner config files from the
+	// server are written in. See WithConfigDir.
+	configDir string
+
+	// jobSigningKey is the server's public key used to verify job
+	// assignments. This is only set with WithJobSigningKey. The key the
+	// server sends is never trusted since it arrives on the same stream
+	// as the jobs it would verify.
+	jobSigningKey ed25519.PublicKey
+
+	// updateCommand is the command that updates the runner to the runner
+	// version published by the server. See WithUpdateCommand. The other
+	// fields track the update, see update.go.
+	updateCommand string
+	updateVersion string
+	updatingVal   int32
+	updatedCh     chan struct{}
+
 	// noopCh is used in tests only. This will cause any noop operations
 	// to block until this channel is closed.
 	noopCh <-chan struct{}
@@ -78,10 +102,11 @@ func New(opts ...Option) (*Runner, error) {
 
 	// Our default runner
 	runner := &Runner{
-		id:     id,
-		logger: hclog.L(),
-		ctx:    context.Background(),
-		runner: &pb.Runner{Id: id},
+		id:        id,
+		logger:    hclog.L(),
+		ctx:       context.Background(),
+		runner:    &pb.Runner{Id: id},
+		updatedCh: make(chan struct{}),
 		factories: map[component.Type]*factory.Factory{
 			component.MapperType:         plugin.BaseFactories[component.MapperType],
 			component.BuilderType:        plugin.BaseFactories[component.BuilderType],
@@ -100,6 +125,26 @@ func New(opts ...Option) (*Runner, error) {
 		}
 	}
 
+	// Config files are written in a directory of their own by default.
+	if runner.configDir == "" {
+		runner.configDir = filepath.Join(os.TempDir(), "waypoint-runner-"+runner.id)
+	}
+
+	// Describe the runner so jobs can be matched to it. Builtin plugins
+	// are the same version as the runner.
+	ver := version.GetVersion().VersionNumber()
+	runner.runner.Os = runtime.GOOS
+	runner.runner.Arch = runtime.GOARCH
+	runner.runner.Version = ver
+	if runner.runner.PluginVersions == nil {
+		runner.runner.PluginVersions = map[string]string{}
+	}
+	for name := range plugin.Builtins {
+		if _, ok := runner.runner.PluginVersions[name]; !ok {
+			runner.runner.PluginVersions[name] = ver
+		}
+	}
+
 	// Setup our runner components list
 	for t, f := range runner.factories {
 		for _, n := range f.Registered() {
@@ -134,7 +179,16 @@ func (r *Runner) Start() error {
 	if err != nil {
 		return err
 	}
-	r.cleanup(func() { client.CloseSend() })
+
+	// Heartbeats are sent until we close, and must stop before we close
+	// the stream since sending isn't safe concurrently with closing.
+	heartbeatCtx, heartbeatCancel := context.WithCancel(r.ctx)
+	var heartbeatWg sync.WaitGroup
+	r.cleanup(func() {
+		heartbeatCancel()
+		heartbeatWg.Wait()
+		client.CloseSend()
+	})
 
 	// Send request
 	if err := client.Send(&pb.RunnerConfigRequest{
@@ -157,6 +211,17 @@ func (r *Runner) Start() error {
 	// Handle the first config so our initial setup is done
 	r.handleConfig(resp.Config)
 
+	// Job assignments are only verified with a pinned key. We don't
+	// trust the key the server sent, but we warn if it doesn't match so
+	// a misconfigured key is obvious before jobs are rejected.
+	if r.jobSigningKey == nil {
+		log.Warn("no job signing key is pinned, job assignments are not verified")
+	} else if len(resp.Config.JobSigningKey) > 0 &&
+		!bytes.Equal(r.jobSigningKey, resp.Config.JobSigningKey) {
+		log.Error("the server's job signing key doesn't match the pinned key, " +
+			"job assignments will be rejected")
+	}
+
 	// Start the watcher
 	ch := make(chan *pb.RunnerConfig)
 	go r.watchConfig(ch)
@@ -164,6 +229,14 @@ func (r *Runner) Start() error {
 	// Start the goroutine that waits for all other configs
 	go r.recvConfig(r.ctx, client, ch)
 
+	// Send heartbeats so the server knows we're alive even if it can't
+	// tell from the stream.
+	heartbeatWg.Add(1)
+	go func() {
+		defer heartbeatWg.Done()
+		r.heartbeatConfig(heartbeatCtx, client)
+	}()
+
 	log.Info("runner registered with server")
 	return nil
 }
@@ -236,6 +309,161 @@ func WithLocal(ui terminal.UI) Option {
 	}
 }
 
+// WithJobSigningKey pins the public key used to verify job assignments
+// from the server. Assignments that aren't signed with this key are
+// rejected. If this isn't set, assignments aren't verified.
+func WithJobSigningKey(key ed25519.PublicKey) Option {
+	return func(r *Runner, cfg *config) error {
+		if len(key) != ed25519.PublicKeySize {
+			return status.Errorf(codes.InvalidArgument,
+				"job signing key must be %d bytes", ed25519.PublicKeySize)
+		}
+
+		r.jobSigningKey = key
+		return nil
+	}
+}
+
+// WithId sets the ID of the runner rather than generating one. This is
+// used for runners started for jobs that already target the runner's ID.
+func WithId(id string) Option {
+	return func(r *Runner, cfg *config) error {
+		r.id = id
+		r.runner.Id = id
+		return nil
+	}
+}
+
+// WithLabels sets the labels of the runner. Jobs can target runners with
+// specific labels.
+func WithLabels(labels map[string]string) Option {
+	return func(r *Runner, cfg *config) error {
+		r.runner.Labels = labels
+		return nil
+	}
+}
+
+// WithPool sets the name of the runner pool the runner joins. Jobs can
+// target the runners of a pool.
+func WithPool(name string) Option {
+	return func(r *Runner, cfg *config) error {
+		r.runner.Pool = name
+		return nil
+	}
+}
+
+// WithProfile sets the name of the runner profile the runner was launched
+// with. Only jobs that target the profile are assigned to the runner.
+func WithProfile(name string) Option {
+	return func(r *Runner, cfg *config) error {
+		r.runner.Profile = name
+		return nil
+	}
+}
+
+// WithCapacity sets the CPU in millicores and memory in megabytes that the
+// runner has for jobs. Jobs that require more than the remaining capacity
+// aren't assigned to the runner. Zero values are unlimited.
+func WithCapacity(cpu, memory int64) Option {
+	return func(r *Runner, cfg *config) error {
+		if cpu > 0 || memory > 0 {
+			r.runner.Capacity = &pb.Resources{Cpu: cpu, Memory: memory}
+		}
+
+		return nil
+	}
+}
+
+// WithMaxJobs sets the most jobs the runner runs at once. The server
+// doesn't assign the runner more jobs while it has this many. Zero is
+// unlimited.
+func WithMaxJobs(n uint32) Option {
+	return func(r *Runner, cfg *config) error {
+		r.runner.MaxJobs = n
+		return nil
+	}
+}
+
+// WithOperations sets the operation types the runner runs, such as
+// "build" or "deploy". The server only assigns the runner jobs of these
+// types. If none are set, the runner runs jobs of any type.
+func WithOperations(ops []string) Option {
+	return func(r *Runner, cfg *config) error {
+		r.runner.Operations = ops
+		return nil
+	}
+}
+
+// WithResourceTags sets the resource tags that describe the specialized
+// hardware the runner has, such as "gpu". Jobs that require tags are only
+// assigned to runners that have all of them.
+func WithResourceTags(tags []string) Option {
+	return func(r *Runner, cfg *config) error {
+		r.runner.ResourceTags = tags
+		return nil
+	}
+}
+
+// WithLocality sets the region and zone the runner runs in, such as
+// "us-east-1" and "us-east-1a". Jobs that prefer a locality are assigned
+// to runners in it first.
+func WithLocality(region, zone string) Option {
+	return func(r *Runner, cfg *config) error {
+		r.runner.Region = region
+		r.runner.Zone = zone
+		return nil
+	}
+}
+
+// WithCost sets the relative cost of running jobs on the runner, such as
+// 1 for spot capacity and 3 for on-demand capacity. Servers that prefer
+// cheap runners assign jobs to cheaper runners first.
+func WithCost(cost float64) Option {
+	return func(r *Runner, cfg *config) error {
+		r.runner.Cost = cost
+		return nil
+	}
+}
+
+// WithPluginVersions sets the versions of plugins the runner has by plugin
+// name, such as plugins that aren't builtin. Builtin plugins default to the
+// version of the runner.
+func WithPluginVersions(versions map[string]string) Option {
+	return func(r *Runner, cfg *config) error {
+		if r.runner.PluginVersions == nil {
+			r.runner.PluginVersions = map[string]string{}
+		}
+		for k, v := range versions {
+			r.runner.PluginVersions[k] = v
+		}
+
+		return nil
+	}
+}
+
+// WithConfigDir sets the directory that the runner config files from the
+// server are written in. If this isn't set, a directory for the runner in
+// the system temporary directory is used.
+func WithConfigDir(dir string) Option {
+	return func(r *Runner, cfg *config) error {
+		r.configDir = dir
+		return nil
+	}
+}
+
+// WithUpdateCommand sets the command that updates the runner, such as a
+// script that installs the runner binary of the version in the
+// WAYPOINT_RUNNER_VERSION environment variable. When the server publishes
+// a runner version other than ours, the runner finishes its jobs and runs
+// the command, and then closes Updated so that it's restarted. The command
+// is run with the system shell.
+func WithUpdateCommand(cmd string) Option {
+	return func(r *Runner, cfg *config) error {
+		r.updateCommand = cmd
+		return nil
+	}
+}
+
 // ByIdOnly sets it so that only jobs that target this runner by specific
 // ID may be assigned.
 func ByIdOnly() Option {
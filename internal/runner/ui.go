@@ -191,12 +191,9 @@ func (u *runnerUI) sendData(r io.ReadCloser, stderr bool) {
 }
 
 func (u *runnerUI) Table(tbl *terminal.Table, opts ...terminal.Option) {
-	var (
-		ptbl *pb.GetJobStreamResponse_Terminal_Event_Table
-		rows []*pb.GetJobStreamResponse_Terminal_Event_TableRow
-	)
-
-	ptbl.Headers = tbl.Headers
+	ptbl := &pb.GetJobStreamResponse_Terminal_Event_Table{
+		Headers: tbl.Headers,
+	}
 
 	for _, row := range tbl.Rows {
 		var entries []*pb.GetJobStreamResponse_Terminal_Event_TableEntry
@@ -208,7 +205,7 @@ func (u *runnerUI) Table(tbl *terminal.Table, opts ...terminal.Option) {
 			})
 		}
 
-		rows = append(rows, &pb.GetJobStreamResponse_Terminal_Event_TableRow{
+		ptbl.Rows = append(ptbl.Rows, &pb.GetJobStreamResponse_Terminal_Event_TableRow{
 			Entries: entries,
 		})
 	}
@@ -574,6 +571,13 @@ func (u *runnerUISG) Add(str string, args ...interface{}) terminal.Step {
 
 	u.ui.evc.Send(ev)
 
+	// Each step is reported as the progress of the job.
+	u.ui.evc.Send(&pb.RunnerJobStreamRequest{
+		Event: &pb.RunnerJobStreamRequest_Progress{
+			Progress: &pb.JobProgress{Step: msg},
+		},
+	})
+
 	return step
 }
 
@@ -0,0 +1,106 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync/atomic"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// handleUpdate starts updating the runner if the server published a
+// runner version other than ours and we have an update command. Each
+// published version is only tried once.
+//
+// This is NOT thread-safe and must only be called from handleConfig.
+func (r *Runner) handleUpdate(c *pb.RunnerConfig) {
+	v := c.DesiredVersion
+	if v == "" || r.updateCommand == "" || v == r.updateVersion {
+		return
+	}
+	if strings.TrimPrefix(v, "v") == strings.TrimPrefix(r.runner.Version, "v") {
+		return
+	}
+
+	// Only one update runs at a time.
+	if !atomic.CompareAndSwapInt32(&r.updatingVal, 0, 1) {
+		return
+	}
+
+	r.updateVersion = v
+	go r.update(v)
+}
+
+// update updates the runner to the given version. The server drains us
+// when we report that we're updating, so once the jobs we're running are
+// done we run the update command. If it succeeds, Updated is closed so
+// that we're restarted with the new version.
+func (r *Runner) update(version string) {
+	log := r.logger.With("version", version)
+	log.Info("server published a new runner version, updating")
+
+	if err := r.reportUpdate(pb.RunnerUpdate_UPDATING, ""); err != nil {
+		log.Warn("error reporting runner update, not updating", "err", err)
+		atomic.StoreInt32(&r.updatingVal, 0)
+		return
+	}
+
+	log.Info("waiting for running jobs to complete before updating")
+	r.acceptWg.Wait()
+
+	log.Info("running update command")
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(r.ctx, "cmd", "/C", r.updateCommand)
+	} else {
+		cmd = exec.CommandContext(r.ctx, "/bin/sh", "-c", r.updateCommand)
+	}
+	cmd.Env = append(os.Environ(),
+		"WAYPOINT_RUNNER_ID="+r.id,
+		"WAYPOINT_RUNNER_VERSION="+version,
+	)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Error("update command failed", "err", err, "output", string(out))
+
+		msg := err.Error()
+		if tail := strings.TrimSpace(string(out)); tail != "" {
+			if len(tail) > 1024 {
+				tail = tail[len(tail)-1024:]
+			}
+
+			msg = fmt.Sprintf("%s: %s", msg, tail)
+		}
+		if err := r.reportUpdate(pb.RunnerUpdate_FAILED, msg); err != nil {
+			log.Warn("error reporting runner update failure", "err", err)
+		}
+
+		atomic.StoreInt32(&r.updatingVal, 0)
+		return
+	}
+
+	log.Info("update command complete, the runner must be restarted")
+	close(r.updatedCh)
+}
+
+// reportUpdate reports the state of our update to the server.
+func (r *Runner) reportUpdate(st pb.RunnerUpdate_State, msg string) error {
+	_, err := r.client.SetRunnerUpdateState(r.ctx, &pb.SetRunnerUpdateStateRequest{
+		RunnerId: r.id,
+		State:    st,
+		Error:    msg,
+	})
+
+	return err
+}
+
+// Updated returns a channel that is closed when the update command ran
+// successfully. The runner should be closed and restarted so that it
+// runs the new version.
+func (r *Runner) Updated() <-chan struct{} {
+	return r.updatedCh
+}
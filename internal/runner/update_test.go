@@ -0,0 +1,87 @@
+package runner
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/singleprocess"
+)
+
+func TestRunnerUpdate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("update command uses the unix shell")
+	}
+
+	t.Run("update command runs", func(t *testing.T) {
+		require := require.New(t)
+		ctx := context.Background()
+		client := singleprocess.TestServer(t)
+
+		out := filepath.Join(testTempDir(t), "version")
+		runner, err := New(
+			WithClient(client),
+			WithUpdateCommand(`printf '%s' "$WAYPOINT_RUNNER_VERSION" > `+out),
+		)
+		require.NoError(err)
+		defer runner.Close()
+		require.NoError(runner.Start())
+
+		_, err = client.SetRunnerVersion(ctx, &pb.SetRunnerVersionRequest{Version: "99.0.0"})
+		require.NoError(err)
+
+		select {
+		case <-runner.Updated():
+		case <-time.After(5 * time.Second):
+			t.Fatal("runner didn't update")
+		}
+
+		data, err := ioutil.ReadFile(out)
+		require.NoError(err)
+		require.Equal("99.0.0", string(data))
+
+		// The runner is drained while it restarts
+		resp, err := client.GetRunnerVersion(ctx, &empty.Empty{})
+		require.NoError(err)
+		require.Len(resp.Updates, 1)
+		require.Equal(pb.RunnerUpdate_UPDATING, resp.Updates[0].State)
+	})
+
+	t.Run("update command fails", func(t *testing.T) {
+		require := require.New(t)
+		ctx := context.Background()
+		client := singleprocess.TestServer(t)
+
+		runner, err := New(
+			WithClient(client),
+			WithUpdateCommand("echo nope; exit 1"),
+		)
+		require.NoError(err)
+		defer runner.Close()
+		require.NoError(runner.Start())
+
+		_, err = client.SetRunnerVersion(ctx, &pb.SetRunnerVersionRequest{Version: "99.0.0"})
+		require.NoError(err)
+
+		require.Eventually(func() bool {
+			resp, err := client.GetRunnerVersion(ctx, &empty.Empty{})
+			require.NoError(err)
+			return len(resp.Updates) == 1 && resp.Updates[0].State == pb.RunnerUpdate_FAILED
+		}, 5*time.Second, 10*time.Millisecond)
+
+		resp, err := client.GetRunnerVersion(ctx, &empty.Empty{})
+		require.NoError(err)
+		require.Contains(resp.Updates[0].Error, "nope")
+
+		r, err := client.GetRunner(ctx, &pb.GetRunnerRequest{RunnerId: runner.Id()})
+		require.NoError(err)
+		require.False(r.Draining)
+	})
+}
@@ -0,0 +1,166 @@
+package runnerauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// awsAudienceHeader is the header in the signed STS request that contains
+// the audience. It must be signed so that it can't be changed, which
+// prevents a request signed for one server being replayed to another.
+const awsAudienceHeader = "X-Waypoint-Audience"
+
+// awsSTSHost matches the global and regional AWS STS endpoints.
+var awsSTSHost = regexp.MustCompile(`^sts(\.[a-z0-9-]+)?\.amazonaws\.com(\.cn)?$`)
+
+// AWSProvider provides the runner identity using the AWS credentials
+// from the environment, such as an EC2 instance profile or ECS task role.
+type AWSProvider struct {
+	// Audience is the audience the server expects. This defaults to
+	// DefaultAudience.
+	Audience string
+}
+
+// LoginRequest implements Provider
+func (p *AWSProvider) LoginRequest(ctx context.Context) (*pb.RunnerLoginRequest, error) {
+	sess, err := session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	audience := p.Audience
+	if audience == "" {
+		audience = DefaultAudience
+	}
+
+	// Build and sign the request, but don't send it. The server sends it.
+	req, _ := sts.New(sess).GetCallerIdentityRequest(&sts.GetCallerIdentityInput{})
+	req.SetContext(ctx)
+	req.HTTPRequest.Header.Set(awsAudienceHeader, audience)
+	if err := req.Sign(); err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(req.HTTPRequest.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{}
+	for k := range req.HTTPRequest.Header {
+		headers[k] = req.HTTPRequest.Header.Get(k)
+	}
+
+	return &pb.RunnerLoginRequest{
+		Identity: &pb.RunnerLoginRequest_AwsIam{
+			AwsIam: &pb.RunnerLoginRequest_AWSIAM{
+				Method:  req.HTTPRequest.Method,
+				Url:     req.HTTPRequest.URL.String(),
+				Headers: headers,
+				Body:    body,
+			},
+		},
+	}, nil
+}
+
+// verifyAWS sends the signed sts:GetCallerIdentity request to AWS and
+// returns the caller ARN. For assumed roles this is the assumed-role ARN,
+// such as "arn:aws:sts::123456789012:assumed-role/runner/i-1234".
+func (v *Verifier) verifyAWS(ctx context.Context, id *pb.RunnerLoginRequest_AWSIAM) (string, error) {
+	// Only ever send the request to AWS STS and only for GetCallerIdentity.
+	// Otherwise a client could make the server send arbitrary requests.
+	u, err := url.Parse(id.Url)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "https" || !awsSTSHost.MatchString(u.Hostname()) {
+		return "", fmt.Errorf("request must be to an AWS STS endpoint")
+	}
+	if id.Method != "POST" {
+		return "", fmt.Errorf("request method must be POST")
+	}
+	query, err := url.ParseQuery(string(id.Body))
+	if err != nil {
+		return "", err
+	}
+	if query.Get("Action") != "GetCallerIdentity" || len(u.Query()) > 0 {
+		return "", fmt.Errorf("request must be for sts:GetCallerIdentity")
+	}
+
+	// The audience must be set and signed.
+	header := http.Header{}
+	for k, value := range id.Headers {
+		header.Set(k, value)
+	}
+	if header.Get(awsAudienceHeader) != v.audience() {
+		return "", fmt.Errorf("request audience must be %q", v.audience())
+	}
+	if !awsHeaderSigned(header.Get("Authorization"), awsAudienceHeader) {
+		return "", fmt.Errorf("request audience header must be signed")
+	}
+
+	req, err := http.NewRequest(id.Method, u.String(), bytes.NewReader(id.Body))
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	req.Header = header
+
+	resp, err := v.httpClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("AWS STS returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Arn string `xml:"GetCallerIdentityResult>Arn"`
+	}
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return "", err
+	}
+	if result.Arn == "" {
+		return "", fmt.Errorf("AWS STS response did not include an ARN")
+	}
+
+	return result.Arn, nil
+}
+
+// awsHeaderSigned returns true if the SigV4 authorization header includes
+// the given header in its signed headers.
+func awsHeaderSigned(auth, header string) bool {
+	header = strings.ToLower(header)
+	for _, part := range strings.Split(auth, ",") {
+		part = strings.TrimSpace(part)
+		if idx := strings.Index(part, "SignedHeaders="); idx != -1 {
+			for _, h := range strings.Split(part[idx+len("SignedHeaders="):], ";") {
+				if h == header {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,131 @@
+package runnerauth
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func TestVerifierAWS(t *testing.T) {
+	const arn = "arn:aws:sts::123456789012:assumed-role/runner/i-1234"
+
+	// Fake STS that returns our ARN for any request.
+	var sent *http.Request
+	client := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			sent = r
+			return &http.Response{
+				StatusCode: 200,
+				Body: ioutil.NopCloser(strings.NewReader(`<GetCallerIdentityResponse>
+  <GetCallerIdentityResult>
+    <Arn>` + arn + `</Arn>
+  </GetCallerIdentityResult>
+</GetCallerIdentityResponse>`)),
+			}, nil
+		}),
+	}
+
+	loginReq := func() *pb.RunnerLoginRequest {
+		return &pb.RunnerLoginRequest{
+			Identity: &pb.RunnerLoginRequest_AwsIam{
+				AwsIam: &pb.RunnerLoginRequest_AWSIAM{
+					Method: "POST",
+					Url:    "https://sts.amazonaws.com/",
+					Headers: map[string]string{
+						"Authorization": "AWS4-HMAC-SHA256 Credential=AKID/20200101/us-east-1/sts/aws4_request, " +
+							"SignedHeaders=content-type;host;x-amz-date;x-waypoint-audience, Signature=abc",
+						"X-Waypoint-Audience": DefaultAudience,
+					},
+					Body: []byte("Action=GetCallerIdentity&Version=2011-06-15"),
+				},
+			},
+		}
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		require := require.New(t)
+
+		v := &Verifier{
+			AWS:        []Rule{{Pattern: "arn:aws:sts::123456789012:assumed-role/runner/*", Pool: "prod"}},
+			HTTPClient: client,
+		}
+
+		id, err := v.Verify(context.Background(), loginReq())
+		require.NoError(err)
+		require.Equal(&Identity{Name: arn, Pool: "prod"}, id)
+		require.Equal("sts.amazonaws.com", sent.URL.Host)
+	})
+
+	t.Run("no matching rule", func(t *testing.T) {
+		require := require.New(t)
+
+		v := &Verifier{
+			AWS:        []Rule{{Pattern: "arn:aws:sts::123456789012:assumed-role/other/*"}},
+			HTTPClient: client,
+		}
+
+		_, err := v.Verify(context.Background(), loginReq())
+		require.Error(err)
+		require.Equal(codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("rejects non-STS hosts", func(t *testing.T) {
+		require := require.New(t)
+
+		v := &Verifier{AWS: []Rule{{Pattern: "*"}}, HTTPClient: client}
+		req := loginReq()
+		req.Identity.(*pb.RunnerLoginRequest_AwsIam).AwsIam.Url = "https://example.com/"
+
+		sent = nil
+		_, err := v.Verify(context.Background(), req)
+		require.Error(err)
+		require.Nil(sent)
+	})
+
+	t.Run("rejects other actions", func(t *testing.T) {
+		require := require.New(t)
+
+		v := &Verifier{AWS: []Rule{{Pattern: "*"}}, HTTPClient: client}
+		req := loginReq()
+		req.Identity.(*pb.RunnerLoginRequest_AwsIam).AwsIam.Body = []byte("Action=AssumeRole")
+
+		sent = nil
+		_, err := v.Verify(context.Background(), req)
+		require.Error(err)
+		require.Nil(sent)
+	})
+
+	t.Run("rejects wrong audience", func(t *testing.T) {
+		require := require.New(t)
+
+		v := &Verifier{Audience: "other", AWS: []Rule{{Pattern: "*"}}, HTTPClient: client}
+
+		sent = nil
+		_, err := v.Verify(context.Background(), loginReq())
+		require.Error(err)
+		require.Nil(sent)
+	})
+
+	t.Run("rejects unsigned audience", func(t *testing.T) {
+		require := require.New(t)
+
+		v := &Verifier{AWS: []Rule{{Pattern: "*"}}, HTTPClient: client}
+		req := loginReq()
+		req.Identity.(*pb.RunnerLoginRequest_AwsIam).AwsIam.Headers["Authorization"] =
+			"AWS4-HMAC-SHA256 Credential=AKID/20200101/us-east-1/sts/aws4_request, " +
+				"SignedHeaders=content-type;host;x-amz-date, Signature=abc"
+
+		sent = nil
+		_, err := v.Verify(context.Background(), req)
+		require.Error(err)
+		require.Nil(sent)
+	})
+}
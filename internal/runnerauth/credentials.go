@@ -0,0 +1,80 @@
+package runnerauth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// refreshWindow is how long before the token expires that we login again.
+const refreshWindow = 5 * time.Minute
+
+// Credentials are gRPC per-RPC credentials that login with a cloud
+// identity and send the resulting runner token. The token is refreshed
+// automatically before it expires.
+type Credentials struct {
+	// Client is used to login. This should be a client that doesn't use
+	// these credentials since RunnerLogin doesn't require authentication.
+	Client pb.WaypointClient
+
+	// Provider provides the identity to login with.
+	Provider Provider
+
+	lock    sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials
+func (c *Credentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := c.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		"authorization": token,
+	}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials
+func (c *Credentials) RequireTransportSecurity() bool {
+	return false
+}
+
+// Token returns the current runner token, logging in if we don't have one
+// or it is about to expire.
+func (c *Credentials) Token(ctx context.Context) (string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.token != "" && (c.expires.IsZero() || time.Until(c.expires) > refreshWindow) {
+		return c.token, nil
+	}
+
+	req, err := c.Provider.LoginRequest(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.Client.RunnerLogin(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	var expires time.Time
+	if resp.ValidUntil != nil {
+		expires, err = ptypes.Timestamp(resp.ValidUntil)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	c.token = resp.Token
+	c.expires = expires
+	return c.token, nil
+}
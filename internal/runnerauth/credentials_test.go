@@ -0,0 +1,59 @@
+package runnerauth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/gen/mocks"
+)
+
+type testProvider struct{}
+
+func (testProvider) LoginRequest(ctx context.Context) (*pb.RunnerLoginRequest, error) {
+	return &pb.RunnerLoginRequest{
+		Identity: &pb.RunnerLoginRequest_Gcp{
+			Gcp: &pb.RunnerLoginRequest_GCP{Jwt: "jwt"},
+		},
+	}, nil
+}
+
+func TestCredentials(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// The first token expires within the refresh window so we should
+	// login again for the next request.
+	soon, err := ptypes.TimestampProto(time.Now().Add(time.Minute))
+	require.NoError(err)
+	later, err := ptypes.TimestampProto(time.Now().Add(time.Hour))
+	require.NoError(err)
+
+	var client mocks.WaypointClient
+	client.On("RunnerLogin", mock.Anything, mock.Anything).
+		Return(&pb.RunnerLoginResponse{Token: "A", ValidUntil: soon}, nil).Once()
+	client.On("RunnerLogin", mock.Anything, mock.Anything).
+		Return(&pb.RunnerLoginResponse{Token: "B", ValidUntil: later}, nil).Once()
+
+	c := &Credentials{Client: &client, Provider: testProvider{}}
+
+	md, err := c.GetRequestMetadata(ctx)
+	require.NoError(err)
+	require.Equal("A", md["authorization"])
+
+	md, err = c.GetRequestMetadata(ctx)
+	require.NoError(err)
+	require.Equal("B", md["authorization"])
+
+	// Cached
+	md, err = c.GetRequestMetadata(ctx)
+	require.NoError(err)
+	require.Equal("B", md["authorization"])
+
+	client.AssertExpectations(t)
+}
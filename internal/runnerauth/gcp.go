@@ -0,0 +1,259 @@
+package runnerauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+const (
+	// gcpCertsURL is where Google publishes the certificates used to sign
+	// identity tokens.
+	gcpCertsURL = "https://www.googleapis.com/oauth2/v1/certs"
+
+	// gcpCertsTTL is how long we cache the certificates. Google rotates
+	// the certificates regularly but publishes new ones well in advance.
+	gcpCertsTTL = 1 * time.Hour
+
+	// gcpMetadataAddr is the address of the GCE metadata server.
+	gcpMetadataAddr = "http://metadata.google.internal"
+
+	// gcpClockSkew is the clock skew we allow when checking token times.
+	gcpClockSkew = 1 * time.Minute
+)
+
+// GCPProvider provides the runner identity using the GCP service account
+// of the instance from the GCE metadata server.
+type GCPProvider struct {
+	// Audience is the audience the server expects. This defaults to
+	// DefaultAudience.
+	Audience string
+
+	// MetadataAddr is the address of the metadata server. This defaults
+	// to the GCE metadata server.
+	MetadataAddr string
+
+	// HTTPClient is the client to use. This defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// LoginRequest implements Provider
+func (p *GCPProvider) LoginRequest(ctx context.Context) (*pb.RunnerLoginRequest, error) {
+	addr := p.MetadataAddr
+	if addr == "" {
+		addr = gcpMetadataAddr
+	}
+	audience := p.Audience
+	if audience == "" {
+		audience = DefaultAudience
+	}
+	client := p.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	u := fmt.Sprintf(
+		"%s/computeMetadata/v1/instance/service-accounts/default/identity?audience=%s&format=full",
+		strings.TrimRight(addr, "/"), url.QueryEscape(audience))
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GCP metadata server returned status %d: %s",
+			resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return &pb.RunnerLoginRequest{
+		Identity: &pb.RunnerLoginRequest_Gcp{
+			Gcp: &pb.RunnerLoginRequest_GCP{
+				Jwt: strings.TrimSpace(string(body)),
+			},
+		},
+	}, nil
+}
+
+// gcpCerts are the cached Google signing certificates by key ID.
+type gcpCerts struct {
+	keys    map[string]*rsa.PublicKey
+	expires time.Time
+}
+
+// gcpClaims are the claims we use from a GCP identity token.
+type gcpClaims struct {
+	Issuer        string `json:"iss"`
+	Audience      string `json:"aud"`
+	Expires       int64  `json:"exp"`
+	IssuedAt      int64  `json:"iat"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// verifyGCP verifies the identity token and returns the service account
+// email.
+func (v *Verifier) verifyGCP(ctx context.Context, id *pb.RunnerLoginRequest_GCP) (string, error) {
+	parts := strings.Split(id.Jwt, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("identity token is malformed")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := gcpDecodeSegment(parts[0], &header); err != nil {
+		return "", err
+	}
+	if header.Alg != "RS256" {
+		return "", fmt.Errorf("identity token algorithm must be RS256")
+	}
+
+	// Verify the signature before we trust any claims.
+	key, err := v.gcpKey(ctx, header.Kid)
+	if err != nil {
+		return "", err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], sig); err != nil {
+		return "", fmt.Errorf("identity token signature is invalid")
+	}
+
+	var claims gcpClaims
+	if err := gcpDecodeSegment(parts[1], &claims); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	switch {
+	case claims.Issuer != "https://accounts.google.com" && claims.Issuer != "accounts.google.com":
+		return "", fmt.Errorf("identity token issuer is invalid")
+
+	case claims.Audience != v.audience():
+		return "", fmt.Errorf("identity token audience must be %q", v.audience())
+
+	case now.Add(-gcpClockSkew).After(time.Unix(claims.Expires, 0)):
+		return "", fmt.Errorf("identity token has expired")
+
+	case now.Add(gcpClockSkew).Before(time.Unix(claims.IssuedAt, 0)):
+		return "", fmt.Errorf("identity token is not valid yet")
+
+	case claims.Email == "" || !claims.EmailVerified:
+		return "", fmt.Errorf("identity token must include a verified email, " +
+			"request it with the \"full\" format")
+	}
+
+	return claims.Email, nil
+}
+
+// gcpKey returns the public key with the given ID, fetching the current
+// certificates if necessary.
+func (v *Verifier) gcpKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.gcpCertsLock.Lock()
+	defer v.gcpCertsLock.Unlock()
+
+	if c := v.gcpCerts; c != nil && time.Now().Before(c.expires) {
+		if key, ok := c.keys[kid]; ok {
+			return key, nil
+		}
+	}
+
+	// Either we have no certs, they expired, or the key is new.
+	c, err := v.gcpFetchCerts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	v.gcpCerts = c
+
+	key, ok := c.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("identity token signing key %q is unknown", kid)
+	}
+
+	return key, nil
+}
+
+func (v *Verifier) gcpFetchCerts(ctx context.Context) (*gcpCerts, error) {
+	req, err := http.NewRequest("GET", gcpCertsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := v.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching GCP certificates returned status %d", resp.StatusCode)
+	}
+
+	var raw map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	result := &gcpCerts{
+		keys:    map[string]*rsa.PublicKey{},
+		expires: time.Now().Add(gcpCertsTTL),
+	}
+	for kid, data := range raw {
+		block, _ := pem.Decode([]byte(data))
+		if block == nil {
+			return nil, fmt.Errorf("GCP certificate %q is not valid PEM", kid)
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		key, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("GCP certificate %q is not an RSA key", kid)
+		}
+
+		result.keys[kid] = key
+	}
+
+	return result, nil
+}
+
+func gcpDecodeSegment(seg string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,143 @@
+package runnerauth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func TestVerifierGCP(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	// Self-signed cert to publish as the Google signing cert
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	fetches := 0
+	client := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			fetches++
+			bs, _ := json.Marshal(map[string]string{"k1": string(certPEM)})
+			return &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(bytes.NewReader(bs)),
+			}, nil
+		}),
+	}
+
+	token := func(kid string, claims map[string]interface{}) *pb.RunnerLoginRequest {
+		enc := func(v interface{}) string {
+			bs, err := json.Marshal(v)
+			require.NoError(t, err)
+			return base64.RawURLEncoding.EncodeToString(bs)
+		}
+
+		signed := enc(map[string]string{"alg": "RS256", "kid": kid}) + "." + enc(claims)
+		hash := sha256.Sum256([]byte(signed))
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+		require.NoError(t, err)
+
+		return &pb.RunnerLoginRequest{
+			Identity: &pb.RunnerLoginRequest_Gcp{
+				Gcp: &pb.RunnerLoginRequest_GCP{
+					Jwt: signed + "." + base64.RawURLEncoding.EncodeToString(sig),
+				},
+			},
+		}
+	}
+
+	validClaims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":            "https://accounts.google.com",
+			"aud":            DefaultAudience,
+			"exp":            time.Now().Add(time.Hour).Unix(),
+			"iat":            time.Now().Unix(),
+			"email":          "runner@project.iam.gserviceaccount.com",
+			"email_verified": true,
+		}
+	}
+
+	v := &Verifier{
+		GCP:        []Rule{{Pattern: "*@project.iam.gserviceaccount.com", Pool: "gcp"}},
+		HTTPClient: client,
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		require := require.New(t)
+
+		id, err := v.Verify(context.Background(), token("k1", validClaims()))
+		require.NoError(err)
+		require.Equal(&Identity{Name: "runner@project.iam.gserviceaccount.com", Pool: "gcp"}, id)
+
+		// Certs should be cached
+		_, err = v.Verify(context.Background(), token("k1", validClaims()))
+		require.NoError(err)
+		require.Equal(1, fetches)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		_, err := v.Verify(context.Background(), token("k2", validClaims()))
+		require.Error(t, err)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		claims := validClaims()
+		claims["exp"] = time.Now().Add(-time.Hour).Unix()
+		_, err := v.Verify(context.Background(), token("k1", claims))
+		require.Error(t, err)
+	})
+
+	t.Run("wrong audience", func(t *testing.T) {
+		claims := validClaims()
+		claims["aud"] = "other"
+		_, err := v.Verify(context.Background(), token("k1", claims))
+		require.Error(t, err)
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		// Use the signature from a valid token with different claims
+		orig := token("k1", validClaims()).Identity.(*pb.RunnerLoginRequest_Gcp).Gcp.Jwt
+		claims := validClaims()
+		claims["email"] = "attacker@project.iam.gserviceaccount.com"
+		other := token("k1", claims).Identity.(*pb.RunnerLoginRequest_Gcp).Gcp.Jwt
+
+		origParts := strings.Split(orig, ".")
+		otherParts := strings.Split(other, ".")
+		req := &pb.RunnerLoginRequest{
+			Identity: &pb.RunnerLoginRequest_Gcp{
+				Gcp: &pb.RunnerLoginRequest_GCP{
+					Jwt: otherParts[0] + "." + otherParts[1] + "." + origParts[2],
+				},
+			},
+		}
+
+		_, err := v.Verify(context.Background(), req)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,165 @@
+// Package runnerauth implements runner authentication using cloud
+// identities such as AWS IAM and GCP service accounts.
+//
+// Runners prove their identity with a document from their cloud provider
+// instead of a long-lived token baked into the runner image. The server
+// verifies the document, maps the identity to a runner pool, and issues
+// a short-lived runner token. Runners login again before the token
+// expires, see Credentials.
+package runnerauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// DefaultAudience is the default audience that identity documents must
+// be issued for. Servers should use a unique audience so that identity
+// documents for one server can't be replayed against another.
+const DefaultAudience = "waypoint"
+
+// Provider provides a cloud identity for a runner to login with.
+type Provider interface {
+	// LoginRequest returns a new login request with a fresh identity
+	// document.
+	LoginRequest(ctx context.Context) (*pb.RunnerLoginRequest, error)
+}
+
+// Rule maps identities that match Pattern to a runner pool. The pattern
+// may contain "*" wildcards which match any sequence of characters.
+type Rule struct {
+	Pattern string
+	Pool    string
+}
+
+// Identity is a verified runner identity.
+type Identity struct {
+	// Name is the cloud identity, such as the AWS caller ARN or the GCP
+	// service account email.
+	Name string
+
+	// Pool is the runner pool from the matching rule.
+	Pool string
+}
+
+// Verifier verifies runner login requests.
+type Verifier struct {
+	// Audience is the audience identity documents must be issued for.
+	// This defaults to DefaultAudience.
+	Audience string
+
+	// AWS and GCP are the rules for each identity type. An identity must
+	// match a rule to login. If there are no rules for a type, logins with
+	// that type are rejected.
+	AWS []Rule
+	GCP []Rule
+
+	// HTTPClient is used to call AWS STS and fetch the GCP signing
+	// certificates. This defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	gcpCertsLock sync.Mutex
+	gcpCerts     *gcpCerts
+}
+
+// Verify verifies the login request and returns the runner identity.
+// This returns a PermissionDenied error if the identity is invalid or
+// doesn't match any rules.
+func (v *Verifier) Verify(ctx context.Context, req *pb.RunnerLoginRequest) (*Identity, error) {
+	var name string
+	var rules []Rule
+	var err error
+	switch id := req.Identity.(type) {
+	case *pb.RunnerLoginRequest_AwsIam:
+		rules = v.AWS
+		name, err = v.verifyAWS(ctx, id.AwsIam)
+
+	case *pb.RunnerLoginRequest_Gcp:
+		rules = v.GCP
+		name, err = v.verifyGCP(ctx, id.Gcp)
+
+	default:
+		return nil, status.Errorf(codes.InvalidArgument,
+			"unsupported runner identity type: %T", req.Identity)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied,
+			"runner identity could not be verified: %s", err)
+	}
+
+	for _, r := range rules {
+		if match(r.Pattern, name) {
+			return &Identity{Name: name, Pool: r.Pool}, nil
+		}
+	}
+
+	return nil, status.Errorf(codes.PermissionDenied,
+		"runner identity %q is not allowed to login", name)
+}
+
+func (v *Verifier) audience() string {
+	if v.Audience == "" {
+		return DefaultAudience
+	}
+
+	return v.Audience
+}
+
+func (v *Verifier) httpClient() *http.Client {
+	if v.HTTPClient == nil {
+		return http.DefaultClient
+	}
+
+	return v.HTTPClient
+}
+
+// ParseRule parses a rule in the format "PATTERN" or "PATTERN=POOL".
+func ParseRule(v string) (Rule, error) {
+	var r Rule
+	r.Pattern = v
+	if idx := strings.LastIndexByte(v, '='); idx != -1 {
+		r.Pattern = v[:idx]
+		r.Pool = v[idx+1:]
+	}
+
+	if r.Pattern == "" {
+		return r, fmt.Errorf("runner identity rule %q has an empty pattern", v)
+	}
+
+	return r, nil
+}
+
+// match returns true if s matches the pattern. The only special character
+// in the pattern is "*" which matches any sequence of characters.
+func match(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+
+	// The first part must be a prefix and the last part a suffix.
+	// Everything in between must appear in order.
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+
+	last := parts[len(parts)-1]
+	for _, p := range parts[1 : len(parts)-1] {
+		idx := strings.Index(s, p)
+		if idx == -1 {
+			return false
+		}
+		s = s[idx+len(p):]
+	}
+
+	return len(s) >= len(last) && strings.HasSuffix(s, last)
+}
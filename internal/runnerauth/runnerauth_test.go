@@ -0,0 +1,56 @@
+package runnerauth
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		Pattern string
+		Value   string
+		Match   bool
+	}{
+		{"foo", "foo", true},
+		{"foo", "foobar", false},
+		{"*", "anything", true},
+		{"foo*", "foobar", true},
+		{"*bar", "foobar", true},
+		{"*bar", "foobaz", false},
+		{"arn:aws:sts::1:assumed-role/runner/*", "arn:aws:sts::1:assumed-role/runner/i-123", true},
+		{"arn:aws:sts::1:assumed-role/runner/*", "arn:aws:sts::1:assumed-role/other/i-123", false},
+		{"a*b*c", "aXbYc", true},
+		{"a*b*c", "aXcYb", false},
+		{"ab*ba", "aba", false},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Pattern+"/"+tt.Value, func(t *testing.T) {
+			require.Equal(t, tt.Match, match(tt.Pattern, tt.Value))
+		})
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	require := require.New(t)
+
+	r, err := ParseRule("runner@p.iam.gserviceaccount.com")
+	require.NoError(err)
+	require.Equal(Rule{Pattern: "runner@p.iam.gserviceaccount.com"}, r)
+
+	r, err = ParseRule("arn:aws:sts::1:assumed-role/runner/*=prod")
+	require.NoError(err)
+	require.Equal(Rule{Pattern: "arn:aws:sts::1:assumed-role/runner/*", Pool: "prod"}, r)
+
+	_, err = ParseRule("=prod")
+	require.Error(err)
+}
+
+// roundTripFunc lets tests intercept all HTTP requests.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,231 @@
+// Package ecs installs runners into AWS ECS as Fargate services.
+package ecs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+
+	"github.com/hashicorp/waypoint/builtin/aws/utils"
+	"github.com/hashicorp/waypoint/internal/runnerinstall"
+)
+
+// Installer installs runners as ECS services with one Fargate task. The
+// environment of the runner, including its token, is set in the task
+// definition so access to the task definitions of the account should be
+// restricted.
+type Installer struct {
+	// Client is the ECS client.
+	Client ecsiface.ECSAPI
+
+	// Cluster is the cluster that runners are installed in. This defaults
+	// to "default".
+	Cluster string
+
+	// Subnets and SecurityGroups configure the network of the runner
+	// tasks. At least one subnet is required.
+	Subnets        []string
+	SecurityGroups []string
+
+	// ExecutionRoleArn and TaskRoleArn are the IAM roles of the runner
+	// tasks. The execution role is needed to pull private images.
+	ExecutionRoleArn string
+	TaskRoleArn      string
+
+	// Cpu and Memory are the resources of the runner tasks in the Fargate
+	// units. These default to "256" and "512".
+	Cpu    string
+	Memory string
+}
+
+// New returns an Installer using the standard AWS credential chain. The
+// supported config options are "region", "cluster", the comma-separated
+// "subnets" and "security_groups", "execution_role_arn", "task_role_arn",
+// "cpu", and "memory".
+func New(config map[string]string) (*Installer, error) {
+	sess, err := utils.GetSession(&utils.SessionConfig{
+		Region: config["region"],
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	i := &Installer{
+		Client:           ecs.New(sess),
+		Cluster:          config["cluster"],
+		Subnets:          splitList(config["subnets"]),
+		SecurityGroups:   splitList(config["security_groups"]),
+		ExecutionRoleArn: config["execution_role_arn"],
+		TaskRoleArn:      config["task_role_arn"],
+		Cpu:              config["cpu"],
+		Memory:           config["memory"],
+	}
+	if len(i.Subnets) == 0 {
+		return nil, fmt.Errorf("the \"subnets\" option is required to install runners into ECS")
+	}
+
+	return i, nil
+}
+
+// Install implements runnerinstall.Installer
+func (i *Installer) Install(ctx context.Context, r *runnerinstall.Runner) error {
+	name := runnerinstall.Name(r.Id)
+
+	taskOut, err := i.Client.RegisterTaskDefinitionWithContext(ctx, i.taskDefinition(r))
+	if err != nil {
+		return err
+	}
+	taskArn := taskOut.TaskDefinition.TaskDefinitionArn
+
+	descOut, err := i.Client.DescribeServicesWithContext(ctx, &ecs.DescribeServicesInput{
+		Cluster:  aws.String(i.cluster()),
+		Services: []*string{aws.String(name)},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, svc := range descOut.Services {
+		if aws.StringValue(svc.Status) != "ACTIVE" {
+			continue
+		}
+
+		_, err = i.Client.UpdateServiceWithContext(ctx, &ecs.UpdateServiceInput{
+			Cluster:            aws.String(i.cluster()),
+			Service:            aws.String(name),
+			TaskDefinition:     taskArn,
+			ForceNewDeployment: aws.Bool(true),
+		})
+		return err
+	}
+
+	_, err = i.Client.CreateServiceWithContext(ctx, &ecs.CreateServiceInput{
+		Cluster:        aws.String(i.cluster()),
+		ServiceName:    aws.String(name),
+		TaskDefinition: taskArn,
+		DesiredCount:   aws.Int64(1),
+		LaunchType:     aws.String(ecs.LaunchTypeFargate),
+
+		// Only one task runs at a time since the runner ID can only be
+		// registered once.
+		DeploymentConfiguration: &ecs.DeploymentConfiguration{
+			MaximumPercent:        aws.Int64(100),
+			MinimumHealthyPercent: aws.Int64(0),
+		},
+
+		NetworkConfiguration: &ecs.NetworkConfiguration{
+			AwsvpcConfiguration: &ecs.AwsVpcConfiguration{
+				Subnets:        aws.StringSlice(i.Subnets),
+				SecurityGroups: aws.StringSlice(i.SecurityGroups),
+				AssignPublicIp: aws.String(ecs.AssignPublicIpEnabled),
+			},
+		},
+
+		Tags: []*ecs.Tag{
+			{
+				Key:   aws.String("waypoint-runner-id"),
+				Value: aws.String(r.Id),
+			},
+		},
+	})
+
+	return err
+}
+
+// Uninstall implements runnerinstall.Installer
+func (i *Installer) Uninstall(ctx context.Context, id string) error {
+	_, err := i.Client.DeleteServiceWithContext(ctx, &ecs.DeleteServiceInput{
+		Cluster: aws.String(i.cluster()),
+		Service: aws.String(runnerinstall.Name(id)),
+		Force:   aws.Bool(true),
+	})
+	if aerr, ok := err.(awserr.Error); ok {
+		switch aerr.Code() {
+		case ecs.ErrCodeServiceNotFoundException, ecs.ErrCodeServiceNotActiveException:
+			err = nil
+		}
+	}
+
+	return err
+}
+
+// taskDefinition returns the task definition for the runner. Every
+// install registers a new revision of the task definition family.
+func (i *Installer) taskDefinition(r *runnerinstall.Runner) *ecs.RegisterTaskDefinitionInput {
+	keys := make([]string, 0, len(r.Env))
+	for k := range r.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	env := make([]*ecs.KeyValuePair, len(keys))
+	for idx, k := range keys {
+		env[idx] = &ecs.KeyValuePair{
+			Name:  aws.String(k),
+			Value: aws.String(r.Env[k]),
+		}
+	}
+
+	cpu, memory := i.Cpu, i.Memory
+	if cpu == "" {
+		cpu = "256"
+	}
+	if memory == "" {
+		memory = "512"
+	}
+
+	input := &ecs.RegisterTaskDefinitionInput{
+		Family:                  aws.String(runnerinstall.Name(r.Id)),
+		Cpu:                     aws.String(cpu),
+		Memory:                  aws.String(memory),
+		NetworkMode:             aws.String(ecs.NetworkModeAwsvpc),
+		RequiresCompatibilities: []*string{aws.String(ecs.CompatibilityFargate)},
+		ContainerDefinitions: []*ecs.ContainerDefinition{
+			{
+				Name:        aws.String("runner"),
+				Image:       aws.String(r.Image),
+				Command:     aws.StringSlice(r.Args),
+				Environment: env,
+				Essential:   aws.Bool(true),
+			},
+		},
+		Tags: []*ecs.Tag{
+			{
+				Key:   aws.String("waypoint-runner-id"),
+				Value: aws.String(r.Id),
+			},
+		},
+	}
+	if i.ExecutionRoleArn != "" {
+		input.ExecutionRoleArn = aws.String(i.ExecutionRoleArn)
+	}
+	if i.TaskRoleArn != "" {
+		input.TaskRoleArn = aws.String(i.TaskRoleArn)
+	}
+
+	return input
+}
+
+func (i *Installer) cluster() string {
+	if i.Cluster == "" {
+		return "default"
+	}
+
+	return i.Cluster
+}
+
+func splitList(v string) []string {
+	if v == "" {
+		return nil
+	}
+
+	return strings.Split(v, ",")
+}
+
+var _ runnerinstall.Installer = (*Installer)(nil)
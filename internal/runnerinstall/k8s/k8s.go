@@ -0,0 +1,189 @@
+// Package k8s installs runners into Kubernetes as Deployments.
+package k8s
+
+import (
+	"context"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/hashicorp/waypoint/internal/runnerinstall"
+)
+
+// Installer installs runners as Kubernetes Deployments with one replica.
+// The environment of the runner, including its token, is stored in a
+// Secret with the same name as the Deployment.
+type Installer struct {
+	// Client is the Kubernetes client.
+	Client kubernetes.Interface
+
+	// Namespace is the namespace that runners are installed in.
+	Namespace string
+
+	// ServiceAccount is the service account of the runner pods. If this is
+	// empty, the default service account of the namespace is used.
+	ServiceAccount string
+}
+
+// New returns an Installer using the default kubeconfig loading rules. The
+// "namespace" config option sets the namespace, which otherwise is the
+// namespace of the current context, and the "service_account" option sets
+// the service account of the runner pods.
+func New(config map[string]string) (*Installer, error) {
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(),
+		&clientcmd.ConfigOverrides{},
+	)
+
+	namespace := config["namespace"]
+	if namespace == "" {
+		ns, _, err := clientConfig.Namespace()
+		if err != nil {
+			return nil, err
+		}
+
+		namespace = ns
+	}
+
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Installer{
+		Client:         clientset,
+		Namespace:      namespace,
+		ServiceAccount: config["service_account"],
+	}, nil
+}
+
+// Install implements runnerinstall.Installer
+func (i *Installer) Install(ctx context.Context, r *runnerinstall.Runner) error {
+	name := runnerinstall.Name(r.Id)
+
+	secrets := i.Client.CoreV1().Secrets(i.Namespace)
+	secret, err := secrets.Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err = secrets.Create(ctx, &corev1.Secret{
+			ObjectMeta: i.objectMeta(r.Id),
+			StringData: r.Env,
+		}, metav1.CreateOptions{})
+
+	case err == nil:
+		secret.Data = nil
+		secret.StringData = r.Env
+		_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return err
+	}
+
+	deployments := i.Client.AppsV1().Deployments(i.Namespace)
+	deployment, err := deployments.Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err = deployments.Create(ctx, i.deployment(r), metav1.CreateOptions{})
+
+	case err == nil:
+		// Annotating the pods with the time of the install restarts them
+		// even if only the token changed, since the environment is only
+		// read when the pod starts.
+		deployment.Spec = i.deployment(r).Spec
+		deployment.Spec.Template.Annotations = map[string]string{
+			"waypoint.hashicorp.com/installed-at": time.Now().UTC().Format(time.RFC3339),
+		}
+		_, err = deployments.Update(ctx, deployment, metav1.UpdateOptions{})
+	}
+
+	return err
+}
+
+// Uninstall implements runnerinstall.Installer
+func (i *Installer) Uninstall(ctx context.Context, id string) error {
+	name := runnerinstall.Name(id)
+
+	propagation := metav1.DeletePropagationBackground
+	err := i.Client.AppsV1().Deployments(i.Namespace).Delete(ctx, name, metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	err = i.Client.CoreV1().Secrets(i.Namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (i *Installer) objectMeta(id string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      runnerinstall.Name(id),
+		Namespace: i.Namespace,
+		Labels:    i.labels(id),
+	}
+}
+
+func (i *Installer) labels(id string) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":       "waypoint-runner",
+		"app.kubernetes.io/instance":   runnerinstall.Name(id),
+		"app.kubernetes.io/managed-by": "waypoint",
+	}
+}
+
+// deployment returns the Deployment for the runner. Only one pod runs at
+// a time since the runner ID can only be registered once.
+func (i *Installer) deployment(r *runnerinstall.Runner) *appsv1.Deployment {
+	name := runnerinstall.Name(r.Id)
+	labels := i.labels(r.Id)
+
+	var replicas int32 = 1
+	return &appsv1.Deployment{
+		ObjectMeta: i.objectMeta(r.Id),
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RecreateDeploymentStrategyType,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: i.ServiceAccount,
+					Containers: []corev1.Container{
+						{
+							Name:  "runner",
+							Image: r.Image,
+							Args:  r.Args,
+							EnvFrom: []corev1.EnvFromSource{
+								{
+									SecretRef: &corev1.SecretEnvSource{
+										LocalObjectReference: corev1.LocalObjectReference{
+											Name: name,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+var _ runnerinstall.Installer = (*Installer)(nil)
@@ -0,0 +1,54 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/hashicorp/waypoint/internal/runnerinstall"
+)
+
+func TestInstaller(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	client := fake.NewSimpleClientset()
+	i := &Installer{Client: client, Namespace: "waypoint"}
+
+	r := &runnerinstall.Runner{
+		Id:    "R_A",
+		Image: "hashicorp/waypoint:0.2.0",
+		Args:  []string{"runner", "agent", "-id=R_A"},
+		Env:   map[string]string{"WAYPOINT_SERVER_TOKEN": "secret"},
+	}
+	require.NoError(i.Install(ctx, r))
+
+	// The token is in a secret, not the deployment
+	name := runnerinstall.Name("R_A")
+	deployment, err := client.AppsV1().Deployments("waypoint").Get(ctx, name, metav1.GetOptions{})
+	require.NoError(err)
+	require.Equal(int32(1), *deployment.Spec.Replicas)
+	require.Empty(deployment.Spec.Template.Spec.Containers[0].Env)
+	secret, err := client.CoreV1().Secrets("waypoint").Get(ctx, name, metav1.GetOptions{})
+	require.NoError(err)
+	require.Equal("secret", secret.StringData["WAYPOINT_SERVER_TOKEN"])
+
+	// Installing again upgrades the runner
+	r.Image = "hashicorp/waypoint:0.3.0"
+	r.Env = map[string]string{"WAYPOINT_SERVER_TOKEN": "rotated"}
+	require.NoError(i.Install(ctx, r))
+	deployment, err = client.AppsV1().Deployments("waypoint").Get(ctx, name, metav1.GetOptions{})
+	require.NoError(err)
+	require.Equal("hashicorp/waypoint:0.3.0", deployment.Spec.Template.Spec.Containers[0].Image)
+	secret, err = client.CoreV1().Secrets("waypoint").Get(ctx, name, metav1.GetOptions{})
+	require.NoError(err)
+	require.Equal("rotated", secret.StringData["WAYPOINT_SERVER_TOKEN"])
+
+	require.NoError(i.Uninstall(ctx, "R_A"))
+	require.NoError(i.Uninstall(ctx, "R_A"))
+	_, err = client.AppsV1().Deployments("waypoint").Get(ctx, name, metav1.GetOptions{})
+	require.Error(err)
+}
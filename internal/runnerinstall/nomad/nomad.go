@@ -0,0 +1,108 @@
+// Package nomad installs runners into Nomad as service jobs.
+package nomad
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+
+	"github.com/hashicorp/waypoint/internal/runnerinstall"
+)
+
+// taskGroup is the name of the task group and task of the Nomad job.
+const taskGroup = "runner"
+
+// Installer installs runners as Nomad service jobs that run the runner
+// with the Docker driver.
+type Installer struct {
+	// Client is the Nomad API client.
+	Client *api.Client
+
+	// Region, Namespace, and Datacenters configure where runners are
+	// installed. Datacenters defaults to "dc1".
+	Region      string
+	Namespace   string
+	Datacenters []string
+}
+
+// New returns an Installer using a client configured with the standard
+// Nomad environment variables such as NOMAD_ADDR and NOMAD_TOKEN. The
+// "region" and "namespace" config options and the comma-separated
+// "datacenters" option configure where runners are installed.
+func New(config map[string]string) (*Installer, error) {
+	client, err := api.NewClient(api.DefaultConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	var datacenters []string
+	if v := config["datacenters"]; v != "" {
+		datacenters = strings.Split(v, ",")
+	}
+
+	return &Installer{
+		Client:      client,
+		Region:      config["region"],
+		Namespace:   config["namespace"],
+		Datacenters: datacenters,
+	}, nil
+}
+
+// Install implements runnerinstall.Installer
+func (i *Installer) Install(ctx context.Context, r *runnerinstall.Runner) error {
+	_, _, err := i.Client.Jobs().Register(i.job(r), i.writeOptions(ctx))
+	return err
+}
+
+// Uninstall implements runnerinstall.Installer
+func (i *Installer) Uninstall(ctx context.Context, id string) error {
+	_, _, err := i.Client.Jobs().Deregister(runnerinstall.Name(id), true, i.writeOptions(ctx))
+	if err != nil && strings.Contains(err.Error(), "404") {
+		err = nil
+	}
+
+	return err
+}
+
+// job returns the Nomad job for the runner. Only one allocation runs at a
+// time since the runner ID can only be registered once. The runner is
+// restarted and rescheduled if it fails.
+func (i *Installer) job(r *runnerinstall.Runner) *api.Job {
+	name := runnerinstall.Name(r.Id)
+	job := api.NewServiceJob(name, name, i.Region, 50)
+	if i.Namespace != "" {
+		job.Namespace = &i.Namespace
+	}
+	job.Datacenters = i.Datacenters
+	if len(job.Datacenters) == 0 {
+		job.Datacenters = []string{"dc1"}
+	}
+	job.Meta = map[string]string{
+		"waypoint.hashicorp.com/runner-id": r.Id,
+	}
+
+	// Nomad only updates the allocation if the job changed, so the token
+	// in the environment changing is enough to restart the runner.
+	maxParallel := 1
+	tg := api.NewTaskGroup(taskGroup, 1)
+	tg.Update = &api.UpdateStrategy{MaxParallel: &maxParallel}
+	job.AddTaskGroup(tg)
+
+	t := api.NewTask(taskGroup, "docker")
+	t.Config = map[string]interface{}{
+		"image": r.Image,
+		"args":  r.Args,
+	}
+	t.Env = r.Env
+	tg.AddTask(t)
+
+	return job
+}
+
+func (i *Installer) writeOptions(ctx context.Context) *api.WriteOptions {
+	w := &api.WriteOptions{Region: i.Region, Namespace: i.Namespace}
+	return w.WithContext(ctx)
+}
+
+var _ runnerinstall.Installer = (*Installer)(nil)
@@ -0,0 +1,31 @@
+package nomad
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint/internal/runnerinstall"
+)
+
+func TestInstallerJob(t *testing.T) {
+	require := require.New(t)
+
+	i := &Installer{Namespace: "ops", Datacenters: []string{"us-east-1"}}
+	job := i.job(&runnerinstall.Runner{
+		Id:    "R_A",
+		Image: "hashicorp/waypoint:latest",
+		Args:  []string{"runner", "agent", "-id=R_A"},
+		Env:   map[string]string{"WAYPOINT_SERVER_TOKEN": "secret"},
+	})
+
+	require.Equal("waypoint-runner-r-a", *job.ID)
+	require.Equal("service", *job.Type)
+	require.Equal("ops", *job.Namespace)
+	require.Equal([]string{"us-east-1"}, job.Datacenters)
+	require.Equal("R_A", job.Meta["waypoint.hashicorp.com/runner-id"])
+	require.Len(job.TaskGroups, 1)
+	require.Equal(1, *job.TaskGroups[0].Count)
+	require.Equal("hashicorp/waypoint:latest", job.TaskGroups[0].Tasks[0].Config["image"])
+	require.Equal("secret", job.TaskGroups[0].Tasks[0].Env["WAYPOINT_SERVER_TOKEN"])
+}
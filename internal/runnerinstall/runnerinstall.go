@@ -0,0 +1,61 @@
+// Package runnerinstall installs static runners into platforms such as
+// Kubernetes, Nomad, or ECS. Unlike the runners that the server dispatches
+// for a single job, installed runners are long running and take jobs from
+// the queue like any other registered runner. The CLI installs them and
+// records the installation in the server so that they can be upgraded or
+// uninstalled later.
+//
+// Installer implementations are in subpackages so that the CLI only
+// depends on the client libraries of the platform in use.
+package runnerinstall
+
+import (
+	"context"
+	"strings"
+)
+
+// Installer installs runners into a platform.
+type Installer interface {
+	// Install installs the runner or updates it if it is already
+	// installed, such as to upgrade its image or rotate its token.
+	Install(ctx context.Context, r *Runner) error
+
+	// Uninstall removes the runner with the given ID. This should not
+	// return an error if the runner isn't installed.
+	Uninstall(ctx context.Context, id string) error
+}
+
+// Runner is a static runner to install.
+type Runner struct {
+	// Id is the ID the runner registers with.
+	Id string
+
+	// Image is the container image to run.
+	Image string
+
+	// Args are the arguments to the container entrypoint.
+	Args []string
+
+	// Env are the environment variables to set. These include the runner
+	// token so they should be treated as secret.
+	Env map[string]string
+}
+
+// Name returns the name of the platform resources of the runner with the
+// given ID. Characters that aren't valid in a DNS label are replaced with
+// dashes.
+func Name(id string) string {
+	return "waypoint-runner-" + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		default:
+			return '-'
+		}
+	}, id)
+}
+
+// Platforms are the platforms that runners can be installed into.
+var Platforms = []string{"ecs", "kubernetes", "nomad"}
@@ -0,0 +1,19 @@
+package runnerinstall
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestName(t *testing.T) {
+	cases := map[string]string{
+		"01ETZJ6ZG9GEQ2MR7C4DS7E4GA": "waypoint-runner-01etzj6zg9geq2mr7c4ds7e4ga",
+		"R_A":                        "waypoint-runner-r-a",
+		"build.1":                    "waypoint-runner-build-1",
+	}
+
+	for id, expected := range cases {
+		require.Equal(t, expected, Name(id), id)
+	}
+}
@@ -0,0 +1,305 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// adminJobPrefix is the path prefix of the admin job view. The view of a
+// job is at /admin/jobs/<id> and the events it shows are streamed from
+// /admin/jobs/<id>/events.
+const adminJobPrefix = "/admin/jobs/"
+
+// adminJobEndpoint is the endpoint that requests for job events are
+// authenticated as.
+const adminJobEndpoint = "GetJobStream"
+
+// adminJobHandler returns an HTTP handler that serves the admin view of a
+// job. The view is a page that streams the events of GetJobStream from
+// the events path as server-sent events, with each event being the JSON
+// encoding of a GetJobStreamResponse. If checker is non-nil, requests for
+// events must have a valid token in the Authorization header, optionally
+// prefixed with "Bearer ". The page itself has no job data so it doesn't
+// require a token; it asks for one.
+func adminJobHandler(svc gen.WaypointServer, checker AuthChecker, log hclog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id := strings.TrimPrefix(r.URL.Path, adminJobPrefix)
+		events := strings.HasSuffix(id, "/events")
+		id = strings.TrimSuffix(id, "/events")
+		if id == "" || strings.Contains(id, "/") {
+			http.NotFound(w, r)
+			return
+		}
+
+		if !events {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			if err := adminJobTemplate.Execute(w, id); err != nil {
+				log.Warn("error rendering admin job view", "err", err)
+			}
+
+			return
+		}
+
+		req := &gen.GetJobStreamRequest{JobId: id, Detail: true}
+		if checker != nil {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			err := checker.Authenticate(r.Context(), token, adminJobEndpoint, readonly)
+			if err != nil {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			if ra, ok := checker.(RequestAuthorizer); ok {
+				err := ra.AuthorizeRequest(r.Context(), token, adminJobEndpoint, req)
+				if err != nil {
+					http.Error(w, "forbidden", http.StatusForbidden)
+					return
+				}
+			}
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+
+		stream := &adminJobStream{ctx: r.Context(), w: w, flusher: flusher}
+		if err := svc.GetJobStream(req, stream); err != nil {
+			// The response may have started, so errors are sent as an
+			// event rather than a status code.
+			log.Debug("error streaming job events", "job", id, "err", err)
+			stream.send("error", status.Convert(err).Proto())
+		}
+	})
+}
+
+// adminJobStream implements gen.Waypoint_GetJobStreamServer by writing
+// each response as a server-sent event.
+type adminJobStream struct {
+	grpc.ServerStream
+
+	ctx     context.Context
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func (s *adminJobStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *adminJobStream) Send(resp *gen.GetJobStreamResponse) error {
+	return s.send("", resp)
+}
+
+// send writes msg as a server-sent event of the given type. If the type is
+// empty, the event is a "message" event.
+func (s *adminJobStream) send(typ string, msg proto.Message) error {
+	var m jsonpb.Marshaler
+	data, err := m.MarshalToString(msg)
+	if err != nil {
+		return err
+	}
+
+	if typ != "" {
+		if _, err := fmt.Fprintf(s.w, "event: %s\n", typ); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(s.w, "data: %s\n\n", data); err != nil {
+		return err
+	}
+
+	s.flusher.Flush()
+	return nil
+}
+
+var adminJobTemplate = template.Must(template.New("job").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Waypoint Job {{.}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #1f2124; }
+h1 { font-size: 1.4em; }
+h2 { font-size: 1.1em; margin-top: 1.5em; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #dce0e6; padding: 0.3em 0.6em; text-align: left; }
+#output { background: #1f2124; color: #f1f2f3; padding: 1em; white-space: pre-wrap; font-family: monospace; max-height: 40em; overflow-y: auto; }
+.error { color: #c73445; }
+</style>
+</head>
+<body>
+<h1>Job <code>{{.}}</code></h1>
+<form id="login" hidden>
+<p>A token is required to view this job.</p>
+<input id="token" type="password" placeholder="Token" size="60">
+<button type="submit">View</button>
+</form>
+<p id="error" class="error"></p>
+
+<h2>Status</h2>
+<table>
+<tr><th>Operation</th><td id="operation"></td></tr>
+<tr><th>Application</th><td id="application"></td></tr>
+<tr><th>State</th><td id="state"></td></tr>
+<tr><th>Blocked by</th><td id="blocked-by">-</td></tr>
+<tr><th>Last heartbeat</th><td id="heartbeat">-</td></tr>
+</table>
+
+<h2>State transitions</h2>
+<table id="transitions"><tr><th>Time</th><th>From</th><th>To</th></tr></table>
+
+<h2>Assignments</h2>
+<table id="assignments"><tr><th>Runner</th><th>Assigned</th><th>Accepted</th><th>Rejected</th></tr></table>
+
+<h2>Output</h2>
+<div id="output"></div>
+
+<script>
+(function() {
+  var jobId = {{.}};
+  var detail = {};
+  var el = function(id) { return document.getElementById(id); };
+  var row = function(table, cells) {
+    var tr = document.createElement("tr");
+    cells.forEach(function(c) {
+      var td = document.createElement("td");
+      td.textContent = c || "-";
+      tr.appendChild(td);
+    });
+    el(table).appendChild(tr);
+  };
+  var write = function(text) {
+    var out = el("output");
+    out.appendChild(document.createTextNode(text));
+    out.scrollTop = out.scrollHeight;
+  };
+
+  var handlers = {
+    state: function(ev) {
+      var job = ev.job || {};
+      el("state").textContent = (ev.current || "UNKNOWN") + (ev.canceling ? " (canceling)" : "");
+      el("operation").textContent = Object.keys(job).filter(function(k) {
+        return ["noop", "build", "push", "deploy", "destroy", "release", "validate", "auth", "docs"].indexOf(k) >= 0;
+      }).join(", ");
+      if (job.application) {
+        el("application").textContent = job.application.project + "/" + (job.application.application || "");
+      }
+      row("transitions", [new Date().toISOString(), ev.previous || "UNKNOWN", ev.current]);
+
+      var assignments = el("assignments");
+      while (assignments.rows.length > 1) { assignments.deleteRow(1); }
+      (job.assignments || []).forEach(function(a) {
+        row("assignments", [a.runner && a.runner.id, a.assignTime, a.ackTime, a.nackTime]);
+      });
+    },
+    detail: function(ev) {
+      detail = ev;
+      el("blocked-by").textContent = ev.blockedBy || "-";
+    },
+    terminal: function(ev) {
+      (ev.events || []).forEach(function(e) {
+        if (e.line) { write(e.line.msg + "\n"); }
+        else if (e.status) { write(e.status.msg + "\n"); }
+        else if (e.raw) { write(atob(e.raw.data || "")); }
+        else if (e.step) { write((e.step.msg || "") + (e.step.output ? atob(e.step.output) : "") + "\n"); }
+        else if (e.namedValues) {
+          (e.namedValues.values || []).forEach(function(v) { write(v.name + ": " + v.value + "\n"); });
+        }
+      });
+    },
+    complete: function(ev) {
+      if (ev.error) { el("error").textContent = "Job failed: " + ev.error.message; }
+      detail = {};
+    },
+    error: function(ev) {
+      el("error").textContent = ev.error ? ev.error.message : "stream error";
+    }
+  };
+
+  setInterval(function() {
+    if (!detail.heartbeatTime) { el("heartbeat").textContent = "-"; return; }
+    var age = Math.round((Date.now() - Date.parse(detail.heartbeatTime)) / 1000);
+    var left = Math.round((Date.parse(detail.heartbeatDeadline) - Date.now()) / 1000);
+    el("heartbeat").textContent = age + "s ago (fails in " + left + "s without another)";
+  }, 1000);
+
+  var dispatch = function(chunk) {
+    var type = "message", data = "";
+    chunk.split("\n").forEach(function(line) {
+      if (line.indexOf("event: ") === 0) { type = line.slice(7); }
+      else if (line.indexOf("data: ") === 0) { data += line.slice(6); }
+    });
+    if (!data) { return; }
+    var msg = JSON.parse(data);
+    if (type === "error") { el("error").textContent = msg.message || "error"; return; }
+    Object.keys(msg).forEach(function(k) { if (handlers[k]) { handlers[k](msg[k]); } });
+  };
+
+  var start = function() {
+    var headers = {};
+    var token = sessionStorage.getItem("waypoint-token");
+    if (token) { headers["Authorization"] = "Bearer " + token; }
+
+    fetch(encodeURIComponent(jobId) + "/events", { headers: headers }).then(function(resp) {
+      if (resp.status === 401 || resp.status === 403) {
+        el("login").hidden = false;
+        el("error").textContent = token ? "The token is not valid for this job." : "";
+        return;
+      }
+      el("login").hidden = true;
+      el("error").textContent = "";
+
+      var reader = resp.body.getReader();
+      var decoder = new TextDecoder();
+      var buf = "";
+      var read = function() {
+        return reader.read().then(function(result) {
+          if (result.done) { return; }
+          buf += decoder.decode(result.value, { stream: true });
+          var idx;
+          while ((idx = buf.indexOf("\n\n")) >= 0) {
+            dispatch(buf.slice(0, idx));
+            buf = buf.slice(idx + 2);
+          }
+          return read();
+        });
+      };
+      return read();
+    }).catch(function(err) {
+      el("error").textContent = err.toString();
+    });
+  };
+
+  el("login").addEventListener("submit", function(e) {
+    e.preventDefault();
+    sessionStorage.setItem("waypoint-token", el("token").value);
+    start();
+  });
+
+  start();
+})();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+type testJobStreamer struct {
+	gen.UnimplementedWaypointServer
+
+	req *gen.GetJobStreamRequest
+}
+
+func (s *testJobStreamer) GetJobStream(
+	req *gen.GetJobStreamRequest,
+	srv gen.Waypoint_GetJobStreamServer,
+) error {
+	s.req = req
+	if req.JobId != "A" {
+		return status.Errorf(codes.NotFound, "job not found")
+	}
+
+	if err := srv.Send(&gen.GetJobStreamResponse{
+		Event: &gen.GetJobStreamResponse_State_{
+			State: &gen.GetJobStreamResponse_State{
+				Current: gen.Job_RUNNING,
+			},
+		},
+	}); err != nil {
+		return err
+	}
+
+	return srv.Send(&gen.GetJobStreamResponse{
+		Event: &gen.GetJobStreamResponse_Detail_{
+			Detail: &gen.GetJobStreamResponse_Detail{BlockedBy: "B"},
+		},
+	})
+}
+
+func TestAdminJobHandler(t *testing.T) {
+	get := func(h http.Handler, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+
+	t.Run("serves the view", func(t *testing.T) {
+		require := require.New(t)
+
+		rec := get(adminJobHandler(&testJobStreamer{}, denyAuth{}, hclog.L()),
+			"/admin/jobs/%3CA%3E")
+		require.Equal(http.StatusOK, rec.Code)
+		require.Contains(rec.Header().Get("Content-Type"), "text/html")
+		require.Contains(rec.Body.String(), "Job <code>&lt;A&gt;</code>")
+		require.NotContains(rec.Body.String(), "<A>")
+	})
+
+	t.Run("streams events", func(t *testing.T) {
+		require := require.New(t)
+
+		svc := &testJobStreamer{}
+		rec := get(adminJobHandler(svc, nil, hclog.L()), "/admin/jobs/A/events")
+		require.Equal(http.StatusOK, rec.Code)
+		require.Equal("text/event-stream", rec.Header().Get("Content-Type"))
+		require.Equal(
+			"data: {\"state\":{\"current\":\"RUNNING\"}}\n\n"+
+				"data: {\"detail\":{\"blockedBy\":\"B\"}}\n\n",
+			rec.Body.String())
+		require.True(svc.req.Detail)
+	})
+
+	t.Run("stream errors are events", func(t *testing.T) {
+		require := require.New(t)
+
+		rec := get(adminJobHandler(&testJobStreamer{}, nil, hclog.L()), "/admin/jobs/B/events")
+		require.Equal(http.StatusOK, rec.Code)
+		require.Contains(rec.Body.String(), "event: error\n")
+		require.Contains(rec.Body.String(), "job not found")
+	})
+
+	t.Run("authenticates the bearer token", func(t *testing.T) {
+		require := require.New(t)
+
+		var auth trivialAuth
+		req := httptest.NewRequest("GET", "/admin/jobs/A/events", nil)
+		req.Header.Set("Authorization", "Bearer abcd")
+		rec := httptest.NewRecorder()
+		adminJobHandler(&testJobStreamer{}, &auth, hclog.L()).ServeHTTP(rec, req)
+		require.Equal(http.StatusOK, rec.Code)
+		require.Equal("abcd", auth.token)
+		require.Equal(adminJobEndpoint, auth.method)
+		require.Equal(readonly, auth.effects)
+	})
+
+	t.Run("rejects invalid tokens", func(t *testing.T) {
+		rec := get(adminJobHandler(&testJobStreamer{}, denyAuth{}, hclog.L()),
+			"/admin/jobs/A/events")
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("invalid paths", func(t *testing.T) {
+		h := adminJobHandler(&testJobStreamer{}, nil, hclog.L())
+		for _, path := range []string{"/admin/jobs/", "/admin/jobs/A/B", "/admin/jobs//events"} {
+			require.Equal(t, http.StatusNotFound, get(h, path).Code, path)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/audit"
+)
+
+// AuditIdentifier is optionally implemented by the service to identify
+// the caller of an RPC in audit events.
+type AuditIdentifier interface {
+	// AuditIdentity returns the identity of the token, such as
+	// "user:waypoint". This should return an empty string if the token
+	// is invalid.
+	AuditIdentity(token string) string
+}
+
+// auditUnaryInterceptor returns a gRPC unary interceptor that exports an
+// audit event for every call. This must be before the auth interceptor so
+// that calls that fail authentication are audited.
+func auditUnaryInterceptor(exp *audit.Exporter, ider AuditIdentifier) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		// Don't audit the reflection API
+		if strings.HasPrefix(info.FullMethod, "/grpc.reflection.v1alpha.ServerReflection/") {
+			return handler(ctx, req)
+		}
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		exp.Export(auditEvent(ctx, ider, info.FullMethod, start, err))
+		return resp, err
+	}
+}
+
+// auditStreamInterceptor returns a gRPC stream interceptor that exports an
+// audit event for every call once the stream ends.
+func auditStreamInterceptor(exp *audit.Exporter, ider AuditIdentifier) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		// Don't audit the reflection API
+		if strings.HasPrefix(info.FullMethod, "/grpc.reflection.v1alpha.ServerReflection/") {
+			return handler(srv, ss)
+		}
+
+		start := time.Now()
+		err := handler(srv, ss)
+		exp.Export(auditEvent(ss.Context(), ider, info.FullMethod, start, err))
+		return err
+	}
+}
+
+// auditEvent builds the audit event for a completed call.
+func auditEvent(
+	ctx context.Context,
+	ider AuditIdentifier,
+	fullMethod string,
+	start time.Time,
+	err error,
+) *audit.Event {
+	name := filepath.Base(fullMethod)
+	effects, ok := Effects[name]
+	if !ok {
+		effects = DefaultEffects
+	}
+
+	st := status.Convert(err)
+	e := &audit.Event{
+		Time:     start,
+		Endpoint: name,
+		Effects:  effects,
+		Code:     st.Code().String(),
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		e.Error = st.Message()
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		e.PeerAddr = p.Addr.String()
+	}
+
+	if ider != nil {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if authHeader, ok := md["authorization"]; ok && len(authHeader) > 0 {
+				e.Identity = ider.AuditIdentity(authHeader[0])
+			}
+		}
+	}
+
+	return e
+}
@@ -18,6 +18,15 @@ type AuthChecker interface {
 	Authenticate(ctx context.Context, token, endpoint string, effects []string) error
 }
 
+// RequestAuthorizer may optionally be implemented by an AuthChecker to
+// authorize the contents of requests in addition to the endpoint. This is
+// used to scope tokens to specific resources. It is called after
+// Authenticate succeeds with every request message, including each message
+// received on a stream.
+type RequestAuthorizer interface {
+	AuthorizeRequest(ctx context.Context, token, endpoint string, req interface{}) error
+}
+
 var readonly = []string{"readonly"}
 
 // Information about the effects of endpoints that are authenticated. If a endpoint
@@ -64,6 +73,13 @@ func authUnaryInterceptor(checker AuthChecker) grpc.UnaryServerInterceptor {
 		if err != nil {
 			return nil, err
 		}
+
+		if ra, ok := checker.(RequestAuthorizer); ok {
+			if err := ra.AuthorizeRequest(ctx, token, name, req); err != nil {
+				return nil, err
+			}
+		}
+
 		return handler(ctx, req)
 	}
 }
@@ -107,7 +123,34 @@ func authStreamInterceptor(checker AuthChecker) grpc.StreamServerInterceptor {
 			return err
 		}
 
+		if ra, ok := checker.(RequestAuthorizer); ok {
+			ss = &authServerStream{
+				ServerStream: ss,
+				authorizer:   ra,
+				token:        token,
+				endpoint:     name,
+			}
+		}
+
 		// Invoke the handler.
 		return handler(srv, ss)
 	}
 }
+
+// authServerStream wraps a grpc.ServerStream to authorize each message
+// received from the client.
+type authServerStream struct {
+	grpc.ServerStream
+
+	authorizer RequestAuthorizer
+	token      string
+	endpoint   string
+}
+
+func (s *authServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	return s.authorizer.AuthorizeRequest(s.Context(), s.token, s.endpoint, m)
+}
@@ -6,7 +6,9 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type trivialAuth struct {
@@ -55,3 +57,43 @@ func TestAuthUnaryInterceptor(t *testing.T) {
 	require.Equal("bar", chk.method)
 	require.Equal(DefaultEffects, chk.effects)
 }
+
+type requestAuth struct {
+	trivialAuth
+
+	req interface{}
+}
+
+func (t *requestAuth) AuthorizeRequest(ctx context.Context, token string, endpoint string, req interface{}) error {
+	t.req = req
+	if req == "denied" {
+		return status.Errorf(codes.PermissionDenied, "denied")
+	}
+
+	return nil
+}
+
+func TestAuthUnaryInterceptor_authorizeRequest(t *testing.T) {
+	require := require.New(t)
+
+	var chk requestAuth
+
+	f := authUnaryInterceptor(&chk)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{
+		"authorization": []string{"this-is-a-token"},
+	})
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "hello", nil
+	}
+
+	resp, err := f(ctx, "allowed", &grpc.UnaryServerInfo{FullMethod: "/foo/bar"}, handler)
+	require.NoError(err)
+	require.Equal("hello", resp)
+	require.Equal("allowed", chk.req)
+
+	_, err = f(ctx, "denied", &grpc.UnaryServerInfo{FullMethod: "/foo/bar"}, handler)
+	require.Error(err)
+	require.Equal(codes.PermissionDenied, status.Code(err))
+}
@@ -18,8 +18,8 @@ type WaypointClient struct {
 	mock.Mock
 }
 
-// BootstrapToken provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) BootstrapToken(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.NewTokenResponse, error) {
+// ApproveJob provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ApproveJob(ctx context.Context, in *gen.ApproveJobRequest, opts ...grpc.CallOption) (*gen.Job, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -29,17 +29,17 @@ func (_m *WaypointClient) BootstrapToken(ctx context.Context, in *emptypb.Empty,
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.NewTokenResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.NewTokenResponse); ok {
+	var r0 *gen.Job
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ApproveJobRequest, ...grpc.CallOption) *gen.Job); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.NewTokenResponse)
+			r0 = ret.Get(0).(*gen.Job)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ApproveJobRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -48,8 +48,8 @@ func (_m *WaypointClient) BootstrapToken(ctx context.Context, in *emptypb.Empty,
 	return r0, r1
 }
 
-// CancelJob provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) CancelJob(ctx context.Context, in *gen.CancelJobRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+// ApproveRunner provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ApproveRunner(ctx context.Context, in *gen.ApproveRunnerRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -60,7 +60,7 @@ func (_m *WaypointClient) CancelJob(ctx context.Context, in *gen.CancelJobReques
 	ret := _m.Called(_ca...)
 
 	var r0 *emptypb.Empty
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.CancelJobRequest, ...grpc.CallOption) *emptypb.Empty); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ApproveRunnerRequest, ...grpc.CallOption) *emptypb.Empty); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
@@ -69,7 +69,7 @@ func (_m *WaypointClient) CancelJob(ctx context.Context, in *gen.CancelJobReques
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.CancelJobRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ApproveRunnerRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -78,8 +78,8 @@ func (_m *WaypointClient) CancelJob(ctx context.Context, in *gen.CancelJobReques
 	return r0, r1
 }
 
-// ConvertInviteToken provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) ConvertInviteToken(ctx context.Context, in *gen.ConvertInviteTokenRequest, opts ...grpc.CallOption) (*gen.NewTokenResponse, error) {
+// BootstrapToken provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) BootstrapToken(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.NewTokenResponse, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -90,7 +90,7 @@ func (_m *WaypointClient) ConvertInviteToken(ctx context.Context, in *gen.Conver
 	ret := _m.Called(_ca...)
 
 	var r0 *gen.NewTokenResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.ConvertInviteTokenRequest, ...grpc.CallOption) *gen.NewTokenResponse); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.NewTokenResponse); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
@@ -99,7 +99,7 @@ func (_m *WaypointClient) ConvertInviteToken(ctx context.Context, in *gen.Conver
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.ConvertInviteTokenRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -108,8 +108,8 @@ func (_m *WaypointClient) ConvertInviteToken(ctx context.Context, in *gen.Conver
 	return r0, r1
 }
 
-// CreateHostname provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) CreateHostname(ctx context.Context, in *gen.CreateHostnameRequest, opts ...grpc.CallOption) (*gen.CreateHostnameResponse, error) {
+// CancelJob provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) CancelJob(ctx context.Context, in *gen.CancelJobRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -119,17 +119,17 @@ func (_m *WaypointClient) CreateHostname(ctx context.Context, in *gen.CreateHost
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.CreateHostnameResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.CreateHostnameRequest, ...grpc.CallOption) *gen.CreateHostnameResponse); ok {
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.CancelJobRequest, ...grpc.CallOption) *emptypb.Empty); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.CreateHostnameResponse)
+			r0 = ret.Get(0).(*emptypb.Empty)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.CreateHostnameRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.CancelJobRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -138,8 +138,8 @@ func (_m *WaypointClient) CreateHostname(ctx context.Context, in *gen.CreateHost
 	return r0, r1
 }
 
-// CreateSnapshot provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) CreateSnapshot(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (gen.Waypoint_CreateSnapshotClient, error) {
+// CancelJobs provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) CancelJobs(ctx context.Context, in *gen.CancelJobsRequest, opts ...grpc.CallOption) (*gen.CancelJobsResponse, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -149,17 +149,17 @@ func (_m *WaypointClient) CreateSnapshot(ctx context.Context, in *emptypb.Empty,
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 gen.Waypoint_CreateSnapshotClient
-	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) gen.Waypoint_CreateSnapshotClient); ok {
+	var r0 *gen.CancelJobsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.CancelJobsRequest, ...grpc.CallOption) *gen.CancelJobsResponse); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(gen.Waypoint_CreateSnapshotClient)
+			r0 = ret.Get(0).(*gen.CancelJobsResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.CancelJobsRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -168,8 +168,8 @@ func (_m *WaypointClient) CreateSnapshot(ctx context.Context, in *emptypb.Empty,
 	return r0, r1
 }
 
-// DeleteHostname provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) DeleteHostname(ctx context.Context, in *gen.DeleteHostnameRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+// CompactDatabase provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) CompactDatabase(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.CompactDatabaseResponse, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -179,17 +179,17 @@ func (_m *WaypointClient) DeleteHostname(ctx context.Context, in *gen.DeleteHost
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *emptypb.Empty
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.DeleteHostnameRequest, ...grpc.CallOption) *emptypb.Empty); ok {
+	var r0 *gen.CompactDatabaseResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.CompactDatabaseResponse); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*emptypb.Empty)
+			r0 = ret.Get(0).(*gen.CompactDatabaseResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.DeleteHostnameRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -198,8 +198,8 @@ func (_m *WaypointClient) DeleteHostname(ctx context.Context, in *gen.DeleteHost
 	return r0, r1
 }
 
-// EntrypointConfig provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) EntrypointConfig(ctx context.Context, in *gen.EntrypointConfigRequest, opts ...grpc.CallOption) (gen.Waypoint_EntrypointConfigClient, error) {
+// ConvertInviteToken provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ConvertInviteToken(ctx context.Context, in *gen.ConvertInviteTokenRequest, opts ...grpc.CallOption) (*gen.NewTokenResponse, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -209,17 +209,17 @@ func (_m *WaypointClient) EntrypointConfig(ctx context.Context, in *gen.Entrypoi
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 gen.Waypoint_EntrypointConfigClient
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.EntrypointConfigRequest, ...grpc.CallOption) gen.Waypoint_EntrypointConfigClient); ok {
+	var r0 *gen.NewTokenResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ConvertInviteTokenRequest, ...grpc.CallOption) *gen.NewTokenResponse); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(gen.Waypoint_EntrypointConfigClient)
+			r0 = ret.Get(0).(*gen.NewTokenResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.EntrypointConfigRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ConvertInviteTokenRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -228,29 +228,29 @@ func (_m *WaypointClient) EntrypointConfig(ctx context.Context, in *gen.Entrypoi
 	return r0, r1
 }
 
-// EntrypointExecStream provides a mock function with given fields: ctx, opts
-func (_m *WaypointClient) EntrypointExecStream(ctx context.Context, opts ...grpc.CallOption) (gen.Waypoint_EntrypointExecStreamClient, error) {
+// CreateAPIKey provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) CreateAPIKey(ctx context.Context, in *gen.CreateAPIKeyRequest, opts ...grpc.CallOption) (*gen.APIKeyTokenResponse, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
 	}
 	var _ca []interface{}
-	_ca = append(_ca, ctx)
+	_ca = append(_ca, ctx, in)
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 gen.Waypoint_EntrypointExecStreamClient
-	if rf, ok := ret.Get(0).(func(context.Context, ...grpc.CallOption) gen.Waypoint_EntrypointExecStreamClient); ok {
-		r0 = rf(ctx, opts...)
+	var r0 *gen.APIKeyTokenResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.CreateAPIKeyRequest, ...grpc.CallOption) *gen.APIKeyTokenResponse); ok {
+		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(gen.Waypoint_EntrypointExecStreamClient)
+			r0 = ret.Get(0).(*gen.APIKeyTokenResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, ...grpc.CallOption) error); ok {
-		r1 = rf(ctx, opts...)
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.CreateAPIKeyRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -258,29 +258,29 @@ func (_m *WaypointClient) EntrypointExecStream(ctx context.Context, opts ...grpc
 	return r0, r1
 }
 
-// EntrypointLogStream provides a mock function with given fields: ctx, opts
-func (_m *WaypointClient) EntrypointLogStream(ctx context.Context, opts ...grpc.CallOption) (gen.Waypoint_EntrypointLogStreamClient, error) {
+// CreateHostname provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) CreateHostname(ctx context.Context, in *gen.CreateHostnameRequest, opts ...grpc.CallOption) (*gen.CreateHostnameResponse, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
 	}
 	var _ca []interface{}
-	_ca = append(_ca, ctx)
+	_ca = append(_ca, ctx, in)
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 gen.Waypoint_EntrypointLogStreamClient
-	if rf, ok := ret.Get(0).(func(context.Context, ...grpc.CallOption) gen.Waypoint_EntrypointLogStreamClient); ok {
-		r0 = rf(ctx, opts...)
+	var r0 *gen.CreateHostnameResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.CreateHostnameRequest, ...grpc.CallOption) *gen.CreateHostnameResponse); ok {
+		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(gen.Waypoint_EntrypointLogStreamClient)
+			r0 = ret.Get(0).(*gen.CreateHostnameResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, ...grpc.CallOption) error); ok {
-		r1 = rf(ctx, opts...)
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.CreateHostnameRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -288,8 +288,8 @@ func (_m *WaypointClient) EntrypointLogStream(ctx context.Context, opts ...grpc.
 	return r0, r1
 }
 
-// GenerateInviteToken provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) GenerateInviteToken(ctx context.Context, in *gen.InviteTokenRequest, opts ...grpc.CallOption) (*gen.NewTokenResponse, error) {
+// CreateRunnerToken provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) CreateRunnerToken(ctx context.Context, in *gen.CreateRunnerTokenRequest, opts ...grpc.CallOption) (*gen.RunnerTokenResponse, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -299,17 +299,17 @@ func (_m *WaypointClient) GenerateInviteToken(ctx context.Context, in *gen.Invit
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.NewTokenResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.InviteTokenRequest, ...grpc.CallOption) *gen.NewTokenResponse); ok {
+	var r0 *gen.RunnerTokenResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.CreateRunnerTokenRequest, ...grpc.CallOption) *gen.RunnerTokenResponse); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.NewTokenResponse)
+			r0 = ret.Get(0).(*gen.RunnerTokenResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.InviteTokenRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.CreateRunnerTokenRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -318,8 +318,8 @@ func (_m *WaypointClient) GenerateInviteToken(ctx context.Context, in *gen.Invit
 	return r0, r1
 }
 
-// GenerateLoginToken provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) GenerateLoginToken(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.NewTokenResponse, error) {
+// CreateSnapshot provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) CreateSnapshot(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (gen.Waypoint_CreateSnapshotClient, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -329,12 +329,12 @@ func (_m *WaypointClient) GenerateLoginToken(ctx context.Context, in *emptypb.Em
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.NewTokenResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.NewTokenResponse); ok {
+	var r0 gen.Waypoint_CreateSnapshotClient
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) gen.Waypoint_CreateSnapshotClient); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.NewTokenResponse)
+			r0 = ret.Get(0).(gen.Waypoint_CreateSnapshotClient)
 		}
 	}
 
@@ -348,8 +348,8 @@ func (_m *WaypointClient) GenerateLoginToken(ctx context.Context, in *emptypb.Em
 	return r0, r1
 }
 
-// GetBuild provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) GetBuild(ctx context.Context, in *gen.GetBuildRequest, opts ...grpc.CallOption) (*gen.Build, error) {
+// DeleteAPIKey provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) DeleteAPIKey(ctx context.Context, in *gen.DeleteAPIKeyRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -359,17 +359,17 @@ func (_m *WaypointClient) GetBuild(ctx context.Context, in *gen.GetBuildRequest,
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.Build
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetBuildRequest, ...grpc.CallOption) *gen.Build); ok {
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.DeleteAPIKeyRequest, ...grpc.CallOption) *emptypb.Empty); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.Build)
+			r0 = ret.Get(0).(*emptypb.Empty)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetBuildRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.DeleteAPIKeyRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -378,8 +378,8 @@ func (_m *WaypointClient) GetBuild(ctx context.Context, in *gen.GetBuildRequest,
 	return r0, r1
 }
 
-// GetConfig provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) GetConfig(ctx context.Context, in *gen.ConfigGetRequest, opts ...grpc.CallOption) (*gen.ConfigGetResponse, error) {
+// DeleteHostname provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) DeleteHostname(ctx context.Context, in *gen.DeleteHostnameRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -389,17 +389,17 @@ func (_m *WaypointClient) GetConfig(ctx context.Context, in *gen.ConfigGetReques
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.ConfigGetResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.ConfigGetRequest, ...grpc.CallOption) *gen.ConfigGetResponse); ok {
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.DeleteHostnameRequest, ...grpc.CallOption) *emptypb.Empty); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.ConfigGetResponse)
+			r0 = ret.Get(0).(*emptypb.Empty)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.ConfigGetRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.DeleteHostnameRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -408,8 +408,8 @@ func (_m *WaypointClient) GetConfig(ctx context.Context, in *gen.ConfigGetReques
 	return r0, r1
 }
 
-// GetDeployment provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) GetDeployment(ctx context.Context, in *gen.GetDeploymentRequest, opts ...grpc.CallOption) (*gen.Deployment, error) {
+// DeleteJob provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) DeleteJob(ctx context.Context, in *gen.DeleteJobRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -419,17 +419,17 @@ func (_m *WaypointClient) GetDeployment(ctx context.Context, in *gen.GetDeployme
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.Deployment
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetDeploymentRequest, ...grpc.CallOption) *gen.Deployment); ok {
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.DeleteJobRequest, ...grpc.CallOption) *emptypb.Empty); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.Deployment)
+			r0 = ret.Get(0).(*emptypb.Empty)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetDeploymentRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.DeleteJobRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -438,8 +438,8 @@ func (_m *WaypointClient) GetDeployment(ctx context.Context, in *gen.GetDeployme
 	return r0, r1
 }
 
-// GetJob provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) GetJob(ctx context.Context, in *gen.GetJobRequest, opts ...grpc.CallOption) (*gen.Job, error) {
+// DeleteJobTemplate provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) DeleteJobTemplate(ctx context.Context, in *gen.DeleteJobTemplateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -449,17 +449,17 @@ func (_m *WaypointClient) GetJob(ctx context.Context, in *gen.GetJobRequest, opt
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.Job
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetJobRequest, ...grpc.CallOption) *gen.Job); ok {
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.DeleteJobTemplateRequest, ...grpc.CallOption) *emptypb.Empty); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.Job)
+			r0 = ret.Get(0).(*emptypb.Empty)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetJobRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.DeleteJobTemplateRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -468,8 +468,8 @@ func (_m *WaypointClient) GetJob(ctx context.Context, in *gen.GetJobRequest, opt
 	return r0, r1
 }
 
-// GetJobStream provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) GetJobStream(ctx context.Context, in *gen.GetJobStreamRequest, opts ...grpc.CallOption) (gen.Waypoint_GetJobStreamClient, error) {
+// DeleteRunnerConfigFile provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) DeleteRunnerConfigFile(ctx context.Context, in *gen.DeleteRunnerConfigFileRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -479,17 +479,17 @@ func (_m *WaypointClient) GetJobStream(ctx context.Context, in *gen.GetJobStream
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 gen.Waypoint_GetJobStreamClient
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetJobStreamRequest, ...grpc.CallOption) gen.Waypoint_GetJobStreamClient); ok {
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.DeleteRunnerConfigFileRequest, ...grpc.CallOption) *emptypb.Empty); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(gen.Waypoint_GetJobStreamClient)
+			r0 = ret.Get(0).(*emptypb.Empty)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetJobStreamRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.DeleteRunnerConfigFileRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -498,8 +498,8 @@ func (_m *WaypointClient) GetJobStream(ctx context.Context, in *gen.GetJobStream
 	return r0, r1
 }
 
-// GetLatestBuild provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) GetLatestBuild(ctx context.Context, in *gen.GetLatestBuildRequest, opts ...grpc.CallOption) (*gen.Build, error) {
+// DeleteRunnerInstallation provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) DeleteRunnerInstallation(ctx context.Context, in *gen.DeleteRunnerInstallationRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -509,17 +509,17 @@ func (_m *WaypointClient) GetLatestBuild(ctx context.Context, in *gen.GetLatestB
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.Build
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetLatestBuildRequest, ...grpc.CallOption) *gen.Build); ok {
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.DeleteRunnerInstallationRequest, ...grpc.CallOption) *emptypb.Empty); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.Build)
+			r0 = ret.Get(0).(*emptypb.Empty)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetLatestBuildRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.DeleteRunnerInstallationRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -528,8 +528,8 @@ func (_m *WaypointClient) GetLatestBuild(ctx context.Context, in *gen.GetLatestB
 	return r0, r1
 }
 
-// GetLatestPushedArtifact provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) GetLatestPushedArtifact(ctx context.Context, in *gen.GetLatestPushedArtifactRequest, opts ...grpc.CallOption) (*gen.PushedArtifact, error) {
+// DeleteRunnerProfile provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) DeleteRunnerProfile(ctx context.Context, in *gen.DeleteRunnerProfileRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -539,17 +539,17 @@ func (_m *WaypointClient) GetLatestPushedArtifact(ctx context.Context, in *gen.G
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.PushedArtifact
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetLatestPushedArtifactRequest, ...grpc.CallOption) *gen.PushedArtifact); ok {
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.DeleteRunnerProfileRequest, ...grpc.CallOption) *emptypb.Empty); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.PushedArtifact)
+			r0 = ret.Get(0).(*emptypb.Empty)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetLatestPushedArtifactRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.DeleteRunnerProfileRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -558,8 +558,8 @@ func (_m *WaypointClient) GetLatestPushedArtifact(ctx context.Context, in *gen.G
 	return r0, r1
 }
 
-// GetLatestRelease provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) GetLatestRelease(ctx context.Context, in *gen.GetLatestReleaseRequest, opts ...grpc.CallOption) (*gen.Release, error) {
+// DrainRunner provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) DrainRunner(ctx context.Context, in *gen.DrainRunnerRequest, opts ...grpc.CallOption) (*gen.Runner, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -569,17 +569,17 @@ func (_m *WaypointClient) GetLatestRelease(ctx context.Context, in *gen.GetLates
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.Release
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetLatestReleaseRequest, ...grpc.CallOption) *gen.Release); ok {
+	var r0 *gen.Runner
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.DrainRunnerRequest, ...grpc.CallOption) *gen.Runner); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.Release)
+			r0 = ret.Get(0).(*gen.Runner)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetLatestReleaseRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.DrainRunnerRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -588,8 +588,8 @@ func (_m *WaypointClient) GetLatestRelease(ctx context.Context, in *gen.GetLates
 	return r0, r1
 }
 
-// GetLogStream provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) GetLogStream(ctx context.Context, in *gen.GetLogStreamRequest, opts ...grpc.CallOption) (gen.Waypoint_GetLogStreamClient, error) {
+// EntrypointConfig provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) EntrypointConfig(ctx context.Context, in *gen.EntrypointConfigRequest, opts ...grpc.CallOption) (gen.Waypoint_EntrypointConfigClient, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -599,17 +599,17 @@ func (_m *WaypointClient) GetLogStream(ctx context.Context, in *gen.GetLogStream
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 gen.Waypoint_GetLogStreamClient
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetLogStreamRequest, ...grpc.CallOption) gen.Waypoint_GetLogStreamClient); ok {
+	var r0 gen.Waypoint_EntrypointConfigClient
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.EntrypointConfigRequest, ...grpc.CallOption) gen.Waypoint_EntrypointConfigClient); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(gen.Waypoint_GetLogStreamClient)
+			r0 = ret.Get(0).(gen.Waypoint_EntrypointConfigClient)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetLogStreamRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.EntrypointConfigRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -618,29 +618,29 @@ func (_m *WaypointClient) GetLogStream(ctx context.Context, in *gen.GetLogStream
 	return r0, r1
 }
 
-// GetProject provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) GetProject(ctx context.Context, in *gen.GetProjectRequest, opts ...grpc.CallOption) (*gen.GetProjectResponse, error) {
+// EntrypointExecStream provides a mock function with given fields: ctx, opts
+func (_m *WaypointClient) EntrypointExecStream(ctx context.Context, opts ...grpc.CallOption) (gen.Waypoint_EntrypointExecStreamClient, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
 	}
 	var _ca []interface{}
-	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, ctx)
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.GetProjectResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetProjectRequest, ...grpc.CallOption) *gen.GetProjectResponse); ok {
-		r0 = rf(ctx, in, opts...)
+	var r0 gen.Waypoint_EntrypointExecStreamClient
+	if rf, ok := ret.Get(0).(func(context.Context, ...grpc.CallOption) gen.Waypoint_EntrypointExecStreamClient); ok {
+		r0 = rf(ctx, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.GetProjectResponse)
+			r0 = ret.Get(0).(gen.Waypoint_EntrypointExecStreamClient)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetProjectRequest, ...grpc.CallOption) error); ok {
-		r1 = rf(ctx, in, opts...)
+	if rf, ok := ret.Get(1).(func(context.Context, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, opts...)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -648,8 +648,38 @@ func (_m *WaypointClient) GetProject(ctx context.Context, in *gen.GetProjectRequ
 	return r0, r1
 }
 
-// GetPushedArtifact provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) GetPushedArtifact(ctx context.Context, in *gen.GetPushedArtifactRequest, opts ...grpc.CallOption) (*gen.PushedArtifact, error) {
+// EntrypointLogStream provides a mock function with given fields: ctx, opts
+func (_m *WaypointClient) EntrypointLogStream(ctx context.Context, opts ...grpc.CallOption) (gen.Waypoint_EntrypointLogStreamClient, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 gen.Waypoint_EntrypointLogStreamClient
+	if rf, ok := ret.Get(0).(func(context.Context, ...grpc.CallOption) gen.Waypoint_EntrypointLogStreamClient); ok {
+		r0 = rf(ctx, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gen.Waypoint_EntrypointLogStreamClient)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExportJobLogs provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ExportJobLogs(ctx context.Context, in *gen.ExportJobLogsRequest, opts ...grpc.CallOption) (gen.Waypoint_ExportJobLogsClient, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -659,17 +689,17 @@ func (_m *WaypointClient) GetPushedArtifact(ctx context.Context, in *gen.GetPush
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.PushedArtifact
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetPushedArtifactRequest, ...grpc.CallOption) *gen.PushedArtifact); ok {
+	var r0 gen.Waypoint_ExportJobLogsClient
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ExportJobLogsRequest, ...grpc.CallOption) gen.Waypoint_ExportJobLogsClient); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.PushedArtifact)
+			r0 = ret.Get(0).(gen.Waypoint_ExportJobLogsClient)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetPushedArtifactRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ExportJobLogsRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -678,8 +708,8 @@ func (_m *WaypointClient) GetPushedArtifact(ctx context.Context, in *gen.GetPush
 	return r0, r1
 }
 
-// GetRelease provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) GetRelease(ctx context.Context, in *gen.GetReleaseRequest, opts ...grpc.CallOption) (*gen.Release, error) {
+// ExportProject provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ExportProject(ctx context.Context, in *gen.ExportProjectRequest, opts ...grpc.CallOption) (gen.Waypoint_ExportProjectClient, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -689,17 +719,17 @@ func (_m *WaypointClient) GetRelease(ctx context.Context, in *gen.GetReleaseRequ
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.Release
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetReleaseRequest, ...grpc.CallOption) *gen.Release); ok {
+	var r0 gen.Waypoint_ExportProjectClient
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ExportProjectRequest, ...grpc.CallOption) gen.Waypoint_ExportProjectClient); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.Release)
+			r0 = ret.Get(0).(gen.Waypoint_ExportProjectClient)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetReleaseRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ExportProjectRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -708,8 +738,1688 @@ func (_m *WaypointClient) GetRelease(ctx context.Context, in *gen.GetReleaseRequ
 	return r0, r1
 }
 
-// GetRunner provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) GetRunner(ctx context.Context, in *gen.GetRunnerRequest, opts ...grpc.CallOption) (*gen.Runner, error) {
+// GenerateInviteToken provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GenerateInviteToken(ctx context.Context, in *gen.InviteTokenRequest, opts ...grpc.CallOption) (*gen.NewTokenResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.NewTokenResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.InviteTokenRequest, ...grpc.CallOption) *gen.NewTokenResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.NewTokenResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.InviteTokenRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GenerateLoginToken provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GenerateLoginToken(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.NewTokenResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.NewTokenResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.NewTokenResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.NewTokenResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBackupStatus provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetBackupStatus(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.GetBackupStatusResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.GetBackupStatusResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.GetBackupStatusResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetBackupStatusResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBuild provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetBuild(ctx context.Context, in *gen.GetBuildRequest, opts ...grpc.CallOption) (*gen.Build, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.Build
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetBuildRequest, ...grpc.CallOption) *gen.Build); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.Build)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetBuildRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetConfig provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetConfig(ctx context.Context, in *gen.ConfigGetRequest, opts ...grpc.CallOption) (*gen.ConfigGetResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.ConfigGetResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ConfigGetRequest, ...grpc.CallOption) *gen.ConfigGetResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ConfigGetResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ConfigGetRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDORAMetrics provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetDORAMetrics(ctx context.Context, in *gen.GetDORAMetricsRequest, opts ...grpc.CallOption) (*gen.GetDORAMetricsResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.GetDORAMetricsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetDORAMetricsRequest, ...grpc.CallOption) *gen.GetDORAMetricsResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetDORAMetricsResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetDORAMetricsRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeployment provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetDeployment(ctx context.Context, in *gen.GetDeploymentRequest, opts ...grpc.CallOption) (*gen.Deployment, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.Deployment
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetDeploymentRequest, ...grpc.CallOption) *gen.Deployment); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.Deployment)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetDeploymentRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeploymentLogStream provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetDeploymentLogStream(ctx context.Context, in *gen.GetDeploymentLogStreamRequest, opts ...grpc.CallOption) (gen.Waypoint_GetDeploymentLogStreamClient, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 gen.Waypoint_GetDeploymentLogStreamClient
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetDeploymentLogStreamRequest, ...grpc.CallOption) gen.Waypoint_GetDeploymentLogStreamClient); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gen.Waypoint_GetDeploymentLogStreamClient)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetDeploymentLogStreamRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetFederationStatus provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetFederationStatus(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.GetFederationStatusResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.GetFederationStatusResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.GetFederationStatusResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetFederationStatusResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetJob provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetJob(ctx context.Context, in *gen.GetJobRequest, opts ...grpc.CallOption) (*gen.Job, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.Job
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetJobRequest, ...grpc.CallOption) *gen.Job); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.Job)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetJobRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetJobConflicts provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetJobConflicts(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.JobConflicts, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.JobConflicts
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.JobConflicts); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.JobConflicts)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetJobLogRange provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetJobLogRange(ctx context.Context, in *gen.GetJobLogRangeRequest, opts ...grpc.CallOption) (*gen.GetJobLogRangeResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.GetJobLogRangeResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetJobLogRangeRequest, ...grpc.CallOption) *gen.GetJobLogRangeResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetJobLogRangeResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetJobLogRangeRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetJobQueuePosition provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetJobQueuePosition(ctx context.Context, in *gen.GetJobQueuePositionRequest, opts ...grpc.CallOption) (*gen.GetJobQueuePositionResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.GetJobQueuePositionResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetJobQueuePositionRequest, ...grpc.CallOption) *gen.GetJobQueuePositionResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetJobQueuePositionResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetJobQueuePositionRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetJobStream provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetJobStream(ctx context.Context, in *gen.GetJobStreamRequest, opts ...grpc.CallOption) (gen.Waypoint_GetJobStreamClient, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 gen.Waypoint_GetJobStreamClient
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetJobStreamRequest, ...grpc.CallOption) gen.Waypoint_GetJobStreamClient); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gen.Waypoint_GetJobStreamClient)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetJobStreamRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetJobTemplate provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetJobTemplate(ctx context.Context, in *gen.GetJobTemplateRequest, opts ...grpc.CallOption) (*gen.JobTemplate, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.JobTemplate
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetJobTemplateRequest, ...grpc.CallOption) *gen.JobTemplate); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.JobTemplate)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetJobTemplateRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLatestBuild provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetLatestBuild(ctx context.Context, in *gen.GetLatestBuildRequest, opts ...grpc.CallOption) (*gen.Build, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.Build
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetLatestBuildRequest, ...grpc.CallOption) *gen.Build); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.Build)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetLatestBuildRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLatestPushedArtifact provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetLatestPushedArtifact(ctx context.Context, in *gen.GetLatestPushedArtifactRequest, opts ...grpc.CallOption) (*gen.PushedArtifact, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.PushedArtifact
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetLatestPushedArtifactRequest, ...grpc.CallOption) *gen.PushedArtifact); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.PushedArtifact)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetLatestPushedArtifactRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLatestRelease provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetLatestRelease(ctx context.Context, in *gen.GetLatestReleaseRequest, opts ...grpc.CallOption) (*gen.Release, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.Release
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetLatestReleaseRequest, ...grpc.CallOption) *gen.Release); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.Release)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetLatestReleaseRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLogStream provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetLogStream(ctx context.Context, in *gen.GetLogStreamRequest, opts ...grpc.CallOption) (gen.Waypoint_GetLogStreamClient, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 gen.Waypoint_GetLogStreamClient
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetLogStreamRequest, ...grpc.CallOption) gen.Waypoint_GetLogStreamClient); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gen.Waypoint_GetLogStreamClient)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetLogStreamRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetProject provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetProject(ctx context.Context, in *gen.GetProjectRequest, opts ...grpc.CallOption) (*gen.GetProjectResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.GetProjectResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetProjectRequest, ...grpc.CallOption) *gen.GetProjectResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetProjectResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetProjectRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPushedArtifact provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetPushedArtifact(ctx context.Context, in *gen.GetPushedArtifactRequest, opts ...grpc.CallOption) (*gen.PushedArtifact, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.PushedArtifact
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetPushedArtifactRequest, ...grpc.CallOption) *gen.PushedArtifact); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.PushedArtifact)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetPushedArtifactRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetQueueDepth provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetQueueDepth(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.GetQueueDepthResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.GetQueueDepthResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.GetQueueDepthResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetQueueDepthResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRelease provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetRelease(ctx context.Context, in *gen.GetReleaseRequest, opts ...grpc.CallOption) (*gen.Release, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.Release
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetReleaseRequest, ...grpc.CallOption) *gen.Release); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.Release)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetReleaseRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRunner provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetRunner(ctx context.Context, in *gen.GetRunnerRequest, opts ...grpc.CallOption) (*gen.Runner, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.Runner
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetRunnerRequest, ...grpc.CallOption) *gen.Runner); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.Runner)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetRunnerRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRunnerInstallation provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetRunnerInstallation(ctx context.Context, in *gen.GetRunnerInstallationRequest, opts ...grpc.CallOption) (*gen.RunnerInstallation, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.RunnerInstallation
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetRunnerInstallationRequest, ...grpc.CallOption) *gen.RunnerInstallation); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.RunnerInstallation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetRunnerInstallationRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRunnerProfile provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetRunnerProfile(ctx context.Context, in *gen.GetRunnerProfileRequest, opts ...grpc.CallOption) (*gen.RunnerProfile, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.RunnerProfile
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetRunnerProfileRequest, ...grpc.CallOption) *gen.RunnerProfile); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.RunnerProfile)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetRunnerProfileRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRunnerStats provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetRunnerStats(ctx context.Context, in *gen.GetRunnerStatsRequest, opts ...grpc.CallOption) (*gen.GetRunnerStatsResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.GetRunnerStatsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetRunnerStatsRequest, ...grpc.CallOption) *gen.GetRunnerStatsResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetRunnerStatsResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetRunnerStatsRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRunnerVersion provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetRunnerVersion(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.GetRunnerVersionResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.GetRunnerVersionResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.GetRunnerVersionResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetRunnerVersionResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetServerConfig provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetServerConfig(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.GetServerConfigResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.GetServerConfigResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.GetServerConfigResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetServerConfigResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTelemetryReport provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetTelemetryReport(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.GetTelemetryReportResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.GetTelemetryReportResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.GetTelemetryReportResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetTelemetryReportResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetVersionInfo provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetVersionInfo(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.GetVersionInfoResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.GetVersionInfoResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.GetVersionInfoResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetVersionInfoResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWorkspace provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetWorkspace(ctx context.Context, in *gen.GetWorkspaceRequest, opts ...grpc.CallOption) (*gen.GetWorkspaceResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.GetWorkspaceResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetWorkspaceRequest, ...grpc.CallOption) *gen.GetWorkspaceResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetWorkspaceResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetWorkspaceRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWorkspacePolicy provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) GetWorkspacePolicy(ctx context.Context, in *gen.GetWorkspacePolicyRequest, opts ...grpc.CallOption) (*gen.WorkspacePolicy, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.WorkspacePolicy
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetWorkspacePolicyRequest, ...grpc.CallOption) *gen.WorkspacePolicy); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.WorkspacePolicy)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetWorkspacePolicyRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ImportProject provides a mock function with given fields: ctx, opts
+func (_m *WaypointClient) ImportProject(ctx context.Context, opts ...grpc.CallOption) (gen.Waypoint_ImportProjectClient, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 gen.Waypoint_ImportProjectClient
+	if rf, ok := ret.Get(0).(func(context.Context, ...grpc.CallOption) gen.Waypoint_ImportProjectClient); ok {
+		r0 = rf(ctx, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gen.Waypoint_ImportProjectClient)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListAPIKeys provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ListAPIKeys(ctx context.Context, in *gen.ListAPIKeysRequest, opts ...grpc.CallOption) (*gen.ListAPIKeysResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.ListAPIKeysResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListAPIKeysRequest, ...grpc.CallOption) *gen.ListAPIKeysResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ListAPIKeysResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListAPIKeysRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListBuilds provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ListBuilds(ctx context.Context, in *gen.ListBuildsRequest, opts ...grpc.CallOption) (*gen.ListBuildsResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.ListBuildsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListBuildsRequest, ...grpc.CallOption) *gen.ListBuildsResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ListBuildsResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListBuildsRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListDeployments provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ListDeployments(ctx context.Context, in *gen.ListDeploymentsRequest, opts ...grpc.CallOption) (*gen.ListDeploymentsResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.ListDeploymentsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListDeploymentsRequest, ...grpc.CallOption) *gen.ListDeploymentsResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ListDeploymentsResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListDeploymentsRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListHostnames provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ListHostnames(ctx context.Context, in *gen.ListHostnamesRequest, opts ...grpc.CallOption) (*gen.ListHostnamesResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.ListHostnamesResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListHostnamesRequest, ...grpc.CallOption) *gen.ListHostnamesResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ListHostnamesResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListHostnamesRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListInstances provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ListInstances(ctx context.Context, in *gen.ListInstancesRequest, opts ...grpc.CallOption) (*gen.ListInstancesResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.ListInstancesResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListInstancesRequest, ...grpc.CallOption) *gen.ListInstancesResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ListInstancesResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListInstancesRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListJobArtifacts provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ListJobArtifacts(ctx context.Context, in *gen.ListJobArtifactsRequest, opts ...grpc.CallOption) (*gen.ListJobArtifactsResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.ListJobArtifactsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListJobArtifactsRequest, ...grpc.CallOption) *gen.ListJobArtifactsResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ListJobArtifactsResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListJobArtifactsRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListJobQueuePauses provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ListJobQueuePauses(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.ListJobQueuePausesResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.ListJobQueuePausesResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.ListJobQueuePausesResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ListJobQueuePausesResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListJobTemplates provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ListJobTemplates(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.ListJobTemplatesResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.ListJobTemplatesResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.ListJobTemplatesResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ListJobTemplatesResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListJobsStream provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ListJobsStream(ctx context.Context, in *gen.ListJobsRequest, opts ...grpc.CallOption) (gen.Waypoint_ListJobsStreamClient, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 gen.Waypoint_ListJobsStreamClient
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListJobsRequest, ...grpc.CallOption) gen.Waypoint_ListJobsStreamClient); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gen.Waypoint_ListJobsStreamClient)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListJobsRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListProjects provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ListProjects(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.ListProjectsResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.ListProjectsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.ListProjectsResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ListProjectsResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPushedArtifacts provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ListPushedArtifacts(ctx context.Context, in *gen.ListPushedArtifactsRequest, opts ...grpc.CallOption) (*gen.ListPushedArtifactsResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.ListPushedArtifactsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListPushedArtifactsRequest, ...grpc.CallOption) *gen.ListPushedArtifactsResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ListPushedArtifactsResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListPushedArtifactsRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListReleases provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ListReleases(ctx context.Context, in *gen.ListReleasesRequest, opts ...grpc.CallOption) (*gen.ListReleasesResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.ListReleasesResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListReleasesRequest, ...grpc.CallOption) *gen.ListReleasesResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ListReleasesResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListReleasesRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListRunnerConfigFiles provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ListRunnerConfigFiles(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.ListRunnerConfigFilesResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.ListRunnerConfigFilesResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.ListRunnerConfigFilesResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ListRunnerConfigFilesResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListRunnerInstallations provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ListRunnerInstallations(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.ListRunnerInstallationsResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.ListRunnerInstallationsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.ListRunnerInstallationsResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ListRunnerInstallationsResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListRunnerProfiles provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ListRunnerProfiles(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.ListRunnerProfilesResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.ListRunnerProfilesResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.ListRunnerProfilesResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ListRunnerProfilesResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListRunnerTokens provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ListRunnerTokens(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.ListRunnerTokensResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.ListRunnerTokensResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.ListRunnerTokensResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ListRunnerTokensResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListRunners provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ListRunners(ctx context.Context, in *gen.ListRunnersRequest, opts ...grpc.CallOption) (*gen.ListRunnersResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.ListRunnersResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListRunnersRequest, ...grpc.CallOption) *gen.ListRunnersResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ListRunnersResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListRunnersRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListSnapshots provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ListSnapshots(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.ListSnapshotsResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.ListSnapshotsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.ListSnapshotsResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ListSnapshotsResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListWorkspaces provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ListWorkspaces(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.ListWorkspacesResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.ListWorkspacesResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.ListWorkspacesResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ListWorkspacesResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PauseJobQueue provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) PauseJobQueue(ctx context.Context, in *gen.JobQueuePause, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.JobQueuePause, ...grpc.CallOption) *emptypb.Empty); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*emptypb.Empty)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.JobQueuePause, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// QueueJob provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) QueueJob(ctx context.Context, in *gen.QueueJobRequest, opts ...grpc.CallOption) (*gen.QueueJobResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.QueueJobResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.QueueJobRequest, ...grpc.CallOption) *gen.QueueJobResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.QueueJobResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.QueueJobRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RestoreSnapshot provides a mock function with given fields: ctx, opts
+func (_m *WaypointClient) RestoreSnapshot(ctx context.Context, opts ...grpc.CallOption) (gen.Waypoint_RestoreSnapshotClient, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 gen.Waypoint_RestoreSnapshotClient
+	if rf, ok := ret.Get(0).(func(context.Context, ...grpc.CallOption) gen.Waypoint_RestoreSnapshotClient); ok {
+		r0 = rf(ctx, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gen.Waypoint_RestoreSnapshotClient)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResumeJobQueue provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) ResumeJobQueue(ctx context.Context, in *gen.JobQueuePause, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -719,17 +2429,17 @@ func (_m *WaypointClient) GetRunner(ctx context.Context, in *gen.GetRunnerReques
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.Runner
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetRunnerRequest, ...grpc.CallOption) *gen.Runner); ok {
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.JobQueuePause, ...grpc.CallOption) *emptypb.Empty); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.Runner)
+			r0 = ret.Get(0).(*emptypb.Empty)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetRunnerRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.JobQueuePause, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -738,8 +2448,8 @@ func (_m *WaypointClient) GetRunner(ctx context.Context, in *gen.GetRunnerReques
 	return r0, r1
 }
 
-// GetServerConfig provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) GetServerConfig(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.GetServerConfigResponse, error) {
+// RevokeRunnerToken provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) RevokeRunnerToken(ctx context.Context, in *gen.RevokeRunnerTokenRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -749,17 +2459,17 @@ func (_m *WaypointClient) GetServerConfig(ctx context.Context, in *emptypb.Empty
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.GetServerConfigResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.GetServerConfigResponse); ok {
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.RevokeRunnerTokenRequest, ...grpc.CallOption) *emptypb.Empty); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.GetServerConfigResponse)
+			r0 = ret.Get(0).(*emptypb.Empty)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.RevokeRunnerTokenRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -768,8 +2478,8 @@ func (_m *WaypointClient) GetServerConfig(ctx context.Context, in *emptypb.Empty
 	return r0, r1
 }
 
-// GetVersionInfo provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) GetVersionInfo(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.GetVersionInfoResponse, error) {
+// RotateAPIKey provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) RotateAPIKey(ctx context.Context, in *gen.RotateAPIKeyRequest, opts ...grpc.CallOption) (*gen.APIKeyTokenResponse, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -779,17 +2489,17 @@ func (_m *WaypointClient) GetVersionInfo(ctx context.Context, in *emptypb.Empty,
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.GetVersionInfoResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.GetVersionInfoResponse); ok {
+	var r0 *gen.APIKeyTokenResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.RotateAPIKeyRequest, ...grpc.CallOption) *gen.APIKeyTokenResponse); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.GetVersionInfoResponse)
+			r0 = ret.Get(0).(*gen.APIKeyTokenResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.RotateAPIKeyRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -798,8 +2508,8 @@ func (_m *WaypointClient) GetVersionInfo(ctx context.Context, in *emptypb.Empty,
 	return r0, r1
 }
 
-// GetWorkspace provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) GetWorkspace(ctx context.Context, in *gen.GetWorkspaceRequest, opts ...grpc.CallOption) (*gen.GetWorkspaceResponse, error) {
+// RotateRunnerToken provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) RotateRunnerToken(ctx context.Context, in *gen.RotateRunnerTokenRequest, opts ...grpc.CallOption) (*gen.RunnerTokenResponse, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -809,17 +2519,17 @@ func (_m *WaypointClient) GetWorkspace(ctx context.Context, in *gen.GetWorkspace
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.GetWorkspaceResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetWorkspaceRequest, ...grpc.CallOption) *gen.GetWorkspaceResponse); ok {
+	var r0 *gen.RunnerTokenResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.RotateRunnerTokenRequest, ...grpc.CallOption) *gen.RunnerTokenResponse); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.GetWorkspaceResponse)
+			r0 = ret.Get(0).(*gen.RunnerTokenResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetWorkspaceRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.RotateRunnerTokenRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -828,29 +2538,29 @@ func (_m *WaypointClient) GetWorkspace(ctx context.Context, in *gen.GetWorkspace
 	return r0, r1
 }
 
-// ListBuilds provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) ListBuilds(ctx context.Context, in *gen.ListBuildsRequest, opts ...grpc.CallOption) (*gen.ListBuildsResponse, error) {
+// RunnerConfig provides a mock function with given fields: ctx, opts
+func (_m *WaypointClient) RunnerConfig(ctx context.Context, opts ...grpc.CallOption) (gen.Waypoint_RunnerConfigClient, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
 	}
 	var _ca []interface{}
-	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, ctx)
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.ListBuildsResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListBuildsRequest, ...grpc.CallOption) *gen.ListBuildsResponse); ok {
-		r0 = rf(ctx, in, opts...)
+	var r0 gen.Waypoint_RunnerConfigClient
+	if rf, ok := ret.Get(0).(func(context.Context, ...grpc.CallOption) gen.Waypoint_RunnerConfigClient); ok {
+		r0 = rf(ctx, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.ListBuildsResponse)
+			r0 = ret.Get(0).(gen.Waypoint_RunnerConfigClient)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListBuildsRequest, ...grpc.CallOption) error); ok {
-		r1 = rf(ctx, in, opts...)
+	if rf, ok := ret.Get(1).(func(context.Context, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, opts...)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -858,8 +2568,8 @@ func (_m *WaypointClient) ListBuilds(ctx context.Context, in *gen.ListBuildsRequ
 	return r0, r1
 }
 
-// ListDeployments provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) ListDeployments(ctx context.Context, in *gen.ListDeploymentsRequest, opts ...grpc.CallOption) (*gen.ListDeploymentsResponse, error) {
+// RunnerGetDeploymentConfig provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) RunnerGetDeploymentConfig(ctx context.Context, in *gen.RunnerGetDeploymentConfigRequest, opts ...grpc.CallOption) (*gen.RunnerGetDeploymentConfigResponse, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -869,17 +2579,17 @@ func (_m *WaypointClient) ListDeployments(ctx context.Context, in *gen.ListDeplo
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.ListDeploymentsResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListDeploymentsRequest, ...grpc.CallOption) *gen.ListDeploymentsResponse); ok {
+	var r0 *gen.RunnerGetDeploymentConfigResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.RunnerGetDeploymentConfigRequest, ...grpc.CallOption) *gen.RunnerGetDeploymentConfigResponse); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.ListDeploymentsResponse)
+			r0 = ret.Get(0).(*gen.RunnerGetDeploymentConfigResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListDeploymentsRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.RunnerGetDeploymentConfigRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -888,29 +2598,29 @@ func (_m *WaypointClient) ListDeployments(ctx context.Context, in *gen.ListDeplo
 	return r0, r1
 }
 
-// ListHostnames provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) ListHostnames(ctx context.Context, in *gen.ListHostnamesRequest, opts ...grpc.CallOption) (*gen.ListHostnamesResponse, error) {
+// RunnerJobStream provides a mock function with given fields: ctx, opts
+func (_m *WaypointClient) RunnerJobStream(ctx context.Context, opts ...grpc.CallOption) (gen.Waypoint_RunnerJobStreamClient, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
 	}
 	var _ca []interface{}
-	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, ctx)
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.ListHostnamesResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListHostnamesRequest, ...grpc.CallOption) *gen.ListHostnamesResponse); ok {
-		r0 = rf(ctx, in, opts...)
+	var r0 gen.Waypoint_RunnerJobStreamClient
+	if rf, ok := ret.Get(0).(func(context.Context, ...grpc.CallOption) gen.Waypoint_RunnerJobStreamClient); ok {
+		r0 = rf(ctx, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.ListHostnamesResponse)
+			r0 = ret.Get(0).(gen.Waypoint_RunnerJobStreamClient)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListHostnamesRequest, ...grpc.CallOption) error); ok {
-		r1 = rf(ctx, in, opts...)
+	if rf, ok := ret.Get(1).(func(context.Context, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, opts...)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -918,8 +2628,8 @@ func (_m *WaypointClient) ListHostnames(ctx context.Context, in *gen.ListHostnam
 	return r0, r1
 }
 
-// ListInstances provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) ListInstances(ctx context.Context, in *gen.ListInstancesRequest, opts ...grpc.CallOption) (*gen.ListInstancesResponse, error) {
+// RunnerLogin provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) RunnerLogin(ctx context.Context, in *gen.RunnerLoginRequest, opts ...grpc.CallOption) (*gen.RunnerLoginResponse, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -929,17 +2639,17 @@ func (_m *WaypointClient) ListInstances(ctx context.Context, in *gen.ListInstanc
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.ListInstancesResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListInstancesRequest, ...grpc.CallOption) *gen.ListInstancesResponse); ok {
+	var r0 *gen.RunnerLoginResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.RunnerLoginRequest, ...grpc.CallOption) *gen.RunnerLoginResponse); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.ListInstancesResponse)
+			r0 = ret.Get(0).(*gen.RunnerLoginResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListInstancesRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.RunnerLoginRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -948,8 +2658,8 @@ func (_m *WaypointClient) ListInstances(ctx context.Context, in *gen.ListInstanc
 	return r0, r1
 }
 
-// ListProjects provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) ListProjects(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.ListProjectsResponse, error) {
+// SearchJobLogs provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) SearchJobLogs(ctx context.Context, in *gen.SearchJobLogsRequest, opts ...grpc.CallOption) (*gen.SearchJobLogsResponse, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -959,17 +2669,17 @@ func (_m *WaypointClient) ListProjects(ctx context.Context, in *emptypb.Empty, o
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.ListProjectsResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.ListProjectsResponse); ok {
+	var r0 *gen.SearchJobLogsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.SearchJobLogsRequest, ...grpc.CallOption) *gen.SearchJobLogsResponse); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.ListProjectsResponse)
+			r0 = ret.Get(0).(*gen.SearchJobLogsResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.SearchJobLogsRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -978,8 +2688,8 @@ func (_m *WaypointClient) ListProjects(ctx context.Context, in *emptypb.Empty, o
 	return r0, r1
 }
 
-// ListPushedArtifacts provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) ListPushedArtifacts(ctx context.Context, in *gen.ListPushedArtifactsRequest, opts ...grpc.CallOption) (*gen.ListPushedArtifactsResponse, error) {
+// SetConfig provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) SetConfig(ctx context.Context, in *gen.ConfigSetRequest, opts ...grpc.CallOption) (*gen.ConfigSetResponse, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -989,17 +2699,17 @@ func (_m *WaypointClient) ListPushedArtifacts(ctx context.Context, in *gen.ListP
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.ListPushedArtifactsResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListPushedArtifactsRequest, ...grpc.CallOption) *gen.ListPushedArtifactsResponse); ok {
+	var r0 *gen.ConfigSetResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ConfigSetRequest, ...grpc.CallOption) *gen.ConfigSetResponse); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.ListPushedArtifactsResponse)
+			r0 = ret.Get(0).(*gen.ConfigSetResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListPushedArtifactsRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ConfigSetRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -1008,8 +2718,8 @@ func (_m *WaypointClient) ListPushedArtifacts(ctx context.Context, in *gen.ListP
 	return r0, r1
 }
 
-// ListReleases provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) ListReleases(ctx context.Context, in *gen.ListReleasesRequest, opts ...grpc.CallOption) (*gen.ListReleasesResponse, error) {
+// SetJobConflicts provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) SetJobConflicts(ctx context.Context, in *gen.JobConflicts, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -1019,17 +2729,17 @@ func (_m *WaypointClient) ListReleases(ctx context.Context, in *gen.ListReleases
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.ListReleasesResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListReleasesRequest, ...grpc.CallOption) *gen.ListReleasesResponse); ok {
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.JobConflicts, ...grpc.CallOption) *emptypb.Empty); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.ListReleasesResponse)
+			r0 = ret.Get(0).(*emptypb.Empty)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListReleasesRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.JobConflicts, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -1038,8 +2748,8 @@ func (_m *WaypointClient) ListReleases(ctx context.Context, in *gen.ListReleases
 	return r0, r1
 }
 
-// ListWorkspaces provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) ListWorkspaces(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*gen.ListWorkspacesResponse, error) {
+// SetJobTemplate provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) SetJobTemplate(ctx context.Context, in *gen.SetJobTemplateRequest, opts ...grpc.CallOption) (*gen.JobTemplate, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -1049,17 +2759,17 @@ func (_m *WaypointClient) ListWorkspaces(ctx context.Context, in *emptypb.Empty,
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.ListWorkspacesResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) *gen.ListWorkspacesResponse); ok {
+	var r0 *gen.JobTemplate
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.SetJobTemplateRequest, ...grpc.CallOption) *gen.JobTemplate); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.ListWorkspacesResponse)
+			r0 = ret.Get(0).(*gen.JobTemplate)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.SetJobTemplateRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -1068,8 +2778,8 @@ func (_m *WaypointClient) ListWorkspaces(ctx context.Context, in *emptypb.Empty,
 	return r0, r1
 }
 
-// QueueJob provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) QueueJob(ctx context.Context, in *gen.QueueJobRequest, opts ...grpc.CallOption) (*gen.QueueJobResponse, error) {
+// SetRunnerConfigFile provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) SetRunnerConfigFile(ctx context.Context, in *gen.SetRunnerConfigFileRequest, opts ...grpc.CallOption) (*gen.RunnerConfigFile, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -1079,17 +2789,17 @@ func (_m *WaypointClient) QueueJob(ctx context.Context, in *gen.QueueJobRequest,
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.QueueJobResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.QueueJobRequest, ...grpc.CallOption) *gen.QueueJobResponse); ok {
+	var r0 *gen.RunnerConfigFile
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.SetRunnerConfigFileRequest, ...grpc.CallOption) *gen.RunnerConfigFile); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.QueueJobResponse)
+			r0 = ret.Get(0).(*gen.RunnerConfigFile)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.QueueJobRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.SetRunnerConfigFileRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -1098,29 +2808,29 @@ func (_m *WaypointClient) QueueJob(ctx context.Context, in *gen.QueueJobRequest,
 	return r0, r1
 }
 
-// RestoreSnapshot provides a mock function with given fields: ctx, opts
-func (_m *WaypointClient) RestoreSnapshot(ctx context.Context, opts ...grpc.CallOption) (gen.Waypoint_RestoreSnapshotClient, error) {
+// SetRunnerInstallation provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) SetRunnerInstallation(ctx context.Context, in *gen.SetRunnerInstallationRequest, opts ...grpc.CallOption) (*gen.RunnerInstallation, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
 	}
 	var _ca []interface{}
-	_ca = append(_ca, ctx)
+	_ca = append(_ca, ctx, in)
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 gen.Waypoint_RestoreSnapshotClient
-	if rf, ok := ret.Get(0).(func(context.Context, ...grpc.CallOption) gen.Waypoint_RestoreSnapshotClient); ok {
-		r0 = rf(ctx, opts...)
+	var r0 *gen.RunnerInstallation
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.SetRunnerInstallationRequest, ...grpc.CallOption) *gen.RunnerInstallation); ok {
+		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(gen.Waypoint_RestoreSnapshotClient)
+			r0 = ret.Get(0).(*gen.RunnerInstallation)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, ...grpc.CallOption) error); ok {
-		r1 = rf(ctx, opts...)
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.SetRunnerInstallationRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1128,29 +2838,29 @@ func (_m *WaypointClient) RestoreSnapshot(ctx context.Context, opts ...grpc.Call
 	return r0, r1
 }
 
-// RunnerConfig provides a mock function with given fields: ctx, opts
-func (_m *WaypointClient) RunnerConfig(ctx context.Context, opts ...grpc.CallOption) (gen.Waypoint_RunnerConfigClient, error) {
+// SetRunnerProfile provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) SetRunnerProfile(ctx context.Context, in *gen.SetRunnerProfileRequest, opts ...grpc.CallOption) (*gen.RunnerProfile, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
 	}
 	var _ca []interface{}
-	_ca = append(_ca, ctx)
+	_ca = append(_ca, ctx, in)
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 gen.Waypoint_RunnerConfigClient
-	if rf, ok := ret.Get(0).(func(context.Context, ...grpc.CallOption) gen.Waypoint_RunnerConfigClient); ok {
-		r0 = rf(ctx, opts...)
+	var r0 *gen.RunnerProfile
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.SetRunnerProfileRequest, ...grpc.CallOption) *gen.RunnerProfile); ok {
+		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(gen.Waypoint_RunnerConfigClient)
+			r0 = ret.Get(0).(*gen.RunnerProfile)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, ...grpc.CallOption) error); ok {
-		r1 = rf(ctx, opts...)
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.SetRunnerProfileRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1158,8 +2868,8 @@ func (_m *WaypointClient) RunnerConfig(ctx context.Context, opts ...grpc.CallOpt
 	return r0, r1
 }
 
-// RunnerGetDeploymentConfig provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) RunnerGetDeploymentConfig(ctx context.Context, in *gen.RunnerGetDeploymentConfigRequest, opts ...grpc.CallOption) (*gen.RunnerGetDeploymentConfigResponse, error) {
+// SetRunnerUpdateState provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) SetRunnerUpdateState(ctx context.Context, in *gen.SetRunnerUpdateStateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -1169,17 +2879,17 @@ func (_m *WaypointClient) RunnerGetDeploymentConfig(ctx context.Context, in *gen
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.RunnerGetDeploymentConfigResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.RunnerGetDeploymentConfigRequest, ...grpc.CallOption) *gen.RunnerGetDeploymentConfigResponse); ok {
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.SetRunnerUpdateStateRequest, ...grpc.CallOption) *emptypb.Empty); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.RunnerGetDeploymentConfigResponse)
+			r0 = ret.Get(0).(*emptypb.Empty)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.RunnerGetDeploymentConfigRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.SetRunnerUpdateStateRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -1188,29 +2898,29 @@ func (_m *WaypointClient) RunnerGetDeploymentConfig(ctx context.Context, in *gen
 	return r0, r1
 }
 
-// RunnerJobStream provides a mock function with given fields: ctx, opts
-func (_m *WaypointClient) RunnerJobStream(ctx context.Context, opts ...grpc.CallOption) (gen.Waypoint_RunnerJobStreamClient, error) {
+// SetRunnerVersion provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) SetRunnerVersion(ctx context.Context, in *gen.SetRunnerVersionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
 	}
 	var _ca []interface{}
-	_ca = append(_ca, ctx)
+	_ca = append(_ca, ctx, in)
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 gen.Waypoint_RunnerJobStreamClient
-	if rf, ok := ret.Get(0).(func(context.Context, ...grpc.CallOption) gen.Waypoint_RunnerJobStreamClient); ok {
-		r0 = rf(ctx, opts...)
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.SetRunnerVersionRequest, ...grpc.CallOption) *emptypb.Empty); ok {
+		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(gen.Waypoint_RunnerJobStreamClient)
+			r0 = ret.Get(0).(*emptypb.Empty)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, ...grpc.CallOption) error); ok {
-		r1 = rf(ctx, opts...)
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.SetRunnerVersionRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1218,8 +2928,8 @@ func (_m *WaypointClient) RunnerJobStream(ctx context.Context, opts ...grpc.Call
 	return r0, r1
 }
 
-// SetConfig provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) SetConfig(ctx context.Context, in *gen.ConfigSetRequest, opts ...grpc.CallOption) (*gen.ConfigSetResponse, error) {
+// SetServerConfig provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) SetServerConfig(ctx context.Context, in *gen.SetServerConfigRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -1229,17 +2939,17 @@ func (_m *WaypointClient) SetConfig(ctx context.Context, in *gen.ConfigSetReques
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *gen.ConfigSetResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.ConfigSetRequest, ...grpc.CallOption) *gen.ConfigSetResponse); ok {
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.SetServerConfigRequest, ...grpc.CallOption) *emptypb.Empty); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.ConfigSetResponse)
+			r0 = ret.Get(0).(*emptypb.Empty)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.ConfigSetRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.SetServerConfigRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -1248,8 +2958,8 @@ func (_m *WaypointClient) SetConfig(ctx context.Context, in *gen.ConfigSetReques
 	return r0, r1
 }
 
-// SetServerConfig provides a mock function with given fields: ctx, in, opts
-func (_m *WaypointClient) SetServerConfig(ctx context.Context, in *gen.SetServerConfigRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+// SetWorkspacePolicy provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) SetWorkspacePolicy(ctx context.Context, in *gen.SetWorkspacePolicyRequest, opts ...grpc.CallOption) (*gen.WorkspacePolicy, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -1259,17 +2969,17 @@ func (_m *WaypointClient) SetServerConfig(ctx context.Context, in *gen.SetServer
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *emptypb.Empty
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.SetServerConfigRequest, ...grpc.CallOption) *emptypb.Empty); ok {
+	var r0 *gen.WorkspacePolicy
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.SetWorkspacePolicyRequest, ...grpc.CallOption) *gen.WorkspacePolicy); ok {
 		r0 = rf(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*emptypb.Empty)
+			r0 = ret.Get(0).(*gen.WorkspacePolicy)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.SetServerConfigRequest, ...grpc.CallOption) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.SetWorkspacePolicyRequest, ...grpc.CallOption) error); ok {
 		r1 = rf(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -1308,6 +3018,36 @@ func (_m *WaypointClient) StartExecStream(ctx context.Context, opts ...grpc.Call
 	return r0, r1
 }
 
+// UndrainRunner provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) UndrainRunner(ctx context.Context, in *gen.DrainRunnerRequest, opts ...grpc.CallOption) (*gen.Runner, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.Runner
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.DrainRunnerRequest, ...grpc.CallOption) *gen.Runner); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.Runner)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.DrainRunnerRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpsertApplication provides a mock function with given fields: ctx, in, opts
 func (_m *WaypointClient) UpsertApplication(ctx context.Context, in *gen.UpsertApplicationRequest, opts ...grpc.CallOption) (*gen.UpsertApplicationResponse, error) {
 	_va := make([]interface{}, len(opts))
@@ -1518,6 +3258,66 @@ func (_m *WaypointClient) ValidateJob(ctx context.Context, in *gen.ValidateJobRe
 	return r0, r1
 }
 
+// VerifyState provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) VerifyState(ctx context.Context, in *gen.VerifyStateRequest, opts ...grpc.CallOption) (*gen.VerifyStateResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gen.VerifyStateResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.VerifyStateRequest, ...grpc.CallOption) *gen.VerifyStateResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.VerifyStateResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.VerifyStateRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WatchJobs provides a mock function with given fields: ctx, in, opts
+func (_m *WaypointClient) WatchJobs(ctx context.Context, in *gen.WatchJobsRequest, opts ...grpc.CallOption) (gen.Waypoint_WatchJobsClient, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 gen.Waypoint_WatchJobsClient
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.WatchJobsRequest, ...grpc.CallOption) gen.Waypoint_WatchJobsClient); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(gen.Waypoint_WatchJobsClient)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.WatchJobsRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // XListJobs provides a mock function with given fields: ctx, in, opts
 func (_m *WaypointClient) XListJobs(ctx context.Context, in *gen.ListJobsRequest, opts ...grpc.CallOption) (*gen.ListJobsResponse, error) {
 	_va := make([]interface{}, len(opts))
@@ -16,21 +16,1265 @@ type WaypointServer struct {
 	mock.Mock
 }
 
+// ApproveJob provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ApproveJob(_a0 context.Context, _a1 *gen.ApproveJobRequest) (*gen.Job, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.Job
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ApproveJobRequest) *gen.Job); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.Job)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ApproveJobRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ApproveRunner provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ApproveRunner(_a0 context.Context, _a1 *gen.ApproveRunnerRequest) (*emptypb.Empty, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ApproveRunnerRequest) *emptypb.Empty); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*emptypb.Empty)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ApproveRunnerRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // BootstrapToken provides a mock function with given fields: _a0, _a1
 func (_m *WaypointServer) BootstrapToken(_a0 context.Context, _a1 *emptypb.Empty) (*gen.NewTokenResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.NewTokenResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.NewTokenResponse); ok {
+	var r0 *gen.NewTokenResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.NewTokenResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.NewTokenResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CancelJob provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) CancelJob(_a0 context.Context, _a1 *gen.CancelJobRequest) (*emptypb.Empty, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.CancelJobRequest) *emptypb.Empty); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*emptypb.Empty)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.CancelJobRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CancelJobs provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) CancelJobs(_a0 context.Context, _a1 *gen.CancelJobsRequest) (*gen.CancelJobsResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.CancelJobsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.CancelJobsRequest) *gen.CancelJobsResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.CancelJobsResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.CancelJobsRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CompactDatabase provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) CompactDatabase(_a0 context.Context, _a1 *emptypb.Empty) (*gen.CompactDatabaseResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.CompactDatabaseResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.CompactDatabaseResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.CompactDatabaseResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ConvertInviteToken provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ConvertInviteToken(_a0 context.Context, _a1 *gen.ConvertInviteTokenRequest) (*gen.NewTokenResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.NewTokenResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ConvertInviteTokenRequest) *gen.NewTokenResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.NewTokenResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ConvertInviteTokenRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateAPIKey provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) CreateAPIKey(_a0 context.Context, _a1 *gen.CreateAPIKeyRequest) (*gen.APIKeyTokenResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.APIKeyTokenResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.CreateAPIKeyRequest) *gen.APIKeyTokenResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.APIKeyTokenResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.CreateAPIKeyRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateHostname provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) CreateHostname(_a0 context.Context, _a1 *gen.CreateHostnameRequest) (*gen.CreateHostnameResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.CreateHostnameResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.CreateHostnameRequest) *gen.CreateHostnameResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.CreateHostnameResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.CreateHostnameRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateRunnerToken provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) CreateRunnerToken(_a0 context.Context, _a1 *gen.CreateRunnerTokenRequest) (*gen.RunnerTokenResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.RunnerTokenResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.CreateRunnerTokenRequest) *gen.RunnerTokenResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.RunnerTokenResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.CreateRunnerTokenRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateSnapshot provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) CreateSnapshot(_a0 *emptypb.Empty, _a1 gen.Waypoint_CreateSnapshotServer) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*emptypb.Empty, gen.Waypoint_CreateSnapshotServer) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteAPIKey provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) DeleteAPIKey(_a0 context.Context, _a1 *gen.DeleteAPIKeyRequest) (*emptypb.Empty, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.DeleteAPIKeyRequest) *emptypb.Empty); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*emptypb.Empty)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.DeleteAPIKeyRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteHostname provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) DeleteHostname(_a0 context.Context, _a1 *gen.DeleteHostnameRequest) (*emptypb.Empty, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.DeleteHostnameRequest) *emptypb.Empty); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*emptypb.Empty)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.DeleteHostnameRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteJob provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) DeleteJob(_a0 context.Context, _a1 *gen.DeleteJobRequest) (*emptypb.Empty, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.DeleteJobRequest) *emptypb.Empty); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*emptypb.Empty)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.DeleteJobRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteJobTemplate provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) DeleteJobTemplate(_a0 context.Context, _a1 *gen.DeleteJobTemplateRequest) (*emptypb.Empty, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.DeleteJobTemplateRequest) *emptypb.Empty); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*emptypb.Empty)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.DeleteJobTemplateRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteRunnerConfigFile provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) DeleteRunnerConfigFile(_a0 context.Context, _a1 *gen.DeleteRunnerConfigFileRequest) (*emptypb.Empty, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.DeleteRunnerConfigFileRequest) *emptypb.Empty); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*emptypb.Empty)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.DeleteRunnerConfigFileRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteRunnerInstallation provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) DeleteRunnerInstallation(_a0 context.Context, _a1 *gen.DeleteRunnerInstallationRequest) (*emptypb.Empty, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.DeleteRunnerInstallationRequest) *emptypb.Empty); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*emptypb.Empty)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.DeleteRunnerInstallationRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteRunnerProfile provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) DeleteRunnerProfile(_a0 context.Context, _a1 *gen.DeleteRunnerProfileRequest) (*emptypb.Empty, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.DeleteRunnerProfileRequest) *emptypb.Empty); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*emptypb.Empty)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.DeleteRunnerProfileRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DrainRunner provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) DrainRunner(_a0 context.Context, _a1 *gen.DrainRunnerRequest) (*gen.Runner, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.Runner
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.DrainRunnerRequest) *gen.Runner); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.Runner)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.DrainRunnerRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EntrypointConfig provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) EntrypointConfig(_a0 *gen.EntrypointConfigRequest, _a1 gen.Waypoint_EntrypointConfigServer) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*gen.EntrypointConfigRequest, gen.Waypoint_EntrypointConfigServer) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EntrypointExecStream provides a mock function with given fields: _a0
+func (_m *WaypointServer) EntrypointExecStream(_a0 gen.Waypoint_EntrypointExecStreamServer) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(gen.Waypoint_EntrypointExecStreamServer) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EntrypointLogStream provides a mock function with given fields: _a0
+func (_m *WaypointServer) EntrypointLogStream(_a0 gen.Waypoint_EntrypointLogStreamServer) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(gen.Waypoint_EntrypointLogStreamServer) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ExportJobLogs provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ExportJobLogs(_a0 *gen.ExportJobLogsRequest, _a1 gen.Waypoint_ExportJobLogsServer) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*gen.ExportJobLogsRequest, gen.Waypoint_ExportJobLogsServer) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ExportProject provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ExportProject(_a0 *gen.ExportProjectRequest, _a1 gen.Waypoint_ExportProjectServer) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*gen.ExportProjectRequest, gen.Waypoint_ExportProjectServer) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GenerateInviteToken provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GenerateInviteToken(_a0 context.Context, _a1 *gen.InviteTokenRequest) (*gen.NewTokenResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.NewTokenResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.InviteTokenRequest) *gen.NewTokenResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.NewTokenResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.InviteTokenRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GenerateLoginToken provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GenerateLoginToken(_a0 context.Context, _a1 *emptypb.Empty) (*gen.NewTokenResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.NewTokenResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.NewTokenResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.NewTokenResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBackupStatus provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetBackupStatus(_a0 context.Context, _a1 *emptypb.Empty) (*gen.GetBackupStatusResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.GetBackupStatusResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.GetBackupStatusResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetBackupStatusResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBuild provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetBuild(_a0 context.Context, _a1 *gen.GetBuildRequest) (*gen.Build, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.Build
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetBuildRequest) *gen.Build); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.Build)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetBuildRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetConfig provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetConfig(_a0 context.Context, _a1 *gen.ConfigGetRequest) (*gen.ConfigGetResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.ConfigGetResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ConfigGetRequest) *gen.ConfigGetResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ConfigGetResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ConfigGetRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDORAMetrics provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetDORAMetrics(_a0 context.Context, _a1 *gen.GetDORAMetricsRequest) (*gen.GetDORAMetricsResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.GetDORAMetricsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetDORAMetricsRequest) *gen.GetDORAMetricsResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetDORAMetricsResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetDORAMetricsRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeployment provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetDeployment(_a0 context.Context, _a1 *gen.GetDeploymentRequest) (*gen.Deployment, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.Deployment
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetDeploymentRequest) *gen.Deployment); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.Deployment)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetDeploymentRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeploymentLogStream provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetDeploymentLogStream(_a0 *gen.GetDeploymentLogStreamRequest, _a1 gen.Waypoint_GetDeploymentLogStreamServer) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*gen.GetDeploymentLogStreamRequest, gen.Waypoint_GetDeploymentLogStreamServer) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetFederationStatus provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetFederationStatus(_a0 context.Context, _a1 *emptypb.Empty) (*gen.GetFederationStatusResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.GetFederationStatusResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.GetFederationStatusResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetFederationStatusResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetJob provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetJob(_a0 context.Context, _a1 *gen.GetJobRequest) (*gen.Job, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.Job
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetJobRequest) *gen.Job); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.Job)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetJobRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetJobConflicts provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetJobConflicts(_a0 context.Context, _a1 *emptypb.Empty) (*gen.JobConflicts, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.JobConflicts
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.JobConflicts); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.JobConflicts)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetJobLogRange provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetJobLogRange(_a0 context.Context, _a1 *gen.GetJobLogRangeRequest) (*gen.GetJobLogRangeResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.GetJobLogRangeResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetJobLogRangeRequest) *gen.GetJobLogRangeResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetJobLogRangeResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetJobLogRangeRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetJobQueuePosition provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetJobQueuePosition(_a0 context.Context, _a1 *gen.GetJobQueuePositionRequest) (*gen.GetJobQueuePositionResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.GetJobQueuePositionResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetJobQueuePositionRequest) *gen.GetJobQueuePositionResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetJobQueuePositionResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetJobQueuePositionRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetJobStream provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetJobStream(_a0 *gen.GetJobStreamRequest, _a1 gen.Waypoint_GetJobStreamServer) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*gen.GetJobStreamRequest, gen.Waypoint_GetJobStreamServer) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetJobTemplate provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetJobTemplate(_a0 context.Context, _a1 *gen.GetJobTemplateRequest) (*gen.JobTemplate, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.JobTemplate
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetJobTemplateRequest) *gen.JobTemplate); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.JobTemplate)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetJobTemplateRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLatestBuild provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetLatestBuild(_a0 context.Context, _a1 *gen.GetLatestBuildRequest) (*gen.Build, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.Build
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetLatestBuildRequest) *gen.Build); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.Build)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetLatestBuildRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLatestPushedArtifact provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetLatestPushedArtifact(_a0 context.Context, _a1 *gen.GetLatestPushedArtifactRequest) (*gen.PushedArtifact, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.PushedArtifact
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetLatestPushedArtifactRequest) *gen.PushedArtifact); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.PushedArtifact)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetLatestPushedArtifactRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLatestRelease provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetLatestRelease(_a0 context.Context, _a1 *gen.GetLatestReleaseRequest) (*gen.Release, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.Release
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetLatestReleaseRequest) *gen.Release); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.Release)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetLatestReleaseRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLogStream provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetLogStream(_a0 *gen.GetLogStreamRequest, _a1 gen.Waypoint_GetLogStreamServer) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*gen.GetLogStreamRequest, gen.Waypoint_GetLogStreamServer) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetProject provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetProject(_a0 context.Context, _a1 *gen.GetProjectRequest) (*gen.GetProjectResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.GetProjectResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetProjectRequest) *gen.GetProjectResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetProjectResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetProjectRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPushedArtifact provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetPushedArtifact(_a0 context.Context, _a1 *gen.GetPushedArtifactRequest) (*gen.PushedArtifact, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.PushedArtifact
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetPushedArtifactRequest) *gen.PushedArtifact); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.PushedArtifact)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetPushedArtifactRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetQueueDepth provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetQueueDepth(_a0 context.Context, _a1 *emptypb.Empty) (*gen.GetQueueDepthResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.GetQueueDepthResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.GetQueueDepthResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetQueueDepthResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRelease provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetRelease(_a0 context.Context, _a1 *gen.GetReleaseRequest) (*gen.Release, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.Release
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetReleaseRequest) *gen.Release); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.Release)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetReleaseRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRunner provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetRunner(_a0 context.Context, _a1 *gen.GetRunnerRequest) (*gen.Runner, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.Runner
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetRunnerRequest) *gen.Runner); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.Runner)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetRunnerRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRunnerInstallation provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetRunnerInstallation(_a0 context.Context, _a1 *gen.GetRunnerInstallationRequest) (*gen.RunnerInstallation, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.RunnerInstallation
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetRunnerInstallationRequest) *gen.RunnerInstallation); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.RunnerInstallation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetRunnerInstallationRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRunnerProfile provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetRunnerProfile(_a0 context.Context, _a1 *gen.GetRunnerProfileRequest) (*gen.RunnerProfile, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.RunnerProfile
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetRunnerProfileRequest) *gen.RunnerProfile); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.RunnerProfile)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetRunnerProfileRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRunnerStats provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetRunnerStats(_a0 context.Context, _a1 *gen.GetRunnerStatsRequest) (*gen.GetRunnerStatsResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.GetRunnerStatsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetRunnerStatsRequest) *gen.GetRunnerStatsResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetRunnerStatsResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetRunnerStatsRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRunnerVersion provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetRunnerVersion(_a0 context.Context, _a1 *emptypb.Empty) (*gen.GetRunnerVersionResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.GetRunnerVersionResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.GetRunnerVersionResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetRunnerVersionResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetServerConfig provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetServerConfig(_a0 context.Context, _a1 *emptypb.Empty) (*gen.GetServerConfigResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.GetServerConfigResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.GetServerConfigResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetServerConfigResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTelemetryReport provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetTelemetryReport(_a0 context.Context, _a1 *emptypb.Empty) (*gen.GetTelemetryReportResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.GetTelemetryReportResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.GetTelemetryReportResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetTelemetryReportResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetVersionInfo provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetVersionInfo(_a0 context.Context, _a1 *emptypb.Empty) (*gen.GetVersionInfoResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.GetVersionInfoResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.GetVersionInfoResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetVersionInfoResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWorkspace provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetWorkspace(_a0 context.Context, _a1 *gen.GetWorkspaceRequest) (*gen.GetWorkspaceResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.GetWorkspaceResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetWorkspaceRequest) *gen.GetWorkspaceResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.GetWorkspaceResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetWorkspaceRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWorkspacePolicy provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) GetWorkspacePolicy(_a0 context.Context, _a1 *gen.GetWorkspacePolicyRequest) (*gen.WorkspacePolicy, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.WorkspacePolicy
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetWorkspacePolicyRequest) *gen.WorkspacePolicy); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.WorkspacePolicy)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetWorkspacePolicyRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ImportProject provides a mock function with given fields: _a0
+func (_m *WaypointServer) ImportProject(_a0 gen.Waypoint_ImportProjectServer) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(gen.Waypoint_ImportProjectServer) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListAPIKeys provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ListAPIKeys(_a0 context.Context, _a1 *gen.ListAPIKeysRequest) (*gen.ListAPIKeysResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.ListAPIKeysResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListAPIKeysRequest) *gen.ListAPIKeysResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.NewTokenResponse)
+			r0 = ret.Get(0).(*gen.ListAPIKeysResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListAPIKeysRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -39,21 +1283,21 @@ func (_m *WaypointServer) BootstrapToken(_a0 context.Context, _a1 *emptypb.Empty
 	return r0, r1
 }
 
-// CancelJob provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) CancelJob(_a0 context.Context, _a1 *gen.CancelJobRequest) (*emptypb.Empty, error) {
+// ListBuilds provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ListBuilds(_a0 context.Context, _a1 *gen.ListBuildsRequest) (*gen.ListBuildsResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *emptypb.Empty
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.CancelJobRequest) *emptypb.Empty); ok {
+	var r0 *gen.ListBuildsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListBuildsRequest) *gen.ListBuildsResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*emptypb.Empty)
+			r0 = ret.Get(0).(*gen.ListBuildsResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.CancelJobRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListBuildsRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -62,21 +1306,21 @@ func (_m *WaypointServer) CancelJob(_a0 context.Context, _a1 *gen.CancelJobReque
 	return r0, r1
 }
 
-// ConvertInviteToken provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) ConvertInviteToken(_a0 context.Context, _a1 *gen.ConvertInviteTokenRequest) (*gen.NewTokenResponse, error) {
+// ListDeployments provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ListDeployments(_a0 context.Context, _a1 *gen.ListDeploymentsRequest) (*gen.ListDeploymentsResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.NewTokenResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.ConvertInviteTokenRequest) *gen.NewTokenResponse); ok {
+	var r0 *gen.ListDeploymentsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListDeploymentsRequest) *gen.ListDeploymentsResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.NewTokenResponse)
+			r0 = ret.Get(0).(*gen.ListDeploymentsResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.ConvertInviteTokenRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListDeploymentsRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -85,21 +1329,21 @@ func (_m *WaypointServer) ConvertInviteToken(_a0 context.Context, _a1 *gen.Conve
 	return r0, r1
 }
 
-// CreateHostname provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) CreateHostname(_a0 context.Context, _a1 *gen.CreateHostnameRequest) (*gen.CreateHostnameResponse, error) {
+// ListHostnames provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ListHostnames(_a0 context.Context, _a1 *gen.ListHostnamesRequest) (*gen.ListHostnamesResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.CreateHostnameResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.CreateHostnameRequest) *gen.CreateHostnameResponse); ok {
+	var r0 *gen.ListHostnamesResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListHostnamesRequest) *gen.ListHostnamesResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.CreateHostnameResponse)
+			r0 = ret.Get(0).(*gen.ListHostnamesResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.CreateHostnameRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListHostnamesRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -108,35 +1352,44 @@ func (_m *WaypointServer) CreateHostname(_a0 context.Context, _a1 *gen.CreateHos
 	return r0, r1
 }
 
-// CreateSnapshot provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) CreateSnapshot(_a0 *emptypb.Empty, _a1 gen.Waypoint_CreateSnapshotServer) error {
+// ListInstances provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ListInstances(_a0 context.Context, _a1 *gen.ListInstancesRequest) (*gen.ListInstancesResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(*emptypb.Empty, gen.Waypoint_CreateSnapshotServer) error); ok {
+	var r0 *gen.ListInstancesResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListInstancesRequest) *gen.ListInstancesResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ListInstancesResponse)
+		}
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListInstancesRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// DeleteHostname provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) DeleteHostname(_a0 context.Context, _a1 *gen.DeleteHostnameRequest) (*emptypb.Empty, error) {
+// ListJobArtifacts provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ListJobArtifacts(_a0 context.Context, _a1 *gen.ListJobArtifactsRequest) (*gen.ListJobArtifactsResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *emptypb.Empty
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.DeleteHostnameRequest) *emptypb.Empty); ok {
+	var r0 *gen.ListJobArtifactsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListJobArtifactsRequest) *gen.ListJobArtifactsResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*emptypb.Empty)
+			r0 = ret.Get(0).(*gen.ListJobArtifactsResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.DeleteHostnameRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListJobArtifactsRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -145,41 +1398,59 @@ func (_m *WaypointServer) DeleteHostname(_a0 context.Context, _a1 *gen.DeleteHos
 	return r0, r1
 }
 
-// EntrypointConfig provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) EntrypointConfig(_a0 *gen.EntrypointConfigRequest, _a1 gen.Waypoint_EntrypointConfigServer) error {
+// ListJobQueuePauses provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ListJobQueuePauses(_a0 context.Context, _a1 *emptypb.Empty) (*gen.ListJobQueuePausesResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(*gen.EntrypointConfigRequest, gen.Waypoint_EntrypointConfigServer) error); ok {
+	var r0 *gen.ListJobQueuePausesResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.ListJobQueuePausesResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ListJobQueuePausesResponse)
+		}
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// EntrypointExecStream provides a mock function with given fields: _a0
-func (_m *WaypointServer) EntrypointExecStream(_a0 gen.Waypoint_EntrypointExecStreamServer) error {
-	ret := _m.Called(_a0)
+// ListJobTemplates provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ListJobTemplates(_a0 context.Context, _a1 *emptypb.Empty) (*gen.ListJobTemplatesResponse, error) {
+	ret := _m.Called(_a0, _a1)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(gen.Waypoint_EntrypointExecStreamServer) error); ok {
-		r0 = rf(_a0)
+	var r0 *gen.ListJobTemplatesResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.ListJobTemplatesResponse); ok {
+		r0 = rf(_a0, _a1)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ListJobTemplatesResponse)
+		}
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// EntrypointLogStream provides a mock function with given fields: _a0
-func (_m *WaypointServer) EntrypointLogStream(_a0 gen.Waypoint_EntrypointLogStreamServer) error {
-	ret := _m.Called(_a0)
+// ListJobsStream provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ListJobsStream(_a0 *gen.ListJobsRequest, _a1 gen.Waypoint_ListJobsStreamServer) error {
+	ret := _m.Called(_a0, _a1)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(gen.Waypoint_EntrypointLogStreamServer) error); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(*gen.ListJobsRequest, gen.Waypoint_ListJobsStreamServer) error); ok {
+		r0 = rf(_a0, _a1)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -187,21 +1458,21 @@ func (_m *WaypointServer) EntrypointLogStream(_a0 gen.Waypoint_EntrypointLogStre
 	return r0
 }
 
-// GenerateInviteToken provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) GenerateInviteToken(_a0 context.Context, _a1 *gen.InviteTokenRequest) (*gen.NewTokenResponse, error) {
+// ListProjects provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ListProjects(_a0 context.Context, _a1 *emptypb.Empty) (*gen.ListProjectsResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.NewTokenResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.InviteTokenRequest) *gen.NewTokenResponse); ok {
+	var r0 *gen.ListProjectsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.ListProjectsResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.NewTokenResponse)
+			r0 = ret.Get(0).(*gen.ListProjectsResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.InviteTokenRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -210,21 +1481,21 @@ func (_m *WaypointServer) GenerateInviteToken(_a0 context.Context, _a1 *gen.Invi
 	return r0, r1
 }
 
-// GenerateLoginToken provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) GenerateLoginToken(_a0 context.Context, _a1 *emptypb.Empty) (*gen.NewTokenResponse, error) {
+// ListPushedArtifacts provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ListPushedArtifacts(_a0 context.Context, _a1 *gen.ListPushedArtifactsRequest) (*gen.ListPushedArtifactsResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.NewTokenResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.NewTokenResponse); ok {
+	var r0 *gen.ListPushedArtifactsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListPushedArtifactsRequest) *gen.ListPushedArtifactsResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.NewTokenResponse)
+			r0 = ret.Get(0).(*gen.ListPushedArtifactsResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListPushedArtifactsRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -233,21 +1504,21 @@ func (_m *WaypointServer) GenerateLoginToken(_a0 context.Context, _a1 *emptypb.E
 	return r0, r1
 }
 
-// GetBuild provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) GetBuild(_a0 context.Context, _a1 *gen.GetBuildRequest) (*gen.Build, error) {
+// ListReleases provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ListReleases(_a0 context.Context, _a1 *gen.ListReleasesRequest) (*gen.ListReleasesResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.Build
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetBuildRequest) *gen.Build); ok {
+	var r0 *gen.ListReleasesResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListReleasesRequest) *gen.ListReleasesResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.Build)
+			r0 = ret.Get(0).(*gen.ListReleasesResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetBuildRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListReleasesRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -256,21 +1527,21 @@ func (_m *WaypointServer) GetBuild(_a0 context.Context, _a1 *gen.GetBuildRequest
 	return r0, r1
 }
 
-// GetConfig provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) GetConfig(_a0 context.Context, _a1 *gen.ConfigGetRequest) (*gen.ConfigGetResponse, error) {
+// ListRunnerConfigFiles provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ListRunnerConfigFiles(_a0 context.Context, _a1 *emptypb.Empty) (*gen.ListRunnerConfigFilesResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.ConfigGetResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.ConfigGetRequest) *gen.ConfigGetResponse); ok {
+	var r0 *gen.ListRunnerConfigFilesResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.ListRunnerConfigFilesResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.ConfigGetResponse)
+			r0 = ret.Get(0).(*gen.ListRunnerConfigFilesResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.ConfigGetRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -279,21 +1550,21 @@ func (_m *WaypointServer) GetConfig(_a0 context.Context, _a1 *gen.ConfigGetReque
 	return r0, r1
 }
 
-// GetDeployment provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) GetDeployment(_a0 context.Context, _a1 *gen.GetDeploymentRequest) (*gen.Deployment, error) {
+// ListRunnerInstallations provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ListRunnerInstallations(_a0 context.Context, _a1 *emptypb.Empty) (*gen.ListRunnerInstallationsResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.Deployment
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetDeploymentRequest) *gen.Deployment); ok {
+	var r0 *gen.ListRunnerInstallationsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.ListRunnerInstallationsResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.Deployment)
+			r0 = ret.Get(0).(*gen.ListRunnerInstallationsResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetDeploymentRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -302,21 +1573,21 @@ func (_m *WaypointServer) GetDeployment(_a0 context.Context, _a1 *gen.GetDeploym
 	return r0, r1
 }
 
-// GetJob provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) GetJob(_a0 context.Context, _a1 *gen.GetJobRequest) (*gen.Job, error) {
+// ListRunnerProfiles provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ListRunnerProfiles(_a0 context.Context, _a1 *emptypb.Empty) (*gen.ListRunnerProfilesResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.Job
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetJobRequest) *gen.Job); ok {
+	var r0 *gen.ListRunnerProfilesResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.ListRunnerProfilesResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.Job)
+			r0 = ret.Get(0).(*gen.ListRunnerProfilesResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetJobRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -325,35 +1596,44 @@ func (_m *WaypointServer) GetJob(_a0 context.Context, _a1 *gen.GetJobRequest) (*
 	return r0, r1
 }
 
-// GetJobStream provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) GetJobStream(_a0 *gen.GetJobStreamRequest, _a1 gen.Waypoint_GetJobStreamServer) error {
+// ListRunnerTokens provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ListRunnerTokens(_a0 context.Context, _a1 *emptypb.Empty) (*gen.ListRunnerTokensResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(*gen.GetJobStreamRequest, gen.Waypoint_GetJobStreamServer) error); ok {
+	var r0 *gen.ListRunnerTokensResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.ListRunnerTokensResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.ListRunnerTokensResponse)
+		}
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// GetLatestBuild provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) GetLatestBuild(_a0 context.Context, _a1 *gen.GetLatestBuildRequest) (*gen.Build, error) {
+// ListRunners provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ListRunners(_a0 context.Context, _a1 *gen.ListRunnersRequest) (*gen.ListRunnersResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.Build
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetLatestBuildRequest) *gen.Build); ok {
+	var r0 *gen.ListRunnersResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListRunnersRequest) *gen.ListRunnersResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.Build)
+			r0 = ret.Get(0).(*gen.ListRunnersResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetLatestBuildRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListRunnersRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -362,21 +1642,21 @@ func (_m *WaypointServer) GetLatestBuild(_a0 context.Context, _a1 *gen.GetLatest
 	return r0, r1
 }
 
-// GetLatestPushedArtifact provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) GetLatestPushedArtifact(_a0 context.Context, _a1 *gen.GetLatestPushedArtifactRequest) (*gen.PushedArtifact, error) {
+// ListSnapshots provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ListSnapshots(_a0 context.Context, _a1 *emptypb.Empty) (*gen.ListSnapshotsResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.PushedArtifact
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetLatestPushedArtifactRequest) *gen.PushedArtifact); ok {
+	var r0 *gen.ListSnapshotsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.ListSnapshotsResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.PushedArtifact)
+			r0 = ret.Get(0).(*gen.ListSnapshotsResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetLatestPushedArtifactRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -385,21 +1665,21 @@ func (_m *WaypointServer) GetLatestPushedArtifact(_a0 context.Context, _a1 *gen.
 	return r0, r1
 }
 
-// GetLatestRelease provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) GetLatestRelease(_a0 context.Context, _a1 *gen.GetLatestReleaseRequest) (*gen.Release, error) {
+// ListWorkspaces provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ListWorkspaces(_a0 context.Context, _a1 *emptypb.Empty) (*gen.ListWorkspacesResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.Release
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetLatestReleaseRequest) *gen.Release); ok {
+	var r0 *gen.ListWorkspacesResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.ListWorkspacesResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.Release)
+			r0 = ret.Get(0).(*gen.ListWorkspacesResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetLatestReleaseRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -408,35 +1688,44 @@ func (_m *WaypointServer) GetLatestRelease(_a0 context.Context, _a1 *gen.GetLate
 	return r0, r1
 }
 
-// GetLogStream provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) GetLogStream(_a0 *gen.GetLogStreamRequest, _a1 gen.Waypoint_GetLogStreamServer) error {
+// PauseJobQueue provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) PauseJobQueue(_a0 context.Context, _a1 *gen.JobQueuePause) (*emptypb.Empty, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(*gen.GetLogStreamRequest, gen.Waypoint_GetLogStreamServer) error); ok {
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.JobQueuePause) *emptypb.Empty); ok {
 		r0 = rf(_a0, _a1)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*emptypb.Empty)
+		}
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.JobQueuePause) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// GetProject provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) GetProject(_a0 context.Context, _a1 *gen.GetProjectRequest) (*gen.GetProjectResponse, error) {
+// QueueJob provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) QueueJob(_a0 context.Context, _a1 *gen.QueueJobRequest) (*gen.QueueJobResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.GetProjectResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetProjectRequest) *gen.GetProjectResponse); ok {
+	var r0 *gen.QueueJobResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.QueueJobRequest) *gen.QueueJobResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.GetProjectResponse)
+			r0 = ret.Get(0).(*gen.QueueJobResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetProjectRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.QueueJobRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -445,21 +1734,35 @@ func (_m *WaypointServer) GetProject(_a0 context.Context, _a1 *gen.GetProjectReq
 	return r0, r1
 }
 
-// GetPushedArtifact provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) GetPushedArtifact(_a0 context.Context, _a1 *gen.GetPushedArtifactRequest) (*gen.PushedArtifact, error) {
+// RestoreSnapshot provides a mock function with given fields: _a0
+func (_m *WaypointServer) RestoreSnapshot(_a0 gen.Waypoint_RestoreSnapshotServer) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(gen.Waypoint_RestoreSnapshotServer) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResumeJobQueue provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) ResumeJobQueue(_a0 context.Context, _a1 *gen.JobQueuePause) (*emptypb.Empty, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.PushedArtifact
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetPushedArtifactRequest) *gen.PushedArtifact); ok {
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.JobQueuePause) *emptypb.Empty); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.PushedArtifact)
+			r0 = ret.Get(0).(*emptypb.Empty)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetPushedArtifactRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.JobQueuePause) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -468,21 +1771,21 @@ func (_m *WaypointServer) GetPushedArtifact(_a0 context.Context, _a1 *gen.GetPus
 	return r0, r1
 }
 
-// GetRelease provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) GetRelease(_a0 context.Context, _a1 *gen.GetReleaseRequest) (*gen.Release, error) {
+// RevokeRunnerToken provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) RevokeRunnerToken(_a0 context.Context, _a1 *gen.RevokeRunnerTokenRequest) (*emptypb.Empty, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.Release
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetReleaseRequest) *gen.Release); ok {
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.RevokeRunnerTokenRequest) *emptypb.Empty); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.Release)
+			r0 = ret.Get(0).(*emptypb.Empty)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetReleaseRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.RevokeRunnerTokenRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -491,21 +1794,21 @@ func (_m *WaypointServer) GetRelease(_a0 context.Context, _a1 *gen.GetReleaseReq
 	return r0, r1
 }
 
-// GetRunner provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) GetRunner(_a0 context.Context, _a1 *gen.GetRunnerRequest) (*gen.Runner, error) {
+// RotateAPIKey provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) RotateAPIKey(_a0 context.Context, _a1 *gen.RotateAPIKeyRequest) (*gen.APIKeyTokenResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.Runner
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetRunnerRequest) *gen.Runner); ok {
+	var r0 *gen.APIKeyTokenResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.RotateAPIKeyRequest) *gen.APIKeyTokenResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.Runner)
+			r0 = ret.Get(0).(*gen.APIKeyTokenResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetRunnerRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.RotateAPIKeyRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -514,21 +1817,21 @@ func (_m *WaypointServer) GetRunner(_a0 context.Context, _a1 *gen.GetRunnerReque
 	return r0, r1
 }
 
-// GetServerConfig provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) GetServerConfig(_a0 context.Context, _a1 *emptypb.Empty) (*gen.GetServerConfigResponse, error) {
+// RotateRunnerToken provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) RotateRunnerToken(_a0 context.Context, _a1 *gen.RotateRunnerTokenRequest) (*gen.RunnerTokenResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.GetServerConfigResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.GetServerConfigResponse); ok {
+	var r0 *gen.RunnerTokenResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.RotateRunnerTokenRequest) *gen.RunnerTokenResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.GetServerConfigResponse)
+			r0 = ret.Get(0).(*gen.RunnerTokenResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.RotateRunnerTokenRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -537,44 +1840,35 @@ func (_m *WaypointServer) GetServerConfig(_a0 context.Context, _a1 *emptypb.Empt
 	return r0, r1
 }
 
-// GetVersionInfo provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) GetVersionInfo(_a0 context.Context, _a1 *emptypb.Empty) (*gen.GetVersionInfoResponse, error) {
-	ret := _m.Called(_a0, _a1)
-
-	var r0 *gen.GetVersionInfoResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.GetVersionInfoResponse); ok {
-		r0 = rf(_a0, _a1)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.GetVersionInfoResponse)
-		}
-	}
+// RunnerConfig provides a mock function with given fields: _a0
+func (_m *WaypointServer) RunnerConfig(_a0 gen.Waypoint_RunnerConfigServer) error {
+	ret := _m.Called(_a0)
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
-		r1 = rf(_a0, _a1)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(gen.Waypoint_RunnerConfigServer) error); ok {
+		r0 = rf(_a0)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// GetWorkspace provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) GetWorkspace(_a0 context.Context, _a1 *gen.GetWorkspaceRequest) (*gen.GetWorkspaceResponse, error) {
+// RunnerGetDeploymentConfig provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) RunnerGetDeploymentConfig(_a0 context.Context, _a1 *gen.RunnerGetDeploymentConfigRequest) (*gen.RunnerGetDeploymentConfigResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.GetWorkspaceResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.GetWorkspaceRequest) *gen.GetWorkspaceResponse); ok {
+	var r0 *gen.RunnerGetDeploymentConfigResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.RunnerGetDeploymentConfigRequest) *gen.RunnerGetDeploymentConfigResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.GetWorkspaceResponse)
+			r0 = ret.Get(0).(*gen.RunnerGetDeploymentConfigResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.GetWorkspaceRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.RunnerGetDeploymentConfigRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -583,21 +1877,35 @@ func (_m *WaypointServer) GetWorkspace(_a0 context.Context, _a1 *gen.GetWorkspac
 	return r0, r1
 }
 
-// ListBuilds provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) ListBuilds(_a0 context.Context, _a1 *gen.ListBuildsRequest) (*gen.ListBuildsResponse, error) {
+// RunnerJobStream provides a mock function with given fields: _a0
+func (_m *WaypointServer) RunnerJobStream(_a0 gen.Waypoint_RunnerJobStreamServer) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(gen.Waypoint_RunnerJobStreamServer) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RunnerLogin provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) RunnerLogin(_a0 context.Context, _a1 *gen.RunnerLoginRequest) (*gen.RunnerLoginResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.ListBuildsResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListBuildsRequest) *gen.ListBuildsResponse); ok {
+	var r0 *gen.RunnerLoginResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.RunnerLoginRequest) *gen.RunnerLoginResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.ListBuildsResponse)
+			r0 = ret.Get(0).(*gen.RunnerLoginResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListBuildsRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.RunnerLoginRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -606,21 +1914,21 @@ func (_m *WaypointServer) ListBuilds(_a0 context.Context, _a1 *gen.ListBuildsReq
 	return r0, r1
 }
 
-// ListDeployments provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) ListDeployments(_a0 context.Context, _a1 *gen.ListDeploymentsRequest) (*gen.ListDeploymentsResponse, error) {
+// SearchJobLogs provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) SearchJobLogs(_a0 context.Context, _a1 *gen.SearchJobLogsRequest) (*gen.SearchJobLogsResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.ListDeploymentsResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListDeploymentsRequest) *gen.ListDeploymentsResponse); ok {
+	var r0 *gen.SearchJobLogsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.SearchJobLogsRequest) *gen.SearchJobLogsResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.ListDeploymentsResponse)
+			r0 = ret.Get(0).(*gen.SearchJobLogsResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListDeploymentsRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.SearchJobLogsRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -629,21 +1937,21 @@ func (_m *WaypointServer) ListDeployments(_a0 context.Context, _a1 *gen.ListDepl
 	return r0, r1
 }
 
-// ListHostnames provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) ListHostnames(_a0 context.Context, _a1 *gen.ListHostnamesRequest) (*gen.ListHostnamesResponse, error) {
+// SetConfig provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) SetConfig(_a0 context.Context, _a1 *gen.ConfigSetRequest) (*gen.ConfigSetResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.ListHostnamesResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListHostnamesRequest) *gen.ListHostnamesResponse); ok {
+	var r0 *gen.ConfigSetResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.ConfigSetRequest) *gen.ConfigSetResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.ListHostnamesResponse)
+			r0 = ret.Get(0).(*gen.ConfigSetResponse)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListHostnamesRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.ConfigSetRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -652,21 +1960,21 @@ func (_m *WaypointServer) ListHostnames(_a0 context.Context, _a1 *gen.ListHostna
 	return r0, r1
 }
 
-// ListInstances provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) ListInstances(_a0 context.Context, _a1 *gen.ListInstancesRequest) (*gen.ListInstancesResponse, error) {
+// SetJobConflicts provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) SetJobConflicts(_a0 context.Context, _a1 *gen.JobConflicts) (*emptypb.Empty, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.ListInstancesResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListInstancesRequest) *gen.ListInstancesResponse); ok {
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.JobConflicts) *emptypb.Empty); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.ListInstancesResponse)
+			r0 = ret.Get(0).(*emptypb.Empty)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListInstancesRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.JobConflicts) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -675,21 +1983,21 @@ func (_m *WaypointServer) ListInstances(_a0 context.Context, _a1 *gen.ListInstan
 	return r0, r1
 }
 
-// ListProjects provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) ListProjects(_a0 context.Context, _a1 *emptypb.Empty) (*gen.ListProjectsResponse, error) {
+// SetJobTemplate provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) SetJobTemplate(_a0 context.Context, _a1 *gen.SetJobTemplateRequest) (*gen.JobTemplate, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.ListProjectsResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.ListProjectsResponse); ok {
+	var r0 *gen.JobTemplate
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.SetJobTemplateRequest) *gen.JobTemplate); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.ListProjectsResponse)
+			r0 = ret.Get(0).(*gen.JobTemplate)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.SetJobTemplateRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -698,21 +2006,21 @@ func (_m *WaypointServer) ListProjects(_a0 context.Context, _a1 *emptypb.Empty)
 	return r0, r1
 }
 
-// ListPushedArtifacts provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) ListPushedArtifacts(_a0 context.Context, _a1 *gen.ListPushedArtifactsRequest) (*gen.ListPushedArtifactsResponse, error) {
+// SetRunnerConfigFile provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) SetRunnerConfigFile(_a0 context.Context, _a1 *gen.SetRunnerConfigFileRequest) (*gen.RunnerConfigFile, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.ListPushedArtifactsResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListPushedArtifactsRequest) *gen.ListPushedArtifactsResponse); ok {
+	var r0 *gen.RunnerConfigFile
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.SetRunnerConfigFileRequest) *gen.RunnerConfigFile); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.ListPushedArtifactsResponse)
+			r0 = ret.Get(0).(*gen.RunnerConfigFile)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListPushedArtifactsRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.SetRunnerConfigFileRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -721,21 +2029,21 @@ func (_m *WaypointServer) ListPushedArtifacts(_a0 context.Context, _a1 *gen.List
 	return r0, r1
 }
 
-// ListReleases provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) ListReleases(_a0 context.Context, _a1 *gen.ListReleasesRequest) (*gen.ListReleasesResponse, error) {
+// SetRunnerInstallation provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) SetRunnerInstallation(_a0 context.Context, _a1 *gen.SetRunnerInstallationRequest) (*gen.RunnerInstallation, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.ListReleasesResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.ListReleasesRequest) *gen.ListReleasesResponse); ok {
+	var r0 *gen.RunnerInstallation
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.SetRunnerInstallationRequest) *gen.RunnerInstallation); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.ListReleasesResponse)
+			r0 = ret.Get(0).(*gen.RunnerInstallation)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.ListReleasesRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.SetRunnerInstallationRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -744,21 +2052,21 @@ func (_m *WaypointServer) ListReleases(_a0 context.Context, _a1 *gen.ListRelease
 	return r0, r1
 }
 
-// ListWorkspaces provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) ListWorkspaces(_a0 context.Context, _a1 *emptypb.Empty) (*gen.ListWorkspacesResponse, error) {
+// SetRunnerProfile provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) SetRunnerProfile(_a0 context.Context, _a1 *gen.SetRunnerProfileRequest) (*gen.RunnerProfile, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.ListWorkspacesResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *emptypb.Empty) *gen.ListWorkspacesResponse); ok {
+	var r0 *gen.RunnerProfile
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.SetRunnerProfileRequest) *gen.RunnerProfile); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.ListWorkspacesResponse)
+			r0 = ret.Get(0).(*gen.RunnerProfile)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *emptypb.Empty) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.SetRunnerProfileRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -767,21 +2075,21 @@ func (_m *WaypointServer) ListWorkspaces(_a0 context.Context, _a1 *emptypb.Empty
 	return r0, r1
 }
 
-// QueueJob provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) QueueJob(_a0 context.Context, _a1 *gen.QueueJobRequest) (*gen.QueueJobResponse, error) {
+// SetRunnerUpdateState provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) SetRunnerUpdateState(_a0 context.Context, _a1 *gen.SetRunnerUpdateStateRequest) (*emptypb.Empty, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.QueueJobResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.QueueJobRequest) *gen.QueueJobResponse); ok {
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.SetRunnerUpdateStateRequest) *emptypb.Empty); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.QueueJobResponse)
+			r0 = ret.Get(0).(*emptypb.Empty)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.QueueJobRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.SetRunnerUpdateStateRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -790,49 +2098,21 @@ func (_m *WaypointServer) QueueJob(_a0 context.Context, _a1 *gen.QueueJobRequest
 	return r0, r1
 }
 
-// RestoreSnapshot provides a mock function with given fields: _a0
-func (_m *WaypointServer) RestoreSnapshot(_a0 gen.Waypoint_RestoreSnapshotServer) error {
-	ret := _m.Called(_a0)
-
-	var r0 error
-	if rf, ok := ret.Get(0).(func(gen.Waypoint_RestoreSnapshotServer) error); ok {
-		r0 = rf(_a0)
-	} else {
-		r0 = ret.Error(0)
-	}
-
-	return r0
-}
-
-// RunnerConfig provides a mock function with given fields: _a0
-func (_m *WaypointServer) RunnerConfig(_a0 gen.Waypoint_RunnerConfigServer) error {
-	ret := _m.Called(_a0)
-
-	var r0 error
-	if rf, ok := ret.Get(0).(func(gen.Waypoint_RunnerConfigServer) error); ok {
-		r0 = rf(_a0)
-	} else {
-		r0 = ret.Error(0)
-	}
-
-	return r0
-}
-
-// RunnerGetDeploymentConfig provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) RunnerGetDeploymentConfig(_a0 context.Context, _a1 *gen.RunnerGetDeploymentConfigRequest) (*gen.RunnerGetDeploymentConfigResponse, error) {
+// SetRunnerVersion provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) SetRunnerVersion(_a0 context.Context, _a1 *gen.SetRunnerVersionRequest) (*emptypb.Empty, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.RunnerGetDeploymentConfigResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.RunnerGetDeploymentConfigRequest) *gen.RunnerGetDeploymentConfigResponse); ok {
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.SetRunnerVersionRequest) *emptypb.Empty); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.RunnerGetDeploymentConfigResponse)
+			r0 = ret.Get(0).(*emptypb.Empty)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.RunnerGetDeploymentConfigRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.SetRunnerVersionRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -841,35 +2121,21 @@ func (_m *WaypointServer) RunnerGetDeploymentConfig(_a0 context.Context, _a1 *ge
 	return r0, r1
 }
 
-// RunnerJobStream provides a mock function with given fields: _a0
-func (_m *WaypointServer) RunnerJobStream(_a0 gen.Waypoint_RunnerJobStreamServer) error {
-	ret := _m.Called(_a0)
-
-	var r0 error
-	if rf, ok := ret.Get(0).(func(gen.Waypoint_RunnerJobStreamServer) error); ok {
-		r0 = rf(_a0)
-	} else {
-		r0 = ret.Error(0)
-	}
-
-	return r0
-}
-
-// SetConfig provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) SetConfig(_a0 context.Context, _a1 *gen.ConfigSetRequest) (*gen.ConfigSetResponse, error) {
+// SetServerConfig provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) SetServerConfig(_a0 context.Context, _a1 *gen.SetServerConfigRequest) (*emptypb.Empty, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *gen.ConfigSetResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.ConfigSetRequest) *gen.ConfigSetResponse); ok {
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.SetServerConfigRequest) *emptypb.Empty); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*gen.ConfigSetResponse)
+			r0 = ret.Get(0).(*emptypb.Empty)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.ConfigSetRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.SetServerConfigRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -878,21 +2144,21 @@ func (_m *WaypointServer) SetConfig(_a0 context.Context, _a1 *gen.ConfigSetReque
 	return r0, r1
 }
 
-// SetServerConfig provides a mock function with given fields: _a0, _a1
-func (_m *WaypointServer) SetServerConfig(_a0 context.Context, _a1 *gen.SetServerConfigRequest) (*emptypb.Empty, error) {
+// SetWorkspacePolicy provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) SetWorkspacePolicy(_a0 context.Context, _a1 *gen.SetWorkspacePolicyRequest) (*gen.WorkspacePolicy, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 *emptypb.Empty
-	if rf, ok := ret.Get(0).(func(context.Context, *gen.SetServerConfigRequest) *emptypb.Empty); ok {
+	var r0 *gen.WorkspacePolicy
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.SetWorkspacePolicyRequest) *gen.WorkspacePolicy); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*emptypb.Empty)
+			r0 = ret.Get(0).(*gen.WorkspacePolicy)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *gen.SetServerConfigRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.SetWorkspacePolicyRequest) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -915,6 +2181,29 @@ func (_m *WaypointServer) StartExecStream(_a0 gen.Waypoint_StartExecStreamServer
 	return r0
 }
 
+// UndrainRunner provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) UndrainRunner(_a0 context.Context, _a1 *gen.DrainRunnerRequest) (*gen.Runner, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.Runner
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.DrainRunnerRequest) *gen.Runner); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.Runner)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.DrainRunnerRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpsertApplication provides a mock function with given fields: _a0, _a1
 func (_m *WaypointServer) UpsertApplication(_a0 context.Context, _a1 *gen.UpsertApplicationRequest) (*gen.UpsertApplicationResponse, error) {
 	ret := _m.Called(_a0, _a1)
@@ -1076,6 +2365,43 @@ func (_m *WaypointServer) ValidateJob(_a0 context.Context, _a1 *gen.ValidateJobR
 	return r0, r1
 }
 
+// VerifyState provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) VerifyState(_a0 context.Context, _a1 *gen.VerifyStateRequest) (*gen.VerifyStateResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *gen.VerifyStateResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *gen.VerifyStateRequest) *gen.VerifyStateResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gen.VerifyStateResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *gen.VerifyStateRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WatchJobs provides a mock function with given fields: _a0, _a1
+func (_m *WaypointServer) WatchJobs(_a0 *gen.WatchJobsRequest, _a1 gen.Waypoint_WatchJobsServer) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*gen.WatchJobsRequest, gen.Waypoint_WatchJobsServer) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // XListJobs provides a mock function with given fields: _a0, _a1
 func (_m *WaypointServer) XListJobs(_a0 context.Context, _a1 *gen.ListJobsRequest) (*gen.ListJobsResponse, error) {
 	ret := _m.Called(_a0, _a1)
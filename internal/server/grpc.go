@@ -39,6 +39,15 @@ func grpcInit(group *run.Group, opts *options) error {
 		),
 	)
 
+	// Audit before authenticating so that failed authentication is audited.
+	if opts.AuditExporter != nil {
+		ider, _ := opts.Service.(AuditIdentifier)
+		so = append(so,
+			grpc.ChainUnaryInterceptor(auditUnaryInterceptor(opts.AuditExporter, ider)),
+			grpc.ChainStreamInterceptor(auditStreamInterceptor(opts.AuditExporter, ider)),
+		)
+	}
+
 	if opts.AuthChecker != nil {
 		so = append(so,
 			grpc.ChainUnaryInterceptor(authUnaryInterceptor(opts.AuthChecker)),
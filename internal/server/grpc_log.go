@@ -4,8 +4,11 @@ import (
 	"context"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hashicorp/go-hclog"
 	"google.golang.org/grpc"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
 )
 
 // logUnaryInterceptor returns a gRPC unary interceptor that inserts a hclog.Logger
@@ -26,7 +29,7 @@ func logUnaryInterceptor(logger hclog.Logger, verbose bool) grpc.UnaryServerInte
 			var reqLogArgs []interface{}
 			// Log the request's attributes only if verbose is set to true.
 			if verbose {
-				reqLogArgs = append(reqLogArgs, "request", req)
+				reqLogArgs = append(reqLogArgs, "request", logRedact(req))
 			}
 			logger.Info(info.FullMethod+" request", reqLogArgs...)
 		}
@@ -84,6 +87,24 @@ func logStreamInterceptor(logger hclog.Logger, verbose bool) grpc.StreamServerIn
 	}
 }
 
+// logRedact returns a value that is safe to log in place of v. This
+// removes any sensitive values such as sensitive config variables.
+func logRedact(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *pb.ConfigSetRequest:
+		v = proto.Clone(v).(*pb.ConfigSetRequest)
+		for _, cv := range v.Variables {
+			if cv.Sensitive && cv.Value != "" {
+				cv.Value = "<sensitive>"
+			}
+		}
+
+		return v
+	}
+
+	return v
+}
+
 type logStream struct {
 	grpc.ServerStream
 	context context.Context
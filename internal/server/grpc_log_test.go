@@ -8,6 +8,8 @@ import (
 	"github.com/hashicorp/go-hclog"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
 )
 
 func TestLogUnaryInterceptor(t *testing.T) {
@@ -50,3 +52,34 @@ func TestLogUnaryInterceptor(t *testing.T) {
 	require.Equal("hello", resp)
 	require.NoError(err)
 }
+
+func TestLogUnaryInterceptor_redact(t *testing.T) {
+	require := require.New(t)
+
+	var buf bytes.Buffer
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:   "test",
+		Level:  hclog.Debug,
+		Output: &buf,
+	})
+
+	req := &pb.ConfigSetRequest{
+		Variables: []*pb.ConfigVar{
+			{Name: "public", Value: "visible"},
+			{Name: "secret", Value: "hunter2", Sensitive: true},
+		},
+	}
+
+	f := logUnaryInterceptor(logger, true)
+	_, err := f(context.Background(), req, &grpc.UnaryServerInfo{},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, nil
+		},
+	)
+	require.NoError(err)
+	require.Contains(buf.String(), "visible")
+	require.NotContains(buf.String(), "hunter2")
+
+	// The original request must not be modified
+	require.Equal("hunter2", req.Variables[1].Value)
+}
@@ -36,11 +36,27 @@ func httpInit(group *run.Group, opts *options) error {
 		Fallback:  "index.html",
 	})
 
+	var metrics http.Handler
+	if opts.MetricsEnabled {
+		if mw, ok := opts.Service.(MetricsWriter); ok {
+			metrics = metricsHandler(mw, opts.AuthChecker, log)
+		} else {
+			log.Warn("service doesn't support metrics, /metrics is disabled")
+		}
+	}
+
+	// The admin job view is part of the browser UI.
+	adminJobs := adminJobHandler(opts.Service, opts.AuthChecker, log)
+
 	// If the path has a grpc prefix we assume it's a GRPC gateway request,
 	// otherwise fall back to serving the UI from the filesystem
 	rootHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if strings.HasPrefix(r.URL.Path, "/grpc") {
+		if metrics != nil && r.URL.Path == "/metrics" {
+			metrics.ServeHTTP(w, r)
+		} else if strings.HasPrefix(r.URL.Path, "/grpc") {
 			grpcWrapped.ServeHTTP(w, r)
+		} else if opts.BrowserUIEnabled && strings.HasPrefix(r.URL.Path, adminJobPrefix) {
+			adminJobs.ServeHTTP(w, r)
 		} else if opts.BrowserUIEnabled {
 			uifs.ServeHTTP(w, r)
 		}
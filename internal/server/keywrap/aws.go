@@ -0,0 +1,57 @@
+package keywrap
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+)
+
+// AWSKMS wraps keys using AWS KMS. Credentials and the region are read
+// from the standard AWS environment and configuration files.
+type AWSKMS struct {
+	client *kms.KMS
+	keyId  string
+}
+
+// NewAWSKMS returns a Wrapper that uses the given AWS KMS key ID, ARN,
+// or alias.
+func NewAWSKMS(keyId string) (*AWSKMS, error) {
+	sess, err := session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &AWSKMS{client: kms.New(sess), keyId: keyId}, nil
+}
+
+// Wrap implements Wrapper
+func (w *AWSKMS) Wrap(ctx context.Context, key []byte) ([]byte, error) {
+	out, err := w.client.EncryptWithContext(ctx, &kms.EncryptInput{
+		KeyId:     aws.String(w.keyId),
+		Plaintext: key,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.CiphertextBlob, nil
+}
+
+// Unwrap implements Wrapper
+func (w *AWSKMS) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	out, err := w.client.DecryptWithContext(ctx, &kms.DecryptInput{
+		KeyId:          aws.String(w.keyId),
+		CiphertextBlob: wrapped,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.Plaintext, nil
+}
+
+var _ Wrapper = (*AWSKMS)(nil)
@@ -0,0 +1,20 @@
+// Package keywrap contains implementations for wrapping (encrypting) the
+// data encryption keys used by the server with an external key management
+// service. The server never stores its data keys in plaintext, so the
+// data can't be read from a copy of the database without access to the
+// key management service.
+package keywrap
+
+import (
+	"context"
+)
+
+// Wrapper wraps and unwraps data encryption keys.
+type Wrapper interface {
+	// Wrap encrypts the given key. The result is opaque and can only be
+	// decrypted with Unwrap of the same wrapper configuration.
+	Wrap(ctx context.Context, key []byte) ([]byte, error)
+
+	// Unwrap decrypts a key previously encrypted with Wrap.
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
@@ -0,0 +1,31 @@
+package keywrap
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/mitchellh/go-testing-interface"
+)
+
+// TestWrapper returns a Wrapper for tests. This does not provide any
+// security and must never be used outside of tests.
+func TestWrapper(t testing.T) Wrapper {
+	return testWrapper{}
+}
+
+var testWrapperPrefix = []byte("test:")
+
+type testWrapper struct{}
+
+func (testWrapper) Wrap(ctx context.Context, key []byte) ([]byte, error) {
+	return append(append([]byte{}, testWrapperPrefix...), key...), nil
+}
+
+func (testWrapper) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	if !bytes.HasPrefix(wrapped, testWrapperPrefix) {
+		return nil, errors.New("key was not wrapped with the test wrapper")
+	}
+
+	return wrapped[len(testWrapperPrefix):], nil
+}
@@ -0,0 +1,113 @@
+package keywrap
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// VaultTransit wraps keys using the Vault transit secrets engine.
+type VaultTransit struct {
+	// Address is the address of the Vault server, such as
+	// "https://vault.example.com:8200".
+	Address string
+
+	// Token is the Vault token to authenticate with. This token must be
+	// able to use the encrypt and decrypt endpoints for Key.
+	Token string
+
+	// Mount is the path the transit engine is mounted at. This defaults
+	// to "transit".
+	Mount string
+
+	// Key is the name of the transit key.
+	Key string
+
+	// Client is the HTTP client to use. This defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Wrap implements Wrapper
+func (w *VaultTransit) Wrap(ctx context.Context, key []byte) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	err := w.do(ctx, "encrypt", map[string]string{
+		"plaintext": base64.StdEncoding.EncodeToString(key),
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(resp.Data.Ciphertext), nil
+}
+
+// Unwrap implements Wrapper
+func (w *VaultTransit) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	err := w.do(ctx, "decrypt", map[string]string{
+		"ciphertext": string(wrapped),
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+}
+
+func (w *VaultTransit) do(ctx context.Context, op string, body, result interface{}) error {
+	mount := w.Mount
+	if mount == "" {
+		mount = "transit"
+	}
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	bs, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/v1/%s/%s/%s",
+		strings.TrimRight(w.Address, "/"), strings.Trim(mount, "/"), op, w.Key)
+	req, err := http.NewRequest("POST", url, bytes.NewReader(bs))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("X-Vault-Token", w.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	bs, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("vault transit %s failed with status %d: %s",
+			op, resp.StatusCode, strings.TrimSpace(string(bs)))
+	}
+
+	return json.Unmarshal(bs, result)
+}
+
+var _ Wrapper = (*VaultTransit)(nil)
@@ -0,0 +1,60 @@
+package keywrap
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultTransit(t *testing.T) {
+	require := require.New(t)
+
+	// Fake transit engine that "encrypts" by prefixing the base64 value.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var data map[string]string
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/waypoint":
+			data = map[string]string{"ciphertext": "vault:v1:" + body["plaintext"]}
+		case "/v1/transit/decrypt/waypoint":
+			data = map[string]string{"plaintext": body["ciphertext"][len("vault:v1:"):]}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	w := &VaultTransit{Address: srv.URL, Token: "secret", Key: "waypoint"}
+
+	wrapped, err := w.Wrap(ctx, []byte("hello"))
+	require.NoError(err)
+	require.NotEqual("hello", string(wrapped))
+
+	key, err := w.Unwrap(ctx, wrapped)
+	require.NoError(err)
+	require.Equal("hello", string(key))
+
+	// Bad token
+	w.Token = "nope"
+	_, err = w.Wrap(ctx, []byte("hello"))
+	require.Error(err)
+	require.Contains(err.Error(), "403")
+}
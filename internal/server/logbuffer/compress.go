@@ -0,0 +1,92 @@
+package logbuffer
+
+import (
+	"github.com/golang/snappy"
+)
+
+// Codec encodes entries to bytes and decodes them back so that a Buffer
+// can store its entries compressed. See Buffer.Compress.
+type Codec interface {
+	Encode(Entry) ([]byte, error)
+	Decode([]byte) (Entry, error)
+}
+
+// compressedEntry is an entry that a Buffer stores encoded with its codec
+// and compressed with snappy.
+type compressedEntry []byte
+
+// Compress sets the codec used to store the entries written to the buffer
+// from now on compressed with snappy. Readers decompress the entries
+// transparently, so they read the same entries that were written.
+// Entries that the codec can't encode are stored as they are.
+//
+// This should be called before the buffer is read.
+func (b *Buffer) Compress(c Codec) {
+	b.cond.L.Lock()
+	defer b.cond.L.Unlock()
+	b.codec = c
+}
+
+// compress returns the entries to store for the written entries. This
+// must be called with the lock held.
+func (b *Buffer) compress(entries []Entry) []Entry {
+	if b.codec == nil {
+		return entries
+	}
+
+	result := make([]Entry, len(entries))
+	for i, e := range entries {
+		result[i] = e
+		if e == nil {
+			continue
+		}
+
+		data, err := b.codec.Encode(e)
+		if err != nil {
+			continue
+		}
+
+		result[i] = compressedEntry(snappy.Encode(nil, data))
+	}
+
+	return result
+}
+
+// decompress returns the entries that were written for the stored
+// entries. Entries that can't be decompressed are skipped, which can
+// only happen if the codec can't decode what it encoded.
+func (b *Buffer) decompress(entries []Entry) []Entry {
+	// Most reads of uncompressed buffers don't need a copy.
+	found := false
+	for _, e := range entries {
+		if _, ok := e.(compressedEntry); ok {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return entries
+	}
+
+	result := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		c, ok := e.(compressedEntry)
+		if !ok {
+			result = append(result, e)
+			continue
+		}
+
+		data, err := snappy.Decode(nil, c)
+		if err != nil {
+			continue
+		}
+		e, err := b.codec.Decode(data)
+		if err != nil {
+			continue
+		}
+
+		result = append(result, e)
+	}
+
+	return result
+}
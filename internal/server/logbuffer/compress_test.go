@@ -0,0 +1,46 @@
+package logbuffer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+)
+
+type testCodec struct{}
+
+func (testCodec) Encode(e Entry) ([]byte, error) {
+	return proto.Marshal(e.(*TestEntry))
+}
+
+func (testCodec) Decode(data []byte) (Entry, error) {
+	var result TestEntry
+	return &result, proto.Unmarshal(data, &result)
+}
+
+func TestBuffer_compress(t *testing.T) {
+	require := require.New(t)
+
+	line := strings.Repeat("building layer ", 64)
+
+	plain := New()
+	defer plain.Close()
+	plain.Write(&TestEntry{Line: line})
+
+	b := New()
+	defer b.Close()
+	b.Compress(testCodec{})
+	r := b.Reader(-1)
+	b.Write(&TestEntry{Line: line}, nil, &TestEntry{Line: "done"})
+
+	// The entries are stored compressed
+	require.True(b.Size() < plain.Size())
+
+	// Readers get the entries that were written
+	v := r.Read(10, false)
+	require.Len(v, 3)
+	require.Equal(line, v[0].(*TestEntry).Line)
+	require.Nil(v[1])
+	require.Equal("done", v[2].(*TestEntry).Line)
+}
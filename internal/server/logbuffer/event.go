@@ -0,0 +1,118 @@
+package logbuffer
+
+import (
+	"time"
+)
+
+// Level is the severity of an Event.
+type Level int
+
+const (
+	LevelUnknown Level = iota
+	LevelTrace
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// Stream is the output stream an Event was written to.
+type Stream int
+
+const (
+	StreamUnknown Stream = iota
+	StreamStdout
+	StreamStderr
+)
+
+// EventType is the kind of output an Event is. Raw output written by a
+// process is EventRaw, and the other types are terminal UI elements that
+// readers can render richly rather than as text.
+type EventType int
+
+const (
+	EventUnknown EventType = iota
+	EventRaw
+	EventLine
+	EventStatus
+	EventNamedValues
+	EventTable
+	EventStepGroup
+	EventStep
+)
+
+// Event is a structured log entry. A Buffer can hold entries of any type,
+// but writing Events lets readers group the output by step, filter it by
+// level, and tell raw output from terminal UI elements without
+// understanding the data that was logged.
+type Event struct {
+	// Timestamp is when the event was logged, as seen by the writer.
+	Timestamp time.Time
+
+	// ReceiveTime is when the event was received, if it was logged
+	// remotely. Unlike Timestamp, this doesn't depend on the clock of the
+	// writer.
+	ReceiveTime time.Time
+
+	// Type is the kind of output of the event, if known.
+	Type EventType
+
+	// Stream is the output stream the event was written to, if known.
+	Stream Stream
+
+	// Step is the ID of the step the event is part of, if any.
+	Step string
+
+	// Level is the severity of the event. Events with an unknown level
+	// are treated as LevelInfo when filtering.
+	Level Level
+
+	// Message is the text of the event. For events that aren't text,
+	// such as tables, this is a plain text rendering of them.
+	Message string
+
+	// Data is the original entry that the event describes, such as a
+	// terminal event, so that readers can still render it in full.
+	Data Entry
+}
+
+// LevelFilter returns a filter for ReadFilter that only keeps Events at
+// the given level or more severe. Entries that aren't Events are kept.
+func LevelFilter(min Level) func(Entry) bool {
+	return func(e Entry) bool {
+		ev, ok := e.(*Event)
+		if !ok {
+			return true
+		}
+
+		level := ev.Level
+		if level == LevelUnknown {
+			level = LevelInfo
+		}
+
+		return level >= min
+	}
+}
+
+// ReadFilter is like Read but only returns the entries that f returns
+// true for. Entries that are filtered out still count toward max, but
+// ReadFilter keeps reading until at least one entry is kept so that it
+// only returns nil when Read would.
+func (r *Reader) ReadFilter(max int, block bool, f func(Entry) bool) []Entry {
+	for {
+		entries := r.Read(max, block)
+		if entries == nil {
+			return nil
+		}
+
+		var result []Entry
+		for _, e := range entries {
+			if f(e) {
+				result = append(result, e)
+			}
+		}
+		if len(result) > 0 {
+			return result
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package logbuffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReader_readFilter(t *testing.T) {
+	require := require.New(t)
+
+	b := New()
+	defer b.Close()
+
+	r := b.Reader(-1)
+	b.Write(
+		&Event{Level: LevelDebug, Message: "debug"},
+		&Event{Message: "unknown"},
+		&Event{Level: LevelWarn, Message: "warn"},
+		&TestEntry{Line: "raw"},
+	)
+	b.Write(&Event{Level: LevelTrace, Message: "trace"})
+	b.Write(&Event{Level: LevelError, Message: "error"})
+
+	// Events below the level are skipped, unknown levels are info, and
+	// other entries are kept
+	v := r.ReadFilter(4, false, LevelFilter(LevelInfo))
+	require.Len(v, 3)
+	require.Equal("unknown", v[0].(*Event).Message)
+	require.Equal("warn", v[1].(*Event).Message)
+	require.Equal("raw", v[2].(*TestEntry).Line)
+
+	// Reads that only find filtered entries keep reading
+	v = r.ReadFilter(1, false, LevelFilter(LevelInfo))
+	require.Len(v, 1)
+	require.Equal("error", v[0].(*Event).Message)
+
+	require.Nil(r.ReadFilter(10, false, LevelFilter(LevelInfo)))
+}
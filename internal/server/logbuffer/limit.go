@@ -0,0 +1,138 @@
+package logbuffer
+
+import (
+	"sync/atomic"
+)
+
+// LimitMode is what a limited Buffer does when a write would take it over
+// its limit. See Buffer.Limit.
+type LimitMode int
+
+const (
+	// LimitTruncate drops the entries in the buffer to make room for the
+	// write right away. Readers that are behind skip the dropped entries.
+	LimitTruncate LimitMode = iota
+
+	// LimitBlock blocks the writer until every reader has read the entries
+	// in the buffer, and then drops them to make room for the write. This
+	// applies backpressure to the writer instead of dropping entries that
+	// readers haven't read. Entries are dropped right away if there are
+	// no readers.
+	LimitBlock
+)
+
+// Limit limits the approximate size in bytes of the entries in the buffer
+// to max. When a write would take the buffer over max, the entries in the
+// buffer are dropped as described by mode. If marker is non-nil, the
+// entry it returns for the number of dropped entries is written in their
+// place so that readers can tell that entries are missing.
+//
+// A single write that is larger than max on its own is still written.
+// A max of zero or less removes the limit.
+func (b *Buffer) Limit(max int64, mode LimitMode, marker func(dropped int) Entry) {
+	b.cond.L.Lock()
+	defer b.cond.L.Unlock()
+
+	b.max = max
+	b.limitMode = mode
+	b.limitMarker = marker
+
+	// Writers waiting for readers check the new limit.
+	b.cond.Broadcast()
+}
+
+// limitWait makes room for the entries if writing them would take the
+// buffer over its limit. This must be called with the lock held and may
+// release it while it waits for readers.
+func (b *Buffer) limitWait(entries []Entry) {
+	var size int64
+	for _, e := range entries {
+		size += entrySize(e)
+	}
+
+	over := func() bool {
+		return b.max > 0 && b.size > 0 && b.size+size > b.max
+	}
+	if !over() {
+		return
+	}
+
+	if b.limitMode == LimitBlock {
+		for over() && !b.caughtUp() {
+			atomic.AddInt32(&b.waiting, 1)
+			b.cond.Wait()
+			atomic.AddInt32(&b.waiting, -1)
+		}
+
+		// Another writer may have made room while we waited.
+		if !over() {
+			return
+		}
+	}
+
+	b.truncate()
+}
+
+// truncate drops the entries in the buffer by moving to a new chunk list
+// and writes the limit marker. This must be called with the lock held.
+func (b *Buffer) truncate() {
+	dropped := b.listSize()
+
+	// Seal the current chunk so that readers of the old chunk list move
+	// on to the new list once they read it rather than waiting for it to
+	// fill up.
+	atomic.StoreUint32(&b.chunks[b.current].sealed, 1)
+	b.chunks = make([]chunk, chunkCount)
+	b.current = 0
+	b.base += int64(dropped)
+
+	if b.tracker != nil {
+		b.tracker.add(-b.size)
+	}
+	b.size = 0
+
+	if b.limitMarker != nil && dropped > 0 {
+		b.write(b.compress([]Entry{b.limitMarker(dropped)}))
+	}
+}
+
+// caughtUp returns true if every reader has read all the entries in the
+// buffer. This must be called with the lock held.
+func (b *Buffer) caughtUp() bool {
+	current := &b.chunks[b.current]
+	size := current.size()
+	for r := range b.readers {
+		if atomic.LoadUint32(&r.closed) > 0 {
+			continue
+		}
+
+		// Readers move to the chunk the buffer is writing to once they
+		// read the chunks before it, so readers on any other chunk are
+		// behind.
+		pos, _ := r.pos.Load().(readerPos)
+		if pos.chunk != current || pos.cursor < size {
+			return false
+		}
+	}
+
+	return true
+}
+
+// readerPos is the position of a reader.
+type readerPos struct {
+	chunk  *chunk
+	cursor uint32
+}
+
+// track records the position of the reader for writers waiting for
+// readers to catch up, and wakes them if there are any. This is called
+// by the goroutine reading the reader.
+func (r *Reader) track() {
+	r.pos.Store(readerPos{chunk: &r.chunks[r.idx], cursor: r.cursor})
+
+	if atomic.LoadInt32(&r.b.waiting) > 0 {
+		r.b.cond.L.Lock()
+		r.b.cond.Broadcast()
+		r.b.cond.L.Unlock()
+	}
+}
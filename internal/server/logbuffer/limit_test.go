@@ -0,0 +1,143 @@
+package logbuffer
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuffer_limitTruncate(t *testing.T) {
+	require := require.New(t)
+
+	// Each entry is about 100 bytes, so the buffer fits three.
+	entry := func(i int) Entry {
+		return &TestEntry{Line: strconv.Itoa(i) + strings.Repeat("x", 80)}
+	}
+	max := 3*entrySize(entry(0)) + 1
+
+	b := New()
+	defer b.Close()
+	b.Limit(max, LimitTruncate, func(dropped int) Entry {
+		return &TestEntry{Line: "dropped " + strconv.Itoa(dropped)}
+	})
+
+	// A reader that is behind
+	r := b.Reader(-1)
+
+	for i := 0; i < 5; i++ {
+		b.Write(entry(i))
+	}
+	require.True(b.Size() <= max)
+
+	// The reader reads the entries before the truncation and then the
+	// marker and the entries after it
+	var lines []string
+	for len(lines) < 6 {
+		v := r.Read(10, true)
+		require.NotNil(v)
+		for _, e := range v {
+			lines = append(lines, e.(*TestEntry).Line[:1])
+		}
+	}
+	require.Equal([]string{"0", "1", "2", "d", "3", "4"}, lines)
+
+	// New readers only see the entries since the truncation
+	v := b.Reader(-1).Read(10, false)
+	require.Len(v, 3)
+	require.Equal("dropped 3", v[0].(*TestEntry).Line)
+}
+
+func TestBuffer_limitTruncateWaitingReader(t *testing.T) {
+	require := require.New(t)
+
+	b := New()
+	defer b.Close()
+	b.Limit(1, LimitTruncate, nil)
+
+	// A reader that has read everything and is waiting for more
+	r := b.Reader(-1)
+	b.Write(&TestEntry{Line: "a"})
+	require.Len(r.Read(10, true), 1)
+
+	resultCh := make(chan []Entry, 1)
+	go func() { resultCh <- r.Read(10, true) }()
+	time.Sleep(50 * time.Millisecond)
+
+	// The write drops the old chunk, and the reader moves on to the new one
+	b.Write(&TestEntry{Line: "b"})
+	select {
+	case v := <-resultCh:
+		require.Len(v, 1)
+		require.Equal("b", v[0].(*TestEntry).Line)
+	case <-time.After(5 * time.Second):
+		t.Fatal("reader should read the new entry")
+	}
+}
+
+func TestBuffer_limitBlock(t *testing.T) {
+	require := require.New(t)
+
+	b := New()
+	defer b.Close()
+	b.Limit(1, LimitBlock, nil)
+
+	// With no readers, writes don't block
+	b.Write(&TestEntry{Line: "a"})
+	b.Write(&TestEntry{Line: "b"})
+
+	// Writes block until the reader reads the entries in the buffer
+	r := b.Reader(-1)
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		b.Write(&TestEntry{Line: "c"})
+	}()
+
+	select {
+	case <-doneCh:
+		t.Fatal("write should block")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	v := r.Read(10, true)
+	require.Len(v, 1)
+	require.Equal("b", v[0].(*TestEntry).Line)
+	select {
+	case <-doneCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("write should finish")
+	}
+
+	// The reader didn't miss anything
+	v = r.Read(10, true)
+	require.Len(v, 1)
+	require.Equal("c", v[0].(*TestEntry).Line)
+
+	// Closing the reader unblocks writers too
+	b.Write(&TestEntry{Line: "d"})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		r.Close()
+	}()
+	b.Write(&TestEntry{Line: "e"})
+}
+
+func TestBuffer_limitTracker(t *testing.T) {
+	require := require.New(t)
+
+	tracker := NewTracker(1024*1024, nil)
+	b := New()
+	b.Track(tracker)
+	b.Limit(2*entrySize(&TestEntry{Line: "a"}), LimitTruncate, nil)
+
+	for i := 0; i < 10; i++ {
+		b.Write(&TestEntry{Line: "a"})
+		require.Equal(b.Size(), tracker.Total())
+	}
+
+	b.Close()
+	require.Equal(int64(0), tracker.Total())
+}
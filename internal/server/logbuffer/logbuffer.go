@@ -22,8 +22,9 @@ var (
 //
 // Callers can use easy APIs to write and read data and the storage and access
 // is managed underneath. If a reader falls behind a writer significantly, then
-// the next read may "jump" forward to catch up. There is no way to explicitly
-// detect a jump currently.
+// the next read may "jump" forward to catch up. A reader can detect a jump
+// with Offset and go back to earlier entries the buffer still holds with
+// SeekTo.
 //
 // Writer
 //
@@ -56,6 +57,27 @@ type Buffer struct {
 	cond    *sync.Cond
 	current int
 	readers map[*Reader]struct{}
+
+	// size is the approximate size in bytes of the entries in chunks.
+	// tracker, if non-nil, is updated with any changes to size.
+	size    int64
+	tracker *Tracker
+
+	// codec, if non-nil, is used to store entries compressed. See
+	// Compress.
+	codec Codec
+
+	// max, if positive, is the limit of size, handled by limitMode.
+	// limitMarker, if non-nil, creates the entry written in place of the
+	// dropped entries. waiting is the number of writers waiting for
+	// readers. See Limit.
+	max         int64
+	limitMode   LimitMode
+	limitMarker func(dropped int) Entry
+	waiting     int32
+
+	// base is the offset of the first entry in chunks. See Reader.SeekTo.
+	base int64
 }
 
 // New creates a new Buffer.
@@ -73,13 +95,32 @@ func New() *Buffer {
 func (b *Buffer) Write(entries ...Entry) {
 	b.cond.L.Lock()
 	defer b.cond.L.Unlock()
+	entries = b.compress(entries)
+
+	// Make room for the entries if the buffer is limited. This may wait
+	// for readers.
+	b.limitWait(entries)
+	b.write(entries)
 
-	// Write all our entries
+	// Wake up any sleeping readers
+	b.cond.Broadcast()
+}
+
+// write writes the entries into the chunks. This must be called with the
+// lock held.
+func (b *Buffer) write(entries []Entry) {
+	// Write all our entries. We track the change in size as we go so
+	// that we can update any tracker once at the end.
+	oldSize := b.size
 	for n := 0; n < len(entries); {
 		current := &b.chunks[b.current]
 
 		// Write our entries
-		n += current.write(entries[n:])
+		written := current.write(entries[n:])
+		for _, e := range entries[n : n+written] {
+			b.size += entrySize(e)
+		}
+		n += written
 
 		// If our chunk is full, we need to move to the next chunk or
 		// otherwise move the full window.
@@ -91,14 +132,47 @@ func (b *Buffer) Write(entries ...Entry) {
 			// hold on to the reference to the old chunk list so they'll
 			// finish reading there.
 			if b.current >= len(b.chunks) {
+				b.base += int64(chunkCount * chunkSize)
 				b.chunks = make([]chunk, chunkCount)
 				b.current = 0
+
+				// The old chunk list is no longer referenced by us so
+				// we no longer account for its size.
+				b.size = 0
 			}
 		}
 	}
 
-	// Wake up any sleeping readers
-	b.cond.Broadcast()
+	if b.tracker != nil {
+		b.tracker.add(b.size - oldSize)
+	}
+}
+
+// Size returns the approximate size in bytes of the entries currently
+// held by this buffer. Entries that have been dropped from the sliding
+// window are not counted even if a slow reader still references them.
+func (b *Buffer) Size() int64 {
+	b.cond.L.Lock()
+	defer b.cond.L.Unlock()
+	return b.size
+}
+
+// Track registers this buffer with the given Tracker. The current size
+// of the buffer and all future writes are counted against the tracker
+// until the buffer is closed. A buffer can only be tracked by one
+// tracker at a time.
+func (b *Buffer) Track(t *Tracker) {
+	b.cond.L.Lock()
+	defer b.cond.L.Unlock()
+
+	if b.tracker != nil {
+		b.tracker.add(-b.size)
+	}
+
+	b.tracker = t
+	if t != nil {
+		t.add(b.size)
+	}
 }
 
 // Reader returns a shared reader for this buffer. The Reader provides
@@ -144,6 +218,8 @@ func (b *Buffer) Reader(maxHistory int32) *Reader {
 
 	// Build our initial reader
 	result := &Reader{b: b, chunks: chunks, cursor: cursor, closeCh: make(chan struct{})}
+	result.offset = b.base + int64((len(b.chunks)-len(chunks))*chunkSize) + int64(cursor)
+	result.pos.Store(readerPos{chunk: &chunks[0], cursor: cursor})
 
 	// Track our reader
 	if b.readers == nil {
@@ -163,6 +239,13 @@ func (b *Buffer) Close() error {
 	b.cond.L.Lock()
 	rs := b.readers
 	b.readers = nil
+
+	// Release our size from the tracker. The memory is reclaimed once
+	// the readers below are gone.
+	if b.tracker != nil {
+		b.tracker.add(-b.size)
+		b.tracker = nil
+	}
 	b.cond.L.Unlock()
 
 	// Close all our readers
@@ -189,6 +272,13 @@ type Reader struct {
 	idx     int
 	cursor  uint32
 	closed  uint32
+
+	// offset is the offset of the entry at cursor. See SeekTo.
+	offset int64
+
+	// pos is the readerPos of the reader for writers waiting for it to
+	// catch up. See track.
+	pos atomic.Value
 }
 
 // Read returns a batch of log entries, up to "max" amount. If "max" isn't
@@ -201,8 +291,12 @@ func (r *Reader) Read(max int, block bool) []Entry {
 		return nil
 	}
 
+	defer r.track()
+
 	chunk := &r.chunks[r.idx] // Important: this must be the pointer
 	result, cursor := chunk.read(r.b.cond, &r.closed, r.cursor, uint32(max), block)
+	r.offset += int64(cursor - r.cursor)
+	result = r.b.decompress(result)
 
 	// If we're not at the end, return our result
 	if !chunk.atEnd(cursor) {
@@ -214,12 +308,21 @@ func (r *Reader) Read(max int, block bool) []Entry {
 	r.idx++
 	r.cursor = 0
 
-	// If we're at the end of our chunk list, get the next set
-	if r.idx >= len(r.chunks) {
-		r.chunks = r.b.Reader(-1).chunks
+	// If we're at the end of our chunk list, get the next set. A sealed
+	// chunk is the end of its list since the buffer moved on to a new
+	// list when it dropped its entries. If we fell behind, this skips
+	// the entries in between, so our offset jumps to the new list.
+	if r.idx >= len(r.chunks) || chunk.isSealed() {
+		r.chunks, r.offset = r.b.currentChunks()
 		r.idx = 0
 	}
 
+	// A sealed chunk can end without returning any entries, so read the
+	// next chunk rather than returning nil as if the reader was closed.
+	if result == nil {
+		return r.Read(max, block)
+	}
+
 	return result
 }
 
@@ -261,14 +364,27 @@ func (r *Reader) CloseContext(ctx context.Context) {
 type chunk struct {
 	idx    uint32
 	buffer []Entry
+
+	// sealed is 1 if the chunk will never be written to again even though
+	// it isn't full. See Buffer.truncate.
+	sealed uint32
 }
 
 // atEnd returns true if the cursor is at the end of the chunk. The
 // end means that there will never be any more new values.
 func (w *chunk) atEnd(cursor uint32) bool {
+	if w.isSealed() {
+		return cursor >= atomic.LoadUint32(&w.idx)
+	}
+
 	return cursor > 0 && cursor >= uint32(len(w.buffer))
 }
 
+// isSealed returns true if the chunk was sealed.
+func (w *chunk) isSealed() bool {
+	return atomic.LoadUint32(&w.sealed) > 0
+}
+
 // full returns true if this chunk is full. full means that the write
 // cursor is at the end of the chunk and no more data can be written. Any
 // calls to write will return with 0.
@@ -308,8 +424,8 @@ func (w *chunk) read(cond *sync.Cond, closed *uint32, current, max uint32, block
 		for idx <= current {
 			cond.Wait()
 
-			// If we closed, exit
-			if atomic.LoadUint32(closed) > 0 {
+			// If we closed or the chunk was sealed, exit
+			if atomic.LoadUint32(closed) > 0 || w.isSealed() {
 				cond.L.Unlock()
 				return nil, current
 			}
@@ -0,0 +1,71 @@
+package logbuffer
+
+// Every entry written to a Buffer has an offset, which is the number of
+// entries written before it. Offsets keep counting across the chunk lists
+// of the buffer, so a reader that reconnects can seek to the offset after
+// the last entry it read to resume exactly where it left off, as long as
+// the buffer still holds that entry.
+
+// Offset returns the offset of the next entry that Read will return,
+// unless the reader falls behind and jumps ahead.
+//
+// Like Read, this is not safe to call concurrently with Read.
+func (r *Reader) Offset() int64 {
+	return r.offset
+}
+
+// SeekTo moves the reader to the entry with the given offset so that the
+// next Read starts there. The offset can be before the current offset of
+// the reader to read entries again. If the buffer no longer holds the
+// entry, the reader moves to the oldest entry it holds, and if the offset
+// is past the latest entry, the reader moves to the next entry to be
+// written. This returns the offset the reader moved to.
+//
+// Like Read, this is not safe to call concurrently with Read.
+func (r *Reader) SeekTo(offset int64) int64 {
+	b := r.b
+	b.cond.L.Lock()
+	defer b.cond.L.Unlock()
+
+	start := b.base
+	end := start + int64(b.listSize())
+	if offset < start {
+		offset = start
+	}
+	if offset > end {
+		offset = end
+	}
+
+	// Every chunk before the current chunk is full, so the chunk holding
+	// the offset can be computed directly.
+	rel := offset - start
+	r.chunks = b.chunks
+	r.idx = int(rel / int64(chunkSize))
+	r.cursor = uint32(rel % int64(chunkSize))
+	r.offset = offset
+
+	// We hold the lock so we can't use track, but any waiting writers
+	// are woken by the next Read.
+	r.pos.Store(readerPos{chunk: &r.chunks[r.idx], cursor: r.cursor})
+
+	return offset
+}
+
+// currentChunks returns the chunk list that the buffer is writing to and
+// the offset of its first entry.
+func (b *Buffer) currentChunks() ([]chunk, int64) {
+	b.cond.L.Lock()
+	defer b.cond.L.Unlock()
+	return b.chunks, b.base
+}
+
+// listSize returns the number of entries in the current chunk list. This
+// must be called with the lock held.
+func (b *Buffer) listSize() int {
+	var result int
+	for i := 0; i <= b.current && i < len(b.chunks); i++ {
+		result += int(b.chunks[i].size())
+	}
+
+	return result
+}
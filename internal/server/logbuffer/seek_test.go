@@ -0,0 +1,112 @@
+package logbuffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReader_seek(t *testing.T) {
+	require := require.New(t)
+	chchunk(t, 2, 4)
+
+	// Write more than a chunk list holds, so the first list is dropped
+	var entries []Entry
+	for i := 0; i < 12; i++ {
+		entries = append(entries, i)
+	}
+	b := New()
+	defer b.Close()
+	b.Write(entries...)
+
+	readAll := func(r *Reader) []Entry {
+		var acc []Entry
+		for {
+			vs := r.Read(10, false)
+			if vs == nil {
+				return acc
+			}
+
+			acc = append(acc, vs...)
+		}
+	}
+
+	r := b.Reader(-1)
+	require.Equal(int64(8), r.Offset())
+	require.Equal([]Entry{8, 9, 10, 11}, readAll(r))
+	require.Equal(int64(12), r.Offset())
+
+	// Seek back within the retained entries and read them again
+	require.Equal(int64(9), r.SeekTo(9))
+	require.Equal([]Entry{9, 10, 11}, readAll(r))
+
+	// Seeking to dropped entries moves to the oldest retained entry
+	require.Equal(int64(8), r.SeekTo(2))
+	require.Equal([]Entry{8, 9, 10, 11}, readAll(r))
+
+	// Seeking past the end waits for the next entry
+	require.Equal(int64(12), r.SeekTo(100))
+	require.Nil(r.Read(10, false))
+	b.Write(12, 13, 14)
+	require.Equal([]Entry{12, 13, 14}, readAll(r))
+	require.Equal(int64(15), r.Offset())
+
+	// A new reader resumes where the last one left off
+	r2 := b.Reader(0)
+	require.Equal(int64(15), r2.Offset())
+	require.Equal(int64(13), r2.SeekTo(13))
+	require.Equal([]Entry{13, 14}, readAll(r2))
+}
+
+func TestReader_offsetJump(t *testing.T) {
+	require := require.New(t)
+	chchunk(t, 2, 2)
+
+	b := New()
+	defer b.Close()
+	r := b.Reader(-1)
+	require.Equal(int64(0), r.Offset())
+
+	// The reader falls behind by more than a chunk list
+	for i := 0; i < 9; i++ {
+		b.Write(i)
+	}
+
+	// The reader finishes its chunk list and then jumps to the offset of
+	// the current list
+	var acc []Entry
+	var offsets []int64
+	for {
+		vs := r.Read(10, false)
+		if vs == nil {
+			break
+		}
+
+		acc = append(acc, vs...)
+		offsets = append(offsets, r.Offset())
+	}
+	require.Equal([]Entry{0, 1, 2, 3, 8}, acc)
+	require.Equal([]int64{2, 8, 9}, offsets)
+}
+
+func TestReader_seekTruncate(t *testing.T) {
+	require := require.New(t)
+
+	b := New()
+	defer b.Close()
+	b.Write(&TestEntry{Line: "a"}, &TestEntry{Line: "b"})
+	b.Limit(1, LimitTruncate, func(dropped int) Entry {
+		return &TestEntry{Line: "dropped"}
+	})
+	b.Write(&TestEntry{Line: "c"})
+
+	// The dropped entries keep their offsets, so the marker and the new
+	// entry follow them
+	r := b.Reader(0)
+	require.Equal(int64(4), r.Offset())
+	require.Equal(int64(2), r.SeekTo(0))
+	v := r.Read(10, false)
+	require.Len(v, 2)
+	require.Equal("dropped", v[0].(*TestEntry).Line)
+	require.Equal("c", v[1].(*TestEntry).Line)
+}
@@ -0,0 +1,72 @@
+package logbuffer
+
+import (
+	"sync/atomic"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// entryOverhead is the approximate fixed cost in bytes of storing a single
+// entry in a buffer, regardless of the entry contents.
+const entryOverhead = 16
+
+// Tracker tracks the total approximate memory used by a set of buffers
+// and notifies when that total exceeds a maximum.
+//
+// The tracker itself doesn't free any memory. The callback given to
+// NewTracker is responsible for closing buffers (or otherwise dropping
+// references to them) to bring the total back under the maximum.
+type Tracker struct {
+	total int64
+	max   int64
+	over  func()
+}
+
+// NewTracker creates a new Tracker. If the total size of all tracked
+// buffers grows beyond max bytes, over is called. over is called from
+// the writer of a buffer while the buffer lock is held so it must not
+// block or call back into the buffer. It will usually want to start a
+// goroutine to perform any cleanup. over may be called many times while
+// the total is over the maximum.
+func NewTracker(max int64, over func()) *Tracker {
+	return &Tracker{max: max, over: over}
+}
+
+// Total returns the current total size in bytes of all tracked buffers.
+func (t *Tracker) Total() int64 {
+	return atomic.LoadInt64(&t.total)
+}
+
+// Max returns the maximum size in bytes configured for this tracker.
+func (t *Tracker) Max() int64 {
+	return t.max
+}
+
+// Over returns true if the total is currently over the maximum.
+func (t *Tracker) Over() bool {
+	return t.Total() > t.max
+}
+
+func (t *Tracker) add(delta int64) {
+	total := atomic.AddInt64(&t.total, delta)
+	if delta > 0 && total > t.max && t.over != nil {
+		t.over()
+	}
+}
+
+// entrySize returns the approximate size in bytes of a single entry.
+func entrySize(e Entry) int64 {
+	size := int64(entryOverhead)
+	if c, ok := e.(compressedEntry); ok {
+		return size + int64(len(c))
+	}
+	if ev, ok := e.(*Event); ok && ev != nil {
+		size += int64(len(ev.Step) + len(ev.Message))
+		e = ev.Data
+	}
+	if m, ok := e.(proto.Message); ok && m != nil {
+		size += int64(proto.Size(m))
+	}
+
+	return size
+}
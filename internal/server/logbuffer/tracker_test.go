@@ -0,0 +1,60 @@
+package logbuffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker(t *testing.T) {
+	require := require.New(t)
+
+	var overCount int
+	tr := NewTracker(100, func() { overCount++ })
+
+	// Write before tracking, the existing size should be counted.
+	b1 := New()
+	b1.Write(nil, nil)
+	b1.Track(tr)
+	require.Equal(int64(2*entryOverhead), tr.Total())
+	require.Equal(b1.Size(), tr.Total())
+	require.False(tr.Over())
+
+	// Writes to a second buffer are counted.
+	b2 := New()
+	b2.Track(tr)
+	b2.Write(&TestEntry{Line: "hello"})
+	require.Equal(b1.Size()+b2.Size(), tr.Total())
+	require.Equal(0, overCount)
+
+	// Go over the max
+	b2.Write(&TestEntry{Line: "this line is long enough to push us over the limit"})
+	require.True(tr.Over())
+	require.Equal(1, overCount)
+
+	// Closing releases the size
+	require.NoError(b2.Close())
+	require.Equal(b1.Size(), tr.Total())
+	require.False(tr.Over())
+	require.NoError(b1.Close())
+	require.Equal(int64(0), tr.Total())
+}
+
+func TestTracker_rollover(t *testing.T) {
+	require := require.New(t)
+
+	tr := NewTracker(1<<20, nil)
+	b := New()
+	defer b.Close()
+	b.Track(tr)
+
+	// Fill up an entire chunk list and then some. Only the entries in the
+	// current window should be counted.
+	for i := 0; i < chunkCount*chunkSize; i++ {
+		b.Write(nil)
+	}
+	b.Write(nil, nil)
+
+	require.Equal(b.Size(), tr.Total())
+	require.True(b.Size() < int64(chunkCount*chunkSize*entryOverhead))
+}
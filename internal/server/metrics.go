@@ -0,0 +1,51 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// MetricsWriter is optionally implemented by the service to serve metrics
+// at /metrics on the HTTP listener.
+type MetricsWriter interface {
+	// WriteMetrics writes the current metrics in the Prometheus text
+	// exposition format.
+	WriteMetrics(ctx context.Context, w io.Writer) error
+}
+
+// metricsEndpoint is the endpoint name that requests to /metrics are
+// authenticated as.
+const metricsEndpoint = "GetDORAMetrics"
+
+// metricsHandler returns an HTTP handler that serves the metrics written
+// by mw. If checker is non-nil, requests must have a valid token in the
+// Authorization header, optionally prefixed with "Bearer ".
+func metricsHandler(mw MetricsWriter, checker AuthChecker, log hclog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if checker != nil {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			err := checker.Authenticate(r.Context(), token, metricsEndpoint, readonly)
+			if err != nil {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		// Write to a buffer first so that we can return an error status
+		// rather than a partial response.
+		var buf bytes.Buffer
+		if err := mw.WriteMetrics(r.Context(), &buf); err != nil {
+			log.Warn("error writing metrics", "err", err)
+			http.Error(w, "error writing metrics", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		buf.WriteTo(w)
+	})
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+type testMetricsWriter struct {
+	err error
+}
+
+func (m *testMetricsWriter) WriteMetrics(ctx context.Context, w io.Writer) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	_, err := io.WriteString(w, "waypoint_test 1\n")
+	return err
+}
+
+type denyAuth struct{}
+
+func (denyAuth) Authenticate(ctx context.Context, token string, endpoint string, effects []string) error {
+	return errors.New("denied")
+}
+
+func TestMetricsHandler(t *testing.T) {
+	t.Run("writes metrics", func(t *testing.T) {
+		require := require.New(t)
+
+		rec := httptest.NewRecorder()
+		metricsHandler(&testMetricsWriter{}, nil, hclog.L()).ServeHTTP(
+			rec, httptest.NewRequest("GET", "/metrics", nil))
+		require.Equal(http.StatusOK, rec.Code)
+		require.Equal("waypoint_test 1\n", rec.Body.String())
+	})
+
+	t.Run("authenticates the bearer token", func(t *testing.T) {
+		require := require.New(t)
+
+		var auth trivialAuth
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		req.Header.Set("Authorization", "Bearer abcd")
+		rec := httptest.NewRecorder()
+		metricsHandler(&testMetricsWriter{}, &auth, hclog.L()).ServeHTTP(rec, req)
+		require.Equal(http.StatusOK, rec.Code)
+		require.Equal("abcd", auth.token)
+		require.Equal(metricsEndpoint, auth.method)
+		require.Equal(readonly, auth.effects)
+	})
+
+	t.Run("rejects invalid tokens", func(t *testing.T) {
+		require := require.New(t)
+
+		rec := httptest.NewRecorder()
+		metricsHandler(&testMetricsWriter{}, denyAuth{}, hclog.L()).ServeHTTP(
+			rec, httptest.NewRequest("GET", "/metrics", nil))
+		require.Equal(http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("errors don't write partial output", func(t *testing.T) {
+		require := require.New(t)
+
+		rec := httptest.NewRecorder()
+		metricsHandler(&testMetricsWriter{err: errors.New("nope")}, nil, hclog.L()).ServeHTTP(
+			rec, httptest.NewRequest("GET", "/metrics", nil))
+		require.Equal(http.StatusInternalServerError, rec.Code)
+		require.NotContains(rec.Body.String(), "waypoint_test")
+	})
+}
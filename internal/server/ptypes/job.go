@@ -2,7 +2,10 @@ package ptypes
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
+	"strings"
+	"time"
 
 	"github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/imdario/mergo"
@@ -51,11 +54,145 @@ func ValidateJob(job *pb.Job) error {
 		validation.Field(&job.Id, validation.By(isEmpty)),
 		validation.Field(&job.Application, validation.Required),
 		validation.Field(&job.Workspace, validation.Required),
-		validation.Field(&job.TargetRunner, validation.Required),
+		validation.Field(&job.TargetRunner, validation.Required, validation.By(validateJobTargetRunner)),
 		validation.Field(&job.Operation, validation.Required),
+		validation.Field(&job.Timeouts, validation.By(validateJobTimeouts)),
+		validation.Field(&job.Affinity, validation.By(validateJobAffinity)),
+		validation.Field(&job.Locality, validation.By(validateJobLocality)),
+		validation.Field(&job.Platform, validation.By(validateJobPlatform)),
+		validation.Field(&job.RequiredTags, validation.Each(validation.Required)),
 	)
 }
 
+// JobTimeout parses a timeout in the Timeouts field of a job. Timeouts
+// must be positive durations such as "30s".
+func JobTimeout(v string) (time.Duration, error) {
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("must be positive: %s", v)
+	}
+
+	return d, nil
+}
+
+// JobPlatform parses the Platform field of a job, such as "linux/amd64"
+// or "darwin", into its os and arch. The arch is empty if only the os is
+// set.
+func JobPlatform(v string) (os, arch string, err error) {
+	parts := strings.Split(v, "/")
+	if len(parts) > 2 {
+		return "", "", fmt.Errorf("must be os or os/arch: %s", v)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return "", "", fmt.Errorf("must be os or os/arch: %s", v)
+		}
+	}
+
+	os = parts[0]
+	if len(parts) == 2 {
+		arch = parts[1]
+	}
+
+	return os, arch, nil
+}
+
+// JobOperation returns the name of the operation of the job, such as
+// "deploy".
+func JobOperation(job *pb.Job) string {
+	switch job.Operation.(type) {
+	case *pb.Job_Noop_:
+		return "noop"
+	case *pb.Job_Build:
+		return "build"
+	case *pb.Job_Push:
+		return "push"
+	case *pb.Job_Deploy:
+		return "deploy"
+	case *pb.Job_Destroy:
+		return "destroy"
+	case *pb.Job_Release:
+		return "release"
+	case *pb.Job_Validate:
+		return "validate"
+	case *pb.Job_Auth:
+		return "auth"
+	case *pb.Job_Docs:
+		return "docs"
+	default:
+		return "unknown"
+	}
+}
+
+func validateJobTargetRunner(v interface{}) error {
+	r, ok := v.(*pb.Ref_Runner)
+	if !ok || r == nil {
+		return nil
+	}
+
+	if pool, ok := r.Target.(*pb.Ref_Runner_Pool); ok && (pool.Pool == nil || pool.Pool.Name == "") {
+		return errors.New("runner pool name must be set")
+	}
+
+	return nil
+}
+
+func validateJobTimeouts(v interface{}) error {
+	t, ok := v.(*pb.Job_Timeouts)
+	if !ok || t == nil {
+		return nil
+	}
+
+	return validation.ValidateStruct(t,
+		validation.Field(&t.Ack, validation.By(validateJobTimeout)),
+		validation.Field(&t.Heartbeat, validation.By(validateJobTimeout)),
+		validation.Field(&t.MaxRun, validation.By(validateJobTimeout)),
+	)
+}
+
+func validateJobAffinity(v interface{}) error {
+	a, ok := v.(*pb.Job_Affinity)
+	if !ok || a == nil {
+		return nil
+	}
+
+	return validation.ValidateStruct(a,
+		validation.Field(&a.Wait, validation.By(validateJobTimeout)),
+	)
+}
+
+func validateJobLocality(v interface{}) error {
+	l, ok := v.(*pb.Job_Locality)
+	if !ok || l == nil {
+		return nil
+	}
+
+	return validation.ValidateStruct(l,
+		validation.Field(&l.Wait, validation.By(validateJobTimeout)),
+	)
+}
+
+func validateJobPlatform(v interface{}) error {
+	if s, ok := v.(string); ok && s != "" {
+		_, _, err := JobPlatform(s)
+		return err
+	}
+
+	return nil
+}
+
+func validateJobTimeout(v interface{}) error {
+	if s, ok := v.(string); ok && s != "" {
+		_, err := JobTimeout(s)
+		return err
+	}
+
+	return nil
+}
+
 func isEmpty(v interface{}) error {
 	if reflect.ValueOf(v).IsZero() {
 		return nil
@@ -25,6 +25,76 @@ func TestValidateJob(t *testing.T) {
 			func(j *pb.Job) { j.Id = "nope" },
 			"id: must be empty",
 		},
+
+		{
+			"valid timeouts",
+			func(j *pb.Job) {
+				j.Timeouts = &pb.Job_Timeouts{Heartbeat: "30s", MaxRun: "2h"}
+			},
+			"",
+		},
+
+		{
+			"invalid timeout",
+			func(j *pb.Job) { j.Timeouts = &pb.Job_Timeouts{Ack: "soon"} },
+			"ack: time: invalid duration",
+		},
+
+		{
+			"negative timeout",
+			func(j *pb.Job) { j.Timeouts = &pb.Job_Timeouts{MaxRun: "-1h"} },
+			"max_run: must be positive",
+		},
+
+		{
+			"invalid affinity wait",
+			func(j *pb.Job) {
+				j.Affinity = &pb.Job_Affinity{PreferLastRunner: true, Wait: "0s"}
+			},
+			"wait: must be positive",
+		},
+
+		{
+			"invalid locality wait",
+			func(j *pb.Job) {
+				j.Locality = &pb.Job_Locality{Region: "us-east-1", Wait: "soon"}
+			},
+			"wait: time: invalid duration",
+		},
+
+		{
+			"valid platform",
+			func(j *pb.Job) { j.Platform = "linux/amd64" },
+			"",
+		},
+
+		{
+			"invalid platform",
+			func(j *pb.Job) { j.Platform = "linux/" },
+			"platform: must be os or os/arch",
+		},
+
+		{
+			"valid required tags",
+			func(j *pb.Job) { j.RequiredTags = []string{"gpu", "kvm"} },
+			"",
+		},
+
+		{
+			"empty required tag",
+			func(j *pb.Job) { j.RequiredTags = []string{"gpu", ""} },
+			"required_tags: (1: cannot be blank.)",
+		},
+
+		{
+			"runner pool without a name",
+			func(j *pb.Job) {
+				j.TargetRunner = &pb.Ref_Runner{
+					Target: &pb.Ref_Runner_Pool{Pool: &pb.Ref_RunnerPool{}},
+				}
+			},
+			"runner pool name must be set",
+		},
 	}
 
 	for _, tt := range cases {
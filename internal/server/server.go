@@ -8,6 +8,7 @@ import (
 	"github.com/oklog/run"
 	"google.golang.org/grpc"
 
+	"github.com/hashicorp/waypoint/internal/audit"
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
 )
 
@@ -90,6 +91,12 @@ type options struct {
 	// BrowserUIEnabled determines if the browser UI should be mounted
 	BrowserUIEnabled bool
 
+	// AuditExporter, if set, exports an audit event for every RPC.
+	AuditExporter *audit.Exporter
+
+	// MetricsEnabled determines if metrics are served at /metrics
+	MetricsEnabled bool
+
 	grpcServer *grpc.Server
 }
 
@@ -132,3 +139,18 @@ func WithAuthentication(ac AuthChecker) Option {
 func WithBrowserUI(enabled bool) Option {
 	return func(opts *options) { opts.BrowserUIEnabled = enabled }
 }
+
+// WithAudit configures the server to export an audit event for every RPC.
+// If the service implements AuditIdentifier, events include the identity
+// of the caller.
+func WithAudit(exp *audit.Exporter) Option {
+	return func(opts *options) { opts.AuditExporter = exp }
+}
+
+// WithMetrics configures the server to serve metrics at /metrics on the
+// HTTP listener. The service must implement MetricsWriter. If
+// authentication is enabled, requests must have a token in the
+// Authorization header.
+func WithMetrics(enabled bool) Option {
+	return func(opts *options) { opts.MetricsEnabled = enabled }
+}
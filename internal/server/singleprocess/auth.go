@@ -6,7 +6,6 @@ import (
 	"crypto/rand"
 	"crypto/subtle"
 	"io"
-	"strings"
 	"time"
 
 	"github.com/golang/protobuf/proto"
@@ -16,9 +15,12 @@ import (
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/blake2b"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
+	"github.com/hashicorp/waypoint/internal/runnerauth"
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/serverconfig"
 )
 
 const (
@@ -44,17 +46,107 @@ const (
 
 var (
 	ErrInvalidToken = errors.New("invalid authentication token")
+
+	// entrypointEndpoints are the only endpoints that entrypoint tokens are
+	// allowed to access. Entrypoint tokens are given to every instance of
+	// a deployment so they're the most exposed credential we issue.
+	entrypointEndpoints = map[string]bool{
+		"EntrypointConfig":     true,
+		"EntrypointLogStream":  true,
+		"EntrypointExecStream": true,
+	}
+
+	// runnerDeniedEndpoints are the endpoints that runner tokens are not
+	// allowed to access. Runners execute jobs on behalf of users so they
+	// need access to most of the API, but not to token or server management.
+	runnerDeniedEndpoints = map[string]bool{
+		"GenerateInviteToken":      true,
+		"GenerateLoginToken":       true,
+		"SetServerConfig":          true,
+		"CreateSnapshot":           true,
+		"ListSnapshots":            true,
+		"RestoreSnapshot":          true,
+		"CompactDatabase":          true,
+		"GetBackupStatus":          true,
+		"VerifyState":              true,
+		"ApproveJob":               true,
+		"SetWorkspacePolicy":       true,
+		"CancelJobs":               true,
+		"DeleteJob":                true,
+		"PauseJobQueue":            true,
+		"ResumeJobQueue":           true,
+		"DrainRunner":              true,
+		"UndrainRunner":            true,
+		"ApproveRunner":            true,
+		"CreateRunnerToken":        true,
+		"ListRunnerTokens":         true,
+		"RevokeRunnerToken":        true,
+		"SetJobTemplate":           true,
+		"DeleteJobTemplate":        true,
+		"SetRunnerProfile":         true,
+		"DeleteRunnerProfile":      true,
+		"SetRunnerConfigFile":      true,
+		"ListRunnerConfigFiles":    true,
+		"DeleteRunnerConfigFile":   true,
+		"SetRunnerInstallation":    true,
+		"DeleteRunnerInstallation": true,
+		"SetRunnerVersion":         true,
+		"SetJobConflicts":          true,
+		"CreateAPIKey":             true,
+		"RotateAPIKey":             true,
+		"ListAPIKeys":              true,
+		"DeleteAPIKey":             true,
+		"ExportProject":            true,
+		"ImportProject":            true,
+	}
+
+	// userAllowedEndpoints are the endpoints that runners are denied but
+	// that users other than the default user are allowed to access. Users
+	// are invited by the default user, so otherwise they have the same
+	// restrictions as runners and can't invite other users or change who
+	// is allowed to approve jobs.
+	userAllowedEndpoints = map[string]bool{
+		"ApproveJob":   true,
+		"CreateAPIKey": true,
+		"RotateAPIKey": true,
+		"ListAPIKeys":  true,
+		"DeleteAPIKey": true,
+	}
+
+	// apiKeyDeniedEndpoints are the endpoints that API key tokens are not
+	// allowed to access. API keys belong to a single user, so they can't
+	// create other credentials or change who is allowed to approve jobs.
+	// Otherwise a user could approve a job more than once as different
+	// users.
+	apiKeyDeniedEndpoints = map[string]bool{
+		"GenerateInviteToken": true,
+		"GenerateLoginToken":  true,
+		"CreateAPIKey":        true,
+		"RotateAPIKey":        true,
+		"DeleteAPIKey":        true,
+		"SetWorkspacePolicy":  true,
+	}
 )
 
 // DecodeToken parses the string and validates it as a valid token. If the token
 // has a validity period attached to it, the period is checked here.
 func (s *service) DecodeToken(token string) (*pb.TokenTransport, *pb.Token, error) {
+	return decodeToken(token, s.state.HMACKeyGet)
+}
+
+// decodeToken decodes and verifies the token with the HMAC key returned by
+// keyGet, which returns nil if there is no key with the ID.
+func decodeToken(
+	token string,
+	keyGet func(string) (*pb.HMACKey, error),
+) (*pb.TokenTransport, *pb.Token, error) {
 	data, err := base58.Decode(token)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	if subtle.ConstantTimeCompare(data[:len(tokenMagic)], []byte(tokenMagic)) != 1 {
+	if len(data) < len(tokenMagic) ||
+		subtle.ConstantTimeCompare(data[:len(tokenMagic)], []byte(tokenMagic)) != 1 {
 		return nil, nil, errors.Wrapf(ErrInvalidToken, "bad magic")
 	}
 
@@ -64,7 +156,7 @@ func (s *service) DecodeToken(token string) (*pb.TokenTransport, *pb.Token, erro
 		return nil, nil, err
 	}
 
-	key, err := s.state.HMACKeyGet(tt.KeyId)
+	key, err := keyGet(tt.KeyId)
 	if err != nil || key == nil {
 		return nil, nil, errors.Wrapf(ErrInvalidToken, "unknown key")
 	}
@@ -111,6 +203,11 @@ func (s *service) Authenticate(ctx context.Context, token, endpoint string, effe
 		return nil
 	}
 
+	// RunnerLogin authenticates with a cloud identity rather than a token.
+	if endpoint == "RunnerLogin" {
+		return nil
+	}
+
 	if token == "" {
 		return status.Errorf(codes.Unauthenticated, "Authorization token is not supplied")
 	}
@@ -125,18 +222,211 @@ func (s *service) Authenticate(ctx context.Context, token, endpoint string, effe
 	}
 
 	// If this is an entrypoint token then we can only access entrypoint APIs.
-	if body.Entrypoint != nil && !strings.HasPrefix(endpoint, "Entrypoint") {
+	// The requests themselves are scoped to the deployment in AuthorizeRequest.
+	if body.Entrypoint != nil && !entrypointEndpoints[endpoint] {
+		return status.Errorf(codes.Unauthenticated, "Unauthorized endpoint")
+	}
+
+	// Runner tokens can't create other tokens or manage the server.
+	if body.Runner != nil && runnerDeniedEndpoints[endpoint] {
 		return status.Errorf(codes.Unauthenticated, "Unauthorized endpoint")
 	}
 
-	// TODO When we have a user model, this is where you'll check for the user.
-	if body.User != DefaultUser {
+	// Tokens for a single runner can be rotated or revoked, so we verify
+	// the token is still valid.
+	if body.Runner != nil && body.Runner.RunnerId != "" {
+		return s.authenticateRunnerToken(body)
+	}
+
+	if body.User == "" {
 		return ErrInvalidToken
 	}
 
+	// Users other than the default user can't manage the server.
+	if body.User != DefaultUser &&
+		runnerDeniedEndpoints[endpoint] && !userAllowedEndpoints[endpoint] {
+		return status.Errorf(codes.Unauthenticated, "Unauthorized endpoint")
+	}
+
+	// API keys are scoped to a user and can be rotated or deleted, so we
+	// verify the key is still valid.
+	if body.ApiKeyId != "" {
+		if apiKeyDeniedEndpoints[endpoint] {
+			return status.Errorf(codes.Unauthenticated, "Unauthorized endpoint")
+		}
+
+		return s.authenticateAPIKey(body)
+	}
+
 	return nil
 }
 
+// callerToken returns the decoded token of the caller from the request
+// metadata. This is the identity of the caller, since the token is signed
+// by the server.
+func (s *service) callerToken(ctx context.Context) (*pb.Token, error) {
+	var token string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md["authorization"]; len(v) > 0 {
+			token = v[0]
+		}
+	}
+	if token == "" {
+		return nil, status.Errorf(codes.Unauthenticated,
+			"Authorization token is not supplied")
+	}
+
+	_, body, err := s.DecodeToken(token)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, err.Error())
+	}
+	if !body.Login {
+		return nil, status.Errorf(codes.Unauthenticated, ErrInvalidToken.Error())
+	}
+
+	return body, nil
+}
+
+// tokenIsAdmin returns true if the token is a login token of the default
+// user. These are the tokens of the operators of the server, which can
+// invite users and assign roles.
+func tokenIsAdmin(body *pb.Token) bool {
+	return body.Login &&
+		body.User == DefaultUser &&
+		body.ApiKeyId == "" &&
+		body.Runner == nil &&
+		body.Entrypoint == nil
+}
+
+// AuthorizeRequest implements server.RequestAuthorizer. Entrypoint tokens
+// are scoped to a single deployment, so an instance can only fetch the
+// config for, and send logs and exec output as, its own deployment. Runner
+// tokens mapped to a pool can only register runners in that pool, and
+// tokens for a single runner can only act as that runner.
+func (s *service) AuthorizeRequest(ctx context.Context, token, endpoint string, req interface{}) error {
+	// Only entrypoint requests are scoped. We check the request type first
+	// so that we don't decode the token again for every other request.
+	var deploymentId, instanceId string
+	switch req := req.(type) {
+	case *pb.EntrypointConfigRequest:
+		deploymentId = req.DeploymentId
+		instanceId = req.InstanceId
+
+	case *pb.EntrypointLogBatch:
+		instanceId = req.InstanceId
+
+	case *pb.EntrypointExecRequest:
+		// Only the open message names the instance. The handler requires
+		// open to be the first message, so the rest of the stream is scoped.
+		open, ok := req.Event.(*pb.EntrypointExecRequest_Open_)
+		if !ok {
+			return nil
+		}
+		instanceId = open.Open.InstanceId
+
+	case *pb.RunnerConfigRequest:
+		// Only the open message registers the runner.
+		open, ok := req.Event.(*pb.RunnerConfigRequest_Open_)
+		if !ok {
+			return nil
+		}
+
+		return s.authorizeRunner(token, open.Open.Runner)
+
+	case *pb.RunnerJobStreamRequest:
+		// Only the request message names the runner.
+		open, ok := req.Event.(*pb.RunnerJobStreamRequest_Request_)
+		if !ok {
+			return nil
+		}
+
+		return s.authorizeRunnerId(token, open.Request.RunnerId)
+
+	case *pb.RotateRunnerTokenRequest:
+		// Runners can rotate their own token, but runners that logged in
+		// with a cloud identity have no token to rotate.
+		_, body, err := s.DecodeToken(token)
+		if err != nil {
+			return err
+		}
+		if body.Runner != nil && body.Runner.RunnerId != req.RunnerId {
+			return status.Errorf(codes.PermissionDenied,
+				"runner token is not authorized for runner %q", req.RunnerId)
+		}
+
+		return nil
+
+	default:
+		return nil
+	}
+
+	_, body, err := s.DecodeToken(token)
+	if err != nil {
+		return err
+	}
+	if body.Entrypoint == nil {
+		return nil
+	}
+
+	denied := status.Errorf(codes.PermissionDenied,
+		"entrypoint token is not authorized for this deployment")
+	if body.Entrypoint.DeploymentId == "" {
+		return denied
+	}
+
+	// Instances are registered with their deployment by EntrypointConfig,
+	// so we verify the instance belongs to the token's deployment. This also
+	// prevents registering an instance ID owned by another deployment.
+	if instanceId != "" {
+		instance, err := s.state.InstanceById(instanceId)
+		switch {
+		case status.Code(err) == codes.NotFound:
+			// A new instance for EntrypointConfig, otherwise denied below.
+
+		case err != nil:
+			return err
+
+		default:
+			if instance.DeploymentId != body.Entrypoint.DeploymentId {
+				return denied
+			}
+
+			if deploymentId == "" {
+				deploymentId = instance.DeploymentId
+			}
+		}
+	}
+
+	if deploymentId != body.Entrypoint.DeploymentId {
+		return denied
+	}
+
+	return nil
+}
+
+// AuditIdentity implements server.AuditIdentifier. This doesn't check
+// whether the token is allowed to call any endpoint, only that it is
+// validly signed.
+func (s *service) AuditIdentity(token string) string {
+	_, body, err := s.DecodeToken(token)
+	if err != nil {
+		return ""
+	}
+
+	switch {
+	case body.ApiKeyId != "":
+		return "apikey:" + body.ApiKeyId
+	case body.Runner != nil && body.Runner.RunnerId != "":
+		return "runner:" + body.Runner.RunnerId
+	case body.Runner != nil:
+		return "runner:" + body.Runner.Identity
+	case body.Entrypoint != nil:
+		return "entrypoint:" + body.Entrypoint.DeploymentId
+	default:
+		return "user:" + body.User
+	}
+}
+
 // Generate a new token by signing the data in body.
 // keyId controls which key is used to sign the key (key values are generated lazily).
 // metadata is attached to the token transport as configuration style information
@@ -236,6 +526,35 @@ func (s *service) NewInviteToken(
 	return s.GenerateToken(keyId, metadata, &body)
 }
 
+// NewUserInviteToken creates an invite token for a user other than the
+// default user. The login token it is exchanged for is for the user and
+// has the given roles.
+func (s *service) NewUserInviteToken(
+	duration time.Duration,
+	keyId string,
+	user string,
+	roles []string,
+) (string, error) {
+	var body pb.Token
+	body.Invite = true
+	body.User = user
+	body.Roles = roles
+	body.TokenId = make([]byte, 16)
+
+	now := time.Now().UTC().Add(duration)
+	body.ValidUntil = &timestamp.Timestamp{
+		Seconds: now.Unix(),
+		Nanos:   int32(now.Nanosecond()),
+	}
+
+	_, err := io.ReadFull(rand.Reader, body.TokenId)
+	if err != nil {
+		return "", err
+	}
+
+	return s.GenerateToken(keyId, nil, &body)
+}
+
 // Create a new invite token. This is just a gRPC wrapper around NewInviteToken.
 func (s *service) GenerateInviteToken(ctx context.Context, req *pb.InviteTokenRequest) (*pb.NewTokenResponse, error) {
 	dur, err := time.ParseDuration(req.Duration)
@@ -243,6 +562,31 @@ func (s *service) GenerateInviteToken(ctx context.Context, req *pb.InviteTokenRe
 		return nil, err
 	}
 
+	// Entrypoint tokens are scoped to a deployment, so one is required.
+	if req.Entrypoint != nil && req.Entrypoint.DeploymentId == "" {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"entrypoint tokens require a deployment ID")
+	}
+
+	// Invites for users are for people, not for entrypoints.
+	if req.User != "" || len(req.Roles) > 0 {
+		if req.User == "" || req.User == DefaultUser {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"roles can only be set for users other than the default user")
+		}
+		if req.Entrypoint != nil {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"entrypoint tokens can't be for a user")
+		}
+
+		token, err := s.NewUserInviteToken(dur, DefaultKeyId, req.User, req.Roles)
+		if err != nil {
+			return nil, err
+		}
+
+		return &pb.NewTokenResponse{Token: token}, nil
+	}
+
 	token, err := s.NewInviteToken(dur, DefaultKeyId, nil, req.Entrypoint)
 	if err != nil {
 		return nil, err
@@ -262,6 +606,20 @@ func (s *service) ExchangeInvite(keyId, invite string) (string, error) {
 		return "", errors.Wrapf(ErrInvalidToken, "not an invite token")
 	}
 
+	// Invites for users are exchanged for a login token for the user.
+	if body.User != "" && body.User != DefaultUser {
+		var login pb.Token
+		login.Login = true
+		login.User = body.User
+		login.Roles = body.Roles
+		login.TokenId = make([]byte, 16)
+		if _, err := io.ReadFull(rand.Reader, login.TokenId); err != nil {
+			return "", err
+		}
+
+		return s.GenerateToken(keyId, tt.Metadata, &login)
+	}
+
 	return s.NewLoginToken(keyId, tt.Metadata, body.Entrypoint)
 }
 
@@ -275,6 +633,130 @@ func (s *service) ConvertInviteToken(ctx context.Context, req *pb.ConvertInviteT
 	return &pb.NewTokenResponse{Token: token}, nil
 }
 
+// authorizeRunner checks that a runner registering with a runner token
+// only registers as the runner the token was issued to, if any, and that
+// a runner token that is mapped to a pool doesn't join a different pool.
+// The runner may join no pool.
+func (s *service) authorizeRunner(token string, r *pb.Runner) error {
+	if err := s.authorizeRunnerId(token, r.GetId()); err != nil {
+		return err
+	}
+	if r.GetPool() == "" {
+		return nil
+	}
+
+	_, body, err := s.DecodeToken(token)
+	if err != nil {
+		return err
+	}
+	if body.Runner == nil || body.Runner.Pool == "" || body.Runner.Pool == r.Pool {
+		return nil
+	}
+
+	return status.Errorf(codes.PermissionDenied,
+		"runner token is not authorized for runner pool %q", r.Pool)
+}
+
+// authorizeRunnerId checks that a token for a single runner is only used
+// as the runner with the given ID. Other tokens may act as any runner.
+func (s *service) authorizeRunnerId(token, id string) error {
+	_, body, err := s.DecodeToken(token)
+	if err != nil {
+		return err
+	}
+	if body.Runner == nil || body.Runner.RunnerId == "" || body.Runner.RunnerId == id {
+		return nil
+	}
+
+	return status.Errorf(codes.PermissionDenied,
+		"runner token is not authorized for runner %q", id)
+}
+
+// Create a new runner token for a runner that logged in with a cloud identity.
+// The token is valid for the given duration.
+func (s *service) NewRunnerToken(
+	keyId string,
+	id *runnerauth.Identity,
+	duration time.Duration,
+) (string, *timestamp.Timestamp, error) {
+	var body pb.Token
+	body.Login = true
+	body.User = DefaultUser
+	body.TokenId = make([]byte, 16)
+	body.Runner = &pb.Token_Runner{
+		Identity: id.Name,
+		Pool:     id.Pool,
+	}
+
+	now := time.Now().UTC().Add(duration)
+	body.ValidUntil = &timestamp.Timestamp{
+		Seconds: now.Unix(),
+		Nanos:   int32(now.Nanosecond()),
+	}
+
+	_, err := io.ReadFull(rand.Reader, body.TokenId)
+	if err != nil {
+		return "", nil, err
+	}
+
+	token, err := s.GenerateToken(keyId, nil, &body)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return token, body.ValidUntil, nil
+}
+
+// Verify a runner cloud identity and return a runner token. This is a gRPC
+// wrapper around NewRunnerToken.
+func (s *service) RunnerLogin(ctx context.Context, req *pb.RunnerLoginRequest) (*pb.RunnerLoginResponse, error) {
+	if s.runnerAuth == nil {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"runner login with cloud identities is not enabled on this server")
+	}
+
+	id, err := s.runnerAuth.Verify(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	token, validUntil, err := s.NewRunnerToken(DefaultKeyId, id, s.runnerTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.RunnerLoginResponse{Token: token, ValidUntil: validUntil}, nil
+}
+
+// runnerAuthVerifier returns the runner identity verifier and token TTL
+// for the given config.
+func runnerAuthVerifier(cfg *serverconfig.RunnerAuth) (*runnerauth.Verifier, time.Duration, error) {
+	v := &runnerauth.Verifier{Audience: cfg.Audience}
+	for _, raw := range cfg.AWSIAM {
+		rule, err := runnerauth.ParseRule(raw)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		v.AWS = append(v.AWS, rule)
+	}
+	for _, raw := range cfg.GCP {
+		rule, err := runnerauth.ParseRule(raw)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		v.GCP = append(v.GCP, rule)
+	}
+
+	ttl := cfg.TokenTTL
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+
+	return v, ttl, nil
+}
+
 func (s *service) BootstrapToken(ctx context.Context, req *empty.Empty) (*pb.NewTokenResponse, error) {
 	if !s.state.HMACKeyEmpty() {
 		return nil, status.Errorf(codes.PermissionDenied, "server is already bootstrapped")
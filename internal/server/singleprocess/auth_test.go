@@ -13,9 +13,12 @@ import (
 	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/blake2b"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
+	"github.com/hashicorp/waypoint/internal/runnerauth"
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/singleprocess/state"
 )
 
 func TestServiceAuth(t *testing.T) {
@@ -155,6 +158,210 @@ func TestServiceAuth(t *testing.T) {
 		_, err = s.ExchangeInvite(DefaultKeyId, invite)
 		require.Error(t, err)
 	})
+
+	t.Run("runner token can't manage tokens or the server", func(t *testing.T) {
+		s := impl.(*service)
+
+		token, _, err := s.NewRunnerToken(DefaultKeyId, &runnerauth.Identity{
+			Name: "arn:aws:sts::123456789012:assumed-role/runner/i-1234",
+			Pool: "aws",
+		}, time.Minute)
+		require.NoError(t, err)
+
+		_, body, err := s.DecodeToken(token)
+		require.NoError(t, err)
+		require.NotNil(t, body.Runner)
+		assert.Equal(t, "aws", body.Runner.Pool)
+		assert.NotNil(t, body.ValidUntil)
+
+		{
+			err := s.Authenticate(context.Background(), token, "RunnerJobStream", nil)
+			require.NoError(t, err)
+		}
+
+		for _, endpoint := range []string{
+			"GenerateLoginToken", "CreateAPIKey", "RotateAPIKey", "ListAPIKeys", "DeleteAPIKey",
+			"ExportProject", "ImportProject", "ListSnapshots", "ListRunnerConfigFiles",
+		} {
+			err := s.Authenticate(context.Background(), token, endpoint, nil)
+			require.Error(t, err, endpoint)
+			require.Equal(t, codes.Unauthenticated, status.Code(err))
+		}
+	})
+
+	t.Run("exchange an invite token for a user", func(t *testing.T) {
+		s := impl.(*service)
+
+		invite, err := s.NewUserInviteToken(time.Minute, DefaultKeyId, "alice", []string{"sre"})
+		require.NoError(t, err)
+
+		lt, err := s.ExchangeInvite(DefaultKeyId, invite)
+		require.NoError(t, err)
+
+		_, body, err := s.DecodeToken(lt)
+		require.NoError(t, err)
+		assert.True(t, body.Login)
+		assert.Equal(t, "alice", body.User)
+		assert.Equal(t, []string{"sre"}, body.Roles)
+
+		// Users can approve jobs and manage their keys, but can't invite
+		// other users or manage the server.
+		for _, endpoint := range []string{"ListBuilds", "ApproveJob", "CreateAPIKey"} {
+			require.NoError(t, s.Authenticate(context.Background(), lt, endpoint, nil), endpoint)
+		}
+		for _, endpoint := range []string{"GenerateInviteToken", "GenerateLoginToken", "SetWorkspacePolicy"} {
+			err := s.Authenticate(context.Background(), lt, endpoint, nil)
+			require.Error(t, err, endpoint)
+			require.Equal(t, codes.Unauthenticated, status.Code(err))
+		}
+	})
+
+	t.Run("only users other than the default user have roles", func(t *testing.T) {
+		s := impl.(*service)
+
+		_, err := s.GenerateInviteToken(context.Background(), &pb.InviteTokenRequest{
+			Duration: "1m",
+			Roles:    []string{"sre"},
+		})
+		require.Error(t, err)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("audit identity", func(t *testing.T) {
+		s := impl.(*service)
+
+		token, err := s.NewLoginToken(DefaultKeyId, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "user:"+DefaultUser, s.AuditIdentity(token))
+
+		token, _, err = s.NewRunnerToken(DefaultKeyId, &runnerauth.Identity{
+			Name: "runner@example.iam.gserviceaccount.com",
+		}, time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, "runner:runner@example.iam.gserviceaccount.com", s.AuditIdentity(token))
+
+		assert.Equal(t, "", s.AuditIdentity("nope"))
+		assert.Equal(t, "", s.AuditIdentity(""))
+	})
+}
+
+func TestServiceAuth_apiKeyDenied(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	s := impl.(*service)
+
+	key, err := s.CreateAPIKey(testUserContext(t, s, "alice"), &pb.CreateAPIKeyRequest{})
+	require.NoError(err)
+
+	require.NoError(s.Authenticate(ctx, key.Token, "ApproveJob", nil))
+	for _, endpoint := range []string{"CreateAPIKey", "SetWorkspacePolicy", "GenerateLoginToken"} {
+		err := s.Authenticate(ctx, key.Token, endpoint, nil)
+		require.Error(err, endpoint)
+		require.Equal(codes.Unauthenticated, status.Code(err))
+	}
+}
+
+func TestServiceAuthorizeRequest_entrypoint(t *testing.T) {
+	ctx := context.Background()
+
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(t, err)
+	s := impl.(*service)
+
+	token, err := s.NewLoginToken(DefaultKeyId, nil, &pb.Token_Entrypoint{DeploymentId: "A"})
+	require.NoError(t, err)
+
+	require.NoError(t, s.state.InstanceCreate(&state.Instance{
+		Id: "a1", DeploymentId: "A", Project: "p", Application: "a", Workspace: "default",
+	}))
+	require.NoError(t, s.state.InstanceCreate(&state.Instance{
+		Id: "b1", DeploymentId: "B", Project: "p", Application: "b", Workspace: "default",
+	}))
+
+	execOpen := func(id string) *pb.EntrypointExecRequest {
+		return &pb.EntrypointExecRequest{
+			Event: &pb.EntrypointExecRequest_Open_{
+				Open: &pb.EntrypointExecRequest_Open{InstanceId: id},
+			},
+		}
+	}
+
+	cases := []struct {
+		Name    string
+		Req     interface{}
+		Allowed bool
+	}{
+		{"own config", &pb.EntrypointConfigRequest{DeploymentId: "A", InstanceId: "a2"}, true},
+		{"own registered instance", &pb.EntrypointConfigRequest{DeploymentId: "A", InstanceId: "a1"}, true},
+		{"other config", &pb.EntrypointConfigRequest{DeploymentId: "B", InstanceId: "a2"}, false},
+		{"other instance id", &pb.EntrypointConfigRequest{DeploymentId: "A", InstanceId: "b1"}, false},
+		{"own logs", &pb.EntrypointLogBatch{InstanceId: "a1"}, true},
+		{"other logs", &pb.EntrypointLogBatch{InstanceId: "b1"}, false},
+		{"unknown instance logs", &pb.EntrypointLogBatch{InstanceId: "nope"}, false},
+		{"own exec", execOpen("a1"), true},
+		{"other exec", execOpen("b1"), false},
+		{"exec output", &pb.EntrypointExecRequest{
+			Event: &pb.EntrypointExecRequest_Output_{
+				Output: &pb.EntrypointExecRequest_Output{},
+			},
+		}, true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := s.AuthorizeRequest(ctx, token, "", tt.Req)
+			if tt.Allowed {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			require.Equal(t, codes.PermissionDenied, status.Code(err))
+		})
+	}
+
+	t.Run("other tokens are not scoped", func(t *testing.T) {
+		token, err := s.NewLoginToken(DefaultKeyId, nil, nil)
+		require.NoError(t, err)
+		require.NoError(t, s.AuthorizeRequest(ctx, token, "", &pb.EntrypointLogBatch{InstanceId: "b1"}))
+	})
+
+	t.Run("only entrypoint endpoints", func(t *testing.T) {
+		for _, endpoint := range []string{"ListInstances", "GetConfig", "QueueJob", "EntrypointUnknown"} {
+			err := s.Authenticate(ctx, token, endpoint, nil)
+			require.Error(t, err, endpoint)
+		}
+	})
+
+	t.Run("invite requires a deployment", func(t *testing.T) {
+		_, err := s.GenerateInviteToken(ctx, &pb.InviteTokenRequest{
+			Duration:   "1h",
+			Entrypoint: &pb.Token_Entrypoint{},
+		})
+		require.Error(t, err)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestServiceRunnerLogin(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	// Create our server
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+
+	// Runner login isn't enabled
+	_, err = impl.RunnerLogin(ctx, &pb.RunnerLoginRequest{
+		Identity: &pb.RunnerLoginRequest_Gcp{
+			Gcp: &pb.RunnerLoginRequest_GCP{Jwt: "foo"},
+		},
+	})
+	require.Error(err)
+	require.Equal(codes.FailedPrecondition, status.Code(err))
 }
 
 func TestServiceBootstrapToken(t *testing.T) {
@@ -180,3 +387,79 @@ func TestServiceBootstrapToken(t *testing.T) {
 		require.Nil(resp)
 	}
 }
+
+func TestServiceAuthorizeRequest_runnerPool(t *testing.T) {
+	ctx := context.Background()
+
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(t, err)
+	s := impl.(*service)
+
+	runnerToken, _, err := s.NewRunnerToken(DefaultKeyId, &runnerauth.Identity{
+		Name: "arn:aws:sts::123456789012:assumed-role/runner/i-1234",
+		Pool: "deploy",
+	}, time.Minute)
+	require.NoError(t, err)
+
+	loginToken, err := s.NewLoginToken(DefaultKeyId, nil, nil)
+	require.NoError(t, err)
+
+	open := func(pool string) *pb.RunnerConfigRequest {
+		return &pb.RunnerConfigRequest{
+			Event: &pb.RunnerConfigRequest_Open_{
+				Open: &pb.RunnerConfigRequest_Open{
+					Runner: &pb.Runner{Id: "A", Pool: pool},
+				},
+			},
+		}
+	}
+
+	cases := []struct {
+		Name    string
+		Token   string
+		Req     interface{}
+		Allowed bool
+	}{
+		{"own pool", runnerToken, open("deploy"), true},
+		{"no pool", runnerToken, open(""), true},
+		{"other pool", runnerToken, open("build"), false},
+		{"login token", loginToken, open("build"), true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := s.AuthorizeRequest(ctx, tt.Token, "", tt.Req)
+			if tt.Allowed {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			require.Equal(t, codes.PermissionDenied, status.Code(err))
+		})
+	}
+}
+
+// testUserToken returns a login token for the user with the given roles.
+// The default user has no roles.
+func testUserToken(t *testing.T, s *service, user string, roles ...string) string {
+	if user == DefaultUser {
+		token, err := s.NewLoginToken(DefaultKeyId, nil, nil)
+		require.NoError(t, err)
+		return token
+	}
+
+	invite, err := s.NewUserInviteToken(time.Minute, DefaultKeyId, user, roles)
+	require.NoError(t, err)
+	token, err := s.ExchangeInvite(DefaultKeyId, invite)
+	require.NoError(t, err)
+	return token
+}
+
+// testUserContext returns a context for calling the service directly as
+// the user with the given roles.
+func testUserContext(t *testing.T, s *service, user string, roles ...string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"authorization", testUserToken(t, s, user, roles...),
+	))
+}
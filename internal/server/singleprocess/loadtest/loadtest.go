@@ -0,0 +1,381 @@
+// Package loadtest runs synthetic job and runner churn against a temporary
+// state store to measure the throughput and latency of job assignment.
+//
+// This is used by "waypoint server load-test" to help with capacity
+// planning. It exercises the same state APIs that the server uses for
+// the runner job stream, but without any network or gRPC overhead, so
+// the results are an upper bound on what the scheduler can do.
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hashicorp/go-hclog"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/singleprocess/state"
+)
+
+// Config configures a load test run.
+type Config struct {
+	// Runners is the number of simulated runners.
+	Runners int
+
+	// Jobs is the total number of jobs to queue.
+	Jobs int
+
+	// Rate is the number of jobs queued per second. If this is zero then
+	// jobs are queued as fast as possible.
+	Rate float64
+
+	// JobDuration is how long each simulated job "runs" after it is acked.
+	JobDuration time.Duration
+
+	// RunnerChurn is how long a runner stays registered before it
+	// deregisters and registers again. If this is zero, runners never churn.
+	RunnerChurn time.Duration
+
+	// TargetedPercent is the percentage (0 to 100) of jobs that target
+	// a specific runner by ID rather than any runner.
+	TargetedPercent int
+
+	// Timeout is the maximum time the load test will run. If this is
+	// reached, the test ends and returns the results gathered so far.
+	Timeout time.Duration
+
+	// Log is the logger to use. If this is nil, logs are discarded.
+	Log hclog.Logger
+}
+
+// Result are the results of a load test run.
+type Result struct {
+	// Queued and Completed are the number of jobs queued and completed.
+	Queued    int
+	Completed int
+
+	// RunnerChurn is the number of times a runner was re-registered.
+	RunnerChurn int
+
+	// Duration is the total wall clock time of the test.
+	Duration time.Duration
+
+	// Throughput is the number of completed jobs per second.
+	Throughput float64
+
+	// Latency is the time between a job being queued and being
+	// assigned to a runner.
+	Latency Percentiles
+}
+
+// Percentiles is a summary of a set of durations.
+type Percentiles struct {
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+// Run runs a load test with the given configuration. This blocks until
+// all jobs are complete, the timeout is reached, or ctx is cancelled.
+func Run(ctx context.Context, cfg *Config) (*Result, error) {
+	if cfg.Runners <= 0 {
+		return nil, fmt.Errorf("at least one runner is required")
+	}
+	if cfg.Jobs <= 0 {
+		return nil, fmt.Errorf("at least one job is required")
+	}
+	if cfg.TargetedPercent < 0 || cfg.TargetedPercent > 100 {
+		return nil, fmt.Errorf("targeted percent must be between 0 and 100")
+	}
+
+	log := cfg.Log
+	if log == nil {
+		log = hclog.NewNullLogger()
+	}
+
+	// Create a temporary state store. We never want to run a load test
+	// against a real database.
+	td, err := ioutil.TempDir("", "waypoint-loadtest")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(td)
+
+	db, err := bolt.Open(filepath.Join(td, "data.db"), 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	st, err := state.New(log.Named("state"), db)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lt := &loadTest{
+		cfg:       cfg,
+		log:       log,
+		state:     st,
+		cancel:    cancel,
+		latencies: make([]time.Duration, 0, cfg.Jobs),
+	}
+
+	// Register all our runners up front so that targeted jobs always
+	// have a valid target.
+	runners := make([]*pb.Runner, cfg.Runners)
+	for i := range runners {
+		runners[i] = &pb.Runner{Id: fmt.Sprintf("loadtest-runner-%d", i)}
+		if err := st.RunnerCreate(runners[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, cfg.Runners+1)
+	for _, r := range runners {
+		wg.Add(1)
+		go func(r *pb.Runner) {
+			defer wg.Done()
+			if err := lt.runRunner(ctx, r); err != nil {
+				errCh <- err
+				cancel()
+			}
+		}(r)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := lt.queueJobs(ctx, runners); err != nil {
+			errCh <- err
+			cancel()
+		}
+	}()
+
+	wg.Wait()
+	duration := time.Since(start)
+
+	select {
+	case err := <-errCh:
+		return nil, err
+	default:
+	}
+
+	lt.lock.Lock()
+	defer lt.lock.Unlock()
+
+	result := &Result{
+		Queued:      int(atomic.LoadInt64(&lt.queued)),
+		Completed:   int(atomic.LoadInt64(&lt.completed)),
+		RunnerChurn: int(atomic.LoadInt64(&lt.churn)),
+		Duration:    duration,
+		Latency:     percentiles(lt.latencies),
+	}
+	if secs := duration.Seconds(); secs > 0 {
+		result.Throughput = float64(result.Completed) / secs
+	}
+
+	return result, nil
+}
+
+type loadTest struct {
+	cfg    *Config
+	log    hclog.Logger
+	state  *state.State
+	cancel context.CancelFunc
+
+	queued    int64
+	completed int64
+	churn     int64
+
+	lock      sync.Mutex
+	latencies []time.Duration
+}
+
+// queueJobs queues the configured number of jobs at the configured rate.
+func (lt *loadTest) queueJobs(ctx context.Context, runners []*pb.Runner) error {
+	var tick <-chan time.Time
+	if lt.cfg.Rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / lt.cfg.Rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for i := 0; i < lt.cfg.Jobs; i++ {
+		if tick != nil {
+			select {
+			case <-tick:
+			case <-ctx.Done():
+				return nil
+			}
+		} else if ctx.Err() != nil {
+			return nil
+		}
+
+		target := &pb.Ref_Runner{
+			Target: &pb.Ref_Runner_Any{
+				Any: &pb.Ref_RunnerAny{},
+			},
+		}
+		if i%100 < lt.cfg.TargetedPercent {
+			target.Target = &pb.Ref_Runner_Id{
+				Id: &pb.Ref_RunnerId{
+					Id: runners[i%len(runners)].Id,
+				},
+			}
+		}
+
+		err := lt.state.JobCreate(context.Background(), &pb.Job{
+			Id: fmt.Sprintf("loadtest-job-%d", i),
+			Application: &pb.Ref_Application{
+				Project:     "loadtest",
+				Application: "loadtest",
+			},
+			Workspace: &pb.Ref_Workspace{
+				Workspace: "default",
+			},
+			TargetRunner: target,
+			Operation: &pb.Job_Noop_{
+				Noop: &pb.Job_Noop{},
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		atomic.AddInt64(&lt.queued, 1)
+	}
+
+	return nil
+}
+
+// runRunner simulates a single runner that repeatedly requests, acks,
+// and completes jobs. If runner churn is enabled, the runner periodically
+// deregisters and registers itself again.
+func (lt *loadTest) runRunner(ctx context.Context, r *pb.Runner) error {
+	registered := time.Now()
+	for {
+		assignCtx, assignCancel := ctx, context.CancelFunc(func() {})
+		if lt.cfg.RunnerChurn > 0 {
+			assignCtx, assignCancel = context.WithDeadline(ctx, registered.Add(lt.cfg.RunnerChurn))
+		}
+
+		job, err := lt.state.JobAssignForRunner(assignCtx, r)
+		assignCancel()
+		if err != nil {
+			// If our parent context is done then the test is over.
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			// If only our assignment context is done, it is time to churn.
+			if assignCtx.Err() != nil {
+				if err := lt.reregister(r); err != nil {
+					return err
+				}
+
+				registered = time.Now()
+				continue
+			}
+
+			return err
+		}
+
+		queueTime, err := ptypes.Timestamp(job.QueueTime)
+		if err != nil {
+			return err
+		}
+		lt.lock.Lock()
+		lt.latencies = append(lt.latencies, time.Since(queueTime))
+		lt.lock.Unlock()
+
+		if _, err := lt.state.JobAck(context.Background(), job.Id, true); err != nil {
+			return err
+		}
+
+		if lt.cfg.JobDuration > 0 {
+			select {
+			case <-time.After(lt.cfg.JobDuration):
+			case <-ctx.Done():
+			}
+		}
+
+		if err := lt.state.JobComplete(context.Background(), job.Id, nil, nil); err != nil {
+			return err
+		}
+
+		// If we completed the last job, the test is done.
+		if atomic.AddInt64(&lt.completed, 1) >= int64(lt.cfg.Jobs) {
+			lt.cancel()
+			return nil
+		}
+
+		if lt.cfg.RunnerChurn > 0 && time.Since(registered) >= lt.cfg.RunnerChurn {
+			if err := lt.reregister(r); err != nil {
+				return err
+			}
+
+			registered = time.Now()
+		}
+	}
+}
+
+// reregister deregisters and registers the runner again.
+func (lt *loadTest) reregister(r *pb.Runner) error {
+	lt.log.Trace("churning runner", "runner", r.Id)
+	if err := lt.state.RunnerDelete(r.Id); err != nil {
+		return err
+	}
+	if err := lt.state.RunnerCreate(r); err != nil {
+		return err
+	}
+
+	atomic.AddInt64(&lt.churn, 1)
+	return nil
+}
+
+// percentiles calculates the percentiles for the given durations. The
+// slice is sorted in place.
+func percentiles(ds []time.Duration) Percentiles {
+	if len(ds) == 0 {
+		return Percentiles{}
+	}
+
+	sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+	at := func(p float64) time.Duration {
+		idx := int(math.Ceil(p*float64(len(ds)))) - 1
+		if idx < 0 {
+			idx = 0
+		}
+
+		return ds[idx]
+	}
+
+	return Percentiles{
+		P50: at(0.50),
+		P90: at(0.90),
+		P99: at(0.99),
+		Max: ds[len(ds)-1],
+	}
+}
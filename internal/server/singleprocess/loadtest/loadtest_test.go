@@ -0,0 +1,64 @@
+package loadtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	t.Run("completes all jobs", func(t *testing.T) {
+		require := require.New(t)
+
+		result, err := Run(context.Background(), &Config{
+			Runners:         4,
+			Jobs:            50,
+			TargetedPercent: 20,
+			Timeout:         30 * time.Second,
+		})
+		require.NoError(err)
+		require.Equal(50, result.Queued)
+		require.Equal(50, result.Completed)
+		require.True(result.Throughput > 0)
+		require.True(result.Latency.P50 <= result.Latency.P99)
+		require.True(result.Latency.P99 <= result.Latency.Max)
+	})
+
+	t.Run("runner churn", func(t *testing.T) {
+		require := require.New(t)
+
+		result, err := Run(context.Background(), &Config{
+			Runners:     2,
+			Jobs:        20,
+			Rate:        200,
+			RunnerChurn: 10 * time.Millisecond,
+			Timeout:     30 * time.Second,
+		})
+		require.NoError(err)
+		require.Equal(20, result.Completed)
+		require.True(result.RunnerChurn > 0)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := Run(context.Background(), &Config{Jobs: 1})
+		require.Error(t, err)
+	})
+}
+
+func TestPercentiles(t *testing.T) {
+	require := require.New(t)
+
+	var ds []time.Duration
+	for i := 100; i > 0; i-- {
+		ds = append(ds, time.Duration(i)*time.Millisecond)
+	}
+
+	p := percentiles(ds)
+	require.Equal(50*time.Millisecond, p.P50)
+	require.Equal(90*time.Millisecond, p.P90)
+	require.Equal(99*time.Millisecond, p.P99)
+	require.Equal(100*time.Millisecond, p.Max)
+	require.Equal(Percentiles{}, percentiles(nil))
+}
@@ -1,7 +1,13 @@
 package singleprocess
 
 import (
+	"context"
+	"crypto/ed25519"
 	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"time"
 
 	"github.com/boltdb/bolt"
 
@@ -11,10 +17,19 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 
+	"github.com/hashicorp/waypoint/internal/backup"
+	"github.com/hashicorp/waypoint/internal/commitstatus"
+	"github.com/hashicorp/waypoint/internal/dispatch"
+	"github.com/hashicorp/waypoint/internal/notify"
+	"github.com/hashicorp/waypoint/internal/pkg/cron"
+	"github.com/hashicorp/waypoint/internal/protocolversion"
+	"github.com/hashicorp/waypoint/internal/runnerauth"
 	"github.com/hashicorp/waypoint/internal/server"
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
 	"github.com/hashicorp/waypoint/internal/server/singleprocess/state"
 	"github.com/hashicorp/waypoint/internal/serverconfig"
+	"github.com/hashicorp/waypoint/internal/telemetry"
+	"github.com/hashicorp/waypoint/internal/tfoutputs"
 )
 
 //go:generate sh -c "protoc -I proto/ proto/*.proto --go_out=plugins=grpc:gen/"
@@ -28,6 +43,63 @@ type service struct {
 	// id is our unique server ID.
 	id string
 
+	// jobSigningKey signs job assignments sent to runners.
+	jobSigningKey ed25519.PrivateKey
+
+	// runnerAuth is not nil if runners can login with cloud identities.
+	// Runner tokens are valid for runnerTokenTTL.
+	runnerAuth     *runnerauth.Verifier
+	runnerTokenTTL time.Duration
+
+	// telemetry collects anonymous usage telemetry. This is always set but
+	// the telemetry is only sent if telemetryConfig is not nil.
+	telemetry       *telemetry.Collector
+	telemetryConfig *serverconfig.Telemetry
+
+	// tfOutputs resolves the Terraform outputs of projects as job variables.
+	tfOutputs tfoutputs.Resolver
+
+	// notifier sends the chat notifications configured for projects.
+	notifier notify.Notifier
+
+	// commitStatus reports job statuses for Git commits. This is nil if
+	// commit statuses aren't configured.
+	commitStatus *commitstatus.Reporter
+
+	// scheduler is not nil if jobs are dispatched to an external scheduler
+	// rather than assigned to registered runners. dispatchLock is held
+	// while submitting jobs to the scheduler.
+	scheduler      dispatch.Scheduler
+	dispatchConfig *serverconfig.Dispatch
+	dispatchLock   sync.Mutex
+
+	// federationServers is not nil if jobs can be forwarded to downstream
+	// servers. federationLock is held while forwarding jobs.
+	federationServers map[string]*federationServer
+	federationConfig  *serverconfig.Federation
+	federationLock    sync.Mutex
+
+	// snapshotConfig is not nil if snapshots are taken on a schedule.
+	// snapshotNext and snapshotErr are the time of the next snapshot and
+	// the error of the last one, protected by snapshotLock.
+	snapshotConfig   *serverconfig.Snapshot
+	snapshotSchedule *cron.Schedule
+	snapshotLock     sync.Mutex
+	snapshotNext     time.Time
+	snapshotErr      error
+
+	// backupStore is not nil if backups are uploaded to remote storage on
+	// a schedule. backupNext, backupLast, and backupErr are the time of the
+	// next backup and the time and error of the last one, protected by
+	// backupLock.
+	backupStore    backup.Store
+	backupConfig   *serverconfig.Backup
+	backupSchedule *cron.Schedule
+	backupLock     sync.Mutex
+	backupNext     time.Time
+	backupLast     time.Time
+	backupErr      error
+
 	// urlConfig is not nil if the URL service is enabled. This is guaranteed
 	// to have the configs set.
 	urlConfig *serverconfig.URL
@@ -50,13 +122,141 @@ func New(opts ...Option) (pb.WaypointServer, error) {
 		log = hclog.L()
 	}
 
-	// Initialize our state
-	st, err := state.New(log, cfg.db)
+	ctx := cfg.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// Initialize our state. If it is replicated, this waits until we're
+	// the leader.
+	var st *state.State
+	var err error
+	if scfg := cfg.serverConfig; scfg != nil && scfg.Raft != nil && scfg.Raft.ID != "" {
+		st, err = raftStateInit(ctx, log, cfg.db, scfg.Raft, cfg.raftLeaderLost, cfg.raftFollower)
+	} else {
+		st, err = state.New(log, cfg.db)
+	}
 	if err != nil {
 		return nil, err
 	}
 	s.state = st
 
+	// Report commit statuses if configured.
+	if scfg := cfg.serverConfig; scfg != nil && scfg.CommitStatus != nil {
+		s.commitStatus, err = commitStatusReporter(scfg.CommitStatus)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Send notifications and report commit statuses for completed jobs.
+	st.JobCompleteHookSet(func(job *pb.Job) {
+		s.notifyJobComplete(log.Named("notify"), job)
+		s.commitStatusJob(log.Named("commit_status"), job)
+	})
+
+	// Limit the memory used by job output if configured.
+	if scfg := cfg.serverConfig; scfg != nil && scfg.JobOutputMaxBytes > 0 {
+		st.JobOutputLimitSet(scfg.JobOutputMaxBytes)
+	}
+
+	// Limit the memory used by the output of each job if configured.
+	if scfg := cfg.serverConfig; scfg != nil && scfg.JobOutputBufferMaxBytes > 0 {
+		st.JobOutputBufferLimitSet(scfg.JobOutputBufferMaxBytes, scfg.JobOutputBufferBlock)
+	}
+
+	// Compress buffered job output if configured.
+	if scfg := cfg.serverConfig; scfg != nil && scfg.JobOutputCompress {
+		if err := st.JobOutputCompressSet(true); err != nil {
+			return nil, err
+		}
+	}
+
+	// Limit the times a job can be not accepted if configured.
+	if scfg := cfg.serverConfig; scfg != nil && scfg.JobMaxNacks > 0 {
+		st.JobMaxNacksSet(scfg.JobMaxNacks)
+	}
+
+	// Allow higher priority jobs to preempt running jobs if configured.
+	if scfg := cfg.serverConfig; scfg != nil && scfg.JobPreemption {
+		st.JobPreemptionSet(true)
+	}
+
+	// Set the strategy for assigning jobs to runners if configured.
+	if scfg := cfg.serverConfig; scfg != nil && scfg.JobScheduler == "weighted" {
+		groups := scfg.RunnerGroups
+		if groups == nil {
+			groups = &serverconfig.RunnerGroups{}
+		}
+
+		sch, err := state.NewWeightedScheduler(groups.Label, groups.Weights, groups.Wait)
+		if err != nil {
+			return nil, err
+		}
+
+		st.SchedulerSet(sch)
+	} else if scfg != nil && scfg.JobScheduler != "" {
+		sch, err := state.SchedulerByName(scfg.JobScheduler)
+		if err != nil {
+			return nil, err
+		}
+
+		st.SchedulerSet(sch)
+	}
+
+	// Prefer cheap runners if configured.
+	if scfg := cfg.serverConfig; scfg != nil && scfg.JobPreferCheapRunners {
+		st.JobPreferCheapSet(true)
+	}
+
+	// Set the default and maximum expiry of queued jobs if configured.
+	if scfg := cfg.serverConfig; scfg != nil {
+		st.JobExpirySet(scfg.JobDefaultExpiry, scfg.JobMaxExpiry)
+	}
+
+	// Set the default job timeouts if configured.
+	if scfg := cfg.serverConfig; scfg != nil && scfg.JobTimeouts != nil {
+		t := scfg.JobTimeouts
+		if err := st.JobTimeoutsSet(t.Ack, t.Heartbeat, t.MaxRun); err != nil {
+			return nil, err
+		}
+	}
+
+	// Require runners to be approved if configured.
+	if scfg := cfg.serverConfig; scfg != nil && scfg.RunnerApproval {
+		st.RunnerApprovalSet(true)
+	}
+
+	// Limit the rate jobs are assigned to each runner if configured.
+	if scfg := cfg.serverConfig; scfg != nil && scfg.RunnerAssignLimit > 0 {
+		st.RunnerAssignLimitSet(scfg.RunnerAssignLimit, scfg.RunnerAssignInterval)
+	}
+
+	// Set what happens to the running jobs of disconnected runners.
+	if scfg := cfg.serverConfig; scfg != nil {
+		policy := state.RunnerDisconnectPolicy(scfg.RunnerDisconnectPolicy)
+		if err := st.RunnerDisconnectPolicySet(policy); err != nil {
+			return nil, err
+		}
+	}
+
+	// Set how long runners can go without a heartbeat if configured.
+	if scfg := cfg.serverConfig; scfg != nil {
+		st.RunnerHeartbeatTimeoutSet(scfg.RunnerHeartbeatTimeout)
+	}
+
+	// Setup encryption for sensitive config if configured.
+	if scfg := cfg.serverConfig; scfg != nil && scfg.ConfigEncryption != nil {
+		w, err := configKeyWrapper(scfg.ConfigEncryption)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := st.ConfigEncryptionSet(context.Background(), w); err != nil {
+			return nil, err
+		}
+	}
+
 	// If we don't have a server ID, set that.
 	id, err := st.ServerIdGet()
 	if err != nil {
@@ -74,6 +274,82 @@ func New(opts ...Option) (pb.WaypointServer, error) {
 	}
 	s.id = id
 
+	// Get the key we sign job assignments with. The public key is logged
+	// so that runners can be started with it pinned.
+	s.jobSigningKey, err = st.JobSigningKeyGet()
+	if err != nil {
+		return nil, err
+	}
+	log.Info("job signing key loaded", "public_key", base64.StdEncoding.EncodeToString(
+		s.jobSigningKey.Public().(ed25519.PublicKey)))
+
+	// Setup runner login with cloud identities if it is enabled.
+	if scfg := cfg.serverConfig; scfg != nil && scfg.RunnerAuth != nil {
+		s.runnerAuth, s.runnerTokenTTL, err = runnerAuthVerifier(scfg.RunnerAuth)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Collect telemetry and send it if it is enabled.
+	s.telemetry = telemetry.NewCollector(protocolversion.Current().Version)
+	if scfg := cfg.serverConfig; scfg != nil && scfg.Telemetry != nil && scfg.Telemetry.Enabled {
+		if scfg.Telemetry.Endpoint == "" {
+			return nil, fmt.Errorf("telemetry requires an endpoint to be configured")
+		}
+
+		s.telemetryConfig = scfg.Telemetry
+		sender := &telemetry.Sender{
+			Endpoint:  scfg.Telemetry.Endpoint,
+			Interval:  scfg.Telemetry.Interval,
+			Collector: s.telemetry,
+			Logger:    log.Named("telemetry"),
+		}
+		go sender.Run(context.Background())
+	}
+
+	// Dispatch jobs to an external scheduler if it is enabled.
+	if scfg := cfg.serverConfig; scfg != nil && scfg.Dispatch != nil && scfg.Dispatch.Scheduler != "" {
+		if err := s.dispatchInit(log, scfg.Dispatch, cfg.scheduler); err != nil {
+			return nil, err
+		}
+	}
+
+	// Forward jobs to downstream servers if it is enabled.
+	if scfg := cfg.serverConfig; scfg != nil && scfg.Federation != nil && len(scfg.Federation.Servers) > 0 {
+		if err := s.federationInit(log, scfg.Federation, cfg.federationClients); err != nil {
+			return nil, err
+		}
+	}
+
+	// Take snapshots on a schedule if it is enabled.
+	if scfg := cfg.serverConfig; scfg != nil && scfg.Snapshot != nil && scfg.Snapshot.Schedule != "" {
+		if err := s.snapshotInit(log, scfg.Snapshot); err != nil {
+			return nil, err
+		}
+	}
+
+	// Upload backups on a schedule if it is enabled.
+	if scfg := cfg.serverConfig; scfg != nil && scfg.Backup != nil && scfg.Backup.Store != "" {
+		if err := s.backupInit(log, scfg.Backup, cfg.backupStore); err != nil {
+			return nil, err
+		}
+	}
+
+	// Compact the database in the background if it is enabled.
+	if scfg := cfg.serverConfig; scfg != nil && scfg.Compact != nil && scfg.Compact.Interval > 0 {
+		if err := s.compactInit(log, scfg.Compact); err != nil {
+			return nil, err
+		}
+	}
+
+	// Prune persisted job output in the background if it is enabled.
+	if scfg := cfg.serverConfig; scfg != nil && scfg.JobOutputRetention != nil {
+		if err := s.jobOutputPruneInit(log, scfg.JobOutputRetention); err != nil {
+			return nil, err
+		}
+	}
+
 	// Setup our URL service config if it is enabled.
 	if scfg := cfg.serverConfig; scfg != nil && scfg.URL != nil && scfg.URL.Enabled {
 		// Set our config
@@ -129,6 +405,14 @@ type config struct {
 	db           *bolt.DB
 	serverConfig *serverconfig.Config
 	log          hclog.Logger
+	scheduler    dispatch.Scheduler
+	backupStore  backup.Store
+
+	federationClients map[string]pb.WaypointClient
+
+	ctx            context.Context
+	raftLeaderLost func()
+	raftFollower   func(pb.WaypointServer)
 
 	acceptUrlTerms bool
 }
@@ -159,6 +443,68 @@ func WithLogger(log hclog.Logger) Option {
 	}
 }
 
+// WithDispatchScheduler sets the scheduler that jobs are dispatched to if
+// dispatch is enabled in the server config.
+func WithDispatchScheduler(d dispatch.Scheduler) Option {
+	return func(s *service, cfg *config) error {
+		cfg.scheduler = d
+		return nil
+	}
+}
+
+// WithBackupStore sets the store that backups are uploaded to if backups
+// are enabled in the server config.
+func WithBackupStore(b backup.Store) Option {
+	return func(s *service, cfg *config) error {
+		cfg.backupStore = b
+		return nil
+	}
+}
+
+// WithFederationClient sets the client used for the downstream server
+// with the given name if federation is enabled in the server config,
+// rather than connecting to its address.
+func WithFederationClient(name string, client pb.WaypointClient) Option {
+	return func(s *service, cfg *config) error {
+		if cfg.federationClients == nil {
+			cfg.federationClients = map[string]pb.WaypointClient{}
+		}
+
+		cfg.federationClients[name] = client
+		return nil
+	}
+}
+
+// WithContext sets the context used while the server starts. If the state
+// is replicated, cancelling this stops waiting to become the leader.
+func WithContext(ctx context.Context) Option {
+	return func(s *service, cfg *config) error {
+		cfg.ctx = ctx
+		return nil
+	}
+}
+
+// WithRaftLeaderLost sets the function called if the state is replicated
+// and this server loses leadership. The server should stop serving since
+// writes fail once this happens.
+func WithRaftLeaderLost(f func()) Option {
+	return func(s *service, cfg *config) error {
+		cfg.raftLeaderLost = f
+		return nil
+	}
+}
+
+// WithRaftFollower sets the function called with a service that serves
+// reads if the state is replicated and this server is a follower. The
+// service implements server.AuthChecker and can serve the reads until New
+// returns, once this server is the leader. The function must not block.
+func WithRaftFollower(f func(pb.WaypointServer)) Option {
+	return func(s *service, cfg *config) error {
+		cfg.raftFollower = f
+		return nil
+	}
+}
+
 func WithAcceptURLTerms(accept bool) Option {
 	return func(s *service, cfg *config) error {
 		cfg.acceptUrlTerms = true
@@ -0,0 +1,189 @@
+package singleprocess
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/server"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func (s *service) CreateAPIKey(
+	ctx context.Context,
+	req *pb.CreateAPIKeyRequest,
+) (*pb.APIKeyTokenResponse, error) {
+	// Keys are always for the caller. Otherwise anyone who can create a key
+	// could act as any user.
+	caller, err := s.callerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.User != "" && req.User != caller.User {
+		return nil, status.Errorf(codes.PermissionDenied,
+			"API keys can only be created for the calling user %q", caller.User)
+	}
+
+	// Keys have the roles of the user unless the default user sets them.
+	roles := caller.Roles
+	if len(req.Roles) > 0 {
+		if !tokenIsAdmin(caller) {
+			return nil, status.Errorf(codes.PermissionDenied,
+				"only the default user can set the roles of API keys")
+		}
+
+		roles = req.Roles
+	}
+
+	id, err := server.Id()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "uuid generation failed: %s", err)
+	}
+
+	key := &pb.APIKey{
+		Id:        id,
+		User:      caller.User,
+		Name:      req.Name,
+		Roles:     roles,
+		CreatedAt: ptypes.TimestampNow(),
+	}
+
+	return s.apiKeyIssue(key)
+}
+
+func (s *service) RotateAPIKey(
+	ctx context.Context,
+	req *pb.RotateAPIKeyRequest,
+) (*pb.APIKeyTokenResponse, error) {
+	key, err := s.apiKeyGetForCaller(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Copy since we modify it.
+	key = proto.Clone(key).(*pb.APIKey)
+	return s.apiKeyIssue(key)
+}
+
+func (s *service) ListAPIKeys(
+	ctx context.Context,
+	req *pb.ListAPIKeysRequest,
+) (*pb.ListAPIKeysResponse, error) {
+	// Users other than the default user can only list their own keys.
+	caller, err := s.callerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user := req.User
+	if !tokenIsAdmin(caller) {
+		if user != "" && user != caller.User {
+			return nil, status.Errorf(codes.PermissionDenied,
+				"only the keys of the calling user %q can be listed", caller.User)
+		}
+
+		user = caller.User
+	}
+
+	keys, err := s.state.APIKeyList(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ListAPIKeysResponse{ApiKeys: keys}, nil
+}
+
+func (s *service) DeleteAPIKey(
+	ctx context.Context,
+	req *pb.DeleteAPIKeyRequest,
+) (*empty.Empty, error) {
+	if _, err := s.apiKeyGetForCaller(ctx, req.Id); err != nil {
+		return nil, err
+	}
+
+	if err := s.state.APIKeyDelete(req.Id); err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
+
+// apiKeyGetForCaller returns the key with the given ID if the caller may
+// manage it. Users other than the default user can only manage their own
+// keys.
+func (s *service) apiKeyGetForCaller(ctx context.Context, id string) (*pb.APIKey, error) {
+	caller, err := s.callerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.state.APIKeyGet(id)
+	if err != nil {
+		return nil, err
+	}
+	if !tokenIsAdmin(caller) && key.User != caller.User {
+		return nil, status.Errorf(codes.PermissionDenied,
+			"API key %q belongs to another user", id)
+	}
+
+	return key, nil
+}
+
+// apiKeyIssue generates a new token for the key and stores the key with
+// the new token ID. Any previous token for the key is no longer valid.
+func (s *service) apiKeyIssue(key *pb.APIKey) (*pb.APIKeyTokenResponse, error) {
+	var body pb.Token
+	body.Login = true
+	body.User = key.User
+	body.ApiKeyId = key.Id
+	body.TokenId = make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, body.TokenId); err != nil {
+		return nil, err
+	}
+
+	token, err := s.GenerateToken(DefaultKeyId, nil, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	key.TokenId = body.TokenId
+	key.RotatedAt = ptypes.TimestampNow()
+	if err := s.state.APIKeyPut(key); err != nil {
+		return nil, err
+	}
+
+	// Get it again so we return the usage as well.
+	key, err = s.state.APIKeyGet(key.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.APIKeyTokenResponse{ApiKey: key, Token: token}, nil
+}
+
+// authenticateAPIKey verifies that the token for an API key is the current
+// token for the key and records the usage of the key.
+func (s *service) authenticateAPIKey(body *pb.Token) error {
+	key, err := s.state.APIKeyGet(body.ApiKeyId)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return ErrInvalidToken
+		}
+
+		return err
+	}
+
+	// The token must be the current token for the key. If the key was
+	// rotated, older tokens are no longer valid.
+	if !bytes.Equal(key.TokenId, body.TokenId) || key.User != body.User {
+		return ErrInvalidToken
+	}
+
+	return s.state.APIKeyUsed(key.Id)
+}
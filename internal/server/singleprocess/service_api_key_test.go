@@ -0,0 +1,133 @@
+package singleprocess
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/server"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func TestServiceAPIKey(t *testing.T) {
+	ctx := context.Background()
+
+	// Create our server
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(t, err)
+	client := server.TestServer(t, impl)
+	s := impl.(*service)
+
+	// asUser returns a context for calling the server as the user.
+	asUser := func(user string, roles ...string) context.Context {
+		return metadata.AppendToOutgoingContext(ctx,
+			"authorization", testUserToken(t, s, user, roles...))
+	}
+	admin := asUser(DefaultUser)
+
+	t.Run("create, rotate, and delete", func(t *testing.T) {
+		require := require.New(t)
+		ctx := asUser("alice")
+
+		// Create
+		resp, err := client.CreateAPIKey(ctx, &pb.CreateAPIKeyRequest{
+			Name: "ci",
+		})
+		require.NoError(err)
+		require.NotEmpty(resp.Token)
+		require.Equal("alice", resp.ApiKey.User)
+		key := resp.ApiKey
+
+		// The token should work and be accounted for
+		require.NoError(s.Authenticate(ctx, resp.Token, "ListBuilds", nil))
+		require.NoError(s.Authenticate(ctx, resp.Token, "ListBuilds", nil))
+		{
+			resp, err := client.ListAPIKeys(ctx, &pb.ListAPIKeysRequest{User: "alice"})
+			require.NoError(err)
+			require.Len(resp.ApiKeys, 1)
+			require.NotNil(resp.ApiKeys[0].Usage)
+			require.NotNil(resp.ApiKeys[0].Usage.LastUsedAt)
+			require.Equal(uint64(2), resp.ApiKeys[0].Usage.CallCount)
+		}
+
+		// Rotate, the old token should no longer work
+		rotated, err := client.RotateAPIKey(ctx, &pb.RotateAPIKeyRequest{Id: key.Id})
+		require.NoError(err)
+		require.NotEqual(resp.Token, rotated.Token)
+		require.Equal(uint64(2), rotated.ApiKey.Usage.CallCount)
+		require.Error(s.Authenticate(ctx, resp.Token, "ListBuilds", nil))
+		require.NoError(s.Authenticate(ctx, rotated.Token, "ListBuilds", nil))
+
+		// Delete, the token should no longer work
+		_, err = client.DeleteAPIKey(ctx, &pb.DeleteAPIKeyRequest{Id: key.Id})
+		require.NoError(err)
+		require.Error(s.Authenticate(ctx, rotated.Token, "ListBuilds", nil))
+	})
+
+	t.Run("is for the calling user", func(t *testing.T) {
+		require := require.New(t)
+
+		resp, err := client.CreateAPIKey(admin, &pb.CreateAPIKeyRequest{Name: "deploys"})
+		require.NoError(err)
+		require.Equal(DefaultUser, resp.ApiKey.User)
+
+		// Keys can't be created for other users
+		_, err = client.CreateAPIKey(admin, &pb.CreateAPIKeyRequest{User: "alice"})
+		require.Error(err)
+		require.Equal(codes.PermissionDenied, status.Code(err))
+
+		// A token is required
+		_, err = client.CreateAPIKey(ctx, &pb.CreateAPIKeyRequest{})
+		require.Error(err)
+		require.Equal(codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("only the default user sets roles", func(t *testing.T) {
+		require := require.New(t)
+
+		resp, err := client.CreateAPIKey(admin, &pb.CreateAPIKeyRequest{Roles: []string{"sre"}})
+		require.NoError(err)
+		require.Equal([]string{"sre"}, resp.ApiKey.Roles)
+
+		// Other users have the roles they were invited with
+		bob := asUser("bob", "deployer")
+		resp, err = client.CreateAPIKey(bob, &pb.CreateAPIKeyRequest{})
+		require.NoError(err)
+		require.Equal("bob", resp.ApiKey.User)
+		require.Equal([]string{"deployer"}, resp.ApiKey.Roles)
+
+		_, err = client.CreateAPIKey(bob, &pb.CreateAPIKeyRequest{Roles: []string{"sre"}})
+		require.Error(err)
+		require.Equal(codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("users only manage their own keys", func(t *testing.T) {
+		require := require.New(t)
+
+		other, err := client.CreateAPIKey(asUser("carol"), &pb.CreateAPIKeyRequest{})
+		require.NoError(err)
+
+		dave := asUser("dave")
+		_, err = client.RotateAPIKey(dave, &pb.RotateAPIKeyRequest{Id: other.ApiKey.Id})
+		require.Equal(codes.PermissionDenied, status.Code(err))
+		_, err = client.DeleteAPIKey(dave, &pb.DeleteAPIKeyRequest{Id: other.ApiKey.Id})
+		require.Equal(codes.PermissionDenied, status.Code(err))
+		_, err = client.ListAPIKeys(dave, &pb.ListAPIKeysRequest{User: "carol"})
+		require.Equal(codes.PermissionDenied, status.Code(err))
+
+		list, err := client.ListAPIKeys(dave, &pb.ListAPIKeysRequest{})
+		require.NoError(err)
+		require.Empty(list.ApiKeys)
+
+		// The default user manages every key
+		list, err = client.ListAPIKeys(admin, &pb.ListAPIKeysRequest{User: "carol"})
+		require.NoError(err)
+		require.Len(list.ApiKeys, 1)
+		_, err = client.DeleteAPIKey(admin, &pb.DeleteAPIKeyRequest{Id: other.ApiKey.Id})
+		require.NoError(err)
+	})
+}
@@ -0,0 +1,230 @@
+package singleprocess
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/backup"
+	"github.com/hashicorp/waypoint/internal/pkg/cron"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/serverconfig"
+)
+
+// backupTimeout is the longest a single backup can take to upload.
+const backupTimeout = 1 * time.Hour
+
+func (s *service) GetBackupStatus(
+	ctx context.Context,
+	req *empty.Empty,
+) (*pb.GetBackupStatusResponse, error) {
+	if s.backupStore == nil {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"backups are not configured")
+	}
+
+	backups, err := s.backupList(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal,
+			"failed to list backups: %s", err)
+	}
+
+	s.backupLock.Lock()
+	next, last, lastErr := s.backupNext, s.backupLast, s.backupErr
+	s.backupLock.Unlock()
+
+	result := &pb.GetBackupStatusResponse{
+		Store:   s.backupStore.Name(),
+		Bucket:  s.backupConfig.Bucket,
+		Backups: backups,
+	}
+	if !next.IsZero() {
+		result.NextTime, _ = ptypes.TimestampProto(next)
+	}
+	if !last.IsZero() {
+		result.LastTime, _ = ptypes.TimestampProto(last)
+	}
+	if lastErr != nil {
+		result.LastError = lastErr.Error()
+	}
+
+	return result, nil
+}
+
+// backupInit validates the backup configuration and starts uploading
+// backups on its schedule.
+func (s *service) backupInit(
+	log hclog.Logger,
+	cfg *serverconfig.Backup,
+	store backup.Store,
+) error {
+	if store == nil {
+		return fmt.Errorf("backup store %q is not available", cfg.Store)
+	}
+	schedule, err := cron.Parse(cfg.Schedule)
+	if err != nil {
+		return fmt.Errorf("invalid backup schedule: %s", err)
+	}
+	if cfg.Retain < 0 {
+		return fmt.Errorf("the number of backups to retain can't be negative")
+	}
+
+	s.backupStore = store
+	s.backupConfig = cfg
+	s.backupSchedule = schedule
+	s.backupNext = schedule.Next(time.Now().UTC())
+	go s.backupRun(context.Background(), log.Named("backup"))
+	return nil
+}
+
+// backupRun uploads a backup at each time of the schedule until the
+// context is cancelled.
+func (s *service) backupRun(ctx context.Context, log hclog.Logger) {
+	for {
+		s.backupLock.Lock()
+		next := s.backupNext
+		s.backupLock.Unlock()
+		if next.IsZero() {
+			log.Error("backup schedule has no upcoming times, stopping")
+			return
+		}
+
+		log.Debug("waiting for next backup", "time", next)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+
+		case <-timer.C:
+		}
+
+		err := s.backupTake(ctx, next)
+		if err != nil {
+			log.Error("backup failed", "err", err)
+		} else {
+			log.Info("backup uploaded", "time", next, "store", s.backupStore.Name())
+		}
+
+		s.backupLock.Lock()
+		s.backupErr = err
+		s.backupLast = next
+		s.backupNext = s.backupSchedule.Next(time.Now().UTC())
+		s.backupLock.Unlock()
+	}
+}
+
+// backupTake streams a snapshot for the time t to the store and then
+// deletes the backups that are no longer retained.
+func (s *service) backupTake(ctx context.Context, t time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, backupTimeout)
+	defer cancel()
+
+	// The snapshot is streamed through a pipe so that it is never held in
+	// memory or written to disk. If the snapshot fails, the upload reads
+	// the error and fails so that a partial backup is never stored.
+	pr, pw := io.Pipe()
+	errCh := make(chan error, 1)
+	go func() {
+		err := s.state.CreateSnapshot(pw)
+		pw.CloseWithError(err)
+		errCh <- err
+	}()
+
+	name := s.backupConfig.Prefix + snapshotName(t)
+	err := s.backupStore.Put(ctx, name, pr)
+
+	// If the upload stopped early, this unblocks the snapshot.
+	pr.Close()
+	if serr := <-errCh; err == nil {
+		err = serr
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.backupRotate(ctx)
+}
+
+// backupRotate deletes the oldest backups beyond the number that are
+// retained.
+func (s *service) backupRotate(ctx context.Context) error {
+	retain := s.backupConfig.Retain
+	if retain == 0 {
+		retain = snapshotDefaultRetain
+	}
+
+	backups, err := s.backupList(ctx)
+	if err != nil {
+		return err
+	}
+	if len(backups) <= retain {
+		return nil
+	}
+
+	for _, b := range backups[retain:] {
+		if err := s.backupStore.Delete(ctx, b.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// backupList returns the backups in the store, most recent first. These
+// are read from the store so that backups uploaded by previous servers
+// are listed and rotated too.
+func (s *service) backupList(ctx context.Context) ([]*pb.SnapshotInfo, error) {
+	prefix := s.backupConfig.Prefix
+	objs, err := s.backupStore.List(ctx, prefix+snapshotPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	type snapshot struct {
+		info *pb.SnapshotInfo
+		time time.Time
+	}
+
+	var backups []snapshot
+	for _, obj := range objs {
+		t, ok := snapshotNameTime(strings.TrimPrefix(obj.Name, prefix))
+		if !ok {
+			continue
+		}
+
+		pt, err := ptypes.TimestampProto(t)
+		if err != nil {
+			return nil, err
+		}
+
+		backups = append(backups, snapshot{
+			info: &pb.SnapshotInfo{
+				Name: obj.Name,
+				Time: pt,
+				Size: obj.Size,
+			},
+			time: t,
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].time.After(backups[j].time)
+	})
+
+	result := make([]*pb.SnapshotInfo, len(backups))
+	for i, b := range backups {
+		result[i] = b.info
+	}
+
+	return result, nil
+}
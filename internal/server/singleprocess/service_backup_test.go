@@ -0,0 +1,185 @@
+package singleprocess
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/backup"
+	"github.com/hashicorp/waypoint/internal/server"
+	"github.com/hashicorp/waypoint/internal/serverconfig"
+)
+
+func TestServiceBackup(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("uploads and rotates backups", func(t *testing.T) {
+		require := require.New(t)
+
+		store := &testBackupStore{}
+
+		// An object that isn't a backup is left alone
+		require.NoError(store.Put(ctx, "wp/other.snap", strings.NewReader("hello")))
+
+		impl, err := New(
+			WithDB(testDB(t)),
+			WithConfig(&serverconfig.Config{
+				Backup: &serverconfig.Backup{
+					Store:    "test",
+					Schedule: "@yearly",
+					Bucket:   "backups",
+					Prefix:   "wp/",
+					Retain:   2,
+				},
+			}),
+			WithBackupStore(store),
+		)
+		require.NoError(err)
+		client := server.TestServer(t, impl)
+		s := testServiceImpl(impl)
+
+		start := time.Date(2020, time.October, 14, 0, 0, 0, 0, time.UTC)
+		for i := 0; i < 3; i++ {
+			require.NoError(s.backupTake(ctx, start.Add(time.Duration(i)*time.Hour)))
+		}
+
+		resp, err := client.GetBackupStatus(ctx, &empty.Empty{})
+		require.NoError(err)
+		require.Equal("test", resp.Store)
+		require.Equal("backups", resp.Bucket)
+		require.NotNil(resp.NextTime)
+		require.Nil(resp.LastTime)
+		require.Len(resp.Backups, 2)
+		require.Equal("wp/waypoint-snapshot-20201014T020000Z.snap", resp.Backups[0].Name)
+		require.Equal("wp/waypoint-snapshot-20201014T010000Z.snap", resp.Backups[1].Name)
+		require.True(resp.Backups[0].Size > 0)
+
+		// The backups are valid snapshots
+		data, ok := store.get(resp.Backups[0].Name)
+		require.True(ok)
+		require.NoError(s.state.StageRestoreSnapshot(bytes.NewReader(data)))
+
+		_, ok = store.get("wp/other.snap")
+		require.True(ok)
+	})
+
+	t.Run("failed upload", func(t *testing.T) {
+		require := require.New(t)
+
+		store := &testBackupStore{putErr: fmt.Errorf("upload failed")}
+		impl, err := New(
+			WithDB(testDB(t)),
+			WithConfig(&serverconfig.Config{
+				Backup: &serverconfig.Backup{
+					Store:    "test",
+					Schedule: "@yearly",
+				},
+			}),
+			WithBackupStore(store),
+		)
+		require.NoError(err)
+		s := testServiceImpl(impl)
+
+		err = s.backupTake(ctx, time.Now())
+		require.Error(err)
+		require.Contains(err.Error(), "upload failed")
+
+		objs, err := store.List(ctx, "")
+		require.NoError(err)
+		require.Empty(objs)
+	})
+
+	t.Run("store not available", func(t *testing.T) {
+		_, err := New(
+			WithDB(testDB(t)),
+			WithConfig(&serverconfig.Config{
+				Backup: &serverconfig.Backup{
+					Store:    "test",
+					Schedule: "@yearly",
+				},
+			}),
+		)
+		require.Error(t, err)
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		require := require.New(t)
+
+		impl, err := New(WithDB(testDB(t)))
+		require.NoError(err)
+		client := server.TestServer(t, impl)
+
+		_, err = client.GetBackupStatus(ctx, &empty.Empty{})
+		require.Error(err)
+		require.Equal(codes.FailedPrecondition, status.Code(err))
+	})
+}
+
+// testBackupStore is a backup.Store in memory.
+type testBackupStore struct {
+	lock    sync.Mutex
+	objects map[string][]byte
+	putErr  error
+}
+
+func (s *testBackupStore) Name() string { return "test" }
+
+func (s *testBackupStore) Put(ctx context.Context, name string, r io.Reader) error {
+	// Read a little and then fail, like an interrupted upload
+	if s.putErr != nil {
+		io.CopyN(ioutil.Discard, r, 10)
+		return s.putErr
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.objects == nil {
+		s.objects = map[string][]byte{}
+	}
+	s.objects[name] = data
+	return nil
+}
+
+func (s *testBackupStore) List(ctx context.Context, prefix string) ([]*backup.Object, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var result []*backup.Object
+	for name, data := range s.objects {
+		if strings.HasPrefix(name, prefix) {
+			result = append(result, &backup.Object{Name: name, Size: int64(len(data))})
+		}
+	}
+
+	return result, nil
+}
+
+func (s *testBackupStore) Delete(ctx context.Context, name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.objects, name)
+	return nil
+}
+
+func (s *testBackupStore) get(name string) ([]byte, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	data, ok := s.objects[name]
+	return data, ok
+}
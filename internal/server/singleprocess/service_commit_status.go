@@ -0,0 +1,122 @@
+package singleprocess
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/hashicorp/waypoint/internal/commitstatus"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+	"github.com/hashicorp/waypoint/internal/serverconfig"
+)
+
+// commitStatusTimeout is the maximum time spent reporting a single status.
+const commitStatusTimeout = 30 * time.Second
+
+// commitStatusReporter returns the reporter for the configuration, or nil
+// if no credentials are configured.
+func commitStatusReporter(cfg *serverconfig.CommitStatus) (*commitstatus.Reporter, error) {
+	var r commitstatus.Reporter
+
+	gh := &commitstatus.GitHub{Address: cfg.GitHubAddress, Token: cfg.GitHubToken}
+	if cfg.GitHubAppID != 0 {
+		if cfg.GitHubAppInstallationID == 0 || cfg.GitHubAppKeyFile == "" {
+			return nil, fmt.Errorf(
+				"GitHub App commit status requires an installation ID and key file")
+		}
+
+		data, err := ioutil.ReadFile(cfg.GitHubAppKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading GitHub App key file: %w", err)
+		}
+
+		key, err := commitstatus.ParseGitHubAppKey(data)
+		if err != nil {
+			return nil, err
+		}
+
+		gh.App = &commitstatus.GitHubApp{
+			ID:             cfg.GitHubAppID,
+			InstallationID: cfg.GitHubAppInstallationID,
+			PrivateKey:     key,
+		}
+	}
+	if gh.Token != "" || gh.App != nil {
+		r.Providers = append(r.Providers, gh)
+	}
+
+	if cfg.GitLabToken != "" {
+		r.Providers = append(r.Providers, &commitstatus.GitLab{
+			Address: cfg.GitLabAddress,
+			Token:   cfg.GitLabToken,
+		})
+	}
+
+	if len(r.Providers) == 0 {
+		return nil, nil
+	}
+
+	return &r, nil
+}
+
+// commitStatusJob reports the status of a job for the Git commit it ran.
+// This does nothing for jobs that didn't run from Git or haven't
+// downloaded their data yet. Errors are logged since the status is
+// informational.
+func (s *service) commitStatusJob(log hclog.Logger, job *pb.Job) {
+	if s.commitStatus == nil {
+		return
+	}
+
+	git := job.DataSource.GetGit()
+	commit := job.DataSourceRef.GetGit().GetCommit()
+	if git == nil || commit == "" {
+		return
+	}
+
+	// We only report operations that change the app. Other operations
+	// such as validation aren't interesting for a commit.
+	switch job.Operation.(type) {
+	case *pb.Job_Build, *pb.Job_Push, *pb.Job_Deploy, *pb.Job_Release, *pb.Job_Destroy:
+	default:
+		return
+	}
+
+	op := serverptypes.JobOperation(job)
+	st := &commitstatus.Status{
+		RepoURL: git.Url,
+		Commit:  commit,
+		Name:    fmt.Sprintf("waypoint/%s/%s", job.Application.Application, op),
+	}
+	switch job.State {
+	case pb.Job_SUCCESS:
+		st.State = commitstatus.StateSuccess
+		st.Description = fmt.Sprintf("Waypoint %s succeeded", op)
+
+	case pb.Job_ERROR:
+		st.State = commitstatus.StateFailure
+		st.Description = fmt.Sprintf("Waypoint %s failed: %s", op, job.Error.GetMessage())
+
+	default:
+		st.State = commitstatus.StatePending
+		st.Description = fmt.Sprintf("Waypoint %s is running", op)
+	}
+	if ws := job.Workspace.GetWorkspace(); ws != "" && ws != "default" {
+		st.Name += "/" + ws
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commitStatusTimeout)
+	defer cancel()
+	ok, err := s.commitStatus.Report(ctx, st)
+	if err != nil {
+		log.Warn("error reporting commit status", "job", job.Id, "commit", commit, "err", err)
+		return
+	}
+	if !ok {
+		log.Trace("no commit status provider for repository", "job", job.Id)
+	}
+}
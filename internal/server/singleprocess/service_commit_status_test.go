@@ -0,0 +1,121 @@
+package singleprocess
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint/internal/commitstatus"
+	"github.com/hashicorp/waypoint/internal/server"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+	"github.com/hashicorp/waypoint/internal/serverconfig"
+)
+
+func TestServiceCommitStatus(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	// Fake GitLab that records the states reported
+	stateCh := make(chan map[string]string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		body["path"] = r.URL.EscapedPath()
+		stateCh <- body
+	}))
+	defer srv.Close()
+
+	next := func() map[string]string {
+		select {
+		case body := <-stateCh:
+			return body
+		case <-time.After(5 * time.Second):
+			t.Fatal("no status reported")
+			return nil
+		}
+	}
+
+	// Create our server
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+	s := testServiceImpl(impl)
+	s.commitStatus = &commitstatus.Reporter{
+		Providers: []commitstatus.Provider{
+			&commitstatus.GitLab{Address: srv.URL, Token: "secret"},
+		},
+	}
+	TestApp(t, client, serverptypes.TestJobNew(t, nil).Application)
+
+	resp, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+		Job: serverptypes.TestJobNew(t, &pb.Job{
+			Operation: &pb.Job_Deploy{Deploy: &pb.Job_DeployOp{}},
+			DataSource: &pb.Job_DataSource{
+				Source: &pb.Job_DataSource_Git{
+					Git: &pb.Job_Git{Url: srv.URL + "/acme/web.git"},
+				},
+			},
+		}),
+	})
+	require.NoError(err)
+
+	job, err := s.state.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+	require.NoError(err)
+	require.Equal(resp.JobId, job.Id)
+	_, err = s.state.JobAck(context.Background(), job.Id, true)
+	require.NoError(err)
+
+	// No status is reported until the commit is known
+	s.commitStatusJob(hclog.L(), job.Job)
+	select {
+	case body := <-stateCh:
+		t.Fatalf("unexpected status: %v", body)
+	default:
+	}
+
+	// The runner reports the commit once it's downloaded
+	jobpb, err := s.state.JobUpdateRef(job.Id, &pb.Job_DataSource_Ref{
+		Ref: &pb.Job_DataSource_Ref_Git{
+			Git: &pb.Job_Git_Ref{Commit: "abc123"},
+		},
+	})
+	require.NoError(err)
+	s.commitStatusJob(hclog.L(), jobpb)
+
+	body := next()
+	require.Equal("/api/v4/projects/acme%2Fweb/statuses/abc123", body["path"])
+	require.Equal("running", body["state"])
+	require.Equal("waypoint/a_test/deploy/w_test", body["name"])
+
+	// Completing the job reports the result
+	require.NoError(s.state.JobComplete(context.Background(), job.Id, nil, nil))
+	body = next()
+	require.Equal("success", body["state"])
+}
+
+func TestCommitStatusReporter(t *testing.T) {
+	require := require.New(t)
+
+	// Nothing configured
+	r, err := commitStatusReporter(&serverconfig.CommitStatus{})
+	require.NoError(err)
+	require.Nil(r)
+
+	// Apps require a key
+	_, err = commitStatusReporter(&serverconfig.CommitStatus{GitHubAppID: 1})
+	require.Error(err)
+
+	r, err = commitStatusReporter(&serverconfig.CommitStatus{
+		GitHubToken: "a",
+		GitLabToken: "b",
+	})
+	require.NoError(err)
+	require.Len(r.Providers, 2)
+}
@@ -0,0 +1,107 @@
+package singleprocess
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/serverconfig"
+)
+
+const (
+	// compactDefaultThreshold is the fraction of the database that must
+	// be free to compact it in the background if it isn't configured.
+	compactDefaultThreshold = 0.5
+
+	// compactMinFree is the least free space in bytes that is worth
+	// compacting the database in the background for.
+	compactMinFree = 1024 * 1024 // 1 MB
+)
+
+func (s *service) CompactDatabase(
+	ctx context.Context,
+	req *empty.Empty,
+) (*pb.CompactDatabaseResponse, error) {
+	before, after, err := s.state.Compact()
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+
+		return nil, status.Errorf(codes.Internal,
+			"failed to compact database: %s", err)
+	}
+
+	return &pb.CompactDatabaseResponse{
+		SizeBefore: before.Size,
+		SizeAfter:  after.Size,
+	}, nil
+}
+
+// compactInit validates the compaction configuration and starts checking
+// if the database should be compacted on its interval.
+func (s *service) compactInit(log hclog.Logger, cfg *serverconfig.Compact) error {
+	threshold := cfg.Threshold
+	if threshold == 0 {
+		threshold = compactDefaultThreshold
+	}
+	if threshold < 0 || threshold > 1 {
+		return fmt.Errorf("the compaction threshold must be between 0 and 1")
+	}
+
+	go s.compactRun(context.Background(), log.Named("compact"), cfg.Interval, threshold)
+	return nil
+}
+
+// compactRun compacts the database every interval if at least threshold
+// of it is free until the context is cancelled.
+func (s *service) compactRun(
+	ctx context.Context,
+	log hclog.Logger,
+	interval time.Duration,
+	threshold float64,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+		}
+
+		if _, err := s.compactIfNeeded(log, threshold); err != nil {
+			log.Error("background compaction failed", "err", err)
+		}
+	}
+}
+
+// compactIfNeeded compacts the database if at least threshold of it is
+// free. This returns true if it was compacted.
+func (s *service) compactIfNeeded(log hclog.Logger, threshold float64) (bool, error) {
+	stats, err := s.state.DBStats()
+	if err != nil {
+		return false, err
+	}
+	if stats.Size == 0 ||
+		stats.Free < compactMinFree ||
+		float64(stats.Free)/float64(stats.Size) < threshold {
+		log.Trace("database doesn't need compaction", "size", stats.Size, "free", stats.Free)
+		return false, nil
+	}
+
+	log.Info("compacting database", "size", stats.Size, "free", stats.Free)
+	if _, _, err := s.state.Compact(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
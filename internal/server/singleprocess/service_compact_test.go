@@ -0,0 +1,68 @@
+package singleprocess
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint/internal/server"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/serverconfig"
+)
+
+func TestServiceCompactDatabase(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	impl, err := New(
+		WithDB(testDB(t)),
+		WithConfig(&serverconfig.Config{
+			Compact: &serverconfig.Compact{
+				Interval: time.Hour,
+			},
+		}),
+	)
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+	s := testServiceImpl(impl)
+
+	// Nothing to compact on a new server
+	compacted, err := s.compactIfNeeded(hclog.L(), compactDefaultThreshold)
+	require.NoError(err)
+	require.False(compacted)
+
+	// Write a lot of data and delete it
+	name := func(i int) string {
+		return fmt.Sprintf("p%d-%s", i, strings.Repeat("x", 4096))
+	}
+	for i := 0; i < 500; i++ {
+		_, err := client.UpsertProject(ctx, &pb.UpsertProjectRequest{
+			Project: &pb.Project{Name: name(i)},
+		})
+		require.NoError(err)
+	}
+	for i := 0; i < 500; i++ {
+		require.NoError(s.state.ProjectDelete(&pb.Ref_Project{Project: name(i)}))
+	}
+
+	// The free space is reclaimed
+	compacted, err = s.compactIfNeeded(hclog.L(), compactDefaultThreshold)
+	require.NoError(err)
+	require.True(compacted)
+
+	// Compacting on demand works and the server still works
+	resp, err := client.CompactDatabase(ctx, &empty.Empty{})
+	require.NoError(err)
+	require.True(resp.SizeAfter > 0)
+
+	_, err = client.UpsertProject(ctx, &pb.UpsertProjectRequest{
+		Project: &pb.Project{Name: "A"},
+	})
+	require.NoError(err)
+}
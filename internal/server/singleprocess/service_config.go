@@ -2,8 +2,12 @@ package singleprocess
 
 import (
 	"context"
+	"fmt"
+	"os"
 
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/keywrap"
+	"github.com/hashicorp/waypoint/internal/serverconfig"
 )
 
 func (s *service) SetConfig(
@@ -28,3 +32,37 @@ func (s *service) GetConfig(
 
 	return &pb.ConfigGetResponse{Variables: vars}, nil
 }
+
+// configKeyWrapper returns the key wrapper for the given config.
+func configKeyWrapper(cfg *serverconfig.ConfigEncryption) (keywrap.Wrapper, error) {
+	switch {
+	case cfg.AWSKMSKeyId != "" && cfg.VaultAddress != "":
+		return nil, fmt.Errorf(
+			"config encryption must use only one of AWS KMS or Vault")
+
+	case cfg.AWSKMSKeyId != "":
+		return keywrap.NewAWSKMS(cfg.AWSKMSKeyId)
+
+	case cfg.VaultAddress != "":
+		if cfg.VaultKey == "" {
+			return nil, fmt.Errorf(
+				"config encryption with Vault requires a transit key name")
+		}
+
+		token := cfg.VaultToken
+		if token == "" {
+			token = os.Getenv("VAULT_TOKEN")
+		}
+
+		return &keywrap.VaultTransit{
+			Address: cfg.VaultAddress,
+			Token:   token,
+			Mount:   cfg.VaultMount,
+			Key:     cfg.VaultKey,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf(
+			"config encryption requires AWS KMS or Vault to be configured")
+	}
+}
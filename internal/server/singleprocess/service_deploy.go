@@ -38,6 +38,11 @@ func (s *service) UpsertDeployment(
 		return nil, err
 	}
 
+	// Record the plugin that was used for telemetry.
+	if insert {
+		s.telemetry.Component(result.Component)
+	}
+
 	// This requires: (1) URL service is enabled (2) auto hostname isn't
 	// explicitly set to false in the request and (3) either the server
 	// default is true or we explicitly ask for it.
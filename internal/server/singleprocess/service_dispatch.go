@@ -0,0 +1,320 @@
+package singleprocess
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/dispatch"
+	"github.com/hashicorp/waypoint/internal/runnerauth"
+	"github.com/hashicorp/waypoint/internal/server"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+	"github.com/hashicorp/waypoint/internal/serverconfig"
+)
+
+const (
+	// dispatchDefaultImage is the image run for dispatched jobs if one
+	// isn't configured.
+	dispatchDefaultImage = "hashicorp/waypoint:latest"
+
+	// dispatchRunnerPool is the pool of the runner tokens given to runners
+	// started for dispatched jobs.
+	dispatchRunnerPool = "dispatch"
+)
+
+// The environment variables that configure the server connection of the
+// runners. These match the serverclient package, which we don't import
+// since it depends on the CLI context.
+const (
+	dispatchEnvServerAddr          = "WAYPOINT_SERVER_ADDR"
+	dispatchEnvServerToken         = "WAYPOINT_SERVER_TOKEN"
+	dispatchEnvServerTls           = "WAYPOINT_SERVER_TLS"
+	dispatchEnvServerTlsSkipVerify = "WAYPOINT_SERVER_TLS_SKIP_VERIFY"
+)
+
+// dispatchPrepare targets a job that is being queued at a new runner that
+// will be started for it in the external scheduler. This does nothing if
+// dispatch is disabled, runners are launched on demand instead, or the job
+// targets a specific runner, such as the local runner of the CLI.
+func (s *service) dispatchPrepare(job *pb.Job) error {
+	// This is managed by the server and never accepted from the caller.
+	job.Dispatch = nil
+
+	if s.scheduler == nil || s.dispatchConfig.OnDemand {
+		return nil
+	}
+	if _, ok := job.TargetRunner.Target.(*pb.Ref_Runner_Any); !ok {
+		return nil
+	}
+
+	runnerId, err := server.Id()
+	if err != nil {
+		return status.Errorf(codes.Internal, "uuid generation failed: %s", err)
+	}
+
+	job.TargetRunner = &pb.Ref_Runner{
+		Target: &pb.Ref_Runner_Id{
+			Id: &pb.Ref_RunnerId{Id: runnerId},
+		},
+	}
+	job.Dispatch = &pb.Job_Dispatch{
+		Scheduler: s.scheduler.Name(),
+		RunnerId:  runnerId,
+	}
+
+	return nil
+}
+
+// dispatchSubmit submits a queued job to the external scheduler if it
+// hasn't been already. If the job can't be submitted, the job fails.
+func (s *service) dispatchSubmit(ctx context.Context, id string) error {
+	if s.scheduler == nil {
+		return nil
+	}
+
+	// We lock so that the monitor and the RPCs don't submit a job twice.
+	s.dispatchLock.Lock()
+	defer s.dispatchLock.Unlock()
+
+	job, err := s.state.JobById(id, nil)
+	if err != nil {
+		return err
+	}
+	if job == nil || job.Dispatch == nil || job.Dispatch.Id != "" {
+		return nil
+	}
+
+	// Runners wait for the jobs targeting them, so we don't start one
+	// until the job can run.
+	if job.State != pb.Job_QUEUED || dispatchApprovalPending(job.Job) {
+		return nil
+	}
+
+	taskId, err := s.dispatchTask(ctx, job.Job)
+	if err != nil {
+		err = status.Errorf(codes.Unavailable,
+			"error dispatching job to %s: %s", job.Dispatch.Scheduler, err)
+		if ferr := s.state.JobFail(id, err); ferr != nil {
+			return ferr
+		}
+
+		return err
+	}
+
+	return s.state.JobDispatchSet(id, &pb.Job_Dispatch{
+		Scheduler: job.Dispatch.Scheduler,
+		RunnerId:  job.Dispatch.RunnerId,
+		Id:        taskId,
+		Status:    string(dispatch.StatusPending),
+	})
+}
+
+// dispatchTask submits the runner for the job to the scheduler and returns
+// the ID of the scheduler job.
+func (s *service) dispatchTask(ctx context.Context, job *pb.Job) (string, error) {
+	// Job IDs are ULIDs which are valid DNS labels once lowercased.
+	task := &dispatch.Task{
+		Name:  "waypoint-job-" + strings.ToLower(job.Id),
+		JobId: job.Id,
+		Args: []string{
+			"runner", "agent",
+			"-id=" + job.Dispatch.RunnerId,
+			"-by-id-only",
+			"-one-shot",
+		},
+	}
+
+	// The runner must run on the platform the job requires.
+	if job.Platform != "" {
+		var err error
+		task.OS, task.Arch, err = serverptypes.JobPlatform(job.Platform)
+		if err != nil {
+			return "", status.Errorf(codes.FailedPrecondition, "invalid platform: %s", err)
+		}
+	}
+
+	return s.dispatchRunner(ctx, task, &runnerauth.Identity{
+		Name: "dispatch/" + job.Id,
+		Pool: dispatchRunnerPool,
+	})
+}
+
+// dispatchRunner submits a task that runs a runner to the scheduler and
+// returns the ID of the scheduler job. The image and the environment to
+// connect to the server are set on the task. The runner authenticates as
+// the given identity.
+func (s *service) dispatchRunner(
+	ctx context.Context,
+	task *dispatch.Task,
+	id *runnerauth.Identity,
+) (string, error) {
+	cfg := s.dispatchConfig
+
+	ttl := cfg.TokenTTL
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+
+	// The runner gets a runner token so it can't manage tokens or the
+	// server, and the token expires in case it leaks.
+	token, _, err := s.NewRunnerToken(DefaultKeyId, id, ttl)
+	if err != nil {
+		return "", err
+	}
+
+	// The task may already have an image and environment from a runner
+	// profile. The server settings always take precedence.
+	if task.Image == "" {
+		task.Image = cfg.Image
+	}
+	if task.Image == "" {
+		task.Image = dispatchDefaultImage
+	}
+
+	env := map[string]string{}
+	for k, v := range task.Env {
+		env[k] = v
+	}
+	env[dispatchEnvServerAddr] = cfg.Addr
+	env[dispatchEnvServerToken] = token
+	if !cfg.TLSDisabled {
+		env[dispatchEnvServerTls] = "1"
+	}
+	if cfg.TLSSkipVerify {
+		env[dispatchEnvServerTlsSkipVerify] = "1"
+	}
+
+	task.Env = env
+	return s.scheduler.Submit(ctx, task)
+}
+
+// dispatchMonitor periodically checks the status of dispatched jobs until
+// the context is cancelled. See dispatchPoll.
+func (s *service) dispatchMonitor(ctx context.Context, log hclog.Logger) {
+	interval := s.dispatchConfig.PollInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+		}
+
+		if err := s.dispatchPoll(ctx, log); err != nil {
+			log.Warn("error checking dispatched jobs", "err", err)
+		}
+	}
+}
+
+// dispatchPoll checks the status of all dispatched jobs once. Jobs that
+// haven't been submitted yet, such as jobs that were waiting for approval,
+// are submitted. Jobs whose scheduler job ended before the job completed
+// are failed. Scheduler jobs are stopped once the job completes.
+func (s *service) dispatchPoll(ctx context.Context, log hclog.Logger) error {
+	jobs, err := s.state.JobListDispatched()
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		// Jobs may be shared with the job cache so we never modify them.
+		d := proto.Clone(job.Dispatch).(*pb.Job_Dispatch)
+		log := log.With("job", job.Id, "dispatch_id", d.Id)
+
+		switch job.State {
+		case pb.Job_SUCCESS, pb.Job_ERROR:
+			if d.Id == "" || d.Status == string(dispatch.StatusStopped) {
+				continue
+			}
+
+			if err := s.scheduler.Stop(ctx, d.Id); err != nil {
+				log.Warn("error stopping dispatched job", "err", err)
+				continue
+			}
+
+			d.Status = string(dispatch.StatusStopped)
+			if err := s.state.JobDispatchSet(job.Id, d); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if d.Id == "" {
+			if err := s.dispatchSubmit(ctx, job.Id); err != nil {
+				log.Warn("error dispatching job", "err", err)
+			}
+
+			continue
+		}
+
+		st, err := s.scheduler.Status(ctx, d.Id)
+		if err != nil {
+			log.Warn("error checking dispatched job status", "err", err)
+			continue
+		}
+
+		if string(st) != d.Status {
+			log.Debug("dispatched job status changed", "old", d.Status, "new", st)
+			d.Status = string(st)
+			if err := s.state.JobDispatchSet(job.Id, d); err != nil {
+				return err
+			}
+		}
+
+		// If the scheduler job ended, the runner exited without completing
+		// the job. JobFail does nothing if the job completed since we read it.
+		if st.Done() {
+			err := s.state.JobFail(job.Id, status.Errorf(codes.Aborted,
+				"%s job %q is %s but the job didn't complete",
+				d.Scheduler, d.Id, st))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// dispatchApprovalPending returns true if the job is waiting for approval.
+func dispatchApprovalPending(job *pb.Job) bool {
+	req := job.ApprovalRequired
+	return req != nil && uint32(len(job.Approvals)) < req.Approvals
+}
+
+// dispatchInit validates the dispatch configuration and enables dispatch.
+func (s *service) dispatchInit(
+	log hclog.Logger,
+	cfg *serverconfig.Dispatch,
+	scheduler dispatch.Scheduler,
+) error {
+	if scheduler == nil {
+		return fmt.Errorf("dispatch scheduler %q is not available", cfg.Scheduler)
+	}
+	if cfg.Addr == "" {
+		return fmt.Errorf("dispatch requires the address that runners connect to the server on")
+	}
+
+	s.scheduler = scheduler
+	s.dispatchConfig = cfg
+	if cfg.OnDemand {
+		go s.runnerLaunchMonitor(context.Background(), log.Named("runner-launch"))
+	} else {
+		go s.dispatchMonitor(context.Background(), log.Named("dispatch"))
+	}
+	return nil
+}
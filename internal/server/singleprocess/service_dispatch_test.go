@@ -0,0 +1,230 @@
+package singleprocess
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint/internal/dispatch"
+	"github.com/hashicorp/waypoint/internal/server"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+	"github.com/hashicorp/waypoint/internal/serverconfig"
+)
+
+func TestServiceDispatch(t *testing.T) {
+	ctx := context.Background()
+	log := hclog.L()
+
+	init := func(t *testing.T) (pb.WaypointClient, *service, *testScheduler) {
+		sched := &testScheduler{}
+		impl, err := New(
+			WithDB(testDB(t)),
+			WithDispatchScheduler(sched),
+			WithConfig(&serverconfig.Config{
+				Dispatch: &serverconfig.Dispatch{
+					Scheduler:    "test",
+					Addr:         "waypoint:9701",
+					PollInterval: time.Hour,
+				},
+			}),
+		)
+		require.NoError(t, err)
+		client := server.TestServer(t, impl)
+		TestApp(t, client, serverptypes.TestJobNew(t, nil).Application)
+		return client, testServiceImpl(impl), sched
+	}
+
+	t.Run("requires a scheduler", func(t *testing.T) {
+		_, err := New(
+			WithDB(testDB(t)),
+			WithConfig(&serverconfig.Config{
+				Dispatch: &serverconfig.Dispatch{
+					Scheduler: "nomad",
+					Addr:      "waypoint:9701",
+				},
+			}),
+		)
+		require.Error(t, err)
+	})
+
+	t.Run("queued jobs start a runner", func(t *testing.T) {
+		require := require.New(t)
+		client, s, sched := init(t)
+
+		resp, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+			Job: serverptypes.TestJobNew(t, nil),
+		})
+		require.NoError(err)
+
+		job, err := client.GetJob(ctx, &pb.GetJobRequest{JobId: resp.JobId})
+		require.NoError(err)
+		require.NotNil(job.Dispatch)
+		require.Equal("test", job.Dispatch.Scheduler)
+		require.Equal(string(dispatch.StatusPending), job.Dispatch.Status)
+		require.Equal(job.Dispatch.RunnerId, job.TargetRunner.GetId().Id)
+
+		task := sched.task(job.Dispatch.Id)
+		require.NotNil(task)
+		require.Equal(job.Id, task.JobId)
+		require.Contains(task.Args, "-id="+job.Dispatch.RunnerId)
+		require.Equal("waypoint:9701", task.Env[dispatchEnvServerAddr])
+		require.NotEmpty(task.Env[dispatchEnvServerToken])
+		require.Equal("1", task.Env[dispatchEnvServerTls])
+
+		// Only the runner started for the job can run it
+		sj, err := s.state.JobAssignForRunner(ctx, &pb.Runner{
+			Id:       job.Dispatch.RunnerId,
+			ByIdOnly: true,
+		})
+		require.NoError(err)
+		require.Equal(job.Id, sj.Id)
+		_, err = s.state.JobAck(context.Background(), job.Id, true)
+		require.NoError(err)
+
+		// Status changes are recorded
+		sched.set(job.Dispatch.Id, dispatch.StatusRunning)
+		require.NoError(s.dispatchPoll(ctx, log))
+		job, err = client.GetJob(ctx, &pb.GetJobRequest{JobId: resp.JobId})
+		require.NoError(err)
+		require.Equal(pb.Job_RUNNING, job.State)
+		require.Equal(string(dispatch.StatusRunning), job.Dispatch.Status)
+
+		// Completed jobs stop the scheduler job
+		require.NoError(s.state.JobComplete(context.Background(), job.Id, nil, nil))
+		require.NoError(s.dispatchPoll(ctx, log))
+		require.True(sched.stopped(job.Dispatch.Id))
+		job, err = client.GetJob(ctx, &pb.GetJobRequest{JobId: resp.JobId})
+		require.NoError(err)
+		require.Equal(pb.Job_SUCCESS, job.State)
+		require.Equal(string(dispatch.StatusStopped), job.Dispatch.Status)
+	})
+
+	t.Run("failed scheduler jobs fail the job", func(t *testing.T) {
+		require := require.New(t)
+		client, s, sched := init(t)
+
+		resp, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+			Job: serverptypes.TestJobNew(t, nil),
+		})
+		require.NoError(err)
+		job, err := client.GetJob(ctx, &pb.GetJobRequest{JobId: resp.JobId})
+		require.NoError(err)
+
+		sched.set(job.Dispatch.Id, dispatch.StatusFailed)
+		require.NoError(s.dispatchPoll(ctx, log))
+
+		job, err = client.GetJob(ctx, &pb.GetJobRequest{JobId: resp.JobId})
+		require.NoError(err)
+		require.Equal(pb.Job_ERROR, job.State)
+		require.NotNil(job.Error)
+		require.Equal(string(dispatch.StatusFailed), job.Dispatch.Status)
+	})
+
+	t.Run("submit errors fail the job", func(t *testing.T) {
+		require := require.New(t)
+		client, _, sched := init(t)
+		sched.err = errors.New("no capacity")
+
+		resp, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+			Job: serverptypes.TestJobNew(t, nil),
+		})
+		require.NoError(err)
+
+		job, err := client.GetJob(ctx, &pb.GetJobRequest{JobId: resp.JobId})
+		require.NoError(err)
+		require.Equal(pb.Job_ERROR, job.State)
+		require.Contains(job.Error.Message, "no capacity")
+	})
+
+	t.Run("jobs targeting a runner are not dispatched", func(t *testing.T) {
+		require := require.New(t)
+		client, _, sched := init(t)
+
+		resp, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+			Job: serverptypes.TestJobNew(t, &pb.Job{
+				TargetRunner: &pb.Ref_Runner{
+					Target: &pb.Ref_Runner_Id{
+						Id: &pb.Ref_RunnerId{Id: "local"},
+					},
+				},
+				Dispatch: &pb.Job_Dispatch{Id: "fake"},
+			}),
+		})
+		require.NoError(err)
+
+		job, err := client.GetJob(ctx, &pb.GetJobRequest{JobId: resp.JobId})
+		require.NoError(err)
+		require.Nil(job.Dispatch)
+		require.Empty(sched.tasks)
+	})
+}
+
+// testScheduler is a dispatch.Scheduler that records tasks in memory.
+type testScheduler struct {
+	sync.Mutex
+
+	err      error
+	tasks    map[string]*dispatch.Task
+	statuses map[string]dispatch.Status
+	stops    map[string]bool
+}
+
+func (s *testScheduler) Name() string { return "test" }
+
+func (s *testScheduler) Submit(ctx context.Context, task *dispatch.Task) (string, error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.err != nil {
+		return "", s.err
+	}
+
+	if s.tasks == nil {
+		s.tasks = map[string]*dispatch.Task{}
+		s.statuses = map[string]dispatch.Status{}
+		s.stops = map[string]bool{}
+	}
+	s.tasks[task.Name] = task
+	s.statuses[task.Name] = dispatch.StatusPending
+	return task.Name, nil
+}
+
+func (s *testScheduler) Status(ctx context.Context, id string) (dispatch.Status, error) {
+	s.Lock()
+	defer s.Unlock()
+	if st, ok := s.statuses[id]; ok {
+		return st, nil
+	}
+
+	return dispatch.StatusLost, nil
+}
+
+func (s *testScheduler) Stop(ctx context.Context, id string) error {
+	s.Lock()
+	defer s.Unlock()
+	s.stops[id] = true
+	return nil
+}
+
+func (s *testScheduler) task(id string) *dispatch.Task {
+	s.Lock()
+	defer s.Unlock()
+	return s.tasks[id]
+}
+
+func (s *testScheduler) set(id string, st dispatch.Status) {
+	s.Lock()
+	defer s.Unlock()
+	s.statuses[id] = st
+}
+
+func (s *testScheduler) stopped(id string) bool {
+	s.Lock()
+	defer s.Unlock()
+	return s.stops[id]
+}
@@ -0,0 +1,392 @@
+package singleprocess
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/singleprocess/state"
+)
+
+// doraDefaultPeriod is the period DORA metrics are calculated over if
+// one isn't requested, and for the metrics endpoint.
+const doraDefaultPeriod = 30 * 24 * time.Hour
+
+// GetDORAMetrics implements pb.WaypointServer
+func (s *service) GetDORAMetrics(
+	ctx context.Context,
+	req *pb.GetDORAMetricsRequest,
+) (*pb.GetDORAMetricsResponse, error) {
+	now := time.Now()
+	since := now.Add(-doraDefaultPeriod)
+	if req.Since != nil {
+		var err error
+		since, err = ptypes.Timestamp(req.Since)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid since: %s", err)
+		}
+		if !since.Before(now) {
+			return nil, status.Errorf(codes.InvalidArgument, "since must be in the past")
+		}
+	}
+
+	metrics, err := s.doraMetrics(req.Project, req.Workspace, since, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetDORAMetricsResponse{Metrics: metrics}, nil
+}
+
+// WriteMetrics implements server.MetricsWriter. This writes the DORA
+// metrics for the default period for every project and workspace, and
+// the metrics of the state such as job counts and database latency.
+func (s *service) WriteMetrics(ctx context.Context, w io.Writer) error {
+	metrics, err := s.doraMetrics(nil, nil, time.Now().Add(-doraDefaultPeriod), time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, g := range doraGauges {
+		fmt.Fprintf(w, "# HELP %s %s\n", g.name, g.help)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", g.name)
+		for _, m := range metrics {
+			fmt.Fprintf(w, "%s{project=\"%s\",workspace=\"%s\"} %s\n",
+				g.name,
+				doraLabelEscaper.Replace(m.Project.Project),
+				doraLabelEscaper.Replace(m.Workspace.Workspace),
+				strconv.FormatFloat(g.value(m), 'g', -1, 64),
+			)
+		}
+	}
+
+	return s.state.WriteMetrics(w)
+}
+
+// doraLabelEscaper escapes label values for the Prometheus text format.
+var doraLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// doraGauges are the gauges written to the metrics endpoint.
+var doraGauges = []struct {
+	name  string
+	help  string
+	value func(*pb.DORAMetrics) float64
+}{
+	{
+		"waypoint_dora_deployments",
+		"Successful deployments in the last 30 days.",
+		func(m *pb.DORAMetrics) float64 { return float64(m.Deployments) },
+	},
+	{
+		"waypoint_dora_failed_deployments",
+		"Failed deployments in the last 30 days.",
+		func(m *pb.DORAMetrics) float64 { return float64(m.FailedDeployments) },
+	},
+	{
+		"waypoint_dora_rollbacks",
+		"Releases of an older deployment in the last 30 days.",
+		func(m *pb.DORAMetrics) float64 { return float64(m.Rollbacks) },
+	},
+	{
+		"waypoint_dora_deployment_frequency",
+		"Successful deployments per day over the last 30 days.",
+		func(m *pb.DORAMetrics) float64 { return m.DeploymentFrequency },
+	},
+	{
+		"waypoint_dora_lead_time_seconds",
+		"Median time from commit to successful deployment over the last 30 days.",
+		func(m *pb.DORAMetrics) float64 { return m.LeadTimeSeconds },
+	},
+	{
+		"waypoint_dora_change_failure_rate",
+		"Fraction of deployments that failed or were rolled back over the last 30 days.",
+		func(m *pb.DORAMetrics) float64 { return m.ChangeFailureRate },
+	},
+	{
+		"waypoint_dora_time_to_restore_seconds",
+		"Mean time to restore after a failed deployment or rollback over the last 30 days.",
+		func(m *pb.DORAMetrics) float64 { return m.TimeToRestoreSeconds },
+	},
+}
+
+// doraMetrics calculates the DORA metrics for each project and workspace
+// for the period from since to now. If project or ws is nil then all
+// projects or workspaces are included.
+func (s *service) doraMetrics(
+	project *pb.Ref_Project,
+	ws *pb.Ref_Workspace,
+	since, now time.Time,
+) ([]*pb.DORAMetrics, error) {
+	var projects []*pb.Ref_Project
+	if project != nil {
+		projects = []*pb.Ref_Project{project}
+	} else {
+		var err error
+		projects, err = s.state.ProjectList()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var result []*pb.DORAMetrics
+	for _, ref := range projects {
+		p, err := s.state.ProjectGet(ref)
+		if err != nil {
+			return nil, err
+		}
+
+		accums := map[string]*doraAccum{}
+		for _, app := range p.Applications {
+			appRef := &pb.Ref_Application{
+				Project:     p.Name,
+				Application: app.Name,
+			}
+
+			deploys, err := s.state.DeploymentList(appRef, state.ListWithWorkspace(ws))
+			if err != nil {
+				return nil, err
+			}
+			releases, err := s.state.ReleaseList(appRef, state.ListWithWorkspace(ws))
+			if err != nil {
+				return nil, err
+			}
+
+			// Metrics are per workspace, and rollbacks and restores are
+			// per app within a workspace, so group by workspace.
+			deploysByWs := map[string][]*pb.Deployment{}
+			for _, d := range deploys {
+				deploysByWs[d.Workspace.Workspace] = append(deploysByWs[d.Workspace.Workspace], d)
+			}
+			releasesByWs := map[string][]*pb.Release{}
+			for _, r := range releases {
+				releasesByWs[r.Workspace.Workspace] = append(releasesByWs[r.Workspace.Workspace], r)
+			}
+
+			for name, ds := range deploysByWs {
+				accum, ok := accums[name]
+				if !ok {
+					accum = &doraAccum{}
+					accums[name] = accum
+				}
+
+				accum.add(ds, releasesByWs[name], since, s.doraCommitTime)
+			}
+		}
+
+		for name, accum := range accums {
+			m := accum.metrics(since, now)
+			m.Project = &pb.Ref_Project{Project: p.Name}
+			m.Workspace = &pb.Ref_Workspace{Workspace: name}
+			result = append(result, m)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Project.Project != result[j].Project.Project {
+			return result[i].Project.Project < result[j].Project.Project
+		}
+
+		return result[i].Workspace.Workspace < result[j].Workspace.Workspace
+	})
+
+	return result, nil
+}
+
+// doraCommitTime returns the time of the commit a deployment was made
+// from, or the zero time if it isn't known.
+func (s *service) doraCommitTime(d *pb.Deployment) time.Time {
+	if d.JobId == "" {
+		return time.Time{}
+	}
+
+	job, err := s.state.JobById(d.JobId, nil)
+	if err != nil || job == nil {
+		return time.Time{}
+	}
+
+	t, err := ptypes.Timestamp(job.DataSourceRef.GetGit().GetTimestamp())
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
+
+// doraAccum accumulates the deployments and releases for the DORA metrics
+// of a single project and workspace.
+type doraAccum struct {
+	deployments uint64
+	failed      uint64
+	rollbacks   uint64
+	leadTimes   []time.Duration
+	restores    []time.Duration
+}
+
+// add adds the deployments and releases of a single application. Only
+// events that completed at or after since are counted.
+func (a *doraAccum) add(
+	deploys []*pb.Deployment,
+	releases []*pb.Release,
+	since time.Time,
+	commitTime func(*pb.Deployment) time.Time,
+) {
+	deploys = doraCompleted(deploys)
+	sort.Slice(deploys, func(i, j int) bool {
+		return doraCompleteTime(deploys[i].Status).Before(doraCompleteTime(deploys[j].Status))
+	})
+
+	// Count deployments and track restores after a failed deployment. A
+	// failure is restored by the next successful deployment.
+	var failedAt time.Time
+	seqs := map[string]uint64{}
+	for _, d := range deploys {
+		seqs[d.Id] = d.Sequence
+
+		t := doraCompleteTime(d.Status)
+		if t.Before(since) {
+			// Still track failures so a restore within the period is
+			// counted from when the failure happened.
+			if d.Status.State == pb.Status_ERROR && failedAt.IsZero() {
+				failedAt = t
+			} else if d.Status.State == pb.Status_SUCCESS {
+				failedAt = time.Time{}
+			}
+
+			continue
+		}
+
+		switch d.Status.State {
+		case pb.Status_SUCCESS:
+			a.deployments++
+			if ct := commitTime(d); !ct.IsZero() && t.After(ct) {
+				a.leadTimes = append(a.leadTimes, t.Sub(ct))
+			}
+
+			if !failedAt.IsZero() {
+				a.restores = append(a.restores, t.Sub(failedAt))
+				failedAt = time.Time{}
+			}
+
+		case pb.Status_ERROR:
+			a.failed++
+			if failedAt.IsZero() {
+				failedAt = t
+			}
+		}
+	}
+
+	// A successful release of a deployment older than the deployment
+	// that was previously released is a rollback. The failure is restored
+	// by the rollback, so the time to restore is from the previous release.
+	var prev *pb.Release
+	for _, r := range doraReleased(releases) {
+		seq, ok := seqs[r.DeploymentId]
+		if !ok {
+			continue
+		}
+
+		if prev != nil && seq < seqs[prev.DeploymentId] {
+			t := doraCompleteTime(r.Status)
+			if !t.Before(since) {
+				a.rollbacks++
+				a.restores = append(a.restores, t.Sub(doraCompleteTime(prev.Status)))
+			}
+		}
+
+		prev = r
+	}
+}
+
+// metrics returns the metrics for everything accumulated so far.
+func (a *doraAccum) metrics(since, now time.Time) *pb.DORAMetrics {
+	result := &pb.DORAMetrics{
+		Deployments:       a.deployments,
+		FailedDeployments: a.failed,
+		Rollbacks:         a.rollbacks,
+	}
+	result.Since, _ = ptypes.TimestampProto(since)
+
+	if days := now.Sub(since).Hours() / 24; days > 0 {
+		result.DeploymentFrequency = float64(a.deployments) / days
+	}
+
+	if total := a.deployments + a.failed; total > 0 {
+		result.ChangeFailureRate = float64(a.failed+a.rollbacks) / float64(total)
+		if result.ChangeFailureRate > 1 {
+			result.ChangeFailureRate = 1
+		}
+	}
+
+	if len(a.leadTimes) > 0 {
+		sort.Slice(a.leadTimes, func(i, j int) bool { return a.leadTimes[i] < a.leadTimes[j] })
+		mid := len(a.leadTimes) / 2
+		median := a.leadTimes[mid]
+		if len(a.leadTimes)%2 == 0 {
+			median = (a.leadTimes[mid-1] + median) / 2
+		}
+
+		result.LeadTimeSeconds = median.Seconds()
+	}
+
+	if len(a.restores) > 0 {
+		var total time.Duration
+		for _, d := range a.restores {
+			total += d
+		}
+
+		result.TimeToRestoreSeconds = (total / time.Duration(len(a.restores))).Seconds()
+	}
+
+	return result
+}
+
+// doraCompleted returns the deployments that have completed.
+func doraCompleted(deploys []*pb.Deployment) []*pb.Deployment {
+	var result []*pb.Deployment
+	for _, d := range deploys {
+		if d.Status == nil || d.Status.CompleteTime == nil {
+			continue
+		}
+
+		switch d.Status.State {
+		case pb.Status_SUCCESS, pb.Status_ERROR:
+			result = append(result, d)
+		}
+	}
+
+	return result
+}
+
+// doraReleased returns the successful releases sorted by the time they
+// completed.
+func doraReleased(releases []*pb.Release) []*pb.Release {
+	var result []*pb.Release
+	for _, r := range releases {
+		if r.Status == nil || r.Status.CompleteTime == nil ||
+			r.Status.State != pb.Status_SUCCESS {
+			continue
+		}
+
+		result = append(result, r)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return doraCompleteTime(result[i].Status).Before(doraCompleteTime(result[j].Status))
+	})
+
+	return result
+}
+
+func doraCompleteTime(st *pb.Status) time.Time {
+	t, _ := ptypes.Timestamp(st.CompleteTime)
+	return t
+}
@@ -0,0 +1,136 @@
+package singleprocess
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/server"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestServiceDORAMetrics(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+	s := testServiceImpl(impl)
+
+	now := time.Now()
+	ts := func(d time.Duration) *pb.Status {
+		pt, err := ptypes.TimestampProto(now.Add(-d))
+		require.NoError(err)
+		return &pb.Status{State: pb.Status_SUCCESS, StartTime: pt, CompleteTime: pt}
+	}
+	failed := func(d time.Duration) *pb.Status {
+		st := ts(d)
+		st.State = pb.Status_ERROR
+		return st
+	}
+
+	// Create a job that ran a commit
+	job := func(id string, commit time.Duration) string {
+		pt, err := ptypes.TimestampProto(now.Add(-commit))
+		require.NoError(err)
+		require.NoError(s.state.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id: id,
+			DataSourceRef: &pb.Job_DataSource_Ref{
+				Ref: &pb.Job_DataSource_Ref_Git{
+					Git: &pb.Job_Git_Ref{Commit: "abc", Timestamp: pt},
+				},
+			},
+		})))
+		return id
+	}
+
+	deploy := func(ws string, st *pb.Status, jobId string) *pb.Deployment {
+		resp, err := client.UpsertDeployment(ctx, &pb.UpsertDeploymentRequest{
+			Deployment: serverptypes.TestValidDeployment(t, &pb.Deployment{
+				Workspace: &pb.Ref_Workspace{Workspace: ws},
+				Status:    st,
+				JobId:     jobId,
+			}),
+		})
+		require.NoError(err)
+		return resp.Deployment
+	}
+
+	release := func(d *pb.Deployment, st *pb.Status) {
+		_, err := client.UpsertRelease(ctx, &pb.UpsertReleaseRequest{
+			Release: serverptypes.TestValidRelease(t, &pb.Release{
+				DeploymentId: d.Id,
+				Status:       st,
+			}),
+		})
+		require.NoError(err)
+	}
+
+	// Outside of the period
+	deploy("default", ts(40*24*time.Hour), "")
+
+	// Deployed with a lead time of 2h, then a failure restored after 2h
+	// by a deployment with a lead time of 4h
+	d1 := deploy("default", ts(10*time.Hour), job("j1", 12*time.Hour))
+	deploy("default", failed(8*time.Hour), "")
+	d3 := deploy("default", ts(6*time.Hour), job("j3", 10*time.Hour))
+
+	// Released and then rolled back an hour later
+	release(d3, ts(5*time.Hour))
+	release(d1, ts(4*time.Hour))
+
+	// Another workspace
+	deploy("staging", ts(time.Hour), "")
+
+	resp, err := client.GetDORAMetrics(ctx, &pb.GetDORAMetricsRequest{})
+	require.NoError(err)
+	require.Len(resp.Metrics, 2)
+
+	m := resp.Metrics[0]
+	require.Equal("p_test", m.Project.Project)
+	require.Equal("default", m.Workspace.Workspace)
+	require.Equal(uint64(2), m.Deployments)
+	require.Equal(uint64(1), m.FailedDeployments)
+	require.Equal(uint64(1), m.Rollbacks)
+	require.InDelta(2.0/30, m.DeploymentFrequency, 0.001)
+	require.InDelta(2.0/3, m.ChangeFailureRate, 0.001)
+	require.InDelta((3 * time.Hour).Seconds(), m.LeadTimeSeconds, 1)
+	require.InDelta((90 * time.Minute).Seconds(), m.TimeToRestoreSeconds, 1)
+
+	m = resp.Metrics[1]
+	require.Equal("staging", m.Workspace.Workspace)
+	require.Equal(uint64(1), m.Deployments)
+	require.Zero(m.ChangeFailureRate)
+
+	// Filter by workspace
+	resp, err = client.GetDORAMetrics(ctx, &pb.GetDORAMetricsRequest{
+		Workspace: &pb.Ref_Workspace{Workspace: "staging"},
+	})
+	require.NoError(err)
+	require.Len(resp.Metrics, 1)
+	require.Equal("staging", resp.Metrics[0].Workspace.Workspace)
+
+	// Since must be in the past
+	future, err := ptypes.TimestampProto(now.Add(time.Hour))
+	require.NoError(err)
+	_, err = client.GetDORAMetrics(ctx, &pb.GetDORAMetricsRequest{Since: future})
+	require.Error(err)
+	require.Equal(codes.InvalidArgument, status.Code(err))
+
+	// Metrics endpoint
+	var buf bytes.Buffer
+	require.NoError(s.WriteMetrics(ctx, &buf))
+	require.Contains(buf.String(), "# TYPE waypoint_dora_deployments gauge\n")
+	require.Contains(buf.String(),
+		`waypoint_dora_deployments{project="p_test",workspace="default"} 2`+"\n")
+	require.Contains(buf.String(),
+		`waypoint_dora_rollbacks{project="p_test",workspace="staging"} 0`+"\n")
+}
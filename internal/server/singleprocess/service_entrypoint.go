@@ -97,7 +97,7 @@ func (s *service) EntrypointConfig(
 			})
 		}
 
-		vars, err := s.state.ConfigGetWatch(&pb.ConfigGetRequest{
+		vars, err := s.state.ConfigGetWatchDecrypted(&pb.ConfigGetRequest{
 			Scope: &pb.ConfigGetRequest_Application{
 				Application: deployment.Application,
 			},
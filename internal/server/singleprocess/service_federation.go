@@ -0,0 +1,376 @@
+package singleprocess
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/server"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/serverconfig"
+)
+
+// federationServer is a downstream server that jobs can be forwarded to.
+type federationServer struct {
+	name   string
+	addr   string
+	client pb.WaypointClient
+}
+
+func (s *service) GetFederationStatus(
+	ctx context.Context,
+	req *empty.Empty,
+) (*pb.GetFederationStatusResponse, error) {
+	if s.federationServers == nil {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"federation is not configured")
+	}
+
+	jobs, err := s.state.JobListFederated()
+	if err != nil {
+		return nil, err
+	}
+
+	servers := map[string]*pb.FederationServer{}
+	var result pb.GetFederationStatusResponse
+	for _, fs := range s.federationServers {
+		st := &pb.FederationServer{
+			Name:    fs.name,
+			Address: fs.addr,
+		}
+
+		resp, err := fs.client.GetVersionInfo(ctx, &empty.Empty{})
+		if err != nil {
+			st.Error = err.Error()
+		} else {
+			st.Reachable = true
+			st.Version = resp.Info
+		}
+
+		servers[fs.name] = st
+		result.Servers = append(result.Servers, st)
+	}
+
+	for _, job := range jobs {
+		st, ok := servers[job.Federation.Server]
+		if !ok {
+			continue
+		}
+
+		switch job.State {
+		case pb.Job_SUCCESS, pb.Job_ERROR:
+			continue
+		}
+
+		switch {
+		case job.Federation.Id == "":
+			st.JobsPending++
+
+		case job.Federation.State == pb.Job_QUEUED:
+			st.JobsQueued++
+
+		case job.Federation.State == pb.Job_WAITING,
+			job.Federation.State == pb.Job_RUNNING:
+			st.JobsRunning++
+		}
+	}
+
+	sort.Slice(result.Servers, func(i, j int) bool {
+		return result.Servers[i].Name < result.Servers[j].Name
+	})
+
+	return &result, nil
+}
+
+// federationPrepare targets a job that is being queued for a downstream
+// server at a runner that doesn't exist, so the job is never assigned to
+// one of this server's runners. This does nothing if the job doesn't name
+// a downstream server.
+func (s *service) federationPrepare(job *pb.Job) error {
+	if job.Federation == nil {
+		return nil
+	}
+
+	// Only the server can be set by the caller.
+	job.Federation = &pb.Job_Federation{Server: job.Federation.Server}
+
+	if s.federationServers == nil {
+		return status.Errorf(codes.FailedPrecondition,
+			"federation is not configured, jobs can't be forwarded")
+	}
+	if _, ok := s.federationServers[job.Federation.Server]; !ok {
+		return status.Errorf(codes.FailedPrecondition,
+			"downstream server %q is not configured", job.Federation.Server)
+	}
+
+	// Runners are specific to each server so we can only forward jobs
+	// that any runner can run.
+	if _, ok := job.TargetRunner.Target.(*pb.Ref_Runner_Any); !ok {
+		return status.Errorf(codes.FailedPrecondition,
+			"jobs forwarded to a downstream server must target any runner")
+	}
+
+	runnerId, err := server.Id()
+	if err != nil {
+		return status.Errorf(codes.Internal, "uuid generation failed: %s", err)
+	}
+
+	job.TargetRunner = &pb.Ref_Runner{
+		Target: &pb.Ref_Runner_Id{
+			Id: &pb.Ref_RunnerId{Id: runnerId},
+		},
+	}
+
+	return nil
+}
+
+// federationSubmit queues a job on its downstream server if it hasn't
+// been already. If the job can't be queued, the job fails.
+func (s *service) federationSubmit(ctx context.Context, id string) error {
+	if s.federationServers == nil {
+		return nil
+	}
+
+	// We lock so that the monitor and the RPCs don't forward a job twice.
+	s.federationLock.Lock()
+	defer s.federationLock.Unlock()
+
+	job, err := s.state.JobById(id, nil)
+	if err != nil {
+		return err
+	}
+	if job == nil || job.Federation == nil || job.Federation.Id != "" {
+		return nil
+	}
+
+	// Jobs waiting for approval here are forwarded once they're approved.
+	// Jobs that are blocked, such as by dependencies that haven't succeeded,
+	// or paused are forwarded by the monitor once they're unblocked.
+	if job.State != pb.Job_QUEUED || dispatchApprovalPending(job.Job) || job.Blocked || job.Paused {
+		return nil
+	}
+
+	fs, ok := s.federationServers[job.Federation.Server]
+	if !ok {
+		return s.state.JobFail(id, status.Errorf(codes.FailedPrecondition,
+			"downstream server %q is not configured", job.Federation.Server))
+	}
+
+	resp, err := fs.client.QueueJob(ctx, &pb.QueueJobRequest{
+		Job: federationJob(job.Job),
+	})
+	if err != nil {
+		err = status.Errorf(codes.Unavailable,
+			"error forwarding job to %s: %s", fs.name, err)
+		if ferr := s.state.JobFail(id, err); ferr != nil {
+			return ferr
+		}
+
+		return err
+	}
+
+	return s.state.JobFederationSet(id, &pb.Job_Federation{
+		Server: fs.name,
+		Id:     resp.JobId,
+		State:  pb.Job_QUEUED,
+	})
+}
+
+// federationJob returns the job to queue on the downstream server. This
+// only has the fields that can be set when queueing a job.
+func federationJob(job *pb.Job) *pb.Job {
+	job = proto.Clone(job).(*pb.Job)
+	return &pb.Job{
+		Application: job.Application,
+		Workspace:   job.Workspace,
+		TargetRunner: &pb.Ref_Runner{
+			Target: &pb.Ref_Runner_Any{Any: &pb.Ref_RunnerAny{}},
+		},
+		Labels:              job.Labels,
+		DataSource:          job.DataSource,
+		DataSourceOverrides: job.DataSourceOverrides,
+		Operation:           job.Operation,
+		Variables:           job.Variables,
+		Priority:            job.Priority,
+		Timeouts:            job.Timeouts,
+	}
+}
+
+// federationMonitor periodically checks the status of forwarded jobs
+// until the context is cancelled. See federationPoll.
+func (s *service) federationMonitor(ctx context.Context, log hclog.Logger) {
+	interval := s.federationConfig.PollInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+		}
+
+		if err := s.federationPoll(ctx, log); err != nil {
+			log.Warn("error checking forwarded jobs", "err", err)
+		}
+	}
+}
+
+// federationPoll checks the status of all forwarded jobs once. Jobs that
+// haven't been forwarded yet, such as jobs that were waiting for approval,
+// are forwarded. Jobs complete with the result of the downstream job once
+// it completes. Jobs that complete here first, such as when they're
+// cancelled, cancel the downstream job.
+func (s *service) federationPoll(ctx context.Context, log hclog.Logger) error {
+	jobs, err := s.state.JobListFederated()
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		// Jobs may be shared with the job cache so we never modify them.
+		f := proto.Clone(job.Federation).(*pb.Job_Federation)
+		log := log.With("job", job.Id, "server", f.Server, "remote_id", f.Id)
+
+		fs, ok := s.federationServers[f.Server]
+		if !ok {
+			continue
+		}
+
+		completed := job.State == pb.Job_SUCCESS || job.State == pb.Job_ERROR
+		if f.Id == "" {
+			if !completed {
+				if err := s.federationSubmit(ctx, job.Id); err != nil {
+					log.Warn("error forwarding job", "err", err)
+				}
+			}
+
+			continue
+		}
+
+		// Once both are complete there is nothing left to do.
+		if completed && (f.State == pb.Job_SUCCESS || f.State == pb.Job_ERROR) {
+			continue
+		}
+
+		if completed {
+			_, err := fs.client.CancelJob(ctx, &pb.CancelJobRequest{JobId: f.Id})
+			if err != nil {
+				log.Warn("error cancelling downstream job", "err", err)
+				continue
+			}
+		}
+
+		remote, err := fs.client.GetJob(ctx, &pb.GetJobRequest{JobId: f.Id})
+		if err != nil {
+			log.Warn("error checking downstream job status", "err", err)
+			continue
+		}
+
+		if remote.State != f.State {
+			log.Debug("downstream job state changed", "old", f.State, "new", remote.State)
+			f.State = remote.State
+			if err := s.state.JobFederationSet(job.Id, f); err != nil {
+				return err
+			}
+		}
+
+		// This does nothing if the job completed since we read it.
+		if !completed && (remote.State == pb.Job_SUCCESS || remote.State == pb.Job_ERROR) {
+			if err := s.state.JobFederationComplete(job.Id, remote); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// federationInit validates the federation configuration, connects to the
+// downstream servers, and enables federation. Servers with a client in
+// clients use that client rather than connecting.
+func (s *service) federationInit(
+	log hclog.Logger,
+	cfg *serverconfig.Federation,
+	clients map[string]pb.WaypointClient,
+) error {
+	servers := map[string]*federationServer{}
+	for _, v := range cfg.Servers {
+		idx := strings.Index(v, "=")
+		if idx <= 0 || idx == len(v)-1 {
+			return fmt.Errorf(
+				"federation server %q must be in the format NAME=ADDRESS", v)
+		}
+
+		name, addr := v[:idx], v[idx+1:]
+		if _, ok := servers[name]; ok {
+			return fmt.Errorf("federation server %q is configured twice", name)
+		}
+
+		client, ok := clients[name]
+		if !ok {
+			opts := []grpc.DialOption{
+				grpc.WithPerRPCCredentials(federationToken(cfg.Token)),
+			}
+			if cfg.TLSDisabled {
+				opts = append(opts, grpc.WithInsecure())
+			} else {
+				opts = append(opts, grpc.WithTransportCredentials(
+					credentials.NewTLS(&tls.Config{
+						InsecureSkipVerify: cfg.TLSSkipVerify,
+					}),
+				))
+			}
+
+			// This doesn't block, so unreachable servers don't prevent
+			// the server from starting.
+			conn, err := grpc.Dial(addr, opts...)
+			if err != nil {
+				return fmt.Errorf("error connecting to federation server %q: %s", name, err)
+			}
+
+			client = pb.NewWaypointClient(conn)
+		}
+
+		servers[name] = &federationServer{
+			name:   name,
+			addr:   addr,
+			client: client,
+		}
+	}
+
+	s.federationConfig = cfg
+	s.federationServers = servers
+	go s.federationMonitor(context.Background(), log.Named("federation"))
+	return nil
+}
+
+// federationToken sends the token for the downstream servers with each
+// request. This matches the serverclient package, which we don't import
+// since it depends on the CLI context.
+type federationToken string
+
+func (t federationToken) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{
+		"authorization": string(t),
+	}, nil
+}
+
+func (t federationToken) RequireTransportSecurity() bool {
+	return false
+}
@@ -0,0 +1,179 @@
+package singleprocess
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/server"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+	"github.com/hashicorp/waypoint/internal/serverconfig"
+)
+
+func TestServiceFederation(t *testing.T) {
+	ctx := context.Background()
+	log := hclog.L()
+	appRef := serverptypes.TestJobNew(t, nil).Application
+
+	// init returns the central server and the downstream server
+	init := func(t *testing.T) (pb.WaypointClient, *service, pb.WaypointClient, *service) {
+		downImpl, err := New(WithDB(testDB(t)))
+		require.NoError(t, err)
+		down := server.TestServer(t, downImpl)
+		TestApp(t, down, appRef)
+
+		impl, err := New(
+			WithDB(testDB(t)),
+			WithFederationClient("us-east", down),
+			WithConfig(&serverconfig.Config{
+				Federation: &serverconfig.Federation{
+					Servers:      []string{"us-east=us-east.example.com:9701"},
+					PollInterval: time.Hour,
+				},
+			}),
+		)
+		require.NoError(t, err)
+		client := server.TestServer(t, impl)
+		TestApp(t, client, appRef)
+
+		return client, testServiceImpl(impl), down, testServiceImpl(downImpl)
+	}
+
+	queue := func(t *testing.T, client pb.WaypointClient) *pb.Job {
+		resp, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+			Job: serverptypes.TestJobNew(t, &pb.Job{
+				Federation: &pb.Job_Federation{Server: "us-east"},
+			}),
+		})
+		require.NoError(t, err)
+
+		job, err := client.GetJob(ctx, &pb.GetJobRequest{JobId: resp.JobId})
+		require.NoError(t, err)
+		return job
+	}
+
+	t.Run("invalid server", func(t *testing.T) {
+		_, err := New(
+			WithDB(testDB(t)),
+			WithConfig(&serverconfig.Config{
+				Federation: &serverconfig.Federation{
+					Servers: []string{"us-east"},
+				},
+			}),
+		)
+		require.Error(t, err)
+	})
+
+	t.Run("jobs run on the downstream server", func(t *testing.T) {
+		require := require.New(t)
+		client, s, down, downS := init(t)
+
+		job := queue(t, client)
+		require.NotNil(job.Federation)
+		require.NotEmpty(job.Federation.Id)
+		require.Equal(pb.Job_QUEUED, job.Federation.State)
+
+		// Local runners can't take the job
+		require.NotNil(job.TargetRunner.GetId())
+		assignable, err := s.state.JobIsAssignable(ctx, job)
+		require.NoError(err)
+		require.False(assignable)
+
+		remote, err := down.GetJob(ctx, &pb.GetJobRequest{JobId: job.Federation.Id})
+		require.NoError(err)
+		require.Equal(pb.Job_QUEUED, remote.State)
+		require.NotNil(remote.TargetRunner.GetAny())
+		require.Nil(remote.Federation)
+
+		// A downstream runner takes the job
+		rj, err := downS.state.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		require.Equal(remote.Id, rj.Id)
+		_, err = downS.state.JobAck(context.Background(), rj.Id, true)
+		require.NoError(err)
+
+		require.NoError(s.federationPoll(ctx, log))
+		job, err = client.GetJob(ctx, &pb.GetJobRequest{JobId: job.Id})
+		require.NoError(err)
+		require.Equal(pb.Job_QUEUED, job.State)
+		require.Equal(pb.Job_RUNNING, job.Federation.State)
+
+		// The status is aggregated
+		st, err := client.GetFederationStatus(ctx, &empty.Empty{})
+		require.NoError(err)
+		require.Len(st.Servers, 1)
+		require.Equal("us-east", st.Servers[0].Name)
+		require.True(st.Servers[0].Reachable)
+		require.NotNil(st.Servers[0].Version)
+		require.Equal(uint32(1), st.Servers[0].JobsRunning)
+
+		// The downstream result completes the job
+		require.NoError(downS.state.JobComplete(context.Background(), rj.Id, nil, status.Errorf(codes.Unknown, "failed")))
+		require.NoError(s.federationPoll(ctx, log))
+		job, err = client.GetJob(ctx, &pb.GetJobRequest{JobId: job.Id})
+		require.NoError(err)
+		require.Equal(pb.Job_ERROR, job.State)
+		require.Equal("failed", job.Error.Message)
+		require.Equal(pb.Job_ERROR, job.Federation.State)
+
+		st, err = client.GetFederationStatus(ctx, &empty.Empty{})
+		require.NoError(err)
+		require.Equal(uint32(0), st.Servers[0].JobsRunning)
+	})
+
+	t.Run("cancelled jobs cancel the downstream job", func(t *testing.T) {
+		require := require.New(t)
+		client, s, down, _ := init(t)
+
+		job := queue(t, client)
+		_, err := client.CancelJob(ctx, &pb.CancelJobRequest{JobId: job.Id})
+		require.NoError(err)
+
+		require.NoError(s.federationPoll(ctx, log))
+		remote, err := down.GetJob(ctx, &pb.GetJobRequest{JobId: job.Federation.Id})
+		require.NoError(err)
+		require.Equal(pb.Job_ERROR, remote.State)
+		require.NotNil(remote.CancelTime)
+
+		job, err = client.GetJob(ctx, &pb.GetJobRequest{JobId: job.Id})
+		require.NoError(err)
+		require.Equal(pb.Job_ERROR, job.Federation.State)
+	})
+
+	t.Run("unknown server", func(t *testing.T) {
+		client, _, _, _ := init(t)
+
+		_, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+			Job: serverptypes.TestJobNew(t, &pb.Job{
+				Federation: &pb.Job_Federation{Server: "eu-west"},
+			}),
+		})
+		require.Error(t, err)
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		impl, err := New(WithDB(testDB(t)))
+		require.NoError(t, err)
+		client := server.TestServer(t, impl)
+		TestApp(t, client, appRef)
+
+		_, err = client.QueueJob(ctx, &pb.QueueJobRequest{
+			Job: serverptypes.TestJobNew(t, &pb.Job{
+				Federation: &pb.Job_Federation{Server: "us-east"},
+			}),
+		})
+		require.Error(t, err)
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+		_, err = client.GetFederationStatus(ctx, &empty.Empty{})
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+}
@@ -0,0 +1,194 @@
+package singleprocess
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/protocolversion"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/singleprocess/state"
+)
+
+// followerEndpoints are the endpoints that raft followers serve.
+var followerEndpoints = map[string]bool{
+	"GetVersionInfo": true,
+	"GetJob":         true,
+	"XListJobs":      true,
+	"ListJobsStream": true,
+}
+
+// followerService serves reads while this server is a raft follower. The
+// requests must set max_staleness to be served by a follower, and the
+// other endpoints aren't served until this server is the leader. This
+// includes GetLogStream, since the logs of the instances are only sent to
+// the leader. See state/raft_follower.go.
+type followerService struct {
+	pb.UnimplementedWaypointServer
+
+	state *state.State
+}
+
+// Authenticate implements server.AuthChecker. This is like
+// service.Authenticate, with the keys and tokens read from the replicated
+// database. Followers don't track the usage of API keys.
+func (s *followerService) Authenticate(ctx context.Context, token, endpoint string, effects []string) error {
+	if !followerEndpoints[endpoint] {
+		return status.Errorf(codes.Unavailable,
+			"this server is not the leader, the leader is %q", s.state.RaftLeader())
+	}
+	if endpoint == "GetVersionInfo" {
+		return nil
+	}
+
+	if token == "" {
+		return status.Errorf(codes.Unauthenticated, "Authorization token is not supplied")
+	}
+
+	_, body, err := decodeToken(token, s.state.FollowerHMACKeyGet)
+	if err != nil {
+		return err
+	}
+	if !body.Login {
+		return ErrInvalidToken
+	}
+	if body.Entrypoint != nil {
+		return status.Errorf(codes.Unauthenticated, "Unauthorized endpoint")
+	}
+
+	if body.Runner != nil && body.Runner.RunnerId != "" {
+		t, err := s.state.FollowerRunnerTokenGet(body.Runner.RunnerId)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return ErrInvalidToken
+			}
+
+			return err
+		}
+		if !bytes.Equal(t.TokenId, body.TokenId) {
+			return ErrInvalidToken
+		}
+
+		return nil
+	}
+
+	if body.User == "" {
+		return ErrInvalidToken
+	}
+
+	if body.ApiKeyId != "" {
+		key, err := s.state.FollowerAPIKeyGet(body.ApiKeyId)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return ErrInvalidToken
+			}
+
+			return err
+		}
+		if !bytes.Equal(key.TokenId, body.TokenId) || key.User != body.User {
+			return ErrInvalidToken
+		}
+	}
+
+	return nil
+}
+
+func (s *followerService) GetVersionInfo(
+	ctx context.Context,
+	req *empty.Empty,
+) (*pb.GetVersionInfoResponse, error) {
+	return &pb.GetVersionInfoResponse{
+		Info: protocolversion.Current(),
+	}, nil
+}
+
+func (s *followerService) GetJob(
+	ctx context.Context,
+	req *pb.GetJobRequest,
+) (*pb.Job, error) {
+	if err := s.check(req.MaxStaleness); err != nil {
+		return nil, err
+	}
+
+	job, err := s.state.FollowerJobById(req.JobId)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, status.Errorf(codes.NotFound, "job not found")
+		}
+
+		return nil, err
+	}
+
+	return redactJob(s.state.Redactor(), job), nil
+}
+
+func (s *followerService) XListJobs(
+	ctx context.Context,
+	req *pb.ListJobsRequest,
+) (*pb.ListJobsResponse, error) {
+	var result []*pb.Job
+	err := s.listJobs(req, func(jobs []*pb.Job) error {
+		result = append(result, jobs...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ListJobsResponse{
+		Jobs: result,
+	}, nil
+}
+
+func (s *followerService) ListJobsStream(
+	req *pb.ListJobsRequest,
+	srv pb.Waypoint_ListJobsStreamServer,
+) error {
+	return s.listJobs(req, func(jobs []*pb.Job) error {
+		return srv.Send(&pb.ListJobsResponse{
+			Jobs: jobs,
+		})
+	})
+}
+
+// listJobs calls fn with the redacted jobs for ListJobsRequest, a batch at
+// a time. See state.FollowerJobListIter.
+func (s *followerService) listJobs(
+	req *pb.ListJobsRequest,
+	fn func([]*pb.Job) error,
+) error {
+	if err := s.check(req.MaxStaleness); err != nil {
+		return err
+	}
+
+	redactor := s.state.Redactor()
+	return s.state.FollowerJobListIter(func(jobs []*pb.Job) error {
+		for i, job := range jobs {
+			jobs[i] = redactJob(redactor, job)
+		}
+
+		return fn(jobs)
+	})
+}
+
+// check returns an error if this server may be more than the max_staleness
+// of the request behind the leader.
+func (s *followerService) check(maxStaleness string) error {
+	var maxStale time.Duration
+	if maxStaleness != "" {
+		var err error
+		maxStale, err = time.ParseDuration(maxStaleness)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument,
+				"invalid max_staleness: %s", err)
+		}
+	}
+
+	return s.state.RaftFollowerCheck(maxStale)
+}
+
+var _ pb.WaypointServer = (*followerService)(nil)
@@ -0,0 +1,83 @@
+package singleprocess
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+	"github.com/hashicorp/waypoint/internal/server/singleprocess/state"
+)
+
+func TestServiceFollower(t *testing.T) {
+	ctx := context.Background()
+	leader, follower := state.TestRaftFollower(t)
+	s := &followerService{state: follower}
+
+	// Tokens are signed by the leader
+	token, err := (&service{state: leader}).NewLoginToken(DefaultKeyId, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, leader.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+		Id: "A",
+	})))
+
+	t.Run("authenticates with the replicated keys", func(t *testing.T) {
+		require := require.New(t)
+
+		require.Eventually(func() bool {
+			return s.Authenticate(ctx, token, "GetJob", nil) == nil
+		}, 5*time.Second, 10*time.Millisecond)
+
+		require.Error(s.Authenticate(ctx, "", "GetJob", nil))
+		require.Error(s.Authenticate(ctx, token+"A", "GetJob", nil))
+		require.NoError(s.Authenticate(ctx, "", "GetVersionInfo", nil))
+	})
+
+	t.Run("only serves reads", func(t *testing.T) {
+		require := require.New(t)
+
+		err := s.Authenticate(ctx, token, "QueueJob", nil)
+		require.Error(err)
+		require.Equal(codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("requires a maximum staleness", func(t *testing.T) {
+		require := require.New(t)
+
+		_, err := s.GetJob(ctx, &pb.GetJobRequest{JobId: "A"})
+		require.Error(err)
+		require.Equal(codes.FailedPrecondition, status.Code(err))
+
+		_, err = s.XListJobs(ctx, &pb.ListJobsRequest{MaxStaleness: "soon"})
+		require.Error(err)
+		require.Equal(codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("reads jobs", func(t *testing.T) {
+		require := require.New(t)
+
+		require.Eventually(func() bool {
+			job, err := s.GetJob(ctx, &pb.GetJobRequest{
+				JobId:        "A",
+				MaxStaleness: "1m",
+			})
+			return err == nil && job.Id == "A"
+		}, 5*time.Second, 10*time.Millisecond)
+
+		resp, err := s.XListJobs(ctx, &pb.ListJobsRequest{MaxStaleness: "1m"})
+		require.NoError(err)
+		require.Len(resp.Jobs, 1)
+
+		_, err = s.GetJob(ctx, &pb.GetJobRequest{
+			JobId:        "B",
+			MaxStaleness: "1m",
+		})
+		require.Error(err)
+		require.Equal(codes.NotFound, status.Code(err))
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/hashicorp/go-hclog"
@@ -11,6 +12,7 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/hashicorp/waypoint/internal/pkg/redact"
 	"github.com/hashicorp/waypoint/internal/server"
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
 	"github.com/hashicorp/waypoint/internal/server/logbuffer"
@@ -31,7 +33,7 @@ func (s *service) GetJob(
 		return nil, status.Errorf(codes.NotFound, "job not found")
 	}
 
-	return job.Job, nil
+	return s.redactJob(job.Job), nil
 }
 
 // TODO: test
@@ -44,28 +46,103 @@ func (s *service) XListJobs(
 		return nil, err
 	}
 
+	for i, job := range jobs {
+		jobs[i] = s.redactJob(job)
+	}
+
 	return &pb.ListJobsResponse{
 		Jobs: jobs,
 	}, nil
 }
 
+func (s *service) ListJobsStream(
+	req *pb.ListJobsRequest,
+	srv pb.Waypoint_ListJobsStreamServer,
+) error {
+	return s.state.JobListIter(func(jobs []*pb.Job) error {
+		for i, job := range jobs {
+			jobs[i] = s.redactJob(job)
+		}
+
+		return srv.Send(&pb.ListJobsResponse{
+			Jobs: jobs,
+		})
+	})
+}
+
+// redactJob returns the job with the values of sensitive config variables
+// masked. Jobs are redacted when they're created, but this also masks
+// values that were made sensitive after the job was queued. If anything is
+// redacted, the result is a copy.
+func (s *service) redactJob(job *pb.Job) *pb.Job {
+	return redactJob(s.state.Redactor(), job)
+}
+
+// redactJob masks the values registered with the redactor in the job. See
+// service.redactJob.
+func redactJob(redactor *redact.Redactor, job *pb.Job) *pb.Job {
+	if job == nil || redactor.Empty() {
+		return job
+	}
+
+	job = proto.Clone(job).(*pb.Job)
+	redactor.Message(job)
+	return job
+}
+
 func (s *service) CancelJob(
 	ctx context.Context,
 	req *pb.CancelJobRequest,
 ) (*empty.Empty, error) {
-	if err := s.state.JobCancel(req.JobId, false); err != nil {
+	if err := s.state.JobCancel(req.JobId, false, req.Revision); err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
+
+func (s *service) DeleteJob(
+	ctx context.Context,
+	req *pb.DeleteJobRequest,
+) (*empty.Empty, error) {
+	if err := s.state.JobDelete(req.JobId); err != nil {
 		return nil, err
 	}
 
 	return &empty.Empty{}, nil
 }
 
+func (s *service) CancelJobs(
+	ctx context.Context,
+	req *pb.CancelJobsRequest,
+) (*pb.CancelJobsResponse, error) {
+	if req.Application == nil || req.Application.Project == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "project must be set")
+	}
+
+	ids, err := s.state.JobCancelBulk(req.Application, req.Workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.CancelJobsResponse{JobIds: ids}, nil
+}
+
 func (s *service) QueueJob(
 	ctx context.Context,
 	req *pb.QueueJobRequest,
 ) (*pb.QueueJobResponse, error) {
 	job := req.Job
 
+	// If the job is queued from a template, the template is the base.
+	if req.Template != "" {
+		var err error
+		job, err = s.jobFromTemplate(req.Template, job)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Validation
 	if job == nil {
 		return nil, status.Errorf(codes.FailedPrecondition, "job must be set")
@@ -107,6 +184,30 @@ func (s *service) QueueJob(
 		job.DataSource = project.DataSource
 	}
 
+	// Jobs can only target runner profiles that exist.
+	if job.RunnerProfile != "" {
+		if _, err := s.state.RunnerProfileGet(job.RunnerProfile); err != nil {
+			if status.Code(err) == codes.NotFound {
+				return nil, status.Errorf(codes.FailedPrecondition,
+					"runner profile not found: %s", job.RunnerProfile)
+			}
+
+			return nil, err
+		}
+	}
+
+	// Jobs can only depend on jobs that exist.
+	for _, depId := range job.DependsOn {
+		dep, err := s.state.JobById(depId, nil)
+		if err != nil {
+			return nil, err
+		}
+		if dep == nil {
+			return nil, status.Errorf(codes.NotFound,
+				"dependency job not found: %s", depId)
+		}
+	}
+
 	// Get the next id
 	id, err := server.Id()
 	if err != nil {
@@ -129,14 +230,85 @@ func (s *service) QueueJob(
 		}
 	}
 
+	// Resolve the Terraform outputs of the project. We do this each time
+	// a job is queued so that jobs always use the latest values.
+	if err := s.jobTerraformVariables(ctx, project, job); err != nil {
+		return nil, err
+	}
+
+	// Deploys and releases to protected workspaces require approval
+	if err := s.jobApprovalRequirement(ctx, job); err != nil {
+		return nil, err
+	}
+
+	// If the job is forwarded to a downstream server, make sure no runner
+	// here takes it. This must be before dispatch so it isn't dispatched.
+	if err := s.federationPrepare(job); err != nil {
+		return nil, err
+	}
+
+	// If jobs are dispatched to an external scheduler, target the runner
+	// that will be started for this job.
+	if err := s.dispatchPrepare(job); err != nil {
+		return nil, err
+	}
+
 	// Queue the job
-	if err := s.state.JobCreate(job); err != nil {
+	if err := s.state.JobCreate(ctx, job); err != nil {
 		return nil, err
 	}
+	s.telemetry.Job(job)
+
+	// Let approvers know the job is waiting for them.
+	if job.ApprovalRequired != nil {
+		go s.notifyJob(hclog.FromContext(ctx), pb.Project_Notification_APPROVAL_REQUESTED, job)
+	}
+
+	// Start the runner for the job. If this fails the job fails, so the
+	// caller sees the error from the job.
+	if err := s.dispatchSubmit(ctx, job.Id); err != nil {
+		hclog.FromContext(ctx).Warn("error dispatching job", "job", job.Id, "err", err)
+	}
+
+	// Forward the job to its downstream server. If this fails the job
+	// fails, like dispatch.
+	if err := s.federationSubmit(ctx, job.Id); err != nil {
+		hclog.FromContext(ctx).Warn("error forwarding job", "job", job.Id, "err", err)
+	}
 
 	return &pb.QueueJobResponse{JobId: job.Id}, nil
 }
 
+// jobTerraformVariables sets the job variables to the Terraform outputs
+// of the project for operations that use the app configuration.
+func (s *service) jobTerraformVariables(ctx context.Context, project *pb.Project, job *pb.Job) error {
+	if project == nil || len(project.TerraformOutputs) == 0 {
+		return nil
+	}
+
+	switch job.Operation.(type) {
+	case *pb.Job_Build, *pb.Job_Push, *pb.Job_Deploy, *pb.Job_Release, *pb.Job_Destroy:
+	default:
+		return nil
+	}
+
+	vars, err := s.tfOutputs.Variables(ctx, project.TerraformOutputs)
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, err.Error())
+	}
+
+	if job.Variables == nil {
+		job.Variables = map[string]string{}
+	}
+	for k, v := range vars {
+		job.Variables[k] = v
+	}
+
+	hclog.FromContext(ctx).Debug("resolved Terraform outputs for job",
+		"variables", len(vars))
+	return nil
+}
+
 func (s *service) ValidateJob(
 	ctx context.Context,
 	req *pb.ValidateJobRequest,
@@ -224,8 +396,10 @@ func (s *service) GetJobStream(
 
 	// Enter the event loop
 	var lastState pb.Job_State
+	var lastDetail *pb.GetJobStreamResponse_Detail
+	var lastProgress *pb.JobProgress
 	var cancelSent bool
-	var eventsCh <-chan []*pb.GetJobStreamResponse_Terminal_Event
+	var eventsCh <-chan *pb.GetJobStreamResponse_Terminal
 	for {
 		select {
 		case <-ctx.Done():
@@ -246,7 +420,7 @@ func (s *service) GetJobStream(
 						State: &pb.GetJobStreamResponse_State{
 							Previous:  lastState,
 							Current:   job.State,
-							Job:       job.Job,
+							Job:       s.redactJob(job.Job),
 							Canceling: canceling,
 						},
 					},
@@ -258,11 +432,44 @@ func (s *service) GetJobStream(
 				cancelSent = canceling
 			}
 
+			// Send the detail if it was requested and it changed.
+			if req.Detail {
+				detail, err := jobStreamDetail(job)
+				if err != nil {
+					return err
+				}
+
+				if lastDetail == nil || !proto.Equal(detail, lastDetail) {
+					if err := server.Send(&pb.GetJobStreamResponse{
+						Event: &pb.GetJobStreamResponse_Detail_{
+							Detail: detail,
+						},
+					}); err != nil {
+						return err
+					}
+
+					lastDetail = detail
+				}
+			}
+
+			// Send the progress if it changed.
+			if job.Progress != nil && job.Progress != lastProgress {
+				if err := server.Send(&pb.GetJobStreamResponse{
+					Event: &pb.GetJobStreamResponse_Progress{
+						Progress: job.Progress,
+					},
+				}); err != nil {
+					return err
+				}
+
+				lastProgress = job.Progress
+			}
+
 			// If we haven't initialized output streaming and the output buffer
 			// is now non-nil, initialize that. This will send any buffered
 			// data down.
 			if eventsCh == nil && job.OutputBuffer != nil {
-				eventsCh, err = s.getJobStreamOutputInit(ctx, job, server)
+				eventsCh, err = s.getJobStreamOutputInit(ctx, job, req, server)
 				if err != nil {
 					return err
 				}
@@ -284,12 +491,10 @@ func (s *service) GetJobStream(
 				})
 			}
 
-		case events := <-eventsCh:
+		case terminal := <-eventsCh:
 			if err := server.Send(&pb.GetJobStreamResponse{
 				Event: &pb.GetJobStreamResponse_Terminal_{
-					Terminal: &pb.GetJobStreamResponse_Terminal{
-						Events: events,
-					},
+					Terminal: terminal,
 				},
 			}); err != nil {
 				return err
@@ -298,15 +503,42 @@ func (s *service) GetJobStream(
 	}
 }
 
-func (s *service) readJobLogBatch(r *logbuffer.Reader, block bool) []*pb.GetJobStreamResponse_Terminal_Event {
-	entries := r.Read(64, block)
+// jobStreamDetail returns the detail event for the job.
+func jobStreamDetail(job *state.Job) (*pb.GetJobStreamResponse_Detail, error) {
+	result := &pb.GetJobStreamResponse_Detail{BlockedBy: job.BlockedBy}
+	if deadline := job.HeartbeatDeadline(); !deadline.IsZero() {
+		var err error
+		result.HeartbeatTime, err = ptypes.TimestampProto(job.HeartbeatTime)
+		if err != nil {
+			return nil, err
+		}
+
+		result.HeartbeatDeadline, err = ptypes.TimestampProto(deadline)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// readJobLogBatch reads the next batch of terminal events at the given
+// level or more severe from the output buffer of a job.
+func (s *service) readJobLogBatch(
+	r *logbuffer.Reader,
+	level pb.GetJobStreamResponse_Terminal_Event_Level,
+	block bool,
+) []*pb.GetJobStreamResponse_Terminal_Event {
+	// The levels of the protobuf enum and the log buffer have the same
+	// values.
+	entries := r.ReadFilter(64, block, logbuffer.LevelFilter(logbuffer.Level(level)))
 	if entries == nil {
 		return nil
 	}
 
 	events := make([]*pb.GetJobStreamResponse_Terminal_Event, len(entries))
 	for i, entry := range entries {
-		events[i] = entry.(*pb.GetJobStreamResponse_Terminal_Event)
+		events[i] = entry.(*logbuffer.Event).Data.(*pb.GetJobStreamResponse_Terminal_Event)
 	}
 
 	return events
@@ -315,13 +547,18 @@ func (s *service) readJobLogBatch(r *logbuffer.Reader, block bool) []*pb.GetJobS
 func (s *service) getJobStreamOutputInit(
 	ctx context.Context,
 	job *state.Job,
+	req *pb.GetJobStreamRequest,
 	server pb.Waypoint_GetJobStreamServer,
-) (<-chan []*pb.GetJobStreamResponse_Terminal_Event, error) {
-	// Send down all our buffered lines.
+) (<-chan *pb.GetJobStreamResponse_Terminal, error) {
+	// Send down all our buffered lines, starting at the offset if the
+	// client is resuming.
 	outputR := job.OutputBuffer.Reader(-1)
 	go outputR.CloseContext(ctx)
+	if req.Offset > 0 {
+		outputR.SeekTo(req.Offset)
+	}
 	for {
-		events := s.readJobLogBatch(outputR, false)
+		events := s.readJobLogBatch(outputR, req.Level, false)
 		if events == nil {
 			break
 		}
@@ -329,8 +566,9 @@ func (s *service) getJobStreamOutputInit(
 		if err := server.Send(&pb.GetJobStreamResponse{
 			Event: &pb.GetJobStreamResponse_Terminal_{
 				Terminal: &pb.GetJobStreamResponse_Terminal{
-					Events:   events,
-					Buffered: true,
+					Events:     events,
+					Buffered:   true,
+					NextOffset: outputR.Offset(),
 				},
 			},
 		}); err != nil {
@@ -339,16 +577,21 @@ func (s *service) getJobStreamOutputInit(
 	}
 
 	// Start a goroutine that reads output
-	eventsCh := make(chan []*pb.GetJobStreamResponse_Terminal_Event, 1)
+	eventsCh := make(chan *pb.GetJobStreamResponse_Terminal, 1)
 	go func() {
 		for {
-			events := s.readJobLogBatch(outputR, true)
+			events := s.readJobLogBatch(outputR, req.Level, true)
 			if events == nil {
 				return
 			}
 
+			terminal := &pb.GetJobStreamResponse_Terminal{
+				Events:     events,
+				NextOffset: outputR.Offset(),
+			}
+
 			select {
-			case eventsCh <- events:
+			case eventsCh <- terminal:
 			case <-ctx.Done():
 				return
 			}
@@ -357,3 +600,73 @@ func (s *service) getJobStreamOutputInit(
 
 	return eventsCh, nil
 }
+
+func (s *service) GetJobQueuePosition(
+	ctx context.Context,
+	req *pb.GetJobQueuePositionRequest,
+) (*pb.GetJobQueuePositionResponse, error) {
+	ahead, err := s.state.JobQueuePosition(req.JobId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetJobQueuePositionResponse{JobsAhead: uint32(ahead)}, nil
+}
+
+func (s *service) ListJobArtifacts(
+	ctx context.Context,
+	req *pb.ListJobArtifactsRequest,
+) (*pb.ListJobArtifactsResponse, error) {
+	job, err := s.state.JobById(req.JobId, nil)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil || job.Job == nil {
+		return nil, status.Errorf(codes.NotFound, "job not found")
+	}
+
+	return &pb.ListJobArtifactsResponse{Artifacts: job.Artifacts}, nil
+}
+
+func (s *service) GetJobLogRange(
+	ctx context.Context,
+	req *pb.GetJobLogRangeRequest,
+) (*pb.GetJobLogRangeResponse, error) {
+	// Get the job first so that if it is complete, all of the output we
+	// read was written before it completed.
+	job, err := s.state.JobById(req.JobId, nil)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil || job.Job == nil {
+		return nil, status.Errorf(codes.NotFound, "job not found")
+	}
+
+	events, offset, total, err := s.state.JobOutputRange(req.JobId, req.Offset, int(req.Limit))
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetJobLogRangeResponse{
+		Events:     events,
+		Offset:     offset,
+		NextOffset: offset + int64(len(events)),
+		Total:      total,
+		Complete:   job.State == pb.Job_SUCCESS || job.State == pb.Job_ERROR,
+	}, nil
+}
+
+func (s *service) SearchJobLogs(
+	ctx context.Context,
+	req *pb.SearchJobLogsRequest,
+) (*pb.SearchJobLogsResponse, error) {
+	matches, truncated, err := s.state.JobOutputSearch(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.SearchJobLogsResponse{
+		Matches:   matches,
+		Truncated: truncated,
+	}, nil
+}
@@ -0,0 +1,27 @@
+package singleprocess
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func (s *service) SetJobConflicts(
+	ctx context.Context,
+	req *pb.JobConflicts,
+) (*empty.Empty, error) {
+	if err := s.state.JobConflictsSet(req); err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
+
+func (s *service) GetJobConflicts(
+	ctx context.Context,
+	req *empty.Empty,
+) (*pb.JobConflicts, error) {
+	return s.state.JobConflictsGet()
+}
@@ -0,0 +1,185 @@
+package singleprocess
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/logbuffer"
+)
+
+// jobLogsExportMaxJobs is the most jobs whose output can be exported at once.
+const jobLogsExportMaxJobs = 100
+
+func (s *service) ExportJobLogs(
+	req *pb.ExportJobLogsRequest,
+	srv pb.Waypoint_ExportJobLogsServer,
+) error {
+	if len(req.JobIds) == 0 {
+		return status.Errorf(codes.InvalidArgument, "at least one job ID is required")
+	}
+	if len(req.JobIds) > jobLogsExportMaxJobs {
+		return status.Errorf(codes.InvalidArgument,
+			"at most %d jobs can be exported at once", jobLogsExportMaxJobs)
+	}
+
+	// Check that all the jobs exist first so that we return an error
+	// before any data is sent.
+	seen := map[string]struct{}{}
+	var ids []string
+	for _, id := range req.JobIds {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+
+		job, err := s.state.JobById(id, nil)
+		if err != nil {
+			return err
+		}
+		if job == nil || job.Job == nil {
+			return status.Errorf(codes.NotFound, "job not found: %s", id)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := srv.Send(&pb.ExportJobLogsResponse{
+		Event: &pb.ExportJobLogsResponse_Open_{
+			Open: &pb.ExportJobLogsResponse_Open{},
+		},
+	}); err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(&jobLogsExportWriter{srv: srv})
+	gzw := gzip.NewWriter(bw)
+	tw := tar.NewWriter(gzw)
+	now := time.Now()
+	for _, id := range ids {
+		// The size of a file must be known before it is written to the
+		// archive, so each job's files are rendered in memory first.
+		text, events, err := s.jobLogsExportRender(id)
+		if err != nil {
+			return err
+		}
+
+		for _, f := range []struct {
+			name string
+			data []byte
+		}{
+			{id + "/output.log", text},
+			{id + "/events.jsonl", events},
+		} {
+			if err := tw.WriteHeader(&tar.Header{
+				Name:    f.name,
+				Mode:    0644,
+				Size:    int64(len(f.data)),
+				ModTime: now,
+			}); err != nil {
+				return err
+			}
+			if _, err := tw.Write(f.data); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gzw.Close(); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// jobLogsExportRender renders the persisted output of a job as a text log
+// and as JSON lines of its terminal events.
+func (s *service) jobLogsExportRender(id string) ([]byte, []byte, error) {
+	var text, events bytes.Buffer
+	var m jsonpb.Marshaler
+	err := s.state.JobOutputEach(id, func(entries []logbuffer.Entry) error {
+		for _, entry := range entries {
+			ev := entry.(*logbuffer.Event)
+			pbev := ev.Data.(*pb.GetJobStreamResponse_Terminal_Event)
+
+			if err := m.Marshal(&events, pbev); err != nil {
+				return err
+			}
+			events.WriteString("\n")
+
+			jobLogsExportText(&text, ev, pbev)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return text.Bytes(), events.Bytes(), nil
+}
+
+// jobLogsExportText writes an event to a text log with a line per line of
+// its message, prefixed with the time and level of the event.
+func jobLogsExportText(
+	buf *bytes.Buffer,
+	ev *logbuffer.Event,
+	pbev *pb.GetJobStreamResponse_Terminal_Event,
+) {
+	msg := strings.TrimRight(ev.Message, "\n")
+	if msg == "" {
+		return
+	}
+
+	// Prefer when the server received the event since the clock of the
+	// runner may be skewed.
+	ts := ev.ReceiveTime
+	if ts.IsZero() {
+		ts = ev.Timestamp
+	}
+	var prefix string
+	if !ts.IsZero() {
+		prefix = ts.UTC().Format(time.RFC3339Nano) + " "
+	}
+
+	level := pbev.Level
+	if level == pb.GetJobStreamResponse_Terminal_Event_LEVEL_UNKNOWN {
+		level = pb.GetJobStreamResponse_Terminal_Event_INFO
+	}
+	prefix += fmt.Sprintf("%-5s ", level)
+
+	for _, line := range strings.Split(msg, "\n") {
+		buf.WriteString(prefix)
+		buf.WriteString(line)
+		buf.WriteString("\n")
+	}
+}
+
+type jobLogsExportWriter struct {
+	srv pb.Waypoint_ExportJobLogsServer
+}
+
+func (w *jobLogsExportWriter) Write(p []byte) (int, error) {
+	// Ignore empty data.
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	return len(p), w.srv.Send(&pb.ExportJobLogsResponse{
+		Event: &pb.ExportJobLogsResponse_Chunk{
+			Chunk: p,
+		},
+	})
+}
@@ -0,0 +1,125 @@
+package singleprocess
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/server"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestServiceExportJobLogs(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	// Create our server
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+	s := testServiceImpl(impl)
+	TestApp(t, client, serverptypes.TestJobNew(t, nil).Application)
+
+	// Run two jobs with some output
+	var ids []string
+	for _, msg := range []string{"first", "second"} {
+		queued, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+			Job: serverptypes.TestJobNew(t, nil),
+		})
+		require.NoError(err)
+		job, err := s.state.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		require.Equal(queued.JobId, job.Id)
+		_, err = s.state.JobAck(ctx, job.Id, true)
+		require.NoError(err)
+		require.NoError(s.state.JobOutputWrite(job.Id, []*pb.GetJobStreamResponse_Terminal_Event{
+			{
+				Event: &pb.GetJobStreamResponse_Terminal_Event_Line_{
+					Line: &pb.GetJobStreamResponse_Terminal_Event_Line{Msg: msg},
+				},
+			},
+			{
+				Event: &pb.GetJobStreamResponse_Terminal_Event_Raw_{
+					Raw: &pb.GetJobStreamResponse_Terminal_Event_Raw{
+						Data:   []byte("oops\nfailed\n"),
+						Stderr: true,
+					},
+				},
+			},
+		}))
+		require.NoError(s.state.JobComplete(ctx, job.Id, nil, nil))
+
+		ids = append(ids, job.Id)
+	}
+
+	stream, err := client.ExportJobLogs(ctx, &pb.ExportJobLogsRequest{JobIds: ids})
+	require.NoError(err)
+	resp, err := stream.Recv()
+	require.NoError(err)
+	require.IsType((*pb.ExportJobLogsResponse_Open_)(nil), resp.Event)
+
+	var buf bytes.Buffer
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(err)
+		buf.Write(resp.Event.(*pb.ExportJobLogsResponse_Chunk).Chunk)
+	}
+
+	// Read the archive
+	gzr, err := gzip.NewReader(&buf)
+	require.NoError(err)
+	tr := tar.NewReader(gzr)
+	files := map[string]string{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(err)
+		data, err := ioutil.ReadAll(tr)
+		require.NoError(err)
+		files[hdr.Name] = string(data)
+	}
+	require.Len(files, 4)
+
+	// The text log has a line per line of output with the time and level
+	lines := strings.Split(strings.TrimSpace(files[ids[0]+"/output.log"]), "\n")
+	require.Len(lines, 3)
+	require.True(strings.HasSuffix(lines[0], " INFO  first"), lines[0])
+	require.True(strings.HasSuffix(lines[2], " INFO  failed"), lines[2])
+	require.Contains(files[ids[1]+"/output.log"], "second")
+
+	// The events are kept as JSON lines
+	events := strings.Split(strings.TrimSpace(files[ids[0]+"/events.jsonl"]), "\n")
+	require.Len(events, 2)
+	require.Contains(events[0], `"msg":"first"`)
+	require.Contains(events[1], `"stream":"STDERR"`)
+
+	// Nothing is exported if a job doesn't exist
+	stream, err = client.ExportJobLogs(ctx, &pb.ExportJobLogsRequest{
+		JobIds: []string{ids[0], "nope"},
+	})
+	require.NoError(err)
+	_, err = stream.Recv()
+	require.Error(err)
+	require.Equal(codes.NotFound, status.Code(err))
+
+	// A job is required
+	stream, err = client.ExportJobLogs(ctx, &pb.ExportJobLogsRequest{})
+	require.NoError(err)
+	_, err = stream.Recv()
+	require.Equal(codes.InvalidArgument, status.Code(err))
+}
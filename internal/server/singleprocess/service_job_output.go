@@ -0,0 +1,75 @@
+package singleprocess
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/serverconfig"
+)
+
+// jobOutputPruneDefaultInterval is the time between pruning job output if
+// it isn't configured.
+const jobOutputPruneDefaultInterval = time.Hour
+
+// jobOutputPruneInit validates the job output retention configuration and
+// starts pruning job output on its interval if a limit is set.
+func (s *service) jobOutputPruneInit(log hclog.Logger, cfg *serverconfig.JobOutputRetention) error {
+	if cfg.MaxAge < 0 || cfg.MaxBytes < 0 || cfg.Interval < 0 {
+		return fmt.Errorf("the job output retention limits and interval can't be negative")
+	}
+	if cfg.MaxAge == 0 && cfg.MaxBytes == 0 {
+		return nil
+	}
+
+	interval := cfg.Interval
+	if interval == 0 {
+		interval = jobOutputPruneDefaultInterval
+	}
+
+	go s.jobOutputPruneRun(context.Background(), log.Named("job_output_prune"),
+		interval, cfg.MaxAge, cfg.MaxBytes)
+	return nil
+}
+
+// jobOutputPruneRun prunes job output every interval until the context
+// is cancelled.
+func (s *service) jobOutputPruneRun(
+	ctx context.Context,
+	log hclog.Logger,
+	interval time.Duration,
+	maxAge time.Duration,
+	maxBytes int64,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+		}
+
+		n, size, err := s.state.JobOutputPrune(maxAge, maxBytes)
+		if err != nil {
+			// Only the leader can write, so followers skip pruning.
+			if status.Code(err) == codes.Unavailable {
+				log.Trace("not pruning job output", "err", err)
+				continue
+			}
+
+			log.Error("failed to prune job output", "err", err)
+			continue
+		}
+
+		if n > 0 {
+			log.Info("pruned job output", "jobs", n, "bytes", size)
+		}
+	}
+}
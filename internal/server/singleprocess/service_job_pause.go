@@ -0,0 +1,53 @@
+package singleprocess
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func (s *service) PauseJobQueue(
+	ctx context.Context,
+	req *pb.JobQueuePause,
+) (*empty.Empty, error) {
+	if req.Project == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "project must be set")
+	}
+
+	if err := s.state.JobQueuePause(req); err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
+
+func (s *service) ResumeJobQueue(
+	ctx context.Context,
+	req *pb.JobQueuePause,
+) (*empty.Empty, error) {
+	if req.Project == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "project must be set")
+	}
+
+	if err := s.state.JobQueueResume(req); err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
+
+func (s *service) ListJobQueuePauses(
+	ctx context.Context,
+	req *empty.Empty,
+) (*pb.ListJobQueuePausesResponse, error) {
+	pauses, err := s.state.JobQueuePauses()
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ListJobQueuePausesResponse{Pauses: pauses}, nil
+}
@@ -0,0 +1,73 @@
+package singleprocess
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func (s *service) SetJobTemplate(
+	ctx context.Context,
+	req *pb.SetJobTemplateRequest,
+) (*pb.JobTemplate, error) {
+	if req.Template == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "template must be set")
+	}
+
+	if err := s.state.JobTemplatePut(req.Template); err != nil {
+		return nil, err
+	}
+
+	return s.state.JobTemplateGet(req.Template.Name)
+}
+
+func (s *service) GetJobTemplate(
+	ctx context.Context,
+	req *pb.GetJobTemplateRequest,
+) (*pb.JobTemplate, error) {
+	return s.state.JobTemplateGet(req.Name)
+}
+
+func (s *service) ListJobTemplates(
+	ctx context.Context,
+	req *empty.Empty,
+) (*pb.ListJobTemplatesResponse, error) {
+	templates, err := s.state.JobTemplateList()
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ListJobTemplatesResponse{Templates: templates}, nil
+}
+
+func (s *service) DeleteJobTemplate(
+	ctx context.Context,
+	req *pb.DeleteJobTemplateRequest,
+) (*empty.Empty, error) {
+	if err := s.state.JobTemplateDelete(req.Name); err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
+
+// jobFromTemplate returns the job to queue from the template with the
+// given name. The fields set in job override the template's job.
+func (s *service) jobFromTemplate(name string, job *pb.Job) (*pb.Job, error) {
+	tmpl, err := s.state.JobTemplateGet(name)
+	if err != nil {
+		return nil, err
+	}
+
+	result := proto.Clone(tmpl.Job).(*pb.Job)
+	if job != nil {
+		proto.Merge(result, job)
+	}
+
+	return result, nil
+}
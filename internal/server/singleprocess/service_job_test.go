@@ -2,13 +2,18 @@ package singleprocess
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/hashicorp/waypoint/internal/server"
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
@@ -65,6 +70,262 @@ func TestServiceQueueJob(t *testing.T) {
 			return job.State == pb.Job_ERROR && job.CancelTime != nil
 		}, 2*time.Second, 10*time.Millisecond)
 	})
+
+	t.Run("dependencies", func(t *testing.T) {
+		require := require.New(t)
+
+		first, err := client.QueueJob(ctx, &Req{
+			Job: serverptypes.TestJobNew(t, nil),
+		})
+		require.NoError(err)
+
+		// Queue a job depending on it
+		resp, err := client.QueueJob(ctx, &Req{
+			Job: serverptypes.TestJobNew(t, &pb.Job{
+				DependsOn: []string{first.JobId},
+			}),
+		})
+		require.NoError(err)
+
+		job, err := testServiceImpl(impl).state.JobById(resp.JobId, nil)
+		require.NoError(err)
+		require.Equal(pb.Job_QUEUED, job.State)
+		require.Equal(first.JobId, job.BlockedBy)
+
+		// Dependencies must exist
+		_, err = client.QueueJob(ctx, &Req{
+			Job: serverptypes.TestJobNew(t, &pb.Job{
+				DependsOn: []string{"nope"},
+			}),
+		})
+		require.Error(err)
+		require.Equal(codes.NotFound, status.Code(err))
+	})
+
+	t.Run("template", func(t *testing.T) {
+		require := require.New(t)
+
+		_, err := client.SetJobTemplate(ctx, &pb.SetJobTemplateRequest{
+			Template: &pb.JobTemplate{
+				Name: "nightly",
+				Job: serverptypes.TestJobNew(t, &pb.Job{
+					Labels:   map[string]string{"kind": "nightly"},
+					Priority: 5,
+				}),
+			},
+		})
+		require.NoError(err)
+
+		// Queue from the template, overriding a field
+		resp, err := client.QueueJob(ctx, &Req{
+			Template: "nightly",
+			Job:      &pb.Job{Priority: 10},
+		})
+		require.NoError(err)
+
+		job, err := testServiceImpl(impl).state.JobById(resp.JobId, nil)
+		require.NoError(err)
+		require.Equal(pb.Job_QUEUED, job.State)
+		require.Equal("nightly", job.Labels["kind"])
+		require.Equal(int32(10), job.Priority)
+
+		// Queue from the template alone
+		resp2, err := client.QueueJob(ctx, &Req{Template: "nightly"})
+		require.NoError(err)
+		require.NotEqual(resp.JobId, resp2.JobId)
+
+		// Templates must exist
+		_, err = client.QueueJob(ctx, &Req{Template: "nope"})
+		require.Error(err)
+		require.Equal(codes.NotFound, status.Code(err))
+	})
+}
+
+func TestServiceCancelJobs(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	// Create our server
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+
+	// Initialize our app
+	job := serverptypes.TestJobNew(t, nil)
+	TestApp(t, client, job.Application)
+
+	// Queue a couple jobs
+	var ids []string
+	for i := 0; i < 2; i++ {
+		resp, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+			Job: serverptypes.TestJobNew(t, nil),
+		})
+		require.NoError(err)
+		ids = append(ids, resp.JobId)
+	}
+
+	// The project is required
+	_, err = client.CancelJobs(ctx, &pb.CancelJobsRequest{})
+	require.Error(err)
+	require.Equal(codes.FailedPrecondition, status.Code(err))
+
+	// Cancel them all
+	resp, err := client.CancelJobs(ctx, &pb.CancelJobsRequest{
+		Application: job.Application,
+		Workspace:   job.Workspace,
+	})
+	require.NoError(err)
+	require.ElementsMatch(ids, resp.JobIds)
+
+	for _, id := range ids {
+		job, err := testServiceImpl(impl).state.JobById(id, nil)
+		require.NoError(err)
+		require.Equal(pb.Job_ERROR, job.State)
+	}
+}
+
+func TestServiceDeleteJob(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	// Create our server
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+
+	// Initialize our app
+	job := serverptypes.TestJobNew(t, nil)
+	TestApp(t, client, job.Application)
+
+	resp, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+		Job: serverptypes.TestJobNew(t, nil),
+	})
+	require.NoError(err)
+
+	// Queued jobs can't be deleted
+	_, err = client.DeleteJob(ctx, &pb.DeleteJobRequest{JobId: resp.JobId})
+	require.Error(err)
+	require.Equal(codes.FailedPrecondition, status.Code(err))
+
+	// Once it is cancelled it can be
+	_, err = client.CancelJob(ctx, &pb.CancelJobRequest{JobId: resp.JobId})
+	require.NoError(err)
+	_, err = client.DeleteJob(ctx, &pb.DeleteJobRequest{JobId: resp.JobId})
+	require.NoError(err)
+
+	_, err = client.GetJob(ctx, &pb.GetJobRequest{JobId: resp.JobId})
+	require.Error(err)
+	require.Equal(codes.NotFound, status.Code(err))
+}
+
+func TestServiceListJobsStream(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	// Create our server
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+
+	// Initialize our app
+	job := serverptypes.TestJobNew(t, nil)
+	TestApp(t, client, job.Application)
+
+	ids := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		resp, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+			Job: serverptypes.TestJobNew(t, nil),
+		})
+		require.NoError(err)
+		ids[resp.JobId] = true
+	}
+
+	stream, err := client.ListJobsStream(ctx, &pb.ListJobsRequest{})
+	require.NoError(err)
+
+	var last string
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(err)
+
+		for _, job := range resp.Jobs {
+			require.True(ids[job.Id])
+			require.True(job.Id > last)
+			delete(ids, job.Id)
+			last = job.Id
+		}
+	}
+	require.Empty(ids)
+}
+
+func TestServiceQueueJob_terraformOutputs(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	var calls int32
+	tfState := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		fmt.Fprintf(w, `{"version": 4, "outputs": {"endpoint": {"value": "db-%d.internal"}}}`, n)
+	}))
+	defer tfState.Close()
+
+	// Create our server
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+	st := testServiceImpl(impl).state
+
+	// Initialize our app and add the Terraform outputs to the project
+	ref := serverptypes.TestJobNew(t, nil).Application
+	TestApp(t, client, ref)
+	project, err := st.ProjectGet(&pb.Ref_Project{Project: ref.Project})
+	require.NoError(err)
+	project.TerraformOutputs = []*pb.Project_TerraformOutputs{
+		{
+			Name:   "db",
+			Prefix: "db_",
+			Source: &pb.Project_TerraformOutputs_State_{
+				State: &pb.Project_TerraformOutputs_State{Url: tfState.URL},
+			},
+		},
+	}
+	require.NoError(st.ProjectPut(project))
+
+	// Builds get the outputs, refreshed each time they're queued
+	for _, expected := range []string{`"db-1.internal"`, `"db-2.internal"`} {
+		resp, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+			Job: serverptypes.TestJobNew(t, &pb.Job{
+				Operation: &pb.Job_Build{Build: &pb.Job_BuildOp{}},
+			}),
+		})
+		require.NoError(err)
+
+		job, err := st.JobById(resp.JobId, nil)
+		require.NoError(err)
+		require.Equal(map[string]string{"db_endpoint": expected}, job.Variables)
+	}
+
+	// Other operations don't
+	resp, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+		Job: serverptypes.TestJobNew(t, nil),
+	})
+	require.NoError(err)
+	job, err := st.JobById(resp.JobId, nil)
+	require.NoError(err)
+	require.Empty(job.Variables)
+
+	// Errors reading the outputs fail the queue
+	tfState.Close()
+	_, err = client.QueueJob(ctx, &pb.QueueJobRequest{
+		Job: serverptypes.TestJobNew(t, &pb.Job{
+			Operation: &pb.Job_Deploy{Deploy: &pb.Job_DeployOp{}},
+		}),
+	})
+	require.Error(err)
+	require.Equal(codes.FailedPrecondition, status.Code(err))
 }
 
 func TestServiceValidateJob(t *testing.T) {
@@ -146,7 +407,7 @@ func TestServiceGetJobStream_complete(t *testing.T) {
 		assignment, ok := resp.Event.(*pb.RunnerJobStreamResponse_Assignment)
 		require.True(ok, "should be an assignment")
 		require.NotNil(assignment)
-		require.Equal(queueResp.JobId, assignment.Assignment.Job.Id)
+		require.Equal(queueResp.JobId, testAssignmentPayload(t, assignment.Assignment).Job.Id)
 
 		require.NoError(runnerStream.Send(&pb.RunnerJobStreamRequest{
 			Event: &pb.RunnerJobStreamRequest_Ack_{
@@ -272,7 +533,7 @@ func TestServiceGetJobStream_bufferedData(t *testing.T) {
 		assignment, ok := resp.Event.(*pb.RunnerJobStreamResponse_Assignment)
 		require.True(ok, "should be an assignment")
 		require.NotNil(assignment)
-		require.Equal(queueResp.JobId, assignment.Assignment.Job.Id)
+		require.Equal(queueResp.JobId, testAssignmentPayload(t, assignment.Assignment).Job.Id)
 
 		require.NoError(runnerStream.Send(&pb.RunnerJobStreamRequest{
 			Event: &pb.RunnerJobStreamRequest_Ack_{
@@ -347,6 +608,306 @@ func TestServiceGetJobStream_bufferedData(t *testing.T) {
 	}
 }
 
+func TestServiceGetJobStream_level(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	// Create our server
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+
+	// Initialize our app
+	TestApp(t, client, serverptypes.TestJobNew(t, nil).Application)
+
+	// Create a job
+	queueResp, err := client.QueueJob(ctx, &pb.QueueJobRequest{Job: serverptypes.TestJobNew(t, nil)})
+	require.NoError(err)
+
+	// Register our runner
+	id, _ := TestRunner(t, client, nil)
+
+	// Start a job request
+	runnerStream, err := client.RunnerJobStream(ctx)
+	require.NoError(err)
+	require.NoError(runnerStream.Send(&pb.RunnerJobStreamRequest{
+		Event: &pb.RunnerJobStreamRequest_Request_{
+			Request: &pb.RunnerJobStreamRequest_Request{
+				RunnerId: id,
+			},
+		},
+	}))
+
+	// Wait for assignment and ack
+	{
+		resp, err := runnerStream.Recv()
+		require.NoError(err)
+		_, ok := resp.Event.(*pb.RunnerJobStreamResponse_Assignment)
+		require.True(ok, "should be an assignment")
+
+		require.NoError(runnerStream.Send(&pb.RunnerJobStreamRequest{
+			Event: &pb.RunnerJobStreamRequest_Ack_{
+				Ack: &pb.RunnerJobStreamRequest_Ack{},
+			},
+		}))
+	}
+
+	// Send an info line and an error line
+	require.NoError(runnerStream.Send(&pb.RunnerJobStreamRequest{
+		Event: &pb.RunnerJobStreamRequest_Terminal{
+			Terminal: &pb.GetJobStreamResponse_Terminal{
+				Events: []*pb.GetJobStreamResponse_Terminal_Event{
+					{
+						Event: &pb.GetJobStreamResponse_Terminal_Event_Line_{
+							Line: &pb.GetJobStreamResponse_Terminal_Event_Line{
+								Msg: "hello",
+							},
+						},
+					},
+					{
+						Event: &pb.GetJobStreamResponse_Terminal_Event_Line_{
+							Line: &pb.GetJobStreamResponse_Terminal_Event_Line{
+								Msg:   "failed",
+								Style: "error",
+							},
+						},
+					},
+				},
+			},
+		},
+	}))
+
+	// Complete the job
+	require.NoError(runnerStream.Send(&pb.RunnerJobStreamRequest{
+		Event: &pb.RunnerJobStreamRequest_Complete_{
+			Complete: &pb.RunnerJobStreamRequest_Complete{},
+		},
+	}))
+	_, err = runnerStream.Recv()
+	require.Equal(io.EOF, err)
+
+	// Only the warnings and errors are streamed
+	stream, err := client.GetJobStream(ctx, &pb.GetJobStreamRequest{
+		JobId: queueResp.JobId,
+		Level: pb.GetJobStreamResponse_Terminal_Event_WARN,
+	})
+	require.NoError(err)
+	{
+		resp := jobStreamRecv(t, stream, (*pb.GetJobStreamResponse_Terminal_)(nil))
+		event := resp.Event.(*pb.GetJobStreamResponse_Terminal_)
+		require.Len(event.Terminal.Events, 1)
+
+		ev := event.Terminal.Events[0]
+		require.Equal(pb.GetJobStreamResponse_Terminal_Event_ERROR, ev.Level)
+		require.Equal("failed", ev.Event.(*pb.GetJobStreamResponse_Terminal_Event_Line_).Line.Msg)
+	}
+
+	{
+		resp := jobStreamRecv(t, stream, (*pb.GetJobStreamResponse_Complete_)(nil))
+		event := resp.Event.(*pb.GetJobStreamResponse_Complete_)
+		require.Nil(event.Complete.Error)
+	}
+}
+
+func TestServiceGetJobStream_offset(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	// Create our server
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+
+	// Initialize our app
+	TestApp(t, client, serverptypes.TestJobNew(t, nil).Application)
+
+	// Create a job
+	queueResp, err := client.QueueJob(ctx, &pb.QueueJobRequest{Job: serverptypes.TestJobNew(t, nil)})
+	require.NoError(err)
+
+	// Register our runner
+	id, _ := TestRunner(t, client, nil)
+
+	// Start a job request
+	runnerStream, err := client.RunnerJobStream(ctx)
+	require.NoError(err)
+	require.NoError(runnerStream.Send(&pb.RunnerJobStreamRequest{
+		Event: &pb.RunnerJobStreamRequest_Request_{
+			Request: &pb.RunnerJobStreamRequest_Request{
+				RunnerId: id,
+			},
+		},
+	}))
+
+	// Wait for assignment and ack
+	{
+		resp, err := runnerStream.Recv()
+		require.NoError(err)
+		_, ok := resp.Event.(*pb.RunnerJobStreamResponse_Assignment)
+		require.True(ok, "should be an assignment")
+
+		require.NoError(runnerStream.Send(&pb.RunnerJobStreamRequest{
+			Event: &pb.RunnerJobStreamRequest_Ack_{
+				Ack: &pb.RunnerJobStreamRequest_Ack{},
+			},
+		}))
+	}
+
+	// Send some lines
+	line := func(msg string) *pb.GetJobStreamResponse_Terminal_Event {
+		return &pb.GetJobStreamResponse_Terminal_Event{
+			Event: &pb.GetJobStreamResponse_Terminal_Event_Line_{
+				Line: &pb.GetJobStreamResponse_Terminal_Event_Line{Msg: msg},
+			},
+		}
+	}
+	require.NoError(runnerStream.Send(&pb.RunnerJobStreamRequest{
+		Event: &pb.RunnerJobStreamRequest_Terminal{
+			Terminal: &pb.GetJobStreamResponse_Terminal{
+				Events: []*pb.GetJobStreamResponse_Terminal_Event{
+					line("a"), line("b"), line("c"),
+				},
+			},
+		},
+	}))
+
+	// Complete the job
+	require.NoError(runnerStream.Send(&pb.RunnerJobStreamRequest{
+		Event: &pb.RunnerJobStreamRequest_Complete_{
+			Complete: &pb.RunnerJobStreamRequest_Complete{},
+		},
+	}))
+	_, err = runnerStream.Recv()
+	require.Equal(io.EOF, err)
+
+	// Stream all the output
+	stream, err := client.GetJobStream(ctx, &pb.GetJobStreamRequest{
+		JobId: queueResp.JobId,
+	})
+	require.NoError(err)
+	var next int64
+	{
+		resp := jobStreamRecv(t, stream, (*pb.GetJobStreamResponse_Terminal_)(nil))
+		event := resp.Event.(*pb.GetJobStreamResponse_Terminal_)
+		require.Len(event.Terminal.Events, 3)
+		next = event.Terminal.NextOffset
+		require.Equal(int64(3), next)
+	}
+
+	// Resume a stream before the last line
+	stream, err = client.GetJobStream(ctx, &pb.GetJobStreamRequest{
+		JobId:  queueResp.JobId,
+		Offset: next - 1,
+	})
+	require.NoError(err)
+	{
+		resp := jobStreamRecv(t, stream, (*pb.GetJobStreamResponse_Terminal_)(nil))
+		event := resp.Event.(*pb.GetJobStreamResponse_Terminal_)
+		require.Len(event.Terminal.Events, 1)
+		require.Equal("c", event.Terminal.Events[0].Event.(*pb.GetJobStreamResponse_Terminal_Event_Line_).Line.Msg)
+		require.Equal(next, event.Terminal.NextOffset)
+	}
+
+	{
+		resp := jobStreamRecv(t, stream, (*pb.GetJobStreamResponse_Complete_)(nil))
+		event := resp.Event.(*pb.GetJobStreamResponse_Complete_)
+		require.Nil(event.Complete.Error)
+	}
+}
+
+func TestServiceGetJobStream_redacted(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	// Create our server
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+	testServiceImpl(impl).state.Redactor().SetValues([]string{"hunter22"})
+
+	// Initialize our app
+	TestApp(t, client, serverptypes.TestJobNew(t, nil).Application)
+
+	// Create a job with a sensitive value in it
+	queueResp, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+		Job: serverptypes.TestJobNew(t, &pb.Job{
+			Labels: map[string]string{"password": "hunter22"},
+		}),
+	})
+	require.NoError(err)
+
+	// Users get the redacted job
+	job, err := client.GetJob(ctx, &pb.GetJobRequest{JobId: queueResp.JobId})
+	require.NoError(err)
+	require.Equal("<sensitive>", job.Labels["password"])
+
+	// Register our runner
+	id, _ := TestRunner(t, client, nil)
+
+	// Start a job request
+	runnerStream, err := client.RunnerJobStream(ctx)
+	require.NoError(err)
+	require.NoError(runnerStream.Send(&pb.RunnerJobStreamRequest{
+		Event: &pb.RunnerJobStreamRequest_Request_{
+			Request: &pb.RunnerJobStreamRequest_Request{
+				RunnerId: id,
+			},
+		},
+	}))
+
+	// The job is stored redacted so the runner gets the redacted job too
+	{
+		resp, err := runnerStream.Recv()
+		require.NoError(err)
+		assignment, ok := resp.Event.(*pb.RunnerJobStreamResponse_Assignment)
+		require.True(ok, "should be an assignment")
+		require.Equal("<sensitive>", testAssignmentPayload(t, assignment.Assignment).Job.Labels["password"])
+
+		require.NoError(runnerStream.Send(&pb.RunnerJobStreamRequest{
+			Event: &pb.RunnerJobStreamRequest_Ack_{
+				Ack: &pb.RunnerJobStreamRequest_Ack{},
+			},
+		}))
+	}
+
+	// Send output that accidentally includes the sensitive value
+	require.NoError(runnerStream.Send(&pb.RunnerJobStreamRequest{
+		Event: &pb.RunnerJobStreamRequest_Terminal{
+			Terminal: &pb.GetJobStreamResponse_Terminal{
+				Events: []*pb.GetJobStreamResponse_Terminal_Event{
+					{
+						Event: &pb.GetJobStreamResponse_Terminal_Event_Line_{
+							Line: &pb.GetJobStreamResponse_Terminal_Event_Line{
+								Msg: "logging in with hunter22",
+							},
+						},
+					},
+				},
+			},
+		},
+	}))
+
+	// Complete the job
+	require.NoError(runnerStream.Send(&pb.RunnerJobStreamRequest{
+		Event: &pb.RunnerJobStreamRequest_Complete_{
+			Complete: &pb.RunnerJobStreamRequest_Complete{},
+		},
+	}))
+	_, err = runnerStream.Recv()
+	require.Equal(io.EOF, err)
+
+	// The output is redacted
+	stream, err := client.GetJobStream(ctx, &pb.GetJobStreamRequest{JobId: queueResp.JobId})
+	require.NoError(err)
+	{
+		resp := jobStreamRecv(t, stream, (*pb.GetJobStreamResponse_Terminal_)(nil))
+		event := resp.Event.(*pb.GetJobStreamResponse_Terminal_)
+		require.Len(event.Terminal.Events, 1)
+		require.Equal("logging in with <sensitive>",
+			event.Terminal.Events[0].GetLine().Msg)
+	}
+}
+
 func TestServiceGetJobStream_expired(t *testing.T) {
 	ctx := context.Background()
 	require := require.New(t)
@@ -378,6 +939,112 @@ func TestServiceGetJobStream_expired(t *testing.T) {
 	}
 }
 
+func TestServiceGetJobStream_detail(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	// Create our server
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+	s := testServiceImpl(impl)
+
+	// Initialize our app
+	TestApp(t, client, serverptypes.TestJobNew(t, nil).Application)
+
+	// Create two deploys that block each other
+	var ids []string
+	for i := 0; i < 2; i++ {
+		queueResp, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+			Job: serverptypes.TestJobNew(t, &pb.Job{
+				Operation: &pb.Job_Deploy{Deploy: &pb.Job_DeployOp{}},
+			}),
+		})
+		require.NoError(err)
+		ids = append(ids, queueResp.JobId)
+	}
+
+	// Run the first one
+	job, err := s.state.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+	require.NoError(err)
+	require.Equal(ids[0], job.Id)
+	_, err = s.state.JobAck(context.Background(), job.Id, true)
+	require.NoError(err)
+
+	// The first job has a heartbeat deadline
+	{
+		stream, err := client.GetJobStream(ctx, &pb.GetJobStreamRequest{
+			JobId:  ids[0],
+			Detail: true,
+		})
+		require.NoError(err)
+
+		resp := jobStreamRecv(t, stream, (*pb.GetJobStreamResponse_Detail_)(nil))
+		detail := resp.Event.(*pb.GetJobStreamResponse_Detail_).Detail
+		require.Empty(detail.BlockedBy)
+		require.NotNil(detail.HeartbeatTime)
+		require.NotNil(detail.HeartbeatDeadline)
+	}
+
+	// The second job is blocked by the first
+	stream, err := client.GetJobStream(ctx, &pb.GetJobStreamRequest{
+		JobId:  ids[1],
+		Detail: true,
+	})
+	require.NoError(err)
+
+	resp := jobStreamRecv(t, stream, (*pb.GetJobStreamResponse_Detail_)(nil))
+	detail := resp.Event.(*pb.GetJobStreamResponse_Detail_).Detail
+	require.Equal(ids[0], detail.BlockedBy)
+	require.Nil(detail.HeartbeatDeadline)
+
+	// Once the first completes, it is no longer blocked
+	require.NoError(s.state.JobComplete(context.Background(), ids[0], nil, nil))
+	resp = jobStreamRecv(t, stream, (*pb.GetJobStreamResponse_Detail_)(nil))
+	detail = resp.Event.(*pb.GetJobStreamResponse_Detail_).Detail
+	require.Empty(detail.BlockedBy)
+}
+
+func TestServiceGetJobStream_progress(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	// Create our server
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+	s := testServiceImpl(impl)
+
+	// Initialize our app
+	TestApp(t, client, serverptypes.TestJobNew(t, nil).Application)
+
+	// Run a job
+	queueResp, err := client.QueueJob(ctx, &pb.QueueJobRequest{Job: serverptypes.TestJobNew(t, nil)})
+	require.NoError(err)
+	job, err := s.state.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+	require.NoError(err)
+	require.Equal(queueResp.JobId, job.Id)
+	_, err = s.state.JobAck(context.Background(), job.Id, true)
+	require.NoError(err)
+
+	stream, err := client.GetJobStream(ctx, &pb.GetJobStreamRequest{JobId: job.Id})
+	require.NoError(err)
+
+	// Report progress
+	for _, p := range []*pb.JobProgress{
+		{Step: "Building image", Percent: 25},
+		{Step: "Pushing image", Percent: 75},
+	} {
+		require.NoError(s.state.JobProgress(job.Id, p))
+
+		resp := jobStreamRecv(t, stream, (*pb.GetJobStreamResponse_Progress)(nil))
+		progress := resp.Event.(*pb.GetJobStreamResponse_Progress).Progress
+		require.Equal(p.Step, progress.Step)
+		require.Equal(p.Percent, progress.Percent)
+		require.NotNil(progress.UpdateTime)
+	}
+}
+
 // jobStreamRecv receives on the stream until an event of the given
 // type is matched.
 func jobStreamRecv(
@@ -0,0 +1,126 @@
+package singleprocess
+
+import (
+	"sort"
+	"strings"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/singleprocess/state"
+)
+
+func (s *service) WatchJobs(
+	req *pb.WatchJobsRequest,
+	srv pb.Waypoint_WatchJobsServer,
+) error {
+	ctx := srv.Context()
+
+	// last is the state of each job that was last sent. If we aren't
+	// sending the current states then the first states are only recorded.
+	var last map[string]pb.Job_State
+	if req.Current {
+		last = map[string]pb.Job_State{}
+	}
+
+	for {
+		// Subscribe before we read the states so we don't miss changes.
+		sub := s.state.EventSubscribe(state.EventTopicJob)
+		states, err := s.state.JobStates(req.Project, nil)
+		if err != nil {
+			sub.Close()
+			return err
+		}
+
+		// Send the transitions since the states we last sent. The first
+		// time, or if we fell behind on events, this is all the changes.
+		if last == nil {
+			last = states
+		} else {
+			var ids []string
+			for id, st := range states {
+				if prev, ok := last[id]; !ok || prev != st {
+					ids = append(ids, id)
+				}
+			}
+			sort.Strings(ids)
+
+			for _, id := range ids {
+				if err := s.watchJobsSend(srv, last, id); err != nil {
+					sub.Close()
+					return err
+				}
+			}
+		}
+
+		// Send each job that changes until we fall behind on events.
+		err = s.watchJobsEvents(srv, req, sub, last)
+		sub.Close()
+		if err != nil || ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+// watchJobsEvents sends the job transitions for the job events until the
+// subscription is closed or the stream ends.
+func (s *service) watchJobsEvents(
+	srv pb.Waypoint_WatchJobsServer,
+	req *pb.WatchJobsRequest,
+	sub *state.EventSubscription,
+	last map[string]pb.Job_State,
+) error {
+	ctx := srv.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case ev, ok := <-sub.Events():
+			if !ok {
+				return nil
+			}
+
+			if req.Project != "" && !strings.EqualFold(ev.Project, req.Project) {
+				continue
+			}
+
+			if err := s.watchJobsSend(srv, last, ev.ID); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// watchJobsSend sends the job if its state changed from the last state
+// sent and records the state that was sent.
+func (s *service) watchJobsSend(
+	srv pb.Waypoint_WatchJobsServer,
+	last map[string]pb.Job_State,
+	id string,
+) error {
+	job, err := s.state.JobById(id, nil)
+	if err != nil {
+		return err
+	}
+	if job == nil {
+		// Deleted since it changed
+		delete(last, id)
+		return nil
+	}
+
+	prev, ok := last[id]
+	if ok && prev == job.State {
+		return nil
+	}
+
+	if err := srv.Send(&pb.WatchJobsResponse{
+		Job:      s.redactJob(job.Job),
+		Previous: prev,
+	}); err != nil {
+		return err
+	}
+
+	// The job may have changed state since we got the event, so record
+	// the state we sent.
+	last[id] = job.State
+	return nil
+}
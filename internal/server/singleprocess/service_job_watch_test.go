@@ -0,0 +1,63 @@
+package singleprocess
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint/internal/server"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestServiceWatchJobs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require := require.New(t)
+
+	// Create our server
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+
+	// Initialize our app
+	TestApp(t, client, serverptypes.TestJobNew(t, nil).Application)
+
+	// Create a job
+	first, err := client.QueueJob(ctx, &pb.QueueJobRequest{Job: serverptypes.TestJobNew(t, nil)})
+	require.NoError(err)
+
+	// Watch with the current states
+	stream, err := client.WatchJobs(ctx, &pb.WatchJobsRequest{
+		Project: "P_TEST",
+		Current: true,
+	})
+	require.NoError(err)
+
+	resp, err := stream.Recv()
+	require.NoError(err)
+	require.Equal(first.JobId, resp.Job.Id)
+	require.Equal(pb.Job_QUEUED, resp.Job.State)
+	require.Equal(pb.Job_UNKNOWN, resp.Previous)
+
+	// Cancel the job
+	_, err = client.CancelJob(ctx, &pb.CancelJobRequest{JobId: first.JobId})
+	require.NoError(err)
+
+	resp, err = stream.Recv()
+	require.NoError(err)
+	require.Equal(first.JobId, resp.Job.Id)
+	require.Equal(pb.Job_ERROR, resp.Job.State)
+	require.Equal(pb.Job_QUEUED, resp.Previous)
+
+	// Create another job
+	second, err := client.QueueJob(ctx, &pb.QueueJobRequest{Job: serverptypes.TestJobNew(t, nil)})
+	require.NoError(err)
+
+	resp, err = stream.Recv()
+	require.NoError(err)
+	require.Equal(second.JobId, resp.Job.Id)
+	require.Equal(pb.Job_QUEUED, resp.Job.State)
+	require.Equal(pb.Job_UNKNOWN, resp.Previous)
+}
@@ -0,0 +1,324 @@
+package singleprocess
+
+import (
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-memdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/logbuffer"
+)
+
+// deploymentLogBatchSize is the most entries sent in a single response.
+const deploymentLogBatchSize = 64
+
+// deploymentLogEntry is an entry of a deployment log stream along with the
+// time it is ordered by.
+type deploymentLogEntry struct {
+	time  time.Time
+	entry *pb.GetDeploymentLogStreamResponse_Entry
+}
+
+func (s *service) GetDeploymentLogStream(
+	req *pb.GetDeploymentLogStreamRequest,
+	srv pb.Waypoint_GetDeploymentLogStreamServer,
+) error {
+	ctx := srv.Context()
+	log := hclog.FromContext(ctx).With("deployment_id", req.DeploymentId)
+
+	if req.DeploymentId == "" {
+		return status.Errorf(codes.InvalidArgument, "deployment ID is required")
+	}
+
+	// Default the limit
+	if req.LimitBacklog <= 0 {
+		req.LimitBacklog = defaultLogLimitBacklog
+	}
+
+	deployment, err := s.state.DeploymentGet(&pb.Ref_Operation{
+		Target: &pb.Ref_Operation_Id{Id: req.DeploymentId},
+	})
+	if err != nil {
+		return err
+	}
+
+	var backlog []deploymentLogEntry
+
+	// The output of the job that created the deployment comes first. We
+	// only keep reading the output buffer if the job is still running.
+	var jobReader *logbuffer.Reader
+	if deployment.JobId != "" {
+		job, err := s.state.JobById(deployment.JobId, nil)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case job == nil:
+			// The job was pruned, so there is no output to send.
+
+		case job.OutputBuffer != nil:
+			r := job.OutputBuffer.Reader(-1)
+			go r.CloseContext(ctx)
+			for {
+				entries := r.Read(deploymentLogBatchSize, false)
+				if entries == nil {
+					break
+				}
+
+				backlog = deploymentLogJobEntries(backlog, entries)
+			}
+
+			if req.Follow && job.State != pb.Job_SUCCESS && job.State != pb.Job_ERROR {
+				jobReader = r
+			} else {
+				r.Close()
+			}
+
+		default:
+			// The job completed, so we read its persisted output.
+			err := s.state.JobOutputEach(job.Id, func(entries []logbuffer.Entry) error {
+				backlog = deploymentLogJobEntries(backlog, entries)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Then the backlog of the logs of each instance.
+	ws := memdb.NewWatchSet()
+	instances, err := s.state.InstancesByDeployment(req.DeploymentId, ws)
+	if err != nil {
+		return err
+	}
+	instanceReaders := map[string]*logbuffer.Reader{}
+	for _, inst := range instances {
+		r := inst.LogBuffer.Reader(req.LimitBacklog)
+		go r.CloseContext(ctx)
+		for {
+			entries := r.Read(deploymentLogBatchSize, false)
+			if entries == nil {
+				break
+			}
+
+			backlog = deploymentLogInstanceEntries(backlog, inst.Id, entries)
+		}
+
+		instanceReaders[inst.Id] = r
+	}
+	log.Trace("sending backlog", "entries", len(backlog), "instances", len(instances))
+
+	// Merge the backlog into a single chronological stream. This is a
+	// stable sort so entries with the same time stay in the order they
+	// were written.
+	sort.SliceStable(backlog, func(i, j int) bool {
+		return backlog[i].time.Before(backlog[j].time)
+	})
+	for len(backlog) > 0 {
+		n := len(backlog)
+		if n > deploymentLogBatchSize {
+			n = deploymentLogBatchSize
+		}
+
+		if err := srv.Send(&pb.GetDeploymentLogStreamResponse{
+			Entries:  deploymentLogPbEntries(backlog[:n]),
+			Buffered: true,
+		}); err != nil {
+			return err
+		}
+
+		backlog = backlog[n:]
+	}
+
+	if !req.Follow {
+		return nil
+	}
+
+	// All the readers send to a single channel since the stream can't be
+	// sent to concurrently.
+	sendCh := make(chan []deploymentLogEntry)
+	follow := func(
+		r *logbuffer.Reader,
+		convert func([]deploymentLogEntry, []logbuffer.Entry) []deploymentLogEntry,
+	) {
+		go func() {
+			for {
+				entries := r.Read(deploymentLogBatchSize, true)
+				if entries == nil {
+					return
+				}
+
+				select {
+				case sendCh <- convert(nil, entries):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	followInstance := func(id string, r *logbuffer.Reader) {
+		follow(r, func(
+			result []deploymentLogEntry,
+			entries []logbuffer.Entry,
+		) []deploymentLogEntry {
+			return deploymentLogInstanceEntries(result, id, entries)
+		})
+	}
+
+	if jobReader != nil {
+		follow(jobReader, deploymentLogJobEntries)
+	}
+	for id, r := range instanceReaders {
+		followInstance(id, r)
+	}
+
+	// Watch for new instances of the deployment. The readers map is only
+	// used by this goroutine from here on.
+	errCh := make(chan error, 1)
+	go func() {
+		for {
+			if err := ws.WatchCtx(ctx); err != nil {
+				return
+			}
+
+			ws = memdb.NewWatchSet()
+			instances, err := s.state.InstancesByDeployment(req.DeploymentId, ws)
+			if err != nil {
+				errCh <- err
+				return
+			}
+
+			for _, inst := range instances {
+				if _, ok := instanceReaders[inst.Id]; ok {
+					continue
+				}
+
+				log.Trace("instance log stream starting", "instance_id", inst.Id)
+				r := inst.LogBuffer.Reader(req.LimitBacklog)
+				go r.CloseContext(ctx)
+				instanceReaders[inst.Id] = r
+				followInstance(inst.Id, r)
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case err := <-errCh:
+			return err
+
+		case entries := <-sendCh:
+			if len(entries) == 0 {
+				continue
+			}
+
+			if err := srv.Send(&pb.GetDeploymentLogStreamResponse{
+				Entries: deploymentLogPbEntries(entries),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// deploymentLogJobEntries appends the entries of the output of a job to
+// result. Events are ordered by when the server received them since the
+// clock of the runner may be skewed. Events without a time inherit the
+// time of the event before them.
+func deploymentLogJobEntries(
+	result []deploymentLogEntry,
+	entries []logbuffer.Entry,
+) []deploymentLogEntry {
+	var last time.Time
+	for _, entry := range entries {
+		ev, ok := entry.(*logbuffer.Event)
+		if !ok {
+			continue
+		}
+		pbev, ok := ev.Data.(*pb.GetJobStreamResponse_Terminal_Event)
+		if !ok {
+			continue
+		}
+
+		ts := ev.ReceiveTime
+		if ts.IsZero() {
+			ts = ev.Timestamp
+		}
+		if ts.IsZero() {
+			ts = last
+		}
+		last = ts
+
+		result = append(result, deploymentLogEntry{
+			time: ts,
+			entry: &pb.GetDeploymentLogStreamResponse_Entry{
+				Source: &pb.GetDeploymentLogStreamResponse_Entry_Job{
+					Job: pbev,
+				},
+			},
+		})
+	}
+
+	return result
+}
+
+// deploymentLogInstanceEntries appends the log entries of an instance to
+// result. Entries without a time inherit the time of the entry before them.
+func deploymentLogInstanceEntries(
+	result []deploymentLogEntry,
+	id string,
+	entries []logbuffer.Entry,
+) []deploymentLogEntry {
+	var last time.Time
+	for _, entry := range entries {
+		line := entry.(*pb.LogBatch_Entry)
+
+		ts := last
+		if line.Timestamp != nil {
+			if t, err := ptypes.Timestamp(line.Timestamp); err == nil {
+				ts = t
+			}
+		}
+		last = ts
+
+		result = append(result, deploymentLogEntry{
+			time: ts,
+			entry: &pb.GetDeploymentLogStreamResponse_Entry{
+				Source: &pb.GetDeploymentLogStreamResponse_Entry_Instance{
+					Instance: &pb.GetDeploymentLogStreamResponse_InstanceLog{
+						InstanceId: id,
+						Entry:      line,
+					},
+				},
+			},
+		})
+	}
+
+	return result
+}
+
+// deploymentLogPbEntries returns the entries to send with their time set.
+func deploymentLogPbEntries(
+	entries []deploymentLogEntry,
+) []*pb.GetDeploymentLogStreamResponse_Entry {
+	result := make([]*pb.GetDeploymentLogStreamResponse_Entry, len(entries))
+	for i, e := range entries {
+		result[i] = e.entry
+		if !e.time.IsZero() {
+			// The time was converted from a timestamp so this can't fail.
+			result[i].Timestamp, _ = ptypes.TimestampProto(e.time)
+		}
+	}
+
+	return result
+}
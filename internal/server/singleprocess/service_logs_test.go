@@ -2,11 +2,16 @@ package singleprocess
 
 import (
 	"context"
+	"io"
 	"strconv"
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/hashicorp/waypoint/internal/server"
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
@@ -158,3 +163,131 @@ func TestServiceGetLogStream_byApp(t *testing.T) {
 	require.NotEmpty(batch.Lines)
 	require.Len(batch.Lines, 25)
 }
+
+func TestServiceGetDeploymentLogStream(t *testing.T) {
+	ctx := context.Background()
+
+	// Create our server
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(t, err)
+	client := server.TestServer(t, impl)
+	s := testServiceImpl(impl)
+	TestApp(t, client, serverptypes.TestJobNew(t, nil).Application)
+
+	// Run the job that creates the deployment
+	queued, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+		Job: serverptypes.TestJobNew(t, nil),
+	})
+	require.NoError(t, err)
+	job, err := s.state.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+	require.NoError(t, err)
+	require.Equal(t, queued.JobId, job.Id)
+	_, err = s.state.JobAck(ctx, job.Id, true)
+	require.NoError(t, err)
+	require.NoError(t, s.state.JobOutputWrite(job.Id, []*pb.GetJobStreamResponse_Terminal_Event{
+		{
+			Event: &pb.GetJobStreamResponse_Terminal_Event_Line_{
+				Line: &pb.GetJobStreamResponse_Terminal_Event_Line{Msg: "deploying"},
+			},
+		},
+	}))
+	require.NoError(t, s.state.JobComplete(ctx, job.Id, nil, nil))
+
+	// Register an instance of the deployment
+	resp, err := client.UpsertDeployment(ctx, &pb.UpsertDeploymentRequest{
+		Deployment: serverptypes.TestValidDeployment(t, &pb.Deployment{
+			Component: &pb.Component{
+				Name: "testapp",
+			},
+			JobId: job.Id,
+		}),
+	})
+	require.NoError(t, err)
+	dep := resp.Deployment
+	configClient, err := client.EntrypointConfig(ctx, &pb.EntrypointConfigRequest{
+		DeploymentId: dep.Id,
+		InstanceId:   "1",
+	})
+	require.NoError(t, err)
+	_, err = configClient.Recv()
+	require.NoError(t, err)
+
+	// Send logs from before and after the job
+	logSendClient, err := client.EntrypointLogStream(ctx)
+	require.NoError(t, err)
+	ts := func(d time.Duration) *timestamp.Timestamp {
+		result, err := ptypes.TimestampProto(time.Now().Add(d))
+		require.NoError(t, err)
+		return result
+	}
+	require.NoError(t, logSendClient.Send(&pb.EntrypointLogBatch{
+		InstanceId: "1",
+		Lines: []*pb.LogBatch_Entry{
+			{Line: "early", Timestamp: ts(-time.Hour)},
+			{Line: "late", Timestamp: ts(time.Hour)},
+		},
+	}))
+	time.Sleep(100 * time.Millisecond)
+
+	t.Run("backlog", func(t *testing.T) {
+		require := require.New(t)
+
+		stream, err := client.GetDeploymentLogStream(ctx, &pb.GetDeploymentLogStreamRequest{
+			DeploymentId: dep.Id,
+		})
+		require.NoError(err)
+		resp, err := stream.Recv()
+		require.NoError(err)
+		require.True(resp.Buffered)
+
+		// The job output is merged with the logs by time
+		require.Len(resp.Entries, 3)
+		require.Equal("early", resp.Entries[0].GetInstance().Entry.Line)
+		require.Equal("1", resp.Entries[0].GetInstance().InstanceId)
+		require.Equal("deploying", resp.Entries[1].GetJob().GetLine().Msg)
+		require.NotNil(resp.Entries[1].Timestamp)
+		require.Equal("late", resp.Entries[2].GetInstance().Entry.Line)
+
+		// Without follow the stream ends after the backlog
+		_, err = stream.Recv()
+		require.Equal(io.EOF, err)
+	})
+
+	t.Run("follow", func(t *testing.T) {
+		require := require.New(t)
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		stream, err := client.GetDeploymentLogStream(ctx, &pb.GetDeploymentLogStreamRequest{
+			DeploymentId: dep.Id,
+			Follow:       true,
+		})
+		require.NoError(err)
+		resp, err := stream.Recv()
+		require.NoError(err)
+		require.Len(resp.Entries, 3)
+
+		// New logs are sent as they're written
+		require.NoError(logSendClient.Send(&pb.EntrypointLogBatch{
+			InstanceId: "1",
+			Lines:      []*pb.LogBatch_Entry{{Line: "new"}},
+		}))
+		resp, err = stream.Recv()
+		require.NoError(err)
+		require.False(resp.Buffered)
+		require.Len(resp.Entries, 1)
+		require.Equal("new", resp.Entries[0].GetInstance().Entry.Line)
+	})
+
+	t.Run("deployment not found", func(t *testing.T) {
+		require := require.New(t)
+
+		stream, err := client.GetDeploymentLogStream(ctx, &pb.GetDeploymentLogStreamRequest{
+			DeploymentId: "nope",
+		})
+		require.NoError(err)
+		_, err = stream.Recv()
+		require.Equal(codes.NotFound, status.Code(err))
+	})
+}
@@ -0,0 +1,70 @@
+package singleprocess
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/hashicorp/waypoint/internal/notify"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+// notifyTimeout is the maximum time spent sending the notifications for
+// a single event.
+const notifyTimeout = 30 * time.Second
+
+// notifyJobComplete sends the notifications for a job that completed.
+// This is called by the state store for every completed job.
+func (s *service) notifyJobComplete(log hclog.Logger, job *pb.Job) {
+	var event pb.Project_Notification_Event
+	switch {
+	case job.State == pb.Job_ERROR:
+		event = pb.Project_Notification_JOB_FAILED
+
+	case job.State == pb.Job_SUCCESS && job.GetDeploy() != nil:
+		event = pb.Project_Notification_DEPLOY_COMPLETE
+
+	default:
+		return
+	}
+
+	s.notifyJob(log, event, job)
+}
+
+// notifyJob sends the notifications configured for the project of the job
+// for the event. Errors are logged since there is no caller to return
+// them to.
+func (s *service) notifyJob(log hclog.Logger, event pb.Project_Notification_Event, job *pb.Job) {
+	if job.Application == nil {
+		return
+	}
+
+	project, err := s.state.ProjectGet(&pb.Ref_Project{Project: job.Application.Project})
+	if err != nil {
+		log.Warn("error reading project for notifications", "job", job.Id, "err", err)
+		return
+	}
+	if len(project.Notifications) == 0 {
+		return
+	}
+
+	msg := &notify.Message{
+		Event:       event,
+		Project:     job.Application.Project,
+		Application: job.Application.Application,
+		Workspace:   job.Workspace.GetWorkspace(),
+		JobId:       job.Id,
+		Operation:   serverptypes.JobOperation(job),
+		Error:       job.Error.GetMessage(),
+		Approvals:   job.ApprovalRequired.GetApprovals(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	if err := s.notifier.Notify(ctx, project.Notifications, msg); err != nil {
+		log.Warn("error sending notification", "job", job.Id,
+			"event", notify.EventName(event), "err", err)
+	}
+}
@@ -0,0 +1,109 @@
+package singleprocess
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint/internal/server"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestServiceNotify(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	textCh := make(chan string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ Text string }
+		json.NewDecoder(r.Body).Decode(&body)
+		textCh <- body.Text
+	}))
+	defer srv.Close()
+
+	next := func() string {
+		select {
+		case text := <-textCh:
+			return text
+		case <-time.After(5 * time.Second):
+			t.Fatal("no notification sent")
+			return ""
+		}
+	}
+
+	// Create our server
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+	s := testServiceImpl(impl)
+	ref := serverptypes.TestJobNew(t, nil).Application
+
+	// Invalid notifications are rejected
+	_, err = client.UpsertProject(ctx, &pb.UpsertProjectRequest{
+		Project: &pb.Project{
+			Name: ref.Project,
+			Notifications: []*pb.Project_Notification{
+				{Name: "slack", WebhookUrl: srv.URL, Template: "{{.Nope}}"},
+			},
+		},
+	})
+	require.Error(err)
+
+	_, err = client.UpsertProject(ctx, &pb.UpsertProjectRequest{
+		Project: &pb.Project{
+			Name: ref.Project,
+			Notifications: []*pb.Project_Notification{
+				{
+					Name:       "slack",
+					WebhookUrl: srv.URL,
+					Template:   "{{.Event}} {{.Operation}} {{.Error}}",
+				},
+			},
+		},
+	})
+	require.NoError(err)
+	_, err = client.UpsertApplication(ctx, &pb.UpsertApplicationRequest{
+		Project: &pb.Ref_Project{Project: ref.Project},
+		Name:    ref.Application,
+	})
+	require.NoError(err)
+
+	// Failed jobs notify
+	resp, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+		Job: serverptypes.TestJobNew(t, &pb.Job{
+			Operation: &pb.Job_Build{Build: &pb.Job_BuildOp{}},
+		}),
+	})
+	require.NoError(err)
+	job, err := s.state.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+	require.NoError(err)
+	require.Equal(resp.JobId, job.Id)
+	_, err = s.state.JobAck(context.Background(), job.Id, true)
+	require.NoError(err)
+	require.NoError(s.state.JobComplete(context.Background(), job.Id, nil, errors.New("boom")))
+	require.Equal("JOB_FAILED build boom", next())
+
+	// Jobs waiting for approval notify
+	_, err = client.SetWorkspacePolicy(ctx, &pb.SetWorkspacePolicyRequest{
+		Policy: &pb.WorkspacePolicy{
+			Workspace:         "prod",
+			RequiredApprovals: 1,
+		},
+	})
+	require.NoError(err)
+	_, err = client.QueueJob(ctx, &pb.QueueJobRequest{
+		Job: serverptypes.TestJobNew(t, &pb.Job{
+			Workspace: &pb.Ref_Workspace{Workspace: "prod"},
+			Operation: &pb.Job_Deploy{Deploy: &pb.Job_DeployOp{}},
+		}),
+	})
+	require.NoError(err)
+	require.Equal("APPROVAL_REQUESTED deploy ", next())
+}
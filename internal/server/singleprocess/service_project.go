@@ -4,7 +4,10 @@ import (
 	"context"
 
 	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
+	"github.com/hashicorp/waypoint/internal/notify"
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
 	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
 )
@@ -15,6 +18,12 @@ func (s *service) UpsertProject(
 	req *pb.UpsertProjectRequest,
 ) (*pb.UpsertProjectResponse, error) {
 	result := req.Project
+	for _, n := range result.Notifications {
+		if err := notify.Validate(n); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+	}
+
 	if err := s.state.ProjectPut(result); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,419 @@
+package singleprocess
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/pkg/protowriter"
+	"github.com/hashicorp/waypoint/internal/protocolversion"
+	"github.com/hashicorp/waypoint/internal/server"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// projectImportMaxRecordSize is the maximum size of a single record in a
+// project import.
+const projectImportMaxRecordSize = 4096 * 1024 // 4MB
+
+func (s *service) ExportProject(
+	req *pb.ExportProjectRequest,
+	srv pb.Waypoint_ExportProjectServer,
+) error {
+	if req.Project == nil || req.Project.Project == "" {
+		return status.Errorf(codes.InvalidArgument, "project is required")
+	}
+
+	// Read all the records first so that we return errors such as the
+	// project not existing before any data is sent.
+	records, err := s.projectExportRecords(req.Project, req.IncludeSensitiveConfig)
+	if err != nil {
+		return err
+	}
+
+	if err := srv.Send(&pb.ExportProjectResponse{
+		Event: &pb.ExportProjectResponse_Open_{
+			Open: &pb.ExportProjectResponse_Open{},
+		},
+	}); err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(&projectExportWriter{srv: srv})
+	gzw := gzip.NewWriter(bw)
+	dw := protowriter.NewDelimitedWriter(gzw)
+	if err := dw.WriteMsg(&pb.ProjectExport_Header{
+		Version: protocolversion.Current(),
+		Project: records[0].GetProject().Name,
+	}); err != nil {
+		return err
+	}
+	for _, rec := range records {
+		if err := dw.WriteMsg(rec); err != nil {
+			return err
+		}
+	}
+
+	// Closing the delimited writer closes the gzip writer.
+	if err := dw.Close(); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+func (s *service) ImportProject(
+	srv pb.Waypoint_ImportProjectServer,
+) error {
+	log := hclog.FromContext(srv.Context())
+
+	// Read our first event which must be an Open event.
+	req, err := srv.Recv()
+	if err != nil {
+		return err
+	}
+	open, ok := req.Event.(*pb.ImportProjectRequest_Open_)
+	if !ok {
+		return status.Errorf(codes.FailedPrecondition,
+			"first message must be Open type")
+	}
+
+	// Read all the data. The import is applied in a single transaction so
+	// we need all the records in memory anyways.
+	var buf bytes.Buffer
+	for {
+		req, err := srv.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		chunk, ok := req.Event.(*pb.ImportProjectRequest_Chunk)
+		if !ok {
+			return status.Errorf(codes.FailedPrecondition,
+				"all messages after Open must be data chunks")
+		}
+
+		buf.Write(chunk.Chunk)
+	}
+
+	header, records, err := projectImportRead(&buf)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument,
+			"error reading project export: %s", err)
+	}
+	log.Info("importing project",
+		"project", header.Project,
+		"created_by", header.Version.GetVersion(),
+		"records", len(records))
+
+	if err := projectImportRemap(records, open.Open.Name); err != nil {
+		return err
+	}
+	if err := s.state.ProjectImport(records); err != nil {
+		return err
+	}
+
+	return srv.SendAndClose(&pb.ImportProjectResponse{
+		Project: &pb.Ref_Project{Project: records[0].GetProject().Name},
+		Records: uint64(len(records)),
+	})
+}
+
+// projectExportRecords returns the records to export for a project. The
+// records are in the order documented on pb.ProjectExport.
+func (s *service) projectExportRecords(
+	ref *pb.Ref_Project,
+	sensitive bool,
+) ([]*pb.ProjectExport_Record, error) {
+	p, err := s.state.ProjectGet(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*pb.ProjectExport_Record{
+		{Record: &pb.ProjectExport_Record_Project{Project: p}},
+	}
+
+	// Config for the project and each app. Getting the config for an app
+	// includes the project config so we only keep the variables that are
+	// scoped to the app.
+	configReqs := []*pb.ConfigGetRequest{{
+		Scope: &pb.ConfigGetRequest_Project{
+			Project: &pb.Ref_Project{Project: p.Name},
+		},
+	}}
+	appRefs := make([]*pb.Ref_Application, len(p.Applications))
+	for i, app := range p.Applications {
+		appRefs[i] = &pb.Ref_Application{
+			Project:     p.Name,
+			Application: app.Name,
+		}
+
+		configReqs = append(configReqs, &pb.ConfigGetRequest{
+			Scope: &pb.ConfigGetRequest_Application{Application: appRefs[i]},
+		})
+	}
+	for _, req := range configReqs {
+		_, appReq := req.Scope.(*pb.ConfigGetRequest_Application)
+
+		var vars []*pb.ConfigVar
+		if sensitive {
+			vars, err = s.state.ConfigGetWatchDecrypted(req, nil)
+		} else {
+			vars, err = s.state.ConfigGet(req)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range vars {
+			if _, ok := v.Scope.(*pb.ConfigVar_Application); ok != appReq {
+				continue
+			}
+			if v.Sensitive && !sensitive {
+				continue
+			}
+
+			result = append(result, &pb.ProjectExport_Record{
+				Record: &pb.ProjectExport_Record_ConfigVar{ConfigVar: v},
+			})
+		}
+	}
+
+	// Completed jobs. Queued and running jobs aren't exported since
+	// they'd never run on the new server.
+	jobs, err := s.state.JobList()
+	if err != nil {
+		return nil, err
+	}
+	var exportJobs []*pb.Job
+	for _, job := range jobs {
+		if !strings.EqualFold(job.Application.GetProject(), p.Name) {
+			continue
+		}
+
+		switch job.State {
+		case pb.Job_SUCCESS, pb.Job_ERROR:
+		default:
+			continue
+		}
+
+		// Only the metadata of the job is exported. Results refer to
+		// IDs that change on import and the variables may have secrets.
+		job.Result = nil
+		job.ExpireTime = nil
+		job.Dispatch = nil
+		if !sensitive {
+			job.Variables = nil
+		}
+
+		exportJobs = append(exportJobs, job)
+	}
+	sort.Slice(exportJobs, func(i, j int) bool {
+		ti, _ := ptypes.Timestamp(exportJobs[i].QueueTime)
+		tj, _ := ptypes.Timestamp(exportJobs[j].QueueTime)
+		return ti.Before(tj)
+	})
+	for _, job := range exportJobs {
+		result = append(result, &pb.ProjectExport_Record{
+			Record: &pb.ProjectExport_Record_Job{Job: job},
+		})
+	}
+
+	// Operations for each app, in sequence order.
+	for _, ref := range appRefs {
+		builds, err := s.state.BuildList(ref)
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(builds, func(i, j int) bool { return builds[i].Sequence < builds[j].Sequence })
+		for _, v := range builds {
+			result = append(result, &pb.ProjectExport_Record{
+				Record: &pb.ProjectExport_Record_Build{Build: v},
+			})
+		}
+
+		artifacts, err := s.state.ArtifactList(ref)
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(artifacts, func(i, j int) bool { return artifacts[i].Sequence < artifacts[j].Sequence })
+		for _, v := range artifacts {
+			result = append(result, &pb.ProjectExport_Record{
+				Record: &pb.ProjectExport_Record_Artifact{Artifact: v},
+			})
+		}
+
+		deploys, err := s.state.DeploymentList(ref)
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(deploys, func(i, j int) bool { return deploys[i].Sequence < deploys[j].Sequence })
+		for _, v := range deploys {
+			result = append(result, &pb.ProjectExport_Record{
+				Record: &pb.ProjectExport_Record_Deployment{Deployment: v},
+			})
+		}
+
+		releases, err := s.state.ReleaseList(ref)
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(releases, func(i, j int) bool { return releases[i].Sequence < releases[j].Sequence })
+		for _, v := range releases {
+			result = append(result, &pb.ProjectExport_Record{
+				Record: &pb.ProjectExport_Record_Release{Release: v},
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// projectImportRead reads a project export.
+func projectImportRead(r io.Reader) (*pb.ProjectExport_Header, []*pb.ProjectExport_Record, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer gzr.Close()
+
+	dr := protowriter.NewDelimitedReader(bufio.NewReader(gzr), projectImportMaxRecordSize)
+	defer dr.Close()
+
+	var header pb.ProjectExport_Header
+	if err := dr.ReadMsg(&header); err != nil {
+		return nil, nil, err
+	}
+
+	var records []*pb.ProjectExport_Record
+	for {
+		var rec pb.ProjectExport_Record
+		err := dr.ReadMsg(&rec)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		records = append(records, &rec)
+	}
+
+	if len(records) == 0 || records[0].GetProject() == nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument,
+			"project export doesn't start with a project")
+	}
+
+	return &header, records, nil
+}
+
+// projectImportRemap gives the jobs and operations in records new IDs and
+// updates the references between them. If name is non-empty, the project
+// is renamed.
+func projectImportRemap(records []*pb.ProjectExport_Record, name string) error {
+	ids := map[string]string{}
+	newId := func(old string) (string, error) {
+		id, err := server.Id()
+		if err != nil {
+			return "", status.Errorf(codes.Internal, "uuid generation failed: %s", err)
+		}
+
+		ids[old] = id
+		return id, nil
+	}
+
+	// References to records that weren't exported are cleared.
+	ref := func(old string) string {
+		return ids[old]
+	}
+
+	rename := func(ref *pb.Ref_Application) {
+		if name != "" && ref != nil {
+			ref.Project = name
+		}
+	}
+
+	var err error
+	for _, rec := range records {
+		switch v := rec.Record.(type) {
+		case *pb.ProjectExport_Record_Project:
+			if name != "" {
+				v.Project.Name = name
+				for _, app := range v.Project.Applications {
+					app.Project = &pb.Ref_Project{Project: name}
+				}
+			}
+
+		case *pb.ProjectExport_Record_ConfigVar:
+			if name != "" {
+				switch scope := v.ConfigVar.Scope.(type) {
+				case *pb.ConfigVar_Project:
+					scope.Project.Project = name
+				case *pb.ConfigVar_Application:
+					rename(scope.Application)
+				}
+			}
+
+		case *pb.ProjectExport_Record_Job:
+			rename(v.Job.Application)
+			v.Job.Id, err = newId(v.Job.Id)
+
+		case *pb.ProjectExport_Record_Build:
+			rename(v.Build.Application)
+			v.Build.JobId = ref(v.Build.JobId)
+			v.Build.Id, err = newId(v.Build.Id)
+
+		case *pb.ProjectExport_Record_Artifact:
+			rename(v.Artifact.Application)
+			v.Artifact.JobId = ref(v.Artifact.JobId)
+			v.Artifact.BuildId = ref(v.Artifact.BuildId)
+			v.Artifact.Id, err = newId(v.Artifact.Id)
+
+		case *pb.ProjectExport_Record_Deployment:
+			rename(v.Deployment.Application)
+			v.Deployment.JobId = ref(v.Deployment.JobId)
+			v.Deployment.ArtifactId = ref(v.Deployment.ArtifactId)
+			v.Deployment.Id, err = newId(v.Deployment.Id)
+
+		case *pb.ProjectExport_Record_Release:
+			rename(v.Release.Application)
+			v.Release.JobId = ref(v.Release.JobId)
+			v.Release.DeploymentId = ref(v.Release.DeploymentId)
+			v.Release.Id, err = newId(v.Release.Id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type projectExportWriter struct {
+	srv pb.Waypoint_ExportProjectServer
+}
+
+func (w *projectExportWriter) Write(p []byte) (int, error) {
+	// Ignore empty data.
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	// Write our data
+	return len(p), w.srv.Send(&pb.ExportProjectResponse{
+		Event: &pb.ExportProjectResponse_Chunk{
+			Chunk: p,
+		},
+	})
+}
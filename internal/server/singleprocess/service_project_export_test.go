@@ -0,0 +1,238 @@
+package singleprocess
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/server"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/keywrap"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestServiceProjectExport(t *testing.T) {
+	ctx := context.Background()
+
+	init := func(t *testing.T) (pb.WaypointClient, *service) {
+		impl, err := New(WithDB(testDB(t)))
+		require.NoError(t, err)
+		s := testServiceImpl(impl)
+		require.NoError(t, s.state.ConfigEncryptionSet(ctx, keywrap.TestWrapper(t)))
+		return server.TestServer(t, impl), s
+	}
+
+	appRef := serverptypes.TestJobNew(t, nil).Application
+
+	// Create the project on the source server
+	src, srcImpl := init(t)
+	TestApp(t, src, appRef)
+	_, err := src.SetConfig(ctx, &pb.ConfigSetRequest{
+		Variables: []*pb.ConfigVar{
+			{
+				Scope: &pb.ConfigVar_Project{
+					Project: &pb.Ref_Project{Project: appRef.Project},
+				},
+				Name:  "PORT",
+				Value: "8080",
+			},
+			{
+				Scope: &pb.ConfigVar_Application{Application: appRef},
+				Name:  "PASSWORD",
+				Value: "hunter2",
+
+				Sensitive: true,
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	// A completed job
+	queued, err := src.QueueJob(ctx, &pb.QueueJobRequest{
+		Job: serverptypes.TestJobNew(t, nil),
+	})
+	require.NoError(t, err)
+	job, err := srcImpl.state.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+	require.NoError(t, err)
+	_, err = srcImpl.state.JobAck(context.Background(), job.Id, true)
+	require.NoError(t, err)
+	require.NoError(t, srcImpl.state.JobComplete(context.Background(), job.Id, nil, nil))
+
+	// Operations that reference each other and the job
+	build, err := src.UpsertBuild(ctx, &pb.UpsertBuildRequest{
+		Build: serverptypes.TestValidBuild(t, &pb.Build{JobId: queued.JobId}),
+	})
+	require.NoError(t, err)
+	artifact, err := src.UpsertPushedArtifact(ctx, &pb.UpsertPushedArtifactRequest{
+		Artifact: serverptypes.TestValidArtifact(t, &pb.PushedArtifact{
+			BuildId: build.Build.Id,
+		}),
+	})
+	require.NoError(t, err)
+	var deploys []*pb.Deployment
+	for i := 0; i < 2; i++ {
+		resp, err := src.UpsertDeployment(ctx, &pb.UpsertDeploymentRequest{
+			Deployment: serverptypes.TestValidDeployment(t, &pb.Deployment{
+				ArtifactId: artifact.Artifact.Id,
+				JobId:      queued.JobId,
+			}),
+		})
+		require.NoError(t, err)
+		deploys = append(deploys, resp.Deployment)
+	}
+	_, err = src.UpsertRelease(ctx, &pb.UpsertReleaseRequest{
+		Release: serverptypes.TestValidRelease(t, &pb.Release{
+			DeploymentId: deploys[1].Id,
+		}),
+	})
+	require.NoError(t, err)
+
+	export := func(t *testing.T, req *pb.ExportProjectRequest) []byte {
+		stream, err := src.ExportProject(ctx, req)
+		require.NoError(t, err)
+
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+		require.IsType(t, (*pb.ExportProjectResponse_Open_)(nil), resp.Event)
+
+		var buf bytes.Buffer
+		for {
+			resp, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			buf.Write(resp.Event.(*pb.ExportProjectResponse_Chunk).Chunk)
+		}
+
+		return buf.Bytes()
+	}
+
+	importProject := func(client pb.WaypointClient, data []byte, name string) (*pb.ImportProjectResponse, error) {
+		stream, err := client.ImportProject(ctx)
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&pb.ImportProjectRequest{
+			Event: &pb.ImportProjectRequest_Open_{
+				Open: &pb.ImportProjectRequest_Open{Name: name},
+			},
+		}))
+		require.NoError(t, stream.Send(&pb.ImportProjectRequest{
+			Event: &pb.ImportProjectRequest_Chunk{Chunk: data},
+		}))
+
+		return stream.CloseAndRecv()
+	}
+
+	t.Run("export and import", func(t *testing.T) {
+		require := require.New(t)
+
+		data := export(t, &pb.ExportProjectRequest{
+			Project:                &pb.Ref_Project{Project: appRef.Project},
+			IncludeSensitiveConfig: true,
+		})
+
+		dst, dstImpl := init(t)
+		resp, err := importProject(dst, data, "")
+		require.NoError(err)
+		require.Equal(appRef.Project, resp.Project.Project)
+
+		// Project and config
+		project, err := dst.GetProject(ctx, &pb.GetProjectRequest{Project: resp.Project})
+		require.NoError(err)
+		require.Len(project.Project.Applications, 1)
+		vars, err := dstImpl.state.ConfigGetWatchDecrypted(&pb.ConfigGetRequest{
+			Scope: &pb.ConfigGetRequest_Application{Application: appRef},
+		}, nil)
+		require.NoError(err)
+		require.Len(vars, 2)
+
+		// Operations have new IDs, keep their sequence and reference
+		// each other
+		deployResp, err := dst.ListDeployments(ctx, &pb.ListDeploymentsRequest{
+			Application: appRef,
+		})
+		require.NoError(err)
+		require.Len(deployResp.Deployments, 2)
+		latest := deployResp.Deployments[0]
+		require.Equal(deploys[1].Sequence, latest.Sequence)
+		require.NotEqual(deploys[1].Id, latest.Id)
+
+		a, err := dst.GetPushedArtifact(ctx, &pb.GetPushedArtifactRequest{
+			Ref: &pb.Ref_Operation{
+				Target: &pb.Ref_Operation_Id{Id: latest.ArtifactId},
+			},
+		})
+		require.NoError(err)
+		require.NotEqual(artifact.Artifact.Id, a.Id)
+
+		release, err := dst.GetLatestRelease(ctx, &pb.GetLatestReleaseRequest{
+			Application: appRef,
+		})
+		require.NoError(err)
+		require.Equal(latest.Id, release.DeploymentId)
+
+		// The job is kept as history
+		j, err := dst.GetJob(ctx, &pb.GetJobRequest{JobId: latest.JobId})
+		require.NoError(err)
+		require.Equal(pb.Job_SUCCESS, j.State)
+
+		// New operations are sequenced after the imported ones
+		newDeploy, err := dst.UpsertDeployment(ctx, &pb.UpsertDeploymentRequest{
+			Deployment: serverptypes.TestValidDeployment(t, nil),
+		})
+		require.NoError(err)
+		require.Equal(latest.Sequence+1, newDeploy.Deployment.Sequence)
+
+		// The project can't be imported again
+		_, err = importProject(dst, data, "")
+		require.Error(err)
+		require.Equal(codes.AlreadyExists, status.Code(err))
+	})
+
+	t.Run("rename and skip sensitive config", func(t *testing.T) {
+		require := require.New(t)
+
+		data := export(t, &pb.ExportProjectRequest{
+			Project: &pb.Ref_Project{Project: appRef.Project},
+		})
+
+		dst, dstImpl := init(t)
+		resp, err := importProject(dst, data, "renamed")
+		require.NoError(err)
+		require.Equal("renamed", resp.Project.Project)
+
+		ref := &pb.Ref_Application{Project: "renamed", Application: appRef.Application}
+		vars, err := dstImpl.state.ConfigGet(&pb.ConfigGetRequest{
+			Scope: &pb.ConfigGetRequest_Application{Application: ref},
+		})
+		require.NoError(err)
+		require.Len(vars, 1)
+		require.Equal("PORT", vars[0].Name)
+
+		deployResp, err := dst.ListDeployments(ctx, &pb.ListDeploymentsRequest{
+			Application: ref,
+		})
+		require.NoError(err)
+		require.Len(deployResp.Deployments, 2)
+
+		_, err = dst.GetProject(ctx, &pb.GetProjectRequest{
+			Project: &pb.Ref_Project{Project: appRef.Project},
+		})
+		require.Error(err)
+	})
+
+	t.Run("export of a project that doesn't exist", func(t *testing.T) {
+		stream, err := src.ExportProject(ctx, &pb.ExportProjectRequest{
+			Project: &pb.Ref_Project{Project: "nope"},
+		})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		require.Error(t, err)
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
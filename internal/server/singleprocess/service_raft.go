@@ -0,0 +1,57 @@
+package singleprocess
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/boltdb/bolt"
+	"github.com/hashicorp/go-hclog"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/singleprocess/state"
+	"github.com/hashicorp/waypoint/internal/serverconfig"
+)
+
+// raftStateInit initializes the state replicated with raft. This blocks
+// until this server is the leader. If follower is set, it is called with
+// the service that serves reads while this server is a follower.
+func raftStateInit(
+	ctx context.Context,
+	log hclog.Logger,
+	db *bolt.DB,
+	cfg *serverconfig.Raft,
+	leaderLost func(),
+	follower func(pb.WaypointServer),
+) (*state.State, error) {
+	peers := map[string]string{}
+	for _, v := range cfg.Peers {
+		idx := strings.Index(v, "=")
+		if idx <= 0 || idx == len(v)-1 {
+			return nil, fmt.Errorf(
+				"raft peer %q must be in the format ID=ADDRESS", v)
+		}
+
+		id, addr := v[:idx], v[idx+1:]
+		if _, ok := peers[id]; ok || id == cfg.ID {
+			return nil, fmt.Errorf("raft peer %q is configured twice", id)
+		}
+
+		peers[id] = addr
+	}
+
+	rcfg := &state.RaftConfig{
+		ID:         cfg.ID,
+		Addr:       cfg.Address,
+		Dir:        cfg.Dir,
+		Peers:      peers,
+		LeaderLost: leaderLost,
+	}
+	if follower != nil {
+		rcfg.Follower = func(st *state.State) {
+			follower(&followerService{state: st})
+		}
+	}
+
+	return state.NewRaft(ctx, log, db, rcfg)
+}
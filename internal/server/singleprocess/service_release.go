@@ -34,6 +34,11 @@ func (s *service) UpsertRelease(
 		return nil, err
 	}
 
+	// Record the plugin that was used for telemetry.
+	if insert {
+		s.telemetry.Component(result.Component)
+	}
+
 	return &pb.UpsertReleaseResponse{Release: result}, nil
 }
 
@@ -2,15 +2,17 @@ package singleprocess
 
 import (
 	"context"
+	"crypto/ed25519"
 	"io"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-memdb"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
-	"github.com/hashicorp/waypoint/internal/server/logbuffer"
 	"github.com/hashicorp/waypoint/internal/server/singleprocess/state"
 )
 
@@ -22,6 +24,120 @@ func (s *service) GetRunner(
 	return s.state.RunnerById(req.RunnerId)
 }
 
+func (s *service) DrainRunner(
+	ctx context.Context,
+	req *pb.DrainRunnerRequest,
+) (*pb.Runner, error) {
+	if req.RunnerId == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "runner ID must be set")
+	}
+
+	return s.state.RunnerDrain(req.RunnerId)
+}
+
+func (s *service) UndrainRunner(
+	ctx context.Context,
+	req *pb.DrainRunnerRequest,
+) (*pb.Runner, error) {
+	if req.RunnerId == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "runner ID must be set")
+	}
+
+	return s.state.RunnerUndrain(req.RunnerId)
+}
+
+func (s *service) ApproveRunner(
+	ctx context.Context,
+	req *pb.ApproveRunnerRequest,
+) (*empty.Empty, error) {
+	if req.RunnerId == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "runner ID must be set")
+	}
+
+	if err := s.state.RunnerApprove(req.RunnerId); err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
+
+const (
+	// listRunnersPageSize is the default and listRunnersMaxPageSize the
+	// maximum number of runners ListRunners returns at once.
+	listRunnersPageSize    = 100
+	listRunnersMaxPageSize = 1000
+)
+
+func (s *service) ListRunners(
+	ctx context.Context,
+	req *pb.ListRunnersRequest,
+) (*pb.ListRunnersResponse, error) {
+	limit := int(req.PageSize)
+	if limit == 0 {
+		limit = listRunnersPageSize
+	}
+	if limit > listRunnersMaxPageSize {
+		limit = listRunnersMaxPageSize
+	}
+
+	runners, more, err := s.state.RunnerList(&state.RunnerListQuery{
+		RunnerQuery: state.RunnerQuery{
+			Labels: req.Labels,
+			OS:     req.Os,
+			Arch:   req.Arch,
+			Plugin: req.Plugin,
+			Pool:   req.Pool,
+			Tags:   req.Tags,
+		},
+		States: req.States,
+		After:  req.PageToken,
+		Limit:  limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListRunnersResponse{Runners: runners}
+	if more {
+		resp.NextPageToken = runners[len(runners)-1].Runner.Id
+	}
+
+	return resp, nil
+}
+
+func (s *service) GetQueueDepth(
+	ctx context.Context,
+	req *empty.Empty,
+) (*pb.GetQueueDepthResponse, error) {
+	targets, err := s.state.JobQueueDepth()
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetQueueDepthResponse{Targets: targets}, nil
+}
+
+func (s *service) GetRunnerStats(
+	ctx context.Context,
+	req *pb.GetRunnerStatsRequest,
+) (*pb.GetRunnerStatsResponse, error) {
+	if req.RunnerId != "" {
+		stats, err := s.state.RunnerStatsGet(req.RunnerId)
+		if err != nil {
+			return nil, err
+		}
+
+		return &pb.GetRunnerStatsResponse{Stats: []*pb.RunnerStats{stats}}, nil
+	}
+
+	stats, err := s.state.RunnerStatsList()
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetRunnerStatsResponse{Stats: stats}, nil
+}
+
 func (s *service) RunnerGetDeploymentConfig(
 	ctx context.Context,
 	req *pb.RunnerGetDeploymentConfigRequest,
@@ -76,6 +192,19 @@ func (s *service) RunnerConfig(
 	}
 	record := req.Open.Runner
 
+	// Runners are drained and approved by the server, never by their
+	// registration.
+	record.Draining = false
+	record.Pending = false
+
+	// A token for a single runner is created by an operator, so the runner
+	// it's for is approved when it registers.
+	if id := s.callerRunnerId(ctx); id != "" && id == record.Id {
+		if err := s.state.RunnerApprove(id); err != nil {
+			return err
+		}
+	}
+
 	// Create our record
 	log = log.With("runner_id", record.Id)
 	log.Trace("registering runner")
@@ -83,23 +212,28 @@ func (s *service) RunnerConfig(
 		return err
 	}
 
-	// Defer deleting this.
-	// TODO(mitchellh): this is too aggressive and we want to have some grace
-	// period for reconnecting clients. We should clean this up.
+	// Record whether the runner has the published version. This doesn't
+	// stop the runner from registering.
+	if err := s.state.RunnerUpdateRegister(record); err != nil {
+		log.Warn("error recording runner update state", "err", err)
+	}
+
+	// Defer deleting this. The jobs of the runner are handled right away
+	// rather than waiting for their timeouts. See runner_disconnect.go.
 	defer func() {
 		log.Trace("deleting runner")
-		if err := s.state.RunnerDelete(record.Id); err != nil {
+		if err := s.state.RunnerDisconnect(record.Id); err != nil {
 			log.Error("failed to delete runner data. This should not happen.", "err", err)
 		}
 	}()
 
 	// Start a goroutine that listens on the recvmsg so we can detect
-	// when the client exited.
+	// when the client exited and record its heartbeats.
 	go func() {
 		defer cancel()
 
 		for {
-			_, err := srv.Recv()
+			event, err := srv.Recv()
 			if err != nil {
 				if err != io.EOF {
 					log.Warn("unknown error from recvmsg", "err", err)
@@ -107,6 +241,24 @@ func (s *service) RunnerConfig(
 
 				return
 			}
+
+			if _, ok := event.Event.(*pb.RunnerConfigRequest_Heartbeat_); !ok {
+				continue
+			}
+
+			err = s.state.RunnerHeartbeat(record.Id)
+			if status.Code(err) == codes.NotFound {
+				// The runner was deregistered because its heartbeats
+				// stopped for too long, but it's back so we register
+				// it again.
+				log.Info("runner sent a heartbeat after it expired, registering again")
+				if err = s.state.RunnerCreate(record); err == nil {
+					err = s.state.RunnerHeartbeat(record.Id)
+				}
+			}
+			if err != nil {
+				log.Warn("error recording runner heartbeat", "err", err)
+			}
 		}
 	}()
 
@@ -115,10 +267,12 @@ func (s *service) RunnerConfig(
 		ws := memdb.NewWatchSet()
 
 		// Build our config
-		config := &pb.RunnerConfig{}
+		config := &pb.RunnerConfig{
+			JobSigningKey: s.jobSigningKey.Public().(ed25519.PublicKey),
+		}
 
 		// Get our config vars
-		vars, err := s.state.ConfigGetWatch(&pb.ConfigGetRequest{
+		vars, err := s.state.ConfigGetWatchDecrypted(&pb.ConfigGetRequest{
 			Scope: &pb.ConfigGetRequest_Runner{
 				Runner: &pb.Ref_RunnerId{
 					Id: record.Id,
@@ -130,6 +284,20 @@ func (s *service) RunnerConfig(
 		}
 		config.ConfigVars = vars
 
+		// Get our config files
+		files, version, err := s.state.RunnerConfigFilesWatch(record.Id, ws)
+		if err != nil {
+			return err
+		}
+		config.ConfigFiles = files
+		config.ConfigVersion = version
+
+		// Get the runner version to update to
+		config.DesiredVersion, err = s.state.RunnerVersionWatch(ws)
+		if err != nil {
+			return err
+		}
+
 		// Send new config
 		if err := srv.Send(&pb.RunnerConfigResponse{
 			Config: config,
@@ -172,8 +340,18 @@ func (s *service) RunnerJobStream(
 		return err
 	}
 
-	// Get a job assignment for this runner
-	job, err := s.state.JobAssignForRunner(ctx, runner)
+	// Get a job assignment for this runner. If the runner is deregistered
+	// while it waits, such as because its heartbeats stopped, we stop
+	// waiting so that it isn't assigned a job.
+	assignCtx, assignCancel := context.WithCancel(ctx)
+	go s.runnerWatchDeregister(assignCtx, assignCancel, runner.Id)
+	job, err := s.state.JobAssignForRunner(assignCtx, runner)
+	deregistered := err != nil && assignCtx.Err() != nil && ctx.Err() == nil
+	assignCancel()
+	if deregistered {
+		return status.Errorf(codes.NotFound,
+			"runner was deregistered while waiting for a job")
+	}
 	if err != nil {
 		return err
 	}
@@ -183,18 +361,20 @@ func (s *service) RunnerJobStream(
 	// If this has an error, we continue to accumulate the error until
 	// we set the ack status in the DB. We do this because if we fail to
 	// send the job assignment we want to nack the job so it is queued again.
-	err = server.Send(&pb.RunnerJobStreamResponse{
-		Event: &pb.RunnerJobStreamResponse_Assignment{
-			Assignment: &pb.RunnerJobStreamResponse_JobAssignment{
-				Job: job.Job,
+	assignment, err := s.jobAssignment(runner.Id, job.Job)
+	if err == nil {
+		err = server.Send(&pb.RunnerJobStreamResponse{
+			Event: &pb.RunnerJobStreamResponse_Assignment{
+				Assignment: assignment,
 			},
-		},
-	})
+		})
+	}
 
 	// Wait for an ack. We only do this if the job assignment above
 	// succeeded. If it didn't succeed, the client will never send us
 	// an ack.
 	ack := false
+	nackReason := ""
 	if err == nil { // if sending the job assignment was a success
 		req, err = server.Recv()
 
@@ -202,12 +382,15 @@ func (s *service) RunnerJobStream(
 		// receive a message, we've set the `err` value and we keep
 		// ack to false so that we nack the job later.
 		if err == nil {
-			switch req.Event.(type) {
+			switch event := req.Event.(type) {
 			case *pb.RunnerJobStreamRequest_Ack_:
 				ack = true
 
 			case *pb.RunnerJobStreamRequest_Error_:
 				ack = false
+				if st := event.Error.GetError(); st != nil {
+					nackReason = "rejected by the runner: " + st.Message
+				}
 
 			default:
 				ack = false
@@ -217,8 +400,18 @@ func (s *service) RunnerJobStream(
 		}
 	}
 
-	// Send the ack.
-	job, ackerr := s.state.JobAck(job.Id, ack)
+	// Send the ack. If the job wasn't accepted, we record why so that
+	// jobs that are dead-lettered show the reason.
+	if !ack && nackReason == "" && err != nil {
+		nackReason = "error sending the job to the runner: " + err.Error()
+	}
+
+	var ackerr error
+	if ack {
+		job, ackerr = s.state.JobAck(ctx, job.Id, true)
+	} else {
+		job, ackerr = s.state.JobNack(job.Id, nackReason)
+	}
 	if ackerr != nil {
 		// If this fails, we just log, there is nothing more we can do.
 		log.Warn("job ack failed", "outer_error", err, "error", ackerr)
@@ -377,6 +570,26 @@ func (s *service) RunnerJobStream(
 	}
 }
 
+// runnerWatchDeregister calls cancel if the runner with the given ID is
+// deregistered before ctx is done.
+func (s *service) runnerWatchDeregister(ctx context.Context, cancel func(), id string) {
+	for {
+		ws := memdb.NewWatchSet()
+		_, err := s.state.RunnerByIdWatch(id, ws)
+		if status.Code(err) == codes.NotFound {
+			cancel()
+			return
+		}
+		if err != nil {
+			return
+		}
+
+		if err := ws.WatchCtx(ctx); err != nil {
+			return
+		}
+	}
+}
+
 func (s *service) handleJobStreamRequest(
 	log hclog.Logger,
 	job *state.Job,
@@ -386,14 +599,36 @@ func (s *service) handleJobStreamRequest(
 	log.Trace("event received", "event", req.Event)
 	switch event := req.Event.(type) {
 	case *pb.RunnerJobStreamRequest_Complete_:
-		return s.state.JobComplete(job.Id, event.Complete.Result, nil)
+		s.putJobArtifacts(log, job, event.Complete.Artifacts)
+		return s.state.JobComplete(srv.Context(), job.Id, event.Complete.Result, nil)
 
 	case *pb.RunnerJobStreamRequest_Error_:
-		return s.state.JobComplete(job.Id, nil, status.FromProto(event.Error.Error).Err())
+		s.putJobArtifacts(log, job, event.Error.Artifacts)
+		return s.state.JobComplete(srv.Context(), job.Id, nil, status.FromProto(event.Error.Error).Err())
 
 	case *pb.RunnerJobStreamRequest_Heartbeat_:
 		return s.state.JobHeartbeat(job.Id)
 
+	case *pb.RunnerJobStreamRequest_Progress:
+		// Progress is informational so we don't fail the job stream if it
+		// can't be recorded.
+		if err := s.state.JobProgress(job.Id, event.Progress); err != nil {
+			log.Warn("error recording job progress", "err", err)
+		}
+
+		return nil
+
+	case *pb.RunnerJobStreamRequest_Download_:
+		jobpb, err := s.state.JobUpdateRef(job.Id, event.Download.DataSourceRef)
+		if err != nil {
+			return err
+		}
+
+		// We report synchronously so that the pending status is always
+		// reported before the status of the completed job.
+		s.commitStatusJob(log, jobpb)
+		return nil
+
 	case *pb.RunnerJobStreamRequest_Terminal:
 		// This shouldn't happen but we want to protect against it to prevent
 		// a panic.
@@ -402,14 +637,18 @@ func (s *service) handleJobStreamRequest(
 			return nil
 		}
 
-		// Write the entries to the output buffer
-		entries := make([]logbuffer.Entry, len(event.Terminal.Events))
-		for i, ev := range event.Terminal.Events {
-			entries[i] = ev
+		// Write the events to the job output. Sensitive values are masked
+		// before they're written so they're never streamed out or stored.
+		redactor := s.state.Redactor()
+		for _, ev := range event.Terminal.Events {
+			redactor.Message(ev)
 		}
 
-		// Write the events
-		job.OutputBuffer.Write(entries...)
+		// The output is buffered even if it can't be persisted, so we don't
+		// fail the job stream.
+		if err := s.state.JobOutputWrite(job.Id, event.Terminal.Events); err != nil {
+			log.Warn("error writing job output", "err", err)
+		}
 
 		return nil
 
@@ -419,3 +658,37 @@ func (s *service) handleJobStreamRequest(
 
 	return nil
 }
+
+// jobAssignment builds the signed assignment of a job to the runner with
+// the given ID. Runners only verify the signature if the operator pinned
+// the server's public key with -job-signing-key, since a key sent over the
+// same connection as the job proves nothing.
+func (s *service) jobAssignment(runnerId string, job *pb.Job) (*pb.RunnerJobStreamResponse_JobAssignment, error) {
+	payload, err := proto.Marshal(&pb.RunnerJobStreamResponse_JobAssignment_Payload{
+		RunnerId:   runnerId,
+		JobId:      job.Id,
+		AssignTime: job.AssignTime,
+		Job:        job,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.RunnerJobStreamResponse_JobAssignment{
+		JobPayload: payload,
+		Signature:  ed25519.Sign(s.jobSigningKey, payload),
+	}, nil
+}
+
+// putJobArtifacts attaches the artifacts sent with the completion of the job.
+// The job completes even if the artifacts can't be stored since its result
+// doesn't depend on them.
+func (s *service) putJobArtifacts(
+	log hclog.Logger,
+	job *state.Job,
+	artifacts []*pb.JobArtifact,
+) {
+	if err := s.state.JobArtifactsPut(job.Id, artifacts); err != nil {
+		log.Warn("error storing job artifacts", "err", err)
+	}
+}
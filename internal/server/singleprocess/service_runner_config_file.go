@@ -0,0 +1,45 @@
+package singleprocess
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func (s *service) SetRunnerConfigFile(
+	ctx context.Context,
+	req *pb.SetRunnerConfigFileRequest,
+) (*pb.RunnerConfigFile, error) {
+	if req.File == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "file must be set")
+	}
+
+	return s.state.RunnerConfigFileSet(req.File)
+}
+
+func (s *service) ListRunnerConfigFiles(
+	ctx context.Context,
+	req *empty.Empty,
+) (*pb.ListRunnerConfigFilesResponse, error) {
+	files, err := s.state.RunnerConfigFileList()
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ListRunnerConfigFilesResponse{Files: files}, nil
+}
+
+func (s *service) DeleteRunnerConfigFile(
+	ctx context.Context,
+	req *pb.DeleteRunnerConfigFileRequest,
+) (*empty.Empty, error) {
+	if err := s.state.RunnerConfigFileDelete(req.Path, req.Target); err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
@@ -0,0 +1,52 @@
+package singleprocess
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func (s *service) SetRunnerInstallation(
+	ctx context.Context,
+	req *pb.SetRunnerInstallationRequest,
+) (*pb.RunnerInstallation, error) {
+	if req.Installation == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "installation must be set")
+	}
+
+	return s.state.RunnerInstallationSet(req.Installation)
+}
+
+func (s *service) GetRunnerInstallation(
+	ctx context.Context,
+	req *pb.GetRunnerInstallationRequest,
+) (*pb.RunnerInstallation, error) {
+	return s.state.RunnerInstallationGet(req.RunnerId)
+}
+
+func (s *service) ListRunnerInstallations(
+	ctx context.Context,
+	req *empty.Empty,
+) (*pb.ListRunnerInstallationsResponse, error) {
+	list, err := s.state.RunnerInstallationList()
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ListRunnerInstallationsResponse{Installations: list}, nil
+}
+
+func (s *service) DeleteRunnerInstallation(
+	ctx context.Context,
+	req *pb.DeleteRunnerInstallationRequest,
+) (*empty.Empty, error) {
+	if err := s.state.RunnerInstallationDelete(req.RunnerId); err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
@@ -0,0 +1,246 @@
+package singleprocess
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-memdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/dispatch"
+	"github.com/hashicorp/waypoint/internal/runnerauth"
+	"github.com/hashicorp/waypoint/internal/server"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+	"github.com/hashicorp/waypoint/internal/server/singleprocess/state"
+)
+
+// runnerLaunchMaxDefault is the most runners launched on demand at once if
+// the dispatch config doesn't set it.
+const runnerLaunchMaxDefault = 10
+
+// runnerLaunchMonitor launches runners on demand until the context is
+// cancelled. Runners are launched when queued jobs have no runner that can
+// be assigned them, and the tasks of launched runners are cleaned up once
+// they exit. The launches are rechecked when the demand changes or every
+// poll interval. See state/runner_launch.go.
+func (s *service) runnerLaunchMonitor(ctx context.Context, log hclog.Logger) {
+	interval := s.dispatchConfig.PollInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	for {
+		if err := s.runnerLaunch(ctx, log); err != nil {
+			log.Warn("error launching runners", "err", err)
+		}
+		if err := s.runnerLaunchPoll(ctx, log); err != nil {
+			log.Warn("error checking launched runners", "err", err)
+		}
+
+		// We watch the demand after launching so that our own launches
+		// don't wake us. If runners couldn't be launched, we wait for the
+		// demand to change or the next poll.
+		ws := memdb.NewWatchSet()
+		if _, err := s.state.RunnerLaunchDemand(ws); err != nil {
+			log.Warn("error watching the demand for runners", "err", err)
+		}
+
+		wctx, cancel := context.WithTimeout(ctx, interval)
+		ws.WatchCtx(wctx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// runnerLaunch launches a runner for each queued job that has no runner
+// that can be assigned it, up to the maximum number of launched runners.
+func (s *service) runnerLaunch(ctx context.Context, log hclog.Logger) error {
+	// We lock so that runners aren't launched twice for the same demand.
+	s.dispatchLock.Lock()
+	defer s.dispatchLock.Unlock()
+
+	demand, err := s.state.RunnerLaunchDemand(nil)
+	if err != nil || len(demand) == 0 {
+		return err
+	}
+
+	launches, err := s.state.RunnerLaunchList()
+	if err != nil {
+		return err
+	}
+
+	max := s.dispatchConfig.MaxRunners
+	if max <= 0 {
+		max = runnerLaunchMaxDefault
+	}
+
+	// Targets are launched in order so the result is the same each time
+	// we reach the maximum.
+	targets := make([]state.RunnerLaunchTarget, 0, len(demand))
+	for target := range demand {
+		targets = append(targets, target)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Pool != targets[j].Pool {
+			return targets[i].Pool < targets[j].Pool
+		}
+		if targets[i].Profile != targets[j].Profile {
+			return targets[i].Profile < targets[j].Profile
+		}
+		return targets[i].Platform < targets[j].Platform
+	})
+
+	active := len(launches)
+	for _, target := range targets {
+		for i := 0; i < demand[target]; i++ {
+			if active >= max {
+				log.Debug("maximum runners are launched", "max", max)
+				return nil
+			}
+
+			id, err := s.runnerLaunchOne(ctx, target)
+			if err != nil {
+				return err
+			}
+			log.Info("launched runner", "runner_id", id, "pool", target.Pool,
+				"profile", target.Profile, "platform", target.Platform)
+			active++
+		}
+	}
+
+	return nil
+}
+
+// runnerLaunchOne launches a runner for the given target and returns its
+// ID. The launch is recorded before the task is submitted so the runner is
+// known when it registers. If the target has a runner profile, the task is
+// configured from the profile, and the platform of the target overrides
+// the platform of the profile.
+func (s *service) runnerLaunchOne(ctx context.Context, target state.RunnerLaunchTarget) (string, error) {
+	pool, profileName := target.Pool, target.Profile
+
+	task := &dispatch.Task{}
+	if profileName != "" {
+		profile, err := s.state.RunnerProfileGet(profileName)
+		if err != nil {
+			return "", err
+		}
+
+		profileName = profile.Name
+		task.Image = profile.OciUrl
+		task.Env = profile.EnvironmentVariables
+		task.Config = profile.PluginConfig
+		task.OS = profile.Os
+		task.Arch = profile.Arch
+	}
+	if target.Platform != "" {
+		os, arch, err := serverptypes.JobPlatform(target.Platform)
+		if err != nil {
+			return "", status.Errorf(codes.Internal, "invalid platform: %s", err)
+		}
+
+		task.OS = os
+		if arch != "" {
+			task.Arch = arch
+		}
+	}
+
+	id, err := server.Id()
+	if err != nil {
+		return "", status.Errorf(codes.Internal, "uuid generation failed: %s", err)
+	}
+
+	if err := s.state.RunnerLaunchCreate(&state.RunnerLaunch{
+		Id:       id,
+		Pool:     pool,
+		Profile:  profileName,
+		Platform: target.Platform,
+	}); err != nil {
+		return "", err
+	}
+
+	args := []string{
+		"runner", "agent",
+		"-id=" + id,
+		"-one-shot",
+	}
+	if pool != "" {
+		args = append(args, "-pool="+pool)
+	}
+	if profileName != "" {
+		args = append(args, "-profile="+profileName)
+	}
+
+	// Runner IDs are ULIDs which are valid DNS labels once lowercased.
+	task.Name = "waypoint-runner-" + strings.ToLower(id)
+	task.Args = args
+	taskId, err := s.dispatchRunner(ctx, task, &runnerauth.Identity{
+		Name: "launch/" + id,
+		Pool: pool,
+	})
+	if err != nil {
+		// The runner was never launched so it doesn't count towards the
+		// demand.
+		if derr := s.state.RunnerLaunchDelete(id); derr != nil {
+			return "", derr
+		}
+
+		return "", status.Errorf(codes.Unavailable,
+			"error launching runner in %s: %s", s.scheduler.Name(), err)
+	}
+
+	return id, s.state.RunnerLaunchTaskSet(id, taskId)
+}
+
+// runnerLaunchPoll checks the tasks of the launched runners once. The
+// tasks of runners that deregistered are stopped, and launches whose task
+// ended are removed, whether or not the runner registered.
+func (s *service) runnerLaunchPoll(ctx context.Context, log hclog.Logger) error {
+	s.dispatchLock.Lock()
+	defer s.dispatchLock.Unlock()
+
+	launches, err := s.state.RunnerLaunchList()
+	if err != nil {
+		return err
+	}
+
+	for _, l := range launches {
+		// The task is still being submitted.
+		if l.TaskId == "" {
+			continue
+		}
+
+		log := log.With("runner_id", l.Id, "task_id", l.TaskId)
+		if l.Status != state.RunnerLaunchExited {
+			st, err := s.scheduler.Status(ctx, l.TaskId)
+			if err != nil {
+				log.Warn("error checking launched runner status", "err", err)
+				continue
+			}
+			if !st.Done() {
+				continue
+			}
+
+			if l.Status == state.RunnerLaunchPending {
+				log.Warn("launched runner exited before it registered", "status", st)
+			}
+		}
+
+		if err := s.scheduler.Stop(ctx, l.TaskId); err != nil {
+			log.Warn("error stopping launched runner", "err", err)
+			continue
+		}
+
+		if err := s.state.RunnerLaunchDelete(l.Id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,219 @@
+package singleprocess
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/dispatch"
+	"github.com/hashicorp/waypoint/internal/server"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+	"github.com/hashicorp/waypoint/internal/server/singleprocess/state"
+	"github.com/hashicorp/waypoint/internal/serverconfig"
+)
+
+func TestServiceRunnerLaunch(t *testing.T) {
+	ctx := context.Background()
+
+	init := func(t *testing.T, sched *testScheduler) (pb.WaypointClient, *service) {
+		impl, err := New(
+			WithDB(testDB(t)),
+			WithDispatchScheduler(sched),
+			WithConfig(&serverconfig.Config{
+				Dispatch: &serverconfig.Dispatch{
+					Scheduler:    "test",
+					Addr:         "waypoint:9701",
+					PollInterval: 10 * time.Millisecond,
+					OnDemand:     true,
+					MaxRunners:   1,
+				},
+			}),
+		)
+		require.NoError(t, err)
+		client := server.TestServer(t, impl)
+		TestApp(t, client, serverptypes.TestJobNew(t, nil).Application)
+		return client, testServiceImpl(impl)
+	}
+
+	// launched returns the submitted launched runners once there are n of
+	// them, none of which have the ID exclude.
+	launched := func(t *testing.T, s *service, n int, exclude string) []*state.RunnerLaunch {
+		var result []*state.RunnerLaunch
+		require.Eventually(t, func() bool {
+			ls, err := s.state.RunnerLaunchList()
+			require.NoError(t, err)
+
+			result = nil
+			for _, l := range ls {
+				if l.Id == exclude || l.TaskId == "" {
+					return false
+				}
+				result = append(result, l)
+			}
+			return len(result) == n
+		}, 5*time.Second, 10*time.Millisecond)
+
+		return result
+	}
+
+	t.Run("queued jobs launch runners up to the maximum", func(t *testing.T) {
+		require := require.New(t)
+		sched := &testScheduler{}
+		client, s := init(t, sched)
+
+		var ids []string
+		for i := 0; i < 2; i++ {
+			resp, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+				Job: serverptypes.TestJobNew(t, nil),
+			})
+			require.NoError(err)
+			ids = append(ids, resp.JobId)
+		}
+
+		// Jobs aren't dispatched to their own runner
+		job, err := client.GetJob(ctx, &pb.GetJobRequest{JobId: ids[0]})
+		require.NoError(err)
+		require.Nil(job.Dispatch)
+
+		l := launched(t, s, 1, "")[0]
+		task := sched.task(l.TaskId)
+		require.NotNil(task)
+		require.Contains(task.Args, "-id="+l.Id)
+		require.Contains(task.Args, "-one-shot")
+		require.NotEmpty(task.Env[dispatchEnvServerToken])
+
+		// The launched runner registers and runs a job
+		r := &pb.Runner{Id: l.Id}
+		require.NoError(s.state.RunnerCreate(r))
+		sj, err := s.state.JobAssignForRunner(ctx, r)
+		require.NoError(err)
+		require.Equal(ids[0], sj.Id)
+
+		// Once it exits its task is stopped and a runner is launched for
+		// the other job.
+		require.NoError(s.state.RunnerDelete(l.Id))
+		require.Eventually(func() bool {
+			return sched.stopped(l.TaskId)
+		}, 5*time.Second, 10*time.Millisecond)
+
+		launched(t, s, 1, l.Id)
+	})
+
+	t.Run("runners are launched in the pool of the job", func(t *testing.T) {
+		require := require.New(t)
+		sched := &testScheduler{}
+		client, s := init(t, sched)
+
+		_, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+			Job: serverptypes.TestJobNew(t, &pb.Job{
+				TargetRunner: &pb.Ref_Runner{
+					Target: &pb.Ref_Runner_Pool{Pool: &pb.Ref_RunnerPool{Name: "deploy"}},
+				},
+			}),
+		})
+		require.NoError(err)
+
+		l := launched(t, s, 1, "")[0]
+		require.Equal("deploy", l.Pool)
+		require.Contains(sched.task(l.TaskId).Args, "-pool=deploy")
+	})
+
+	t.Run("runners are launched from the runner profile of the job", func(t *testing.T) {
+		require := require.New(t)
+		sched := &testScheduler{}
+		client, s := init(t, sched)
+
+		_, err := client.SetRunnerProfile(ctx, &pb.SetRunnerProfileRequest{
+			Profile: &pb.RunnerProfile{
+				Name:                 "arm",
+				OciUrl:               "example.com/runner:arm",
+				EnvironmentVariables: map[string]string{"FOO": "bar"},
+				Arch:                 "arm64",
+			},
+		})
+		require.NoError(err)
+
+		// Jobs can't target profiles that don't exist
+		_, err = client.QueueJob(ctx, &pb.QueueJobRequest{
+			Job: serverptypes.TestJobNew(t, &pb.Job{RunnerProfile: "nope"}),
+		})
+		require.Error(err)
+		require.Equal(codes.FailedPrecondition, status.Code(err))
+
+		_, err = client.QueueJob(ctx, &pb.QueueJobRequest{
+			Job: serverptypes.TestJobNew(t, &pb.Job{RunnerProfile: "arm"}),
+		})
+		require.NoError(err)
+
+		l := launched(t, s, 1, "")[0]
+		require.Equal("arm", l.Profile)
+
+		task := sched.task(l.TaskId)
+		require.Contains(task.Args, "-profile=arm")
+		require.Equal("example.com/runner:arm", task.Image)
+		require.Equal("arm64", task.Arch)
+		require.Equal("bar", task.Env["FOO"])
+		require.NotEmpty(task.Env[dispatchEnvServerToken])
+	})
+
+	t.Run("runners are launched on the platform of the job", func(t *testing.T) {
+		require := require.New(t)
+		sched := &testScheduler{}
+		client, s := init(t, sched)
+
+		_, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+			Job: serverptypes.TestJobNew(t, &pb.Job{Platform: "linux/arm64"}),
+		})
+		require.NoError(err)
+
+		l := launched(t, s, 1, "")[0]
+		require.Equal("linux/arm64", l.Platform)
+
+		task := sched.task(l.TaskId)
+		require.Equal("linux", task.OS)
+		require.Equal("arm64", task.Arch)
+	})
+
+	t.Run("runners that exit before registering are removed", func(t *testing.T) {
+		require := require.New(t)
+		sched := &testScheduler{}
+		client, s := init(t, sched)
+
+		_, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+			Job: serverptypes.TestJobNew(t, nil),
+		})
+		require.NoError(err)
+
+		l := launched(t, s, 1, "")[0]
+		sched.set(l.TaskId, dispatch.StatusFailed)
+		require.Eventually(func() bool {
+			return sched.stopped(l.TaskId)
+		}, 5*time.Second, 10*time.Millisecond)
+
+		// The job is still queued so another runner is launched
+		launched(t, s, 1, l.Id)
+	})
+
+	t.Run("submit errors don't record a launch", func(t *testing.T) {
+		require := require.New(t)
+		sched := &testScheduler{err: errors.New("no capacity")}
+		client, s := init(t, sched)
+
+		_, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+			Job: serverptypes.TestJobNew(t, nil),
+		})
+		require.NoError(err)
+
+		err = s.runnerLaunch(ctx, hclog.L())
+		require.Error(err)
+		require.Contains(err.Error(), "no capacity")
+		launched(t, s, 0, "")
+	})
+}
@@ -0,0 +1,56 @@
+package singleprocess
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func (s *service) SetRunnerProfile(
+	ctx context.Context,
+	req *pb.SetRunnerProfileRequest,
+) (*pb.RunnerProfile, error) {
+	if req.Profile == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "profile must be set")
+	}
+
+	if err := s.state.RunnerProfilePut(req.Profile); err != nil {
+		return nil, err
+	}
+
+	return s.state.RunnerProfileGet(req.Profile.Name)
+}
+
+func (s *service) GetRunnerProfile(
+	ctx context.Context,
+	req *pb.GetRunnerProfileRequest,
+) (*pb.RunnerProfile, error) {
+	return s.state.RunnerProfileGet(req.Name)
+}
+
+func (s *service) ListRunnerProfiles(
+	ctx context.Context,
+	req *empty.Empty,
+) (*pb.ListRunnerProfilesResponse, error) {
+	profiles, err := s.state.RunnerProfileList()
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ListRunnerProfilesResponse{Profiles: profiles}, nil
+}
+
+func (s *service) DeleteRunnerProfile(
+	ctx context.Context,
+	req *pb.DeleteRunnerProfileRequest,
+) (*empty.Empty, error) {
+	if err := s.state.RunnerProfileDelete(req.Name); err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
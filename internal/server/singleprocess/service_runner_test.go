@@ -2,16 +2,22 @@ package singleprocess
 
 import (
 	"context"
+	"crypto/ed25519"
 	"io"
 	"testing"
+	"time"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	"github.com/hashicorp/waypoint/internal/server"
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
 	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+	"github.com/hashicorp/waypoint/internal/serverconfig"
 )
 
 // Complete happy path job stream
@@ -54,7 +60,16 @@ func TestServiceRunnerJobStream_complete(t *testing.T) {
 		assignment, ok := resp.Event.(*pb.RunnerJobStreamResponse_Assignment)
 		require.True(ok, "should be an assignment")
 		require.NotNil(assignment)
-		require.Equal(queueResp.JobId, assignment.Assignment.Job.Id)
+		require.Equal(queueResp.JobId, testAssignmentPayload(t, assignment.Assignment).Job.Id)
+
+		// The assignment should be signed with the runner and job
+		pub := impl.(*service).jobSigningKey.Public().(ed25519.PublicKey)
+		require.True(ed25519.Verify(pub,
+			assignment.Assignment.JobPayload, assignment.Assignment.Signature))
+		payload := testAssignmentPayload(t, assignment.Assignment)
+		require.Equal(id, payload.RunnerId)
+		require.Equal(queueResp.JobId, payload.JobId)
+		require.NotNil(payload.AssignTime)
 
 		require.NoError(stream.Send(&pb.RunnerJobStreamRequest{
 			Event: &pb.RunnerJobStreamRequest_Ack_{
@@ -145,7 +160,7 @@ func TestServiceRunnerJobStream_errorBeforeAck(t *testing.T) {
 		assignment, ok := resp.Event.(*pb.RunnerJobStreamResponse_Assignment)
 		require.True(ok, "should be an assignment")
 		require.NotNil(assignment)
-		require.Equal(queueResp.JobId, assignment.Assignment.Job.Id)
+		require.Equal(queueResp.JobId, testAssignmentPayload(t, assignment.Assignment).Job.Id)
 
 		require.NoError(stream.Send(&pb.RunnerJobStreamRequest{
 			Event: &pb.RunnerJobStreamRequest_Error_{
@@ -207,7 +222,7 @@ func TestServiceRunnerJobStream_cancel(t *testing.T) {
 		assignment, ok := resp.Event.(*pb.RunnerJobStreamResponse_Assignment)
 		require.True(ok, "should be an assignment")
 		require.NotNil(assignment)
-		require.Equal(queueResp.JobId, assignment.Assignment.Job.Id)
+		require.Equal(queueResp.JobId, testAssignmentPayload(t, assignment.Assignment).Job.Id)
 
 		require.NoError(stream.Send(&pb.RunnerJobStreamRequest{
 			Event: &pb.RunnerJobStreamRequest_Ack_{
@@ -284,3 +299,339 @@ func TestServiceRunnerGetDeploymentConfig(t *testing.T) {
 		require.NotEmpty(resp.ServerAddr)
 	})
 }
+
+func TestServiceDrainRunner(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+	s := testServiceImpl(impl)
+
+	_, err = client.DrainRunner(ctx, &pb.DrainRunnerRequest{RunnerId: "nope"})
+	require.Error(err)
+	require.Equal(codes.NotFound, status.Code(err))
+
+	require.NoError(s.state.RunnerCreate(serverptypes.TestRunner(t, &pb.Runner{Id: "A"})))
+
+	r, err := client.DrainRunner(ctx, &pb.DrainRunnerRequest{RunnerId: "A"})
+	require.NoError(err)
+	require.True(r.Draining)
+
+	r, err = client.GetRunner(ctx, &pb.GetRunnerRequest{RunnerId: "A"})
+	require.NoError(err)
+	require.True(r.Draining)
+
+	r, err = client.UndrainRunner(ctx, &pb.DrainRunnerRequest{RunnerId: "A"})
+	require.NoError(err)
+	require.False(r.Draining)
+}
+
+func TestServiceApproveRunner(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	impl, err := New(
+		WithDB(testDB(t)),
+		WithConfig(&serverconfig.Config{RunnerApproval: true}),
+	)
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+
+	_, err = client.ApproveRunner(ctx, &pb.ApproveRunnerRequest{})
+	require.Error(err)
+	require.Equal(codes.FailedPrecondition, status.Code(err))
+
+	// New runners are pending, even if they say they aren't
+	id, _ := TestRunner(t, client, &pb.Runner{Pending: false})
+	r, err := client.GetRunner(ctx, &pb.GetRunnerRequest{RunnerId: id})
+	require.NoError(err)
+	require.True(r.Pending)
+
+	_, err = client.ApproveRunner(ctx, &pb.ApproveRunnerRequest{RunnerId: id})
+	require.NoError(err)
+
+	r, err = client.GetRunner(ctx, &pb.GetRunnerRequest{RunnerId: id})
+	require.NoError(err)
+	require.False(r.Pending)
+
+	// Runners that register with a token created for them are approved
+	resp, err := client.CreateRunnerToken(ctx, &pb.CreateRunnerTokenRequest{RunnerId: "R"})
+	require.NoError(err)
+	stream, err := client.RunnerConfig(
+		metadata.AppendToOutgoingContext(ctx, "authorization", resp.Token))
+	require.NoError(err)
+	defer stream.CloseSend()
+	require.NoError(stream.Send(&pb.RunnerConfigRequest{
+		Event: &pb.RunnerConfigRequest_Open_{
+			Open: &pb.RunnerConfigRequest_Open{
+				Runner: &pb.Runner{Id: "R"},
+			},
+		},
+	}))
+	_, err = stream.Recv()
+	require.NoError(err)
+
+	r, err = client.GetRunner(ctx, &pb.GetRunnerRequest{RunnerId: "R"})
+	require.NoError(err)
+	require.False(r.Pending)
+}
+
+func TestServiceRunnerConfig_heartbeat(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+	s := testServiceImpl(impl)
+
+	id, _ := TestRunner(t, client, nil)
+
+	// Wait on a job, which stops if the runner is deregistered
+	stream, err := client.RunnerJobStream(ctx)
+	require.NoError(err)
+	require.NoError(stream.Send(&pb.RunnerJobStreamRequest{
+		Event: &pb.RunnerJobStreamRequest_Request_{
+			Request: &pb.RunnerJobStreamRequest_Request{
+				RunnerId: id,
+			},
+		},
+	}))
+	time.Sleep(50 * time.Millisecond)
+
+	require.NoError(s.state.RunnerDelete(id))
+	_, err = stream.Recv()
+	require.Error(err)
+	require.Equal(codes.NotFound, status.Code(err))
+
+	// A heartbeat from a deregistered runner registers it again
+	config, err := client.RunnerConfig(ctx)
+	require.NoError(err)
+	defer config.CloseSend()
+	require.NoError(config.Send(&pb.RunnerConfigRequest{
+		Event: &pb.RunnerConfigRequest_Open_{
+			Open: &pb.RunnerConfigRequest_Open{
+				Runner: &pb.Runner{Id: "B"},
+			},
+		},
+	}))
+	_, err = config.Recv()
+	require.NoError(err)
+
+	require.NoError(s.state.RunnerDelete("B"))
+	require.NoError(config.Send(&pb.RunnerConfigRequest{
+		Event: &pb.RunnerConfigRequest_Heartbeat_{
+			Heartbeat: &pb.RunnerConfigRequest_Heartbeat{},
+		},
+	}))
+	require.Eventually(func() bool {
+		lastSeen, err := s.state.RunnerLastSeen("B")
+		return err == nil && !lastSeen.IsZero()
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestServiceRunnerConfig_files(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+
+	_, err = client.SetRunnerConfigFile(ctx, &pb.SetRunnerConfigFileRequest{
+		File: &pb.RunnerConfigFile{Path: "docker/config.json", Data: []byte("{}")},
+	})
+	require.NoError(err)
+
+	config, err := client.RunnerConfig(ctx)
+	require.NoError(err)
+	defer config.CloseSend()
+	require.NoError(config.Send(&pb.RunnerConfigRequest{
+		Event: &pb.RunnerConfigRequest_Open_{
+			Open: &pb.RunnerConfigRequest_Open{
+				Runner: &pb.Runner{Id: "A"},
+			},
+		},
+	}))
+
+	// The files are sent when the runner registers
+	resp, err := config.Recv()
+	require.NoError(err)
+	require.Len(resp.Config.ConfigFiles, 1)
+	require.Equal("{}", string(resp.Config.ConfigFiles[0].Data))
+	require.Equal(uint64(1), resp.Config.ConfigVersion)
+
+	// And again when they change
+	_, err = client.DeleteRunnerConfigFile(ctx, &pb.DeleteRunnerConfigFileRequest{
+		Path: "docker/config.json",
+	})
+	require.NoError(err)
+	resp, err = config.Recv()
+	require.NoError(err)
+	require.Empty(resp.Config.ConfigFiles)
+	require.Equal(uint64(2), resp.Config.ConfigVersion)
+
+	list, err := client.ListRunnerConfigFiles(ctx, &empty.Empty{})
+	require.NoError(err)
+	require.Empty(list.Files)
+}
+
+func TestServiceRunnerConfig_version(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+
+	_, err = client.SetRunnerVersion(ctx, &pb.SetRunnerVersionRequest{Version: "0.3.0"})
+	require.NoError(err)
+
+	config, err := client.RunnerConfig(ctx)
+	require.NoError(err)
+	defer config.CloseSend()
+	require.NoError(config.Send(&pb.RunnerConfigRequest{
+		Event: &pb.RunnerConfigRequest_Open_{
+			Open: &pb.RunnerConfigRequest_Open{
+				Runner: &pb.Runner{Id: "A", Version: "0.2.0"},
+			},
+		},
+	}))
+
+	// The version is sent when the runner registers and the runner is
+	// pending until it updates
+	resp, err := config.Recv()
+	require.NoError(err)
+	require.Equal("0.3.0", resp.Config.DesiredVersion)
+
+	version, err := client.GetRunnerVersion(ctx, &empty.Empty{})
+	require.NoError(err)
+	require.Len(version.Updates, 1)
+	require.Equal(pb.RunnerUpdate_PENDING, version.Updates[0].State)
+
+	_, err = client.SetRunnerUpdateState(ctx, &pb.SetRunnerUpdateStateRequest{
+		RunnerId: "A",
+		State:    pb.RunnerUpdate_UPDATING,
+	})
+	require.NoError(err)
+
+	// And again when it changes
+	_, err = client.SetRunnerVersion(ctx, &pb.SetRunnerVersionRequest{Version: "0.3.1"})
+	require.NoError(err)
+	resp, err = config.Recv()
+	require.NoError(err)
+	require.Equal("0.3.1", resp.Config.DesiredVersion)
+}
+
+func TestServiceListRunners(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+	s := testServiceImpl(impl)
+
+	for _, id := range []string{"A", "B", "C"} {
+		require.NoError(s.state.RunnerCreate(serverptypes.TestRunner(t, &pb.Runner{Id: id})))
+	}
+	_, err = s.state.RunnerDrain("B")
+	require.NoError(err)
+
+	resp, err := client.ListRunners(ctx, &pb.ListRunnersRequest{PageSize: 2})
+	require.NoError(err)
+	require.Len(resp.Runners, 2)
+	require.Equal("A", resp.Runners[0].Runner.Id)
+	require.Equal(pb.RunnerStatus_DRAINING, resp.Runners[1].State)
+	require.NotEmpty(resp.NextPageToken)
+
+	resp, err = client.ListRunners(ctx, &pb.ListRunnersRequest{
+		PageSize:  2,
+		PageToken: resp.NextPageToken,
+	})
+	require.NoError(err)
+	require.Len(resp.Runners, 1)
+	require.Equal("C", resp.Runners[0].Runner.Id)
+	require.Empty(resp.NextPageToken)
+}
+
+func TestServiceGetQueueDepth(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+	s := testServiceImpl(impl)
+
+	// No runner is registered so the job can't be assigned
+	require.NoError(s.state.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+		Id: "A",
+		TargetRunner: &pb.Ref_Runner{Target: &pb.Ref_Runner_Pool{
+			Pool: &pb.Ref_RunnerPool{Name: "build"},
+		}},
+	})))
+
+	resp, err := client.GetQueueDepth(ctx, &empty.Empty{})
+	require.NoError(err)
+	require.Len(resp.Targets, 1)
+	require.Equal("build", resp.Targets[0].Target.GetPool().GetName())
+	require.Equal(uint32(1), resp.Targets[0].Queued)
+	require.Equal(uint32(1), resp.Targets[0].Unassignable)
+
+	// A runner in the pool can run it
+	require.NoError(s.state.RunnerCreate(serverptypes.TestRunner(t, &pb.Runner{Pool: "build"})))
+
+	resp, err = client.GetQueueDepth(ctx, &empty.Empty{})
+	require.NoError(err)
+	require.Len(resp.Targets, 1)
+	require.Equal(uint32(1), resp.Targets[0].Queued)
+	require.Equal(uint32(0), resp.Targets[0].Unassignable)
+}
+
+func TestServiceGetRunnerStats(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+	s := testServiceImpl(impl)
+
+	r := serverptypes.TestRunner(t, nil)
+	require.NoError(s.state.RunnerCreate(r))
+
+	// Run a job on the runner
+	require.NoError(s.state.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+	job, err := s.state.JobAssignForRunner(ctx, r)
+	require.NoError(err)
+	_, err = s.state.JobAck(ctx, job.Id, true)
+	require.NoError(err)
+	require.NoError(s.state.JobComplete(ctx, job.Id, nil, nil))
+
+	resp, err := client.GetRunnerStats(ctx, &pb.GetRunnerStatsRequest{})
+	require.NoError(err)
+	require.Len(resp.Stats, 1)
+	require.Equal(r.Id, resp.Stats[0].RunnerId)
+	require.Equal(uint64(1), resp.Stats[0].JobsRun)
+
+	resp, err = client.GetRunnerStats(ctx, &pb.GetRunnerStatsRequest{RunnerId: r.Id})
+	require.NoError(err)
+	require.Len(resp.Stats, 1)
+
+	_, err = client.GetRunnerStats(ctx, &pb.GetRunnerStatsRequest{RunnerId: "nope"})
+	require.Error(err)
+	require.Equal(codes.NotFound, status.Code(err))
+}
+
+// testAssignmentPayload returns the signed payload of a job assignment.
+func testAssignmentPayload(
+	t *testing.T,
+	a *pb.RunnerJobStreamResponse_JobAssignment,
+) *pb.RunnerJobStreamResponse_JobAssignment_Payload {
+	var payload pb.RunnerJobStreamResponse_JobAssignment_Payload
+	require.NoError(t, proto.Unmarshal(a.JobPayload, &payload))
+	return &payload
+}
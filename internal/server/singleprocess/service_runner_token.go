@@ -0,0 +1,149 @@
+package singleprocess
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/server"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func (s *service) CreateRunnerToken(
+	ctx context.Context,
+	req *pb.CreateRunnerTokenRequest,
+) (*pb.RunnerTokenResponse, error) {
+	id := req.RunnerId
+	if id == "" {
+		var err error
+		id, err = server.Id()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "uuid generation failed: %s", err)
+		}
+	}
+
+	// Each runner has a single token. Rotate replaces it so that creating
+	// can't be used to get a second token for a runner.
+	_, err := s.state.RunnerTokenGet(id)
+	if err == nil {
+		return nil, status.Errorf(codes.AlreadyExists,
+			"runner %s already has a token, rotate it to issue a new one", id)
+	}
+	if status.Code(err) != codes.NotFound {
+		return nil, err
+	}
+
+	return s.runnerTokenIssue(&pb.RunnerToken{
+		RunnerId:  id,
+		CreatedAt: ptypes.TimestampNow(),
+	})
+}
+
+func (s *service) RotateRunnerToken(
+	ctx context.Context,
+	req *pb.RotateRunnerTokenRequest,
+) (*pb.RunnerTokenResponse, error) {
+	t, err := s.state.RunnerTokenGet(req.RunnerId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Copy since we modify it.
+	t = proto.Clone(t).(*pb.RunnerToken)
+	return s.runnerTokenIssue(t)
+}
+
+func (s *service) ListRunnerTokens(
+	ctx context.Context,
+	req *empty.Empty,
+) (*pb.ListRunnerTokensResponse, error) {
+	tokens, err := s.state.RunnerTokenList()
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ListRunnerTokensResponse{RunnerTokens: tokens}, nil
+}
+
+func (s *service) RevokeRunnerToken(
+	ctx context.Context,
+	req *pb.RevokeRunnerTokenRequest,
+) (*empty.Empty, error) {
+	if err := s.state.RunnerTokenDelete(req.RunnerId); err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
+
+// runnerTokenIssue generates a new token for the runner and stores it with
+// the new token ID. Any previous token for the runner is no longer valid.
+func (s *service) runnerTokenIssue(t *pb.RunnerToken) (*pb.RunnerTokenResponse, error) {
+	var body pb.Token
+	body.Login = true
+	body.User = DefaultUser
+	body.Runner = &pb.Token_Runner{RunnerId: t.RunnerId}
+	body.TokenId = make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, body.TokenId); err != nil {
+		return nil, err
+	}
+
+	token, err := s.GenerateToken(DefaultKeyId, nil, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	t.TokenId = body.TokenId
+	t.RotatedAt = ptypes.TimestampNow()
+	if err := s.state.RunnerTokenPut(t); err != nil {
+		return nil, err
+	}
+
+	return &pb.RunnerTokenResponse{RunnerToken: t, Token: token}, nil
+}
+
+// authenticateRunnerToken verifies that the token for a single runner is
+// the current token of the runner.
+func (s *service) authenticateRunnerToken(body *pb.Token) error {
+	t, err := s.state.RunnerTokenGet(body.Runner.RunnerId)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return ErrInvalidToken
+		}
+
+		return err
+	}
+
+	// The token must be the current token for the runner. If the token
+	// was rotated, older tokens are no longer valid.
+	if !bytes.Equal(t.TokenId, body.TokenId) {
+		return ErrInvalidToken
+	}
+
+	return nil
+}
+
+// callerRunnerId returns the ID of the runner that the token in the request
+// metadata was created for with CreateRunnerToken. This is empty for other
+// tokens.
+func (s *service) callerRunnerId(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md["authorization"]) == 0 {
+		return ""
+	}
+
+	_, body, err := s.DecodeToken(md["authorization"][0])
+	if err != nil || body.Runner == nil {
+		return ""
+	}
+
+	return body.Runner.RunnerId
+}
@@ -0,0 +1,152 @@
+package singleprocess
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/server"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func TestServiceRunnerToken(t *testing.T) {
+	ctx := context.Background()
+
+	// Create our server
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(t, err)
+	client := server.TestServer(t, impl)
+	s := impl.(*service)
+
+	t.Run("create, rotate, and revoke", func(t *testing.T) {
+		require := require.New(t)
+
+		// Create
+		resp, err := client.CreateRunnerToken(ctx, &pb.CreateRunnerTokenRequest{
+			RunnerId: "A",
+		})
+		require.NoError(err)
+		require.NotEmpty(resp.Token)
+		require.Equal("A", resp.RunnerToken.RunnerId)
+		require.NoError(s.Authenticate(ctx, resp.Token, "RunnerJobStream", nil))
+		require.Equal("runner:A", s.AuditIdentity(resp.Token))
+
+		// Creating again fails
+		_, err = client.CreateRunnerToken(ctx, &pb.CreateRunnerTokenRequest{
+			RunnerId: "A",
+		})
+		require.Error(err)
+		require.Equal(codes.AlreadyExists, status.Code(err))
+
+		// Rotate, the old token should no longer work
+		rotated, err := client.RotateRunnerToken(ctx, &pb.RotateRunnerTokenRequest{RunnerId: "A"})
+		require.NoError(err)
+		require.NotEqual(resp.Token, rotated.Token)
+		require.Equal(resp.RunnerToken.CreatedAt.Seconds, rotated.RunnerToken.CreatedAt.Seconds)
+		require.Error(s.Authenticate(ctx, resp.Token, "RunnerJobStream", nil))
+		require.NoError(s.Authenticate(ctx, rotated.Token, "RunnerJobStream", nil))
+
+		// List
+		{
+			resp, err := client.ListRunnerTokens(ctx, &empty.Empty{})
+			require.NoError(err)
+			require.Len(resp.RunnerTokens, 1)
+			require.Equal("A", resp.RunnerTokens[0].RunnerId)
+		}
+
+		// Revoke, the token should no longer work
+		_, err = client.RevokeRunnerToken(ctx, &pb.RevokeRunnerTokenRequest{RunnerId: "A"})
+		require.NoError(err)
+		require.Error(s.Authenticate(ctx, rotated.Token, "RunnerJobStream", nil))
+	})
+
+	t.Run("generates an ID", func(t *testing.T) {
+		require := require.New(t)
+
+		resp, err := client.CreateRunnerToken(ctx, &pb.CreateRunnerTokenRequest{})
+		require.NoError(err)
+		require.NotEmpty(resp.RunnerToken.RunnerId)
+	})
+
+	t.Run("runner tokens can't manage other tokens", func(t *testing.T) {
+		require := require.New(t)
+
+		resp, err := client.CreateRunnerToken(ctx, &pb.CreateRunnerTokenRequest{
+			RunnerId: "B",
+		})
+		require.NoError(err)
+
+		for _, endpoint := range []string{"CreateRunnerToken", "RevokeRunnerToken", "ListRunnerTokens"} {
+			err := s.Authenticate(ctx, resp.Token, endpoint, nil)
+			require.Error(err, endpoint)
+			require.Equal(codes.Unauthenticated, status.Code(err))
+		}
+		require.NoError(s.Authenticate(ctx, resp.Token, "RotateRunnerToken", nil))
+	})
+}
+
+func TestServiceAuthorizeRequest_runnerToken(t *testing.T) {
+	ctx := context.Background()
+
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(t, err)
+	s := impl.(*service)
+
+	resp, err := s.CreateRunnerToken(ctx, &pb.CreateRunnerTokenRequest{RunnerId: "A"})
+	require.NoError(t, err)
+	runnerToken := resp.Token
+
+	loginToken, err := s.NewLoginToken(DefaultKeyId, nil, nil)
+	require.NoError(t, err)
+
+	open := func(id string) *pb.RunnerConfigRequest {
+		return &pb.RunnerConfigRequest{
+			Event: &pb.RunnerConfigRequest_Open_{
+				Open: &pb.RunnerConfigRequest_Open{
+					Runner: &pb.Runner{Id: id},
+				},
+			},
+		}
+	}
+
+	stream := func(id string) *pb.RunnerJobStreamRequest {
+		return &pb.RunnerJobStreamRequest{
+			Event: &pb.RunnerJobStreamRequest_Request_{
+				Request: &pb.RunnerJobStreamRequest_Request{RunnerId: id},
+			},
+		}
+	}
+
+	cases := []struct {
+		Name    string
+		Token   string
+		Req     interface{}
+		Allowed bool
+	}{
+		{"register as own runner", runnerToken, open("A"), true},
+		{"register as other runner", runnerToken, open("B"), false},
+		{"job stream as own runner", runnerToken, stream("A"), true},
+		{"job stream as other runner", runnerToken, stream("B"), false},
+		{"rotate own token", runnerToken, &pb.RotateRunnerTokenRequest{RunnerId: "A"}, true},
+		{"rotate other token", runnerToken, &pb.RotateRunnerTokenRequest{RunnerId: "B"}, false},
+		{"login token", loginToken, open("B"), true},
+		{"login token rotate", loginToken, &pb.RotateRunnerTokenRequest{RunnerId: "A"}, true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := s.AuthorizeRequest(ctx, tt.Token, "", tt.Req)
+			if tt.Allowed {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			require.Equal(t, codes.PermissionDenied, status.Code(err))
+		})
+	}
+}
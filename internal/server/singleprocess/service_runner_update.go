@@ -0,0 +1,54 @@
+package singleprocess
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func (s *service) SetRunnerVersion(
+	ctx context.Context,
+	req *pb.SetRunnerVersionRequest,
+) (*empty.Empty, error) {
+	if err := s.state.RunnerVersionSet(req.Version); err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
+
+func (s *service) GetRunnerVersion(
+	ctx context.Context,
+	req *empty.Empty,
+) (*pb.GetRunnerVersionResponse, error) {
+	version, updates, err := s.state.RunnerVersionGet()
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetRunnerVersionResponse{
+		Version: version,
+		Updates: updates,
+	}, nil
+}
+
+func (s *service) SetRunnerUpdateState(
+	ctx context.Context,
+	req *pb.SetRunnerUpdateStateRequest,
+) (*empty.Empty, error) {
+	// A runner with a token of its own can only report its own state.
+	if id := s.callerRunnerId(ctx); id != "" && id != req.RunnerId {
+		return nil, status.Errorf(codes.PermissionDenied,
+			"runner token can only report the update state of runner %s", id)
+	}
+
+	if err := s.state.RunnerUpdateStateSet(req.RunnerId, req.State, req.Error); err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
@@ -89,6 +89,11 @@ func (s *service) RestoreSnapshot(
 	go func() {
 		defer close(restoreCloseCh)
 		defer pr.Close()
+		if open.Open.Online {
+			restoreCloseCh <- s.state.RestoreSnapshot(pr)
+			return
+		}
+
 		restoreCloseCh <- s.state.StageRestoreSnapshot(pr)
 	}()
 
@@ -117,7 +122,7 @@ func (s *service) RestoreSnapshot(
 			}
 
 			// Restore was successful.
-			if open.Open.Exit {
+			if open.Open.Exit && !open.Open.Online {
 				log.Warn("restore requested exit, closing database and exiting NOW")
 				s.state.Close()
 				os.Exit(2) // kind of a weird exit code to note this was manufactured
@@ -0,0 +1,256 @@
+package singleprocess
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/pkg/cron"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/serverconfig"
+)
+
+const (
+	// snapshotDefaultRetain is the number of scheduled snapshots kept if
+	// it isn't configured.
+	snapshotDefaultRetain = 7
+
+	// Scheduled snapshots are named with the prefix, the UTC time they
+	// were taken in snapshotTimeFormat, and the suffix. Only files named
+	// like this are listed and rotated so the destination can be shared.
+	snapshotPrefix     = "waypoint-snapshot-"
+	snapshotSuffix     = ".snap"
+	snapshotTimeFormat = "20060102T150405Z"
+)
+
+func (s *service) ListSnapshots(
+	ctx context.Context,
+	req *empty.Empty,
+) (*pb.ListSnapshotsResponse, error) {
+	if s.snapshotConfig == nil {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"scheduled snapshots are not configured")
+	}
+
+	snapshots, err := s.snapshotList()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal,
+			"failed to list snapshots: %s", err)
+	}
+
+	s.snapshotLock.Lock()
+	next, lastErr := s.snapshotNext, s.snapshotErr
+	s.snapshotLock.Unlock()
+
+	result := &pb.ListSnapshotsResponse{Snapshots: snapshots}
+	if !next.IsZero() {
+		result.NextTime, _ = ptypes.TimestampProto(next)
+	}
+	if lastErr != nil {
+		result.LastError = lastErr.Error()
+	}
+
+	return result, nil
+}
+
+// snapshotInit validates the snapshot configuration and starts taking
+// snapshots on its schedule.
+func (s *service) snapshotInit(log hclog.Logger, cfg *serverconfig.Snapshot) error {
+	schedule, err := cron.Parse(cfg.Schedule)
+	if err != nil {
+		return fmt.Errorf("invalid snapshot schedule: %s", err)
+	}
+	if cfg.Path == "" {
+		return fmt.Errorf("scheduled snapshots require a path to write snapshots to")
+	}
+	if cfg.Retain < 0 {
+		return fmt.Errorf("the number of snapshots to retain can't be negative")
+	}
+	if err := os.MkdirAll(cfg.Path, 0700); err != nil {
+		return fmt.Errorf("failed to create snapshot path: %s", err)
+	}
+
+	s.snapshotConfig = cfg
+	s.snapshotSchedule = schedule
+	s.snapshotNext = schedule.Next(time.Now().UTC())
+	go s.snapshotRun(context.Background(), log.Named("snapshot"))
+	return nil
+}
+
+// snapshotRun takes a snapshot at each time of the schedule until the
+// context is cancelled.
+func (s *service) snapshotRun(ctx context.Context, log hclog.Logger) {
+	for {
+		s.snapshotLock.Lock()
+		next := s.snapshotNext
+		s.snapshotLock.Unlock()
+		if next.IsZero() {
+			log.Error("snapshot schedule has no upcoming times, stopping")
+			return
+		}
+
+		log.Debug("waiting for next snapshot", "time", next)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+
+		case <-timer.C:
+		}
+
+		err := s.snapshotTake(next)
+		if err != nil {
+			log.Error("scheduled snapshot failed", "err", err)
+		} else {
+			log.Info("scheduled snapshot written", "time", next)
+		}
+
+		s.snapshotLock.Lock()
+		s.snapshotErr = err
+		s.snapshotNext = s.snapshotSchedule.Next(time.Now().UTC())
+		s.snapshotLock.Unlock()
+	}
+}
+
+// snapshotTake writes a snapshot for the time t to the destination and
+// then deletes the snapshots that are no longer retained.
+func (s *service) snapshotTake(t time.Time) error {
+	// We write to a temporary file and rename it once it is complete so
+	// a partial snapshot is never listed or rotated over a good one.
+	f, err := ioutil.TempFile(s.snapshotConfig.Path, ".waypoint-snapshot-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := s.state.CreateSnapshot(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	name := snapshotName(t)
+	if err := os.Rename(f.Name(), filepath.Join(s.snapshotConfig.Path, name)); err != nil {
+		return err
+	}
+
+	return s.snapshotRotate()
+}
+
+// snapshotRotate deletes the oldest snapshots beyond the number that are
+// retained.
+func (s *service) snapshotRotate() error {
+	retain := s.snapshotConfig.Retain
+	if retain == 0 {
+		retain = snapshotDefaultRetain
+	}
+
+	snapshots, err := s.snapshotList()
+	if err != nil {
+		return err
+	}
+	if len(snapshots) <= retain {
+		return nil
+	}
+
+	for _, snap := range snapshots[retain:] {
+		path := filepath.Join(s.snapshotConfig.Path, snap.Name)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// snapshotList returns the scheduled snapshots in the destination, most
+// recent first. These are read from the destination rather than tracked
+// in memory so they're listed across restarts.
+func (s *service) snapshotList() ([]*pb.SnapshotInfo, error) {
+	entries, err := ioutil.ReadDir(s.snapshotConfig.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	type snapshot struct {
+		info *pb.SnapshotInfo
+		time time.Time
+	}
+
+	var snapshots []snapshot
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Mode().IsRegular() {
+			continue
+		}
+
+		t, ok := snapshotNameTime(name)
+		if !ok {
+			continue
+		}
+
+		pt, err := ptypes.TimestampProto(t)
+		if err != nil {
+			return nil, err
+		}
+
+		snapshots = append(snapshots, snapshot{
+			info: &pb.SnapshotInfo{
+				Name: name,
+				Time: pt,
+				Size: entry.Size(),
+			},
+			time: t,
+		})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].time.After(snapshots[j].time)
+	})
+
+	result := make([]*pb.SnapshotInfo, len(snapshots))
+	for i, snap := range snapshots {
+		result[i] = snap.info
+	}
+
+	return result, nil
+}
+
+// snapshotName returns the name of the scheduled snapshot for the time t.
+func snapshotName(t time.Time) string {
+	return snapshotPrefix + t.UTC().Format(snapshotTimeFormat) + snapshotSuffix
+}
+
+// snapshotNameTime returns the time of the scheduled snapshot with the
+// given name. This returns false if the name isn't a scheduled snapshot.
+func snapshotNameTime(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, snapshotPrefix) || !strings.HasSuffix(name, snapshotSuffix) {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(snapshotTimeFormat,
+		strings.TrimSuffix(strings.TrimPrefix(name, snapshotPrefix), snapshotSuffix))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
@@ -0,0 +1,99 @@
+package singleprocess
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/server"
+	"github.com/hashicorp/waypoint/internal/serverconfig"
+)
+
+func TestServiceSnapshotSchedule(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("takes and rotates snapshots", func(t *testing.T) {
+		require := require.New(t)
+
+		dir, err := ioutil.TempDir("", "waypoint-test")
+		require.NoError(err)
+		defer os.RemoveAll(dir)
+
+		// A file that isn't a snapshot is left alone
+		other := filepath.Join(dir, "other.snap")
+		require.NoError(ioutil.WriteFile(other, []byte("hello"), 0600))
+
+		impl, err := New(
+			WithDB(testDB(t)),
+			WithConfig(&serverconfig.Config{
+				Snapshot: &serverconfig.Snapshot{
+					Schedule: "@yearly",
+					Path:     dir,
+					Retain:   2,
+				},
+			}),
+		)
+		require.NoError(err)
+		client := server.TestServer(t, impl)
+		s := testServiceImpl(impl)
+
+		start := time.Date(2020, time.October, 14, 0, 0, 0, 0, time.UTC)
+		for i := 0; i < 3; i++ {
+			require.NoError(s.snapshotTake(start.Add(time.Duration(i) * time.Hour)))
+		}
+
+		resp, err := client.ListSnapshots(ctx, &empty.Empty{})
+		require.NoError(err)
+		require.Empty(resp.LastError)
+		require.NotNil(resp.NextTime)
+		require.Len(resp.Snapshots, 2)
+		require.Equal("waypoint-snapshot-20201014T020000Z.snap", resp.Snapshots[0].Name)
+		require.Equal("waypoint-snapshot-20201014T010000Z.snap", resp.Snapshots[1].Name)
+		require.True(resp.Snapshots[0].Size > 0)
+
+		ts, err := ptypes.Timestamp(resp.Snapshots[0].Time)
+		require.NoError(err)
+		require.Equal(start.Add(2*time.Hour), ts)
+
+		// The snapshots are valid
+		f, err := os.Open(filepath.Join(dir, resp.Snapshots[0].Name))
+		require.NoError(err)
+		defer f.Close()
+		require.NoError(s.state.StageRestoreSnapshot(f))
+
+		_, err = os.Stat(other)
+		require.NoError(err)
+	})
+
+	t.Run("invalid schedule", func(t *testing.T) {
+		_, err := New(
+			WithDB(testDB(t)),
+			WithConfig(&serverconfig.Config{
+				Snapshot: &serverconfig.Snapshot{
+					Schedule: "every day",
+					Path:     "snapshots",
+				},
+			}),
+		)
+		require.Error(t, err)
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		impl, err := New(WithDB(testDB(t)))
+		require.NoError(t, err)
+		client := server.TestServer(t, impl)
+
+		_, err = client.ListSnapshots(ctx, &empty.Empty{})
+		require.Error(t, err)
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+}
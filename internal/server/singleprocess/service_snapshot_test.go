@@ -109,3 +109,73 @@ func TestServiceRestoreSnapshot_full(t *testing.T) {
 	require.NoError(err)
 	require.NotNil(resp)
 }
+
+func TestServiceRestoreSnapshot_online(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	// Create our server
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+
+	// Create a project and take a snapshot
+	_, err = client.UpsertProject(ctx, &pb.UpsertProjectRequest{
+		Project: &pb.Project{Name: "A"},
+	})
+	require.NoError(err)
+
+	var snapshotBuf bytes.Buffer
+	{
+		stream, err := client.CreateSnapshot(ctx, &empty.Empty{})
+		require.NoError(err)
+
+		// Should get the open message
+		resp, err := stream.Recv()
+		require.NoError(err)
+		require.IsType((*pb.CreateSnapshotResponse_Open_)(nil), resp.Event)
+
+		// Get all the data
+		for {
+			resp, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(err)
+			snapshotBuf.Write(resp.Event.(*pb.CreateSnapshotResponse_Chunk).Chunk)
+		}
+	}
+
+	// Create another project after the snapshot
+	_, err = client.UpsertProject(ctx, &pb.UpsertProjectRequest{
+		Project: &pb.Project{Name: "B"},
+	})
+	require.NoError(err)
+
+	// Restore online
+	stream, err := client.RestoreSnapshot(ctx)
+	require.NoError(err)
+	require.NoError(stream.Send(&pb.RestoreSnapshotRequest{
+		Event: &pb.RestoreSnapshotRequest_Open_{
+			Open: &pb.RestoreSnapshotRequest_Open{Online: true},
+		},
+	}))
+	require.NoError(stream.Send(&pb.RestoreSnapshotRequest{
+		Event: &pb.RestoreSnapshotRequest_Chunk{
+			Chunk: snapshotBuf.Bytes(),
+		},
+	}))
+	_, err = stream.CloseAndRecv()
+	require.NoError(err)
+
+	// The project created after the snapshot is gone without a restart
+	_, err = client.GetProject(ctx, &pb.GetProjectRequest{
+		Project: &pb.Ref_Project{Project: "A"},
+	})
+	require.NoError(err)
+	_, err = client.GetProject(ctx, &pb.GetProjectRequest{
+		Project: &pb.Ref_Project{Project: "B"},
+	})
+	require.Error(err)
+	require.Equal(codes.NotFound, status.Code(err))
+}
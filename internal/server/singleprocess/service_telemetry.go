@@ -0,0 +1,28 @@
+package singleprocess
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func (s *service) GetTelemetryReport(
+	ctx context.Context,
+	req *empty.Empty,
+) (*pb.GetTelemetryReportResponse, error) {
+	report, err := json.Marshal(s.telemetry.Report())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.GetTelemetryReportResponse{Report: string(report)}
+	if cfg := s.telemetryConfig; cfg != nil {
+		resp.Enabled = true
+		resp.Endpoint = cfg.Endpoint
+	}
+
+	return resp, nil
+}
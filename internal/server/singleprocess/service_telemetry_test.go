@@ -0,0 +1,43 @@
+package singleprocess
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint/internal/server"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+	"github.com/hashicorp/waypoint/internal/telemetry"
+)
+
+func TestServiceTelemetry(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	// Create our server
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+
+	// Create a build
+	_, err = client.UpsertBuild(ctx, &pb.UpsertBuildRequest{
+		Build: serverptypes.TestValidBuild(t, &pb.Build{
+			Component: &pb.Component{Type: pb.Component_BUILDER, Name: "docker"},
+		}),
+	})
+	require.NoError(err)
+
+	// Telemetry is collected but disabled by default
+	resp, err := client.GetTelemetryReport(ctx, &empty.Empty{})
+	require.NoError(err)
+	require.False(resp.Enabled)
+	require.Empty(resp.Endpoint)
+
+	var report telemetry.Report
+	require.NoError(json.Unmarshal([]byte(resp.Report), &report))
+	require.Equal(uint64(1), report.Plugins["builder/docker"])
+}
@@ -0,0 +1,27 @@
+package singleprocess
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func (s *service) VerifyState(
+	ctx context.Context,
+	req *pb.VerifyStateRequest,
+) (*pb.VerifyStateResponse, error) {
+	issues, err := s.state.Verify(req.Repair)
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+
+		return nil, status.Errorf(codes.Internal,
+			"failed to verify state: %s", err)
+	}
+
+	return &pb.VerifyStateResponse{Issues: issues}, nil
+}
@@ -0,0 +1,137 @@
+package singleprocess
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func (s *service) SetWorkspacePolicy(
+	ctx context.Context,
+	req *pb.SetWorkspacePolicyRequest,
+) (*pb.WorkspacePolicy, error) {
+	p := req.Policy
+	if p == nil || p.Workspace == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "policy workspace must be set")
+	}
+
+	if err := s.state.WorkspacePolicySet(p); err != nil {
+		return nil, err
+	}
+
+	return s.state.WorkspacePolicyGet(p.Workspace)
+}
+
+func (s *service) GetWorkspacePolicy(
+	ctx context.Context,
+	req *pb.GetWorkspacePolicyRequest,
+) (*pb.WorkspacePolicy, error) {
+	if req.Workspace == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "workspace must be set")
+	}
+
+	return s.state.WorkspacePolicyGet(req.Workspace)
+}
+
+func (s *service) ApproveJob(
+	ctx context.Context,
+	req *pb.ApproveJobRequest,
+) (*pb.Job, error) {
+	user, roles, err := s.callerUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := s.state.JobApprove(req.JobId, user, roles, req.Revision)
+	if err != nil {
+		return nil, err
+	}
+
+	log := hclog.FromContext(ctx)
+	log.Info("job approved", "job", job.Id, "user", user,
+		"approvals", len(job.Approvals),
+		"required", job.ApprovalRequired.GetApprovals())
+
+	// Dispatched and forwarded jobs are submitted once they are approved.
+	if err := s.dispatchSubmit(ctx, job.Id); err != nil {
+		log.Warn("error dispatching job", "job", job.Id, "err", err)
+	}
+	if err := s.federationSubmit(ctx, job.Id); err != nil {
+		log.Warn("error forwarding job", "job", job.Id, "err", err)
+	}
+
+	return s.redactJob(job.Job), nil
+}
+
+// jobApprovalRequirement sets the approval requirement of a job that is
+// being queued from the policy of its workspace and the user queueing it,
+// who can't approve it. Only deploys and releases require approval.
+func (s *service) jobApprovalRequirement(ctx context.Context, job *pb.Job) error {
+	// These are managed by the server and never accepted from the caller.
+	job.ApprovalRequired = nil
+	job.Approvals = nil
+	job.QueuedBy = ""
+
+	if body, err := s.callerToken(ctx); err == nil &&
+		body.Runner == nil && body.Entrypoint == nil {
+		job.QueuedBy = body.User
+	}
+
+	switch job.Operation.(type) {
+	case *pb.Job_Deploy, *pb.Job_Release:
+	default:
+		return nil
+	}
+
+	p, err := s.state.WorkspacePolicyGet(job.Workspace.Workspace)
+	if err != nil {
+		return err
+	}
+	if p.RequiredApprovals == 0 {
+		return nil
+	}
+
+	job.ApprovalRequired = &pb.Job_ApprovalRequirement{
+		Approvals: p.RequiredApprovals,
+		Roles:     p.ApproverRoles,
+	}
+	return nil
+}
+
+// callerUser returns the user and roles of the caller. Approvals are bound
+// to the user of the token, which is signed by the server, so a caller
+// can't approve as anyone else. Users and their roles are only created by
+// the default user inviting them, and API keys are always for the user
+// that created them with the roles of that user, so creating more keys
+// doesn't let a caller approve a job more than once. The default user has
+// no roles unless it uses an API key with roles.
+func (s *service) callerUser(ctx context.Context) (string, []string, error) {
+	body, err := s.callerToken(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	if body.Runner != nil || body.Entrypoint != nil {
+		return "", nil, status.Errorf(codes.PermissionDenied,
+			"only user tokens can be used for this operation")
+	}
+
+	if body.ApiKeyId == "" {
+		return body.User, body.Roles, nil
+	}
+
+	key, err := s.state.APIKeyGet(body.ApiKeyId)
+	if err != nil {
+		return "", nil, err
+	}
+	if key.User != body.User {
+		return "", nil, status.Errorf(codes.Unauthenticated, ErrInvalidToken.Error())
+	}
+
+	key = proto.Clone(key).(*pb.APIKey)
+	return key.User, key.Roles, nil
+}
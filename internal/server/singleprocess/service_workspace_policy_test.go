@@ -0,0 +1,216 @@
+package singleprocess
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/server"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestServiceApproveJob(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	// Create our server
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+	TestApp(t, client, serverptypes.TestJobNew(t, nil).Application)
+
+	// Protect the workspace
+	policy, err := client.SetWorkspacePolicy(ctx, &pb.SetWorkspacePolicyRequest{
+		Policy: &pb.WorkspacePolicy{
+			Workspace:         "prod",
+			RequiredApprovals: 2,
+			ApproverRoles:     []string{"sre"},
+		},
+	})
+	require.NoError(err)
+	require.Equal(uint32(2), policy.RequiredApprovals)
+
+	policy, err = client.GetWorkspacePolicy(ctx, &pb.GetWorkspacePolicyRequest{Workspace: "prod"})
+	require.NoError(err)
+	require.Equal([]string{"sre"}, policy.ApproverRoles)
+
+	// Builds don't require approval even in a protected workspace
+	resp, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+		Job: serverptypes.TestJobNew(t, &pb.Job{
+			Workspace: &pb.Ref_Workspace{Workspace: "prod"},
+			Operation: &pb.Job_Build{Build: &pb.Job_BuildOp{}},
+		}),
+	})
+	require.NoError(err)
+	job, err := client.GetJob(ctx, &pb.GetJobRequest{JobId: resp.JobId})
+	require.NoError(err)
+	require.Nil(job.ApprovalRequired)
+
+	// Deploys do, and the requirement can't be set by the caller
+	resp, err = client.QueueJob(ctx, &pb.QueueJobRequest{
+		Job: serverptypes.TestJobNew(t, &pb.Job{
+			Workspace: &pb.Ref_Workspace{Workspace: "prod"},
+			Operation: &pb.Job_Deploy{Deploy: &pb.Job_DeployOp{}},
+			Approvals: []*pb.Job_Approval{{User: "mallory"}},
+		}),
+	})
+	require.NoError(err)
+	job, err = client.GetJob(ctx, &pb.GetJobRequest{JobId: resp.JobId})
+	require.NoError(err)
+	require.NotNil(job.ApprovalRequired)
+	require.Equal(uint32(2), job.ApprovalRequired.Approvals)
+	require.Empty(job.Approvals)
+
+	// Approving requires a token
+	_, err = client.ApproveJob(ctx, &pb.ApproveJobRequest{JobId: job.Id})
+	require.Error(err)
+	require.Equal(codes.Unauthenticated, status.Code(err))
+
+	approve := func(user string, roles ...string) (*pb.Job, error) {
+		s := impl.(*service)
+		key, err := s.CreateAPIKey(testUserContext(t, s, user, roles...), &pb.CreateAPIKeyRequest{})
+		require.NoError(err)
+
+		return client.ApproveJob(
+			metadata.AppendToOutgoingContext(ctx, "authorization", key.Token),
+			&pb.ApproveJobRequest{JobId: job.Id},
+		)
+	}
+
+	// Users without the role can't approve
+	_, err = approve("alice")
+	require.Error(err)
+	require.Equal(codes.PermissionDenied, status.Code(err))
+
+	job, err = approve("alice", "sre")
+	require.NoError(err)
+	require.Len(job.Approvals, 1)
+
+	// Another key for the same user doesn't count twice
+	_, err = approve("alice", "sre")
+	require.Error(err)
+	require.Equal(codes.AlreadyExists, status.Code(err))
+
+	job, err = approve("bob", "sre")
+	require.NoError(err)
+	require.Len(job.Approvals, 2)
+	require.Equal("bob", job.Approvals[1].User)
+
+	// One caller can't approve more than once by minting keys for other
+	// users or with other roles.
+	s := impl.(*service)
+	mallory := testUserContext(t, s, "mallory")
+	_, err = s.CreateAPIKey(mallory, &pb.CreateAPIKeyRequest{User: "bob"})
+	require.Equal(codes.PermissionDenied, status.Code(err))
+	_, err = s.CreateAPIKey(mallory, &pb.CreateAPIKeyRequest{Roles: []string{"sre"}})
+	require.Equal(codes.PermissionDenied, status.Code(err))
+	for i := 0; i < 3; i++ {
+		key, err := s.CreateAPIKey(mallory, &pb.CreateAPIKeyRequest{})
+		require.NoError(err)
+		require.Equal("mallory", key.ApiKey.User)
+
+		_, err = client.ApproveJob(
+			metadata.AppendToOutgoingContext(ctx, "authorization", key.Token),
+			&pb.ApproveJobRequest{JobId: job.Id},
+		)
+		require.Equal(codes.PermissionDenied, status.Code(err))
+	}
+
+	// Nor can the default user by creating keys with roles
+	for i := 0; i < 2; i++ {
+		key, err := s.CreateAPIKey(testUserContext(t, s, DefaultUser), &pb.CreateAPIKeyRequest{
+			Roles: []string{"sre"},
+		})
+		require.NoError(err)
+
+		job, err = client.ApproveJob(
+			metadata.AppendToOutgoingContext(ctx, "authorization", key.Token),
+			&pb.ApproveJobRequest{JobId: job.Id},
+		)
+		if i == 0 {
+			require.NoError(err)
+			require.Len(job.Approvals, 3)
+			require.Equal(DefaultUser, job.Approvals[2].User)
+		} else {
+			require.Equal(codes.AlreadyExists, status.Code(err))
+		}
+	}
+}
+
+func TestServiceApproveJob_userToken(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	impl, err := New(WithDB(testDB(t)))
+	require.NoError(err)
+	client := server.TestServer(t, impl)
+	s := impl.(*service)
+	TestApp(t, client, serverptypes.TestJobNew(t, nil).Application)
+
+	_, err = client.SetWorkspacePolicy(ctx, &pb.SetWorkspacePolicyRequest{
+		Policy: &pb.WorkspacePolicy{
+			Workspace:         "prod",
+			RequiredApprovals: 2,
+			ApproverRoles:     []string{"sre"},
+		},
+	})
+	require.NoError(err)
+	resp, err := client.QueueJob(ctx, &pb.QueueJobRequest{
+		Job: serverptypes.TestJobNew(t, &pb.Job{
+			Workspace: &pb.Ref_Workspace{Workspace: "prod"},
+			Operation: &pb.Job_Deploy{Deploy: &pb.Job_DeployOp{}},
+		}),
+	})
+	require.NoError(err)
+
+	// Users approve with the roles they were invited with
+	approve := func(token string) (*pb.Job, error) {
+		return client.ApproveJob(
+			metadata.AppendToOutgoingContext(ctx, "authorization", token),
+			&pb.ApproveJobRequest{JobId: resp.JobId},
+		)
+	}
+	alice := testUserToken(t, s, "alice", "sre")
+	job, err := approve(alice)
+	require.NoError(err)
+	require.Len(job.Approvals, 1)
+
+	// A key of the same user is the same approver
+	key, err := s.CreateAPIKey(testUserContext(t, s, "alice", "sre"), &pb.CreateAPIKeyRequest{})
+	require.NoError(err)
+	_, err = approve(key.Token)
+	require.Equal(codes.AlreadyExists, status.Code(err))
+
+	job, err = approve(testUserToken(t, s, "bob", "sre"))
+	require.NoError(err)
+	require.Len(job.Approvals, 2)
+
+	// The user that queued a job can't approve it
+	resp, err = client.QueueJob(
+		metadata.AppendToOutgoingContext(ctx, "authorization", alice),
+		&pb.QueueJobRequest{
+			Job: serverptypes.TestJobNew(t, &pb.Job{
+				Workspace: &pb.Ref_Workspace{Workspace: "prod"},
+				Operation: &pb.Job_Deploy{Deploy: &pb.Job_DeployOp{}},
+				QueuedBy:  "bob",
+			}),
+		},
+	)
+	require.NoError(err)
+	job, err = client.GetJob(ctx, &pb.GetJobRequest{JobId: resp.JobId})
+	require.NoError(err)
+	require.Equal("alice", job.QueuedBy)
+
+	_, err = approve(alice)
+	require.Error(err)
+	require.Equal(codes.PermissionDenied, status.Code(err))
+
+	job, err = approve(testUserToken(t, s, "bob", "sre"))
+	require.NoError(err)
+	require.Len(job.Approvals, 1)
+}
@@ -0,0 +1,326 @@
+package state
+
+import (
+	"sort"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hashicorp/go-memdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+var apiKeyBucket = []byte("api_keys")
+
+// apiKeyUsageFlushInterval is the minimum time between persisting the
+// usage of an API key. Usage in between is only kept in memory so that
+// authenticating a request doesn't require a write every time.
+const apiKeyUsageFlushInterval = time.Minute
+
+func init() {
+	dbBuckets = append(dbBuckets, apiKeyBucket)
+	dbIndexers = append(dbIndexers, (*State).apiKeyIndexInit)
+	schemas = append(schemas, apiKeyIndexSchema)
+}
+
+// apiKeyUsage is the usage of an API key that hasn't been persisted yet.
+type apiKeyUsage struct {
+	lastUsed time.Time
+	calls    uint64
+	flushed  time.Time
+}
+
+// APIKeyPut creates or updates an API key. The usage of the key is managed
+// by the state store and any usage set on the given key is ignored.
+func (s *State) APIKeyPut(key *pb.APIKey) error {
+	memTxn := s.inmemWriteTxn()
+	defer memTxn.Abort()
+
+	err := s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		b := dbTxn.Bucket(apiKeyBucket)
+
+		key = proto.Clone(key).(*pb.APIKey)
+		key.Usage = nil
+
+		// Keep the usage we have stored if this key exists already.
+		var existing pb.APIKey
+		err := dbGet(b, []byte(key.Id), &existing)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			key.Usage = existing.Usage
+		}
+
+		if err := dbPut(b, []byte(key.Id), key); err != nil {
+			return err
+		}
+
+		return s.apiKeyIndexSet(memTxn, key)
+	})
+	if err == nil {
+		memTxn.Commit()
+	}
+
+	return err
+}
+
+// APIKeyGet gets an API key by ID, including its usage. This will return
+// a NotFound error if the key doesn't exist.
+func (s *State) APIKeyGet(id string) (*pb.APIKey, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	raw, err := memTxn.First(apiKeyIndexTableName, apiKeyIndexIdIndexName, id)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, status.Errorf(codes.NotFound, "API key not found: %s", id)
+	}
+
+	return s.apiKeyWithUsage(raw.(*apiKeyIndexRecord).Key), nil
+}
+
+// APIKeyList lists the API keys, including their usage, sorted by creation
+// time. If user is not empty, only the keys for that user are returned.
+func (s *State) APIKeyList(user string) ([]*pb.APIKey, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	var iter memdb.ResultIterator
+	var err error
+	if user == "" {
+		iter, err = memTxn.Get(apiKeyIndexTableName, apiKeyIndexIdIndexName)
+	} else {
+		iter, err = memTxn.Get(apiKeyIndexTableName, apiKeyIndexUserIndexName, user)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*pb.APIKey
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		result = append(result, s.apiKeyWithUsage(raw.(*apiKeyIndexRecord).Key))
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		ti, tj := result[i].CreatedAt, result[j].CreatedAt
+		if ti == nil || tj == nil {
+			return tj != nil
+		}
+
+		if ti.Seconds != tj.Seconds {
+			return ti.Seconds < tj.Seconds
+		}
+
+		return ti.Nanos < tj.Nanos
+	})
+
+	return result, nil
+}
+
+// APIKeyDelete deletes an API key. This returns a NotFound error if the
+// key doesn't exist.
+func (s *State) APIKeyDelete(id string) error {
+	memTxn := s.inmemWriteTxn()
+	defer memTxn.Abort()
+
+	err := s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		b := dbTxn.Bucket(apiKeyBucket)
+		if b.Get([]byte(id)) == nil {
+			return status.Errorf(codes.NotFound, "API key not found: %s", id)
+		}
+
+		if err := dbDelete(b, []byte(id)); err != nil {
+			return err
+		}
+
+		_, err := memTxn.DeleteAll(apiKeyIndexTableName, apiKeyIndexIdIndexName, id)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	memTxn.Commit()
+
+	s.apiKeyUsageLock.Lock()
+	delete(s.apiKeyUsage, id)
+	s.apiKeyUsageLock.Unlock()
+
+	return nil
+}
+
+// APIKeyUsed records that the API key was used to authenticate a request.
+// Usage is persisted at most once every apiKeyUsageFlushInterval per key.
+func (s *State) APIKeyUsed(id string) error {
+	now := time.Now()
+
+	s.apiKeyUsageLock.Lock()
+	if s.apiKeyUsage == nil {
+		s.apiKeyUsage = make(map[string]*apiKeyUsage)
+	}
+	u, ok := s.apiKeyUsage[id]
+	if !ok {
+		u = &apiKeyUsage{}
+		s.apiKeyUsage[id] = u
+	}
+	u.lastUsed = now
+	u.calls++
+
+	// If we persisted recently, we're done.
+	if now.Sub(u.flushed) < apiKeyUsageFlushInterval {
+		s.apiKeyUsageLock.Unlock()
+		return nil
+	}
+	calls := u.calls
+	u.calls = 0
+	u.flushed = now
+	s.apiKeyUsageLock.Unlock()
+
+	err := s.apiKeyUsageFlush(id, now, calls)
+	if err != nil {
+		// Put the calls back so they're persisted on the next flush.
+		s.apiKeyUsageLock.Lock()
+		if u, ok := s.apiKeyUsage[id]; ok {
+			u.calls += calls
+			u.flushed = time.Time{}
+		}
+		s.apiKeyUsageLock.Unlock()
+	}
+
+	return err
+}
+
+// apiKeyUsageFlush adds the given usage to the persisted usage of the key.
+func (s *State) apiKeyUsageFlush(id string, lastUsed time.Time, calls uint64) error {
+	ts, err := ptypes.TimestampProto(lastUsed)
+	if err != nil {
+		return err
+	}
+
+	memTxn := s.inmemWriteTxn()
+	defer memTxn.Abort()
+
+	err = s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		b := dbTxn.Bucket(apiKeyBucket)
+
+		var key pb.APIKey
+		if err := dbGet(b, []byte(id), &key); err != nil {
+			// If the key was deleted there is nothing to record.
+			if status.Code(err) == codes.NotFound {
+				return nil
+			}
+
+			return err
+		}
+
+		if key.Usage == nil {
+			key.Usage = &pb.APIKey_Usage{}
+		}
+		key.Usage.LastUsedAt = ts
+		key.Usage.CallCount += calls
+
+		if err := dbPut(b, []byte(id), &key); err != nil {
+			return err
+		}
+
+		return s.apiKeyIndexSet(memTxn, &key)
+	})
+	if err == nil {
+		memTxn.Commit()
+	}
+
+	return err
+}
+
+// apiKeyWithUsage returns the key with any usage that hasn't been
+// persisted yet. The key must not be modified since it is owned by memdb,
+// so this returns a copy if there is any usage to add.
+func (s *State) apiKeyWithUsage(key *pb.APIKey) *pb.APIKey {
+	s.apiKeyUsageLock.Lock()
+	defer s.apiKeyUsageLock.Unlock()
+
+	u, ok := s.apiKeyUsage[key.Id]
+	if !ok || u.calls == 0 {
+		return key
+	}
+
+	ts, err := ptypes.TimestampProto(u.lastUsed)
+	if err != nil {
+		return key
+	}
+
+	key = proto.Clone(key).(*pb.APIKey)
+	if key.Usage == nil {
+		key.Usage = &pb.APIKey_Usage{}
+	}
+	key.Usage.LastUsedAt = ts
+	key.Usage.CallCount += u.calls
+	return key
+}
+
+// apiKeyIndexSet writes an index record for a single API key.
+func (s *State) apiKeyIndexSet(txn *memdb.Txn, value *pb.APIKey) error {
+	return txn.Insert(apiKeyIndexTableName, &apiKeyIndexRecord{
+		Id:   value.Id,
+		User: value.User,
+		Key:  value,
+	})
+}
+
+// apiKeyIndexInit initializes the API key index from persisted data.
+func (s *State) apiKeyIndexInit(dbTxn *bolt.Tx, memTxn *memdb.Txn) error {
+	bucket := dbTxn.Bucket(apiKeyBucket)
+	return bucket.ForEach(func(k, v []byte) error {
+		var value pb.APIKey
+		if err := proto.Unmarshal(v, &value); err != nil {
+			return err
+		}
+
+		return s.apiKeyIndexSet(memTxn, &value)
+	})
+}
+
+func apiKeyIndexSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: apiKeyIndexTableName,
+		Indexes: map[string]*memdb.IndexSchema{
+			apiKeyIndexIdIndexName: {
+				Name:         apiKeyIndexIdIndexName,
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "Id",
+					Lowercase: true,
+				},
+			},
+
+			apiKeyIndexUserIndexName: {
+				Name:         apiKeyIndexUserIndexName,
+				AllowMissing: true,
+				Unique:       false,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "User",
+					Lowercase: true,
+				},
+			},
+		},
+	}
+}
+
+const (
+	apiKeyIndexTableName     = "api-key-index"
+	apiKeyIndexIdIndexName   = "id"
+	apiKeyIndexUserIndexName = "user"
+)
+
+type apiKeyIndexRecord struct {
+	Id   string
+	User string
+	Key  *pb.APIKey
+}
@@ -0,0 +1,97 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func TestAPIKey(t *testing.T) {
+	t.Run("Put, Get, List, and Delete", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		// Get non-existent
+		_, err := s.APIKeyGet("a")
+		require.Error(err)
+		require.Equal(codes.NotFound, status.Code(err))
+
+		// Create
+		require.NoError(s.APIKeyPut(&pb.APIKey{Id: "a", User: "alice", CreatedAt: ptypes.TimestampNow()}))
+		require.NoError(s.APIKeyPut(&pb.APIKey{Id: "b", User: "bob", CreatedAt: ptypes.TimestampNow()}))
+
+		{
+			resp, err := s.APIKeyGet("a")
+			require.NoError(err)
+			require.Equal("alice", resp.User)
+		}
+
+		// List all
+		{
+			resp, err := s.APIKeyList("")
+			require.NoError(err)
+			require.Len(resp, 2)
+			require.Equal("a", resp[0].Id)
+		}
+
+		// List by user
+		{
+			resp, err := s.APIKeyList("bob")
+			require.NoError(err)
+			require.Len(resp, 1)
+			require.Equal("b", resp[0].Id)
+		}
+
+		// Delete
+		require.NoError(s.APIKeyDelete("a"))
+		_, err = s.APIKeyGet("a")
+		require.Error(err)
+		require.Equal(codes.NotFound, status.Code(err))
+
+		err = s.APIKeyDelete("a")
+		require.Error(err)
+		require.Equal(codes.NotFound, status.Code(err))
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.APIKeyPut(&pb.APIKey{Id: "a"}))
+
+		// The first use is persisted immediately, the rest are buffered.
+		for i := 0; i < 3; i++ {
+			require.NoError(s.APIKeyUsed("a"))
+		}
+
+		resp, err := s.APIKeyGet("a")
+		require.NoError(err)
+		require.NotNil(resp.Usage)
+		require.NotNil(resp.Usage.LastUsedAt)
+		require.Equal(uint64(3), resp.Usage.CallCount)
+
+		// Updating the key should not reset the persisted usage
+		require.NoError(s.APIKeyPut(&pb.APIKey{Id: "a", Name: "updated"}))
+		resp, err = s.APIKeyGet("a")
+		require.NoError(err)
+		require.Equal("updated", resp.Name)
+		require.Equal(uint64(3), resp.Usage.CallCount)
+
+		// Reload the state and only the persisted usage should remain.
+		s = TestStateReinit(t, s)
+		defer s.Close()
+
+		resp, err = s.APIKeyGet("a")
+		require.NoError(err)
+		require.Equal(uint64(1), resp.Usage.CallCount)
+	})
+}
@@ -89,12 +89,21 @@ func (op *appOperation) register() {
 	schemas = append(schemas, op.memSchema)
 }
 
+// registerEvents publishes events with the given topic for changes to
+// this operation. See event.go.
+func (op *appOperation) registerEvents(topic EventTopic) {
+	eventTables[op.memTableName()] = func(obj interface{}) (EventTopic, string, string) {
+		rec := obj.(*operationIndexRecord)
+		return topic, rec.Id, rec.Project
+	}
+}
+
 // Put inserts or updates an operation record.
 func (op *appOperation) Put(s *State, update bool, value proto.Message) error {
-	memTxn := s.inmem.Txn(true)
+	memTxn := s.inmemWriteTxn()
 	defer memTxn.Abort()
 
-	err := s.db.Update(func(dbTxn *bolt.Tx) error {
+	err := s.dbUpdate(func(dbTxn *bolt.Tx) error {
 		return op.dbPut(s, dbTxn, memTxn, update, value)
 	})
 	if err == nil {
@@ -110,7 +119,7 @@ func (op *appOperation) Get(s *State, ref *pb.Ref_Operation) (interface{}, error
 	defer memTxn.Abort()
 
 	result := op.newStruct()
-	err := s.db.View(func(tx *bolt.Tx) error {
+	err := s.dbView(func(tx *bolt.Tx) error {
 		var id string
 		switch t := ref.Target.(type) {
 		case *pb.Ref_Operation_Id:
@@ -196,7 +205,7 @@ func (op *appOperation) List(s *State, opts *listOperationsOptions) ([]interface
 	}
 
 	var result []interface{}
-	s.db.View(func(tx *bolt.Tx) error {
+	s.dbView(func(tx *bolt.Tx) error {
 		for {
 			current := iter.Next()
 			if current == nil {
@@ -406,6 +415,43 @@ func (op *appOperation) dbPut(
 	return op.indexPut(s, memTxn, value)
 }
 
+// dbImport writes an operation imported from another server. Unlike
+// dbPut, the sequence number of the value is kept and the application is
+// expected to already exist.
+func (op *appOperation) dbImport(
+	s *State,
+	dbTxn *bolt.Tx,
+	memTxn *memdb.Txn,
+	value proto.Message,
+) error {
+	appRef := op.valueField(value, "Application").(*pb.Ref_Application)
+	if appRef == nil {
+		return status.Errorf(codes.Internal, "state: Application must be set on value %T", value)
+	}
+	if wsRef := op.valueField(value, "Workspace").(*pb.Ref_Workspace); wsRef == nil {
+		return status.Errorf(codes.Internal, "state: Workspace must be set on value %T", value)
+	}
+
+	// Ensure that new operations are sequenced after the imported ones.
+	if f := op.valueFieldReflect(value, "Sequence"); f.IsValid() {
+		seq := f.Interface().(uint64)
+		if ptr := op.appSeq(appRef); seq > atomic.LoadUint64(ptr) {
+			atomic.StoreUint64(ptr, seq)
+		}
+	}
+
+	if f := op.valueFieldReflect(value, "Preload"); f.IsValid() {
+		f.Set(reflect.New(f.Type().Elem()))
+	}
+
+	id := []byte(op.valueField(value, "Id").(string))
+	if err := dbPut(dbTxn.Bucket(op.Bucket), id, value); err != nil {
+		return err
+	}
+
+	return op.indexPut(s, memTxn, value)
+}
+
 // appSeq gets the pointer to the sequence number for the given application.
 // This can only safely be called while holding the memdb write transaction.
 func (op *appOperation) appSeq(ref *pb.Ref_Application) *uint64 {
@@ -12,10 +12,10 @@ import (
 
 // AppPut creates or updates the application.
 func (s *State) AppPut(app *pb.Application) (*pb.Application, error) {
-	memTxn := s.inmem.Txn(true)
+	memTxn := s.inmemWriteTxn()
 	defer memTxn.Abort()
 
-	err := s.db.Update(func(dbTxn *bolt.Tx) error {
+	err := s.dbUpdate(func(dbTxn *bolt.Tx) error {
 		return s.appPut(dbTxn, memTxn, app)
 	})
 	if err == nil {
@@ -28,10 +28,10 @@ func (s *State) AppPut(app *pb.Application) (*pb.Application, error) {
 // AppDelete deletes an application from a project. This will also delete
 // all the operations associated with this application.
 func (s *State) AppDelete(ref *pb.Ref_Application) error {
-	memTxn := s.inmem.Txn(true)
+	memTxn := s.inmemWriteTxn()
 	defer memTxn.Abort()
 
-	err := s.db.Update(func(dbTxn *bolt.Tx) error {
+	err := s.dbUpdate(func(dbTxn *bolt.Tx) error {
 		return s.appDelete(dbTxn, memTxn, ref)
 	})
 	if err == nil {
@@ -47,7 +47,7 @@ func (s *State) AppGet(ref *pb.Ref_Application) (*pb.Application, error) {
 	defer memTxn.Abort()
 
 	var result *pb.Application
-	err := s.db.View(func(dbTxn *bolt.Tx) error {
+	err := s.dbView(func(dbTxn *bolt.Tx) error {
 		var err error
 		result, err = s.appGet(dbTxn, memTxn, ref)
 		return err
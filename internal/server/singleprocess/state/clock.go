@@ -0,0 +1,46 @@
+package state
+
+import (
+	"time"
+)
+
+// Clock is the source of time for the state. The timeouts and expiries of
+// jobs are measured with it and its tickers drive the timer wheel, so
+// tests can use a TestClock to trigger them without waiting. The default
+// is the system clock.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTicker returns a ticker that sends the time on its channel every
+	// d. Ticks are dropped if the receiver falls behind.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker is a ticker created by a Clock. It mimics time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Option configures a State created with New or NewRaft.
+type Option func(*State)
+
+// WithClock sets the clock of the state.
+func WithClock(c Clock) Option {
+	return func(s *State) { s.clock = c }
+}
+
+// systemClock is the Clock of the system time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTicker struct{ t *time.Ticker }
+
+func (t systemTicker) C() <-chan time.Time { return t.t.C }
+func (t systemTicker) Stop()               { t.t.Stop() }
@@ -0,0 +1,118 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestStateClock(t *testing.T) {
+	ctx := context.Background()
+
+	// state returns the current state of the job
+	state := func(t *testing.T, s *State, id string) func() pb.Job_State {
+		return func() pb.Job_State {
+			job, err := s.JobById(id, nil)
+			require.NoError(t, err)
+			return job.State
+		}
+	}
+
+	t.Run("ack timeout", func(t *testing.T) {
+		require := require.New(t)
+
+		clock := TestClockNew()
+		s := TestState(t, WithClock(clock))
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		_, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+
+		clock.Advance(time.Minute)
+		require.Equal(pb.Job_WAITING, state(t, s, "A")())
+
+		// Past the default ack timeout it is queued again
+		clock.Advance(time.Minute)
+		require.Eventually(func() bool {
+			return state(t, s, "A")() == pb.Job_QUEUED
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("heartbeat timeout", func(t *testing.T) {
+		require := require.New(t)
+
+		clock := TestClockNew()
+		s := TestState(t, WithClock(clock))
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		_, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		_, err = s.JobAck(context.Background(), "A", true)
+		require.NoError(err)
+
+		// A heartbeat keeps it running
+		clock.Advance(time.Minute)
+		require.NoError(s.JobHeartbeat("A"))
+		clock.Advance(time.Minute)
+		require.Equal(pb.Job_RUNNING, state(t, s, "A")())
+
+		clock.Advance(time.Minute)
+		require.Eventually(func() bool {
+			return state(t, s, "A")() == pb.Job_ERROR
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		require := require.New(t)
+
+		clock := TestClockNew()
+		s := TestState(t, WithClock(clock))
+		defer s.Close()
+
+		ts, err := ptypes.TimestampProto(clock.Now().Add(time.Hour))
+		require.NoError(err)
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:         "A",
+			ExpireTime: ts,
+		})))
+
+		clock.Advance(30 * time.Minute)
+		require.Equal(pb.Job_QUEUED, state(t, s, "A")())
+
+		clock.Advance(30 * time.Minute)
+		require.Eventually(func() bool {
+			return state(t, s, "A")() == pb.Job_ERROR
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("end time", func(t *testing.T) {
+		require := require.New(t)
+
+		clock := TestClockNew()
+		s := TestState(t, WithClock(clock))
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		_, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		_, err = s.JobAck(ctx, "A", true)
+		require.NoError(err)
+
+		clock.Advance(time.Minute)
+		require.NoError(s.JobComplete(ctx, "A", nil, nil))
+
+		txn := s.inmem.Txn(false)
+		defer txn.Abort()
+		raw, err := txn.First(jobTableName, jobIdIndexName, "A")
+		require.NoError(err)
+		require.Equal(clock.Now(), raw.(*jobIndex).EndTime)
+	})
+}
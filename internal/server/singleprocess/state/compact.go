@@ -0,0 +1,213 @@
+package state
+
+import (
+	"os"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/natefinch/atomic"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// This file has the methods related to compacting the database. bolt
+// reuses the pages freed by deletes but never shrinks its file, so after
+// a lot of churn the file can be much larger than the data in it. The
+// only way to reclaim the space is to copy the data into a new file and
+// replace the old file with it.
+//
+// The database is replaced while the server is running so every access
+// to s.db must hold dbLock for reading. Compact holds it for writing.
+
+// compactTxMaxSize is the size of the data copied in a single transaction
+// when compacting so the copy doesn't have to fit in memory.
+const compactTxMaxSize = 64 * 1024 * 1024 // 64 MB
+
+// DBStats is the size of the database file and the space in it that is
+// free to be reused or reclaimed by compacting.
+type DBStats struct {
+	Size int64
+	Free int64
+}
+
+// DBStats returns the size of the database file and how much of it is
+// free.
+func (s *State) DBStats() (*DBStats, error) {
+	var result DBStats
+	err := s.dbView(func(dbTxn *bolt.Tx) error {
+		db := dbTxn.DB()
+		result.Size = dbTxn.Size()
+		result.Free = int64(db.Stats().FreePageN) * int64(db.Info().PageSize)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Compact rewrites the database into a new file without the free space
+// and replaces the database with it. Reads and writes wait until this is
+// complete. This returns the stats of the database before and after.
+func (s *State) Compact() (*DBStats, *DBStats, error) {
+	// With raft, we hold the raft lock first so no writes are being
+	// applied while we replace the database.
+	if s.raft != nil {
+		s.raft.lock.Lock()
+		defer s.raft.lock.Unlock()
+	}
+
+	before, err := s.DBStats()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.dbLock.Lock()
+	defer s.dbLock.Unlock()
+
+	log := s.log.Named("compact")
+	path := s.db.Path()
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Copy all the data into a new file next to the database.
+	tempPath := path + ".compact"
+	if err := os.Remove(tempPath); err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+	defer os.Remove(tempPath)
+
+	log.Info("copying the database to compact it", "path", tempPath, "size", before.Size)
+	tempDb, err := bolt.Open(tempPath, fi.Mode(), &bolt.Options{Timeout: 2 * time.Second})
+	if err != nil {
+		return nil, nil, err
+	}
+	err = compactCopy(tempDb, s.db)
+	if cerr := tempDb.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		log.Error("error copying the database", "err", err)
+		return nil, nil, status.Errorf(codes.Internal,
+			"failed to copy the database: %s", err)
+	}
+
+	// Replace our database with the copy. If anything fails after we
+	// close the database we try to reopen whichever file is in place so
+	// that the server keeps working.
+	old := s.db
+	if err := old.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	log.Info("atomically replacing db file", "src", tempPath, "dest", path)
+	replaceErr := atomic.ReplaceFile(tempPath, path)
+	if replaceErr != nil {
+		log.Error("error replacing file", "err", replaceErr)
+	}
+
+	db, err := bolt.Open(path, fi.Mode(), &bolt.Options{Timeout: 2 * time.Second})
+	if err != nil {
+		log.Error("error reopening db", "err", err)
+		return nil, nil, err
+	}
+	s.db = db
+
+	// Writes are recorded by database so we move the recorder over.
+	if s.raft != nil {
+		raftRecorders.Delete(old)
+		raftRecorders.Store(db, s.raft.recorder)
+	}
+
+	if replaceErr != nil {
+		return nil, nil, status.Errorf(codes.Internal,
+			"failed to replace the database: %s", replaceErr)
+	}
+
+	after := &DBStats{Size: fi.Size()}
+	if fi, err := os.Stat(path); err == nil {
+		after.Size = fi.Size()
+	}
+	after.Free = int64(db.Stats().FreePageN) * int64(db.Info().PageSize)
+
+	log.Info("database compacted", "before", before.Size, "after", after.Size)
+	return before, after, nil
+}
+
+// compactCopy copies all the buckets of src into dst. The data is written
+// in transactions of at most compactTxMaxSize.
+func compactCopy(dst, src *bolt.DB) error {
+	dstTxn, err := dst.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer func() { dstTxn.Rollback() }()
+
+	var size int
+	err = src.View(func(srcTxn *bolt.Tx) error {
+		return srcTxn.ForEach(func(name []byte, b *bolt.Bucket) error {
+			return compactCopyBucket(b, [][]byte{name}, func(path [][]byte, k, v []byte) error {
+				// Commit and start a new transaction if this one is full.
+				if size+len(k)+len(v) > compactTxMaxSize {
+					if err := dstTxn.Commit(); err != nil {
+						return err
+					}
+
+					var err error
+					if dstTxn, err = dst.Begin(true); err != nil {
+						return err
+					}
+					size = 0
+				}
+				size += len(k) + len(v)
+
+				// Open the bucket at the path, creating it if it doesn't
+				// exist. A nil value is a nested bucket.
+				var parent *bolt.Bucket
+				create := dstTxn.CreateBucketIfNotExists
+				for _, name := range path {
+					var err error
+					if parent, err = create(name); err != nil {
+						return err
+					}
+					create = parent.CreateBucketIfNotExists
+				}
+				if v == nil {
+					_, err := create(k)
+					return err
+				}
+
+				return parent.Put(k, v)
+			})
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return dstTxn.Commit()
+}
+
+// compactCopyBucket calls fn for every key in the bucket at path and its
+// nested buckets. The value is nil for nested buckets.
+func compactCopyBucket(
+	b *bolt.Bucket,
+	path [][]byte,
+	fn func(path [][]byte, k, v []byte) error,
+) error {
+	// Create the bucket itself so empty buckets are copied too.
+	if err := fn(path[:len(path)-1], path[len(path)-1], nil); err != nil {
+		return err
+	}
+
+	return b.ForEach(func(k, v []byte) error {
+		if v != nil {
+			return fn(path, k, v)
+		}
+
+		return compactCopyBucket(b.Bucket(k), append(path[:len(path):len(path)], k), fn)
+	})
+}
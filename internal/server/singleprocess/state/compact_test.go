@@ -0,0 +1,64 @@
+package state
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestCompact(t *testing.T) {
+	require := require.New(t)
+
+	s := TestState(t)
+	defer s.Close()
+
+	// Write a lot of data and delete most of it
+	name := func(i int) string {
+		return fmt.Sprintf("p%d-%s", i, strings.Repeat("x", 4096))
+	}
+	for i := 0; i < 500; i++ {
+		require.NoError(s.ProjectPut(serverptypes.TestProject(t, &pb.Project{
+			Name: name(i),
+		})))
+	}
+	for i := 1; i < 500; i++ {
+		require.NoError(s.ProjectDelete(&pb.Ref_Project{Project: name(i)}))
+	}
+
+	stats, err := s.DBStats()
+	require.NoError(err)
+	require.True(stats.Free > 0)
+
+	// Compact
+	before, after, err := s.Compact()
+	require.NoError(err)
+	require.True(after.Size < before.Size, "%d < %d", after.Size, before.Size)
+
+	// The remaining data is there
+	_, err = s.ProjectGet(&pb.Ref_Project{Project: name(0)})
+	require.NoError(err)
+	_, err = s.ProjectGet(&pb.Ref_Project{Project: name(1)})
+	require.Error(err)
+	require.Equal(codes.NotFound, status.Code(err))
+
+	// Writes still work
+	require.NoError(s.ProjectPut(serverptypes.TestProject(t, &pb.Project{
+		Name: "A",
+	})))
+
+	// And everything is there after a restart
+	s2, err := TestStateRestart(t, s)
+	require.NoError(err)
+	defer s2.Close()
+	_, err = s2.ProjectGet(&pb.Ref_Project{Project: name(0)})
+	require.NoError(err)
+	_, err = s2.ProjectGet(&pb.Ref_Project{Project: "A"})
+	require.NoError(err)
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/boltdb/bolt"
 	"github.com/golang/protobuf/proto"
 	"github.com/hashicorp/go-memdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
 	serversort "github.com/hashicorp/waypoint/internal/server/sort"
@@ -22,23 +24,34 @@ func init() {
 
 // ConfigSet writes a configuration variable to the data store.
 func (s *State) ConfigSet(vs ...*pb.ConfigVar) error {
-	memTxn := s.inmem.Txn(true)
+	memTxn := s.inmemWriteTxn()
 	defer memTxn.Abort()
 
-	err := s.db.Update(func(dbTxn *bolt.Tx) error {
+	sensitive := false
+	err := s.dbUpdate(func(dbTxn *bolt.Tx) error {
 		for _, v := range vs {
 			if err := s.configSet(dbTxn, memTxn, v); err != nil {
 				return err
 			}
+
+			sensitive = sensitive || v.Sensitive
 		}
 
 		return nil
 	})
-	if err == nil {
-		memTxn.Commit()
+	if err != nil {
+		return err
+	}
+	memTxn.Commit()
+
+	// Update the redacted values. We refresh on any change to a variable
+	// that may have been sensitive since unsetting a variable doesn't
+	// include the sensitive flag.
+	if sensitive || !s.redactor.Empty() {
+		return s.redactRefresh()
 	}
 
-	return err
+	return nil
 }
 
 // ConfigGet gets all the configuration for the given request.
@@ -48,18 +61,51 @@ func (s *State) ConfigGet(req *pb.ConfigGetRequest) ([]*pb.ConfigVar, error) {
 
 // ConfigGetWatch gets all the configuration for the given request. If a non-nil
 // WatchSet is given, this can be watched for potential changes in the config.
+//
+// Sensitive variables are returned with an empty value.
 func (s *State) ConfigGetWatch(req *pb.ConfigGetRequest, ws memdb.WatchSet) ([]*pb.ConfigVar, error) {
+	return s.configGetWatch(req, ws, false)
+}
+
+// ConfigGetWatchDecrypted is the same as ConfigGetWatch but sensitive
+// variables are decrypted. This must only be used to deliver config to
+// entrypoints and runners, never to return config to users.
+func (s *State) ConfigGetWatchDecrypted(req *pb.ConfigGetRequest, ws memdb.WatchSet) ([]*pb.ConfigVar, error) {
+	return s.configGetWatch(req, ws, true)
+}
+
+func (s *State) configGetWatch(
+	req *pb.ConfigGetRequest,
+	ws memdb.WatchSet,
+	decrypt bool,
+) ([]*pb.ConfigVar, error) {
 	memTxn := s.inmem.Txn(false)
 	defer memTxn.Abort()
 
 	var result []*pb.ConfigVar
-	err := s.db.View(func(dbTxn *bolt.Tx) error {
+	err := s.dbView(func(dbTxn *bolt.Tx) error {
 		var err error
 		result, err = s.configGetMerged(dbTxn, memTxn, ws, req)
 		return err
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The encrypted value never leaves the state store. If we're
+	// decrypting, we replace the value with the plaintext.
+	for _, v := range result {
+		if v.Sensitive && decrypt {
+			v.Value, err = s.configDecrypt(v.EncryptedValue)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		v.EncryptedValue = nil
+	}
 
-	return result, err
+	return result, nil
 }
 
 func (s *State) configSet(
@@ -67,12 +113,39 @@ func (s *State) configSet(
 	memTxn *memdb.Txn,
 	value *pb.ConfigVar,
 ) error {
+	// Config is only delivered to runners by ID or to all runners.
+	if scope, ok := value.Scope.(*pb.ConfigVar_Runner); ok {
+		switch scope.Runner.Target.(type) {
+		case *pb.Ref_Runner_Labels:
+			return status.Errorf(codes.InvalidArgument,
+				"config variable %q can't target runners by label", value.Name)
+
+		case *pb.Ref_Runner_Pool:
+			return status.Errorf(codes.InvalidArgument,
+				"config variable %q can't target runners by pool", value.Name)
+		}
+	}
+
 	id := s.configVarId(value)
 
+	// Sensitive values are only stored encrypted. The encrypted value is
+	// never accepted from the caller.
+	value = proto.Clone(value).(*pb.ConfigVar)
+	value.EncryptedValue = nil
+	if value.Sensitive && value.Value != "" {
+		ciphertext, err := s.configEncrypt(value.Value)
+		if err != nil {
+			return err
+		}
+
+		value.Value = ""
+		value.EncryptedValue = ciphertext
+	}
+
 	// Get the global bucket and write the value to it.
 	b := dbTxn.Bucket(configBucket)
-	if value.Value == "" {
-		if err := b.Delete(id); err != nil {
+	if configVarEmpty(value) {
+		if err := dbDelete(b, id); err != nil {
 			return err
 		}
 	} else {
@@ -306,7 +379,7 @@ func (s *State) configIndexSet(txn *memdb.Txn, id []byte, value *pb.ConfigVar) e
 	}
 
 	// If we have no value, we delete from the memdb index
-	if value.Value == "" {
+	if configVarEmpty(value) {
 		return txn.Delete(configIndexTableName, record)
 	}
 
@@ -330,6 +403,12 @@ func (s *State) configIndexInit(dbTxn *bolt.Tx, memTxn *memdb.Txn) error {
 	})
 }
 
+// configVarEmpty returns true if the variable has no value, either
+// plaintext or encrypted. Setting an empty variable deletes it.
+func configVarEmpty(v *pb.ConfigVar) bool {
+	return v.Value == "" && len(v.EncryptedValue) == 0
+}
+
 func (s *State) configVarId(v *pb.ConfigVar) []byte {
 	switch scope := v.Scope.(type) {
 	case *pb.ConfigVar_Application:
@@ -0,0 +1,136 @@
+package state
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+
+	"github.com/boltdb/bolt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint/internal/server/keywrap"
+)
+
+var (
+	configKeyBucket = []byte("config_key")
+	configKeyId     = []byte("data")
+)
+
+func init() {
+	dbBuckets = append(dbBuckets, configKeyBucket)
+}
+
+// ConfigEncryptionSet sets the wrapper used to protect the key that
+// encrypts sensitive config variables. The first time this is called a new
+// random data key is generated, wrapped, and persisted. Later calls unwrap
+// the persisted key, so the same wrapper configuration must be used.
+//
+// Until this is called, setting or delivering sensitive config variables
+// fails.
+func (s *State) ConfigEncryptionSet(ctx context.Context, w keywrap.Wrapper) error {
+	var wrapped []byte
+	err := s.dbView(func(dbTxn *bolt.Tx) error {
+		if v := dbTxn.Bucket(configKeyBucket).Get(configKeyId); v != nil {
+			wrapped = append([]byte{}, v...)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var key []byte
+	if wrapped == nil {
+		// No key yet, generate a new one and persist the wrapped value.
+		key = make([]byte, 32)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return err
+		}
+
+		wrapped, err = w.Wrap(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		err = s.dbUpdate(func(dbTxn *bolt.Tx) error {
+			return dbPutRaw(dbTxn.Bucket(configKeyBucket), configKeyId, wrapped)
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		key, err = w.Unwrap(ctx, wrapped)
+		if err != nil {
+			return err
+		}
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+
+	s.configKeyLock.Lock()
+	s.configKey = aead
+	s.configKeyLock.Unlock()
+
+	// Now that sensitive values can be decrypted, register them for
+	// redaction.
+	return s.redactRefresh()
+}
+
+// configEncrypt encrypts a sensitive config value.
+func (s *State) configEncrypt(value string) ([]byte, error) {
+	aead, err := s.configAEAD()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, []byte(value), nil), nil
+}
+
+// configDecrypt decrypts a value encrypted with configEncrypt.
+func (s *State) configDecrypt(ciphertext []byte) (string, error) {
+	aead, err := s.configAEAD()
+	if err != nil {
+		return "", err
+	}
+
+	size := aead.NonceSize()
+	if len(ciphertext) < size {
+		return "", status.Errorf(codes.DataLoss, "encrypted config value is corrupt")
+	}
+
+	plaintext, err := aead.Open(nil, ciphertext[:size], ciphertext[size:], nil)
+	if err != nil {
+		return "", status.Errorf(codes.DataLoss,
+			"failed to decrypt sensitive config value: %s", err)
+	}
+
+	return string(plaintext), nil
+}
+
+func (s *State) configAEAD() (cipher.AEAD, error) {
+	s.configKeyLock.RLock()
+	defer s.configKeyLock.RUnlock()
+	if s.configKey == nil {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"sensitive config variables require config encryption to be "+
+				"configured on the server")
+	}
+
+	return s.configKey, nil
+}
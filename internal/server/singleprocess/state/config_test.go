@@ -1,13 +1,18 @@
 package state
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"github.com/boltdb/bolt"
 	"github.com/hashicorp/go-memdb"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/keywrap"
 )
 
 func TestConfig(t *testing.T) {
@@ -201,6 +206,30 @@ func TestConfig(t *testing.T) {
 		}
 	})
 
+	t.Run("runner configs by label are rejected", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		err := s.ConfigSet(&pb.ConfigVar{
+			Scope: &pb.ConfigVar_Runner{
+				Runner: &pb.Ref_Runner{
+					Target: &pb.Ref_Runner_Labels{
+						Labels: &pb.Ref_RunnerLabels{
+							Labels: map[string]string{"gpu": "true"},
+						},
+					},
+				},
+			},
+
+			Name:  "foo",
+			Value: "bar",
+		})
+		require.Error(err)
+		require.Equal(codes.InvalidArgument, status.Code(err))
+	})
+
 	t.Run("runner configs any", func(t *testing.T) {
 		require := require.New(t)
 
@@ -423,3 +452,102 @@ func TestConfigWatch(t *testing.T) {
 		require.False(ws.Watch(time.After(100 * time.Millisecond)))
 	})
 }
+
+func TestConfigSensitive(t *testing.T) {
+	sensitiveVar := func() *pb.ConfigVar {
+		return &pb.ConfigVar{
+			Scope: &pb.ConfigVar_Project{
+				Project: &pb.Ref_Project{Project: "foo"},
+			},
+
+			Name:      "password",
+			Value:     "hunter2",
+			Sensitive: true,
+		}
+	}
+
+	req := &pb.ConfigGetRequest{
+		Scope: &pb.ConfigGetRequest_Project{
+			Project: &pb.Ref_Project{Project: "foo"},
+		},
+	}
+
+	t.Run("requires encryption", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		err := s.ConfigSet(sensitiveVar())
+		require.Error(err)
+		require.Equal(codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("encrypted and redacted", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		require.NoError(s.ConfigEncryptionSet(
+			context.Background(), keywrap.TestWrapper(t)))
+		require.NoError(s.ConfigSet(sensitiveVar()))
+
+		// The plaintext should not be on disk
+		require.NoError(s.db.View(func(tx *bolt.Tx) error {
+			return tx.Bucket(configBucket).ForEach(func(k, v []byte) error {
+				require.NotContains(string(v), "hunter2")
+				return nil
+			})
+		}))
+
+		// Reads are redacted
+		vs, err := s.ConfigGet(req)
+		require.NoError(err)
+		require.Len(vs, 1)
+		require.True(vs[0].Sensitive)
+		require.Empty(vs[0].Value)
+		require.Empty(vs[0].EncryptedValue)
+
+		// Decrypted reads get the value
+		vs, err = s.ConfigGetWatchDecrypted(req, nil)
+		require.NoError(err)
+		require.Len(vs, 1)
+		require.Equal("hunter2", vs[0].Value)
+		require.Empty(vs[0].EncryptedValue)
+
+		// Delete it
+		v := sensitiveVar()
+		v.Value = ""
+		require.NoError(s.ConfigSet(v))
+		vs, err = s.ConfigGet(req)
+		require.NoError(err)
+		require.Empty(vs)
+	})
+
+	t.Run("key persists across restarts", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		require.NoError(s.ConfigEncryptionSet(
+			context.Background(), keywrap.TestWrapper(t)))
+		require.NoError(s.ConfigSet(sensitiveVar()))
+
+		s = TestStateReinit(t, s)
+		defer s.Close()
+
+		// Without the key we can list but not decrypt
+		vs, err := s.ConfigGet(req)
+		require.NoError(err)
+		require.Len(vs, 1)
+		_, err = s.ConfigGetWatchDecrypted(req, nil)
+		require.Error(err)
+
+		require.NoError(s.ConfigEncryptionSet(
+			context.Background(), keywrap.TestWrapper(t)))
+		vs, err = s.ConfigGetWatchDecrypted(req, nil)
+		require.NoError(err)
+		require.Len(vs, 1)
+		require.Equal("hunter2", vs[0].Value)
+	})
+}
@@ -1,11 +1,11 @@
 package state
 
 import (
-	"strconv"
-	"strings"
+	"time"
 
 	"github.com/boltdb/bolt"
 	"github.com/golang/protobuf/proto"
+	"github.com/hashicorp/go-hclog"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -23,22 +23,32 @@ var (
 //
 // DB Version
 //
-// THIS SHOULD BE CHANGED WITH EXTREME CAUTION. Changing this will force users
-// to perform a db upgrade when they upgrade their Waypoint version.
+// THIS SHOULD BE CHANGED WITH EXTREME CAUTION. Changing this requires adding
+// a migration to dbMigrations in migrate.go that upgrades the data from the
+// previous version. Migrations run when the server starts and can't be undone
+// so users can't downgrade their Waypoint version afterwards.
 //
 //!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!
 const (
-	dbVersion int64 = 1
+	dbVersion int64 = 2
 )
 
 func init() {
 	dbBuckets = append(dbBuckets, sysBucket)
 }
 
+// dbView runs fn in a read-only transaction.
+func (s *State) dbView(fn func(*bolt.Tx) error) error {
+	s.dbLock.RLock()
+	defer s.dbLock.RUnlock()
+	defer s.metrics.dbRead.observe(time.Now())
+	return s.db.View(fn)
+}
+
 // dbInit sets up the database. This should be called once on all new
 // DB handles before accepting API calls. It is safe to be called multiple
-// times.
-func dbInit(db *bolt.DB) error {
+// times. If the data is an older version, it is migrated.
+func dbInit(log hclog.Logger, db *bolt.DB) error {
 	return db.Update(func(tx *bolt.Tx) error {
 		// Create all our buckets
 		for _, b := range dbBuckets {
@@ -47,39 +57,8 @@ func dbInit(db *bolt.DB) error {
 			}
 		}
 
-		// Check our data version
-		sys := tx.Bucket(sysBucket)
-		vsnRaw := sys.Get(sysVersionKey)
-
-		// Initialize the version with our current version if it isn't set.
-		if len(vsnRaw) == 0 {
-			if err := sys.Put(sysVersionKey, []byte(strconv.FormatInt(dbVersion, 10))); err != nil {
-				return status.Errorf(codes.Internal,
-					"failed to write initial database version: %s", err)
-			}
-		} else {
-			vsn, err := strconv.ParseInt(string(vsnRaw), 10, 64)
-			if err != nil {
-				return status.Errorf(codes.Internal,
-					"failed to read database version: %s", err)
-			}
-
-			if vsn != dbVersion {
-				return status.Errorf(codes.FailedPrecondition, strings.TrimSpace(`
-The database version on disk does not match the server version.
-
-The server cannot safely read this data. Please upgrade or downgrade your server
-to a version that is capable of reading this data version. You can find this
-information on the Waypoint website.
-
-On-disk data version: %d
- Server data version: %d
-
-`), vsn, dbVersion)
-			}
-		}
-
-		return nil
+		// Check our data version and migrate it if necessary
+		return dbVersionUpgrade(log, tx)
 	})
 }
 
@@ -92,13 +71,36 @@ func dbPut(b *bolt.Bucket, id []byte, msg proto.Message) error {
 		return status.Errorf(codes.Internal, "failed to encode data: %s", err)
 	}
 
-	if err := b.Put(id, enc); err != nil {
+	if err := dbPutRaw(b, id, enc); err != nil {
 		return status.Errorf(codes.Aborted, "failed to write data: %s", err)
 	}
 
 	return nil
 }
 
+// dbPutRaw puts a raw value into a bucket for the given id. All writes
+// must use dbPut, dbPutRaw, or dbDelete so that they can be replicated.
+// See raft.go.
+func dbPutRaw(b *bolt.Bucket, id, value []byte) error {
+	if err := b.Put(id, value); err != nil {
+		return err
+	}
+
+	raftRecord(b, id, value, false)
+	return nil
+}
+
+// dbDelete deletes the value for the given id from a bucket. Deleting a
+// value that doesn't exist does nothing.
+func dbDelete(b *bolt.Bucket, id []byte) error {
+	if err := b.Delete(id); err != nil {
+		return err
+	}
+
+	raftRecord(b, id, nil, true)
+	return nil
+}
+
 // dbGet is a helper to get a single proto.Message from a bucket. Errors
 // are guaranteed to be in gRPC status format.
 func dbGet(b *bolt.Bucket, id []byte, msg proto.Message) error {
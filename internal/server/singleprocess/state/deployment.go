@@ -11,6 +11,7 @@ var deploymentOp = &appOperation{
 
 func init() {
 	deploymentOp.register()
+	deploymentOp.registerEvents(EventTopicDeployment)
 }
 
 // DeploymentPut inserts or updates a deployment record.
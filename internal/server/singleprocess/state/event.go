@@ -0,0 +1,172 @@
+package state
+
+import (
+	"sync"
+
+	"github.com/hashicorp/go-memdb"
+)
+
+// This file has the event bus. Every write transaction on the in-memory
+// database tracks its changes and, once it is committed, publishes an
+// event for each changed object in a table that has an event topic.
+// Subsystems such as webhooks, audit, and metrics subscribe to the topics
+// they care about rather than each watching memdb themselves.
+//
+// Events only say what changed. Objects can be changed again by the time
+// an event is received so subscribers read the current object by ID.
+
+// EventTopic is the kind of object that an event is for.
+type EventTopic int
+
+const (
+	EventTopicInvalid EventTopic = iota
+	EventTopicJob
+	EventTopicRunner
+	EventTopicDeployment
+)
+
+// EventOp is the change that was made to the object.
+type EventOp int
+
+const (
+	EventOpInvalid EventOp = iota
+	EventOpCreate
+	EventOpUpdate
+	EventOpDelete
+)
+
+// Event is a single change to an object.
+type Event struct {
+	Topic EventTopic
+	Op    EventOp
+
+	// ID is the ID of the object.
+	ID string
+
+	// Project is the project that the object belongs to, if any.
+	Project string
+}
+
+// eventBufferSize is the number of events buffered for each subscriber.
+// Subscribers that fall further behind than this are closed.
+const eventBufferSize = 1024
+
+// eventTables maps the in-memory tables that publish events to a function
+// that returns the topic, ID, and project of an object in the table.
+// Other files should use init() to register their tables.
+var eventTables = map[string]func(obj interface{}) (EventTopic, string, string){}
+
+// eventBus delivers events to subscribers. The zero value is ready to use.
+type eventBus struct {
+	lock sync.Mutex
+	subs map[*EventSubscription]struct{}
+}
+
+// EventSubscription receives the events of the topics it subscribed to.
+type EventSubscription struct {
+	bus    *eventBus
+	topics map[EventTopic]struct{}
+	ch     chan *Event
+	closed bool
+}
+
+// EventSubscribe subscribes to the events for the given topics, or all
+// topics if none are given. Close must be called when the subscriber is
+// done.
+func (s *State) EventSubscribe(topics ...EventTopic) *EventSubscription {
+	sub := &EventSubscription{
+		bus:    &s.events,
+		topics: map[EventTopic]struct{}{},
+		ch:     make(chan *Event, eventBufferSize),
+	}
+	for _, t := range topics {
+		sub.topics[t] = struct{}{}
+	}
+
+	s.events.lock.Lock()
+	defer s.events.lock.Unlock()
+	if s.events.subs == nil {
+		s.events.subs = map[*EventSubscription]struct{}{}
+	}
+	s.events.subs[sub] = struct{}{}
+
+	return sub
+}
+
+// Events returns the channel that events are sent on in the order they
+// were committed. The channel is closed when the subscription is closed.
+// If the subscriber falls too far behind, events are dropped and the
+// channel is closed, so the subscriber should read the current state and
+// subscribe again.
+func (sub *EventSubscription) Events() <-chan *Event {
+	return sub.ch
+}
+
+// Close unsubscribes. It is safe to call this multiple times.
+func (sub *EventSubscription) Close() {
+	sub.bus.lock.Lock()
+	defer sub.bus.lock.Unlock()
+	sub.closeLocked()
+}
+
+func (sub *EventSubscription) closeLocked() {
+	if sub.closed {
+		return
+	}
+
+	sub.closed = true
+	close(sub.ch)
+	delete(sub.bus.subs, sub)
+}
+
+// inmemWriteTxn starts a write transaction on the in-memory database that
+// publishes events for its changes once it is committed.
+func (s *State) inmemWriteTxn() *memdb.Txn {
+	txn := s.inmem.Txn(true)
+	txn.TrackChanges()
+	txn.Defer(func() { s.eventPublish(txn.Changes()) })
+	return txn
+}
+
+// eventPublish sends the events for the changes to the subscribers. This
+// never blocks.
+func (s *State) eventPublish(changes memdb.Changes) {
+	s.events.lock.Lock()
+	defer s.events.lock.Unlock()
+	if len(s.events.subs) == 0 {
+		return
+	}
+
+	for _, change := range changes {
+		fn, ok := eventTables[change.Table]
+		if !ok {
+			continue
+		}
+
+		ev := &Event{Op: EventOpUpdate}
+		obj := change.After
+		switch {
+		case change.Created():
+			ev.Op = EventOpCreate
+		case change.Deleted():
+			ev.Op = EventOpDelete
+			obj = change.Before
+		}
+		ev.Topic, ev.ID, ev.Project = fn(obj)
+
+		for sub := range s.events.subs {
+			if len(sub.topics) > 0 {
+				if _, ok := sub.topics[ev.Topic]; !ok {
+					continue
+				}
+			}
+
+			select {
+			case sub.ch <- ev:
+			default:
+				s.log.Warn("event subscriber is too slow, closing its subscription")
+				sub.closeLocked()
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestEventSubscribe(t *testing.T) {
+	// next returns the next event or fails if there isn't one.
+	next := func(t *testing.T, sub *EventSubscription) *Event {
+		select {
+		case ev, ok := <-sub.Events():
+			require.True(t, ok, "subscription closed")
+			return ev
+
+		case <-time.After(time.Second):
+			t.Fatal("no event")
+			return nil
+		}
+	}
+
+	// empty fails if there is an event.
+	empty := func(t *testing.T, sub *EventSubscription) {
+		select {
+		case ev := <-sub.Events():
+			t.Fatalf("unexpected event: %#v", ev)
+
+		default:
+		}
+	}
+
+	t.Run("jobs", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		sub := s.EventSubscribe(EventTopicJob)
+		defer sub.Close()
+
+		// Create a job
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		ev := next(t, sub)
+		require.Equal(EventTopicJob, ev.Topic)
+		require.Equal(EventOpCreate, ev.Op)
+		require.Equal("A", ev.ID)
+		require.NotEmpty(ev.Project)
+
+		// Cancel it
+		require.NoError(s.JobCancel("A", false, 0))
+		ev = next(t, sub)
+		require.Equal(EventOpUpdate, ev.Op)
+		require.Equal("A", ev.ID)
+
+		// Runners aren't in the topic
+		require.NoError(s.RunnerCreate(serverptypes.TestRunner(t, nil)))
+		empty(t, sub)
+	})
+
+	t.Run("runners and deployments", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		sub := s.EventSubscribe()
+		defer sub.Close()
+
+		// Runners
+		r := serverptypes.TestRunner(t, nil)
+		require.NoError(s.RunnerCreate(r))
+		ev := next(t, sub)
+		require.Equal(EventTopicRunner, ev.Topic)
+		require.Equal(EventOpCreate, ev.Op)
+		require.Equal(r.Id, ev.ID)
+
+		require.NoError(s.RunnerDelete(r.Id))
+		ev = next(t, sub)
+		require.Equal(EventTopicRunner, ev.Topic)
+		require.Equal(EventOpDelete, ev.Op)
+		require.Equal(r.Id, ev.ID)
+
+		// Deployments
+		require.NoError(s.DeploymentPut(false, serverptypes.TestValidDeployment(t, &pb.Deployment{
+			Id: "D",
+		})))
+		ev = next(t, sub)
+		require.Equal(EventTopicDeployment, ev.Topic)
+		require.Equal(EventOpCreate, ev.Op)
+		require.Equal("D", ev.ID)
+		empty(t, sub)
+	})
+
+	t.Run("close", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		sub := s.EventSubscribe()
+		sub.Close()
+		sub.Close()
+
+		_, ok := <-sub.Events()
+		require.False(ok)
+
+		// Writes still work
+		require.NoError(s.RunnerCreate(serverptypes.TestRunner(t, nil)))
+	})
+
+	t.Run("slow subscribers are closed", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		slow := s.EventSubscribe(EventTopicRunner)
+		defer slow.Close()
+
+		for i := 0; i < eventBufferSize+1; i++ {
+			require.NoError(s.RunnerCreate(serverptypes.TestRunner(t, &pb.Runner{
+				Id: fmt.Sprintf("R%d", i),
+			})))
+		}
+
+		// The buffered events are still delivered and then it is closed
+		count := 0
+		for range slow.Events() {
+			count++
+		}
+		require.Equal(eventBufferSize, count)
+	})
+}
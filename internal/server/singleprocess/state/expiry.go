@@ -0,0 +1,249 @@
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/hashicorp/go-memdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// This file has the expiry of records. Any kind of record can be set to
+// expire at a time by registering a handler for its kind and calling
+// expirySet when it is written. The expiries are persisted so that they
+// survive restarts and are processed by a single timer that is set for the
+// earliest one, rather than a timer for every record.
+
+var (
+	// expiryBucket has the expiries ordered by time. The keys are the
+	// time followed by the kind and ID of the record and the values are
+	// empty.
+	expiryBucket = []byte("expiry")
+
+	// expiryRecordBucket has the expiry of each record. The keys are the
+	// kind and ID of the record and the values are its key in expiryBucket.
+	expiryRecordBucket = []byte("expiry_records")
+)
+
+// expiryHandlers are the functions called when a record of each kind
+// expires, by kind. These are registered in init. A handler may be called
+// more than once for the same expiry, such as if the server stops while it
+// is running, so it must do nothing if the record already expired. If the
+// handler returns a NotFound error the record was deleted and the expiry
+// is removed. For any other error it is tried again later.
+var expiryHandlers = map[string]func(s *State, id string) error{}
+
+// expiryRetryInterval is how long to wait to process an expiry again if
+// its handler fails. This is a var so tests can set it.
+var expiryRetryInterval = 10 * time.Second
+
+// expiryManager has the timer that processes the expiries.
+type expiryManager struct {
+	// lock protects timer and next.
+	lock  sync.Mutex
+	timer *wheelTimer
+	next  time.Time
+
+	// runLock is held while expiries are processed so that they're only
+	// processed by one goroutine at a time.
+	runLock sync.Mutex
+}
+
+func init() {
+	dbBuckets = append(dbBuckets, expiryBucket, expiryRecordBucket)
+	dbIndexers = append(dbIndexers, (*State).expiryIndexInit)
+}
+
+// expirySet sets the record of the kind and ID to expire at t, replacing
+// any expiry it already has. expiryWake must be called once the
+// transaction commits.
+func expirySet(dbTxn *bolt.Tx, kind, id string, t time.Time) error {
+	if err := expiryDelete(dbTxn, kind, id); err != nil {
+		return err
+	}
+
+	record := expiryRecordKey(kind, id)
+	key := make([]byte, 8, 8+len(record))
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	key = append(key, record...)
+
+	if err := dbPutRaw(dbTxn.Bucket(expiryBucket), key, []byte{}); err != nil {
+		return err
+	}
+
+	return dbPutRaw(dbTxn.Bucket(expiryRecordBucket), record, key)
+}
+
+// expiryDelete deletes the expiry of the record of the kind and ID if it
+// has one.
+func expiryDelete(dbTxn *bolt.Tx, kind, id string) error {
+	record := expiryRecordKey(kind, id)
+	key := dbTxn.Bucket(expiryRecordBucket).Get(record)
+	if key == nil {
+		return nil
+	}
+
+	if err := dbDelete(dbTxn.Bucket(expiryBucket), key); err != nil {
+		return err
+	}
+
+	return dbDelete(dbTxn.Bucket(expiryRecordBucket), record)
+}
+
+// expiryRecordKey returns the key of the record of the kind and ID.
+func expiryRecordKey(kind, id string) []byte {
+	return []byte(kind + "\x00" + id)
+}
+
+// expiryIndexInit sets the timer for the earliest expiry.
+func (s *State) expiryIndexInit(dbTxn *bolt.Tx, memTxn *memdb.Txn) error {
+	s.expirySchedule(expiryFirst(dbTxn))
+	return nil
+}
+
+// expiryWake sets the timer for the earliest expiry. This must be called
+// after any transaction that calls expirySet commits.
+func (s *State) expiryWake() {
+	next, err := s.expiryNext()
+	if err != nil {
+		s.log.Warn("error reading the next expiry", "err", err)
+		return
+	}
+
+	s.expirySchedule(next)
+}
+
+// expiryNext returns the time of the earliest expiry. This is zero if
+// there are none.
+func (s *State) expiryNext() (time.Time, error) {
+	var result time.Time
+	err := s.dbView(func(dbTxn *bolt.Tx) error {
+		result = expiryFirst(dbTxn)
+		return nil
+	})
+
+	return result, err
+}
+
+// expiryFirst returns the time of the earliest expiry in the transaction.
+func expiryFirst(dbTxn *bolt.Tx) time.Time {
+	k, _ := dbTxn.Bucket(expiryBucket).Cursor().First()
+	if len(k) < 8 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, int64(binary.BigEndian.Uint64(k)))
+}
+
+// expirySchedule sets the timer to process the expiries at t. If t is zero
+// the timer is stopped.
+func (s *State) expirySchedule(t time.Time) {
+	m := &s.expiry
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if t.IsZero() {
+		if m.timer != nil {
+			m.timer.Stop()
+		}
+		m.next = t
+		return
+	}
+
+	// The timer is already set for this time.
+	if t.Equal(m.next) {
+		return
+	}
+	m.next = t
+
+	d := t.Sub(s.clock.Now())
+	if d <= 0 {
+		d = 1
+	}
+	if m.timer == nil {
+		m.timer = s.timers.AfterFunc(d, s.expiryRun)
+		return
+	}
+	m.timer.Reset(d)
+}
+
+// expiryRun processes the expiries that are due and then sets the timer
+// for the next one.
+func (s *State) expiryRun() {
+	m := &s.expiry
+	m.runLock.Lock()
+	defer m.runLock.Unlock()
+
+	// The timer fired so it must be set again even for the same time.
+	m.lock.Lock()
+	m.next = time.Time{}
+	m.lock.Unlock()
+
+	now := s.clock.Now()
+	var due [][]byte
+	err := s.dbView(func(dbTxn *bolt.Tx) error {
+		c := dbTxn.Bucket(expiryBucket).Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			if len(k) < 8 || int64(binary.BigEndian.Uint64(k)) > now.UnixNano() {
+				break
+			}
+
+			due = append(due, append([]byte(nil), k...))
+		}
+
+		return nil
+	})
+	if err != nil {
+		s.log.Warn("error reading expiries", "err", err)
+		s.expirySchedule(now.Add(expiryRetryInterval))
+		return
+	}
+
+	failed := false
+	for _, key := range due {
+		record := key[8:]
+		idx := bytes.IndexByte(record, 0)
+		if idx < 0 {
+			continue
+		}
+		kind, id := string(record[:idx]), string(record[idx+1:])
+
+		if fn, ok := expiryHandlers[kind]; !ok {
+			s.log.Warn("no handler for expired record, removing", "kind", kind, "id", id)
+		} else if err := fn(s, id); err != nil && status.Code(err) != codes.NotFound {
+			s.log.Warn("error expiring record", "kind", kind, "id", id, "err", err)
+			failed = true
+			continue
+		}
+
+		// Remove the expiry unless it was set again while it was processed.
+		err := s.dbUpdate(func(dbTxn *bolt.Tx) error {
+			if !bytes.Equal(dbTxn.Bucket(expiryRecordBucket).Get(record), key) {
+				return nil
+			}
+
+			return expiryDelete(dbTxn, kind, id)
+		})
+		if err != nil {
+			s.log.Warn("error removing expiry", "kind", kind, "id", id, "err", err)
+			failed = true
+		}
+	}
+
+	next, err := s.expiryNext()
+	if err != nil {
+		s.log.Warn("error reading the next expiry", "err", err)
+		failed = true
+	}
+
+	// If any expiry failed it is still due, so wait before trying again.
+	if retry := now.Add(expiryRetryInterval); failed && next.Before(retry) {
+		next = retry
+	}
+
+	s.expirySchedule(next)
+}
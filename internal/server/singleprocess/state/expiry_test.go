@@ -0,0 +1,209 @@
+package state
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestExpiry(t *testing.T) {
+	// A kind of record that records when it expires
+	var lock sync.Mutex
+	var expired []string
+	var fail error
+	expiryHandlers["test"] = func(s *State, id string) error {
+		lock.Lock()
+		defer lock.Unlock()
+		if fail != nil {
+			return fail
+		}
+
+		expired = append(expired, id)
+		return nil
+	}
+	defer delete(expiryHandlers, "test")
+
+	reset := func() {
+		lock.Lock()
+		defer lock.Unlock()
+		expired = nil
+		fail = nil
+	}
+	expiredIds := func() []string {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]string(nil), expired...)
+	}
+	set := func(t *testing.T, s *State, id string, d time.Duration) {
+		require.NoError(t, s.dbUpdate(func(dbTxn *bolt.Tx) error {
+			return expirySet(dbTxn, "test", id, time.Now().Add(d))
+		}))
+		s.expiryWake()
+	}
+
+	t.Run("expires in order", func(t *testing.T) {
+		require := require.New(t)
+		reset()
+
+		s := TestState(t)
+		defer s.Close()
+
+		set(t, s, "B", 40*time.Millisecond)
+		set(t, s, "A", 20*time.Millisecond)
+		set(t, s, "C", time.Hour)
+
+		require.Eventually(func() bool {
+			return len(expiredIds()) == 2
+		}, 2*time.Second, 10*time.Millisecond)
+		require.Equal([]string{"A", "B"}, expiredIds())
+
+		// Only the expiry that isn't due is left
+		require.Eventually(func() bool {
+			next, err := s.expiryNext()
+			require.NoError(err)
+			return next.After(time.Now().Add(time.Minute))
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("replaced and deleted", func(t *testing.T) {
+		require := require.New(t)
+		reset()
+
+		s := TestState(t)
+		defer s.Close()
+
+		set(t, s, "A", time.Hour)
+		set(t, s, "A", 10*time.Millisecond)
+		set(t, s, "B", 10*time.Millisecond)
+		require.NoError(s.dbUpdate(func(dbTxn *bolt.Tx) error {
+			return expiryDelete(dbTxn, "test", "B")
+		}))
+
+		require.Eventually(func() bool {
+			return len(expiredIds()) == 1
+		}, 2*time.Second, 10*time.Millisecond)
+		require.Equal([]string{"A"}, expiredIds())
+
+		// The expiry is removed once it is processed
+		require.Eventually(func() bool {
+			next, err := s.expiryNext()
+			require.NoError(err)
+			return next.IsZero()
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("survives restart", func(t *testing.T) {
+		require := require.New(t)
+		reset()
+
+		s := TestState(t)
+		defer s.Close()
+		set(t, s, "A", 50*time.Millisecond)
+
+		s2 := TestStateReinit(t, s)
+		defer s2.Close()
+		require.NoError(s.Close())
+
+		require.Eventually(func() bool {
+			return len(expiredIds()) == 1
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("retried on error", func(t *testing.T) {
+		require := require.New(t)
+		reset()
+
+		defer func(d time.Duration) { expiryRetryInterval = d }(expiryRetryInterval)
+		expiryRetryInterval = 50 * time.Millisecond
+
+		lock.Lock()
+		fail = status.Errorf(codes.Unavailable, "not now")
+		lock.Unlock()
+
+		s := TestState(t)
+		defer s.Close()
+		set(t, s, "A", 0)
+
+		time.Sleep(20 * time.Millisecond)
+		lock.Lock()
+		fail = nil
+		lock.Unlock()
+
+		require.Eventually(func() bool {
+			return len(expiredIds()) == 1
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("removed if the record doesn't exist", func(t *testing.T) {
+		require := require.New(t)
+		reset()
+
+		lock.Lock()
+		fail = status.Errorf(codes.NotFound, "gone")
+		lock.Unlock()
+
+		s := TestState(t)
+		defer s.Close()
+		set(t, s, "A", 0)
+
+		require.Eventually(func() bool {
+			next, err := s.expiryNext()
+			require.NoError(err)
+			return next.IsZero()
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestJobExpiryMigrate(t *testing.T) {
+	require := require.New(t)
+
+	s := TestState(t)
+	defer s.Close()
+
+	// Jobs that were persisted without their expiry
+	expire, err := ptypes.TimestampProto(time.Now().Add(50 * time.Millisecond))
+	require.NoError(err)
+	require.NoError(s.db.Update(func(dbTxn *bolt.Tx) error {
+		for _, job := range []*pb.Job{
+			{Id: "A", State: pb.Job_QUEUED, ExpireTime: expire},
+			{Id: "B", State: pb.Job_SUCCESS, ExpireTime: expire},
+			{Id: "C", State: pb.Job_QUEUED},
+		} {
+			job = serverptypes.TestJobNew(t, job)
+			job.QueueTime = ptypes.TimestampNow()
+			if err := dbPut(dbTxn.Bucket(jobBucket), []byte(job.Id), job); err != nil {
+				return err
+			}
+		}
+
+		return dbPutRaw(dbTxn.Bucket(sysBucket), sysVersionKey, []byte("1"))
+	}))
+
+	s = TestStateReinit(t, s)
+	defer s.Close()
+
+	// Only the queued job with an expiry expires
+	require.Eventually(func() bool {
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		return job.State == pb.Job_ERROR
+	}, 2*time.Second, 10*time.Millisecond)
+
+	job, err := s.JobById("C", nil)
+	require.NoError(err)
+	require.Equal(pb.Job_QUEUED, job.State)
+
+	require.NoError(s.dbView(func(dbTxn *bolt.Tx) error {
+		require.Nil(dbTxn.Bucket(expiryRecordBucket).Get(expiryRecordKey(expiryKindJob, "B")))
+		return nil
+	}))
+}
@@ -29,11 +29,11 @@ func (s *State) HMACKeyEmpty() bool {
 // HMACKeyCreateIfNotExist creates a new HMAC key with the given ID and size. If a
 // key with the given ID exists already it will be returned.
 func (s *State) HMACKeyCreateIfNotExist(id string, size int) (*pb.HMACKey, error) {
-	memTxn := s.inmem.Txn(true)
+	memTxn := s.inmemWriteTxn()
 	defer memTxn.Abort()
 
 	var result *pb.HMACKey
-	err := s.db.Update(func(dbTxn *bolt.Tx) error {
+	err := s.dbUpdate(func(dbTxn *bolt.Tx) error {
 		var err error
 
 		// If we have this key already, then return that.
@@ -0,0 +1,67 @@
+package state
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+)
+
+// IndexInt indexes a signed integer field of a struct so that the index
+// sorts by the value. memdb.IntFieldIndex encodes values as varints which
+// is fine for exact matches but doesn't sort.
+type IndexInt struct {
+	Field string
+
+	// Asc if true will index with ascending order. This is false by
+	// default so that the largest values are first, like IndexTime.
+	Asc bool
+}
+
+func (idx *IndexInt) FromObject(obj interface{}) (bool, []byte, error) {
+	v := reflect.Indirect(reflect.ValueOf(obj))
+	fv := v.FieldByName(idx.Field)
+	if !fv.IsValid() {
+		return false, nil,
+			fmt.Errorf("field '%s' is invalid %#v ", idx.Field, obj)
+	}
+
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+	default:
+		return false, nil,
+			fmt.Errorf("field '%s' is not a signed integer %v ", idx.Field, obj)
+	}
+
+	return true, idx.fromInt(fv.Int()), nil
+}
+
+func (idx *IndexInt) FromArgs(args ...interface{}) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("must provide only a single argument")
+	}
+
+	v := reflect.ValueOf(args[0])
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+	default:
+		return nil, fmt.Errorf("argument must be a signed integer: %#v", args[0])
+	}
+
+	return idx.fromInt(v.Int()), nil
+}
+
+func (idx *IndexInt) fromInt(v int64) []byte {
+	// Flipping the sign bit orders negative values before positive values
+	// when the bytes are compared.
+	val := uint64(v) ^ (1 << 63)
+
+	// If we're descending, we invert every bit so larger values are
+	// SMALLER when compared.
+	if !idx.Asc {
+		val = ^val
+	}
+
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], val)
+	return buf[:]
+}
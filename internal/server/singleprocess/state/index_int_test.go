@@ -0,0 +1,42 @@
+package state
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexInt(t *testing.T) {
+	values := []int64{math.MinInt64, -10, -1, 0, 1, 10, math.MaxInt64}
+
+	for _, asc := range []bool{true, false} {
+		idx := &IndexInt{Field: "Value", Asc: asc}
+		for i := 1; i < len(values); i++ {
+			lo, err := idx.FromArgs(values[i-1])
+			require.NoError(t, err)
+			hi, err := idx.FromArgs(values[i])
+			require.NoError(t, err)
+
+			if asc {
+				require.Equal(t, -1, bytes.Compare(lo, hi), values[i])
+			} else {
+				require.Equal(t, 1, bytes.Compare(lo, hi), values[i])
+			}
+		}
+	}
+
+	idx := &IndexInt{Field: "Value"}
+	ok, v, err := idx.FromObject(struct{ Value int32 }{Value: 12})
+	require.NoError(t, err)
+	require.True(t, ok)
+	expected, err := idx.FromArgs(12)
+	require.NoError(t, err)
+	require.Equal(t, expected, v)
+
+	_, err = idx.FromArgs("12")
+	require.Error(t, err)
+	_, _, err = idx.FromObject(struct{ Value string }{})
+	require.Error(t, err)
+}
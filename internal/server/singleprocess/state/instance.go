@@ -19,6 +19,7 @@ const (
 
 func init() {
 	schemas = append(schemas, instanceSchema)
+	inmemOnlyTables[instanceTableName] = struct{}{}
 }
 
 func instanceSchema() *memdb.TableSchema {
@@ -115,7 +116,7 @@ func (i *Instance) Proto() *pb.Instance {
 }
 
 func (s *State) InstanceCreate(rec *Instance) error {
-	txn := s.inmem.Txn(true)
+	txn := s.inmemWriteTxn()
 	defer txn.Abort()
 
 	// Create our instance
@@ -134,7 +135,7 @@ func (s *State) InstanceCreate(rec *Instance) error {
 }
 
 func (s *State) InstanceDelete(id string) error {
-	txn := s.inmem.Txn(true)
+	txn := s.inmemWriteTxn()
 	defer txn.Abort()
 	if _, err := txn.DeleteAll(instanceTableName, instanceIdIndexName, id); err != nil {
 		return status.Errorf(codes.Aborted, err.Error())
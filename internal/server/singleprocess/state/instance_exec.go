@@ -18,6 +18,7 @@ const (
 
 func init() {
 	schemas = append(schemas, instanceExecSchema)
+	inmemOnlyTables[instanceExecTableName] = struct{}{}
 }
 
 var instanceExecId int64
@@ -61,7 +62,7 @@ type InstanceExec struct {
 }
 
 func (s *State) InstanceExecCreateByDeployment(did string, exec *InstanceExec) error {
-	txn := s.inmem.Txn(true)
+	txn := s.inmemWriteTxn()
 	defer txn.Abort()
 
 	// Find all the instances by deployment
@@ -117,7 +118,7 @@ func (s *State) InstanceExecCreateByDeployment(did string, exec *InstanceExec) e
 }
 
 func (s *State) InstanceExecDelete(id int64) error {
-	txn := s.inmem.Txn(true)
+	txn := s.inmemWriteTxn()
 	defer txn.Abort()
 	if _, err := txn.DeleteAll(instanceExecTableName, instanceExecIdIndexName, id); err != nil {
 		return status.Errorf(codes.Aborted, err.Error())
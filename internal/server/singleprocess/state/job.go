@@ -3,8 +3,11 @@ package state
 import (
 	"context"
 	"fmt"
+	"math"
 	"reflect"
-	"sort"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/boltdb/bolt"
@@ -17,6 +20,7 @@ import (
 
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
 	"github.com/hashicorp/waypoint/internal/server/logbuffer"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
 )
 
 var (
@@ -26,18 +30,37 @@ var (
 	jobHeartbeatTimeout = 2 * time.Minute
 )
 
+// jobUnmarshalParallelMin is the number of jobs from which they're
+// unmarshalled in parallel when indexing them.
+const jobUnmarshalParallelMin = 1024
+
 const (
-	jobTableName          = "jobs"
-	jobIdIndexName        = "id"
-	jobStateIndexName     = "state"
-	jobQueueTimeIndexName = "queue-time"
-	jobTargetIdIndexName  = "target-id"
+	jobTableName           = "jobs"
+	jobIdIndexName         = "id"
+	jobStateIndexName      = "state"
+	jobQueueTimeIndexName  = "queue-time"
+	jobTargetIdIndexName   = "target-id"
+	jobTargetPoolIndexName = "target-pool"
+	jobDependsOnIndexName  = "depends-on"
+	jobScopeIndexName      = "scope"
 )
 
 func init() {
 	dbBuckets = append(dbBuckets, jobBucket)
 	dbIndexers = append(dbIndexers, (*State).jobIndexInit)
 	schemas = append(schemas, jobSchema)
+	eventTables[jobTableName] = jobEvent
+}
+
+// jobEvent returns the event fields for a job. See event.go.
+func jobEvent(obj interface{}) (EventTopic, string, string) {
+	idx := obj.(*jobIndex)
+	var project string
+	if idx.Application != nil {
+		project = idx.Application.Project
+	}
+
+	return EventTopicJob, idx.Id, project
 }
 
 func jobSchema() *memdb.TableSchema {
@@ -72,6 +95,10 @@ func jobSchema() *memdb.TableSchema {
 							Field: "State",
 						},
 
+						&IndexInt{
+							Field: "Priority",
+						},
+
 						&IndexTime{
 							Field: "QueueTime",
 							Asc:   true,
@@ -95,6 +122,10 @@ func jobSchema() *memdb.TableSchema {
 							Lowercase: true,
 						},
 
+						&IndexInt{
+							Field: "Priority",
+						},
+
 						&IndexTime{
 							Field: "QueueTime",
 							Asc:   true,
@@ -102,6 +133,59 @@ func jobSchema() *memdb.TableSchema {
 					},
 				},
 			},
+
+			// See job_pool.go
+			jobTargetPoolIndexName: {
+				Name:         jobTargetPoolIndexName,
+				AllowMissing: true,
+				Unique:       false,
+				Indexer: &memdb.CompoundIndex{
+					Indexes: []memdb.Indexer{
+						&memdb.IntFieldIndex{
+							Field: "State",
+						},
+
+						&memdb.StringFieldIndex{
+							Field: "TargetPool",
+						},
+
+						&IndexInt{
+							Field: "Priority",
+						},
+
+						&IndexTime{
+							Field: "QueueTime",
+							Asc:   true,
+						},
+					},
+				},
+			},
+
+			jobDependsOnIndexName: {
+				Name:         jobDependsOnIndexName,
+				AllowMissing: true,
+				Unique:       false,
+				Indexer: &memdb.StringSliceFieldIndex{
+					Field: "DependsOn",
+				},
+			},
+
+			// See job_scope.go
+			jobScopeIndexName: {
+				Name:         jobScopeIndexName,
+				AllowMissing: false,
+				Unique:       false,
+				Indexer: &memdb.CompoundIndex{
+					Indexes: []memdb.Indexer{
+						&jobScopeIndex{Field: "Project"},
+						&jobScopeIndex{Field: "Application"},
+						&jobScopeIndex{Field: "Workspace"},
+						&memdb.IntFieldIndex{
+							Field: "State",
+						},
+					},
+				},
+			},
 		},
 	}
 }
@@ -117,24 +201,125 @@ type jobIndex struct {
 	Application *pb.Ref_Application
 	Workspace   *pb.Ref_Workspace
 
+	// Priority is the priority of the job. Queued jobs are assigned in
+	// order of priority, highest first, and then by QueueTime.
+	Priority int32
+
 	// QueueTime is the time that the job was queued.
 	QueueTime time.Time
 
+	// DependsOn are the IDs of the jobs that must succeed before this job
+	// can be assigned. See job_depends.go.
+	DependsOn []string
+
 	// TargetAny will be true if this job targets anything
 	TargetAny bool
 
 	// TargetRunnerId is the ID of the runner to target.
 	TargetRunnerId string
 
+	// TargetLabels are the labels that a runner must have to be assigned
+	// this job. See job_labels.go.
+	TargetLabels map[string]string
+
+	// TargetPool is the name of the runner pool that a runner must be in
+	// to be assigned this job. See job_pool.go.
+	TargetPool string
+
+	// Operation is the name of the job's operation type and Plugins are
+	// the plugins it uses. Runners that can't run them aren't assigned
+	// the job. See job_capabilities.go.
+	Operation string
+	Plugins   []string
+
+	// RunnerProfile is the name of the runner profile that a runner must
+	// have registered with to be assigned this job. See runner_profile.go.
+	RunnerProfile string
+
+	// PlatformOS and PlatformArch are the platform the job must run on
+	// from the Job.Platform field. Either may be empty to allow any. See
+	// job_capabilities.go.
+	PlatformOS   string
+	PlatformArch string
+
+	// RequiredTags are the resource tags the runner must have, lowercased
+	// and sorted. See job_tags.go.
+	RequiredTags []string
+
+	// WeightGroup is the runner group the queued job is reserved for by
+	// the weighted scheduler, if any. See scheduler_weighted.go.
+	WeightGroup string
+
+	// AssignedRunnerId is the ID of the runner the job was last assigned
+	// to, if any.
+	AssignedRunnerId string
+
+	// AffinityRunnerId is the ID of the runner the job prefers and
+	// AffinityAvoidApp is true if the job avoids runners running jobs for
+	// its application. Runners the job doesn't prefer aren't assigned it
+	// until it has been queued for AffinityWait. See job_affinity.go.
+	AffinityRunnerId string
+	AffinityAvoidApp bool
+	AffinityWait     time.Duration
+
+	// LocalityRegion and LocalityZone are where the job prefers to run,
+	// lowercased. Runners elsewhere aren't assigned the job until it has
+	// been queued for LocalityWait. See job_locality.go.
+	LocalityRegion string
+	LocalityZone   string
+	LocalityWait   time.Duration
+
+	// Progress is the last progress reported by the runner while the job
+	// was running. This is only in memory. See job_progress.go.
+	Progress *pb.JobProgress
+
+	// Resources are the resources the job requires or nil if it doesn't
+	// require any. See job_resources.go.
+	Resources *pb.Resources
+
+	// PreemptedBy is the ID of the job that this running job was cancelled
+	// for. The job is queued again once the runner stops it rather than
+	// failing. See job_preempt.go.
+	PreemptedBy string
+
+	// ApprovalPending is true if this job still needs approvals before it
+	// can be assigned. See job_approval.go.
+	ApprovalPending bool
+
+	// Dispatched is true if this job is run by a runner started for it in
+	// an external scheduler. See job_dispatch.go.
+	Dispatched bool
+
+	// Federated is true if this job is forwarded to a downstream server.
+	// See job_federation.go.
+	Federated bool
+
 	// State is the current state of this job.
 	State pb.Job_State
 
 	// StateTimer holds a timer that is usually acting as a timeout mechanism
 	// on the current state. When the state changes, the timer should be cancelled.
-	StateTimer *time.Timer
+	StateTimer *wheelTimer
+
+	// AckTimeout and HeartbeatTimeout are the timeouts of the StateTimer
+	// while the job is waiting and running. MaxRunDuration is the longest
+	// the job can run, enforced by MaxRunTimer. These are the job's
+	// overrides or the server defaults. See job_timeout.go.
+	AckTimeout       time.Duration
+	HeartbeatTimeout time.Duration
+	MaxRunDuration   time.Duration
+	MaxRunTimer      *wheelTimer
 
 	// OutputBuffer stores the terminal output
 	OutputBuffer *logbuffer.Buffer
+
+	// HeartbeatTime is the time of the last heartbeat while the job is
+	// running. This isn't persisted, so it is reset when the server starts.
+	HeartbeatTime time.Time
+
+	// EndTime is the time that End was called on this job. This is zero
+	// if the job hasn't ended.
+	EndTime time.Time
 }
 
 // Job is the exported structure that is returned for most state APIs
@@ -149,27 +334,199 @@ type Job struct {
 	OutputBuffer *logbuffer.Buffer
 
 	// Blocked is true if this job is blocked on another job for the same
-	// project/app/workspace.
-	Blocked bool
+	// project/app/workspace. BlockedBy is the ID of that job.
+	Blocked   bool
+	BlockedBy string
+
+	// Paused is true if this job is queued but the job queue for its
+	// project/app/workspace is paused. See job_pause.go.
+	Paused bool
+
+	// Progress is the last progress reported by the runner, if any.
+	Progress *pb.JobProgress
+
+	// Artifacts are the artifacts the runner attached to the job when it
+	// completed. This is only set for completed jobs. See job_artifact.go.
+	Artifacts []*pb.JobArtifact
+
+	// HeartbeatTime is the time of the last heartbeat from the runner if
+	// the job is running. See HeartbeatDeadline.
+	HeartbeatTime time.Time
+
+	heartbeatTimeout time.Duration
 }
 
-// JobCreate queues the given job.
-func (s *State) JobCreate(jobpb *pb.Job) error {
-	txn := s.inmem.Txn(true)
+// HeartbeatDeadline returns the time the job fails at if the runner
+// doesn't send another heartbeat. This is zero if the job isn't running.
+func (j *Job) HeartbeatDeadline() time.Time {
+	if j.State != pb.Job_RUNNING || j.HeartbeatTime.IsZero() {
+		return time.Time{}
+	}
+
+	return j.HeartbeatTime.Add(j.heartbeatTimeout)
+}
+
+// JobCreate queues the given job. If the context is done before the job
+// is persisted, it isn't created.
+func (s *State) JobCreate(ctx context.Context, jobpb *pb.Job) error {
+	// Persist the job first. We do this without holding the in-memory
+	// write lock and use a batched write so that when many jobs are queued
+	// at once they share a single disk transaction and sync. The job isn't
+	// visible to any APIs until it is indexed below.
+	err := s.dbBatchCtx(ctx, func(dbTxn *bolt.Tx) error {
+		return s.jobCreate(dbTxn, jobpb)
+	})
+	if err != nil {
+		return err
+	}
+
+	txn := s.inmemWriteTxn()
+	defer txn.Abort()
+
+	idx, err := s.jobIndexSet(txn, []byte(jobpb.Id), jobpb)
+	if err != nil {
+		return err
+	}
+
+	txn.Commit()
+	atomic.AddUint64(&s.metrics.jobsCreated, 1)
+	if jobpb.ExpireTime != nil {
+		s.expiryWake()
+	}
+	s.jobCacheSet(jobpb)
+	notified := s.jobNotifyQueued(idx)
+
+	// The job is already created at this point so we only log errors.
+	if err := s.jobDependsCheck(idx); err != nil {
+		s.log.Warn("error checking job dependencies", "job", idx.Id, "err", err)
+	}
+
+	// If no runner was waiting for the job, it may preempt a running job.
+	if !notified {
+		if err := s.jobPreemptCheck(idx); err != nil {
+			s.log.Warn("error checking job preemption", "job", idx.Id, "err", err)
+		}
+	}
+
+	return nil
+}
+
+// JobCreateBatch queues all the given jobs atomically: either they're all
+// created or none are. Jobs may depend on other jobs in the batch, which
+// is how a chain of jobs such as a build, deploy, and release is queued
+// without a partial chain being left behind if one of them fails.
+func (s *State) JobCreateBatch(jobs []*pb.Job) error {
+	seen := map[string]struct{}{}
+	for _, jobpb := range jobs {
+		if _, ok := seen[jobpb.Id]; ok {
+			return status.Errorf(codes.InvalidArgument,
+				"job %q is in the batch more than once", jobpb.Id)
+		}
+		seen[jobpb.Id] = struct{}{}
+	}
+
+	// Unlike JobCreate, we hold the in-memory write transaction while we
+	// persist so that none of the jobs can be assigned until they're all
+	// created.
+	txn := s.inmemWriteTxn()
 	defer txn.Abort()
 
-	err := s.db.Update(func(dbTxn *bolt.Tx) error {
-		return s.jobCreate(dbTxn, txn, jobpb)
+	err := s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		for _, jobpb := range jobs {
+			if err := s.jobCreate(dbTxn, jobpb); err != nil {
+				return err
+			}
+		}
+
+		return nil
 	})
-	if err == nil {
-		txn.Commit()
+	if err != nil {
+		return err
+	}
+
+	idxs := make([]*jobIndex, len(jobs))
+	for i, jobpb := range jobs {
+		idxs[i], err = s.jobIndexSet(txn, []byte(jobpb.Id), jobpb)
+		if err != nil {
+			// The jobs are persisted but not indexed, so they'll be
+			// indexed when the server restarts.
+			return err
+		}
+	}
+
+	txn.Commit()
+	atomic.AddUint64(&s.metrics.jobsCreated, uint64(len(jobs)))
+	s.expiryWake()
+
+	for i, jobpb := range jobs {
+		idx := idxs[i]
+		s.jobCacheSet(jobpb)
+		notified := s.jobNotifyQueued(idx)
+
+		// The jobs are already created at this point so we only log errors.
+		if err := s.jobDependsCheck(idx); err != nil {
+			s.log.Warn("error checking job dependencies", "job", idx.Id, "err", err)
+		}
+		if !notified {
+			if err := s.jobPreemptCheck(idx); err != nil {
+				s.log.Warn("error checking job preemption", "job", idx.Id, "err", err)
+			}
+		}
 	}
 
-	return err
+	return nil
 }
 
 // JobList returns the list of jobs.
 func (s *State) JobList() ([]*pb.Job, error) {
+	ids, err := s.jobListIds()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.jobListRead(ids)
+}
+
+// jobListBatchSize is the number of jobs JobListIter reads at a time. This
+// is a var so tests can set it.
+var jobListBatchSize = 100
+
+// JobListIter calls fn with the jobs in the order of their IDs, in batches
+// of at most jobListBatchSize. Only a batch of jobs is held in memory at a
+// time, so this should be used over JobList when there may be many jobs.
+// If fn returns an error the iteration stops and it is returned. Jobs that
+// are deleted while iterating are skipped.
+func (s *State) JobListIter(fn func([]*pb.Job) error) error {
+	ids, err := s.jobListIds()
+	if err != nil {
+		return err
+	}
+
+	for len(ids) > 0 {
+		n := jobListBatchSize
+		if n > len(ids) {
+			n = len(ids)
+		}
+
+		jobs, err := s.jobListRead(ids[:n])
+		if err != nil {
+			return err
+		}
+		ids = ids[n:]
+
+		if len(jobs) == 0 {
+			continue
+		}
+		if err := fn(jobs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// jobListIds returns the IDs of all the jobs in order.
+func (s *State) jobListIds() ([]string, error) {
 	memTxn := s.inmem.Txn(false)
 	defer memTxn.Abort()
 
@@ -178,21 +535,90 @@ func (s *State) JobList() ([]*pb.Job, error) {
 		return nil, err
 	}
 
-	var result []*pb.Job
-	for {
-		next := iter.Next()
-		if next == nil {
-			break
+	var ids []string
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		ids = append(ids, raw.(*jobIndex).Id)
+	}
+
+	return ids, nil
+}
+
+// jobListRead returns the jobs with the given IDs in the same order. Jobs
+// that were deleted since the IDs were listed are skipped.
+func (s *State) jobListRead(ids []string) ([]*pb.Job, error) {
+	// Read the jobs that aren't cached in a single transaction rather
+	// than one per job.
+	result := make([]*pb.Job, len(ids))
+	var missing []int
+	for i, id := range ids {
+		if raw, ok := s.jobCache.Get(id); ok {
+			result[i] = proto.Clone(raw.(*pb.Job)).(*pb.Job)
+		} else {
+			missing = append(missing, i)
 		}
-		idx := next.(*jobIndex)
+	}
 
-		var job *pb.Job
-		err = s.db.View(func(dbTxn *bolt.Tx) error {
-			job, err = s.jobById(dbTxn, idx.Id)
-			return err
+	if len(missing) > 0 {
+		err := s.dbView(func(dbTxn *bolt.Tx) error {
+			for _, i := range missing {
+				job, err := s.jobById(dbTxn, ids[i])
+				if status.Code(err) == codes.NotFound {
+					continue
+				}
+				if err != nil {
+					return err
+				}
+
+				result[i] = job
+			}
+
+			return nil
 		})
+		if err != nil {
+			return nil, err
+		}
+	}
 
-		result = append(result, job)
+	// Remove the jobs that were deleted.
+	jobs := result[:0]
+	for _, job := range result {
+		if job != nil && job.DeleteTime == nil {
+			jobs = append(jobs, job)
+		}
+	}
+
+	return jobs, nil
+}
+
+// JobStates returns the current state of every job by ID. If project is
+// set, only the jobs of that project are returned. The watch set, if given,
+// fires when any job changes.
+func (s *State) JobStates(project string, ws memdb.WatchSet) (map[string]pb.Job_State, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	var jobs []*jobIndex
+	if project == "" {
+		iter, err := memTxn.Get(jobTableName, jobIdIndexName+"_prefix", "")
+		if err != nil {
+			return nil, err
+		}
+		ws.Add(iter.WatchCh())
+
+		for raw := iter.Next(); raw != nil; raw = iter.Next() {
+			jobs = append(jobs, raw.(*jobIndex))
+		}
+	} else {
+		var err error
+		jobs, err = s.jobsByScope(memTxn, ws, project, "", "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := map[string]pb.Job_State{}
+	for _, idx := range jobs {
+		result[idx.Id] = idx.State
 	}
 
 	return result, nil
@@ -217,23 +643,35 @@ func (s *State) JobById(id string, ws memdb.WatchSet) (*Job, error) {
 	}
 	jobIdx := raw.(*jobIndex)
 
-	// Get blocked status if it is queued.
-	var blocked bool
+	// Get blocked and paused status if it is queued.
+	var blockedBy string
+	var paused bool
 	if jobIdx.State == pb.Job_QUEUED {
-		blocked, err = s.jobIsBlocked(memTxn, jobIdx, ws)
+		blockedBy, err = s.jobBlockedBy(memTxn, jobIdx, ws)
+		if err != nil {
+			return nil, err
+		}
+
+		paused, err = s.jobPaused(memTxn, jobIdx)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	var job *pb.Job
-	err = s.db.View(func(dbTxn *bolt.Tx) error {
-		job, err = s.jobById(dbTxn, jobIdx.Id)
-		return err
-	})
+	job, err := s.jobByIdCached(jobIdx.Id)
 
 	result := jobIdx.Job(job)
-	result.Blocked = blocked
+	result.Blocked = blockedBy != ""
+	result.BlockedBy = blockedBy
+	result.Paused = paused
+	if err != nil {
+		return result, err
+	}
+
+	switch jobIdx.State {
+	case pb.Job_SUCCESS, pb.Job_ERROR:
+		result.Artifacts, err = s.JobArtifacts(jobIdx.Id)
+	}
 
 	return result, err
 }
@@ -248,18 +686,97 @@ func (s *State) JobById(id string, ws memdb.WatchSet) (*Job, error) {
 // If ctx is provided and assignment has to block waiting for new jobs,
 // this will cancel when the context is done.
 func (s *State) JobAssignForRunner(ctx context.Context, r *pb.Runner) (*Job, error) {
+	// Turn our runner into a runner record so we can more efficiently assign
+	runnerRec := newRunnerRecord(r)
+
+	// passSeq is set if we were woken for a job targeting any runner so
+	// that we can pass the notification on if we can't run the job.
+	var passSeq uint64
+
 RETRY_ASSIGN:
+	// Register to be notified of newly queued jobs before we search for
+	// candidates so that we can't miss a job queued between our search
+	// and when we begin waiting.
+	waiter := s.jobNotify.register(runnerRec)
+	waiter.setPass(passSeq)
+	passSeq = 0
+
 	txn := s.inmem.Txn(false)
 	defer txn.Abort()
 
-	// Turn our runner into a runner record so we can more efficiently assign
-	runnerRec := newRunnerRecord(r)
+	// Drained runners aren't assigned new jobs, so we wait until the runner
+	// changes. See RunnerDrain.
+	runnerCh, draining, err := s.runnerDraining(txn, r.Id)
+	if err != nil {
+		s.jobNotify.unregister(waiter)
+		return nil, err
+	}
+	if draining {
+		s.jobNotify.unregister(waiter)
+		txn.Abort()
+
+		select {
+		case <-runnerCh:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		goto RETRY_ASSIGN
+	}
+
+	// Runners with their maximum number of jobs aren't assigned more, so
+	// we wait until one of their jobs ends. See runner_concurrency.go.
+	fullCh, full, err := s.runnerFull(txn, r)
+	if err != nil {
+		s.jobNotify.unregister(waiter)
+		return nil, err
+	}
+	if full {
+		s.jobNotify.unregister(waiter)
+		txn.Abort()
+
+		select {
+		case <-fullCh:
+		case <-runnerCh:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		goto RETRY_ASSIGN
+	}
+
+	// Runners that were assigned their limit of jobs recently aren't
+	// assigned more until the limit allows. See runner_assign_limit.go.
+	if d, err := s.runnerAssignLimited(txn, r); err != nil {
+		s.jobNotify.unregister(waiter)
+		return nil, err
+	} else if d > 0 {
+		s.jobNotify.unregister(waiter)
+		txn.Abort()
+
+		limitCh := make(chan struct{})
+		t := s.timers.AfterFunc(d, func() { close(limitCh) })
+		select {
+		case <-limitCh:
+		case <-runnerCh:
+		case <-ctx.Done():
+		}
+		t.Stop()
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		goto RETRY_ASSIGN
+	}
 
 	// candidateQuery finds candidate jobs to assign.
-	type candidateFunc func(*memdb.Txn, memdb.WatchSet, *runnerRecord) (*jobIndex, error)
+	type candidateFunc func(*memdb.Txn, *jobWaiter, *runnerRecord, int) ([]*jobIndex, error)
 	candidateQuery := []candidateFunc{
 		s.jobCandidateById,
 		s.jobCandidateAny,
+		s.jobCandidateLabels,
+		s.jobCandidatePool,
 	}
 
 	// If the runner is by id only, then explicitly set it to by id only.
@@ -270,49 +787,52 @@ RETRY_ASSIGN:
 	}
 
 	// Build the list of candidates
+	sch := s.schedulerGet()
 	var candidates []*jobIndex
-	ws := memdb.NewWatchSet()
 	for _, f := range candidateQuery {
-		job, err := f(txn, ws, runnerRec)
+		jobs, err := f(txn, waiter, runnerRec, sch.CandidateLimit())
 		if err != nil {
+			s.jobNotify.unregister(waiter)
 			return nil, err
 		}
-		if job == nil {
-			continue
-		}
 
-		candidates = append(candidates, job)
+		candidates = append(candidates, jobs...)
 	}
 
-	// If we have no candidates, then we have to wait for a job to show up.
-	// We set up a blocking query on the job table for a non-assigned job.
-	if len(candidates) == 0 {
-		iter, err := txn.Get(jobTableName, jobStateIndexName, pb.Job_QUEUED)
+	// Let the scheduler choose the order we try to assign the candidates.
+	if len(candidates) > 0 {
+		var err error
+		candidates, err = s.schedulerOrder(txn, sch, r, candidates)
 		if err != nil {
+			s.jobNotify.unregister(waiter)
 			return nil, err
 		}
-
-		ws.Add(iter.WatchCh())
 	}
 
 	// We're done reading so abort the transaction
 	txn.Abort()
 
-	// If we have a watch channel set that means we didn't find any
-	// results and we need to retry after waiting for changes.
+	// If we have no candidates, then we have to wait for a job to show up.
+	// We wait for either a notification that a job we can run was queued,
+	// or that something blocking a queued candidate changed. Only the
+	// runners that may be assigned a job because of a change are notified,
+	// so a queued job doesn't wake every waiting runner.
 	if len(candidates) == 0 {
-		ws.WatchCtx(ctx)
+		select {
+		case <-waiter.ch:
+		case <-runnerCh:
+		case <-ctx.Done():
+		}
+
+		passSeq = waiter.woken()
+		s.jobNotify.unregister(waiter)
 		if err := ctx.Err(); err != nil {
 			return nil, err
 		}
 
 		goto RETRY_ASSIGN
 	}
-
-	// We sort our candidates by queue time so that we can find the earliest
-	sort.Slice(candidates, func(i, j int) bool {
-		return candidates[i].QueueTime.Before(candidates[j].QueueTime)
-	})
+	s.jobNotify.unregister(waiter)
 
 	// Grab a write lock since we're going to delete, modify, add the
 	// job that we chose. No need to defer here since the first defer works
@@ -321,11 +841,41 @@ RETRY_ASSIGN:
 	// Write locks are exclusive so this will ensure we're the only one
 	// writing at a time. This lets us be sure we're the only one "assigning"
 	// a job candidate.
-	txn = s.inmem.Txn(true)
+	txn = s.inmemWriteTxn()
+
+	// The runner may have been drained or assigned other jobs since our
+	// search. If so, another runner is notified of the candidate in our
+	// place.
+	if _, draining, err := s.runnerDraining(txn, r.Id); err != nil {
+		txn.Abort()
+		return nil, err
+	} else if draining {
+		txn.Abort()
+		s.jobNotifyQueued(candidates[0])
+		goto RETRY_ASSIGN
+	}
+	if _, full, err := s.runnerFull(txn, r); err != nil {
+		txn.Abort()
+		return nil, err
+	} else if full {
+		txn.Abort()
+		s.jobNotifyQueued(candidates[0])
+		goto RETRY_ASSIGN
+	}
+	if d, err := s.runnerAssignLimited(txn, r); err != nil {
+		txn.Abort()
+		return nil, err
+	} else if d > 0 {
+		txn.Abort()
+		s.jobNotifyQueued(candidates[0])
+		goto RETRY_ASSIGN
+	}
+
 	for _, job := range candidates {
 		// Get the job
 		raw, err := txn.First(jobTableName, jobIdIndexName, job.Id)
 		if err != nil {
+			txn.Abort()
 			return nil, err
 		}
 		if raw == nil {
@@ -347,55 +897,131 @@ RETRY_ASSIGN:
 		if blocked, err := s.jobIsBlocked(txn, job, nil); blocked {
 			continue
 		} else if err != nil {
+			txn.Abort()
 			return nil, err
 		}
 
-		// Update our state and update our on-disk job
-		job.State = pb.Job_WAITING
-		result, err := s.jobReadAndUpdate(job.Id, func(jobpb *pb.Job) error {
-			jobpb.State = job.State
-			jobpb.AssignTime, err = ptypes.TimestampProto(time.Now())
-			if err != nil {
-				// This should never happen since encoding a time now should be safe
-				panic("time encoding failed: " + err.Error())
-			}
+		// The queue may have been paused since our search.
+		if paused, err := s.jobPaused(txn, job); paused {
+			continue
+		} else if err != nil {
+			txn.Abort()
+			return nil, err
+		}
 
-			return nil
-		})
-		if err != nil {
+		// Another job may have been assigned to the runner since our search.
+		if fits, err := s.jobFits(txn, job, r); err != nil {
+			txn.Abort()
 			return nil, err
+		} else if !fits {
+			continue
 		}
 
+		// The runner the job prefers may have registered since our search.
+		if held, err := s.jobAffinityHeld(txn, nil, job, r); held {
+			continue
+		} else if err != nil {
+			txn.Abort()
+			return nil, err
+		}
+		if held, err := s.jobLocalityHeld(txn, nil, job, r); held {
+			continue
+		} else if err != nil {
+			txn.Abort()
+			return nil, err
+		}
+		if held, err := s.jobCostHeld(txn, nil, job, r); held {
+			continue
+		} else if err != nil {
+			txn.Abort()
+			return nil, err
+		}
+		if held, err := s.jobWeightHeld(txn, nil, job, r); held {
+			continue
+		} else if err != nil {
+			txn.Abort()
+			return nil, err
+		}
+
+		// Everything in memory is updated before the on-disk job so that
+		// abort undoes the assignment if any step fails.
+		job = job.Copy()
+		job.State = pb.Job_WAITING
+		job.AssignedRunnerId = r.Id
+
 		// Create our timer to requeue this if it isn't acked
-		job.StateTimer = time.AfterFunc(jobWaitingTimeout, func() {
-			s.log.Info("job ack timer expired", "job", job.Id, "timeout", jobWaitingTimeout)
-			s.JobAck(job.Id, false)
+		job.StateTimer = s.timers.AfterFunc(job.AckTimeout, func() {
+			s.log.Info("job ack timer expired", "job", job.Id, "timeout", job.AckTimeout)
+			s.JobNack(job.Id, jobAckTimeoutReason(job.AckTimeout))
 		})
+		abort := func(err error) (*Job, error) {
+			job.StateTimer.Stop()
+			txn.Abort()
+			return nil, err
+		}
 
 		if err := txn.Insert(jobTableName, job); err != nil {
-			return nil, err
+			return abort(err)
 		}
 
-		// Update our assignment state
+		// Update our assignment state.
 		if err := s.jobAssignedSet(txn, job, true); err != nil {
-			s.JobAck(job.Id, false)
-			return nil, err
+			return abort(err)
+		}
+
+		if err := s.runnerAssignAdd(txn, r); err != nil {
+			return abort(err)
+		}
+
+		// A runner launched on demand no longer counts towards the demand
+		// for runners. See runner_launch.go.
+		if err := s.runnerLaunchStatusSet(txn, r.Id, RunnerLaunchRunning); err != nil {
+			return abort(err)
+		}
+
+		// Update our on-disk job. This isn't bounded by ctx since the
+		// index is already updated.
+		result, err := s.jobReadAndUpdate(context.Background(), job.Id, func(jobpb *pb.Job) error {
+			jobpb.State = job.State
+			jobpb.AssignTime, err = ptypes.TimestampProto(s.clock.Now())
+			if err != nil {
+				// This should never happen since encoding a time now should be safe
+				panic("time encoding failed: " + err.Error())
+			}
+
+			jobpb.AssignedRunner = &pb.Ref_RunnerId{Id: r.Id}
+			jobpb.Assignments = append(jobpb.Assignments, &pb.Job_Assignment{
+				Runner:     jobpb.AssignedRunner,
+				AssignTime: jobpb.AssignTime,
+			})
+
+			return nil
+		})
+		if err != nil {
+			return abort(err)
 		}
 
 		txn.Commit()
+		atomic.AddUint64(&s.metrics.jobsAssigned, 1)
 		return job.Job(result), nil
 	}
 	txn.Abort()
 
 	// If we reached here, all of our candidates were invalid, we retry
+	atomic.AddUint64(&s.metrics.jobAssignRetries, 1)
 	goto RETRY_ASSIGN
 }
 
 // JobAck acknowledges that a job has been accepted or rejected by the runner.
 // If ack is false, then this will move the job back to the queued state
-// and be eligible for assignment.
-func (s *State) JobAck(id string, ack bool) (*Job, error) {
-	txn := s.inmem.Txn(true)
+// and be eligible for assignment, unless it has been rejected too many
+// times. See JobNack.
+func (s *State) JobAck(ctx context.Context, id string, ack bool) (*Job, error) {
+	return s.jobAck(ctx, id, ack, "")
+}
+
+func (s *State) jobAck(ctx context.Context, id string, ack bool, reason string) (*Job, error) {
+	txn := s.inmemWriteTxn()
 	defer txn.Abort()
 
 	// Get the job
@@ -406,7 +1032,7 @@ func (s *State) JobAck(id string, ack bool) (*Job, error) {
 	if raw == nil {
 		return nil, status.Errorf(codes.NotFound, "job not found: %s", id)
 	}
-	job := raw.(*jobIndex)
+	job := raw.(*jobIndex).Copy()
 
 	// If the job is not in the assigned state, then this is an error.
 	if job.State != pb.Job_WAITING {
@@ -415,25 +1041,57 @@ func (s *State) JobAck(id string, ack bool) (*Job, error) {
 			job.State.String())
 	}
 
-	result, err := s.jobReadAndUpdate(job.Id, func(jobpb *pb.Job) error {
+	now := s.clock.Now()
+	result, err := s.jobReadAndUpdate(ctx, job.Id, func(jobpb *pb.Job) error {
+		nowpb, err := ptypes.TimestampProto(now)
+		if err != nil {
+			// This should never happen since encoding a time now should be safe
+			panic("time encoding failed: " + err.Error())
+		}
+
+		// Record the result of the current assignment
+		var assignment *pb.Job_Assignment
+		if n := len(jobpb.Assignments); n > 0 {
+			assignment = jobpb.Assignments[n-1]
+		} else {
+			// Jobs assigned before assignments were recorded
+			assignment = &pb.Job_Assignment{}
+		}
+
 		if ack {
 			// Set to accepted
 			job.State = pb.Job_RUNNING
 			jobpb.State = job.State
-			jobpb.AckTime, err = ptypes.TimestampProto(time.Now())
-			if err != nil {
-				// This should never happen since encoding a time now should be safe
-				panic("time encoding failed: " + err.Error())
-			}
+			jobpb.AckTime = nowpb
+			assignment.AckTime = nowpb
 
 			// We also initialize the output buffer here because we can
 			// expect output to begin streaming in.
-			job.OutputBuffer = logbuffer.New()
+			job.OutputBuffer = s.jobOutputNew()
+			job.HeartbeatTime = now
 		} else {
+			if reason == "" {
+				reason = "rejected by the runner"
+			}
+
 			// Set to queued
 			job.State = pb.Job_QUEUED
 			jobpb.State = job.State
 			jobpb.AssignTime = nil
+			jobpb.NackCount++
+			assignment.NackTime = nowpb
+			assignment.NackReason = reason
+
+			// If it wasn't accepted too many times, it is dead-lettered
+			if limit := s.jobNackLimit(); jobpb.NackCount > limit {
+				s.log.Warn("job was not accepted too many times, moving to dead-letter queue",
+					"job", job.Id, "nacks", jobpb.NackCount, "reason", reason)
+				job.State = pb.Job_DEAD_LETTER
+				jobpb.State = job.State
+				jobpb.Error = status.Newf(codes.Aborted,
+					"job was not accepted by a runner %d times, last reason: %s",
+					jobpb.NackCount, reason).Proto()
+			}
 		}
 
 		return nil
@@ -442,24 +1100,38 @@ func (s *State) JobAck(id string, ack bool) (*Job, error) {
 		return nil, err
 	}
 
+	// The output of a previous run of the job is replaced by the output of
+	// this run. See job_output.go.
+	if ack {
+		if err := s.jobOutputReset(job.Id); err != nil {
+			return nil, err
+		}
+	}
+
 	// Cancel our timer
 	if job.StateTimer != nil {
 		job.StateTimer.Stop()
 		job.StateTimer = nil
 	}
 
-	// Create a new timer that we'll use for our heartbeat. After this
-	// timer expires, the job will immediately move to an error state.
-	job.StateTimer = time.AfterFunc(jobHeartbeatTimeout, func() {
-		s.log.Info("canceling job due to heartbeat timeout", "job", job.Id)
-		// Force cancel
-		err := s.JobCancel(job.Id, true)
-		if err != nil {
-			s.log.Error("error canceling job due to heartbeat failure", "error", err, "job", job.Id)
-		}
-	})
+	if ack {
+		// Create a new timer that we'll use for our heartbeat. After this
+		// timer expires, the job will immediately move to an error state.
+		job.StateTimer = s.timers.AfterFunc(job.HeartbeatTimeout, func() {
+			s.log.Info("canceling job due to heartbeat timeout", "job", job.Id)
+			atomic.AddUint64(&s.metrics.jobHeartbeatsMissed, 1)
+			// Force cancel
+			err := s.JobCancel(job.Id, true, 0)
+			if err != nil {
+				s.log.Error("error canceling job due to heartbeat failure", "error", err, "job", job.Id)
+			}
+		})
+
+		s.log.Debug("heartbeat timer set", "job", job.Id, "timeout", job.HeartbeatTimeout)
 
-	s.log.Debug("heartbeat timer set", "job", job.Id, "timeout", jobHeartbeatTimeout)
+		// Once accepted, the job can only run for its maximum duration.
+		s.jobMaxRunTimerSet(job, s.clock.Now())
+	}
 
 	// Insert to update
 	if err := txn.Insert(jobTableName, job); err != nil {
@@ -474,14 +1146,158 @@ func (s *State) JobAck(id string, ack bool) (*Job, error) {
 	}
 
 	txn.Commit()
+
+	// If we nacked, the job is queued again so a runner may take it.
+	if job.State == pb.Job_QUEUED {
+		s.jobNotifyQueued(job)
+	}
+
 	return job.Job(result), nil
 }
 
 // JobComplete marks a running job as complete. If an error is given,
 // the job is marked as failed (a completed state). If no error is given,
 // the job is marked as successful.
-func (s *State) JobComplete(id string, result *pb.Job_Result, cerr error) error {
-	txn := s.inmem.Txn(true)
+func (s *State) JobComplete(ctx context.Context, id string, result *pb.Job_Result, cerr error) error {
+	txn := s.inmemWriteTxn()
+	defer txn.Abort()
+
+	// Get the job
+	raw, err := txn.First(jobTableName, jobIdIndexName, id)
+	if err != nil {
+		return err
+	}
+	if raw == nil {
+		return status.Errorf(codes.NotFound, "job not found: %s", id)
+	}
+	job := raw.(*jobIndex).Copy()
+
+	// Update our assigned state
+	if err := s.jobAssignedSet(txn, job, false); err != nil {
+		return err
+	}
+
+	// If the job is not in the assigned state, then this is an error.
+	if job.State != pb.Job_RUNNING {
+		return status.Errorf(codes.FailedPrecondition,
+			"job can't be completed from state: %s",
+			job.State.String())
+	}
+
+	// If the job was preempted and stopped, it is queued again rather than
+	// failing.
+	if cerr != nil && job.PreemptedBy != "" {
+		if err := s.jobPreemptRequeue(txn, job); err != nil {
+			return err
+		}
+
+		txn.Commit()
+		s.jobNotifyQueued(job)
+		return nil
+	}
+
+	_, err = s.jobReadAndUpdate(ctx, job.Id, func(jobpb *pb.Job) error {
+		// Set to complete, assume success for now
+		job.State = pb.Job_SUCCESS
+		jobpb.State = job.State
+		jobpb.Result = result
+		if result != nil && !s.redactor.Empty() {
+			// Builds often echo credentials by accident, so we never store
+			// the values of sensitive config variables in results.
+			jobpb.Result = proto.Clone(result).(*pb.Job_Result)
+			s.redactor.Message(jobpb.Result)
+		}
+		jobpb.CompleteTime, err = ptypes.TimestampProto(s.clock.Now())
+		if err != nil {
+			// This should never happen since encoding a time now should be safe
+			panic("time encoding failed: " + err.Error())
+		}
+
+		if cerr != nil {
+			job.State = pb.Job_ERROR
+			jobpb.State = job.State
+
+			st, _ := status.FromError(cerr)
+			jobpb.Error = st.Proto()
+			s.redactor.Message(jobpb.Error)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Runners are preferred by jobs for the same operation once they
+	// have run it successfully. See job_affinity.go.
+	if job.State == pb.Job_SUCCESS {
+		if err := s.jobAffinitySet(txn, job); err != nil {
+			return err
+		}
+	}
+
+	// End the job
+	job.End(s.clock.Now())
+
+	// Insert to update
+	if err := txn.Insert(jobTableName, job); err != nil {
+		return err
+	}
+
+	txn.Commit()
+	s.jobCompleted(job.Id)
+	return nil
+}
+
+// JobCompleteHookSet sets a function that is called with each job that
+// completes, whether it succeeded, failed, or was cancelled. The function
+// is called in a new goroutine after the change is committed. This should
+// be called once before the state is used.
+func (s *State) JobCompleteHookSet(fn func(*pb.Job)) {
+	s.jobCompleteHook = fn
+}
+
+// jobCompleted calls the job complete hook if one is set. This must be
+// called after the transaction completing the job is committed.
+func (s *State) jobCompleted(id string) {
+	s.jobDependentsUpdate(id)
+	s.runnerStatsJobCompleted(id)
+
+	memTxn := s.inmem.Txn(false)
+	if raw, err := memTxn.First(jobTableName, jobIdIndexName, id); err == nil && raw != nil {
+		s.metrics.jobCompleted(raw.(*jobIndex).State)
+	}
+	memTxn.Abort()
+
+	fn := s.jobCompleteHook
+	if fn == nil {
+		return
+	}
+
+	go func() {
+		job, err := s.jobByIdCached(id)
+		if err != nil {
+			s.log.Warn("error reading completed job", "job", id, "err", err)
+			return
+		}
+
+		fn(job)
+	}()
+}
+
+// JobCancel marks a job as cancelled. This will set the internal state
+// and request the cancel but if the job is running then it is up to downstream
+// to listen for and react to Job changes for cancellation. If rev is set,
+// the job is only cancelled if that is its current revision.
+func (s *State) JobCancel(id string, force bool, rev uint64) error {
+	return s.jobCancelById(id, force, rev, nil)
+}
+
+// jobCancelById cancels the job with the given ID. If st is set, it is the
+// error of the job if it is cancelled immediately rather than the default
+// cancellation error.
+func (s *State) jobCancelById(id string, force bool, rev uint64, st *status.Status) error {
+	txn := s.inmemWriteTxn()
 	defer txn.Abort()
 
 	// Get the job
@@ -492,37 +1308,127 @@ func (s *State) JobComplete(id string, result *pb.Job_Result, cerr error) error
 	if raw == nil {
 		return status.Errorf(codes.NotFound, "job not found: %s", id)
 	}
-	job := raw.(*jobIndex)
+	if err := s.jobRevisionCheck(id, rev); err != nil {
+		return err
+	}
+	job := raw.(*jobIndex).Copy()
+	completed := job.State == pb.Job_SUCCESS || job.State == pb.Job_ERROR
+
+	if err := s.jobCancel(txn, job, force, st); err != nil {
+		return err
+	}
+
+	txn.Commit()
+	if !completed && job.State == pb.Job_ERROR {
+		s.jobCompleted(job.Id)
+	}
+
+	return nil
+}
+
+// JobCancelBulk cancels all the queued, waiting, and running jobs for the
+// application in one transaction. If the application name is empty then
+// the jobs of every application in the project are cancelled. If ws is nil
+// then the jobs in every workspace are cancelled. This returns the IDs of
+// the cancelled jobs. See JobCancel.
+func (s *State) JobCancelBulk(ref *pb.Ref_Application, ws *pb.Ref_Workspace) ([]string, error) {
+	txn := s.inmemWriteTxn()
+	defer txn.Abort()
+
+	var wsName string
+	if ws != nil {
+		wsName = ws.Workspace
+	}
+	jobs, err := s.jobsByScope(txn, nil, ref.Project, ref.Application, wsName,
+		pb.Job_QUEUED, pb.Job_WAITING, pb.Job_RUNNING)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, job := range jobs {
+		jobs[i] = job.Copy()
+	}
+	if err := s.jobCancelAll(txn, jobs, false, nil); err != nil {
+		return nil, err
+	}
+
+	var result, completed []string
+	for _, job := range jobs {
+		result = append(result, job.Id)
+		if job.State == pb.Job_ERROR {
+			completed = append(completed, job.Id)
+		}
+	}
+
+	txn.Commit()
+	for _, id := range completed {
+		s.jobCompleted(id)
+	}
+
+	return result, nil
+}
+
+func (s *State) jobCancel(txn *memdb.Txn, job *jobIndex, force bool, st *status.Status) error {
+	return s.jobCancelAll(txn, []*jobIndex{job}, force, st)
+}
+
+// jobCancelAll cancels the jobs. The jobs are written to disk in one
+// transaction before their indexes are updated, so if this fails none of
+// the jobs are cancelled.
+func (s *State) jobCancelAll(txn *memdb.Txn, jobs []*jobIndex, force bool, st *status.Status) error {
+	var cancelled []*jobIndex
+	var states []pb.Job_State
+	for _, job := range jobs {
+		state, ok := jobCancelState(job, force)
+		if !ok {
+			s.log.Debug("attempted to cancel completed job", "state", job.State.String(), "job", job.Id)
+			continue
+		}
+
+		s.log.Debug("changing job state for cancel", "old-state", job.State.String(), "new-state", state.String(), "job", job.Id, "force", force)
+		cancelled = append(cancelled, job)
+		states = append(states, state)
+	}
+	if len(cancelled) == 0 {
+		return nil
+	}
+
+	// Persist the on-disk data
+	now := s.clock.Now()
+	var results []*pb.Job
+	err := s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		results = nil
+		for i, job := range cancelled {
+			jobpb, err := s.jobById(dbTxn, job.Id)
+			if err != nil {
+				return err
+			}
 
-	// Update our assigned state
-	if err := s.jobAssignedSet(txn, job, false); err != nil {
-		return err
-	}
+			jobpb.State = states[i]
+			jobpb.CancelTime, err = ptypes.TimestampProto(now)
+			if err != nil {
+				// This should never happen since encoding a time now should be safe
+				panic("time encoding failed: " + err.Error())
+			}
 
-	// If the job is not in the assigned state, then this is an error.
-	if job.State != pb.Job_RUNNING {
-		return status.Errorf(codes.FailedPrecondition,
-			"job can't be completed from state: %s",
-			job.State.String())
-	}
+			// If we transitioned to the error state we note that we were force
+			// cancelled. We can only be in the error state under that scenario
+			// since otherwise we would've returned early.
+			if jobpb.State == pb.Job_ERROR {
+				jobSt := st
+				if jobSt == nil {
+					jobSt = status.New(codes.Canceled, "canceled")
+				}
 
-	_, err = s.jobReadAndUpdate(job.Id, func(jobpb *pb.Job) error {
-		// Set to complete, assume success for now
-		job.State = pb.Job_SUCCESS
-		jobpb.State = job.State
-		jobpb.Result = result
-		jobpb.CompleteTime, err = ptypes.TimestampProto(time.Now())
-		if err != nil {
-			// This should never happen since encoding a time now should be safe
-			panic("time encoding failed: " + err.Error())
-		}
+				jobpb.Error = jobSt.Proto()
+			}
 
-		if cerr != nil {
-			job.State = pb.Job_ERROR
-			jobpb.State = job.State
+			jobpb.Revision++
+			if err := dbPut(dbTxn.Bucket(jobBucket), []byte(job.Id), jobpb); err != nil {
+				return err
+			}
 
-			st, _ := status.FromError(cerr)
-			jobpb.Error = st.Proto()
+			results = append(results, jobpb)
 		}
 
 		return nil
@@ -530,118 +1436,82 @@ func (s *State) JobComplete(id string, result *pb.Job_Result, cerr error) error
 	if err != nil {
 		return err
 	}
-
-	// End the job
-	job.End()
-
-	// Insert to update
-	if err := txn.Insert(jobTableName, job); err != nil {
-		return err
+	for _, jobpb := range results {
+		s.jobCacheSet(jobpb)
 	}
 
-	txn.Commit()
-	return nil
-}
+	for i, job := range cancelled {
+		assigned := job.State == pb.Job_WAITING || job.State == pb.Job_RUNNING
+		job.State = states[i]
+		if assigned {
+			// A job that was cancelled isn't queued again if it was also
+			// preempted.
+			job.PreemptedBy = ""
+		}
 
-// JobCancel marks a job as cancelled. This will set the internal state
-// and request the cancel but if the job is running then it is up to downstream
-// to listen for and react to Job changes for cancellation.
-func (s *State) JobCancel(id string, force bool) error {
-	txn := s.inmem.Txn(true)
-	defer txn.Abort()
+		if assigned && job.State == pb.Job_ERROR {
+			job.End(now)
 
-	// Get the job
-	raw, err := txn.First(jobTableName, jobIdIndexName, id)
-	if err != nil {
-		return err
-	}
-	if raw == nil {
-		return status.Errorf(codes.NotFound, "job not found: %s", id)
-	}
-	job := raw.(*jobIndex)
+			// Update our assigned state to unblock future jobs
+			if err := s.jobAssignedSet(txn, job, false); err != nil {
+				return err
+			}
+		}
 
-	if err := s.jobCancel(txn, job, force); err != nil {
-		return err
+		// Store the inmem data
+		// This will be seen by a currently running RunnerJobStream goroutine, which
+		// will then see that the job has been canceled and send the request to cancel
+		// down to the runner.
+		if err := txn.Insert(jobTableName, job); err != nil {
+			return err
+		}
 	}
 
-	txn.Commit()
 	return nil
 }
 
-func (s *State) jobCancel(txn *memdb.Txn, job *jobIndex, force bool) error {
-	oldState := job.State
-
+// jobCancelState returns the state of the job once it is cancelled. This
+// returns false if the job is already completed.
+func jobCancelState(job *jobIndex, force bool) (pb.Job_State, bool) {
 	// How we handle cancel depends on the state
 	switch job.State {
 	case pb.Job_ERROR, pb.Job_SUCCESS:
-		s.log.Debug("attempted to cancel completed job", "state", job.State.String(), "job", job.Id)
 		// Jobs that are already completed do nothing for cancellation.
 		// We do not mark that they were requested as cancelled since they
 		// completed fine.
-		return nil
+		return job.State, false
 
-	case pb.Job_QUEUED:
+	case pb.Job_QUEUED, pb.Job_DEAD_LETTER:
 		// For queued jobs, we immediately transition them to an error state.
-		job.State = pb.Job_ERROR
+		return pb.Job_ERROR, true
 
 	case pb.Job_WAITING, pb.Job_RUNNING:
 		// For these states, we just need to mark it as cancelled and have
 		// downstream listeners complete the job. However, if we are forcing
 		// then we immediately transition to error.
 		if force {
-			job.State = pb.Job_ERROR
-			job.End()
-		}
-	}
-
-	s.log.Debug("changing job state for cancel", "old-state", oldState.String(), "new-state", job.State.String(), "job", job.Id, "force", force)
-
-	if force && job.State == pb.Job_ERROR {
-		// Update our assigned state to unblock future jobs
-		if err := s.jobAssignedSet(txn, job, false); err != nil {
-			return err
+			return pb.Job_ERROR, true
 		}
 	}
 
-	// Persist the on-disk data
-	_, err := s.jobReadAndUpdate(job.Id, func(jobpb *pb.Job) error {
-		var err error
-		jobpb.State = job.State
-		jobpb.CancelTime, err = ptypes.TimestampProto(time.Now())
-		if err != nil {
-			// This should never happen since encoding a time now should be safe
-			panic("time encoding failed: " + err.Error())
-		}
-
-		// If we transitioned to the error state we note that we were force
-		// cancelled. We can only be in the error state under that scenario
-		// since otherwise we would've returned early.
-		if jobpb.State == pb.Job_ERROR {
-			jobpb.Error = status.New(codes.Canceled, "canceled").Proto()
-		}
+	return job.State, true
+}
 
+// JobUpdateRef sets the data source ref of a job, which is the exact
+// revision of the data that the job runs with. This returns the updated
+// job.
+func (s *State) JobUpdateRef(id string, ref *pb.Job_DataSource_Ref) (*pb.Job, error) {
+	return s.jobReadAndUpdate(context.Background(), id, func(jobpb *pb.Job) error {
+		jobpb.DataSourceRef = ref
 		return nil
 	})
-	if err != nil {
-		return err
-	}
-
-	// Store the inmem data
-	// This will be seen by a currently running RunnerJobStream goroutine, which
-	// will then see that the job has been canceled and send the request to cancel
-	// down to the runner.
-	if err := txn.Insert(jobTableName, job); err != nil {
-		return err
-	}
-
-	return nil
 }
 
 // JobHeartbeat resets the heartbeat timer for a running job. If the job
 // is not currently running this does nothing, it will not return an error.
 // If the job doesn't exist then this will return an error.
 func (s *State) JobHeartbeat(id string) error {
-	txn := s.inmem.Txn(true)
+	txn := s.inmemWriteTxn()
 	defer txn.Abort()
 
 	if err := s.jobHeartbeat(txn, id); err != nil {
@@ -661,7 +1531,7 @@ func (s *State) jobHeartbeat(txn *memdb.Txn, id string) error {
 	if raw == nil {
 		return status.Errorf(codes.NotFound, "job not found: %s", id)
 	}
-	job := raw.(*jobIndex)
+	job := raw.(*jobIndex).Copy()
 
 	// If the job is not in the running state, we do nothing.
 	if job.State != pb.Job_RUNNING {
@@ -677,14 +1547,21 @@ func (s *State) jobHeartbeat(txn *memdb.Txn, id string) error {
 	}
 
 	// Reset the timer
-	job.StateTimer.Reset(jobHeartbeatTimeout)
+	job.StateTimer.Reset(job.HeartbeatTimeout)
 
-	return nil
+	// Update the heartbeat time. We insert the job so that watchers of the
+	// job see the heartbeat.
+	now := s.clock.Now()
+	if !job.HeartbeatTime.IsZero() {
+		s.metrics.jobHeartbeat.observeDuration(now.Sub(job.HeartbeatTime))
+	}
+	job.HeartbeatTime = now
+	return txn.Insert(jobTableName, job)
 }
 
 // JobExpire expires a job. This will cancel the job if it is still queued.
 func (s *State) JobExpire(id string) error {
-	txn := s.inmem.Txn(true)
+	txn := s.inmemWriteTxn()
 	defer txn.Abort()
 
 	// Get the job
@@ -695,19 +1572,25 @@ func (s *State) JobExpire(id string) error {
 	if raw == nil {
 		return status.Errorf(codes.NotFound, "job not found: %s", id)
 	}
-	job := raw.(*jobIndex)
+	job := raw.(*jobIndex).Copy()
 
 	// How we handle depends on the state
 	switch job.State {
-	case pb.Job_QUEUED, pb.Job_WAITING:
-		if err := s.jobCancel(txn, job, false); err != nil {
+	case pb.Job_QUEUED, pb.Job_WAITING, pb.Job_DEAD_LETTER:
+		if err := s.jobCancel(txn, job, false, nil); err != nil {
 			return err
 		}
 
 	default:
+		txn.Commit()
+		return nil
 	}
 
 	txn.Commit()
+	if job.State == pb.Job_ERROR {
+		s.jobCompleted(job.Id)
+	}
+
 	return nil
 }
 
@@ -734,6 +1617,16 @@ func (s *State) JobIsAssignable(ctx context.Context, jobpb *pb.Job) (bool, error
 		return false, nil
 	}
 
+	// compat has the fields of the job used to check the runner can run
+	// it. See job_capabilities.go.
+	compat := &jobIndex{
+		Operation: serverptypes.JobOperation(jobpb),
+		Plugins:   jobpb.Plugins,
+	}
+	compat.RunnerProfile = jobpb.RunnerProfile
+	compat.PlatformOS, compat.PlatformArch = jobPlatform(jobpb)
+	compat.RequiredTags = resourceTags(jobpb.RequiredTags)
+
 	// If we have a special targeting constraint, that has to be met
 	var iter memdb.ResultIterator
 	var targetCheck func(*pb.Runner) (bool, error)
@@ -744,11 +1637,27 @@ func (s *State) JobIsAssignable(ctx context.Context, jobpb *pb.Job) (bool, error
 			return !r.ByIdOnly, nil
 		}
 
-		iter, err = memTxn.LowerBound(runnerTableName, runnerIdIndexName, "")
+		iter, err = s.runnerCandidates(memTxn, compat)
 
 	case *pb.Ref_Runner_Id:
 		iter, err = memTxn.Get(runnerTableName, runnerIdIndexName, v.Id.Id)
 
+	case *pb.Ref_Runner_Labels:
+		// Runners that are by ID only can't be assigned jobs by label.
+		targetCheck = func(r *pb.Runner) (bool, error) {
+			return !r.ByIdOnly && runnerLabelsMatch(r, v.Labels.Labels), nil
+		}
+
+		iter, err = s.runnerCandidates(memTxn, compat)
+
+	case *pb.Ref_Runner_Pool:
+		// Runners that are by ID only can't be assigned jobs by pool.
+		targetCheck = func(r *pb.Runner) (bool, error) {
+			return !r.ByIdOnly, nil
+		}
+
+		iter, err = memTxn.Get(runnerTableName, runnerPoolIndexName, v.Pool.Name)
+
 	default:
 		return false, fmt.Errorf("unknown runner target value: %#v", jobpb.TargetRunner.Target)
 	}
@@ -764,6 +1673,11 @@ func (s *State) JobIsAssignable(ctx context.Context, jobpb *pb.Job) (bool, error
 		}
 		runner := raw.(*runnerRecord)
 
+		// Drained and pending runners aren't assigned new jobs.
+		if runner.Runner.Draining || runner.Runner.Pending {
+			continue
+		}
+
 		// Check our target-specific check
 		if targetCheck != nil {
 			check, err := targetCheck(runner.Runner)
@@ -775,21 +1689,35 @@ func (s *State) JobIsAssignable(ctx context.Context, jobpb *pb.Job) (bool, error
 			}
 		}
 
+		// The job must fit in the runner's capacity
+		if !resourcesFit(jobResources(jobpb), runner.Runner.Capacity, runner.Runner.Capacity) {
+			continue
+		}
+
+		// The runner must be able to run the job
+		if !jobCompatible(compat, runner.Runner) {
+			continue
+		}
+
 		// This works!
 		return true, nil
 	}
 }
 
-// jobIndexInit initializes the config index from persisted data.
+// jobIndexInit initializes the job index from persisted data.
 func (s *State) jobIndexInit(dbTxn *bolt.Tx, memTxn *memdb.Txn) error {
-	bucket := dbTxn.Bucket(jobBucket)
-	return bucket.ForEach(func(k, v []byte) error {
-		var value pb.Job
-		if err := proto.Unmarshal(v, &value); err != nil {
-			return err
+	jobs, err := jobUnmarshalAll(dbTxn.Bucket(jobBucket))
+	if err != nil {
+		return err
+	}
+
+	for _, value := range jobs {
+		// Deleted jobs aren't indexed. See job_delete.go.
+		if value.DeleteTime != nil {
+			continue
 		}
 
-		idx, err := s.jobIndexSet(memTxn, k, &value)
+		idx, err := s.jobIndexSet(memTxn, []byte(value.Id), value)
 		if err != nil {
 			return err
 		}
@@ -800,9 +1728,58 @@ func (s *State) jobIndexInit(dbTxn *bolt.Tx, memTxn *memdb.Txn) error {
 				return err
 			}
 		}
+	}
+
+	// Rehydrate the output of the jobs. See job_output.go.
+	return s.jobOutputInit(dbTxn, memTxn)
+}
 
+// jobUnmarshalAll unmarshals every job in the bucket in key order. With
+// many jobs, unmarshalling dominates the time to index the jobs when the
+// server starts, so it is spread across the CPUs.
+func jobUnmarshalAll(b *bolt.Bucket) ([]*pb.Job, error) {
+	// The values are only valid for the life of the transaction, which
+	// outlives the workers below, so they don't need to be copied.
+	var raw [][]byte
+	if err := b.ForEach(func(k, v []byte) error {
+		raw = append(raw, v)
 		return nil
-	})
+	}); err != nil {
+		return nil, err
+	}
+
+	workers := runtime.GOMAXPROCS(0)
+	if len(raw) < jobUnmarshalParallelMin {
+		workers = 1
+	}
+
+	result := make([]*pb.Job, len(raw))
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(raw); i += workers {
+				var job pb.Job
+				if err := proto.Unmarshal(raw[i], &job); err != nil {
+					errs[w] = err
+					return
+				}
+
+				result[i] = &job
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
 }
 
 // jobIndexSet writes an index record for a single job.
@@ -813,6 +1790,25 @@ func (s *State) jobIndexSet(txn *memdb.Txn, id []byte, jobpb *pb.Job) (*jobIndex
 		Application: jobpb.Application,
 		Workspace:   jobpb.Workspace,
 		OpType:      reflect.TypeOf(jobpb.Operation),
+		Operation:   serverptypes.JobOperation(jobpb),
+		Plugins:     jobpb.Plugins,
+		Priority:    jobpb.Priority,
+		DependsOn:   jobpb.DependsOn,
+		Resources:   jobResources(jobpb),
+
+		ApprovalPending: jobApprovalPending(jobpb),
+		Dispatched:      jobpb.Dispatch != nil,
+		Federated:       jobpb.Federation != nil,
+	}
+	if jobpb.AssignedRunner != nil {
+		rec.AssignedRunnerId = jobpb.AssignedRunner.Id
+	}
+	rec.RunnerProfile = jobpb.RunnerProfile
+	rec.PlatformOS, rec.PlatformArch = jobPlatform(jobpb)
+	rec.RequiredTags = resourceTags(jobpb.RequiredTags)
+
+	if err := s.jobTimeoutsIndexSet(rec, jobpb); err != nil {
+		return nil, err
 	}
 
 	// Target
@@ -826,6 +1822,18 @@ func (s *State) jobIndexSet(txn *memdb.Txn, id []byte, jobpb *pb.Job) (*jobIndex
 	case *pb.Ref_Runner_Id:
 		rec.TargetRunnerId = v.Id.Id
 
+	case *pb.Ref_Runner_Labels:
+		// No labels matches any runner, so we treat it as such.
+		if len(v.Labels.Labels) == 0 {
+			rec.TargetAny = true
+			break
+		}
+
+		rec.TargetLabels = v.Labels.Labels
+
+	case *pb.Ref_Runner_Pool:
+		rec.TargetPool = v.Pool.Name
+
 	default:
 		return nil, fmt.Errorf("unknown runner target value: %#v", jobpb.TargetRunner.Target)
 	}
@@ -846,76 +1854,127 @@ func (s *State) jobIndexSet(txn *memdb.Txn, id []byte, jobpb *pb.Job) (*jobIndex
 		*ts.Field = t
 	}
 
+	if err := s.jobAffinityIndexSet(txn, rec, jobpb); err != nil {
+		return nil, err
+	}
+	if err := s.jobLocalityIndexSet(rec, jobpb); err != nil {
+		return nil, err
+	}
+	s.jobCostIndexSet(rec)
+	if err := s.jobWeightIndexSet(txn, rec); err != nil {
+		return nil, err
+	}
+
 	// If this job is assigned. Then we have to start a nacking timer.
 	// We reset the nack timer so it gives runners time to reconnect.
 	if rec.State == pb.Job_WAITING {
 		// Create our timer to requeue this if it isn't acked
-		rec.StateTimer = time.AfterFunc(jobWaitingTimeout, func() {
-			s.JobAck(rec.Id, false)
+		rec.StateTimer = s.timers.AfterFunc(rec.AckTimeout, func() {
+			s.JobNack(rec.Id, jobAckTimeoutReason(rec.AckTimeout))
 		})
 	}
 
 	// If this job is running, we need to restart a heartbeat timeout.
 	// This should only happen on reinit. This is tested.
 	if rec.State == pb.Job_RUNNING {
-		rec.StateTimer = time.AfterFunc(jobHeartbeatTimeout, func() {
+		rec.HeartbeatTime = s.clock.Now()
+		rec.StateTimer = s.timers.AfterFunc(rec.HeartbeatTimeout, func() {
+			atomic.AddUint64(&s.metrics.jobHeartbeatsMissed, 1)
+
 			// Force cancel
-			s.JobCancel(rec.Id, true)
+			s.JobCancel(rec.Id, true, 0)
 		})
-	}
 
-	// If we have an expiry, we need to set a timer to expire this job.
-	if jobpb.ExpireTime != nil {
-		now := time.Now()
-
-		t, err := ptypes.Timestamp(jobpb.ExpireTime)
+		// The maximum run duration is from when the job was accepted.
+		ackTime, err := ptypes.Timestamp(jobpb.AckTime)
 		if err != nil {
-			return nil, err
-		}
-
-		dur := t.Sub(now)
-		if dur < 0 {
-			dur = 1
+			ackTime = rec.HeartbeatTime
 		}
-
-		time.AfterFunc(dur, func() { s.JobExpire(jobpb.Id) })
+		s.jobMaxRunTimerSet(rec, ackTime)
 	}
 
 	// Insert the index
 	return rec, txn.Insert(jobTableName, rec)
 }
 
-func (s *State) jobCreate(dbTxn *bolt.Tx, memTxn *memdb.Txn, jobpb *pb.Job) error {
+// jobCreate persists a new queued job. This may be called more than
+// once for the same job if it is part of a batch that is retried, so it
+// must not have any side effects other than the write.
+func (s *State) jobCreate(dbTxn *bolt.Tx, jobpb *pb.Job) error {
+	// The values of sensitive config variables are never stored, like
+	// the job output and results.
+	s.redactor.Message(jobpb)
+
 	// Setup our initial job state
 	var err error
+	now := s.clock.Now()
 	jobpb.State = pb.Job_QUEUED
-	jobpb.QueueTime, err = ptypes.TimestampProto(time.Now())
+	jobpb.Revision = 1
+	jobpb.QueueTime, err = ptypes.TimestampProto(now)
 	if err != nil {
 		return err
 	}
 
-	id := []byte(jobpb.Id)
+	// Apply the default and maximum expiry
+	if err := s.jobExpirySet(dbTxn, jobpb, now); err != nil {
+		return err
+	}
 
 	// Insert into bolt
-	if err := dbPut(dbTxn.Bucket(jobBucket), id, jobpb); err != nil {
-		return err
+	return dbPut(dbTxn.Bucket(jobBucket), []byte(jobpb.Id), jobpb)
+}
+
+// jobNotifyQueued wakes the runners that may be assigned the given job.
+// This must be called after the transaction that queued the job commits.
+// This returns false if no runners that may be assigned the job were
+// waiting.
+func (s *State) jobNotifyQueued(idx *jobIndex) bool {
+	// The runners the job prefers are woken as well since the runner that
+	// is woken for the target may not be assigned it. See job_affinity.go
+	// and job_locality.go.
+	if idx.AffinityRunnerId != "" {
+		s.jobNotify.notifyKey(jobNotifyRunnerKey(idx.AffinityRunnerId))
 	}
+	s.jobNotifyWeightQueued(idx)
+	s.jobNotifyLocalityQueued(idx)
 
-	// Insert into the DB
-	_, err = s.jobIndexSet(memTxn, id, jobpb)
-	return err
+	switch {
+	case idx.TargetAny:
+		return s.jobNotify.notifyAny()
+
+	case idx.TargetRunnerId != "":
+		return s.jobNotify.notifyKey(jobNotifyRunnerKey(idx.TargetRunnerId))
+
+	case len(idx.TargetLabels) > 0:
+		return s.jobNotify.notifyKey(jobNotifyLabelsKey(idx.TargetLabels))
+
+	case idx.TargetPool != "":
+		return s.jobNotify.notifyKey(jobNotifyPoolKey(idx.TargetPool))
+	}
+
+	return false
 }
 
 func (s *State) jobById(dbTxn *bolt.Tx, id string) (*pb.Job, error) {
 	var result pb.Job
 	b := dbTxn.Bucket(jobBucket)
-	return &result, dbGet(b, []byte(id), &result)
+	if err := dbGet(b, []byte(id), &result); err != nil {
+		return &result, err
+	}
+	if result.DeleteTime != nil {
+		return &result, status.Errorf(codes.NotFound, "job not found: %s", id)
+	}
+
+	return &result, nil
 }
 
-func (s *State) jobReadAndUpdate(id string, f func(*pb.Job) error) (*pb.Job, error) {
+// jobReadAndUpdate reads the job with the given ID, updates it with f,
+// and persists it. If the context is done before the job is read, f isn't
+// called.
+func (s *State) jobReadAndUpdate(ctx context.Context, id string, f func(*pb.Job) error) (*pb.Job, error) {
 	var result *pb.Job
-	var err error
-	return result, s.db.Update(func(dbTxn *bolt.Tx) error {
+	err := s.dbUpdateCtx(ctx, func(dbTxn *bolt.Tx) error {
+		var err error
 		result, err = s.jobById(dbTxn, id)
 		if err != nil {
 			return err
@@ -925,26 +1984,36 @@ func (s *State) jobReadAndUpdate(id string, f func(*pb.Job) error) (*pb.Job, err
 		if err := f(result); err != nil {
 			return err
 		}
+		result.Revision++
 
 		// Commit
 		return dbPut(dbTxn.Bucket(jobBucket), []byte(id), result)
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.jobCacheSet(result)
+	return result, nil
 }
 
-// jobCandidateById returns the most promising candidate job to assign
-// that is targeting a specific runner by ID.
-func (s *State) jobCandidateById(memTxn *memdb.Txn, ws memdb.WatchSet, r *runnerRecord) (*jobIndex, error) {
+// jobCandidateById returns up to limit candidate jobs to assign that are
+// targeting a specific runner by ID, or all of them if limit is zero.
+// Candidates are ordered by priority, highest first, and then queue time.
+func (s *State) jobCandidateById(memTxn *memdb.Txn, w *jobWaiter, r *runnerRecord, limit int) ([]*jobIndex, error) {
 	iter, err := memTxn.LowerBound(
 		jobTableName,
 		jobTargetIdIndexName,
 		pb.Job_QUEUED,
 		r.Id,
+		int32(math.MaxInt32),
 		time.Unix(0, 0),
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	var result []*jobIndex
 	for {
 		raw := iter.Next()
 		if raw == nil {
@@ -956,31 +2025,69 @@ func (s *State) jobCandidateById(memTxn *memdb.Txn, ws memdb.WatchSet, r *runner
 			continue
 		}
 
-		// If this job is blocked, it is not a candidate.
-		if blocked, err := s.jobIsBlocked(memTxn, job, ws); err != nil {
+		// Jobs waiting for approval are not candidates. We're notified
+		// when they are approved.
+		if job.ApprovalPending {
+			continue
+		}
+
+		// Jobs of paused queues are not candidates. We're notified when
+		// the queue is resumed.
+		if paused, err := s.jobPaused(memTxn, job); err != nil {
+			return nil, err
+		} else if paused {
+			w.watch(jobNotifyPauseKey(job.Application.Project))
+			continue
+		}
+
+		// If this job is blocked, it is not a candidate. We watch for it
+		// to become unblocked in case we have to wait.
+		if blockedBy, err := s.jobBlockedBy(memTxn, job, nil); err != nil {
+			return nil, err
+		} else if blockedBy != "" {
+			w.watch(jobNotifyBlockedKey(job, blockedBy))
+			continue
+		}
+
+		// Jobs the runner can't run are not candidates. See
+		// job_capabilities.go.
+		if !jobCompatible(job, r.Runner) {
+			continue
+		}
+
+		// Jobs that don't fit in the runner's remaining capacity are not
+		// candidates.
+		if fits, err := s.jobFits(memTxn, job, r.Runner); err != nil {
 			return nil, err
-		} else if blocked {
+		} else if !fits {
 			continue
 		}
 
-		return job, nil
+		result = append(result, job)
+		if limit > 0 && len(result) >= limit {
+			break
+		}
 	}
 
-	return nil, nil
+	return result, nil
 }
 
-// jobCandidateAny returns the first candidate job that targets any runner.
-func (s *State) jobCandidateAny(memTxn *memdb.Txn, ws memdb.WatchSet, r *runnerRecord) (*jobIndex, error) {
+// jobCandidateAny returns up to limit candidate jobs that target any
+// runner, or all of them if limit is zero. Candidates are ordered by
+// priority, highest first, and then queue time.
+func (s *State) jobCandidateAny(memTxn *memdb.Txn, w *jobWaiter, r *runnerRecord, limit int) ([]*jobIndex, error) {
 	iter, err := memTxn.LowerBound(
 		jobTableName,
 		jobQueueTimeIndexName,
 		pb.Job_QUEUED,
+		int32(math.MaxInt32),
 		time.Unix(0, 0),
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	var result []*jobIndex
 	for {
 		raw := iter.Next()
 		if raw == nil {
@@ -992,31 +2099,157 @@ func (s *State) jobCandidateAny(memTxn *memdb.Txn, ws memdb.WatchSet, r *runnerR
 			continue
 		}
 
-		// If this job is blocked, it is not a candidate.
-		if blocked, err := s.jobIsBlocked(memTxn, job, ws); err != nil {
+		// Jobs waiting for approval are not candidates. We're notified
+		// when they are approved.
+		if job.ApprovalPending {
+			continue
+		}
+
+		// Jobs of paused queues are not candidates. We're notified when
+		// the queue is resumed.
+		if paused, err := s.jobPaused(memTxn, job); err != nil {
+			return nil, err
+		} else if paused {
+			w.watch(jobNotifyPauseKey(job.Application.Project))
+			continue
+		}
+
+		// If this job is blocked, it is not a candidate. We watch for it
+		// to become unblocked in case we have to wait.
+		if blockedBy, err := s.jobBlockedBy(memTxn, job, nil); err != nil {
+			return nil, err
+		} else if blockedBy != "" {
+			w.watch(jobNotifyBlockedKey(job, blockedBy))
+			continue
+		}
+
+		// Jobs the runner can't run are not candidates. See
+		// job_capabilities.go. Another runner is woken in our place in
+		// case we were woken for the job.
+		if !jobCompatible(job, r.Runner) {
+			w.pass()
+			continue
+		}
+
+		// Jobs that don't fit in the runner's remaining capacity are not
+		// candidates. Another runner is woken in our place in case we
+		// were woken for the job.
+		if fits, err := s.jobFits(memTxn, job, r.Runner); err != nil {
+			return nil, err
+		} else if !fits {
+			w.pass()
+			continue
+		}
+
+		// Jobs that prefer another runner, or that avoid this one, are
+		// not candidates until their affinity wait ends. See
+		// job_affinity.go.
+		if held, err := s.jobAffinityHeld(memTxn, w, job, r.Runner); err != nil {
+			return nil, err
+		} else if held {
+			continue
+		}
+
+		// Jobs that prefer runners elsewhere are not candidates until
+		// their locality wait ends. See job_locality.go.
+		if held, err := s.jobLocalityHeld(memTxn, w, job, r.Runner); err != nil {
+			return nil, err
+		} else if held {
+			continue
+		}
+
+		// Jobs that a cheaper waiting runner can run are not candidates
+		// until their wait ends. See job_cost.go.
+		if held, err := s.jobCostHeld(memTxn, w, job, r.Runner); err != nil {
+			return nil, err
+		} else if held {
+			continue
+		}
+
+		// Jobs reserved for another runner group are not candidates until
+		// their wait ends. See scheduler_weighted.go.
+		if held, err := s.jobWeightHeld(memTxn, w, job, r.Runner); err != nil {
 			return nil, err
-		} else if blocked {
+		} else if held {
 			continue
 		}
 
-		return job, nil
+		result = append(result, job)
+		if limit > 0 && len(result) >= limit {
+			break
+		}
 	}
 
-	return nil, nil
+	return result, nil
 }
 
 // Job returns the Job for an index.
 func (idx *jobIndex) Job(jobpb *pb.Job) *Job {
 	return &Job{
-		Job:          jobpb,
-		OutputBuffer: idx.OutputBuffer,
+		Job:           jobpb,
+		OutputBuffer:  idx.OutputBuffer,
+		HeartbeatTime: idx.HeartbeatTime,
+		Progress:      idx.Progress,
+
+		heartbeatTimeout: idx.HeartbeatTimeout,
+	}
+}
+
+// jobsByState returns the jobs in the given states from the state index.
+func jobsByState(memTxn *memdb.Txn, states ...pb.Job_State) ([]*jobIndex, error) {
+	var result []*jobIndex
+	for _, state := range states {
+		iter, err := memTxn.Get(jobTableName, jobStateIndexName, state)
+		if err != nil {
+			return nil, err
+		}
+
+		for raw := iter.Next(); raw != nil; raw = iter.Next() {
+			result = append(result, raw.(*jobIndex))
+		}
+	}
+
+	return result, nil
+}
+
+// Copy returns a copy of the index. The indexes in memdb are shared with
+// readers and memdb indexes them by their fields, so they must not be
+// modified. Write paths modify a copy and insert it instead.
+func (idx *jobIndex) Copy() *jobIndex {
+	result := *idx
+	return &result
+}
+
+// before returns true if this job should be assigned before other. Jobs
+// with a higher priority come first and then jobs that were queued first.
+// Jobs queued at the same time are ordered by ID so the order is the same
+// for assignment and for queue positions.
+func (idx *jobIndex) before(other *jobIndex) bool {
+	if idx.Priority != other.Priority {
+		return idx.Priority > other.Priority
+	}
+	if !idx.QueueTime.Equal(other.QueueTime) {
+		return idx.QueueTime.Before(other.QueueTime)
 	}
+
+	return idx.Id < other.Id
+}
+
+// End notes this job is complete at the given time and performs any
+// cleanup on the index.
+func (idx *jobIndex) End(now time.Time) {
+	idx.EndTime = now
+	idx.stopTimers()
 }
 
-// End notes this job is complete and performs any cleanup on the index.
-func (idx *jobIndex) End() {
+// stopTimers stops all the timers of the job.
+func (idx *jobIndex) stopTimers() {
 	if idx.StateTimer != nil {
 		idx.StateTimer.Stop()
 		idx.StateTimer = nil
 	}
+	if idx.MaxRunTimer != nil {
+		idx.MaxRunTimer.Stop()
+		idx.MaxRunTimer = nil
+	}
 }
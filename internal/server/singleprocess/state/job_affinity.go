@@ -0,0 +1,186 @@
+package state
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-memdb"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+// This file has the methods related to job affinity. Jobs can prefer the
+// runner that last ran the same operation successfully for their
+// application, such as the runner that built it last so that its build
+// cache is reused, and can avoid runners that are running jobs for the
+// same application. These are preferences: a job isn't a candidate for a
+// runner it doesn't prefer, or that it avoids, until it has been queued for
+// its affinity wait. After that it can be assigned to any runner it
+// targets. A job only waits for the runner it prefers while that runner is
+// registered and not drained.
+//
+// The runner that last ran each operation for an application is only kept
+// in memory like the runners themselves.
+
+const (
+	jobAffinityTableName   = "job-affinity"
+	jobAffinityIdIndexName = "id"
+)
+
+// jobAffinityWait is the default time a job waits for a runner it prefers.
+const jobAffinityWait = 30 * time.Second
+
+func init() {
+	schemas = append(schemas, jobAffinitySchema)
+	inmemOnlyTables[jobAffinityTableName] = struct{}{}
+}
+
+func jobAffinitySchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: jobAffinityTableName,
+		Indexes: map[string]*memdb.IndexSchema{
+			jobAffinityIdIndexName: {
+				Name:         jobAffinityIdIndexName,
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "Id",
+					Lowercase: true,
+				},
+			},
+		},
+	}
+}
+
+// jobAffinityRecord is the runner that last ran an operation successfully
+// for an application.
+type jobAffinityRecord struct {
+	// Id is the key from jobAffinityKey.
+	Id string
+
+	RunnerId string
+}
+
+// jobAffinityKey is the key of the runner that last ran the given
+// operation for the application.
+func jobAffinityKey(app *pb.Ref_Application, op string) string {
+	return fmt.Sprintf("%s/%s/%s", app.Project, app.Application, op)
+}
+
+// jobAffinityIndexSet sets the affinity of a job index. The preferred
+// runner of a queued job is the runner that last ran the same operation
+// for the application when it is indexed.
+func (s *State) jobAffinityIndexSet(txn *memdb.Txn, rec *jobIndex, jobpb *pb.Job) error {
+	a := jobpb.Affinity
+	if a == nil || (!a.PreferLastRunner && !a.AvoidAppRunners) {
+		return nil
+	}
+
+	rec.AffinityWait = jobAffinityWait
+	if a.Wait != "" {
+		d, err := serverptypes.JobTimeout(a.Wait)
+		if err != nil {
+			return fmt.Errorf("invalid affinity wait: %s", err)
+		}
+
+		rec.AffinityWait = d
+	}
+	rec.AffinityAvoidApp = a.AvoidAppRunners
+
+	if rec.State != pb.Job_QUEUED {
+		return nil
+	}
+
+	if a.PreferLastRunner {
+		raw, err := txn.First(jobAffinityTableName, jobAffinityIdIndexName,
+			jobAffinityKey(rec.Application, rec.Operation))
+		if err != nil {
+			return err
+		}
+		if raw != nil {
+			rec.AffinityRunnerId = raw.(*jobAffinityRecord).RunnerId
+		}
+	}
+
+	// Runners that skipped the job are woken once it can be assigned to
+	// any runner.
+	if d := rec.QueueTime.Add(rec.AffinityWait).Sub(s.clock.Now()); d > 0 {
+		id := rec.Id
+		s.timers.AfterFunc(d, func() {
+			s.jobNotify.notifyKey(jobNotifyAffinityKey(id))
+		})
+	}
+
+	return nil
+}
+
+// jobAffinityHeld returns true if the job isn't a candidate for the runner
+// yet because of its affinity. If so and w is not nil, w is notified once
+// the job can be assigned to any runner.
+func (s *State) jobAffinityHeld(
+	memTxn *memdb.Txn,
+	w *jobWaiter,
+	job *jobIndex,
+	r *pb.Runner,
+) (bool, error) {
+	if job.AffinityWait <= 0 || !s.clock.Now().Before(job.QueueTime.Add(job.AffinityWait)) {
+		return false, nil
+	}
+
+	held := false
+	if job.AffinityRunnerId != "" && !strings.EqualFold(job.AffinityRunnerId, r.Id) {
+		raw, err := memTxn.First(runnerTableName, runnerIdIndexName, job.AffinityRunnerId)
+		if err != nil {
+			return false, err
+		}
+
+		if raw != nil {
+			preferred := raw.(*runnerRecord).Runner
+			held = !preferred.Draining && !preferred.Pending
+		}
+	}
+
+	if !held && job.AffinityAvoidApp {
+		iter, err := memTxn.Get(jobAssignedTableName, jobAssignedRunnerIndexName, r.Id)
+		if err != nil {
+			return false, err
+		}
+
+		for raw := iter.Next(); raw != nil; raw = iter.Next() {
+			other := raw.(*jobAssignedIndex)
+			if strings.EqualFold(other.Project, job.Application.Project) &&
+				strings.EqualFold(other.Application, job.Application.Application) {
+				held = true
+				break
+			}
+		}
+	}
+
+	if held && w != nil {
+		w.watch(jobNotifyAffinityKey(job.Id))
+	}
+
+	return held, nil
+}
+
+// jobAffinitySet records the runner that ran the job as the runner that
+// last ran its operation for its application. This is called when the job
+// succeeds.
+func (s *State) jobAffinitySet(memTxn *memdb.Txn, job *jobIndex) error {
+	if job.AssignedRunnerId == "" {
+		return nil
+	}
+
+	return memTxn.Insert(jobAffinityTableName, &jobAffinityRecord{
+		Id:       jobAffinityKey(job.Application, job.Operation),
+		RunnerId: job.AssignedRunnerId,
+	})
+}
+
+// jobNotifyAffinityKey is the notification key for the job with the given
+// ID to be assignable to any runner it targets.
+func jobNotifyAffinityKey(id string) string {
+	return "affinity:" + id
+}
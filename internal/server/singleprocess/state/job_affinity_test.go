@@ -0,0 +1,148 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobAffinity(t *testing.T) {
+	ctx := context.Background()
+
+	// assignTimeout tries to assign a job to the runner, giving up quickly.
+	assignTimeout := func(t *testing.T, s *State, r *pb.Runner) (*Job, error) {
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		return s.JobAssignForRunner(ctx, r)
+	}
+
+	// run assigns, acks, and completes the next job for the runner.
+	run := func(t *testing.T, s *State, r *pb.Runner) *Job {
+		job, err := s.JobAssignForRunner(ctx, r)
+		require.NoError(t, err)
+		_, err = s.JobAck(ctx, job.Id, true)
+		require.NoError(t, err)
+		require.NoError(t, s.JobComplete(ctx, job.Id, nil, nil))
+		return job
+	}
+
+	t.Run("prefers the last runner", func(t *testing.T) {
+		require := require.New(t)
+
+		clock := TestClockNew()
+		s := TestState(t, WithClock(clock))
+		defer s.Close()
+
+		rA := serverptypes.TestRunner(t, &pb.Runner{Id: "R_A"})
+		rB := serverptypes.TestRunner(t, &pb.Runner{Id: "R_B"})
+		require.NoError(s.RunnerCreate(rA))
+		require.NoError(s.RunnerCreate(rB))
+
+		// R_A runs the operation first
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		require.Equal("A", run(t, s, rA).Id)
+
+		affinity := &pb.Job_Affinity{PreferLastRunner: true}
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id:       "B",
+			Affinity: affinity,
+		})))
+
+		// R_B isn't assigned the job while it waits for R_A
+		_, err := assignTimeout(t, s, rB)
+		require.Equal(context.DeadlineExceeded, err)
+
+		// R_A is
+		require.Equal("B", run(t, s, rA).Id)
+
+		// R_B is assigned the job once the wait ends
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id:       "C",
+			Affinity: affinity,
+		})))
+		resultCh := make(chan *Job, 1)
+		go func() {
+			job, err := s.JobAssignForRunner(ctx, rB)
+			if err == nil {
+				resultCh <- job
+			}
+		}()
+
+		select {
+		case <-resultCh:
+			t.Fatal("should not be assigned")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		clock.Advance(jobAffinityWait)
+		select {
+		case job := <-resultCh:
+			require.Equal("C", job.Id)
+		case <-time.After(5 * time.Second):
+			t.Fatal("should be assigned")
+		}
+	})
+
+	t.Run("doesn't wait for a deregistered runner", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		rA := serverptypes.TestRunner(t, &pb.Runner{Id: "R_A"})
+		rB := serverptypes.TestRunner(t, &pb.Runner{Id: "R_B"})
+		require.NoError(s.RunnerCreate(rA))
+		require.NoError(s.RunnerCreate(rB))
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		require.Equal("A", run(t, s, rA).Id)
+		require.NoError(s.RunnerDelete("R_A"))
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id:       "B",
+			Affinity: &pb.Job_Affinity{PreferLastRunner: true},
+		})))
+
+		job, err := assignTimeout(t, s, rB)
+		require.NoError(err)
+		require.Equal("B", job.Id)
+	})
+
+	t.Run("avoids runners running jobs for the same app", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		rA := serverptypes.TestRunner(t, &pb.Runner{Id: "R_A"})
+		rB := serverptypes.TestRunner(t, &pb.Runner{Id: "R_B"})
+		require.NoError(s.RunnerCreate(rA))
+		require.NoError(s.RunnerCreate(rB))
+
+		// R_A is running a job for the app
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		job, err := s.JobAssignForRunner(ctx, rA)
+		require.NoError(err)
+		_, err = s.JobAck(ctx, job.Id, true)
+		require.NoError(err)
+
+		// A job for another workspace of the app so it isn't blocked
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "B",
+			Workspace: &pb.Ref_Workspace{Workspace: "other"},
+			Affinity:  &pb.Job_Affinity{AvoidAppRunners: true},
+		})))
+
+		_, err = assignTimeout(t, s, rA)
+		require.Equal(context.DeadlineExceeded, err)
+
+		job, err = assignTimeout(t, s, rB)
+		require.NoError(err)
+		require.Equal("B", job.Id)
+	})
+}
@@ -0,0 +1,120 @@
+package state
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// JobApprove records the approval of a queued job by the given user. The
+// user must have one of the roles allowed by the job's approval
+// requirement and can't be the user that queued the job. Each user can
+// approve a job once. Once the job has the
+// required number of approvals, it becomes assignable. If rev is set, the
+// job is only approved if that is its current revision.
+func (s *State) JobApprove(id, user string, roles []string, rev uint64) (*Job, error) {
+	txn := s.inmemWriteTxn()
+	defer txn.Abort()
+
+	// Get the job
+	raw, err := txn.First(jobTableName, jobIdIndexName, id)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, status.Errorf(codes.NotFound, "job not found: %s", id)
+	}
+	if err := s.jobRevisionCheck(id, rev); err != nil {
+		return nil, err
+	}
+	job := raw.(*jobIndex).Copy()
+
+	if job.State != pb.Job_QUEUED {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"job can't be approved from state: %s",
+			job.State.String())
+	}
+
+	result, err := s.jobReadAndUpdate(context.Background(), job.Id, func(jobpb *pb.Job) error {
+		req := jobpb.ApprovalRequired
+		if req == nil {
+			return status.Errorf(codes.FailedPrecondition,
+				"job doesn't require approval")
+		}
+
+		if jobpb.QueuedBy != "" && jobpb.QueuedBy == user {
+			return status.Errorf(codes.PermissionDenied,
+				"user %q queued this job and can't approve it", user)
+		}
+
+		if !jobApprovalRoleAllowed(req.Roles, roles) {
+			return status.Errorf(codes.PermissionDenied,
+				"user %q doesn't have a role that can approve this job", user)
+		}
+
+		for _, a := range jobpb.Approvals {
+			if a.User == user {
+				return status.Errorf(codes.AlreadyExists,
+					"user %q already approved this job", user)
+			}
+		}
+
+		now, err := ptypes.TimestampProto(s.clock.Now())
+		if err != nil {
+			// This should never happen since encoding a time now should be safe
+			panic("time encoding failed: " + err.Error())
+		}
+
+		jobpb.Approvals = append(jobpb.Approvals, &pb.Job_Approval{
+			User: user,
+			Time: now,
+		})
+		job.ApprovalPending = jobApprovalPending(jobpb)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Insert to update
+	if err := txn.Insert(jobTableName, job); err != nil {
+		return nil, err
+	}
+	txn.Commit()
+
+	// If the job is now approved, wake a runner to take it.
+	if !job.ApprovalPending {
+		s.jobNotifyQueued(job)
+	}
+
+	return job.Job(result), nil
+}
+
+// jobApprovalPending returns true if the job requires approvals that it
+// doesn't have yet.
+func jobApprovalPending(jobpb *pb.Job) bool {
+	req := jobpb.ApprovalRequired
+	return req != nil && uint32(len(jobpb.Approvals)) < req.Approvals
+}
+
+// jobApprovalRoleAllowed returns true if any of the roles is allowed.
+// If there are no allowed roles, all users are allowed.
+func jobApprovalRoleAllowed(allowed, roles []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	for _, a := range allowed {
+		for _, r := range roles {
+			if a == r {
+				return true
+			}
+		}
+	}
+
+	return false
+}
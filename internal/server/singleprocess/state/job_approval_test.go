@@ -0,0 +1,157 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobApprove(t *testing.T) {
+	t.Run("job is assigned once approved", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id: "A",
+			ApprovalRequired: &pb.Job_ApprovalRequirement{
+				Approvals: 2,
+				Roles:     []string{"approver"},
+			},
+		})))
+
+		// Not assignable yet
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.Error(err)
+		require.Nil(job)
+
+		// Wait for assignment in the background
+		jobCh := make(chan *Job, 1)
+		go func() {
+			job, err := s.JobAssignForRunner(context.Background(), &pb.Runner{Id: "R_A"})
+			if err == nil {
+				jobCh <- job
+			}
+		}()
+
+		// Users without a role can't approve
+		_, err = s.JobApprove("A", "alice", []string{"viewer"}, 0)
+		require.Error(err)
+		require.Equal(codes.PermissionDenied, status.Code(err))
+
+		// First approval
+		job, err = s.JobApprove("A", "alice", []string{"viewer", "approver"}, 0)
+		require.NoError(err)
+		require.Len(job.Approvals, 1)
+		require.Equal("alice", job.Approvals[0].User)
+
+		// The same user can't approve twice
+		_, err = s.JobApprove("A", "alice", []string{"approver"}, 0)
+		require.Error(err)
+		require.Equal(codes.AlreadyExists, status.Code(err))
+
+		select {
+		case <-jobCh:
+			t.Fatal("job should not be assigned yet")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		// Second approval
+		_, err = s.JobApprove("A", "bob", []string{"approver"}, 0)
+		require.NoError(err)
+
+		select {
+		case job := <-jobCh:
+			require.Equal("A", job.Id)
+			require.Len(job.Approvals, 2)
+		case <-time.After(2 * time.Second):
+			t.Fatal("job should be assigned")
+		}
+
+		// Can't approve once it isn't queued
+		_, err = s.JobApprove("A", "carol", []string{"approver"}, 0)
+		require.Error(err)
+		require.Equal(codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("job without a requirement", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id: "A",
+		})))
+
+		_, err := s.JobApprove("A", "alice", nil, 0)
+		require.Error(err)
+		require.Equal(codes.FailedPrecondition, status.Code(err))
+
+		_, err = s.JobApprove("B", "alice", nil, 0)
+		require.Error(err)
+		require.Equal(codes.NotFound, status.Code(err))
+	})
+
+	t.Run("the user that queued the job can't approve it", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:       "A",
+			QueuedBy: "alice",
+			ApprovalRequired: &pb.Job_ApprovalRequirement{
+				Approvals: 1,
+			},
+		})))
+
+		_, err := s.JobApprove("A", "alice", nil, 0)
+		require.Error(err)
+		require.Equal(codes.PermissionDenied, status.Code(err))
+
+		job, err := s.JobApprove("A", "bob", nil, 0)
+		require.NoError(err)
+		require.Len(job.Approvals, 1)
+	})
+
+	t.Run("pending approval survives restart", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id: "A",
+			ApprovalRequired: &pb.Job_ApprovalRequirement{
+				Approvals: 1,
+			},
+		})))
+
+		s = TestStateReinit(t, s)
+		defer s.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.Error(err)
+
+		_, err = s.JobApprove("A", "alice", nil, 0)
+		require.NoError(err)
+
+		job, err := s.JobAssignForRunner(context.Background(), &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		require.Equal("A", job.Id)
+	})
+}
@@ -0,0 +1,114 @@
+package state
+
+import (
+	"bytes"
+
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to job artifacts. Artifacts are small
+// named blobs such as reports or plan output that a runner attaches to a
+// job when it completes. They're only read when a completed job is read so
+// they're not indexed in memory.
+
+var jobArtifactBucket = []byte("job_artifact")
+
+// jobArtifactMaxSize is the maximum size of the data of a single artifact.
+const jobArtifactMaxSize = 1024 * 1024
+
+func init() {
+	dbBuckets = append(dbBuckets, jobArtifactBucket)
+}
+
+// JobArtifactsPut attaches the artifacts to the job. The job must exist
+// and not be complete yet. Artifact names must be unique for the job and
+// an artifact with the same name as an existing artifact replaces it.
+func (s *State) JobArtifactsPut(id string, artifacts []*pb.JobArtifact) error {
+	if len(artifacts) == 0 {
+		return nil
+	}
+
+	names := map[string]struct{}{}
+	for _, a := range artifacts {
+		if a.Name == "" {
+			return status.Errorf(codes.FailedPrecondition,
+				"artifact name must be set")
+		}
+		if _, ok := names[a.Name]; ok {
+			return status.Errorf(codes.FailedPrecondition,
+				"duplicate artifact name: %s", a.Name)
+		}
+		names[a.Name] = struct{}{}
+
+		if len(a.Data) > jobArtifactMaxSize {
+			return status.Errorf(codes.FailedPrecondition,
+				"artifact %q is larger than the maximum size of %d bytes",
+				a.Name, jobArtifactMaxSize)
+		}
+	}
+
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	raw, err := memTxn.First(jobTableName, jobIdIndexName, id)
+	if err != nil {
+		return err
+	}
+	if raw == nil {
+		return status.Errorf(codes.NotFound, "job not found: %s", id)
+	}
+	switch raw.(*jobIndex).State {
+	case pb.Job_SUCCESS, pb.Job_ERROR:
+		return status.Errorf(codes.FailedPrecondition,
+			"job is already complete: %s", id)
+	}
+
+	now, err := ptypes.TimestampProto(s.clock.Now())
+	if err != nil {
+		return err
+	}
+
+	return s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		b := dbTxn.Bucket(jobArtifactBucket)
+		for _, a := range artifacts {
+			a = proto.Clone(a).(*pb.JobArtifact)
+			a.CreateTime = now
+			if err := dbPut(b, jobArtifactId(id, a.Name), a); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// JobArtifacts returns the artifacts attached to the job sorted by name.
+func (s *State) JobArtifacts(id string) ([]*pb.JobArtifact, error) {
+	var result []*pb.JobArtifact
+	err := s.dbView(func(dbTxn *bolt.Tx) error {
+		prefix := jobArtifactId(id, "")
+		c := dbTxn.Bucket(jobArtifactBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var value pb.JobArtifact
+			if err := proto.Unmarshal(v, &value); err != nil {
+				return err
+			}
+
+			result = append(result, &value)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+func jobArtifactId(id, name string) []byte {
+	return []byte(id + "/" + name)
+}
@@ -0,0 +1,112 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobArtifacts(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("attached artifacts are read with completed jobs", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		_, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		_, err = s.JobAck(context.Background(), "A", true)
+		require.NoError(err)
+
+		require.NoError(s.JobArtifactsPut("A", []*pb.JobArtifact{
+			{Name: "sbom.json", ContentType: "application/json", Data: []byte("{}")},
+			{Name: "plan.txt", ContentType: "text/plain", Data: []byte("plan")},
+		}))
+
+		// Not read until the job completes
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.Empty(job.Artifacts)
+
+		require.NoError(s.JobComplete(context.Background(), "A", nil, nil))
+		job, err = s.JobById("A", nil)
+		require.NoError(err)
+		require.Len(job.Artifacts, 2)
+		require.Equal("plan.txt", job.Artifacts[0].Name)
+		require.Equal("sbom.json", job.Artifacts[1].Name)
+		require.Equal([]byte("{}"), job.Artifacts[1].Data)
+		require.NotNil(job.Artifacts[1].CreateTime)
+
+		// Can't attach more once complete
+		err = s.JobArtifactsPut("A", []*pb.JobArtifact{{Name: "late"}})
+		require.Error(err)
+		require.Equal(codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("artifacts of other jobs are not read", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		for _, id := range []string{"A", "AB"} {
+			require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: id})))
+			require.NoError(s.JobArtifactsPut(id, []*pb.JobArtifact{{Name: id}}))
+		}
+
+		result, err := s.JobArtifacts("A")
+		require.NoError(err)
+		require.Len(result, 1)
+		require.Equal("A", result[0].Name)
+	})
+
+	t.Run("invalid artifacts", func(t *testing.T) {
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(t, s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+
+		cases := []struct {
+			Name      string
+			Id        string
+			Artifacts []*pb.JobArtifact
+			Code      codes.Code
+		}{
+			{"missing name", "A", []*pb.JobArtifact{{}}, codes.FailedPrecondition},
+			{
+				"duplicate name", "A",
+				[]*pb.JobArtifact{{Name: "a"}, {Name: "a"}},
+				codes.FailedPrecondition,
+			},
+			{
+				"too large", "A",
+				[]*pb.JobArtifact{{Name: "a", Data: make([]byte, jobArtifactMaxSize+1)}},
+				codes.FailedPrecondition,
+			},
+			{"unknown job", "B", []*pb.JobArtifact{{Name: "a"}}, codes.NotFound},
+		}
+
+		for _, tt := range cases {
+			t.Run(tt.Name, func(t *testing.T) {
+				require := require.New(t)
+
+				err := s.JobArtifactsPut(tt.Id, tt.Artifacts)
+				require.Error(err)
+				require.Equal(tt.Code, status.Code(err))
+			})
+		}
+
+		result, err := s.JobArtifacts("A")
+		require.NoError(t, err)
+		require.Empty(t, result)
+	})
+}
@@ -11,9 +11,10 @@ import (
 // This file has the methods related to tracking assigned jobs. I pulled this
 // out into a separate file since the job queueing logic is already quite long.
 
-// blockOps are the operation types that can NOT be run in parallel. Anything
-// in this list will block if any other operation in this list is running
-// for the app and workspace.
+// blockOps are the operation types that can NOT be run in parallel by
+// default. Anything in this list will block if any other operation in this
+// list is running for the app and workspace. This can be changed with
+// JobConflictsSet, see job_conflicts.go.
 var blockOps = map[reflect.Type]struct{}{
 	reflect.TypeOf((*pb.Job_Deploy)(nil)):  {},
 	reflect.TypeOf((*pb.Job_Destroy)(nil)): {},
@@ -25,8 +26,10 @@ func init() {
 }
 
 const (
-	jobAssignedTableName   = "jobs-assigned"
-	jobAssignedIdIndexName = "id"
+	jobAssignedTableName          = "jobs-assigned"
+	jobAssignedIdIndexName        = "id"
+	jobAssignedWorkspaceIndexName = "workspace"
+	jobAssignedRunnerIndexName    = "runner"
 )
 
 func jobAssignedSchema() *memdb.TableSchema {
@@ -53,9 +56,47 @@ func jobAssignedSchema() *memdb.TableSchema {
 							Field:     "Workspace",
 							Lowercase: true,
 						},
+
+						&memdb.StringFieldIndex{
+							Field: "JobId",
+						},
+					},
+				},
+			},
+
+			jobAssignedWorkspaceIndexName: {
+				Name:         jobAssignedWorkspaceIndexName,
+				AllowMissing: false,
+				Unique:       false,
+				Indexer: &memdb.CompoundIndex{
+					Indexes: []memdb.Indexer{
+						&memdb.StringFieldIndex{
+							Field:     "Project",
+							Lowercase: true,
+						},
+
+						&memdb.StringFieldIndex{
+							Field:     "Application",
+							Lowercase: true,
+						},
+
+						&memdb.StringFieldIndex{
+							Field:     "Workspace",
+							Lowercase: true,
+						},
 					},
 				},
 			},
+
+			jobAssignedRunnerIndexName: {
+				Name:         jobAssignedRunnerIndexName,
+				AllowMissing: true,
+				Unique:       false,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "RunnerId",
+					Lowercase: true,
+				},
+			},
 		},
 	}
 }
@@ -64,55 +105,98 @@ type jobAssignedIndex struct {
 	Project     string
 	Application string
 	Workspace   string
+
+	// JobId is the job that is assigned and OpType is its operation type.
+	JobId  string
+	OpType reflect.Type
+
+	// RunnerId is the runner the job is assigned to. See
+	// runner_concurrency.go.
+	RunnerId string
 }
 
 // jobIsBlocked will return true if the given job is currently blocked because
-// a job with the same project/app/workspace is executing.
+// a job with the same project/app/workspace is executing or because a job it
+// depends on hasn't succeeded yet.
 //
 // If ws is set then a watch will be added for any changes in assigned jobs.
 // Note that this trigger doesn't mean that the blocking is necessarily gone
 // but something changed to warrant rechecking.
 func (s *State) jobIsBlocked(memTxn *memdb.Txn, idx *jobIndex, ws memdb.WatchSet) (bool, error) {
+	id, err := s.jobBlockedBy(memTxn, idx, ws)
+	return id != "", err
+}
+
+// jobBlockedBy returns the ID of the job that the given job is blocked by,
+// or an empty string if it isn't blocked. See jobIsBlocked.
+func (s *State) jobBlockedBy(memTxn *memdb.Txn, idx *jobIndex, ws memdb.WatchSet) (string, error) {
+	// Dependencies block every operation type.
+	if id, err := s.jobDependencyBlockedBy(memTxn, idx, ws); err != nil || id != "" {
+		return id, err
+	}
+
 	// If this job represents a parallelizable operation type, then allow it.
-	if _, ok := blockOps[idx.OpType]; !ok {
-		return false, nil
+	conflicts, err := s.jobConflictsGet(memTxn)
+	if err != nil {
+		return "", err
+	}
+	if len(conflicts.Types[idx.OpType]) == 0 {
+		return "", nil
 	}
 
 	// Look for this project/app/ws combo
-	watchCh, value, err := memTxn.FirstWatch(
+	iter, err := memTxn.Get(
 		jobAssignedTableName,
-		jobAssignedIdIndexName,
+		jobAssignedWorkspaceIndexName,
 		idx.Application.Project,
 		idx.Application.Application,
 		idx.Workspace.Workspace,
 	)
 	if err != nil {
-		return false, err
+		return "", err
 	}
 	if ws != nil {
-		ws.Add(watchCh)
+		ws.Add(iter.WatchCh())
+	}
+
+	// Blocked if we have a record for a conflicting operation
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		rec := raw.(*jobAssignedIndex)
+		if rec.JobId != idx.Id && conflicts.conflicts(idx.OpType, rec.OpType) {
+			return rec.JobId, nil
+		}
 	}
 
-	// Blocked if we have a record
-	return value != nil, nil
+	return "", nil
 }
 
-// jobAssignedSet records the given job as assigned.
+// jobAssignedSet records the given job as assigned. Jobs of every operation
+// type are recorded since the conflicts can change while they're assigned.
 func (s *State) jobAssignedSet(memTxn *memdb.Txn, idx *jobIndex, assigned bool) error {
-	// If this job represents a parallelizable operation type, then do nothing.
-	if _, ok := blockOps[idx.OpType]; !ok {
-		return nil
-	}
-
 	rec := &jobAssignedIndex{
 		Project:     idx.Application.Project,
 		Application: idx.Application.Application,
 		Workspace:   idx.Workspace.Workspace,
+		JobId:       idx.Id,
+		OpType:      idx.OpType,
+		RunnerId:    idx.AssignedRunnerId,
 	}
 
 	if assigned {
 		return memTxn.Insert(jobAssignedTableName, rec)
 	}
 
+	// Wake any runners waiting on jobs blocked by this one once the
+	// change is committed.
+	key := jobNotifyBlockKey(idx.Application, idx.Workspace)
+	memTxn.Defer(func() { s.jobNotify.notifyKey(key) })
+
+	// The resources the job required are released, so the runner it was
+	// assigned may now fit jobs it couldn't before. See job_resources.go.
+	if idx.Resources != nil && idx.AssignedRunnerId != "" {
+		runnerKey := jobNotifyRunnerKey(idx.AssignedRunnerId)
+		memTxn.Defer(func() { s.jobNotify.notifyKey(runnerKey) })
+	}
+
 	return memTxn.Delete(jobAssignedTableName, rec)
 }
@@ -0,0 +1,78 @@
+package state
+
+import (
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// jobCacheSize is the number of unmarshalled jobs to keep in memory.
+// Read APIs such as JobById are called frequently by clients polling for
+// job status so this avoids a disk read and unmarshal for hot jobs.
+const jobCacheSize = 256
+
+// jobByIdCached returns the job with the given ID, using the cache if
+// possible. The result is always a copy that is safe to modify.
+//
+// This must only be used for reads. Writes must read the job within their
+// write transaction with jobById so that they always see the latest value.
+func (s *State) jobByIdCached(id string) (*pb.Job, error) {
+	if raw, ok := s.jobCache.Get(id); ok {
+		return proto.Clone(raw.(*pb.Job)).(*pb.Job), nil
+	}
+
+	// Note the generation before we read. If any job is written while we
+	// read then we don't cache our result since it may be stale.
+	s.jobCacheLock.Lock()
+	gen := s.jobCacheGen
+	s.jobCacheLock.Unlock()
+
+	var job *pb.Job
+	err := s.dbView(func(dbTxn *bolt.Tx) error {
+		var err error
+		job, err = s.jobById(dbTxn, id)
+		return err
+	})
+	if err != nil {
+		return job, err
+	}
+
+	s.jobCacheLock.Lock()
+	if gen == s.jobCacheGen {
+		s.jobCache.Add(id, proto.Clone(job))
+	}
+	s.jobCacheLock.Unlock()
+
+	return job, nil
+}
+
+// jobCacheSet updates the cache with the given job. This must be called
+// after every write of a job once the write is committed.
+func (s *State) jobCacheSet(job *pb.Job) {
+	s.jobCacheLock.Lock()
+	defer s.jobCacheLock.Unlock()
+
+	s.jobCacheGen++
+	s.jobCache.Add(job.Id, proto.Clone(job))
+}
+
+// jobCacheDelete removes the job with the given ID from the cache. This
+// must be called once a job is removed from the database.
+func (s *State) jobCacheDelete(id string) {
+	s.jobCacheLock.Lock()
+	defer s.jobCacheLock.Unlock()
+
+	s.jobCacheGen++
+	s.jobCache.Remove(id)
+}
+
+// jobCachePurge removes all jobs from the cache. This must be called if
+// the jobs are replaced outside of the write APIs.
+func (s *State) jobCachePurge() {
+	s.jobCacheLock.Lock()
+	defer s.jobCacheLock.Unlock()
+
+	s.jobCacheGen++
+	s.jobCache.Purge()
+}
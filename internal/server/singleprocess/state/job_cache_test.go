@@ -0,0 +1,67 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobCache(t *testing.T) {
+	t.Run("returns copies", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id: "A",
+		})))
+
+		// Modifying the result should not modify the cached value
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		job.State = pb.Job_ERROR
+
+		job, err = s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_QUEUED, job.State)
+
+		jobs, err := s.JobList()
+		require.NoError(err)
+		require.Len(jobs, 1)
+		jobs[0].State = pb.Job_ERROR
+
+		job, err = s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_QUEUED, job.State)
+	})
+
+	t.Run("updated on write", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id: "A",
+		})))
+
+		// Read to populate the cache
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_QUEUED, job.State)
+
+		// Assign, which writes the job
+		_, err = s.JobAssignForRunner(context.Background(), &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+
+		job, err = s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_WAITING, job.State)
+		require.NotNil(job.AssignTime)
+	})
+}
@@ -0,0 +1,81 @@
+package state
+
+import (
+	"strings"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+// This file has the methods related to matching jobs to the capabilities
+// that runners advertise. Runners can limit the operation types they run
+// with the Runner.Operations field, such as runners that only build, and
+// advertise their plugins with Runner.PluginVersions. Jobs name the
+// plugins they use with the Job.Plugins field. Jobs can require the
+// platform of the runner with the Job.Platform field, which is matched
+// against the Runner.Os and Runner.Arch that runners advertise. A runner
+// that doesn't advertise its platform can't run jobs that require one. A
+// job is only a candidate for a runner that can run it so it isn't
+// assigned to a runner that would reject it. A runner woken for a job it
+// can't run, such as a job for another platform, wakes another waiting
+// runner in its place. See job_notify.go.
+
+// jobCompatible returns true if the runner can run the job.
+func jobCompatible(idx *jobIndex, r *pb.Runner) bool {
+	// Jobs for a runner profile are only run by runners launched with it,
+	// and those runners only run jobs for it. See runner_profile.go.
+	if !strings.EqualFold(idx.RunnerProfile, r.Profile) {
+		return false
+	}
+
+	if idx.PlatformOS != "" && !strings.EqualFold(idx.PlatformOS, r.Os) {
+		return false
+	}
+	if idx.PlatformArch != "" && !strings.EqualFold(idx.PlatformArch, r.Arch) {
+		return false
+	}
+
+	if !jobTagsMatch(idx, r) {
+		return false
+	}
+
+	if len(r.Operations) > 0 {
+		found := false
+		for _, op := range r.Operations {
+			if strings.EqualFold(op, idx.Operation) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	// Runners that don't advertise plugins are assumed to have them all.
+	if len(r.PluginVersions) > 0 {
+		for _, name := range idx.Plugins {
+			if _, ok := r.PluginVersions[name]; !ok {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// jobPlatform returns the os and arch that the job requires. These are
+// empty if the job doesn't require a platform. Jobs are validated when
+// they're queued so an invalid platform is ignored.
+func jobPlatform(jobpb *pb.Job) (string, string) {
+	if jobpb.Platform == "" {
+		return "", ""
+	}
+
+	os, arch, err := serverptypes.JobPlatform(jobpb.Platform)
+	if err != nil {
+		return "", ""
+	}
+
+	return os, arch
+}
@@ -0,0 +1,276 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobAssignForRunner_capabilities(t *testing.T) {
+	ctx := context.Background()
+
+	// assign returns the job assigned to the runner or nil if it
+	// isn't assigned one.
+	assign := func(t *testing.T, s *State, r *pb.Runner) *Job {
+		actx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		job, err := s.JobAssignForRunner(actx, r)
+		if err == context.DeadlineExceeded {
+			return nil
+		}
+		require.NoError(t, err)
+		return job
+	}
+
+	t.Run("operations", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "A",
+			Operation: &pb.Job_Build{Build: &pb.Job_BuildOp{}},
+		})))
+
+		require.Nil(assign(t, s, &pb.Runner{Id: "R_A", Operations: []string{"deploy"}}))
+
+		job := assign(t, s, &pb.Runner{Id: "R_B", Operations: []string{"deploy", "build"}})
+		require.NotNil(job)
+		require.Equal("A", job.Id)
+	})
+
+	t.Run("wakes a waiting runner that can run the job", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		job := &pb.Job{
+			Id:        "A",
+			Operation: &pb.Job_Build{Build: &pb.Job_BuildOp{}},
+			Plugins:   []string{"nomad"},
+		}
+
+		// The runners that wait first can't run the job
+		id := testJobAssignWaiting(t, s, job,
+			&pb.Runner{Id: "R_A", Operations: []string{"deploy"}},
+			&pb.Runner{Id: "R_B", PluginVersions: map[string]string{"docker": "v0.1.0"}},
+			&pb.Runner{Id: "R_C", Operations: []string{"build"}},
+		)
+		require.Equal("R_C", id)
+	})
+
+	t.Run("plugins", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id:      "A",
+			Plugins: []string{"nomad"},
+		})))
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id:      "B",
+			Plugins: []string{"nomad"},
+		})))
+
+		require.Nil(assign(t, s, &pb.Runner{
+			Id:             "R_A",
+			PluginVersions: map[string]string{"docker": "v0.1.0"},
+		}))
+
+		job := assign(t, s, &pb.Runner{
+			Id:             "R_B",
+			PluginVersions: map[string]string{"docker": "v0.1.0", "nomad": "v0.1.0"},
+		})
+		require.NotNil(job)
+		require.Equal("A", job.Id)
+
+		// Runners that don't advertise plugins are assumed to have them
+		job = assign(t, s, &pb.Runner{Id: "R_C"})
+		require.NotNil(job)
+		require.Equal("B", job.Id)
+	})
+
+	t.Run("platform", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id:       "A",
+			Platform: "linux/arm64",
+		})))
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "B",
+			Workspace: &pb.Ref_Workspace{Workspace: "B"},
+			Platform:  "darwin",
+		})))
+
+		// Runners that don't advertise their platform aren't assigned
+		// jobs that require one
+		require.Nil(assign(t, s, &pb.Runner{Id: "R_A"}))
+		require.Nil(assign(t, s, &pb.Runner{Id: "R_B", Os: "linux", Arch: "amd64"}))
+
+		job := assign(t, s, &pb.Runner{Id: "R_C", Os: "linux", Arch: "arm64"})
+		require.NotNil(job)
+		require.Equal("A", job.Id)
+
+		// Jobs that only require an os run on any arch
+		job = assign(t, s, &pb.Runner{Id: "R_D", Os: "darwin", Arch: "arm64"})
+		require.NotNil(job)
+		require.Equal("B", job.Id)
+	})
+
+	t.Run("platform wakes a waiting runner that can run the job", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		// The runners that wait first aren't on the platform
+		id := testJobAssignWaiting(t, s, &pb.Job{Id: "A", Platform: "linux/arm64"},
+			&pb.Runner{Id: "R_A"},
+			&pb.Runner{Id: "R_B", Os: "linux", Arch: "amd64"},
+			&pb.Runner{Id: "R_C", Os: "linux", Arch: "arm64"},
+		)
+		require.Equal("R_C", id)
+	})
+
+	t.Run("resource tags", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id:           "A",
+			RequiredTags: []string{"gpu"},
+		})))
+
+		// Runners without all the tags aren't assigned the job
+		require.Nil(assign(t, s, &pb.Runner{Id: "R_A"}))
+		require.Nil(assign(t, s, &pb.Runner{Id: "R_B", ResourceTags: []string{"kvm"}}))
+
+		job := assign(t, s, &pb.Runner{Id: "R_C", ResourceTags: []string{"kvm", "GPU"}})
+		require.NotNil(job)
+		require.Equal("A", job.Id)
+	})
+
+	t.Run("resource tags wake a waiting runner that has them", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		// The runners that wait first don't have the tag
+		id := testJobAssignWaiting(t, s, &pb.Job{Id: "A", RequiredTags: []string{"gpu"}},
+			&pb.Runner{Id: "R_A"},
+			&pb.Runner{Id: "R_B", ResourceTags: []string{"kvm"}},
+			&pb.Runner{Id: "R_C", ResourceTags: []string{"GPU"}},
+		)
+		require.Equal("R_C", id)
+	})
+}
+
+func TestJobIsAssignable_capabilities(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	s := TestState(t)
+	defer s.Close()
+
+	require.NoError(s.RunnerCreate(serverptypes.TestRunner(t, &pb.Runner{
+		Operations:     []string{"build"},
+		PluginVersions: map[string]string{"docker": "v0.1.0"},
+		Os:             "linux",
+		Arch:           "amd64",
+		ResourceTags:   []string{"gpu", "large-disk"},
+	})))
+
+	for _, tc := range []struct {
+		Job      *pb.Job
+		Expected bool
+	}{
+		{&pb.Job{Operation: &pb.Job_Build{Build: &pb.Job_BuildOp{}}}, true},
+		{&pb.Job{Operation: &pb.Job_Deploy{Deploy: &pb.Job_DeployOp{}}}, false},
+		{&pb.Job{
+			Operation: &pb.Job_Build{Build: &pb.Job_BuildOp{}},
+			Plugins:   []string{"docker"},
+		}, true},
+		{&pb.Job{
+			Operation: &pb.Job_Build{Build: &pb.Job_BuildOp{}},
+			Plugins:   []string{"pack"},
+		}, false},
+		{&pb.Job{
+			Operation: &pb.Job_Build{Build: &pb.Job_BuildOp{}},
+			Platform:  "linux",
+		}, true},
+		{&pb.Job{
+			Operation: &pb.Job_Build{Build: &pb.Job_BuildOp{}},
+			Platform:  "windows/amd64",
+		}, false},
+		{&pb.Job{
+			Operation:    &pb.Job_Build{Build: &pb.Job_BuildOp{}},
+			RequiredTags: []string{"GPU", "large-disk"},
+		}, true},
+		{&pb.Job{
+			Operation:    &pb.Job_Build{Build: &pb.Job_BuildOp{}},
+			RequiredTags: []string{"gpu", "kvm"},
+		}, false},
+		{&pb.Job{
+			Operation:    &pb.Job_Build{Build: &pb.Job_BuildOp{}},
+			RequiredTags: []string{"gpu"},
+			TargetRunner: &pb.Ref_Runner{
+				Target: &pb.Ref_Runner_Labels{
+					Labels: &pb.Ref_RunnerLabels{},
+				},
+			},
+		}, true},
+	} {
+		result, err := s.JobIsAssignable(ctx, serverptypes.TestJobNew(t, tc.Job))
+		require.NoError(err)
+		require.Equal(tc.Expected, result, "%v", tc.Job)
+	}
+}
+
+// testJobAssignWaiting starts each runner waiting for an assignment in
+// order, then queues the job and returns the ID of the runner that is
+// assigned it.
+func testJobAssignWaiting(t *testing.T, s *State, job *pb.Job, runners ...*pb.Runner) string {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	idCh := make(chan string, len(runners))
+	for i, r := range runners {
+		r := r
+		go func() {
+			if _, err := s.JobAssignForRunner(ctx, r); err == nil {
+				idCh <- r.Id
+			}
+		}()
+
+		n := i + 1
+		require.Eventually(t, func() bool {
+			return testJobWaiters(s) == n
+		}, 2*time.Second, 10*time.Millisecond)
+	}
+
+	require.NoError(t, s.JobCreate(ctx, serverptypes.TestJobNew(t, job)))
+
+	select {
+	case id := <-idCh:
+		return id
+	case <-time.After(2 * time.Second):
+		t.Fatal("no runner was assigned the job")
+		return ""
+	}
+}
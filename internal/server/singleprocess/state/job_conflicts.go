@@ -0,0 +1,214 @@
+package state
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hashicorp/go-memdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to job conflicts. Jobs for the same
+// application and workspace are only blocked by each other if their
+// operation types conflict. The conflicts are stored in the state so that
+// operators can change them, and default to blockOps if they aren't set.
+
+var (
+	jobConflictsBucket = []byte("job_conflicts")
+	jobConflictsKey    = []byte("conflicts")
+)
+
+// jobOpTypes are the operation types by the name used in job conflicts.
+// These are the names of the operation fields of pb.Job.
+var jobOpTypes = map[string]reflect.Type{
+	"noop":     reflect.TypeOf((*pb.Job_Noop_)(nil)),
+	"build":    reflect.TypeOf((*pb.Job_Build)(nil)),
+	"push":     reflect.TypeOf((*pb.Job_Push)(nil)),
+	"deploy":   reflect.TypeOf((*pb.Job_Deploy)(nil)),
+	"destroy":  reflect.TypeOf((*pb.Job_Destroy)(nil)),
+	"release":  reflect.TypeOf((*pb.Job_Release)(nil)),
+	"validate": reflect.TypeOf((*pb.Job_Validate)(nil)),
+	"auth":     reflect.TypeOf((*pb.Job_Auth)(nil)),
+	"docs":     reflect.TypeOf((*pb.Job_Docs)(nil)),
+}
+
+func init() {
+	dbBuckets = append(dbBuckets, jobConflictsBucket)
+	dbIndexers = append(dbIndexers, (*State).jobConflictsIndexInit)
+	schemas = append(schemas, jobConflictsIndexSchema)
+}
+
+// JobConflictsSet sets the operation types that conflict. Queued jobs that
+// were blocked by jobs that no longer conflict can be assigned once this
+// returns.
+func (s *State) JobConflictsSet(c *pb.JobConflicts) error {
+	for _, rule := range c.Rules {
+		for _, op := range rule.Operations {
+			if _, ok := jobOpTypes[op]; !ok {
+				return status.Errorf(codes.FailedPrecondition,
+					"unknown operation type: %q", op)
+			}
+		}
+	}
+
+	memTxn := s.inmemWriteTxn()
+	defer memTxn.Abort()
+
+	err := s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		return dbPut(dbTxn.Bucket(jobConflictsBucket), jobConflictsKey, c)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.jobConflictsIndexSet(memTxn, c); err != nil {
+		return err
+	}
+
+	// Wake the runners that skipped jobs blocked by an assigned job since
+	// the jobs may no longer conflict.
+	iter, err := memTxn.Get(jobAssignedTableName, jobAssignedIdIndexName+"_prefix", "")
+	if err != nil {
+		return err
+	}
+	keys := map[string]struct{}{}
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		rec := raw.(*jobAssignedIndex)
+		keys[jobNotifyBlockKey(
+			&pb.Ref_Application{Project: rec.Project, Application: rec.Application},
+			&pb.Ref_Workspace{Workspace: rec.Workspace},
+		)] = struct{}{}
+	}
+	memTxn.Defer(func() {
+		for k := range keys {
+			s.jobNotify.notifyKey(k)
+		}
+	})
+
+	memTxn.Commit()
+	return nil
+}
+
+// JobConflictsGet returns the operation types that conflict. If they were
+// never set, the default conflicts are returned.
+func (s *State) JobConflictsGet() (*pb.JobConflicts, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	rec, err := s.jobConflictsGet(memTxn)
+	if err != nil {
+		return nil, err
+	}
+
+	return proto.Clone(rec.Conflicts).(*pb.JobConflicts), nil
+}
+
+// jobConflictsGet returns the current conflicts.
+func (s *State) jobConflictsGet(memTxn *memdb.Txn) (*jobConflictsIndexRecord, error) {
+	raw, err := memTxn.First(jobConflictsIndexTableName, jobConflictsIndexIdIndexName, string(jobConflictsKey))
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return jobConflictsDefault, nil
+	}
+
+	return raw.(*jobConflictsIndexRecord), nil
+}
+
+// jobConflictsDefault are the conflicts if they were never set. The
+// operations in blockOps all conflict with each other.
+var jobConflictsDefault = func() *jobConflictsIndexRecord {
+	var ops []string
+	for name, t := range jobOpTypes {
+		if _, ok := blockOps[t]; ok {
+			ops = append(ops, name)
+		}
+	}
+	sort.Strings(ops)
+
+	return newJobConflictsIndexRecord(&pb.JobConflicts{
+		Rules: []*pb.JobConflicts_Rule{{Operations: ops}},
+	})
+}()
+
+// jobConflictsIndexSet writes the index record for the conflicts.
+func (s *State) jobConflictsIndexSet(txn *memdb.Txn, value *pb.JobConflicts) error {
+	return txn.Insert(jobConflictsIndexTableName, newJobConflictsIndexRecord(value))
+}
+
+// jobConflictsIndexInit initializes the conflicts index from persisted data.
+func (s *State) jobConflictsIndexInit(dbTxn *bolt.Tx, memTxn *memdb.Txn) error {
+	data := dbTxn.Bucket(jobConflictsBucket).Get(jobConflictsKey)
+	if data == nil {
+		return nil
+	}
+
+	var value pb.JobConflicts
+	if err := proto.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	return s.jobConflictsIndexSet(memTxn, &value)
+}
+
+func jobConflictsIndexSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: jobConflictsIndexTableName,
+		Indexes: map[string]*memdb.IndexSchema{
+			jobConflictsIndexIdIndexName: {
+				Name:         jobConflictsIndexIdIndexName,
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field: "Id",
+				},
+			},
+		},
+	}
+}
+
+const (
+	jobConflictsIndexTableName   = "job-conflicts-index"
+	jobConflictsIndexIdIndexName = "id"
+)
+
+type jobConflictsIndexRecord struct {
+	Id        string
+	Conflicts *pb.JobConflicts
+
+	// Types are the operation types that each operation type conflicts with.
+	Types map[reflect.Type]map[reflect.Type]struct{}
+}
+
+func newJobConflictsIndexRecord(c *pb.JobConflicts) *jobConflictsIndexRecord {
+	types := map[reflect.Type]map[reflect.Type]struct{}{}
+	for _, rule := range c.Rules {
+		for _, a := range rule.Operations {
+			for _, b := range rule.Operations {
+				ta, tb := jobOpTypes[a], jobOpTypes[b]
+				if types[ta] == nil {
+					types[ta] = map[reflect.Type]struct{}{}
+				}
+				types[ta][tb] = struct{}{}
+			}
+		}
+	}
+
+	return &jobConflictsIndexRecord{
+		Id:        string(jobConflictsKey),
+		Conflicts: c,
+		Types:     types,
+	}
+}
+
+// conflicts returns true if jobs of the two operation types conflict.
+func (r *jobConflictsIndexRecord) conflicts(a, b reflect.Type) bool {
+	_, ok := r.Types[a][b]
+	return ok
+}
@@ -0,0 +1,121 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobConflicts(t *testing.T) {
+	ctx := context.Background()
+
+	deploy := &pb.Job_Deploy{Deploy: &pb.Job_DeployOp{}}
+	release := &pb.Job_Release{Release: &pb.Job_ReleaseOp{}}
+
+	t.Run("defaults", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		c, err := s.JobConflictsGet()
+		require.NoError(err)
+		require.Len(c.Rules, 1)
+		require.Equal([]string{"deploy", "destroy", "release"}, c.Rules[0].Operations)
+	})
+
+	t.Run("unknown operation", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		err := s.JobConflictsSet(&pb.JobConflicts{
+			Rules: []*pb.JobConflicts_Rule{{Operations: []string{"nope"}}},
+		})
+		require.Equal(codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("operations that don't conflict run at once", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		// Only deploys conflict with each other
+		require.NoError(s.JobConflictsSet(&pb.JobConflicts{
+			Rules: []*pb.JobConflicts_Rule{{Operations: []string{"deploy"}}},
+		}))
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A", Operation: deploy})))
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "B", Operation: release})))
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "C", Operation: deploy})))
+
+		// The deploy and release are assigned
+		for _, id := range []string{"A", "B"} {
+			job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+			require.NoError(err)
+			require.Equal(id, job.Id)
+		}
+
+		// The second deploy is blocked
+		job, err := s.JobById("C", nil)
+		require.NoError(err)
+		require.True(job.Blocked)
+		require.Equal("A", job.BlockedBy)
+	})
+
+	t.Run("changing conflicts unblocks jobs", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A", Operation: deploy})))
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "B", Operation: release})))
+
+		job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		require.Equal("A", job.Id)
+
+		// The release is blocked by default
+		doneCh := make(chan *Job, 1)
+		go func() {
+			job, _ := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_B"})
+			doneCh <- job
+		}()
+
+		select {
+		case <-doneCh:
+			t.Fatal("release should be blocked")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		require.NoError(s.JobConflictsSet(&pb.JobConflicts{}))
+
+		select {
+		case job := <-doneCh:
+			require.NotNil(job)
+			require.Equal("B", job.Id)
+
+		case <-time.After(1 * time.Second):
+			t.Fatal("waiting runner should be assigned the release")
+		}
+
+		// The conflicts are persisted
+		s2, err := TestStateRestart(t, s)
+		require.NoError(err)
+		defer s2.Close()
+
+		c, err := s2.JobConflictsGet()
+		require.NoError(err)
+		require.Empty(c.Rules)
+	})
+}
@@ -0,0 +1,120 @@
+package state
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-memdb"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to preferring cheap runners. Runners
+// advertise the relative cost of running jobs on them with the Runner.Cost
+// field, such as 1 for spot capacity and 3 for on-demand capacity. If the
+// server prefers cheap runners, a job that targets any runner wakes the
+// cheapest waiting runner, and a job isn't a candidate for a runner while
+// a cheaper runner that can run it is waiting for a job.
+//
+// Jobs never wait for cheap capacity that is busy: a job is only held for
+// cheaper runners that are free right now, and only until it has been
+// queued for jobCostWait in case the cheaper runner doesn't take it.
+
+// jobCostWait is the longest a job is held for a cheaper runner.
+const jobCostWait = 10 * time.Second
+
+// JobPreferCheapSet sets whether jobs are assigned to the cheapest waiting
+// runner that can run them. This is disabled by default. This should be
+// called once before the state is used.
+func (s *State) JobPreferCheapSet(enabled bool) {
+	s.jobPreferCheap = enabled
+	s.jobNotify.preferCheap = enabled
+}
+
+// jobCostIndexSet wakes the runners that skipped the queued job for a
+// cheaper runner once it can be assigned to any runner.
+func (s *State) jobCostIndexSet(rec *jobIndex) {
+	if !s.jobPreferCheap || rec.State != pb.Job_QUEUED {
+		return
+	}
+
+	if d := rec.QueueTime.Add(jobCostWait).Sub(s.clock.Now()); d > 0 {
+		id := rec.Id
+		s.timers.AfterFunc(d, func() {
+			s.jobNotify.notifyKey(jobNotifyCostKey(id))
+		})
+	}
+}
+
+// jobCostHeld returns true if the job isn't a candidate for the runner yet
+// because a cheaper runner that can run it is waiting for a job. The
+// cheaper runner is woken in case the job didn't notify it. If the job is
+// held and w is not nil, w is notified once the job can be assigned to
+// any runner.
+func (s *State) jobCostHeld(
+	memTxn *memdb.Txn,
+	w *jobWaiter,
+	job *jobIndex,
+	r *pb.Runner,
+) (bool, error) {
+	if !s.jobPreferCheap || !s.clock.Now().Before(job.QueueTime.Add(jobCostWait)) {
+		return false, nil
+	}
+
+	held := false
+	for _, id := range s.jobNotify.cheaper(runnerCost(r)) {
+		if strings.EqualFold(id, r.Id) {
+			continue
+		}
+
+		raw, err := memTxn.First(runnerTableName, runnerIdIndexName, id)
+		if err != nil {
+			return false, err
+		}
+		if raw == nil {
+			continue
+		}
+
+		other := raw.(*runnerRecord).Runner
+		if !job.targets(other) || !jobCompatible(job, other) {
+			continue
+		}
+		if fits, err := s.jobFits(memTxn, job, other); err != nil {
+			return false, err
+		} else if !fits {
+			continue
+		}
+		if _, full, err := s.runnerFull(memTxn, other); err != nil {
+			return false, err
+		} else if full {
+			continue
+		}
+
+		held = true
+		s.jobNotify.notifyKey(jobNotifyRunnerKey(other.Id))
+		break
+	}
+
+	if held && w != nil {
+		w.watch(jobNotifyCostKey(job.Id))
+	}
+
+	return held, nil
+}
+
+// runnerCost returns the cost of the runner. Runners that don't advertise
+// a cost are the most expensive.
+func runnerCost(r *pb.Runner) float64 {
+	if r.Cost <= 0 {
+		return math.Inf(1)
+	}
+
+	return r.Cost
+}
+
+// jobNotifyCostKey is the notification key for the job with the given ID
+// to be assignable to runners of any cost.
+func jobNotifyCostKey(id string) string {
+	return "cost:" + id
+}
@@ -0,0 +1,169 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobPreferCheap(t *testing.T) {
+	ctx := context.Background()
+
+	// wait starts assigning a job to each runner in order so that they
+	// start waiting in that order, and returns the channel of the runner
+	// that is assigned the job.
+	wait := func(s *State, ctx context.Context, rs ...*pb.Runner) <-chan string {
+		ch := make(chan string, len(rs))
+		for _, r := range rs {
+			r := r
+			go func() {
+				job, err := s.JobAssignForRunner(ctx, r)
+				if err == nil {
+					ch <- r.Id + ":" + job.Id
+				}
+			}()
+
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		return ch
+	}
+
+	t.Run("wakes the cheapest waiting runner", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		s.JobPreferCheapSet(true)
+
+		expensive := serverptypes.TestRunner(t, &pb.Runner{Id: "R_A", Cost: 3})
+		unset := serverptypes.TestRunner(t, &pb.Runner{Id: "R_B"})
+		cheap := serverptypes.TestRunner(t, &pb.Runner{Id: "R_C", Cost: 1})
+		require.NoError(s.RunnerCreate(expensive))
+		require.NoError(s.RunnerCreate(unset))
+		require.NoError(s.RunnerCreate(cheap))
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		ch := wait(s, ctx, unset, expensive, cheap)
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		select {
+		case v := <-ch:
+			require.Equal("R_C:A", v)
+		case <-time.After(5 * time.Second):
+			t.Fatal("should be assigned")
+		}
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "B"})))
+		select {
+		case v := <-ch:
+			require.Equal("R_A:B", v)
+		case <-time.After(5 * time.Second):
+			t.Fatal("should be assigned")
+		}
+	})
+
+	t.Run("wakes the first waiting runner by default", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		expensive := serverptypes.TestRunner(t, &pb.Runner{Id: "R_A", Cost: 3})
+		cheap := serverptypes.TestRunner(t, &pb.Runner{Id: "R_B", Cost: 1})
+		require.NoError(s.RunnerCreate(expensive))
+		require.NoError(s.RunnerCreate(cheap))
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		ch := wait(s, ctx, expensive, cheap)
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		select {
+		case v := <-ch:
+			require.Equal("R_A:A", v)
+		case <-time.After(5 * time.Second):
+			t.Fatal("should be assigned")
+		}
+	})
+
+	t.Run("holds jobs for a waiting cheaper runner", func(t *testing.T) {
+		require := require.New(t)
+
+		clock := TestClockNew()
+		s := TestState(t, WithClock(clock))
+		defer s.Close()
+		s.JobPreferCheapSet(true)
+
+		labels := map[string]string{"env": "prod"}
+		expensive := serverptypes.TestRunner(t, &pb.Runner{Id: "R_A", Cost: 3, Labels: labels})
+		cheap := serverptypes.TestRunner(t, &pb.Runner{Id: "R_B", Cost: 1, Labels: labels})
+		require.NoError(s.RunnerCreate(expensive))
+		require.NoError(s.RunnerCreate(cheap))
+
+		// The cheap runner is waiting for a job but doesn't take one
+		w := s.jobNotify.register(newRunnerRecord(cheap))
+		defer s.jobNotify.unregister(w)
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		ch := wait(s, ctx, expensive)
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id: "A",
+			TargetRunner: &pb.Ref_Runner{
+				Target: &pb.Ref_Runner_Labels{
+					Labels: &pb.Ref_RunnerLabels{Labels: labels},
+				},
+			},
+		})))
+
+		// The cheap runner is woken but the expensive runner isn't assigned
+		// the job until the wait ends
+		select {
+		case <-w.ch:
+		case <-time.After(5 * time.Second):
+			t.Fatal("cheap runner should be woken")
+		}
+		select {
+		case <-ch:
+			t.Fatal("should not be assigned")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		clock.Advance(jobCostWait)
+		select {
+		case v := <-ch:
+			require.Equal("R_A:A", v)
+		case <-time.After(5 * time.Second):
+			t.Fatal("should be assigned")
+		}
+	})
+
+	t.Run("doesn't hold jobs for busy cheaper runners", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		s.JobPreferCheapSet(true)
+
+		expensive := serverptypes.TestRunner(t, &pb.Runner{Id: "R_A", Cost: 3})
+		cheap := serverptypes.TestRunner(t, &pb.Runner{Id: "R_B", Cost: 1})
+		require.NoError(s.RunnerCreate(expensive))
+		require.NoError(s.RunnerCreate(cheap))
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		job, err := s.JobAssignForRunner(ctx, expensive)
+		require.NoError(err)
+		require.Equal("A", job.Id)
+	})
+}
@@ -0,0 +1,117 @@
+package state
+
+import (
+	"context"
+
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to the dead-letter queue. Jobs that
+// runners don't accept more than a number of times move to the DEAD_LETTER
+// state rather than cycling between runners forever. They stay there until
+// they're requeued or cancelled.
+
+// jobMaxNacks is the default number of times a job can be not accepted by
+// runners before it is dead-lettered. This is a var so tests can set it.
+var jobMaxNacks = 5
+
+// JobMaxNacksSet sets the number of times a job can be not accepted by
+// runners, whether rejected or not accepted in time, before it moves to
+// the DEAD_LETTER state. A max of zero or less uses the default of 5. This
+// should be called once before the state is used.
+func (s *State) JobMaxNacksSet(max int) {
+	s.jobMaxNacks = max
+}
+
+// jobNackLimit returns the number of times a job can be not accepted.
+func (s *State) jobNackLimit() uint32 {
+	if s.jobMaxNacks > 0 {
+		return uint32(s.jobMaxNacks)
+	}
+
+	return uint32(jobMaxNacks)
+}
+
+// JobNack records that the runner assigned the job didn't accept it for
+// the given reason. The job is queued again or, if it hasn't been accepted
+// too many times, moves to the DEAD_LETTER state. See JobAck.
+func (s *State) JobNack(id string, reason string) (*Job, error) {
+	return s.jobAck(context.Background(), id, false, reason)
+}
+
+// jobAckTimeoutReason is the reason a job wasn't accepted if the runner
+// didn't accept it in time.
+func jobAckTimeoutReason(timeout time.Duration) string {
+	return fmt.Sprintf("not accepted by the runner within %s", timeout)
+}
+
+// JobListDeadLetter returns the jobs in the DEAD_LETTER state.
+func (s *State) JobListDeadLetter() ([]*pb.Job, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	jobs, err := jobsByState(memTxn, pb.Job_DEAD_LETTER)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*pb.Job
+	for _, idx := range jobs {
+		job, err := s.jobByIdCached(idx.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, job)
+	}
+
+	return result, nil
+}
+
+// JobRequeue moves a job in the DEAD_LETTER state back to the queue. The
+// error and the number of times it wasn't accepted are reset.
+func (s *State) JobRequeue(id string) error {
+	txn := s.inmemWriteTxn()
+	defer txn.Abort()
+
+	// Get the job
+	raw, err := txn.First(jobTableName, jobIdIndexName, id)
+	if err != nil {
+		return err
+	}
+	if raw == nil {
+		return status.Errorf(codes.NotFound, "job not found: %s", id)
+	}
+	job := raw.(*jobIndex).Copy()
+
+	if job.State != pb.Job_DEAD_LETTER {
+		return status.Errorf(codes.FailedPrecondition,
+			"job can't be requeued from state: %s",
+			job.State.String())
+	}
+
+	job.State = pb.Job_QUEUED
+	_, err = s.jobReadAndUpdate(context.Background(), job.Id, func(jobpb *pb.Job) error {
+		jobpb.State = job.State
+		jobpb.Error = nil
+		jobpb.NackCount = 0
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := txn.Insert(jobTableName, job); err != nil {
+		return err
+	}
+
+	txn.Commit()
+	s.jobNotifyQueued(job)
+	return nil
+}
@@ -0,0 +1,109 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	s := TestState(t)
+	defer s.Close()
+	s.JobMaxNacksSet(2)
+
+	require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+
+	// nack assigns and rejects the job
+	nack := func(reason string) *Job {
+		job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		require.Equal("A", job.Id)
+		job, err = s.JobNack(job.Id, reason)
+		require.NoError(err)
+		return job
+	}
+
+	// The job is requeued up to the limit
+	for i := 1; i <= 2; i++ {
+		job := nack("")
+		require.Equal(pb.Job_QUEUED, job.State)
+		require.Equal(uint32(i), job.NackCount)
+		require.Equal("rejected by the runner", job.Assignments[i-1].NackReason)
+	}
+
+	list, err := s.JobListDeadLetter()
+	require.NoError(err)
+	require.Empty(list)
+
+	// Then it is dead-lettered with the last reason
+	job := nack("plugin not installed")
+	require.Equal(pb.Job_DEAD_LETTER, job.State)
+	require.Equal(int32(codes.Aborted), job.Error.Code)
+	require.Contains(job.Error.Message, "plugin not installed")
+
+	list, err = s.JobListDeadLetter()
+	require.NoError(err)
+	require.Len(list, 1)
+	require.Equal("A", list[0].Id)
+
+	// Only dead-lettered jobs can be requeued
+	require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "B"})))
+	err = s.JobRequeue("B")
+	require.Error(err)
+	require.NoError(s.JobCancel("B", false, 0))
+
+	// Requeue it and it can run
+	require.NoError(s.JobRequeue("A"))
+	job, err = s.JobById("A", nil)
+	require.NoError(err)
+	require.Equal(pb.Job_QUEUED, job.State)
+	require.Nil(job.Error)
+	require.Zero(job.NackCount)
+
+	job, err = s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+	require.NoError(err)
+	require.Equal("A", job.Id)
+	_, err = s.JobAck(context.Background(), job.Id, true)
+	require.NoError(err)
+	require.NoError(s.JobComplete(context.Background(), job.Id, nil, nil))
+
+	list, err = s.JobListDeadLetter()
+	require.NoError(err)
+	require.Empty(list)
+}
+
+func TestJobDeadLetter_cancel(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	s := TestState(t)
+	defer s.Close()
+	s.JobMaxNacksSet(1)
+
+	require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+	for i := 0; i < 2; i++ {
+		job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		_, err = s.JobAck(context.Background(), job.Id, false)
+		require.NoError(err)
+	}
+
+	job, err := s.JobById("A", nil)
+	require.NoError(err)
+	require.Equal(pb.Job_DEAD_LETTER, job.State)
+
+	// Cancelling ends it
+	require.NoError(s.JobCancel("A", false, 0))
+	job, err = s.JobById("A", nil)
+	require.NoError(err)
+	require.Equal(pb.Job_ERROR, job.State)
+	require.Equal(int32(codes.Canceled), job.Error.Code)
+}
@@ -0,0 +1,269 @@
+package state
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hashicorp/go-memdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to deleting jobs. Deleting a job
+// doesn't remove it from the database. The job is marked with a delete
+// time, which is a tombstone, and removed from the in-memory indexes so it
+// isn't returned by any API. This keeps the deletion an ordinary write
+// that is replicated like any other. The job and its artifacts are purged
+// after jobTombstoneRetention. The jobs of a project are deleted when the
+// project is, whether or not they're complete.
+
+// expiryKindJobPurge is the kind of the expiries that purge deleted jobs.
+const expiryKindJobPurge = "job_purge"
+
+// jobTombstoneRetention is how long deleted jobs are kept before they're
+// purged. This is a var so tests can set it.
+var jobTombstoneRetention = 24 * time.Hour
+
+func init() {
+	expiryHandlers[expiryKindJobPurge] = jobPurge
+	projectDeleteHooks = append(projectDeleteHooks, jobProjectDelete)
+}
+
+// JobDelete deletes the completed job with the given ID.
+func (s *State) JobDelete(id string) error {
+	txn := s.inmemWriteTxn()
+	defer txn.Abort()
+
+	raw, err := txn.First(jobTableName, jobIdIndexName, id)
+	if err != nil {
+		return err
+	}
+	if raw == nil {
+		return status.Errorf(codes.NotFound, "job not found: %s", id)
+	}
+	idx := raw.(*jobIndex)
+
+	switch idx.State {
+	case pb.Job_SUCCESS, pb.Job_ERROR:
+	default:
+		return status.Errorf(codes.FailedPrecondition,
+			"job %q must be complete to be deleted, it is %s", id, idx.State)
+	}
+
+	var result *pb.Job
+	err = s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		var err error
+		result, err = s.jobTombstone(dbTxn, id, s.clock.Now(), nil)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	after, err := s.jobUnindex(txn, idx)
+	if err != nil {
+		return err
+	}
+
+	txn.Commit()
+	after()
+	s.jobCacheSet(result)
+	s.expiryWake()
+	return nil
+}
+
+// jobTombstone marks the job with the given ID as deleted and sets it to
+// be purged. If the job isn't complete, it is cancelled with the status
+// st. expiryWake must be called once the transaction commits.
+func (s *State) jobTombstone(
+	dbTxn *bolt.Tx,
+	id string,
+	now time.Time,
+	st *status.Status,
+) (*pb.Job, error) {
+	result, err := s.jobById(dbTxn, id)
+	if err != nil {
+		return nil, err
+	}
+
+	nowpb, err := ptypes.TimestampProto(now)
+	if err != nil {
+		return nil, err
+	}
+
+	switch result.State {
+	case pb.Job_SUCCESS, pb.Job_ERROR:
+	default:
+		if st == nil {
+			st = status.New(codes.Canceled, "canceled")
+		}
+
+		result.State = pb.Job_ERROR
+		result.CancelTime = nowpb
+		result.Error = st.Proto()
+	}
+
+	result.DeleteTime = nowpb
+	result.Revision++
+	if err := dbPut(dbTxn.Bucket(jobBucket), []byte(id), result); err != nil {
+		return nil, err
+	}
+
+	return result, expirySet(dbTxn, expiryKindJobPurge, id, now.Add(jobTombstoneRetention))
+}
+
+// jobUnindex removes the deleted job from the in-memory indexes. The
+// returned function must be called once the transaction commits. It
+// stops the timers of the job and releases its output.
+func (s *State) jobUnindex(txn *memdb.Txn, idx *jobIndex) (func(), error) {
+	if idx.State == pb.Job_RUNNING || idx.State == pb.Job_WAITING {
+		if err := s.jobAssignedSet(txn, idx, false); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := txn.Delete(jobTableName, idx); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		idx.stopTimers()
+		if idx.OutputBuffer != nil {
+			idx.OutputBuffer.Close()
+		}
+	}, nil
+}
+
+// jobProjectDelete deletes the jobs of a project that is deleted. Jobs
+// that aren't complete are cancelled. Runners running the jobs are told
+// to stop when they next report on them since the jobs no longer exist.
+func jobProjectDelete(
+	s *State,
+	dbTxn *bolt.Tx,
+	memTxn *memdb.Txn,
+	ref *pb.Ref_Project,
+) (func(), error) {
+	jobs, err := s.jobsByScope(memTxn, nil, ref.Project, "", "")
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	st := status.Newf(codes.Canceled, "project %q was deleted", ref.Project)
+
+	var results []*pb.Job
+	var afters []func()
+	var cancelled []string
+	for _, idx := range jobs {
+		switch idx.State {
+		case pb.Job_SUCCESS, pb.Job_ERROR:
+		default:
+			cancelled = append(cancelled, idx.Id)
+		}
+
+		result, err := s.jobTombstone(dbTxn, idx.Id, now, st)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+
+		after, err := s.jobUnindex(memTxn, idx)
+		if err != nil {
+			return nil, err
+		}
+		afters = append(afters, after)
+	}
+
+	return func() {
+		for i, after := range afters {
+			after()
+			s.jobCacheSet(results[i])
+		}
+
+		s.expiryWake()
+		for _, id := range cancelled {
+			s.jobDeletedDependents(id)
+		}
+	}, nil
+}
+
+// jobDeletedDependents fails the queued jobs that depend on the job with
+// the given ID, which was deleted before it completed. Jobs that no longer
+// exist don't block their dependents, so otherwise they would run.
+func (s *State) jobDeletedDependents(id string) {
+	s.jobNotify.notifyKey(jobNotifyCompleteKey(id))
+
+	memTxn := s.inmem.Txn(false)
+	iter, err := memTxn.Get(jobTableName, jobDependsOnIndexName, id)
+	if err != nil {
+		memTxn.Abort()
+		s.log.Warn("error listing dependent jobs", "job", id, "err", err)
+		return
+	}
+
+	var dependents []string
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		if idx := raw.(*jobIndex); idx.State == pb.Job_QUEUED {
+			dependents = append(dependents, idx.Id)
+		}
+	}
+	memTxn.Abort()
+
+	for _, dep := range dependents {
+		err := s.JobFail(dep, status.Errorf(codes.FailedPrecondition,
+			"dependency %s was deleted", id))
+		if err != nil {
+			s.log.Warn("error failing dependent job", "job", dep, "dependency", id, "err", err)
+		}
+	}
+}
+
+// jobPurge is the expiry handler that purges a deleted job and its
+// artifacts from the database.
+func jobPurge(s *State, id string) error {
+	purged := false
+	err := s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		var job pb.Job
+		if err := dbGet(dbTxn.Bucket(jobBucket), []byte(id), &job); err != nil {
+			return err
+		}
+		if job.DeleteTime == nil {
+			return nil
+		}
+
+		if err := dbDelete(dbTxn.Bucket(jobBucket), []byte(id)); err != nil {
+			return err
+		}
+		purged = true
+
+		// Collect the keys first since a bucket can't be modified while
+		// it is iterated.
+		var keys [][]byte
+		prefix := jobArtifactId(id, "")
+		c := dbTxn.Bucket(jobArtifactBucket).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			keys = append(keys, append([]byte(nil), k...))
+		}
+		for _, k := range keys {
+			if err := dbDelete(dbTxn.Bucket(jobArtifactBucket), k); err != nil {
+				return err
+			}
+		}
+
+		return jobOutputDelete(dbTxn, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	// The cache still has the tombstone of the job.
+	if purged {
+		s.jobCacheDelete(id)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobDelete(t *testing.T) {
+	// complete creates a job and runs it to completion
+	complete := func(t *testing.T, s *State, id string) {
+		require := require.New(t)
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: id})))
+		job, err := s.JobAssignForRunner(context.Background(), &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		require.Equal(id, job.Id)
+		require.NoError(s.JobArtifactsPut(id, []*pb.JobArtifact{{Name: "report"}}))
+		_, err = s.JobAck(context.Background(), id, true)
+		require.NoError(err)
+		require.NoError(s.JobComplete(context.Background(), id, nil, nil))
+	}
+
+	t.Run("soft deletes a completed job", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		complete(t, s, "A")
+		require.NoError(s.JobDelete("A"))
+
+		// The job isn't returned by any API
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.Nil(job)
+		jobs, err := s.JobList()
+		require.NoError(err)
+		require.Empty(jobs)
+
+		// It can't be deleted again
+		err = s.JobDelete("A")
+		require.Error(err)
+		require.Equal(codes.NotFound, status.Code(err))
+
+		// The tombstone is still persisted and isn't indexed on restart
+		require.NoError(s.dbView(func(dbTxn *bolt.Tx) error {
+			var job pb.Job
+			require.NoError(dbGet(dbTxn.Bucket(jobBucket), []byte("A"), &job))
+			require.NotNil(job.DeleteTime)
+			return nil
+		}))
+
+		s = TestStateReinit(t, s)
+		defer s.Close()
+		job, err = s.JobById("A", nil)
+		require.NoError(err)
+		require.Nil(job)
+
+		issues, err := s.Verify(false)
+		require.NoError(err)
+		require.Empty(issues)
+	})
+
+	t.Run("running jobs can't be deleted", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		err := s.JobDelete("A")
+		require.Error(err)
+		require.Equal(codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("purged after the retention", func(t *testing.T) {
+		require := require.New(t)
+
+		defer func(d time.Duration) { jobTombstoneRetention = d }(jobTombstoneRetention)
+		jobTombstoneRetention = 10 * time.Millisecond
+
+		s := TestState(t)
+		defer s.Close()
+
+		complete(t, s, "A")
+		complete(t, s, "B")
+		require.NoError(s.JobDelete("A"))
+
+		require.Eventually(func() bool {
+			var exists bool
+			require.NoError(s.dbView(func(dbTxn *bolt.Tx) error {
+				exists = dbTxn.Bucket(jobBucket).Get([]byte("A")) != nil
+				return nil
+			}))
+			return !exists
+		}, 2*time.Second, 10*time.Millisecond)
+
+		// The job is evicted from the cache
+		require.Eventually(func() bool {
+			_, ok := s.jobCache.Get("A")
+			return !ok
+		}, 2*time.Second, 10*time.Millisecond)
+
+		// The artifacts of the job are purged too
+		artifacts, err := s.JobArtifacts("A")
+		require.NoError(err)
+		require.Empty(artifacts)
+		artifacts, err = s.JobArtifacts("B")
+		require.NoError(err)
+		require.Len(artifacts, 1)
+	})
+}
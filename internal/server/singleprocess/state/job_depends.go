@@ -0,0 +1,120 @@
+package state
+
+import (
+	"github.com/hashicorp/go-memdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to jobs that depend on other jobs. A
+// job with dependencies is blocked until they all succeed and fails if any
+// of them fail.
+
+// jobDependencyBlockedBy returns the ID of the first dependency of the job
+// that hasn't succeeded yet, or an empty string if they all have. Jobs that
+// no longer exist don't block. If ws is set then a watch will be added for
+// changes to the dependencies.
+func (s *State) jobDependencyBlockedBy(
+	memTxn *memdb.Txn,
+	idx *jobIndex,
+	ws memdb.WatchSet,
+) (string, error) {
+	for _, id := range idx.DependsOn {
+		watchCh, raw, err := memTxn.FirstWatch(jobTableName, jobIdIndexName, id)
+		if err != nil {
+			return "", err
+		}
+		if ws != nil {
+			ws.Add(watchCh)
+		}
+
+		if raw != nil && raw.(*jobIndex).State != pb.Job_SUCCESS {
+			return id, nil
+		}
+	}
+
+	return "", nil
+}
+
+// jobDependencyFailed returns the ID of a dependency of the job that
+// failed, or an empty string if none have.
+func (s *State) jobDependencyFailed(memTxn *memdb.Txn, idx *jobIndex) (string, error) {
+	for _, id := range idx.DependsOn {
+		raw, err := memTxn.First(jobTableName, jobIdIndexName, id)
+		if err != nil {
+			return "", err
+		}
+
+		if raw != nil && raw.(*jobIndex).State == pb.Job_ERROR {
+			return id, nil
+		}
+	}
+
+	return "", nil
+}
+
+// jobDependsCheck fails the queued job if any of its dependencies have
+// failed. This is called once the job is created in case a dependency
+// failed before then.
+func (s *State) jobDependsCheck(idx *jobIndex) error {
+	if len(idx.DependsOn) == 0 {
+		return nil
+	}
+
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	failed, err := s.jobDependencyFailed(memTxn, idx)
+	if err != nil || failed == "" {
+		return err
+	}
+	memTxn.Abort()
+
+	return s.jobDependencyFail(idx.Id, failed)
+}
+
+// jobDependentsUpdate updates the jobs that depend on the completed job
+// with the given ID. If the job succeeded, runners waiting on its dependents
+// are woken. If it failed, its queued dependents fail, which in turn fails
+// their dependents.
+func (s *State) jobDependentsUpdate(id string) {
+	s.jobNotify.notifyKey(jobNotifyCompleteKey(id))
+
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	raw, err := memTxn.First(jobTableName, jobIdIndexName, id)
+	if err != nil || raw == nil || raw.(*jobIndex).State != pb.Job_ERROR {
+		return
+	}
+
+	iter, err := memTxn.Get(jobTableName, jobDependsOnIndexName, id)
+	if err != nil {
+		s.log.Warn("error listing dependent jobs", "job", id, "err", err)
+		return
+	}
+
+	var dependents []string
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		if idx := raw.(*jobIndex); idx.State == pb.Job_QUEUED {
+			dependents = append(dependents, idx.Id)
+		}
+	}
+	memTxn.Abort()
+
+	for _, dep := range dependents {
+		if err := s.jobDependencyFail(dep, id); err != nil {
+			s.log.Warn("error failing dependent job", "job", dep, "dependency", id, "err", err)
+		}
+	}
+}
+
+// jobDependencyFail fails the job with the given ID because its dependency
+// failed.
+func (s *State) jobDependencyFail(id, dependency string) error {
+	s.log.Info("failing job since a dependency failed", "job", id, "dependency", dependency)
+	return s.JobFail(id, status.Errorf(codes.FailedPrecondition,
+		"dependency %s failed", dependency))
+}
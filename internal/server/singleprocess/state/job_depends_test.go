@@ -0,0 +1,151 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobDependsOn(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("waits for dependencies to succeed", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		// Create a chain of jobs, queued in reverse
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "C",
+			DependsOn: []string{"A", "B"},
+		})))
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "B",
+			DependsOn: []string{"A"},
+		})))
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id: "A",
+		})))
+
+		job, err := s.JobById("C", nil)
+		require.NoError(err)
+		require.True(job.Blocked)
+		require.Equal("A", job.BlockedBy)
+
+		// Run each job in order
+		for _, id := range []string{"A", "B", "C"} {
+			job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+			require.NoError(err)
+			require.Equal(id, job.Id)
+			_, err = s.JobAck(context.Background(), job.Id, true)
+			require.NoError(err)
+
+			// Nothing else can be assigned while it runs
+			actx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+			_, err = s.JobAssignForRunner(actx, &pb.Runner{Id: "R_B"})
+			cancel()
+			require.Equal(context.DeadlineExceeded, err)
+
+			require.NoError(s.JobComplete(context.Background(), job.Id, nil, nil))
+		}
+	})
+
+	t.Run("waiting runners are woken", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id: "A",
+			TargetRunner: &pb.Ref_Runner{
+				Target: &pb.Ref_Runner_Id{Id: &pb.Ref_RunnerId{Id: "R_A"}},
+			},
+		})))
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "B",
+			DependsOn: []string{"A"},
+		})))
+
+		job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		require.Equal("A", job.Id)
+		_, err = s.JobAck(context.Background(), job.Id, true)
+		require.NoError(err)
+
+		// Wait for B on another runner
+		doneCh := make(chan *Job)
+		go func() {
+			defer close(doneCh)
+			job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_B"})
+			if err == nil {
+				doneCh <- job
+			}
+		}()
+
+		select {
+		case <-doneCh:
+			t.Fatal("should wait")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		require.NoError(s.JobComplete(context.Background(), "A", nil, nil))
+		select {
+		case job := <-doneCh:
+			require.NotNil(job)
+			require.Equal("B", job.Id)
+		case <-time.After(2 * time.Second):
+			t.Fatal("should be assigned")
+		}
+	})
+
+	t.Run("failed dependencies fail dependents", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "B",
+			DependsOn: []string{"A"},
+		})))
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "C",
+			DependsOn: []string{"B"},
+		})))
+
+		job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		require.Equal("A", job.Id)
+		_, err = s.JobAck(context.Background(), job.Id, true)
+		require.NoError(err)
+		require.NoError(s.JobComplete(context.Background(), job.Id, nil, status.Errorf(codes.Unknown, "failed")))
+
+		for _, id := range []string{"B", "C"} {
+			job, err := s.JobById(id, nil)
+			require.NoError(err)
+			require.Equal(pb.Job_ERROR, job.State, id)
+			require.Equal(int32(codes.FailedPrecondition), job.Error.Code)
+			require.Contains(job.Error.Message, "failed")
+		}
+
+		// Jobs created after the dependency failed fail immediately
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "D",
+			DependsOn: []string{"A"},
+		})))
+		job, err = s.JobById("D", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_ERROR, job.State)
+		require.Contains(job.Error.Message, "dependency A failed")
+	})
+}
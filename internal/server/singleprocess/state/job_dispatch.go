@@ -0,0 +1,134 @@
+package state
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// JobDispatchSet updates the dispatch information of a job that was
+// created with dispatch information. This is used to record the job ID
+// and status in the external scheduler.
+func (s *State) JobDispatchSet(id string, d *pb.Job_Dispatch) error {
+	_, err := s.jobReadAndUpdate(context.Background(), id, func(jobpb *pb.Job) error {
+		if jobpb.Dispatch == nil {
+			return status.Errorf(codes.FailedPrecondition,
+				"job %s is not dispatched to an external scheduler", id)
+		}
+
+		jobpb.Dispatch = d
+		return nil
+	})
+
+	return err
+}
+
+// JobListDispatched returns all the jobs that are dispatched to an external
+// scheduler, including completed jobs.
+func (s *State) JobListDispatched() ([]*pb.Job, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	iter, err := memTxn.Get(jobTableName, jobIdIndexName+"_prefix", "")
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*pb.Job
+	for {
+		next := iter.Next()
+		if next == nil {
+			break
+		}
+		idx := next.(*jobIndex)
+		if !idx.Dispatched {
+			continue
+		}
+
+		job, err := s.jobByIdCached(idx.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, job)
+	}
+
+	return result, nil
+}
+
+// JobFail marks a job that hasn't completed as failed with the given
+// error. This is used when a job can't be completed by a runner, such as
+// when the external scheduler fails to run the runner started for it.
+// Completed jobs are not modified.
+func (s *State) JobFail(id string, ferr error) error {
+	st, _ := status.FromError(ferr)
+	return s.jobEnd(id, pb.Job_ERROR, nil, st)
+}
+
+// jobEnd moves a job that hasn't completed to a completed state with the
+// given result and error. Completed jobs are not modified.
+func (s *State) jobEnd(
+	id string,
+	state pb.Job_State,
+	result *pb.Job_Result,
+	st *status.Status,
+) error {
+	txn := s.inmemWriteTxn()
+	defer txn.Abort()
+
+	// Get the job
+	raw, err := txn.First(jobTableName, jobIdIndexName, id)
+	if err != nil {
+		return err
+	}
+	if raw == nil {
+		return status.Errorf(codes.NotFound, "job not found: %s", id)
+	}
+	job := raw.(*jobIndex).Copy()
+
+	switch job.State {
+	case pb.Job_SUCCESS, pb.Job_ERROR:
+		return nil
+
+	case pb.Job_WAITING, pb.Job_RUNNING:
+		// Assigned jobs may block other jobs, so unblock them.
+		if err := s.jobAssignedSet(txn, job, false); err != nil {
+			return err
+		}
+	}
+
+	job.State = state
+	job.End(s.clock.Now())
+
+	_, err = s.jobReadAndUpdate(context.Background(), job.Id, func(jobpb *pb.Job) error {
+		jobpb.State = job.State
+		jobpb.Result = result
+		jobpb.CompleteTime, err = ptypes.TimestampProto(s.clock.Now())
+		if err != nil {
+			// This should never happen since encoding a time now should be safe
+			panic("time encoding failed: " + err.Error())
+		}
+
+		if st != nil {
+			jobpb.Error = st.Proto()
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Insert to update
+	if err := txn.Insert(jobTableName, job); err != nil {
+		return err
+	}
+
+	txn.Commit()
+	s.jobCompleted(job.Id)
+	return nil
+}
@@ -0,0 +1,129 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobDispatch(t *testing.T) {
+	require := require.New(t)
+
+	s := TestState(t)
+	defer s.Close()
+
+	// Create a dispatched job and a normal job
+	require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+		Id: "A",
+		TargetRunner: &pb.Ref_Runner{
+			Target: &pb.Ref_Runner_Id{Id: &pb.Ref_RunnerId{Id: "R_A"}},
+		},
+		Dispatch: &pb.Job_Dispatch{Scheduler: "nomad", RunnerId: "R_A"},
+	})))
+	require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+		Id: "B",
+	})))
+
+	jobs, err := s.JobListDispatched()
+	require.NoError(err)
+	require.Len(jobs, 1)
+	require.Equal("A", jobs[0].Id)
+
+	// Record the scheduler job
+	require.NoError(s.JobDispatchSet("A", &pb.Job_Dispatch{
+		Scheduler: "nomad",
+		RunnerId:  "R_A",
+		Id:        "waypoint-job-a",
+		Status:    "pending",
+	}))
+	job, err := s.JobById("A", nil)
+	require.NoError(err)
+	require.Equal("waypoint-job-a", job.Dispatch.Id)
+
+	// Jobs that aren't dispatched can't be updated
+	err = s.JobDispatchSet("B", &pb.Job_Dispatch{Scheduler: "nomad"})
+	require.Error(err)
+	require.Equal(codes.FailedPrecondition, status.Code(err))
+}
+
+func TestJobFail(t *testing.T) {
+	t.Run("queued", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id: "A",
+		})))
+
+		require.NoError(s.JobFail("A", status.Errorf(codes.Aborted, "lost")))
+
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_ERROR, job.State)
+		require.NotNil(job.Error)
+		require.Equal(int32(codes.Aborted), job.Error.Code)
+		require.NotNil(job.CompleteTime)
+	})
+
+	t.Run("running unblocks other jobs", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		// Two deploys for the same app block each other
+		for _, id := range []string{"A", "B"} {
+			require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+				Id:        id,
+				Operation: &pb.Job_Deploy{Deploy: &pb.Job_DeployOp{}},
+			})))
+		}
+
+		job, err := s.JobAssignForRunner(context.Background(), &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		require.Equal("A", job.Id)
+		_, err = s.JobAck(context.Background(), job.Id, true)
+		require.NoError(err)
+
+		job, err = s.JobById("B", nil)
+		require.NoError(err)
+		require.True(job.Blocked)
+
+		require.NoError(s.JobFail("A", status.Errorf(codes.Aborted, "lost")))
+
+		job, err = s.JobById("B", nil)
+		require.NoError(err)
+		require.False(job.Blocked)
+	})
+
+	t.Run("completed jobs are unchanged", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id: "A",
+		})))
+		job, err := s.JobAssignForRunner(context.Background(), &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		_, err = s.JobAck(context.Background(), job.Id, true)
+		require.NoError(err)
+		require.NoError(s.JobComplete(context.Background(), job.Id, nil, nil))
+
+		require.NoError(s.JobFail("A", status.Errorf(codes.Aborted, "lost")))
+
+		job, err = s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_SUCCESS, job.State)
+		require.Nil(job.Error)
+	})
+}
@@ -0,0 +1,128 @@
+package state
+
+import (
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to the job expiry policy. Queued jobs
+// that don't set an expiry are given the default one and jobs can't set
+// an expiry later than the maximum, so that abandoned jobs don't stay
+// queued forever.
+
+// expiryKindJob is the kind of the expiries of jobs. See expiry.go.
+const expiryKindJob = "job"
+
+func init() {
+	expiryHandlers[expiryKindJob] = jobExpire
+}
+
+// jobExpiry is the expiry policy for queued jobs. Zero values mean there
+// is no default or maximum.
+type jobExpiry struct {
+	def time.Duration
+	max time.Duration
+}
+
+// JobExpirySet sets the default and maximum expiry for queued jobs,
+// relative to when they're queued. Jobs queued without an expiry expire
+// after def and jobs with a later expiry than max expire after max. Zero
+// values disable the default or maximum. This should be called once before
+// the state is used and only applies to jobs queued after it is called.
+func (s *State) JobExpirySet(def, max time.Duration) {
+	if max > 0 && def > max {
+		def = max
+	}
+
+	s.jobExpiry = jobExpiry{def: def, max: max}
+}
+
+// jobExpirySet sets the expire time of the job according to the expiry
+// policy and sets the job to expire then. now is the time the job is
+// queued.
+func (s *State) jobExpirySet(dbTxn *bolt.Tx, jobpb *pb.Job, now time.Time) error {
+	p := s.jobExpiry
+	if jobpb.ExpireTime == nil && p.def > 0 {
+		var err error
+		jobpb.ExpireTime, err = ptypes.TimestampProto(now.Add(p.def))
+		if err != nil {
+			return err
+		}
+	}
+	if jobpb.ExpireTime == nil {
+		return nil
+	}
+
+	t, err := ptypes.Timestamp(jobpb.ExpireTime)
+	if err != nil {
+		return err
+	}
+	if max := now.Add(p.max); p.max > 0 && t.After(max) {
+		t = max
+		jobpb.ExpireTime, err = ptypes.TimestampProto(max)
+		if err != nil {
+			return err
+		}
+	}
+
+	return expirySet(dbTxn, expiryKindJob, jobpb.Id, t)
+}
+
+// jobExpire is the expiry handler of jobs.
+func jobExpire(s *State, id string) error {
+	err := s.JobExpire(id)
+	if status.Code(err) != codes.NotFound {
+		return err
+	}
+
+	// Jobs are persisted before they're indexed, so the job may not be
+	// indexed yet.
+	var persisted bool
+	if err := s.dbView(func(dbTxn *bolt.Tx) error {
+		_, err := s.jobById(dbTxn, id)
+		persisted = err == nil
+		return nil
+	}); err != nil {
+		return err
+	}
+	if persisted {
+		return status.Errorf(codes.Unavailable, "job %q isn't indexed yet", id)
+	}
+
+	return err
+}
+
+// jobExpiryMigrate sets the jobs that were persisted before expiries were
+// persisted and haven't ended to expire at their expire time.
+func jobExpiryMigrate(log hclog.Logger, dbTxn *bolt.Tx) error {
+	jobs, err := jobUnmarshalAll(dbTxn.Bucket(jobBucket))
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		if job.ExpireTime == nil ||
+			job.State == pb.Job_SUCCESS || job.State == pb.Job_ERROR {
+			continue
+		}
+
+		t, err := ptypes.Timestamp(job.ExpireTime)
+		if err != nil {
+			log.Warn("job has an invalid expire time, it won't expire", "job", job.Id, "err", err)
+			continue
+		}
+
+		if err := expirySet(dbTxn, expiryKindJob, job.Id, t); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
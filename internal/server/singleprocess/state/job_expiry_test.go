@@ -0,0 +1,82 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobExpiry(t *testing.T) {
+	t.Run("default expiry", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		s.JobExpirySet(10*time.Millisecond, 0)
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.NotNil(job.ExpireTime)
+
+		// The job expires
+		require.Eventually(func() bool {
+			job, err := s.JobById("A", nil)
+			require.NoError(err)
+			return job.State == pb.Job_ERROR
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("no default expiry", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.Nil(job.ExpireTime)
+	})
+
+	t.Run("maximum expiry", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		s.JobExpirySet(0, time.Hour)
+
+		expire := func(id string, d time.Duration) *pb.Job {
+			ts, err := ptypes.TimestampProto(time.Now().Add(d))
+			require.NoError(err)
+			return &pb.Job{Id: id, ExpireTime: ts}
+		}
+
+		// A later expiry is limited to the maximum
+		job := serverptypes.TestJobNew(t, expire("A", 24*time.Hour))
+		require.NoError(s.JobCreate(context.Background(), job))
+		ts, err := ptypes.Timestamp(job.ExpireTime)
+		require.NoError(err)
+		require.WithinDuration(time.Now().Add(time.Hour), ts, time.Minute)
+
+		// An earlier expiry is kept
+		job = serverptypes.TestJobNew(t, expire("B", time.Minute))
+		require.NoError(s.JobCreate(context.Background(), job))
+		ts, err = ptypes.Timestamp(job.ExpireTime)
+		require.NoError(err)
+		require.WithinDuration(time.Now().Add(time.Minute), ts, 10*time.Second)
+
+		// No expiry is not limited
+		job = serverptypes.TestJobNew(t, &pb.Job{Id: "C"})
+		require.NoError(s.JobCreate(context.Background(), job))
+		require.Nil(job.ExpireTime)
+	})
+}
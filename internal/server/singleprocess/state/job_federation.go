@@ -0,0 +1,79 @@
+package state
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// JobFederationSet updates the federation information of a job that was
+// created to be forwarded to a downstream server. This is used to record
+// the job ID and state on the downstream server.
+func (s *State) JobFederationSet(id string, f *pb.Job_Federation) error {
+	_, err := s.jobReadAndUpdate(context.Background(), id, func(jobpb *pb.Job) error {
+		if jobpb.Federation == nil {
+			return status.Errorf(codes.FailedPrecondition,
+				"job %s is not forwarded to a downstream server", id)
+		}
+
+		jobpb.Federation = f
+		return nil
+	})
+
+	return err
+}
+
+// JobListFederated returns all the jobs that are forwarded to a downstream
+// server, including completed jobs.
+func (s *State) JobListFederated() ([]*pb.Job, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	iter, err := memTxn.Get(jobTableName, jobIdIndexName+"_prefix", "")
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*pb.Job
+	for {
+		next := iter.Next()
+		if next == nil {
+			break
+		}
+		idx := next.(*jobIndex)
+		if !idx.Federated {
+			continue
+		}
+
+		job, err := s.jobByIdCached(idx.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, job)
+	}
+
+	return result, nil
+}
+
+// JobFederationComplete completes a forwarded job with the final state,
+// result, and error of the job on the downstream server. Completed jobs
+// are not modified.
+func (s *State) JobFederationComplete(id string, remote *pb.Job) error {
+	switch remote.State {
+	case pb.Job_SUCCESS, pb.Job_ERROR:
+	default:
+		return status.Errorf(codes.FailedPrecondition,
+			"downstream job can't complete the job from state: %s", remote.State)
+	}
+
+	var st *status.Status
+	if remote.Error != nil {
+		st = status.FromProto(remote.Error)
+	}
+
+	return s.jobEnd(id, remote.State, remote.Result, st)
+}
@@ -0,0 +1,69 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobFederation(t *testing.T) {
+	require := require.New(t)
+
+	s := TestState(t)
+	defer s.Close()
+
+	// Create a forwarded job and a normal job
+	require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+		Id: "A",
+		TargetRunner: &pb.Ref_Runner{
+			Target: &pb.Ref_Runner_Id{Id: &pb.Ref_RunnerId{Id: "R_A"}},
+		},
+		Federation: &pb.Job_Federation{Server: "us-east"},
+	})))
+	require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+		Id: "B",
+	})))
+
+	jobs, err := s.JobListFederated()
+	require.NoError(err)
+	require.Len(jobs, 1)
+	require.Equal("A", jobs[0].Id)
+
+	// Record the downstream job
+	require.NoError(s.JobFederationSet("A", &pb.Job_Federation{
+		Server: "us-east",
+		Id:     "remote",
+		State:  pb.Job_QUEUED,
+	}))
+	job, err := s.JobById("A", nil)
+	require.NoError(err)
+	require.Equal("remote", job.Federation.Id)
+
+	// Jobs that aren't forwarded can't be updated
+	err = s.JobFederationSet("B", &pb.Job_Federation{Server: "us-east"})
+	require.Error(err)
+	require.Equal(codes.FailedPrecondition, status.Code(err))
+
+	// Incomplete downstream jobs can't complete the job
+	err = s.JobFederationComplete("A", &pb.Job{State: pb.Job_RUNNING})
+	require.Error(err)
+
+	// The downstream result completes the job
+	require.NoError(s.JobFederationComplete("A", &pb.Job{
+		State:  pb.Job_ERROR,
+		Error:  status.New(codes.Unknown, "deploy failed").Proto(),
+		Result: &pb.Job_Result{},
+	}))
+	job, err = s.JobById("A", nil)
+	require.NoError(err)
+	require.Equal(pb.Job_ERROR, job.State)
+	require.Equal("deploy failed", job.Error.Message)
+	require.NotNil(job.Result)
+	require.NotNil(job.CompleteTime)
+}
@@ -0,0 +1,65 @@
+package state
+
+import (
+	"sort"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to finding running jobs whose runner
+// is late sending heartbeats. A running job fails once its runner doesn't
+// send a heartbeat for the job's heartbeat timeout, so jobs that are late
+// are listed to warn operators before their work is force canceled. The
+// intervals between heartbeats are also recorded in the state metrics.
+// See metrics.go.
+
+// jobHeartbeatLateFraction is the fraction of its heartbeat timeout a
+// running job can go without a heartbeat before it is counted as late in
+// the state metrics.
+const jobHeartbeatLateFraction = 0.5
+
+// JobHeartbeatLateList returns the running jobs that haven't had a
+// heartbeat for at least the given fraction of their heartbeat timeout,
+// such as 0.5 for half. The jobs closest to their heartbeat deadline are
+// first. See Job.HeartbeatDeadline.
+func (s *State) JobHeartbeatLateList(fraction float64) ([]*Job, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	jobs, err := jobsByState(memTxn, pb.Job_RUNNING)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	var late []*jobIndex
+	for _, idx := range jobs {
+		if idx.HeartbeatTime.IsZero() || idx.HeartbeatTimeout <= 0 {
+			continue
+		}
+
+		since := now.Sub(idx.HeartbeatTime)
+		if since.Seconds() < fraction*idx.HeartbeatTimeout.Seconds() {
+			continue
+		}
+
+		late = append(late, idx)
+	}
+
+	sort.Slice(late, func(i, j int) bool {
+		return late[i].HeartbeatTime.Add(late[i].HeartbeatTimeout).Before(
+			late[j].HeartbeatTime.Add(late[j].HeartbeatTimeout))
+	})
+
+	result := make([]*Job, 0, len(late))
+	for _, idx := range late {
+		job, err := s.jobByIdCached(idx.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, idx.Job(job))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,81 @@
+package state
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobHeartbeatLateList(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	clock := TestClockNew()
+	s := TestState(t, WithClock(clock))
+	defer s.Close()
+
+	r := serverptypes.TestRunner(t, nil)
+	require.NoError(s.RunnerCreate(r))
+
+	// Start two jobs
+	for _, id := range []string{"A", "B"} {
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id:        id,
+			Workspace: &pb.Ref_Workspace{Workspace: id},
+		})))
+		job, err := s.JobAssignForRunner(ctx, r)
+		require.NoError(err)
+		_, err = s.JobAck(ctx, job.Id, true)
+		require.NoError(err)
+	}
+
+	// No jobs are late yet
+	late, err := s.JobHeartbeatLateList(0.5)
+	require.NoError(err)
+	require.Empty(late)
+
+	// B has a heartbeat, A doesn't
+	clock.Advance(jobHeartbeatTimeout / 4)
+	require.NoError(s.JobHeartbeat("B"))
+	clock.Advance(jobHeartbeatTimeout/4 + time.Second)
+
+	late, err = s.JobHeartbeatLateList(0.5)
+	require.NoError(err)
+	require.Len(late, 1)
+	require.Equal("A", late[0].Id)
+	require.Equal(pb.Job_RUNNING, late[0].State)
+
+	// Both are late at a lower fraction, A first
+	late, err = s.JobHeartbeatLateList(0.25)
+	require.NoError(err)
+	require.Len(late, 2)
+	require.Equal("A", late[0].Id)
+	require.Equal("B", late[1].Id)
+
+	// The late jobs and the heartbeat interval are in the metrics
+	var buf bytes.Buffer
+	require.NoError(s.WriteMetrics(&buf))
+	out := buf.String()
+	require.Contains(out, "waypoint_state_jobs_heartbeat_late 1\n")
+	require.Contains(out, `waypoint_state_heartbeat_interval_seconds_count{type="job"} 1`+"\n")
+	require.Contains(out, `waypoint_state_heartbeat_interval_seconds_bucket{type="job",le="30"} 1`+"\n")
+	require.Contains(out, `waypoint_state_heartbeat_interval_seconds_bucket{type="job",le="15"} 0`+"\n")
+
+	// A fails once it misses its heartbeat
+	clock.Advance(jobHeartbeatTimeout / 2)
+	require.Eventually(func() bool {
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		return job.State == pb.Job_ERROR
+	}, 5*time.Second, 10*time.Millisecond)
+
+	buf.Reset()
+	require.NoError(s.WriteMetrics(&buf))
+	require.Contains(buf.String(), `waypoint_state_heartbeats_missed_total{type="job"} 1`+"\n")
+}
@@ -0,0 +1,158 @@
+package state
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-memdb"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to jobs that target runners by label.
+// These jobs can be assigned to any runner that has all of the job's target
+// labels with the same values. Runners register their labels with the
+// Runner.Labels field.
+
+// jobCandidateLabels returns up to limit candidate jobs that target
+// runners with labels that the runner has, or all of them if limit is
+// zero. Candidates are ordered by priority, highest first, and then queue
+// time.
+func (s *State) jobCandidateLabels(memTxn *memdb.Txn, w *jobWaiter, r *runnerRecord, limit int) ([]*jobIndex, error) {
+	// A runner with no labels can't match any label targets. Jobs with an
+	// empty set of labels target any runner and are indexed as such.
+	if len(r.Runner.Labels) == 0 {
+		return nil, nil
+	}
+
+	iter, err := memTxn.LowerBound(
+		jobTableName,
+		jobQueueTimeIndexName,
+		pb.Job_QUEUED,
+		int32(math.MaxInt32),
+		time.Unix(0, 0),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*jobIndex
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+
+		job := raw.(*jobIndex)
+		if job.State != pb.Job_QUEUED || len(job.TargetLabels) == 0 {
+			continue
+		}
+
+		if !runnerLabelsMatch(r.Runner, job.TargetLabels) {
+			continue
+		}
+
+		// Jobs waiting for approval are not candidates. We're notified
+		// when they are approved.
+		if job.ApprovalPending {
+			continue
+		}
+
+		// Jobs of paused queues are not candidates. We're notified when
+		// the queue is resumed.
+		if paused, err := s.jobPaused(memTxn, job); err != nil {
+			return nil, err
+		} else if paused {
+			w.watch(jobNotifyPauseKey(job.Application.Project))
+			continue
+		}
+
+		// If this job is blocked, it is not a candidate. We watch for it
+		// to become unblocked in case we have to wait.
+		if blockedBy, err := s.jobBlockedBy(memTxn, job, nil); err != nil {
+			return nil, err
+		} else if blockedBy != "" {
+			w.watch(jobNotifyBlockedKey(job, blockedBy))
+			continue
+		}
+
+		// Jobs the runner can't run are not candidates. See
+		// job_capabilities.go.
+		if !jobCompatible(job, r.Runner) {
+			continue
+		}
+
+		// Jobs that don't fit in the runner's remaining capacity are not
+		// candidates.
+		if fits, err := s.jobFits(memTxn, job, r.Runner); err != nil {
+			return nil, err
+		} else if !fits {
+			continue
+		}
+
+		// Jobs that prefer another runner, or that avoid this one, are
+		// not candidates until their affinity wait ends. See
+		// job_affinity.go.
+		if held, err := s.jobAffinityHeld(memTxn, w, job, r.Runner); err != nil {
+			return nil, err
+		} else if held {
+			continue
+		}
+
+		// Jobs that prefer runners elsewhere are not candidates until
+		// their locality wait ends. See job_locality.go.
+		if held, err := s.jobLocalityHeld(memTxn, w, job, r.Runner); err != nil {
+			return nil, err
+		} else if held {
+			continue
+		}
+
+		// Jobs that a cheaper waiting runner can run are not candidates
+		// until their wait ends. See job_cost.go.
+		if held, err := s.jobCostHeld(memTxn, w, job, r.Runner); err != nil {
+			return nil, err
+		} else if held {
+			continue
+		}
+
+		result = append(result, job)
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// runnerLabelsMatch returns true if the runner has all of the labels with
+// the same values. The runner may have other labels.
+func runnerLabelsMatch(r *pb.Runner, labels map[string]string) bool {
+	for k, v := range labels {
+		if rv, ok := r.Labels[k]; !ok || rv != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// jobNotifyLabelsKey is the notification key for jobs targeting runners
+// with the given labels. Runners watch the key for each of their labels. A
+// job targeting multiple labels notifies on the key of only the first of
+// them since the runners that can be assigned the job have all of them.
+func jobNotifyLabelsKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return jobNotifyLabelKey(keys[0], labels[keys[0]])
+}
+
+// jobNotifyLabelKey is the notification key for jobs targeting runners
+// with the given label.
+func jobNotifyLabelKey(k, v string) string {
+	return "label:" + k + "=" + v
+}
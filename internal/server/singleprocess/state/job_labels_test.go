@@ -0,0 +1,173 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobAssignForRunner_labels(t *testing.T) {
+	ctx := context.Background()
+
+	labelTarget := func(labels map[string]string) *pb.Ref_Runner {
+		return &pb.Ref_Runner{
+			Target: &pb.Ref_Runner_Labels{
+				Labels: &pb.Ref_RunnerLabels{Labels: labels},
+			},
+		}
+	}
+
+	t.Run("matches runners with all labels", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id: "A",
+			TargetRunner: labelTarget(map[string]string{
+				"arch": "arm64",
+				"gpu":  "true",
+			}),
+		})))
+
+		// Runners missing a label or with a different value don't match
+		for _, labels := range []map[string]string{
+			nil,
+			{"arch": "arm64"},
+			{"arch": "amd64", "gpu": "true"},
+		} {
+			actx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+			_, err := s.JobAssignForRunner(actx, &pb.Runner{Id: "R_A", Labels: labels})
+			cancel()
+			require.Equal(context.DeadlineExceeded, err)
+		}
+
+		// Runners that are by ID only don't match
+		actx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		_, err := s.JobAssignForRunner(actx, &pb.Runner{
+			Id:       "R_A",
+			ByIdOnly: true,
+			Labels:   map[string]string{"arch": "arm64", "gpu": "true"},
+		})
+		cancel()
+		require.Equal(context.DeadlineExceeded, err)
+
+		// A runner with extra labels matches
+		job, err := s.JobAssignForRunner(ctx, &pb.Runner{
+			Id:     "R_A",
+			Labels: map[string]string{"arch": "arm64", "gpu": "true", "zone": "a"},
+		})
+		require.NoError(err)
+		require.Equal("A", job.Id)
+	})
+
+	t.Run("empty labels target any runner", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:           "A",
+			TargetRunner: labelTarget(nil),
+		})))
+
+		job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		require.Equal("A", job.Id)
+	})
+
+	t.Run("wakes only matching runners", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		// Wait on a runner without the label
+		otherCh := make(chan *Job, 1)
+		otherCtx, otherCancel := context.WithCancel(ctx)
+		defer otherCancel()
+		go func() {
+			job, _ := s.JobAssignForRunner(otherCtx, &pb.Runner{
+				Id:     "R_A",
+				Labels: map[string]string{"gpu": "false"},
+			})
+			otherCh <- job
+		}()
+
+		// Wait on a runner with the label
+		doneCh := make(chan *Job, 1)
+		go func() {
+			job, _ := s.JobAssignForRunner(ctx, &pb.Runner{
+				Id:     "R_B",
+				Labels: map[string]string{"gpu": "true"},
+			})
+			doneCh <- job
+		}()
+
+		// Let both runners start waiting
+		time.Sleep(50 * time.Millisecond)
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:           "A",
+			TargetRunner: labelTarget(map[string]string{"gpu": "true"}),
+		})))
+
+		select {
+		case job := <-doneCh:
+			require.NotNil(job)
+			require.Equal("A", job.Id)
+
+		case <-time.After(1 * time.Second):
+			t.Fatal("runner with label should be assigned the job")
+		}
+
+		otherCancel()
+		require.Nil(<-otherCh)
+	})
+}
+
+func TestJobIsAssignable_labels(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	s := TestState(t)
+	defer s.Close()
+
+	require.NoError(s.RunnerCreate(serverptypes.TestRunner(t, &pb.Runner{
+		Labels: map[string]string{"arch": "arm64"},
+	})))
+	require.NoError(s.RunnerCreate(serverptypes.TestRunner(t, &pb.Runner{
+		ByIdOnly: true,
+		Labels:   map[string]string{"gpu": "true"},
+	})))
+
+	for _, tt := range []struct {
+		Labels   map[string]string
+		Expected bool
+	}{
+		{map[string]string{"arch": "arm64"}, true},
+		{map[string]string{"arch": "amd64"}, false},
+		{map[string]string{"arch": "arm64", "gpu": "true"}, false},
+
+		// Only matches a runner that is by ID only
+		{map[string]string{"gpu": "true"}, false},
+	} {
+		result, err := s.JobIsAssignable(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id: "A",
+			TargetRunner: &pb.Ref_Runner{
+				Target: &pb.Ref_Runner_Labels{
+					Labels: &pb.Ref_RunnerLabels{Labels: tt.Labels},
+				},
+			},
+		}))
+		require.NoError(err)
+		require.Equal(tt.Expected, result, "%v", tt.Labels)
+	}
+}
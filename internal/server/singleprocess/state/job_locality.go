@@ -0,0 +1,161 @@
+package state
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-memdb"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+// This file has the methods related to job locality. Runners report the
+// region and zone they run in with the Runner.Region and Runner.Zone
+// fields, and jobs can prefer a locality with the Job.Locality field,
+// such as the region of their deployment target. Runners in the zone the
+// job prefers are preferred over runners in the rest of its region, which
+// are preferred over runners elsewhere.
+//
+// Like affinity, this is a preference: a job isn't a candidate for a
+// runner that is less local than another runner that could run it until
+// it has been queued for its locality wait. After that it can be assigned
+// to any runner it targets. A job doesn't wait if no runner that is more
+// local can run it, so jobs fall back to remote runners right away when
+// there are no local ones.
+
+// jobLocalityIndexSet sets the locality of a job index.
+func (s *State) jobLocalityIndexSet(rec *jobIndex, jobpb *pb.Job) error {
+	l := jobpb.Locality
+	if l == nil || (l.Region == "" && l.Zone == "") {
+		return nil
+	}
+
+	rec.LocalityRegion = strings.ToLower(l.Region)
+	rec.LocalityZone = strings.ToLower(l.Zone)
+	rec.LocalityWait = jobAffinityWait
+	if l.Wait != "" {
+		d, err := serverptypes.JobTimeout(l.Wait)
+		if err != nil {
+			return fmt.Errorf("invalid locality wait: %s", err)
+		}
+
+		rec.LocalityWait = d
+	}
+
+	if rec.State != pb.Job_QUEUED {
+		return nil
+	}
+
+	// Runners that skipped the job are woken once it can be assigned to
+	// any runner.
+	if d := rec.QueueTime.Add(rec.LocalityWait).Sub(s.clock.Now()); d > 0 {
+		id := rec.Id
+		s.timers.AfterFunc(d, func() {
+			s.jobNotify.notifyKey(jobNotifyLocalityKey(id))
+		})
+	}
+
+	return nil
+}
+
+// jobLocalityHeld returns true if the job isn't a candidate for the runner
+// yet because a more local runner can run it. If so and w is not nil, w is
+// notified once the job can be assigned to any runner.
+func (s *State) jobLocalityHeld(
+	memTxn *memdb.Txn,
+	w *jobWaiter,
+	job *jobIndex,
+	r *pb.Runner,
+) (bool, error) {
+	if job.LocalityWait <= 0 || !s.clock.Now().Before(job.QueueTime.Add(job.LocalityWait)) {
+		return false, nil
+	}
+
+	rank := jobLocalityRank(job, r)
+	if rank == jobLocalityRankMax(job) {
+		return false, nil
+	}
+
+	iter, err := memTxn.Get(runnerTableName, runnerIdIndexName+"_prefix", "")
+	if err != nil {
+		return false, err
+	}
+
+	held := false
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		other := raw.(*runnerRecord).Runner
+		if strings.EqualFold(other.Id, r.Id) {
+			continue
+		}
+
+		if jobLocalityRank(job, other) > rank && job.targets(other) && jobCompatible(job, other) {
+			held = true
+			break
+		}
+	}
+
+	if held && w != nil {
+		w.watch(jobNotifyLocalityKey(job.Id))
+	}
+
+	return held, nil
+}
+
+// jobLocalityRank returns how local the runner is for the job: 2 if it is
+// in the zone the job prefers, 1 if it is only in the region, and 0
+// otherwise.
+func jobLocalityRank(job *jobIndex, r *pb.Runner) int {
+	if job.LocalityRegion != "" && !strings.EqualFold(job.LocalityRegion, r.Region) {
+		return 0
+	}
+
+	if job.LocalityZone != "" && strings.EqualFold(job.LocalityZone, r.Zone) {
+		return 2
+	}
+	if job.LocalityRegion != "" {
+		return 1
+	}
+
+	return 0
+}
+
+// jobLocalityRankMax returns the rank of the most local runners for the
+// job.
+func jobLocalityRankMax(job *jobIndex) int {
+	if job.LocalityZone != "" {
+		return 2
+	}
+
+	return 1
+}
+
+// jobNotifyLocalityQueued wakes the runners in the locality the queued
+// job prefers, since the runner that is woken for the target may be
+// elsewhere.
+func (s *State) jobNotifyLocalityQueued(idx *jobIndex) {
+	if idx.LocalityRegion != "" {
+		s.jobNotify.notifyKey(jobNotifyRegionKey(idx.LocalityRegion))
+	}
+	if idx.LocalityZone != "" {
+		s.jobNotify.notifyKey(jobNotifyZoneKey(idx.LocalityZone))
+	}
+}
+
+// jobNotifyRegionKey is the notification key for jobs that prefer runners
+// in the region.
+func jobNotifyRegionKey(region string) string {
+	return "region:" + strings.ToLower(region)
+}
+
+// jobNotifyZoneKey is the notification key for jobs that prefer runners in
+// the zone.
+func jobNotifyZoneKey(zone string) string {
+	return "zone:" + strings.ToLower(zone)
+}
+
+// jobNotifyLocalityKey is the notification key for the job with the given
+// ID to be assignable to runners in any locality.
+func jobNotifyLocalityKey(id string) string {
+	return "locality:" + id
+}
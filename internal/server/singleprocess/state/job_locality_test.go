@@ -0,0 +1,158 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobLocality(t *testing.T) {
+	ctx := context.Background()
+
+	// assignTimeout tries to assign a job to the runner, giving up quickly.
+	assignTimeout := func(t *testing.T, s *State, r *pb.Runner) (*Job, error) {
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		return s.JobAssignForRunner(ctx, r)
+	}
+
+	t.Run("prefers runners in the region", func(t *testing.T) {
+		require := require.New(t)
+
+		clock := TestClockNew()
+		s := TestState(t, WithClock(clock))
+		defer s.Close()
+
+		local := serverptypes.TestRunner(t, &pb.Runner{Id: "R_A", Region: "US-East-1"})
+		remote := serverptypes.TestRunner(t, &pb.Runner{Id: "R_B", Region: "eu-west-1"})
+		require.NoError(s.RunnerCreate(local))
+		require.NoError(s.RunnerCreate(remote))
+
+		locality := &pb.Job_Locality{Region: "us-east-1"}
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id:       "A",
+			Locality: locality,
+		})))
+
+		// The remote runner isn't assigned the job while it waits
+		_, err := assignTimeout(t, s, remote)
+		require.Equal(context.DeadlineExceeded, err)
+
+		// The local runner is
+		job, err := s.JobAssignForRunner(ctx, local)
+		require.NoError(err)
+		require.Equal("A", job.Id)
+
+		// The remote runner is assigned the job once the wait ends
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id:       "B",
+			Locality: locality,
+		})))
+		resultCh := make(chan *Job, 1)
+		go func() {
+			job, err := s.JobAssignForRunner(ctx, remote)
+			if err == nil {
+				resultCh <- job
+			}
+		}()
+
+		select {
+		case <-resultCh:
+			t.Fatal("should not be assigned")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		clock.Advance(jobAffinityWait)
+		select {
+		case job := <-resultCh:
+			require.Equal("B", job.Id)
+		case <-time.After(5 * time.Second):
+			t.Fatal("should be assigned")
+		}
+	})
+
+	t.Run("prefers runners in the zone", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		zone := serverptypes.TestRunner(t, &pb.Runner{Id: "R_A", Region: "us-east-1", Zone: "us-east-1a"})
+		region := serverptypes.TestRunner(t, &pb.Runner{Id: "R_B", Region: "us-east-1", Zone: "us-east-1b"})
+		require.NoError(s.RunnerCreate(zone))
+		require.NoError(s.RunnerCreate(region))
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id:       "A",
+			Locality: &pb.Job_Locality{Region: "us-east-1", Zone: "us-east-1a"},
+		})))
+
+		_, err := assignTimeout(t, s, region)
+		require.Equal(context.DeadlineExceeded, err)
+
+		job, err := s.JobAssignForRunner(ctx, zone)
+		require.NoError(err)
+		require.Equal("A", job.Id)
+	})
+
+	t.Run("falls back if no local runner can run the job", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		// The local runner only builds and the other is drained
+		local := serverptypes.TestRunner(t, &pb.Runner{
+			Id:         "R_A",
+			Region:     "us-east-1",
+			Operations: []string{"build"},
+		})
+		drained := serverptypes.TestRunner(t, &pb.Runner{Id: "R_B", Region: "us-east-1"})
+		remote := serverptypes.TestRunner(t, &pb.Runner{Id: "R_C", Region: "eu-west-1"})
+		require.NoError(s.RunnerCreate(local))
+		require.NoError(s.RunnerCreate(drained))
+		require.NoError(s.RunnerCreate(remote))
+		_, err := s.RunnerDrain("R_B")
+		require.NoError(err)
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id:       "A",
+			Locality: &pb.Job_Locality{Region: "us-east-1"},
+		})))
+
+		job, err := assignTimeout(t, s, remote)
+		require.NoError(err)
+		require.Equal("A", job.Id)
+	})
+
+	t.Run("custom wait", func(t *testing.T) {
+		require := require.New(t)
+
+		clock := TestClockNew()
+		s := TestState(t, WithClock(clock))
+		defer s.Close()
+
+		local := serverptypes.TestRunner(t, &pb.Runner{Id: "R_A", Region: "us-east-1"})
+		remote := serverptypes.TestRunner(t, &pb.Runner{Id: "R_B"})
+		require.NoError(s.RunnerCreate(local))
+		require.NoError(s.RunnerCreate(remote))
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id:       "A",
+			Locality: &pb.Job_Locality{Region: "us-east-1", Wait: "5s"},
+		})))
+
+		_, err := assignTimeout(t, s, remote)
+		require.Equal(context.DeadlineExceeded, err)
+
+		clock.Advance(5 * time.Second)
+		job, err := s.JobAssignForRunner(ctx, remote)
+		require.NoError(err)
+		require.Equal("A", job.Id)
+	})
+}
@@ -0,0 +1,308 @@
+package state
+
+import (
+	"strings"
+	"sync"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// jobNotifier wakes runners that are waiting for a job assignment.
+//
+// Rather than have every waiting runner watch the entire queue, runners
+// register a waiter and changes wake only the runners that could be
+// assigned a job because of that change. A job targeting any runner wakes
+// exactly one waiting runner, in the order they started waiting. Everything
+// else wakes the waiters watching a specific key. Every waiter watches the
+// key for its own runner ID so that jobs targeting that runner wake only
+// that runner, and the keys for each of its labels so that jobs targeting
+// runners by label wake only the runners with that label. Waiters that
+// found only blocked jobs also watch the key for
+// the project/app/workspace or the dependency they're blocked on so they're
+// woken when it is unblocked.
+//
+// This avoids a thundering herd of runners all racing for the write lock
+// for every queue change.
+//
+// A runner woken for a job targeting any runner may not be able to run
+// it, such as when the job doesn't fit in the runner's remaining capacity.
+// The runner then passes the notification on to the next waiter. Only
+// waiters that were registered when the job was queued are passed to, so
+// a job that no runner can run wakes each runner at most once.
+//
+// If preferCheap is set, a job targeting any runner wakes the cheapest
+// waiting runner instead, in the order they started waiting among runners
+// with the same cost. See job_cost.go.
+//
+// The zero value is ready to use.
+type jobNotifier struct {
+	lock sync.Mutex
+
+	// any is the FIFO list of waiters that can accept jobs that target
+	// any runner.
+	any []*jobWaiter
+
+	// keys are the waiters for each key.
+	keys map[string][]*jobWaiter
+
+	// all are all of the waiters.
+	all []*jobWaiter
+
+	// seq is the sequence number of the last registered waiter.
+	seq uint64
+
+	// preferCheap wakes the cheapest waiter for jobs that target any
+	// runner rather than the first.
+	preferCheap bool
+}
+
+// jobWaiter is a single runner waiting for assignment. ch receives a
+// value when the runner should recheck for candidate jobs.
+type jobWaiter struct {
+	n    *jobNotifier
+	id   string
+	seq  uint64
+	cost float64
+	any  bool
+	keys []string
+	ch   chan struct{}
+
+	// anySeq is the sequence number of the last waiter that could be
+	// woken by the notification for a job targeting any runner that woke
+	// this waiter, or zero if it wasn't woken by one. passSeq is the
+	// same for the notification this waiter may pass on. See pass.
+	anySeq  uint64
+	passSeq uint64
+}
+
+// register registers a new waiter for the given runner. This must be
+// called before searching for candidate jobs so that no notifications
+// are missed between the search and waiting. The returned waiter must
+// be unregistered when the caller is done with it.
+func (n *jobNotifier) register(r *runnerRecord) *jobWaiter {
+	w := &jobWaiter{
+		n:    n,
+		id:   r.Id,
+		cost: runnerCost(r.Runner),
+		any:  !r.Runner.ByIdOnly,
+		ch:   make(chan struct{}, 1),
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.seq++
+	w.seq = n.seq
+	n.all = append(n.all, w)
+
+	if w.any {
+		n.any = append(n.any, w)
+	}
+	n.watchLocked(w, jobNotifyRunnerKey(r.Id))
+	if w.any {
+		for k, v := range r.Runner.Labels {
+			n.watchLocked(w, jobNotifyLabelKey(k, v))
+		}
+		if r.Pool != "" {
+			n.watchLocked(w, jobNotifyPoolKey(r.Pool))
+		}
+		if r.Runner.Region != "" {
+			n.watchLocked(w, jobNotifyRegionKey(r.Runner.Region))
+		}
+		if r.Runner.Zone != "" {
+			n.watchLocked(w, jobNotifyZoneKey(r.Runner.Zone))
+		}
+	}
+
+	return w
+}
+
+// unregister removes the waiter. If the waiter was woken but never
+// consumed the notification, the notification is passed on to another
+// waiter so that the job that caused it isn't stranded in the queue.
+func (n *jobNotifier) unregister(w *jobWaiter) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.all = jobWaiterRemove(n.all, w)
+	n.any = jobWaiterRemove(n.any, w)
+	for _, k := range w.keys {
+		if list := jobWaiterRemove(n.keys[k], w); len(list) > 0 {
+			n.keys[k] = list
+		} else {
+			delete(n.keys, k)
+		}
+	}
+
+	select {
+	case <-w.ch:
+		n.notifyAnyLocked(n.seq)
+	default:
+	}
+}
+
+// notifyAny wakes a single runner that can accept a job targeting any
+// runner. This returns false if no runners were waiting.
+func (n *jobNotifier) notifyAny() bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.notifyAnyLocked(n.seq)
+}
+
+// notifyAnyLocked wakes a single runner that can accept a job targeting
+// any runner among the waiters with a sequence number up to seq.
+func (n *jobNotifier) notifyAnyLocked(seq uint64) bool {
+	// Pick the first waiter, or the first of the cheapest. It is removed
+	// from the list so that the next notification goes to a different
+	// runner.
+	var w *jobWaiter
+	for _, v := range n.any {
+		if v.seq > seq {
+			continue
+		}
+		if w == nil || (n.preferCheap && v.cost < w.cost) {
+			w = v
+		}
+	}
+	if w == nil {
+		return false
+	}
+
+	n.any = jobWaiterRemove(n.any, w)
+	w.anySeq = seq
+	w.notify()
+	return true
+}
+
+// cheaper returns the runner IDs of the waiters that are cheaper than the
+// given cost.
+func (n *jobNotifier) cheaper(cost float64) []string {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	var result []string
+	for _, w := range n.all {
+		if w.cost < cost {
+			result = append(result, w.id)
+		}
+	}
+
+	return result
+}
+
+// notifyKey wakes all the waiters watching the given key. This returns
+// false if no runners were waiting on the key.
+func (n *jobNotifier) notifyKey(key string) bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	for _, w := range n.keys[key] {
+		w.notify()
+	}
+
+	return len(n.keys[key]) > 0
+}
+
+func (n *jobNotifier) watchLocked(w *jobWaiter, key string) {
+	for _, k := range w.keys {
+		if k == key {
+			return
+		}
+	}
+
+	if n.keys == nil {
+		n.keys = make(map[string][]*jobWaiter)
+	}
+	n.keys[key] = append(n.keys[key], w)
+	w.keys = append(w.keys, key)
+}
+
+// watch adds a key for this waiter to be notified on.
+func (w *jobWaiter) watch(key string) {
+	w.n.lock.Lock()
+	defer w.n.lock.Unlock()
+	w.n.watchLocked(w, key)
+}
+
+// woken returns the sequence number to pass to the next waiter created by
+// the same runner if this waiter was woken for a job targeting any runner.
+// See pass.
+func (w *jobWaiter) woken() uint64 {
+	w.n.lock.Lock()
+	defer w.n.lock.Unlock()
+	return w.anySeq
+}
+
+// setPass sets the sequence number returned by woken for the waiter the
+// runner had before this one.
+func (w *jobWaiter) setPass(seq uint64) {
+	w.n.lock.Lock()
+	defer w.n.lock.Unlock()
+	w.passSeq = seq
+}
+
+// pass passes the notification for a job targeting any runner that woke
+// the runner on to the next waiter. This is called when the runner can't
+// run a job targeting any runner so that a runner that can is woken in
+// its place. The notification is only passed once.
+func (w *jobWaiter) pass() {
+	w.n.lock.Lock()
+	defer w.n.lock.Unlock()
+
+	if w.passSeq == 0 {
+		return
+	}
+
+	w.n.notifyAnyLocked(w.passSeq)
+	w.passSeq = 0
+}
+
+// notify wakes the waiter. This never blocks.
+func (w *jobWaiter) notify() {
+	select {
+	case w.ch <- struct{}{}:
+	default:
+	}
+}
+
+// jobNotifyRunnerKey is the notification key for jobs targeting a runner.
+func jobNotifyRunnerKey(id string) string {
+	return "runner:" + strings.ToLower(id)
+}
+
+// jobNotifyBlockKey is the notification key for changes to whether jobs
+// for the project/app/workspace are blocked.
+func jobNotifyBlockKey(app *pb.Ref_Application, ws *pb.Ref_Workspace) string {
+	return strings.ToLower("block:" + app.Project + "/" + app.Application + "/" + ws.Workspace)
+}
+
+// jobNotifyCompleteKey is the notification key for the completion of the
+// job with the given ID. Jobs that depend on it may then be unblocked.
+func jobNotifyCompleteKey(id string) string {
+	return "complete:" + id
+}
+
+// jobNotifyBlockedKey is the notification key to watch for the job to be
+// unblocked when it is blocked by the job with the given ID.
+func jobNotifyBlockedKey(idx *jobIndex, blockedBy string) string {
+	for _, id := range idx.DependsOn {
+		if id == blockedBy {
+			return jobNotifyCompleteKey(id)
+		}
+	}
+
+	return jobNotifyBlockKey(idx.Application, idx.Workspace)
+}
+
+// jobWaiterRemove removes w from the list, preserving order.
+func jobWaiterRemove(list []*jobWaiter, w *jobWaiter) []*jobWaiter {
+	for i, v := range list {
+		if v == w {
+			copy(list[i:], list[i+1:])
+			list[len(list)-1] = nil
+			return list[:len(list)-1]
+		}
+	}
+
+	return list
+}
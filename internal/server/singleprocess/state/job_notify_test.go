@@ -0,0 +1,143 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func TestJobNotifier(t *testing.T) {
+	notified := func(w *jobWaiter) bool {
+		select {
+		case <-w.ch:
+			return true
+		default:
+			return false
+		}
+	}
+
+	t.Run("any wakes one waiter in order", func(t *testing.T) {
+		require := require.New(t)
+
+		var n jobNotifier
+		w1 := n.register(newRunnerRecord(&pb.Runner{Id: "A"}))
+		w2 := n.register(newRunnerRecord(&pb.Runner{Id: "B"}))
+		defer n.unregister(w1)
+		defer n.unregister(w2)
+
+		n.notifyAny()
+		require.True(notified(w1))
+		require.False(notified(w2))
+
+		n.notifyAny()
+		require.False(notified(w1))
+		require.True(notified(w2))
+
+		// No more waiters
+		n.notifyAny()
+		require.False(notified(w1))
+		require.False(notified(w2))
+	})
+
+	t.Run("by id only waits for targeted jobs", func(t *testing.T) {
+		require := require.New(t)
+
+		var n jobNotifier
+		w1 := n.register(newRunnerRecord(&pb.Runner{Id: "A", ByIdOnly: true}))
+		w2 := n.register(newRunnerRecord(&pb.Runner{Id: "B"}))
+		defer n.unregister(w1)
+		defer n.unregister(w2)
+
+		n.notifyAny()
+		require.False(notified(w1))
+		require.True(notified(w2))
+
+		n.notifyKey(jobNotifyRunnerKey("a"))
+		require.True(notified(w1))
+		require.False(notified(w2))
+	})
+
+	t.Run("keys", func(t *testing.T) {
+		require := require.New(t)
+
+		var n jobNotifier
+		w1 := n.register(newRunnerRecord(&pb.Runner{Id: "A"}))
+		w2 := n.register(newRunnerRecord(&pb.Runner{Id: "B"}))
+		defer n.unregister(w1)
+		defer n.unregister(w2)
+
+		key := jobNotifyBlockKey(
+			&pb.Ref_Application{Project: "p", Application: "a"},
+			&pb.Ref_Workspace{Workspace: "w"},
+		)
+		w1.watch(key)
+		w1.watch(key)
+		w2.watch(key)
+		require.Len(n.keys[key], 2)
+
+		n.notifyKey(key)
+		require.True(notified(w1))
+		require.True(notified(w2))
+
+		n.unregister(w1)
+		require.Len(n.keys[key], 1)
+	})
+
+	t.Run("unconsumed notifications are passed on", func(t *testing.T) {
+		require := require.New(t)
+
+		var n jobNotifier
+		w1 := n.register(newRunnerRecord(&pb.Runner{Id: "A"}))
+		w2 := n.register(newRunnerRecord(&pb.Runner{Id: "B"}))
+		defer n.unregister(w2)
+
+		// Notify the first but it goes away without reading it.
+		n.notifyAny()
+		n.unregister(w1)
+		require.True(notified(w2))
+	})
+
+	t.Run("rejected notifications are passed to earlier waiters", func(t *testing.T) {
+		require := require.New(t)
+
+		var n jobNotifier
+		w1 := n.register(newRunnerRecord(&pb.Runner{Id: "A"}))
+		w2 := n.register(newRunnerRecord(&pb.Runner{Id: "B"}))
+		defer n.unregister(w2)
+
+		// The first is woken and registers again to search for the job.
+		n.notifyAny()
+		require.True(notified(w1))
+		seq := w1.woken()
+		n.unregister(w1)
+		w3 := n.register(newRunnerRecord(&pb.Runner{Id: "A"}))
+		defer n.unregister(w3)
+		w3.setPass(seq)
+
+		// It can't run the job so it passes the notification on, but
+		// only once.
+		w3.pass()
+		require.True(notified(w2))
+		w3.pass()
+		require.False(notified(w3))
+
+		// The second can't run it either. The notification isn't passed
+		// back to the first since it has seen the job.
+		w4 := n.register(newRunnerRecord(&pb.Runner{Id: "B"}))
+		defer n.unregister(w4)
+		w4.setPass(w2.woken())
+		w4.pass()
+		require.False(notified(w3))
+		require.False(notified(w4))
+	})
+}
+
+// testJobWaiters returns the number of runners waiting for a job that
+// targets any runner.
+func testJobWaiters(s *State) int {
+	s.jobNotify.lock.Lock()
+	defer s.jobNotify.lock.Unlock()
+	return len(s.jobNotify.any)
+}
@@ -0,0 +1,784 @@
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/snappy"
+	"github.com/hashicorp/go-memdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/logbuffer"
+)
+
+// The terminal output of jobs is buffered in memory for streaming and is
+// also persisted so that it survives the server restarting. Each write is
+// persisted as a chunk keyed by the job ID and a sequence number, and the
+// output buffers of jobs are rehydrated from the chunks when the server
+// starts. The chunks of a job are removed when it is purged, and when it
+// starts running again so that only the output of its last run is kept.
+// The events are buffered as structured entries with a level, step, and
+// stream so that readers can filter them. The buffer of each job can be
+// bounded so that runaway output is dropped or slows down the runner
+// rather than exhausting memory. Chunks are persisted compressed,
+// and buffers can store their events compressed too. Since output
+// dominates the size of the database, the output of completed jobs can
+// also be pruned by age and total size with JobOutputPrune while their
+// job records are kept.
+
+var jobOutputBucket = []byte("job_output")
+
+func init() {
+	dbBuckets = append(dbBuckets, jobOutputBucket)
+}
+
+// JobOutputWrite writes terminal events to the output of the running job
+// with the given ID. The events are written to the output buffer even if
+// they can't be persisted.
+func (s *State) JobOutputWrite(id string, events []*pb.GetJobStreamResponse_Terminal_Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	raw, err := memTxn.First(jobTableName, jobIdIndexName, id)
+	if err != nil {
+		return err
+	}
+	if raw == nil {
+		return status.Errorf(codes.NotFound, "job not found: %s", id)
+	}
+	buf := raw.(*jobIndex).OutputBuffer
+	if buf == nil {
+		return status.Errorf(codes.FailedPrecondition,
+			"job is not running: %s", id)
+	}
+	memTxn.Abort()
+
+	// Stamp the events with when we received them so that they have
+	// accurate times even if the clock of the runner is skewed.
+	now, err := ptypes.TimestampProto(s.clock.Now())
+	if err != nil {
+		return err
+	}
+	for _, ev := range events {
+		if ev.ReceiveTime == nil {
+			ev.ReceiveTime = now
+		}
+	}
+
+	buf.Write(jobOutputEntries(events)...)
+
+	data, err := jobOutputMarshal(events)
+	if err != nil {
+		return err
+	}
+
+	return s.dbBatch(func(dbTxn *bolt.Tx) error {
+		b := dbTxn.Bucket(jobOutputBucket)
+		return dbPutRaw(b, jobOutputKey(id, jobOutputNextSeq(b, id)), data)
+	})
+}
+
+// JobOutputLimitSet sets the maximum total size in bytes of the output
+// buffered in memory across all jobs. When the total goes over this limit,
+// the output buffers of completed jobs are evicted, least recently
+// completed first. The output of running jobs is never evicted.
+//
+// A max of zero or less disables the limit. This should be called once
+// before the state is used. The output that was rehydrated when the state
+// was opened is tracked against the limit.
+func (s *State) JobOutputLimitSet(max int64) {
+	if max <= 0 {
+		s.jobOutputTracker = nil
+		return
+	}
+
+	s.jobOutputTracker = logbuffer.NewTracker(max, s.jobOutputOverLimit)
+
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+	iter, err := memTxn.Get(jobTableName, jobIdIndexName+"_prefix", "")
+	if err != nil {
+		// This can't happen since the index exists.
+		panic(err)
+	}
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		if buf := raw.(*jobIndex).OutputBuffer; buf != nil {
+			buf.Track(s.jobOutputTracker)
+		}
+	}
+}
+
+// JobOutputSize returns the total size in bytes of the output buffered
+// in memory across all jobs. This is only tracked if a limit is set with
+// JobOutputLimitSet, otherwise this always returns zero.
+func (s *State) JobOutputSize() int64 {
+	if s.jobOutputTracker == nil {
+		return 0
+	}
+
+	return s.jobOutputTracker.Total()
+}
+
+// jobOutputNew creates a new output buffer for a job, tracking it
+// against our limit if we have one.
+func (s *State) jobOutputNew() *logbuffer.Buffer {
+	result := logbuffer.New()
+	if s.jobOutputCompress {
+		result.Compress(jobOutputCodec{})
+	}
+	if s.jobOutputTracker != nil {
+		result.Track(s.jobOutputTracker)
+	}
+	if s.jobOutputBufferMax > 0 {
+		result.Limit(s.jobOutputBufferMax, s.jobOutputBufferMode, s.jobOutputDropped)
+	}
+
+	return result
+}
+
+// JobOutputBufferLimitSet sets the maximum size in bytes of the output
+// buffered in memory for a single job, so that a job with runaway output
+// can't exhaust the memory of the server. When the output of a job goes
+// over this limit, the output buffered so far is dropped and replaced by
+// a warning. If block is true, writes of the output instead wait until
+// every stream of the job has read the buffered output before dropping
+// it, which slows down the runner rather than losing output.
+//
+// Output that is dropped from the buffer is still persisted. A max of
+// zero or less disables the limit. This should be called once before the
+// state is used.
+func (s *State) JobOutputBufferLimitSet(max int64, block bool) {
+	s.jobOutputBufferMax = max
+	s.jobOutputBufferMode = logbuffer.LimitTruncate
+	if block {
+		s.jobOutputBufferMode = logbuffer.LimitBlock
+	}
+
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+	iter, err := memTxn.Get(jobTableName, jobIdIndexName+"_prefix", "")
+	if err != nil {
+		// This can't happen since the index exists.
+		panic(err)
+	}
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		if buf := raw.(*jobIndex).OutputBuffer; buf != nil {
+			buf.Limit(max, s.jobOutputBufferMode, s.jobOutputDropped)
+		}
+	}
+}
+
+// jobOutputDropped returns the warning that replaces output dropped from
+// a job output buffer that went over its limit.
+func (s *State) jobOutputDropped(dropped int) logbuffer.Entry {
+	ts, _ := ptypes.TimestampProto(s.clock.Now())
+	return jobOutputEntries([]*pb.GetJobStreamResponse_Terminal_Event{{
+		Timestamp:   ts,
+		ReceiveTime: ts,
+		Event: &pb.GetJobStreamResponse_Terminal_Event_Line_{
+			Line: &pb.GetJobStreamResponse_Terminal_Event_Line{
+				Msg: fmt.Sprintf(
+					"%d earlier lines of output were dropped because the output "+
+						"of this job is over the server's buffer limit.", dropped),
+				Style: "warning",
+			},
+		},
+	}})[0]
+}
+
+// JobOutputCompressSet sets whether job output buffers store their
+// events compressed, which uses less memory for verbose output at the
+// cost of decompressing it for each reader. This is disabled by default.
+// This should be called once before the state is used. The output that
+// was rehydrated when the state was created is buffered again compressed.
+func (s *State) JobOutputCompressSet(enabled bool) error {
+	s.jobOutputCompress = enabled
+	if !enabled {
+		return nil
+	}
+
+	memTxn := s.inmemWriteTxn()
+	defer memTxn.Abort()
+	iter, err := memTxn.Get(jobTableName, jobIdIndexName+"_prefix", "")
+	if err != nil {
+		return err
+	}
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		if job := raw.(*jobIndex); job.OutputBuffer != nil {
+			job.OutputBuffer.Close()
+			job.OutputBuffer = s.jobOutputNew()
+		}
+	}
+
+	err = s.dbView(func(dbTxn *bolt.Tx) error {
+		return s.jobOutputInit(dbTxn, memTxn)
+	})
+	if err != nil {
+		return err
+	}
+
+	memTxn.Commit()
+	return nil
+}
+
+// jobOutputCodec encodes the events of job output buffers so the buffers
+// can store them compressed.
+type jobOutputCodec struct{}
+
+func (jobOutputCodec) Encode(e logbuffer.Entry) ([]byte, error) {
+	if ev, ok := e.(*logbuffer.Event); ok {
+		e = ev.Data
+	}
+
+	m, ok := e.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("entry must be a terminal event: %#v", e)
+	}
+
+	return proto.Marshal(m)
+}
+
+func (jobOutputCodec) Decode(data []byte) (logbuffer.Entry, error) {
+	var ev pb.GetJobStreamResponse_Terminal_Event
+	if err := proto.Unmarshal(data, &ev); err != nil {
+		return nil, err
+	}
+
+	return jobOutputEntries([]*pb.GetJobStreamResponse_Terminal_Event{&ev})[0], nil
+}
+
+// jobOutputOverLimit is called by the output tracker whenever the total
+// output size goes over the limit. This is called with a buffer lock held
+// so we only start an eviction in the background, and only one at a time.
+func (s *State) jobOutputOverLimit() {
+	if !atomic.CompareAndSwapUint32(&s.jobOutputEvicting, 0, 1) {
+		return
+	}
+
+	go func() {
+		defer atomic.StoreUint32(&s.jobOutputEvicting, 0)
+		if err := s.jobOutputEvict(); err != nil {
+			s.log.Warn("error evicting job output buffers", "err", err)
+		}
+	}()
+}
+
+// jobOutputEvict closes and drops the output buffers of completed jobs
+// until the total buffered output is under the limit.
+func (s *State) jobOutputEvict() error {
+	tracker := s.jobOutputTracker
+	if tracker == nil {
+		return nil
+	}
+
+	txn := s.inmemWriteTxn()
+	defer txn.Abort()
+
+	// Find all the completed jobs that still have output.
+	jobs, err := jobsByState(txn, pb.Job_SUCCESS, pb.Job_ERROR)
+	if err != nil {
+		return err
+	}
+
+	var candidates []*jobIndex
+	for _, job := range jobs {
+		if job.OutputBuffer != nil {
+			candidates = append(candidates, job)
+		}
+	}
+
+	// Evict the jobs that completed the longest time ago first.
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].EndTime.Before(candidates[j].EndTime)
+	})
+
+	var evicted int
+	for _, job := range candidates {
+		if !tracker.Over() {
+			break
+		}
+
+		job.OutputBuffer.Close()
+		job = job.Copy()
+		job.OutputBuffer = nil
+		if err := txn.Insert(jobTableName, job); err != nil {
+			return err
+		}
+
+		evicted++
+	}
+
+	txn.Commit()
+
+	s.log.Debug("evicted job output buffers",
+		"evicted", evicted,
+		"total", tracker.Total(),
+		"max", tracker.Max())
+	if tracker.Over() {
+		s.log.Warn("job output is over the limit and only running jobs have output, "+
+			"output memory usage will continue to grow until jobs complete",
+			"total", tracker.Total(),
+			"max", tracker.Max())
+	}
+
+	return nil
+}
+
+// JobOutputPrune removes the persisted output of completed jobs that
+// completed more than maxAge ago, and then the output of the completed
+// jobs that completed longest ago until the persisted output is at most
+// maxBytes. Zero disables either limit. The output of jobs that aren't
+// complete is never removed, and the output buffers of the pruned jobs
+// are dropped as well. This returns the number of jobs whose output was
+// removed and the bytes that were removed.
+func (s *State) JobOutputPrune(maxAge time.Duration, maxBytes int64) (int, int64, error) {
+	if maxAge <= 0 && maxBytes <= 0 {
+		return 0, 0, nil
+	}
+
+	type candidate struct {
+		id   string
+		end  time.Time
+		size int64
+	}
+
+	// Find the size of the output of each job and when it completed. The
+	// output of deleted jobs is pruned first.
+	var total int64
+	var candidates []*candidate
+	err := s.dbView(func(dbTxn *bolt.Tx) error {
+		sizes := map[string]int64{}
+		var ids []string
+		err := dbTxn.Bucket(jobOutputBucket).ForEach(func(k, v []byte) error {
+			id := jobOutputIdFromKey(k)
+			if _, ok := sizes[id]; !ok {
+				ids = append(ids, id)
+			}
+
+			sizes[id] += int64(len(v))
+			total += int64(len(v))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			c := &candidate{id: id, size: sizes[id]}
+			job, err := s.jobById(dbTxn, id)
+			if err != nil && status.Code(err) != codes.NotFound {
+				return err
+			}
+			if err == nil && job.DeleteTime == nil {
+				if job.State != pb.Job_SUCCESS && job.State != pb.Job_ERROR {
+					continue
+				}
+
+				if job.CompleteTime != nil {
+					if c.end, err = ptypes.Timestamp(job.CompleteTime); err != nil {
+						return err
+					}
+				}
+			}
+
+			candidates = append(candidates, c)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].end.Before(candidates[j].end)
+	})
+
+	now := s.clock.Now()
+	var pruned []string
+	var prunedSize int64
+	for _, c := range candidates {
+		if !(maxAge > 0 && now.Sub(c.end) > maxAge) && !(maxBytes > 0 && total > maxBytes) {
+			break
+		}
+
+		pruned = append(pruned, c.id)
+		prunedSize += c.size
+		total -= c.size
+	}
+	if len(pruned) == 0 {
+		return 0, 0, nil
+	}
+
+	err = s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		for _, id := range pruned {
+			if err := jobOutputDelete(dbTxn, id); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Drop the output buffers too so the output is gone the same way it
+	// would be after a restart.
+	memTxn := s.inmemWriteTxn()
+	defer memTxn.Abort()
+	for _, id := range pruned {
+		raw, err := memTxn.First(jobTableName, jobIdIndexName, id)
+		if err != nil {
+			return 0, 0, err
+		}
+		if raw == nil {
+			continue
+		}
+
+		job := raw.(*jobIndex)
+		if job.OutputBuffer == nil || (job.State != pb.Job_SUCCESS && job.State != pb.Job_ERROR) {
+			continue
+		}
+
+		job.OutputBuffer.Close()
+		job = job.Copy()
+		job.OutputBuffer = nil
+		if err := memTxn.Insert(jobTableName, job); err != nil {
+			return 0, 0, err
+		}
+	}
+	memTxn.Commit()
+
+	return len(pruned), prunedSize, nil
+}
+
+// jobOutputEntries sets the level, step, and stream of the terminal
+// events that the runner didn't set and returns the events as structured
+// entries for an output buffer. The events are updated in place so the
+// structure is persisted with them.
+func jobOutputEntries(events []*pb.GetJobStreamResponse_Terminal_Event) []logbuffer.Entry {
+	entries := make([]logbuffer.Entry, len(events))
+	for i, ev := range events {
+		var (
+			msg string
+			typ logbuffer.EventType
+		)
+		level := pb.GetJobStreamResponse_Terminal_Event_INFO
+		switch e := ev.Event.(type) {
+		case *pb.GetJobStreamResponse_Terminal_Event_Line_:
+			typ = logbuffer.EventLine
+			msg = e.Line.Msg
+			level = jobOutputStyleLevel(e.Line.Style)
+
+		case *pb.GetJobStreamResponse_Terminal_Event_Status_:
+			typ = logbuffer.EventStatus
+			msg = e.Status.Msg
+			level = jobOutputStatusLevel(e.Status.Status)
+
+		case *pb.GetJobStreamResponse_Terminal_Event_Raw_:
+			typ = logbuffer.EventRaw
+			msg = string(e.Raw.Data)
+			if ev.Stream == pb.GetJobStreamResponse_Terminal_Event_STREAM_UNKNOWN {
+				ev.Stream = pb.GetJobStreamResponse_Terminal_Event_STDOUT
+				if e.Raw.Stderr {
+					ev.Stream = pb.GetJobStreamResponse_Terminal_Event_STDERR
+				}
+			}
+
+		case *pb.GetJobStreamResponse_Terminal_Event_NamedValues_:
+			typ = logbuffer.EventNamedValues
+			msg = jobOutputNamedValuesText(e.NamedValues)
+
+		case *pb.GetJobStreamResponse_Terminal_Event_Table_:
+			typ = logbuffer.EventTable
+			msg = jobOutputTableText(e.Table)
+
+		case *pb.GetJobStreamResponse_Terminal_Event_StepGroup_:
+			typ = logbuffer.EventStepGroup
+
+		case *pb.GetJobStreamResponse_Terminal_Event_Step_:
+			typ = logbuffer.EventStep
+			msg = e.Step.Msg
+			level = jobOutputStatusLevel(e.Step.Status)
+			if ev.StepId == "" {
+				ev.StepId = strconv.Itoa(int(e.Step.Id))
+			}
+
+			// The output of a step is the raw output of what it runs.
+			if len(e.Step.Output) > 0 {
+				typ = logbuffer.EventRaw
+				msg = string(e.Step.Output)
+				if ev.Stream == pb.GetJobStreamResponse_Terminal_Event_STREAM_UNKNOWN {
+					ev.Stream = pb.GetJobStreamResponse_Terminal_Event_STDOUT
+				}
+			}
+		}
+		if ev.Level == pb.GetJobStreamResponse_Terminal_Event_LEVEL_UNKNOWN {
+			ev.Level = level
+		}
+
+		// The timestamps are zero if they weren't set. Output persisted
+		// before receive times were recorded doesn't have them.
+		var ts, received time.Time
+		if ev.Timestamp != nil {
+			ts, _ = ptypes.Timestamp(ev.Timestamp)
+		}
+		if ev.ReceiveTime != nil {
+			received, _ = ptypes.Timestamp(ev.ReceiveTime)
+		}
+
+		// The levels and streams of the protobuf enums and the log buffer
+		// have the same values.
+		entries[i] = &logbuffer.Event{
+			Timestamp:   ts,
+			ReceiveTime: received,
+			Type:        typ,
+			Stream:      logbuffer.Stream(ev.Stream),
+			Step:        ev.StepId,
+			Level:       logbuffer.Level(ev.Level),
+			Message:     msg,
+			Data:        ev,
+		}
+	}
+
+	return entries
+}
+
+// jobOutputNamedValuesText renders named values as text with a value
+// per line.
+func jobOutputNamedValuesText(nv *pb.GetJobStreamResponse_Terminal_Event_NamedValues) string {
+	var buf strings.Builder
+	for _, v := range nv.Values {
+		fmt.Fprintf(&buf, "%s: %s\n", v.Name, v.Value)
+	}
+
+	return buf.String()
+}
+
+// jobOutputTableText renders a table as text with a row per line and the
+// cells of a row separated by tabs.
+func jobOutputTableText(tbl *pb.GetJobStreamResponse_Terminal_Event_Table) string {
+	var buf strings.Builder
+	if len(tbl.Headers) > 0 {
+		buf.WriteString(strings.Join(tbl.Headers, "\t"))
+		buf.WriteString("\n")
+	}
+	for _, row := range tbl.Rows {
+		values := make([]string, len(row.Entries))
+		for i, e := range row.Entries {
+			values[i] = e.Value
+		}
+
+		buf.WriteString(strings.Join(values, "\t"))
+		buf.WriteString("\n")
+	}
+
+	return buf.String()
+}
+
+// jobOutputStyleLevel returns the level of a terminal line with the given
+// style.
+func jobOutputStyleLevel(style string) pb.GetJobStreamResponse_Terminal_Event_Level {
+	switch style {
+	case "error", "error-bold":
+		return pb.GetJobStreamResponse_Terminal_Event_ERROR
+
+	case "warning", "warning-bold":
+		return pb.GetJobStreamResponse_Terminal_Event_WARN
+
+	default:
+		return pb.GetJobStreamResponse_Terminal_Event_INFO
+	}
+}
+
+// jobOutputStatusLevel returns the level of a terminal status or step
+// with the given status.
+func jobOutputStatusLevel(status string) pb.GetJobStreamResponse_Terminal_Event_Level {
+	switch status {
+	case "error":
+		return pb.GetJobStreamResponse_Terminal_Event_ERROR
+
+	case "warn":
+		return pb.GetJobStreamResponse_Terminal_Event_WARN
+
+	default:
+		return pb.GetJobStreamResponse_Terminal_Event_INFO
+	}
+}
+
+// jobOutputCompressed is the first byte of persisted output chunks that
+// are compressed with snappy. Output persisted before chunks were
+// compressed is the marshaled chunk, which can't start with a zero byte
+// since protobuf field numbers start at one.
+const jobOutputCompressed = 0
+
+// jobOutputMarshal returns the persisted form of an output chunk with
+// the given events.
+func jobOutputMarshal(events []*pb.GetJobStreamResponse_Terminal_Event) ([]byte, error) {
+	data, err := proto.Marshal(&pb.GetJobStreamResponse_Terminal{Events: events})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, 1, 1+snappy.MaxEncodedLen(len(data)))
+	result[0] = jobOutputCompressed
+	return append(result, snappy.Encode(nil, data)...), nil
+}
+
+// jobOutputUnmarshal returns the output chunk of its persisted form.
+func jobOutputUnmarshal(v []byte) (*pb.GetJobStreamResponse_Terminal, error) {
+	if len(v) > 0 && v[0] == jobOutputCompressed {
+		var err error
+		if v, err = snappy.Decode(nil, v[1:]); err != nil {
+			return nil, err
+		}
+	}
+
+	var result pb.GetJobStreamResponse_Terminal
+	if err := proto.Unmarshal(v, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// jobOutputKey is the key of the persisted output chunk of the job with
+// the given sequence number. Chunks are ordered by sequence number within
+// each job.
+func jobOutputKey(id string, seq uint64) []byte {
+	key := make([]byte, len(id)+9)
+	copy(key, id)
+	key[len(id)] = '/'
+	binary.BigEndian.PutUint64(key[len(id)+1:], seq)
+	return key
+}
+
+// jobOutputNextSeq returns the sequence number of the next output chunk
+// of the job. This is one more than the sequence number of the last chunk
+// rather than the sequence of the bucket, since the sequence of the bucket
+// isn't replicated or kept when the database is compacted or restored.
+func jobOutputNextSeq(b *bolt.Bucket, id string) uint64 {
+	prefix := []byte(id + "/")
+	c := b.Cursor()
+	k, _ := c.Seek(jobOutputKey(id, math.MaxUint64))
+	if k == nil {
+		k, _ = c.Last()
+	} else if !bytes.Equal(k, jobOutputKey(id, math.MaxUint64)) {
+		k, _ = c.Prev()
+	}
+
+	// IDs that have this ID as a prefix sort within the prefix too.
+	for ; k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Prev() {
+		if jobOutputIdFromKey(k) == id {
+			return binary.BigEndian.Uint64(k[len(id)+1:]) + 1
+		}
+	}
+
+	return 1
+}
+
+// jobOutputIdFromKey returns the job ID of a persisted output chunk key.
+func jobOutputIdFromKey(k []byte) string {
+	if len(k) < 9 {
+		return ""
+	}
+
+	return string(k[:len(k)-9])
+}
+
+// jobOutputDelete removes the persisted output of the job.
+func jobOutputDelete(dbTxn *bolt.Tx, id string) error {
+	// Collect the keys first since a bucket can't be modified while it
+	// is iterated.
+	var keys [][]byte
+	prefix := []byte(id + "/")
+	c := dbTxn.Bucket(jobOutputBucket).Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		if jobOutputIdFromKey(k) == id {
+			keys = append(keys, append([]byte(nil), k...))
+		}
+	}
+	for _, k := range keys {
+		if err := dbDelete(dbTxn.Bucket(jobOutputBucket), k); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// jobOutputReset removes the persisted output of a previous run of the job
+// when it starts running again.
+func (s *State) jobOutputReset(id string) error {
+	var found bool
+	prefix := []byte(id + "/")
+	err := s.dbView(func(dbTxn *bolt.Tx) error {
+		k, _ := dbTxn.Bucket(jobOutputBucket).Cursor().Seek(prefix)
+		found = k != nil && bytes.HasPrefix(k, prefix)
+		return nil
+	})
+	if err != nil || !found {
+		return err
+	}
+
+	return s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		return jobOutputDelete(dbTxn, id)
+	})
+}
+
+// jobOutputInit rehydrates the output buffers of the indexed jobs from
+// their persisted output.
+func (s *State) jobOutputInit(dbTxn *bolt.Tx, memTxn *memdb.Txn) error {
+	var (
+		id  string
+		idx *jobIndex
+	)
+	return dbTxn.Bucket(jobOutputBucket).ForEach(func(k, v []byte) error {
+		if kid := jobOutputIdFromKey(k); kid != id {
+			id = kid
+			idx = nil
+
+			raw, err := memTxn.First(jobTableName, jobIdIndexName, id)
+			if err != nil {
+				return err
+			}
+			if raw == nil {
+				return nil
+			}
+
+			idx = raw.(*jobIndex)
+			if idx.OutputBuffer == nil {
+				idx.OutputBuffer = s.jobOutputNew()
+			}
+		}
+		if idx == nil {
+			return nil
+		}
+
+		chunk, err := jobOutputUnmarshal(v)
+		if err != nil {
+			return err
+		}
+
+		idx.OutputBuffer.Write(jobOutputEntries(chunk.Events)...)
+
+		return nil
+	})
+}
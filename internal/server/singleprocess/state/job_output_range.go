@@ -0,0 +1,122 @@
+package state
+
+import (
+	"bytes"
+
+	"github.com/boltdb/bolt"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/logbuffer"
+)
+
+const (
+	// jobOutputRangeDefaultLimit and jobOutputRangeMaxLimit are the default
+	// and largest number of events a range returns.
+	jobOutputRangeDefaultLimit = 1000
+	jobOutputRangeMaxLimit     = 10000
+)
+
+// JobOutputRange returns up to limit events of the persisted output of the
+// job with the given ID starting at the line offset, where each event is a
+// line. A negative offset counts back from the end of the output. This
+// also returns the offset of the first event returned and the total number
+// of events of the job. The output of a job that doesn't exist is empty.
+func (s *State) JobOutputRange(
+	id string,
+	offset int64,
+	limit int,
+) ([]*pb.GetJobStreamResponse_Terminal_Event, int64, int64, error) {
+	if limit <= 0 {
+		limit = jobOutputRangeDefaultLimit
+	}
+	if limit > jobOutputRangeMaxLimit {
+		limit = jobOutputRangeMaxLimit
+	}
+
+	var (
+		result []*pb.GetJobStreamResponse_Terminal_Event
+		total  int64
+	)
+	err := s.dbView(func(dbTxn *bolt.Tx) error {
+		// Resolve an offset from the end with a first pass that only
+		// counts the events.
+		if offset < 0 {
+			err := jobOutputForEach(dbTxn, id, func(chunk *pb.GetJobStreamResponse_Terminal) error {
+				total += int64(len(chunk.Events))
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			offset += total
+			if offset < 0 {
+				offset = 0
+			}
+			total = 0
+		}
+
+		end := offset + int64(limit)
+		return jobOutputForEach(dbTxn, id, func(chunk *pb.GetJobStreamResponse_Terminal) error {
+			for _, ev := range chunk.Events {
+				if total >= offset && total < end {
+					result = append(result, ev)
+				}
+
+				total++
+			}
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	// An offset past the end starts at the end.
+	if offset > total {
+		offset = total
+	}
+
+	return result, offset, total, nil
+}
+
+// JobOutputEach calls cb with the events of each write of the persisted
+// output of the job with the given ID in the order they were written. The
+// events are structured entries like the entries of the output buffer of
+// the job. cb is called within a read transaction, so it shouldn't block.
+func (s *State) JobOutputEach(id string, cb func([]logbuffer.Entry) error) error {
+	return s.dbView(func(dbTxn *bolt.Tx) error {
+		return jobOutputForEach(dbTxn, id, func(chunk *pb.GetJobStreamResponse_Terminal) error {
+			return cb(jobOutputEntries(chunk.Events))
+		})
+	})
+}
+
+// jobOutputForEach calls cb with each persisted output chunk of the job in
+// the order they were written.
+func jobOutputForEach(
+	dbTxn *bolt.Tx,
+	id string,
+	cb func(*pb.GetJobStreamResponse_Terminal) error,
+) error {
+	prefix := []byte(id + "/")
+	c := dbTxn.Bucket(jobOutputBucket).Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		// IDs that have this ID as a prefix sort within the prefix too.
+		if jobOutputIdFromKey(k) != id {
+			continue
+		}
+
+		chunk, err := jobOutputUnmarshal(v)
+		if err != nil {
+			return err
+		}
+
+		if err := cb(chunk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package state
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobOutputRange(t *testing.T) {
+	ctx := context.Background()
+
+	s := TestState(t)
+	defer s.Close()
+
+	line := func(msg string) *pb.GetJobStreamResponse_Terminal_Event {
+		return &pb.GetJobStreamResponse_Terminal_Event{
+			Event: &pb.GetJobStreamResponse_Terminal_Event_Line_{
+				Line: &pb.GetJobStreamResponse_Terminal_Event_Line{Msg: msg},
+			},
+		}
+	}
+
+	msgs := func(events []*pb.GetJobStreamResponse_Terminal_Event) []string {
+		var result []string
+		for _, ev := range events {
+			result = append(result, ev.Event.(*pb.GetJobStreamResponse_Terminal_Event_Line_).Line.Msg)
+		}
+		return result
+	}
+
+	// Run a job that writes ten lines over a few writes, and a job with an
+	// ID that has the first ID as a prefix.
+	for _, id := range []string{"A", "AB"} {
+		require.NoError(t, s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id: id,
+		})))
+		_, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(t, err)
+		_, err = s.JobAck(ctx, id, true)
+		require.NoError(t, err)
+	}
+	for i := 0; i < 10; i += 2 {
+		require.NoError(t, s.JobOutputWrite("A", []*pb.GetJobStreamResponse_Terminal_Event{
+			line(strconv.Itoa(i)), line(strconv.Itoa(i + 1)),
+		}))
+	}
+	require.NoError(t, s.JobOutputWrite("AB", []*pb.GetJobStreamResponse_Terminal_Event{
+		line("other"),
+	}))
+
+	t.Run("from the start", func(t *testing.T) {
+		require := require.New(t)
+
+		events, offset, total, err := s.JobOutputRange("A", 0, 3)
+		require.NoError(err)
+		require.Equal([]string{"0", "1", "2"}, msgs(events))
+		require.Equal(int64(0), offset)
+		require.Equal(int64(10), total)
+	})
+
+	t.Run("within a write", func(t *testing.T) {
+		require := require.New(t)
+
+		events, offset, _, err := s.JobOutputRange("A", 3, 4)
+		require.NoError(err)
+		require.Equal([]string{"3", "4", "5", "6"}, msgs(events))
+		require.Equal(int64(3), offset)
+	})
+
+	t.Run("from the end", func(t *testing.T) {
+		require := require.New(t)
+
+		events, offset, total, err := s.JobOutputRange("A", -3, 0)
+		require.NoError(err)
+		require.Equal([]string{"7", "8", "9"}, msgs(events))
+		require.Equal(int64(7), offset)
+		require.Equal(int64(10), total)
+
+		// Further back than the start starts at the start
+		events, offset, _, err = s.JobOutputRange("A", -50, 2)
+		require.NoError(err)
+		require.Equal([]string{"0", "1"}, msgs(events))
+		require.Equal(int64(0), offset)
+	})
+
+	t.Run("past the end", func(t *testing.T) {
+		require := require.New(t)
+
+		events, offset, total, err := s.JobOutputRange("A", 20, 0)
+		require.NoError(err)
+		require.Empty(events)
+		require.Equal(int64(10), offset)
+		require.Equal(int64(10), total)
+	})
+
+	t.Run("no output", func(t *testing.T) {
+		require := require.New(t)
+
+		events, offset, total, err := s.JobOutputRange("nope", -10, 0)
+		require.NoError(err)
+		require.Empty(events)
+		require.Equal(int64(0), offset)
+		require.Equal(int64(0), total)
+	})
+}
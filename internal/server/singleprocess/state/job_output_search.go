@@ -0,0 +1,210 @@
+package state
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/logbuffer"
+)
+
+const (
+	// jobOutputSearchDefaultLimit and jobOutputSearchMaxLimit are the
+	// default and largest number of matches a search returns.
+	jobOutputSearchDefaultLimit = 100
+	jobOutputSearchMaxLimit     = 1000
+)
+
+// JobOutputSearch searches the persisted output of jobs for the events
+// whose message matches the query of the request. The jobs to search are
+// found with the scope index, so searches scoped to a project or
+// application only read the output of its jobs. This returns the matches
+// of the most recently queued jobs first and true if there were more
+// matches than the limit.
+func (s *State) JobOutputSearch(
+	req *pb.SearchJobLogsRequest,
+) ([]*pb.SearchJobLogsResponse_Match, bool, error) {
+	match, err := jobOutputSearchMatcher(req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var start, end time.Time
+	if req.StartTime != nil {
+		if start, err = ptypes.Timestamp(req.StartTime); err != nil {
+			return nil, false, status.Errorf(codes.InvalidArgument,
+				"invalid start time: %s", err)
+		}
+	}
+	if req.EndTime != nil {
+		if end, err = ptypes.Timestamp(req.EndTime); err != nil {
+			return nil, false, status.Errorf(codes.InvalidArgument,
+				"invalid end time: %s", err)
+		}
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = jobOutputSearchDefaultLimit
+	}
+	if limit > jobOutputSearchMaxLimit {
+		limit = jobOutputSearchMaxLimit
+	}
+
+	jobs, err := s.jobOutputSearchJobs(req.Application, req.Workspace)
+	if err != nil {
+		return nil, false, err
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].QueueTime.After(jobs[j].QueueTime)
+	})
+
+	// The levels of the protobuf enum and the log buffer have the same
+	// values.
+	levelOk := logbuffer.LevelFilter(logbuffer.Level(req.Level))
+
+	var result []*pb.SearchJobLogsResponse_Match
+	truncated := false
+	err = s.dbView(func(dbTxn *bolt.Tx) error {
+		c := dbTxn.Bucket(jobOutputBucket).Cursor()
+		for _, job := range jobs {
+			// Jobs queued after the end of the range can't have logged
+			// in it.
+			if !end.IsZero() && job.QueueTime.After(end) {
+				continue
+			}
+
+			prefix := []byte(job.Id + "/")
+			for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+				if jobOutputIdFromKey(k) != job.Id {
+					continue
+				}
+
+				chunk, err := jobOutputUnmarshal(v)
+				if err != nil {
+					return err
+				}
+
+				for _, entry := range jobOutputEntries(chunk.Events) {
+					ev := entry.(*logbuffer.Event)
+					if !start.IsZero() || !end.IsZero() {
+						// Prefer when the server received the event since
+						// the clock of the runner may be skewed.
+						ts := ev.ReceiveTime
+						if ts.IsZero() {
+							ts = ev.Timestamp
+						}
+
+						if ts.IsZero() ||
+							(!start.IsZero() && ts.Before(start)) ||
+							(!end.IsZero() && ts.After(end)) {
+							continue
+						}
+					}
+					if !levelOk(ev) || !match(ev.Message) {
+						continue
+					}
+
+					if len(result) >= limit {
+						truncated = true
+						return nil
+					}
+
+					result = append(result, &pb.SearchJobLogsResponse_Match{
+						JobId:       job.Id,
+						Application: job.Application,
+						Workspace:   job.Workspace,
+						Event:       ev.Data.(*pb.GetJobStreamResponse_Terminal_Event),
+						Message:     ev.Message,
+					})
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return result, truncated, nil
+}
+
+// jobOutputSearchJobs returns the jobs in the scope of a search. If app is
+// nil, all jobs are returned.
+func (s *State) jobOutputSearchJobs(
+	app *pb.Ref_Application,
+	ws *pb.Ref_Workspace,
+) ([]*jobIndex, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	if app != nil {
+		if app.Project == "" {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"the project of the application is required")
+		}
+
+		return s.jobsByScope(memTxn, nil, app.Project, app.Application, ws.GetWorkspace())
+	}
+
+	iter, err := memTxn.Get(jobTableName, jobIdIndexName+"_prefix", "")
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*jobIndex
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		job := raw.(*jobIndex)
+		if ws != nil && !strings.EqualFold(job.Workspace.GetWorkspace(), ws.Workspace) {
+			continue
+		}
+
+		result = append(result, job)
+	}
+
+	return result, nil
+}
+
+// jobOutputSearchMatcher returns the function that matches event messages
+// for the query of a search.
+func jobOutputSearchMatcher(req *pb.SearchJobLogsRequest) (func(string) bool, error) {
+	if req.Query == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "a query is required")
+	}
+
+	if req.Regex {
+		expr := req.Query
+		if req.IgnoreCase {
+			expr = "(?i)" + expr
+		}
+
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"invalid regular expression: %s", err)
+		}
+
+		return re.MatchString, nil
+	}
+
+	if req.IgnoreCase {
+		query := strings.ToLower(req.Query)
+		return func(msg string) bool {
+			return strings.Contains(strings.ToLower(msg), query)
+		}, nil
+	}
+
+	return func(msg string) bool {
+		return strings.Contains(msg, req.Query)
+	}, nil
+}
@@ -0,0 +1,130 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobOutputSearch(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	clock := TestClockNew()
+	s := TestState(t, WithClock(clock))
+	defer s.Close()
+
+	// The output of A is received two hours before the output of B
+	now := clock.Now().Add(2 * time.Hour)
+	line := func(msg, style string, ts time.Time) *pb.GetJobStreamResponse_Terminal_Event {
+		pts, err := ptypes.TimestampProto(ts)
+		require.NoError(err)
+		return &pb.GetJobStreamResponse_Terminal_Event{
+			Timestamp: pts,
+			Event: &pb.GetJobStreamResponse_Terminal_Event_Line_{
+				Line: &pb.GetJobStreamResponse_Terminal_Event_Line{Msg: msg, Style: style},
+			},
+		}
+	}
+
+	// Run a job of each app that writes the given output
+	for _, job := range []struct {
+		id     string
+		app    string
+		events []*pb.GetJobStreamResponse_Terminal_Event
+	}{
+		{"A", "web", []*pb.GetJobStreamResponse_Terminal_Event{
+			line("deploying web", "", now.Add(-2*time.Hour)),
+			line("connection refused", "error", now.Add(-2*time.Hour)),
+		}},
+		{"B", "api", []*pb.GetJobStreamResponse_Terminal_Event{
+			// The clock of this runner is skewed
+			line("Connection Refused by upstream", "warning", now.Add(-24*time.Hour)),
+		}},
+	} {
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id: job.id,
+			Application: &pb.Ref_Application{
+				Project:     "p",
+				Application: job.app,
+			},
+		})))
+		assigned, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		require.Equal(job.id, assigned.Id)
+		_, err = s.JobAck(ctx, job.id, true)
+		require.NoError(err)
+		require.NoError(s.JobOutputWrite(job.id, job.events))
+		require.NoError(s.JobComplete(ctx, job.id, nil, nil))
+		clock.Advance(2 * time.Hour)
+	}
+
+	search := func(req *pb.SearchJobLogsRequest) []string {
+		matches, _, err := s.JobOutputSearch(req)
+		require.NoError(err)
+
+		var result []string
+		for _, m := range matches {
+			result = append(result, m.JobId+":"+m.Message)
+		}
+		return result
+	}
+
+	// Substring, most recently queued jobs first
+	require.Equal([]string{
+		"B:Connection Refused by upstream",
+		"A:connection refused",
+	}, search(&pb.SearchJobLogsRequest{Query: "refused", IgnoreCase: true}))
+	require.Equal([]string{"A:connection refused"},
+		search(&pb.SearchJobLogsRequest{Query: "refused"}))
+
+	// Regex
+	require.Equal([]string{"A:deploying web"},
+		search(&pb.SearchJobLogsRequest{Query: "^deploy.*web$", Regex: true}))
+
+	// Scoped to an application
+	require.Equal([]string{"B:Connection Refused by upstream"},
+		search(&pb.SearchJobLogsRequest{
+			Query:       "refused",
+			IgnoreCase:  true,
+			Application: &pb.Ref_Application{Project: "p", Application: "api"},
+		}))
+	require.Empty(search(&pb.SearchJobLogsRequest{
+		Query:       "refused",
+		Application: &pb.Ref_Application{Project: "other"},
+	}))
+
+	// Scoped to a time range, by when the server received the output
+	start, err := ptypes.TimestampProto(now.Add(-time.Hour))
+	require.NoError(err)
+	require.Equal([]string{"B:Connection Refused by upstream"},
+		search(&pb.SearchJobLogsRequest{Query: "Refused", StartTime: start}))
+
+	// By level
+	require.Equal([]string{"A:connection refused"},
+		search(&pb.SearchJobLogsRequest{
+			Query:      "refused",
+			IgnoreCase: true,
+			Level:      pb.GetJobStreamResponse_Terminal_Event_ERROR,
+		}))
+
+	// Limited
+	matches, truncated, err := s.JobOutputSearch(&pb.SearchJobLogsRequest{Query: "e", Limit: 1})
+	require.NoError(err)
+	require.Len(matches, 1)
+	require.True(truncated)
+
+	// Invalid queries
+	_, _, err = s.JobOutputSearch(&pb.SearchJobLogsRequest{})
+	require.Equal(codes.InvalidArgument, status.Code(err))
+	_, _, err = s.JobOutputSearch(&pb.SearchJobLogsRequest{Query: "(", Regex: true})
+	require.Equal(codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,620 @@
+package state
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/go-memdb"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/logbuffer"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobOutputLimit(t *testing.T) {
+	t.Run("evicts completed job output", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		s.JobOutputLimitSet(1024)
+
+		line := func(n int) *pb.GetJobStreamResponse_Terminal_Event {
+			return &pb.GetJobStreamResponse_Terminal_Event{
+				Event: &pb.GetJobStreamResponse_Terminal_Event_Line_{
+					Line: &pb.GetJobStreamResponse_Terminal_Event_Line{
+						Msg: strings.Repeat("x", n),
+					},
+				},
+			}
+		}
+
+		// Create, run, and complete a job with some output.
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id: "A",
+		})))
+		job, err := s.JobAssignForRunner(context.Background(), &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		job, err = s.JobAck(context.Background(), job.Id, true)
+		require.NoError(err)
+		job.OutputBuffer.Write(line(512))
+		require.True(s.JobOutputSize() > 512)
+		require.NoError(s.JobComplete(context.Background(), job.Id, nil, nil))
+
+		// Start a second job and write enough to go over the limit.
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id: "B",
+		})))
+		job, err = s.JobAssignForRunner(context.Background(), &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		job, err = s.JobAck(context.Background(), job.Id, true)
+		require.NoError(err)
+		job.OutputBuffer.Write(line(768))
+
+		// The completed job's output should be evicted.
+		require.Eventually(func() bool {
+			job, err := s.JobById("A", nil)
+			require.NoError(err)
+			return job.OutputBuffer == nil
+		}, 2*time.Second, 10*time.Millisecond)
+		require.True(s.JobOutputSize() <= 1024)
+
+		// The running job should keep its output
+		job, err = s.JobById("B", memdb.NewWatchSet())
+		require.NoError(err)
+		require.NotNil(job.OutputBuffer)
+	})
+
+	t.Run("never evicts running job output", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		s.JobOutputLimitSet(64)
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id: "A",
+		})))
+		job, err := s.JobAssignForRunner(context.Background(), &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		job, err = s.JobAck(context.Background(), job.Id, true)
+		require.NoError(err)
+		job.OutputBuffer.Write(&pb.GetJobStreamResponse_Terminal_Event{
+			Event: &pb.GetJobStreamResponse_Terminal_Event_Line_{
+				Line: &pb.GetJobStreamResponse_Terminal_Event_Line{
+					Msg: strings.Repeat("x", 128),
+				},
+			},
+		})
+
+		require.NoError(s.jobOutputEvict())
+		job, err = s.JobById("A", nil)
+		require.NoError(err)
+		require.NotNil(job.OutputBuffer)
+		require.True(s.JobOutputSize() > 64)
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id: "A",
+		})))
+		job, err := s.JobAssignForRunner(context.Background(), &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		job, err = s.JobAck(context.Background(), job.Id, true)
+		require.NoError(err)
+		job.OutputBuffer.Write(nil)
+		require.Equal(int64(0), s.JobOutputSize())
+	})
+
+	t.Run("drops output of a single job over the buffer limit", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		s.JobOutputBufferLimitSet(1024, false)
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id: "A",
+		})))
+		job, err := s.JobAssignForRunner(context.Background(), &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		job, err = s.JobAck(context.Background(), job.Id, true)
+		require.NoError(err)
+
+		line := func(msg string) *pb.GetJobStreamResponse_Terminal_Event {
+			return &pb.GetJobStreamResponse_Terminal_Event{
+				Event: &pb.GetJobStreamResponse_Terminal_Event_Line_{
+					Line: &pb.GetJobStreamResponse_Terminal_Event_Line{Msg: msg},
+				},
+			}
+		}
+		for i := 0; i < 3; i++ {
+			require.NoError(s.JobOutputWrite(job.Id, []*pb.GetJobStreamResponse_Terminal_Event{
+				line(strings.Repeat("x", 400)),
+			}))
+		}
+
+		// The first two lines are replaced by a warning
+		entries := job.OutputBuffer.Reader(-1).Read(10, false)
+		require.Len(entries, 2)
+		ev := entries[0].(*logbuffer.Event)
+		require.Equal(logbuffer.LevelWarn, ev.Level)
+		require.Contains(ev.Message, "2 earlier lines")
+		require.Equal(strings.Repeat("x", 400), entries[1].(*logbuffer.Event).Message)
+	})
+}
+
+func TestJobOutputPersist(t *testing.T) {
+	ctx := context.Background()
+
+	line := func(msg string) *pb.GetJobStreamResponse_Terminal_Event {
+		return &pb.GetJobStreamResponse_Terminal_Event{
+			Event: &pb.GetJobStreamResponse_Terminal_Event_Line_{
+				Line: &pb.GetJobStreamResponse_Terminal_Event_Line{Msg: msg},
+			},
+		}
+	}
+
+	// lines reads the buffered output of the job.
+	lines := func(t *testing.T, s *State, id string) []string {
+		job, err := s.JobById(id, nil)
+		require.NoError(t, err)
+		if job.OutputBuffer == nil {
+			return nil
+		}
+
+		var result []string
+		r := job.OutputBuffer.Reader(-1)
+		defer r.Close()
+		for {
+			entries := r.Read(64, false)
+			if entries == nil {
+				return result
+			}
+
+			for _, e := range entries {
+				ev := e.(*logbuffer.Event).Data.(*pb.GetJobStreamResponse_Terminal_Event)
+				result = append(result, ev.Event.(*pb.GetJobStreamResponse_Terminal_Event_Line_).Line.Msg)
+			}
+		}
+	}
+
+	// run assigns and acks the next job.
+	run := func(t *testing.T, s *State) *Job {
+		job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(t, err)
+		job, err = s.JobAck(ctx, job.Id, true)
+		require.NoError(t, err)
+		return job
+	}
+
+	t.Run("output survives a restart", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		run(t, s)
+		require.NoError(s.JobOutputWrite("A", []*pb.GetJobStreamResponse_Terminal_Event{line("one"), line("two")}))
+		require.NoError(s.JobOutputWrite("A", []*pb.GetJobStreamResponse_Terminal_Event{line("three")}))
+		require.NoError(s.JobComplete(ctx, "A", nil, nil))
+		require.Equal([]string{"one", "two", "three"}, lines(t, s, "A"))
+
+		s = TestStateReinit(t, s)
+		defer s.Close()
+		require.Equal([]string{"one", "two", "three"}, lines(t, s, "A"))
+
+		// Rehydrated output is tracked against the limit
+		s.JobOutputLimitSet(1024 * 1024)
+		require.True(s.JobOutputSize() > 0)
+	})
+
+	t.Run("output is persisted compressed", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		run(t, s)
+		require.NoError(s.JobOutputWrite("A", []*pb.GetJobStreamResponse_Terminal_Event{line("one")}))
+
+		// Write a chunk the way it was persisted before compression
+		data, err := proto.Marshal(&pb.GetJobStreamResponse_Terminal{
+			Events: []*pb.GetJobStreamResponse_Terminal_Event{line("two")},
+		})
+		require.NoError(err)
+		require.NoError(s.dbUpdate(func(dbTxn *bolt.Tx) error {
+			b := dbTxn.Bucket(jobOutputBucket)
+			v := b.Get(jobOutputKey("A", 1))
+			require.NotEmpty(v)
+			require.Equal(byte(jobOutputCompressed), v[0])
+
+			return dbPutRaw(b, jobOutputKey("A", 2), data)
+		}))
+
+		s = TestStateReinit(t, s)
+		defer s.Close()
+		require.Equal([]string{"one", "two"}, lines(t, s, "A"))
+	})
+
+	t.Run("buffers store output compressed", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		run(t, s)
+		require.NoError(s.JobOutputWrite("A", []*pb.GetJobStreamResponse_Terminal_Event{
+			line(strings.Repeat("building layer ", 64)),
+		}))
+
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		size := job.OutputBuffer.Size()
+
+		// Rehydrated output is buffered again compressed
+		s = TestStateReinit(t, s)
+		defer s.Close()
+		require.NoError(s.JobOutputCompressSet(true))
+		job, err = s.JobById("A", nil)
+		require.NoError(err)
+		require.True(job.OutputBuffer.Size() < size)
+		require.NoError(s.JobOutputWrite("A", []*pb.GetJobStreamResponse_Terminal_Event{line("done")}))
+
+		require.Equal([]string{strings.Repeat("building layer ", 64), "done"}, lines(t, s, "A"))
+
+		// Readers get structured events
+		r := job.OutputBuffer.Reader(-1)
+		defer r.Close()
+		entries := r.Read(1, false)
+		require.Len(entries, 1)
+		require.Equal(logbuffer.LevelInfo, entries[0].(*logbuffer.Event).Level)
+	})
+
+	t.Run("output of a previous run is replaced", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		require.NoError(s.RunnerDisconnectPolicySet(RunnerDisconnectRequeue))
+
+		require.NoError(s.RunnerCreate(&pb.Runner{Id: "R_A"}))
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		run(t, s)
+		require.NoError(s.JobOutputWrite("A", []*pb.GetJobStreamResponse_Terminal_Event{line("first")}))
+
+		// The runner disconnects and the job runs again
+		require.NoError(s.RunnerDisconnect("R_A"))
+		require.NoError(s.RunnerCreate(&pb.Runner{Id: "R_A"}))
+		run(t, s)
+		require.NoError(s.JobOutputWrite("A", []*pb.GetJobStreamResponse_Terminal_Event{line("second")}))
+
+		s = TestStateReinit(t, s)
+		defer s.Close()
+		require.Equal([]string{"second"}, lines(t, s, "A"))
+	})
+
+	t.Run("output written after compaction is appended", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		run(t, s)
+		require.NoError(s.JobOutputWrite("A", []*pb.GetJobStreamResponse_Terminal_Event{line("one")}))
+		require.NoError(s.JobOutputWrite("A", []*pb.GetJobStreamResponse_Terminal_Event{line("two")}))
+
+		// Compacting doesn't keep the sequence of the bucket
+		_, _, err := s.Compact()
+		require.NoError(err)
+		require.NoError(s.JobOutputWrite("A", []*pb.GetJobStreamResponse_Terminal_Event{line("three")}))
+
+		events, _, total, err := s.JobOutputRange("A", 0, 0)
+		require.NoError(err)
+		require.Equal(int64(3), total)
+		require.Equal("three", events[2].Event.(*pb.GetJobStreamResponse_Terminal_Event_Line_).Line.Msg)
+	})
+
+	t.Run("output written through raft is appended", func(t *testing.T) {
+		require := require.New(t)
+
+		td, err := ioutil.TempDir("", "test")
+		require.NoError(err)
+		defer os.RemoveAll(td)
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(err)
+		addr := ln.Addr().String()
+		ln.Close()
+
+		// Replicated writes are rolled back once they're recorded, so the
+		// sequence of the bucket never advances.
+		s, err := NewRaft(ctx, hclog.L(), testDB(t), &RaftConfig{
+			ID:   "a",
+			Addr: addr,
+			Dir:  td,
+		})
+		require.NoError(err)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		run(t, s)
+		for _, msg := range []string{"one", "two", "three"} {
+			require.NoError(s.JobOutputWrite("A", []*pb.GetJobStreamResponse_Terminal_Event{line(msg)}))
+		}
+
+		events, _, total, err := s.JobOutputRange("A", 0, 0)
+		require.NoError(err)
+		require.Equal(int64(3), total)
+		require.Equal("one", events[0].Event.(*pb.GetJobStreamResponse_Terminal_Event_Line_).Line.Msg)
+	})
+
+	t.Run("job must be running", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		err := s.JobOutputWrite("A", []*pb.GetJobStreamResponse_Terminal_Event{line("x")})
+		require.Equal(codes.FailedPrecondition, status.Code(err))
+
+		err = s.JobOutputWrite("B", []*pb.GetJobStreamResponse_Terminal_Event{line("x")})
+		require.Equal(codes.NotFound, status.Code(err))
+	})
+}
+
+func TestJobOutputPrune(t *testing.T) {
+	ctx := context.Background()
+
+	events := []*pb.GetJobStreamResponse_Terminal_Event{{
+		Event: &pb.GetJobStreamResponse_Terminal_Event_Line_{
+			Line: &pb.GetJobStreamResponse_Terminal_Event_Line{Msg: "hello"},
+		},
+	}}
+
+	// run queues, runs, and writes output for a job, completing it if
+	// complete is true.
+	run := func(t *testing.T, s *State, id string, complete bool) {
+		require := require.New(t)
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: id})))
+		job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		_, err = s.JobAck(ctx, job.Id, true)
+		require.NoError(err)
+		require.NoError(s.JobOutputWrite(id, events))
+		if complete {
+			require.NoError(s.JobComplete(ctx, id, nil, nil))
+		}
+	}
+
+	// persisted returns the IDs of the jobs with persisted output.
+	persisted := func(t *testing.T, s *State) []string {
+		var result []string
+		require.NoError(t, s.dbView(func(dbTxn *bolt.Tx) error {
+			return dbTxn.Bucket(jobOutputBucket).ForEach(func(k, v []byte) error {
+				if id := jobOutputIdFromKey(k); len(result) == 0 || result[len(result)-1] != id {
+					result = append(result, id)
+				}
+				return nil
+			})
+		}))
+		return result
+	}
+
+	t.Run("by age", func(t *testing.T) {
+		require := require.New(t)
+
+		clock := TestClockNew()
+		s := TestState(t, WithClock(clock))
+		defer s.Close()
+
+		run(t, s, "A", true)
+		clock.Advance(2 * time.Hour)
+		run(t, s, "B", true)
+		run(t, s, "C", false)
+		clock.Advance(2 * time.Hour)
+
+		n, size, err := s.JobOutputPrune(3*time.Hour, 0)
+		require.NoError(err)
+		require.Equal(1, n)
+		require.True(size > 0)
+		require.Equal([]string{"B", "C"}, persisted(t, s))
+
+		// The job is kept but its output is gone
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.Nil(job.OutputBuffer)
+
+		// Running jobs are never pruned
+		n, _, err = s.JobOutputPrune(time.Hour, 0)
+		require.NoError(err)
+		require.Equal(1, n)
+		require.Equal([]string{"C"}, persisted(t, s))
+	})
+
+	t.Run("by size", func(t *testing.T) {
+		require := require.New(t)
+
+		clock := TestClockNew()
+		s := TestState(t, WithClock(clock))
+		defer s.Close()
+
+		run(t, s, "A", true)
+		clock.Advance(time.Minute)
+		run(t, s, "B", true)
+		clock.Advance(time.Minute)
+		run(t, s, "C", true)
+
+		// Prune down to the size of one job's output
+		var one int64
+		require.NoError(s.dbView(func(dbTxn *bolt.Tx) error {
+			return dbTxn.Bucket(jobOutputBucket).ForEach(func(k, v []byte) error {
+				if jobOutputIdFromKey(k) == "C" {
+					one += int64(len(v))
+				}
+				return nil
+			})
+		}))
+
+		n, _, err := s.JobOutputPrune(0, one)
+		require.NoError(err)
+		require.Equal(2, n)
+		require.Equal([]string{"C"}, persisted(t, s))
+
+		// Nothing more to prune
+		n, _, err = s.JobOutputPrune(0, one)
+		require.NoError(err)
+		require.Equal(0, n)
+	})
+}
+
+func TestJobOutputEntries(t *testing.T) {
+	require := require.New(t)
+
+	events := []*pb.GetJobStreamResponse_Terminal_Event{
+		{
+			Event: &pb.GetJobStreamResponse_Terminal_Event_Line_{
+				Line: &pb.GetJobStreamResponse_Terminal_Event_Line{Msg: "warning", Style: "warning-bold"},
+			},
+		},
+		{
+			Event: &pb.GetJobStreamResponse_Terminal_Event_Step_{
+				Step: &pb.GetJobStreamResponse_Terminal_Event_Step{Id: 3, Msg: "deploying", Status: "error"},
+			},
+		},
+		{
+			Event: &pb.GetJobStreamResponse_Terminal_Event_Raw_{
+				Raw: &pb.GetJobStreamResponse_Terminal_Event_Raw{Data: []byte("oops"), Stderr: true},
+			},
+		},
+		{
+			// Structure set by the runner is kept
+			Level: pb.GetJobStreamResponse_Terminal_Event_DEBUG,
+			Event: &pb.GetJobStreamResponse_Terminal_Event_Line_{
+				Line: &pb.GetJobStreamResponse_Terminal_Event_Line{Msg: "debug"},
+			},
+		},
+		{
+			Event: &pb.GetJobStreamResponse_Terminal_Event_Table_{
+				Table: &pb.GetJobStreamResponse_Terminal_Event_Table{
+					Headers: []string{"Name", "Status"},
+					Rows: []*pb.GetJobStreamResponse_Terminal_Event_TableRow{
+						{Entries: []*pb.GetJobStreamResponse_Terminal_Event_TableEntry{
+							{Value: "web"}, {Value: "ready", Color: "green"},
+						}},
+					},
+				},
+			},
+		},
+		{
+			Event: &pb.GetJobStreamResponse_Terminal_Event_Step_{
+				Step: &pb.GetJobStreamResponse_Terminal_Event_Step{Id: 3, Output: []byte("pulling\n")},
+			},
+		},
+	}
+
+	entries := jobOutputEntries(events)
+	require.Len(entries, 6)
+
+	ev := entries[0].(*logbuffer.Event)
+	require.Equal(logbuffer.LevelWarn, ev.Level)
+	require.Equal("warning", ev.Message)
+
+	ev = entries[1].(*logbuffer.Event)
+	require.Equal(logbuffer.EventStep, ev.Type)
+	require.Equal(logbuffer.LevelError, ev.Level)
+	require.Equal("3", ev.Step)
+	require.Equal("deploying", ev.Message)
+
+	ev = entries[2].(*logbuffer.Event)
+	require.Equal(logbuffer.EventRaw, ev.Type)
+	require.Equal(logbuffer.LevelInfo, ev.Level)
+	require.Equal(logbuffer.StreamStderr, ev.Stream)
+	require.Equal("oops", ev.Message)
+
+	ev = entries[3].(*logbuffer.Event)
+	require.Equal(logbuffer.LevelDebug, ev.Level)
+	require.Equal(logbuffer.EventLine, ev.Type)
+
+	// Terminal UI elements are typed and rendered as text
+	ev = entries[4].(*logbuffer.Event)
+	require.Equal(logbuffer.EventTable, ev.Type)
+	require.Equal("Name\tStatus\nweb\tready\n", ev.Message)
+	require.Equal(events[4], ev.Data)
+
+	// The output of a step is raw output that is part of the step
+	ev = entries[5].(*logbuffer.Event)
+	require.Equal(logbuffer.EventRaw, ev.Type)
+	require.Equal(logbuffer.StreamStdout, ev.Stream)
+	require.Equal("3", ev.Step)
+	require.Equal("pulling\n", ev.Message)
+
+	// The events are updated so the structure is persisted
+	require.Equal(pb.GetJobStreamResponse_Terminal_Event_ERROR, events[1].Level)
+	require.Equal("3", events[1].StepId)
+	require.Equal(pb.GetJobStreamResponse_Terminal_Event_STDERR, events[2].Stream)
+}
+
+func TestJobOutputWrite_receiveTime(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	clock := TestClockNew()
+	s := TestState(t, WithClock(clock))
+	defer s.Close()
+
+	require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+		Id: "A",
+	})))
+	job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+	require.NoError(err)
+	job, err = s.JobAck(ctx, job.Id, true)
+	require.NoError(err)
+
+	// The runner's clock is a day behind
+	skewed, err := ptypes.TimestampProto(clock.Now().Add(-24 * time.Hour))
+	require.NoError(err)
+	require.NoError(s.JobOutputWrite(job.Id, []*pb.GetJobStreamResponse_Terminal_Event{
+		{
+			Timestamp: skewed,
+			Event: &pb.GetJobStreamResponse_Terminal_Event_Line_{
+				Line: &pb.GetJobStreamResponse_Terminal_Event_Line{Msg: "hello"},
+			},
+		},
+	}))
+
+	// The buffered event has both times
+	ev := job.OutputBuffer.Reader(-1).Read(1, false)[0].(*logbuffer.Event)
+	require.True(ev.ReceiveTime.Equal(clock.Now()))
+	require.True(ev.Timestamp.Equal(clock.Now().Add(-24 * time.Hour)))
+
+	// The receive time is persisted with the event
+	events, _, _, err := s.JobOutputRange(job.Id, 0, 0)
+	require.NoError(err)
+	require.Len(events, 1)
+	received, err := ptypes.Timestamp(events[0].ReceiveTime)
+	require.NoError(err)
+	require.True(received.Equal(clock.Now()))
+}
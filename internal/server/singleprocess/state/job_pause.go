@@ -0,0 +1,221 @@
+package state
+
+import (
+	"strings"
+
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hashicorp/go-memdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to pausing the job queue. Queued jobs
+// of a paused project, application, or workspace stay queued and aren't
+// assigned to runners until the queue is resumed. Jobs that are already
+// assigned keep running.
+
+var jobPauseBucket = []byte("job_pause")
+
+func init() {
+	dbBuckets = append(dbBuckets, jobPauseBucket)
+	dbIndexers = append(dbIndexers, (*State).jobPauseIndexInit)
+	schemas = append(schemas, jobPauseIndexSchema)
+	projectDeleteHooks = append(projectDeleteHooks, jobPauseProjectDelete)
+}
+
+// JobQueuePause pauses the assignment of the queued jobs that match the
+// pause. If the application or workspace is empty, the jobs of every
+// application or in every workspace of the project are paused.
+func (s *State) JobQueuePause(p *pb.JobQueuePause) error {
+	if p.Project == "" {
+		return status.Errorf(codes.FailedPrecondition, "project must be set")
+	}
+
+	p = proto.Clone(p).(*pb.JobQueuePause)
+	var err error
+	p.PauseTime, err = ptypes.TimestampProto(s.clock.Now())
+	if err != nil {
+		return err
+	}
+
+	memTxn := s.inmemWriteTxn()
+	defer memTxn.Abort()
+
+	id := jobPauseId(p.Project, p.Application, p.Workspace)
+	err = s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		return dbPut(dbTxn.Bucket(jobPauseBucket), id, p)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.jobPauseIndexSet(memTxn, id, p); err != nil {
+		return err
+	}
+
+	memTxn.Commit()
+	return nil
+}
+
+// JobQueueResume resumes the assignment of the jobs paused with the same
+// project, application, and workspace. Resuming a queue that isn't paused
+// does nothing.
+func (s *State) JobQueueResume(p *pb.JobQueuePause) error {
+	memTxn := s.inmemWriteTxn()
+	defer memTxn.Abort()
+
+	id := jobPauseId(p.Project, p.Application, p.Workspace)
+	err := s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		return dbDelete(dbTxn.Bucket(jobPauseBucket), id)
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := memTxn.DeleteAll(jobPauseIndexTableName, jobPauseIndexIdIndexName, string(id)); err != nil {
+		return err
+	}
+
+	memTxn.Commit()
+
+	// Wake the runners that skipped jobs of the project.
+	s.jobNotify.notifyKey(jobNotifyPauseKey(p.Project))
+	return nil
+}
+
+// JobQueuePauses returns the paused job queues.
+func (s *State) JobQueuePauses() ([]*pb.JobQueuePause, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	iter, err := memTxn.Get(jobPauseIndexTableName, jobPauseIndexIdIndexName+"_prefix", "")
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*pb.JobQueuePause
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		result = append(result, proto.Clone(raw.(*jobPauseIndexRecord).Pause).(*pb.JobQueuePause))
+	}
+
+	return result, nil
+}
+
+// jobPauseProjectDelete deletes the paused queues of a project that is
+// deleted.
+func jobPauseProjectDelete(
+	s *State,
+	dbTxn *bolt.Tx,
+	memTxn *memdb.Txn,
+	ref *pb.Ref_Project,
+) (func(), error) {
+	iter, err := memTxn.Get(jobPauseIndexTableName, jobPauseIndexIdIndexName+"_prefix",
+		strings.ToLower(ref.Project+"/"))
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		ids = append(ids, raw.(*jobPauseIndexRecord).Id)
+	}
+
+	for _, id := range ids {
+		if err := dbDelete(dbTxn.Bucket(jobPauseBucket), []byte(id)); err != nil {
+			return nil, err
+		}
+		if _, err := memTxn.DeleteAll(jobPauseIndexTableName, jobPauseIndexIdIndexName, id); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// jobPaused returns true if the assignment of the job is paused.
+func (s *State) jobPaused(memTxn *memdb.Txn, idx *jobIndex) (bool, error) {
+	if idx.Application == nil || idx.Workspace == nil {
+		return false, nil
+	}
+
+	project := idx.Application.Project
+	for _, app := range []string{idx.Application.Application, ""} {
+		for _, ws := range []string{idx.Workspace.Workspace, ""} {
+			raw, err := memTxn.First(
+				jobPauseIndexTableName,
+				jobPauseIndexIdIndexName,
+				string(jobPauseId(project, app, ws)),
+			)
+			if err != nil {
+				return false, err
+			}
+			if raw != nil {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// jobPauseIndexSet writes an index record for a paused queue.
+func (s *State) jobPauseIndexSet(txn *memdb.Txn, id []byte, value *pb.JobQueuePause) error {
+	return txn.Insert(jobPauseIndexTableName, &jobPauseIndexRecord{
+		Id:    string(id),
+		Pause: value,
+	})
+}
+
+// jobPauseIndexInit initializes the paused queue index from persisted data.
+func (s *State) jobPauseIndexInit(dbTxn *bolt.Tx, memTxn *memdb.Txn) error {
+	bucket := dbTxn.Bucket(jobPauseBucket)
+	return bucket.ForEach(func(k, v []byte) error {
+		var value pb.JobQueuePause
+		if err := proto.Unmarshal(v, &value); err != nil {
+			return err
+		}
+
+		return s.jobPauseIndexSet(memTxn, k, &value)
+	})
+}
+
+func jobPauseId(project, app, ws string) []byte {
+	return []byte(strings.ToLower(project + "/" + app + "/" + ws))
+}
+
+// jobNotifyPauseKey is the notification key for resuming the jobs of
+// the project.
+func jobNotifyPauseKey(project string) string {
+	return "pause:" + strings.ToLower(project)
+}
+
+func jobPauseIndexSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: jobPauseIndexTableName,
+		Indexes: map[string]*memdb.IndexSchema{
+			jobPauseIndexIdIndexName: {
+				Name:         jobPauseIndexIdIndexName,
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "Id",
+					Lowercase: true,
+				},
+			},
+		},
+	}
+}
+
+const (
+	jobPauseIndexTableName   = "job-pause-index"
+	jobPauseIndexIdIndexName = "id"
+)
+
+type jobPauseIndexRecord struct {
+	Id    string
+	Pause *pb.JobQueuePause
+}
@@ -0,0 +1,129 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobQueuePause(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("paused jobs aren't assigned until resumed", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		pause := &pb.JobQueuePause{Project: "p_test", Workspace: "w_test"}
+		require.NoError(s.JobQueuePause(pause))
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id: "A",
+		})))
+
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.True(job.Paused)
+
+		// Jobs in other workspaces are still assigned
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "B",
+			Workspace: &pb.Ref_Workspace{Workspace: "other"},
+		})))
+		job, err = s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		require.Equal("B", job.Id)
+
+		// Wait for the paused job
+		doneCh := make(chan *Job, 1)
+		go func() {
+			job, _ := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_B"})
+			doneCh <- job
+		}()
+
+		select {
+		case <-doneCh:
+			t.Fatal("paused job should not be assigned")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		pauses, err := s.JobQueuePauses()
+		require.NoError(err)
+		require.Len(pauses, 1)
+		require.NotNil(pauses[0].PauseTime)
+
+		// Resume, which wakes the runner
+		require.NoError(s.JobQueueResume(pause))
+		select {
+		case job := <-doneCh:
+			require.NotNil(job)
+			require.Equal("A", job.Id)
+
+		case <-time.After(1 * time.Second):
+			t.Fatal("job should be assigned once resumed")
+		}
+
+		pauses, err = s.JobQueuePauses()
+		require.NoError(err)
+		require.Empty(pauses)
+	})
+
+	t.Run("project pauses match every app and workspace", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobQueuePause(&pb.JobQueuePause{Project: "P_TEST"}))
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id: "A",
+		})))
+
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.True(job.Paused)
+
+		actx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, err = s.JobAssignForRunner(actx, &pb.Runner{Id: "R_A"})
+		require.Equal(context.DeadlineExceeded, err)
+	})
+
+	t.Run("pauses are persisted", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobQueuePause(&pb.JobQueuePause{
+			Project:     "p_test",
+			Application: "a_test",
+		}))
+
+		s, err := TestStateRestart(t, s)
+		require.NoError(err)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id: "A",
+		})))
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.True(job.Paused)
+	})
+
+	t.Run("project is required", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.Error(s.JobQueuePause(&pb.JobQueuePause{}))
+	})
+}
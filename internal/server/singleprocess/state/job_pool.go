@@ -0,0 +1,127 @@
+package state
+
+import (
+	"math"
+	"time"
+
+	"github.com/hashicorp/go-memdb"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to jobs that target a runner pool.
+// Runners join a pool when they register with the Runner.Pool field and
+// these jobs can be assigned to any runner in the pool. This separates
+// runners for different purposes, such as runners that deploy to
+// production from runners that build. Runners in a pool are still assigned
+// jobs that target any runner.
+
+// jobCandidatePool returns up to limit candidate jobs that target the
+// runner's pool, or all of them if limit is zero. Candidates are ordered
+// by priority, highest first, and then queue time.
+func (s *State) jobCandidatePool(memTxn *memdb.Txn, w *jobWaiter, r *runnerRecord, limit int) ([]*jobIndex, error) {
+	if r.Pool == "" {
+		return nil, nil
+	}
+
+	iter, err := memTxn.LowerBound(
+		jobTableName,
+		jobTargetPoolIndexName,
+		pb.Job_QUEUED,
+		r.Pool,
+		int32(math.MaxInt32),
+		time.Unix(0, 0),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*jobIndex
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+
+		job := raw.(*jobIndex)
+		if job.State != pb.Job_QUEUED || job.TargetPool != r.Pool {
+			continue
+		}
+
+		// Jobs waiting for approval are not candidates. We're notified
+		// when they are approved.
+		if job.ApprovalPending {
+			continue
+		}
+
+		// Jobs of paused queues are not candidates. We're notified when
+		// the queue is resumed.
+		if paused, err := s.jobPaused(memTxn, job); err != nil {
+			return nil, err
+		} else if paused {
+			w.watch(jobNotifyPauseKey(job.Application.Project))
+			continue
+		}
+
+		// If this job is blocked, it is not a candidate. We watch for it
+		// to become unblocked in case we have to wait.
+		if blockedBy, err := s.jobBlockedBy(memTxn, job, nil); err != nil {
+			return nil, err
+		} else if blockedBy != "" {
+			w.watch(jobNotifyBlockedKey(job, blockedBy))
+			continue
+		}
+
+		// Jobs the runner can't run are not candidates. See
+		// job_capabilities.go.
+		if !jobCompatible(job, r.Runner) {
+			continue
+		}
+
+		// Jobs that don't fit in the runner's remaining capacity are not
+		// candidates.
+		if fits, err := s.jobFits(memTxn, job, r.Runner); err != nil {
+			return nil, err
+		} else if !fits {
+			continue
+		}
+
+		// Jobs that prefer another runner, or that avoid this one, are
+		// not candidates until their affinity wait ends. See
+		// job_affinity.go.
+		if held, err := s.jobAffinityHeld(memTxn, w, job, r.Runner); err != nil {
+			return nil, err
+		} else if held {
+			continue
+		}
+
+		// Jobs that prefer runners elsewhere are not candidates until
+		// their locality wait ends. See job_locality.go.
+		if held, err := s.jobLocalityHeld(memTxn, w, job, r.Runner); err != nil {
+			return nil, err
+		} else if held {
+			continue
+		}
+
+		// Jobs that a cheaper waiting runner can run are not candidates
+		// until their wait ends. See job_cost.go.
+		if held, err := s.jobCostHeld(memTxn, w, job, r.Runner); err != nil {
+			return nil, err
+		} else if held {
+			continue
+		}
+
+		result = append(result, job)
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// jobNotifyPoolKey is the notification key for jobs targeting the runners
+// of the given pool.
+func jobNotifyPoolKey(pool string) string {
+	return "pool:" + pool
+}
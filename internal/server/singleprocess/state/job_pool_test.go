@@ -0,0 +1,139 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobAssignForRunner_pool(t *testing.T) {
+	ctx := context.Background()
+
+	poolTarget := func(name string) *pb.Ref_Runner {
+		return &pb.Ref_Runner{
+			Target: &pb.Ref_Runner_Pool{
+				Pool: &pb.Ref_RunnerPool{Name: name},
+			},
+		}
+	}
+
+	t.Run("matches runners in the pool", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:           "A",
+			TargetRunner: poolTarget("deploy"),
+		})))
+
+		// Runners in no pool, another pool, or that are by ID only don't
+		// match
+		for _, r := range []*pb.Runner{
+			{Id: "R_A"},
+			{Id: "R_A", Pool: "build"},
+			{Id: "R_A", Pool: "deploy", ByIdOnly: true},
+		} {
+			actx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+			_, err := s.JobAssignForRunner(actx, r)
+			cancel()
+			require.Equal(context.DeadlineExceeded, err)
+		}
+
+		job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A", Pool: "deploy"})
+		require.NoError(err)
+		require.Equal("A", job.Id)
+	})
+
+	t.Run("runners in a pool are assigned jobs for any runner", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+
+		job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A", Pool: "deploy"})
+		require.NoError(err)
+		require.Equal("A", job.Id)
+	})
+
+	t.Run("wakes only runners in the pool", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		// Wait on a runner in another pool
+		otherCh := make(chan *Job, 1)
+		otherCtx, otherCancel := context.WithCancel(ctx)
+		defer otherCancel()
+		go func() {
+			job, _ := s.JobAssignForRunner(otherCtx, &pb.Runner{Id: "R_A", Pool: "build"})
+			otherCh <- job
+		}()
+
+		// Wait on a runner in the pool
+		doneCh := make(chan *Job, 1)
+		go func() {
+			job, _ := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_B", Pool: "deploy"})
+			doneCh <- job
+		}()
+
+		// Let both runners start waiting
+		time.Sleep(50 * time.Millisecond)
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:           "A",
+			TargetRunner: poolTarget("deploy"),
+		})))
+
+		select {
+		case job := <-doneCh:
+			require.NotNil(job)
+			require.Equal("A", job.Id)
+
+		case <-time.After(1 * time.Second):
+			t.Fatal("runner in the pool should be assigned the job")
+		}
+
+		otherCancel()
+		require.Nil(<-otherCh)
+	})
+}
+
+func TestJobIsAssignable_pool(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	s := TestState(t)
+	defer s.Close()
+
+	require.NoError(s.RunnerCreate(serverptypes.TestRunner(t, &pb.Runner{Pool: "deploy"})))
+	require.NoError(s.RunnerCreate(serverptypes.TestRunner(t, &pb.Runner{
+		Pool:     "build",
+		ByIdOnly: true,
+	})))
+
+	for pool, expected := range map[string]bool{
+		"deploy": true,
+		"build":  false,
+		"other":  false,
+	} {
+		result, err := s.JobIsAssignable(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			TargetRunner: &pb.Ref_Runner{
+				Target: &pb.Ref_Runner_Pool{
+					Pool: &pb.Ref_RunnerPool{Name: pool},
+				},
+			},
+		}))
+		require.NoError(err)
+		require.Equal(expected, result, pool)
+	}
+}
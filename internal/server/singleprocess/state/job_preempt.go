@@ -0,0 +1,213 @@
+package state
+
+import (
+	"context"
+
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hashicorp/go-memdb"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to preemption. When preemption is
+// enabled and a job is queued while no runner that can run it is waiting,
+// the lowest priority running job with a lower priority on a runner that
+// can run the new job is cancelled. Once its runner stops it, it is queued
+// again rather than failing and the runner is assigned the new job.
+
+// JobPreemptionSet sets whether queued jobs can preempt running jobs with
+// a lower priority. This is disabled by default. This should be called
+// once before the state is used.
+func (s *State) JobPreemptionSet(enabled bool) {
+	s.jobPreemption = enabled
+}
+
+// jobPreemptCheck preempts a running job for the queued job if possible.
+// This should be called once the job is queued if no runners that may be
+// assigned the job were waiting.
+func (s *State) jobPreemptCheck(idx *jobIndex) error {
+	if !s.jobPreemption {
+		return nil
+	}
+
+	// Jobs that can't be assigned yet or that run elsewhere don't preempt.
+	if idx.ApprovalPending || idx.Dispatched || idx.Federated {
+		return nil
+	}
+
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	// Jobs that are blocked or paused couldn't be assigned even if a
+	// runner was free.
+	if blocked, err := s.jobIsBlocked(memTxn, idx, nil); err != nil || blocked {
+		return err
+	}
+	if paused, err := s.jobPaused(memTxn, idx); err != nil || paused {
+		return err
+	}
+
+	victim, err := s.jobPreemptCandidate(memTxn, idx)
+	if err != nil || victim == nil {
+		return err
+	}
+	memTxn.Abort()
+
+	return s.jobPreempt(victim.Id, idx.Id)
+}
+
+// jobPreemptCandidate returns the running job to preempt for the given job,
+// or nil if there isn't one. The candidate has the lowest priority, and of
+// those, was queued last.
+func (s *State) jobPreemptCandidate(memTxn *memdb.Txn, idx *jobIndex) (*jobIndex, error) {
+	jobs, err := jobsByState(memTxn, pb.Job_RUNNING)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *jobIndex
+	for _, job := range jobs {
+		if job.PreemptedBy != "" {
+			continue
+		}
+		if job.Priority >= idx.Priority {
+			continue
+		}
+		if result != nil && !result.before(job) {
+			continue
+		}
+
+		// The runner running the job must be able to run the new job.
+		jobpb, err := s.jobByIdCached(job.Id)
+		if err != nil {
+			return nil, err
+		}
+		if jobpb.AssignedRunner == nil {
+			continue
+		}
+		raw, err := memTxn.First(runnerTableName, runnerIdIndexName, jobpb.AssignedRunner.Id)
+		if err != nil {
+			return nil, err
+		}
+		if raw == nil || !idx.targets(raw.(*runnerRecord).Runner) {
+			continue
+		}
+
+		result = job
+	}
+
+	return result, nil
+}
+
+// jobPreempt cancels the running job with the given ID so that the job
+// with the ID by can run. The runner is sent the cancellation and the job
+// is queued again once the runner stops it.
+func (s *State) jobPreempt(id, by string) error {
+	txn := s.inmemWriteTxn()
+	defer txn.Abort()
+
+	raw, err := txn.First(jobTableName, jobIdIndexName, id)
+	if err != nil || raw == nil {
+		return err
+	}
+	job := raw.(*jobIndex).Copy()
+
+	// The job may have completed or been preempted since we chose it.
+	if job.State != pb.Job_RUNNING || job.PreemptedBy != "" {
+		return nil
+	}
+
+	s.log.Info("preempting running job for a higher priority job",
+		"job", id, "preempted_by", by)
+
+	job.PreemptedBy = by
+	_, err = s.jobReadAndUpdate(context.Background(), job.Id, func(jobpb *pb.Job) error {
+		now, err := ptypes.TimestampProto(s.clock.Now())
+		if err != nil {
+			// This should never happen since encoding a time now should be safe
+			panic("time encoding failed: " + err.Error())
+		}
+
+		// Setting the cancel time sends the cancellation to the runner.
+		jobpb.CancelTime = now
+		if n := len(jobpb.Assignments); n > 0 {
+			jobpb.Assignments[n-1].PreemptTime = now
+			jobpb.Assignments[n-1].PreemptedBy = by
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := txn.Insert(jobTableName, job); err != nil {
+		return err
+	}
+
+	txn.Commit()
+	return nil
+}
+
+// jobPreemptRequeue queues a preempted job again once its runner stopped
+// it. The job keeps its original queue time so it is assigned before jobs
+// of the same priority that were queued after it.
+func (s *State) jobPreemptRequeue(txn *memdb.Txn, job *jobIndex) error {
+	if job.StateTimer != nil {
+		job.StateTimer.Stop()
+		job.StateTimer = nil
+	}
+	if job.MaxRunTimer != nil {
+		job.MaxRunTimer.Stop()
+		job.MaxRunTimer = nil
+	}
+
+	job.State = pb.Job_QUEUED
+	job.PreemptedBy = ""
+	job.HeartbeatTime = time.Time{}
+	job.Progress = nil
+	_, err := s.jobReadAndUpdate(context.Background(), job.Id, func(jobpb *pb.Job) error {
+		jobpb.State = job.State
+		jobpb.AssignTime = nil
+		jobpb.AckTime = nil
+		jobpb.CancelTime = nil
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return txn.Insert(jobTableName, job)
+}
+
+// targets returns true if the job can be assigned to the runner once the
+// runner's jobs are stopped.
+func (idx *jobIndex) targets(r *pb.Runner) bool {
+	// Drained and pending runners aren't assigned new jobs.
+	if r.Draining || r.Pending {
+		return false
+	}
+
+	if !resourcesFit(idx.Resources, r.Capacity, r.Capacity) {
+		return false
+	}
+
+	switch {
+	case idx.TargetAny:
+		return !r.ByIdOnly
+
+	case idx.TargetRunnerId != "":
+		return strings.EqualFold(idx.TargetRunnerId, r.Id)
+
+	case len(idx.TargetLabels) > 0:
+		return !r.ByIdOnly && runnerLabelsMatch(r, idx.TargetLabels)
+
+	case idx.TargetPool != "":
+		return !r.ByIdOnly && r.Pool == idx.TargetPool
+	}
+
+	return false
+}
@@ -0,0 +1,220 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobPreempt(t *testing.T) {
+	ctx := context.Background()
+
+	// run queues a job with the given priority and runs it on the runner.
+	run := func(t *testing.T, s *State, r *pb.Runner, id string, priority int32) {
+		require := require.New(t)
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:       id,
+			Priority: priority,
+		})))
+
+		job, err := s.JobAssignForRunner(ctx, r)
+		require.NoError(err)
+		require.Equal(id, job.Id)
+		_, err = s.JobAck(context.Background(), id, true)
+		require.NoError(err)
+	}
+
+	canceled := status.Error(codes.Canceled, "canceled")
+
+	t.Run("preempts and requeues a lower priority job", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		s.JobPreemptionSet(true)
+
+		r := serverptypes.TestRunner(t, nil)
+		require.NoError(s.RunnerCreate(r))
+		run(t, s, r, "A", 0)
+
+		// Queue a higher priority job with no free runner
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:       "B",
+			Priority: 10,
+		})))
+
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_RUNNING, job.State)
+		require.NotNil(job.CancelTime)
+		require.Len(job.Assignments, 1)
+		require.NotNil(job.Assignments[0].PreemptTime)
+		require.Equal("B", job.Assignments[0].PreemptedBy)
+
+		// The runner stops the job, which queues it again
+		require.NoError(s.JobComplete(context.Background(), "A", nil, canceled))
+		job, err = s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_QUEUED, job.State)
+		require.Nil(job.CancelTime)
+		require.Nil(job.Error)
+
+		// The higher priority job runs first and then the preempted job
+		for _, id := range []string{"B", "A"} {
+			job, err := s.JobAssignForRunner(ctx, r)
+			require.NoError(err)
+			require.Equal(id, job.Id)
+			require.Nil(job.CancelTime)
+			_, err = s.JobAck(context.Background(), id, true)
+			require.NoError(err)
+			require.NoError(s.JobComplete(context.Background(), id, nil, nil))
+		}
+
+		job, err = s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_SUCCESS, job.State)
+		require.Len(job.Assignments, 2)
+	})
+
+	t.Run("preempts the lowest priority job", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		s.JobPreemptionSet(true)
+
+		r1 := serverptypes.TestRunner(t, nil)
+		r2 := serverptypes.TestRunner(t, nil)
+		require.NoError(s.RunnerCreate(r1))
+		require.NoError(s.RunnerCreate(r2))
+		run(t, s, r1, "A", 1)
+		run(t, s, r2, "B", -1)
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:       "C",
+			Priority: 5,
+		})))
+
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.Nil(job.CancelTime)
+		job, err = s.JobById("B", nil)
+		require.NoError(err)
+		require.NotNil(job.CancelTime)
+	})
+
+	t.Run("does not preempt", func(t *testing.T) {
+		cases := []struct {
+			Name     string
+			Enabled  bool
+			Priority int32
+			Target   *pb.Ref_Runner
+		}{
+			{"when disabled", false, 10, nil},
+			{"equal priority", true, 0, nil},
+			{
+				"runners that can't run the job", true, 10,
+				&pb.Ref_Runner{
+					Target: &pb.Ref_Runner_Labels{
+						Labels: &pb.Ref_RunnerLabels{
+							Labels: map[string]string{"gpu": "true"},
+						},
+					},
+				},
+			},
+		}
+
+		for _, tt := range cases {
+			t.Run(tt.Name, func(t *testing.T) {
+				require := require.New(t)
+
+				s := TestState(t)
+				defer s.Close()
+				s.JobPreemptionSet(tt.Enabled)
+
+				r := serverptypes.TestRunner(t, nil)
+				require.NoError(s.RunnerCreate(r))
+				run(t, s, r, "A", 0)
+
+				require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+					Id:           "B",
+					Priority:     tt.Priority,
+					TargetRunner: tt.Target,
+				})))
+
+				job, err := s.JobById("A", nil)
+				require.NoError(err)
+				require.Nil(job.CancelTime)
+			})
+		}
+	})
+
+	t.Run("does not preempt if a runner is waiting", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		s.JobPreemptionSet(true)
+
+		r := serverptypes.TestRunner(t, nil)
+		require.NoError(s.RunnerCreate(r))
+		run(t, s, r, "A", 0)
+
+		// Wait on another runner
+		doneCh := make(chan *Job, 1)
+		go func() {
+			job, _ := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_B"})
+			doneCh <- job
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:       "B",
+			Priority: 10,
+		})))
+
+		select {
+		case job := <-doneCh:
+			require.NotNil(job)
+			require.Equal("B", job.Id)
+
+		case <-time.After(1 * time.Second):
+			t.Fatal("waiting runner should be assigned the job")
+		}
+
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.Nil(job.CancelTime)
+	})
+
+	t.Run("cancelled jobs are not requeued", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		s.JobPreemptionSet(true)
+
+		r := serverptypes.TestRunner(t, nil)
+		require.NoError(s.RunnerCreate(r))
+		run(t, s, r, "A", 0)
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:       "B",
+			Priority: 10,
+		})))
+		require.NoError(s.JobCancel("A", false, 0))
+		require.NoError(s.JobComplete(context.Background(), "A", nil, canceled))
+
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_ERROR, job.State)
+	})
+}
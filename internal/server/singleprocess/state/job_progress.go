@@ -0,0 +1,53 @@
+package state
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// JobProgress records the progress of a running job reported by its runner.
+// The progress is only kept in memory, like the job output, and watchers of
+// the job are woken so that it can be streamed.
+func (s *State) JobProgress(id string, p *pb.JobProgress) error {
+	if p.Percent > 100 {
+		return status.Errorf(codes.FailedPrecondition,
+			"progress percent must be at most 100, got %d", p.Percent)
+	}
+
+	txn := s.inmemWriteTxn()
+	defer txn.Abort()
+
+	raw, err := txn.First(jobTableName, jobIdIndexName, id)
+	if err != nil {
+		return err
+	}
+	if raw == nil {
+		return status.Errorf(codes.NotFound, "job not found: %s", id)
+	}
+	job := raw.(*jobIndex).Copy()
+
+	// Progress can only be reported while the job is running.
+	if job.State != pb.Job_RUNNING {
+		return status.Errorf(codes.FailedPrecondition,
+			"job progress can't be reported in state: %s", job.State)
+	}
+
+	p = proto.Clone(p).(*pb.JobProgress)
+	p.UpdateTime, err = ptypes.TimestampProto(s.clock.Now())
+	if err != nil {
+		return err
+	}
+
+	// We insert the job so that watchers of the job see the progress.
+	job.Progress = p
+	if err := txn.Insert(jobTableName, job); err != nil {
+		return err
+	}
+
+	txn.Commit()
+	return nil
+}
@@ -0,0 +1,48 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobProgress(t *testing.T) {
+	require := require.New(t)
+
+	s := TestState(t)
+	defer s.Close()
+
+	require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+
+	// Can't report progress until the job is running
+	err := s.JobProgress("A", &pb.JobProgress{Step: "Building"})
+	require.Equal(codes.FailedPrecondition, status.Code(err))
+
+	_, err = s.JobAssignForRunner(context.Background(), &pb.Runner{Id: "R_A"})
+	require.NoError(err)
+	_, err = s.JobAck(context.Background(), "A", true)
+	require.NoError(err)
+
+	// Report progress
+	require.NoError(s.JobProgress("A", &pb.JobProgress{Step: "Building", Percent: 50}))
+	job, err := s.JobById("A", nil)
+	require.NoError(err)
+	require.NotNil(job.Progress)
+	require.Equal("Building", job.Progress.Step)
+	require.Equal(uint32(50), job.Progress.Percent)
+	require.NotNil(job.Progress.UpdateTime)
+
+	// Invalid percent
+	err = s.JobProgress("A", &pb.JobProgress{Percent: 101})
+	require.Equal(codes.FailedPrecondition, status.Code(err))
+
+	// Not found
+	err = s.JobProgress("B", &pb.JobProgress{})
+	require.Equal(codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,118 @@
+package state
+
+import (
+	"sort"
+	"strings"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// JobQueueDepth returns the number of queued jobs that could be assigned
+// now by the runners they target, and how many of them no registered
+// runner can run. Targets are sorted by kind and then by value.
+func (s *State) JobQueueDepth() ([]*pb.GetQueueDepthResponse_Target, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	var runners []*pb.Runner
+	iter, err := memTxn.LowerBound(runnerTableName, runnerIdIndexName, "")
+	if err != nil {
+		return nil, err
+	}
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		runners = append(runners, raw.(*runnerRecord).Runner)
+	}
+
+	jobs, err := jobsByState(memTxn, pb.Job_QUEUED)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := map[string]*pb.GetQueueDepthResponse_Target{}
+	for _, idx := range jobs {
+		// Only count jobs that a runner could be assigned now.
+		ok, err := s.jobQueueAhead(memTxn, idx, nil)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		key, target := jobQueueDepthTarget(idx)
+		depth, ok := byKey[key]
+		if !ok {
+			depth = &pb.GetQueueDepthResponse_Target{Target: target}
+			byKey[key] = depth
+		}
+
+		depth.Queued++
+		if !jobRunnable(idx, runners) {
+			depth.Unassignable++
+		}
+	}
+
+	keys := make([]string, 0, len(byKey))
+	for k := range byKey {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	result := make([]*pb.GetQueueDepthResponse_Target, len(keys))
+	for i, k := range keys {
+		result[i] = byKey[k]
+	}
+
+	return result, nil
+}
+
+// jobRunnable returns true if any of the runners can run the job.
+func jobRunnable(idx *jobIndex, runners []*pb.Runner) bool {
+	for _, r := range runners {
+		if idx.targets(r) && jobCompatible(idx, r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// jobQueueDepthTarget returns the runner target of the job and a key that
+// is the same for jobs with the same target.
+func jobQueueDepthTarget(idx *jobIndex) (string, *pb.Ref_Runner) {
+	switch {
+	case idx.TargetRunnerId != "":
+		return "id:" + idx.TargetRunnerId, &pb.Ref_Runner{
+			Target: &pb.Ref_Runner_Id{
+				Id: &pb.Ref_RunnerId{Id: idx.TargetRunnerId},
+			},
+		}
+
+	case len(idx.TargetLabels) > 0:
+		pairs := make([]string, 0, len(idx.TargetLabels))
+		for k, v := range idx.TargetLabels {
+			pairs = append(pairs, k+"="+v)
+		}
+		sort.Strings(pairs)
+
+		return "labels:" + strings.Join(pairs, ","), &pb.Ref_Runner{
+			Target: &pb.Ref_Runner_Labels{
+				Labels: &pb.Ref_RunnerLabels{Labels: idx.TargetLabels},
+			},
+		}
+
+	case idx.TargetPool != "":
+		return "pool:" + idx.TargetPool, &pb.Ref_Runner{
+			Target: &pb.Ref_Runner_Pool{
+				Pool: &pb.Ref_RunnerPool{Name: idx.TargetPool},
+			},
+		}
+
+	default:
+		return "any", &pb.Ref_Runner{
+			Target: &pb.Ref_Runner_Any{
+				Any: &pb.Ref_RunnerAny{},
+			},
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobQueueDepth(t *testing.T) {
+	require := require.New(t)
+
+	s := TestState(t)
+	defer s.Close()
+
+	// A runner with a label in a pool
+	require.NoError(s.RunnerCreate(serverptypes.TestRunner(t, &pb.Runner{
+		Id:     "R_A",
+		Pool:   "build",
+		Labels: map[string]string{"arch": "arm64"},
+	})))
+
+	// Jobs for different workspaces so that they don't block each other
+	create := func(id string, target *pb.Ref_Runner, dependsOn ...string) {
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:           id,
+			Workspace:    &pb.Ref_Workspace{Workspace: id},
+			TargetRunner: target,
+			DependsOn:    dependsOn,
+		})))
+	}
+	labels := func(l map[string]string) *pb.Ref_Runner {
+		return &pb.Ref_Runner{Target: &pb.Ref_Runner_Labels{
+			Labels: &pb.Ref_RunnerLabels{Labels: l},
+		}}
+	}
+	pool := func(name string) *pb.Ref_Runner {
+		return &pb.Ref_Runner{Target: &pb.Ref_Runner_Pool{
+			Pool: &pb.Ref_RunnerPool{Name: name},
+		}}
+	}
+
+	create("A", nil)
+	create("B", nil, "A")
+	create("C", labels(map[string]string{"arch": "arm64"}))
+	create("D", labels(map[string]string{"arch": "amd64", "gpu": "true"}))
+	create("E", labels(map[string]string{"gpu": "true", "arch": "amd64"}))
+	create("F", pool("build"))
+	create("G", pool("deploy"))
+
+	result, err := s.JobQueueDepth()
+	require.NoError(err)
+
+	type depth struct {
+		Queued, Unassignable uint32
+	}
+	actual := map[string]depth{}
+	for _, d := range result {
+		key, _ := jobQueueDepthTarget(&jobIndex{
+			TargetAny:      d.Target.GetAny() != nil,
+			TargetRunnerId: d.Target.GetId().GetId(),
+			TargetLabels:   d.Target.GetLabels().GetLabels(),
+			TargetPool:     d.Target.GetPool().GetName(),
+		})
+		actual[key] = depth{d.Queued, d.Unassignable}
+	}
+
+	// B is blocked by A so it isn't counted
+	require.Equal(map[string]depth{
+		"any":                        {1, 0},
+		"labels:arch=arm64":          {1, 0},
+		"labels:arch=amd64,gpu=true": {2, 2},
+		"pool:build":                 {1, 0},
+		"pool:deploy":                {1, 1},
+	}, actual)
+
+	// Targets are sorted
+	require.Len(result, 5)
+	require.NotNil(result[0].Target.GetAny())
+}
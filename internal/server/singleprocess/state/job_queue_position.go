@@ -0,0 +1,92 @@
+package state
+
+import (
+	"github.com/hashicorp/go-memdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// JobQueuePosition returns the number of queued jobs that will be assigned
+// before the queued job with the given ID. Jobs are counted if they are
+// ordered before the job, could be assigned now, and can run on a runner
+// that can run the job. If no registered runner can run the job, every job
+// ordered before it that could be assigned now is counted.
+func (s *State) JobQueuePosition(id string) (int, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	raw, err := memTxn.First(jobTableName, jobIdIndexName, id)
+	if err != nil {
+		return 0, err
+	}
+	if raw == nil {
+		return 0, status.Errorf(codes.NotFound, "job not found: %s", id)
+	}
+	job := raw.(*jobIndex)
+	if job.State != pb.Job_QUEUED {
+		return 0, status.Errorf(codes.FailedPrecondition,
+			"job is not queued, current state: %s", job.State)
+	}
+
+	// Find the runners that can run the job
+	var runners []*pb.Runner
+	iter, err := memTxn.LowerBound(runnerTableName, runnerIdIndexName, "")
+	if err != nil {
+		return 0, err
+	}
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		if r := raw.(*runnerRecord).Runner; job.targets(r) {
+			runners = append(runners, r)
+		}
+	}
+
+	jobs, err := jobsByState(memTxn, pb.Job_QUEUED)
+	if err != nil {
+		return 0, err
+	}
+
+	var result int
+	for _, other := range jobs {
+		if other.Id == job.Id || !other.before(job) {
+			continue
+		}
+
+		ok, err := s.jobQueueAhead(memTxn, other, runners)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			result++
+		}
+	}
+
+	return result, nil
+}
+
+// jobQueueAhead returns true if the queued job could be assigned now to
+// one of the runners. If runners is empty, any runner is allowed.
+func (s *State) jobQueueAhead(memTxn *memdb.Txn, idx *jobIndex, runners []*pb.Runner) (bool, error) {
+	if idx.ApprovalPending || idx.Dispatched || idx.Federated {
+		return false, nil
+	}
+
+	if blocked, err := s.jobIsBlocked(memTxn, idx, nil); err != nil || blocked {
+		return false, err
+	}
+	if paused, err := s.jobPaused(memTxn, idx); err != nil || paused {
+		return false, err
+	}
+
+	if len(runners) == 0 {
+		return true, nil
+	}
+	for _, r := range runners {
+		if idx.targets(r) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,114 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobQueuePosition(t *testing.T) {
+	t.Run("counts jobs ordered before", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		// Jobs for different workspaces so that they don't block each other
+		create := func(id, ws string, priority int32) {
+			require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+				Id:        id,
+				Workspace: &pb.Ref_Workspace{Workspace: ws},
+				Priority:  priority,
+			})))
+		}
+		create("A", "a", 0)
+		create("B", "b", 0)
+		create("C", "c", 10)
+
+		for id, expected := range map[string]int{"C": 0, "A": 1, "B": 2} {
+			ahead, err := s.JobQueuePosition(id)
+			require.NoError(err)
+			require.Equal(expected, ahead, id)
+		}
+	})
+
+	t.Run("skips blocked jobs", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		// B is ordered first but is blocked by A, which is ordered last
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "A",
+			Workspace: &pb.Ref_Workspace{Workspace: "a"},
+			Priority:  -1,
+		})))
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "B",
+			Workspace: &pb.Ref_Workspace{Workspace: "b"},
+			Priority:  10,
+			DependsOn: []string{"A"},
+		})))
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "C",
+			Workspace: &pb.Ref_Workspace{Workspace: "c"},
+		})))
+
+		ahead, err := s.JobQueuePosition("C")
+		require.NoError(err)
+		require.Equal(0, ahead)
+	})
+
+	t.Run("skips jobs for other runners", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		r := serverptypes.TestRunner(t, nil)
+		require.NoError(s.RunnerCreate(r))
+
+		// A targets a runner by label that the registered runner doesn't have
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "A",
+			Workspace: &pb.Ref_Workspace{Workspace: "a"},
+			TargetRunner: &pb.Ref_Runner{
+				Target: &pb.Ref_Runner_Labels{
+					Labels: &pb.Ref_RunnerLabels{
+						Labels: map[string]string{"gpu": "true"},
+					},
+				},
+			},
+		})))
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "B",
+			Workspace: &pb.Ref_Workspace{Workspace: "b"},
+		})))
+
+		ahead, err := s.JobQueuePosition("B")
+		require.NoError(err)
+		require.Equal(0, ahead)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		_, err := s.JobQueuePosition("A")
+		require.Equal(codes.NotFound, status.Code(err))
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		require.NoError(s.JobCancel("A", false, 0))
+		_, err = s.JobQueuePosition("A")
+		require.Equal(codes.FailedPrecondition, status.Code(err))
+	})
+}
@@ -0,0 +1,89 @@
+package state
+
+import (
+	"github.com/hashicorp/go-memdb"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to job resource requirements. Jobs can
+// require CPU and memory with the Job.Resources field and runners can
+// advertise their capacity with the Runner.Capacity field. A job is only
+// assigned to a runner if it fits in the runner's capacity less the
+// resources required by the jobs already assigned to the runner. Jobs and
+// runners that don't set resources aren't constrained.
+
+// jobFits returns true if the job fits in the remaining capacity of
+// the runner.
+func (s *State) jobFits(memTxn *memdb.Txn, idx *jobIndex, r *pb.Runner) (bool, error) {
+	if idx.Resources == nil || r.Capacity == nil {
+		return true, nil
+	}
+
+	used, err := s.runnerResourcesUsed(memTxn, r.Id)
+	if err != nil {
+		return false, err
+	}
+
+	return resourcesFit(idx.Resources, &pb.Resources{
+		Cpu:    r.Capacity.Cpu - used.Cpu,
+		Memory: r.Capacity.Memory - used.Memory,
+	}, r.Capacity), nil
+}
+
+// runnerResourcesUsed returns the total resources required by the jobs
+// assigned to the runner with the given ID.
+func (s *State) runnerResourcesUsed(memTxn *memdb.Txn, id string) (*pb.Resources, error) {
+	jobs, err := jobsByState(memTxn, pb.Job_WAITING, pb.Job_RUNNING)
+	if err != nil {
+		return nil, err
+	}
+
+	var result pb.Resources
+	for _, idx := range jobs {
+		if idx.Resources == nil {
+			continue
+		}
+
+		jobpb, err := s.jobByIdCached(idx.Id)
+		if err != nil {
+			return nil, err
+		}
+		if jobpb.AssignedRunner == nil || jobpb.AssignedRunner.Id != id {
+			continue
+		}
+
+		result.Cpu += idx.Resources.Cpu
+		result.Memory += idx.Resources.Memory
+	}
+
+	return &result, nil
+}
+
+// resourcesFit returns true if the required resources fit in the available
+// resources. A resource that is unset in capacity is unlimited.
+func resourcesFit(required, available, capacity *pb.Resources) bool {
+	if required == nil || capacity == nil {
+		return true
+	}
+
+	if capacity.Cpu > 0 && required.Cpu > available.Cpu {
+		return false
+	}
+	if capacity.Memory > 0 && required.Memory > available.Memory {
+		return false
+	}
+
+	return true
+}
+
+// jobResources returns the resources the job requires or nil if it doesn't
+// require any.
+func jobResources(jobpb *pb.Job) *pb.Resources {
+	r := jobpb.Resources
+	if r == nil || (r.Cpu <= 0 && r.Memory <= 0) {
+		return nil
+	}
+
+	return r
+}
@@ -0,0 +1,227 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobAssignForRunner_resources(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("skips jobs that don't fit", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		// A big job queued first and a small job
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "A",
+			Resources: &pb.Resources{Cpu: 4000, Memory: 8192},
+		})))
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "B",
+			Resources: &pb.Resources{Cpu: 500},
+		})))
+
+		// A small runner gets the small job
+		small := &pb.Runner{
+			Id:       "R_A",
+			Capacity: &pb.Resources{Cpu: 1000, Memory: 1024},
+		}
+		job, err := s.JobAssignForRunner(ctx, small)
+		require.NoError(err)
+		require.Equal("B", job.Id)
+
+		// The big job doesn't fit
+		actx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, err = s.JobAssignForRunner(actx, small)
+		require.Equal(context.DeadlineExceeded, err)
+
+		// A runner without a capacity gets the big job
+		job, err = s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_B"})
+		require.NoError(err)
+		require.Equal("A", job.Id)
+	})
+
+	t.Run("uses the remaining capacity", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		r := &pb.Runner{
+			Id:       "R_A",
+			Capacity: &pb.Resources{Memory: 1024},
+		}
+
+		for _, id := range []string{"A", "B"} {
+			require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+				Id:        id,
+				Resources: &pb.Resources{Memory: 768},
+			})))
+		}
+
+		job, err := s.JobAssignForRunner(ctx, r)
+		require.NoError(err)
+		require.Equal("A", job.Id)
+
+		// The second job doesn't fit while the first is assigned
+		actx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, err = s.JobAssignForRunner(actx, r)
+		require.Equal(context.DeadlineExceeded, err)
+
+		// Once the first job completes it does
+		_, err = s.JobAck(context.Background(), "A", true)
+		require.NoError(err)
+		require.NoError(s.JobComplete(context.Background(), "A", nil, nil))
+
+		job, err = s.JobAssignForRunner(ctx, r)
+		require.NoError(err)
+		require.Equal("B", job.Id)
+	})
+
+	t.Run("wakes a waiting runner that fits", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		// The small runner starts waiting first so it is woken first.
+		small := &pb.Runner{
+			Id:       "R_A",
+			Capacity: &pb.Resources{Cpu: 1000},
+		}
+		sctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		smallErrCh := make(chan error, 1)
+		go func() {
+			_, err := s.JobAssignForRunner(sctx, small)
+			smallErrCh <- err
+		}()
+		require.Eventually(func() bool {
+			return testJobWaiters(s) == 1
+		}, 2*time.Second, 10*time.Millisecond)
+
+		big := &pb.Runner{
+			Id:       "R_B",
+			Capacity: &pb.Resources{Cpu: 4000},
+		}
+		bigCh := make(chan *Job, 1)
+		go func() {
+			job, err := s.JobAssignForRunner(ctx, big)
+			if err != nil {
+				job = nil
+			}
+			bigCh <- job
+		}()
+		require.Eventually(func() bool {
+			return testJobWaiters(s) == 2
+		}, 2*time.Second, 10*time.Millisecond)
+
+		// The job only fits the big runner
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "A",
+			Resources: &pb.Resources{Cpu: 2000},
+		})))
+
+		select {
+		case job := <-bigCh:
+			require.NotNil(job)
+			require.Equal("A", job.Id)
+		case <-time.After(2 * time.Second):
+			t.Fatal("big runner was not assigned the job")
+		}
+
+		// The small runner is still waiting
+		cancel()
+		require.Equal(context.Canceled, <-smallErrCh)
+	})
+
+	t.Run("wakes the runner when resources are released", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		r := &pb.Runner{
+			Id:       "R_A",
+			Capacity: &pb.Resources{Memory: 1024},
+		}
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "A",
+			Resources: &pb.Resources{Memory: 768},
+		})))
+		job, err := s.JobAssignForRunner(ctx, r)
+		require.NoError(err)
+		require.Equal("A", job.Id)
+		_, err = s.JobAck(context.Background(), "A", true)
+		require.NoError(err)
+
+		// The runner waits since the second job doesn't fit
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "B",
+			Resources: &pb.Resources{Memory: 768},
+		})))
+		jobCh := make(chan *Job, 1)
+		go func() {
+			job, err := s.JobAssignForRunner(ctx, r)
+			if err != nil {
+				job = nil
+			}
+			jobCh <- job
+		}()
+		require.Eventually(func() bool {
+			return testJobWaiters(s) == 1
+		}, 2*time.Second, 10*time.Millisecond)
+
+		// Completing the first job wakes it
+		require.NoError(s.JobComplete(context.Background(), "A", nil, nil))
+
+		select {
+		case job := <-jobCh:
+			require.NotNil(job)
+			require.Equal("B", job.Id)
+		case <-time.After(2 * time.Second):
+			t.Fatal("runner was not assigned the job")
+		}
+	})
+}
+
+func TestJobIsAssignable_resources(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	s := TestState(t)
+	defer s.Close()
+
+	require.NoError(s.RunnerCreate(serverptypes.TestRunner(t, &pb.Runner{
+		Capacity: &pb.Resources{Cpu: 2000, Memory: 4096},
+	})))
+
+	for _, tt := range []struct {
+		Resources *pb.Resources
+		Expected  bool
+	}{
+		{nil, true},
+		{&pb.Resources{Cpu: 2000}, true},
+		{&pb.Resources{Cpu: 4000}, false},
+		{&pb.Resources{Memory: 8192}, false},
+	} {
+		result, err := s.JobIsAssignable(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "A",
+			Resources: tt.Resources,
+		}))
+		require.NoError(err)
+		require.Equal(tt.Expected, result, "%v", tt.Resources)
+	}
+}
@@ -0,0 +1,45 @@
+package state
+
+import (
+	"github.com/boltdb/bolt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// This file has the methods related to job revisions. The revision of a
+// job starts at 1 when it is created and is incremented by every change
+// in jobReadAndUpdate. Admin actions such as cancelling or approving a job
+// can pass the revision of the job they last read so that they fail with
+// the Aborted code rather than silently overwriting a concurrent change.
+
+// jobRevisionCheck returns an Aborted error if rev is set and isn't the
+// current revision of the job. Callers must hold the in-memory write
+// transaction so that other admin actions can't change the job between
+// the check and their change.
+func (s *State) jobRevisionCheck(id string, rev uint64) error {
+	if rev == 0 {
+		return nil
+	}
+
+	var current uint64
+	err := s.dbView(func(dbTxn *bolt.Tx) error {
+		jobpb, err := s.jobById(dbTxn, id)
+		if err != nil {
+			return err
+		}
+
+		current = jobpb.Revision
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if current != rev {
+		return status.Errorf(codes.Aborted,
+			"job %s was changed: expected revision %d, current revision %d",
+			id, rev, current)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobRevision(t *testing.T) {
+	t.Run("incremented by changes", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(uint64(1), job.Revision)
+
+		_, err = s.JobUpdateRef("A", &pb.Job_DataSource_Ref{})
+		require.NoError(err)
+		job, err = s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(uint64(2), job.Revision)
+	})
+
+	t.Run("cancel with a stale revision", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+
+		// Someone else changes the job
+		_, err := s.JobUpdateRef("A", &pb.Job_DataSource_Ref{})
+		require.NoError(err)
+
+		err = s.JobCancel("A", false, 1)
+		require.Error(err)
+		require.Equal(codes.Aborted, status.Code(err))
+
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_QUEUED, job.State)
+
+		// With the current revision it is cancelled
+		require.NoError(s.JobCancel("A", false, job.Revision))
+		job, err = s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_ERROR, job.State)
+		require.Equal(uint64(3), job.Revision)
+	})
+
+	t.Run("approve with a stale revision", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:               "A",
+			ApprovalRequired: &pb.Job_ApprovalRequirement{Approvals: 2},
+		})))
+
+		job, err := s.JobApprove("A", "alice", nil, 1)
+		require.NoError(err)
+		require.Equal(uint64(2), job.Revision)
+
+		// Bob read the job before alice approved it
+		_, err = s.JobApprove("A", "bob", nil, 1)
+		require.Error(err)
+		require.Equal(codes.Aborted, status.Code(err))
+	})
+}
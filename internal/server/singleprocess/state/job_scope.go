@@ -0,0 +1,140 @@
+package state
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-memdb"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to the scope index of jobs, which
+// indexes jobs by their project, application, workspace, and state. This
+// lets queries for the jobs of a project or application read only those
+// jobs rather than scanning the whole job table.
+
+// jobScopeIndex indexes a field of the application or workspace ref of a
+// job. Like memdb.StringFieldIndex with Lowercase, matches are case
+// insensitive. Jobs without the ref are indexed with an empty value.
+type jobScopeIndex struct {
+	Field string
+}
+
+func (idx *jobScopeIndex) FromObject(obj interface{}) (bool, []byte, error) {
+	job, ok := obj.(*jobIndex)
+	if !ok {
+		return false, nil, fmt.Errorf("object must be a job: %#v", obj)
+	}
+
+	var v string
+	switch idx.Field {
+	case "Project":
+		v = job.Application.GetProject()
+	case "Application":
+		v = job.Application.GetApplication()
+	case "Workspace":
+		v = job.Workspace.GetWorkspace()
+	default:
+		return false, nil, fmt.Errorf("field '%s' is invalid", idx.Field)
+	}
+
+	return true, idx.fromString(v), nil
+}
+
+func (idx *jobScopeIndex) FromArgs(args ...interface{}) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("must provide only a single argument")
+	}
+
+	v, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument must be a string: %#v", args[0])
+	}
+
+	return idx.fromString(v), nil
+}
+
+// PrefixFromArgs matches the whole value, so a prefix query on the scope
+// index matches exact projects, applications, and workspaces.
+func (idx *jobScopeIndex) PrefixFromArgs(args ...interface{}) ([]byte, error) {
+	return idx.FromArgs(args...)
+}
+
+func (idx *jobScopeIndex) fromString(v string) []byte {
+	return []byte(strings.ToLower(v) + "\x00")
+}
+
+// jobsByScope returns the jobs of the project that are in one of the given
+// states, or in any state if none are given. If app or ws are set, only
+// the jobs of that application or workspace are returned. If watchSet is
+// set then a watch will be added for changes to the jobs.
+func (s *State) jobsByScope(
+	memTxn *memdb.Txn,
+	watchSet memdb.WatchSet,
+	project, app, ws string,
+	states ...pb.Job_State,
+) ([]*jobIndex, error) {
+	// The index can only be queried by a prefix of its fields. If the
+	// workspace is set without the application, we query the project and
+	// filter the workspaces below.
+	args := []interface{}{project}
+	if app != "" {
+		args = append(args, app)
+		if ws != "" {
+			args = append(args, ws)
+		}
+	}
+
+	var iters []memdb.ResultIterator
+	if len(args) == 3 && len(states) > 0 {
+		for _, st := range states {
+			iter, err := memTxn.Get(jobTableName, jobScopeIndexName, append(args, st)...)
+			if err != nil {
+				return nil, err
+			}
+			watchSet.Add(iter.WatchCh())
+			iters = append(iters, iter)
+		}
+	} else {
+		iter, err := memTxn.Get(jobTableName, jobScopeIndexName+"_prefix", args...)
+		if err != nil {
+			return nil, err
+		}
+		watchSet.Add(iter.WatchCh())
+		iters = append(iters, iter)
+	}
+
+	var result []*jobIndex
+	for _, iter := range iters {
+		for raw := iter.Next(); raw != nil; raw = iter.Next() {
+			job := raw.(*jobIndex)
+			if !jobStateIn(job.State, states) {
+				continue
+			}
+			if ws != "" && !strings.EqualFold(job.Workspace.GetWorkspace(), ws) {
+				continue
+			}
+
+			result = append(result, job)
+		}
+	}
+
+	return result, nil
+}
+
+// jobStateIn returns true if the state is one of the states or if there
+// are no states.
+func jobStateIn(state pb.Job_State, states []pb.Job_State) bool {
+	if len(states) == 0 {
+		return true
+	}
+
+	for _, st := range states {
+		if st == state {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,84 @@
+package state
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobsByScope(t *testing.T) {
+	require := require.New(t)
+
+	s := TestState(t)
+	defer s.Close()
+
+	create := func(id, project, app, ws string) {
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:          id,
+			Application: &pb.Ref_Application{Project: project, Application: app},
+			Workspace:   &pb.Ref_Workspace{Workspace: ws},
+		})))
+	}
+	create("A", "p1", "a1", "w1")
+	create("B", "p1", "a1", "w2")
+	create("C", "p1", "a2", "w1")
+	create("D", "p2", "a1", "w1")
+
+	// Run A so that it changes state
+	job, err := s.JobAssignForRunner(context.Background(), &pb.Runner{Id: "R_A"})
+	require.NoError(err)
+	require.Equal("A", job.Id)
+
+	ids := func(project, app, ws string, states ...pb.Job_State) []string {
+		memTxn := s.inmem.Txn(false)
+		defer memTxn.Abort()
+
+		jobs, err := s.jobsByScope(memTxn, nil, project, app, ws, states...)
+		require.NoError(err)
+
+		var result []string
+		for _, job := range jobs {
+			result = append(result, job.Id)
+		}
+		sort.Strings(result)
+		return result
+	}
+
+	require.Equal([]string{"A", "B", "C"}, ids("P1", "", ""))
+	require.Equal([]string{"A", "B"}, ids("p1", "A1", ""))
+	require.Equal([]string{"A", "C"}, ids("p1", "", "w1"))
+	require.Equal([]string{"B"}, ids("p1", "a1", "w2"))
+	require.Equal([]string{"B", "C"}, ids("p1", "", "", pb.Job_QUEUED))
+	require.Equal([]string{"A"}, ids("p1", "a1", "w1", pb.Job_WAITING))
+	require.Empty(ids("p1", "a1", "w1", pb.Job_QUEUED))
+	require.Empty(ids("p3", "", ""))
+
+	// JobStates uses the index for a project
+	states, err := s.JobStates("p1", nil)
+	require.NoError(err)
+	require.Equal(map[string]pb.Job_State{
+		"A": pb.Job_WAITING,
+		"B": pb.Job_QUEUED,
+		"C": pb.Job_QUEUED,
+	}, states)
+
+	// Complete and delete A. The indexes have no entries left for it.
+	_, err = s.JobAck(context.Background(), "A", true)
+	require.NoError(err)
+	require.NoError(s.JobComplete(context.Background(), "A", nil, nil))
+	require.NoError(s.JobDelete("A"))
+	require.Equal([]string{"B", "C"}, ids("p1", "", ""))
+	require.Empty(ids("p1", "a1", "w1",
+		pb.Job_WAITING, pb.Job_RUNNING, pb.Job_SUCCESS))
+
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+	jobs, err := jobsByState(memTxn, pb.Job_WAITING, pb.Job_RUNNING, pb.Job_SUCCESS)
+	require.NoError(err)
+	require.Empty(jobs)
+}
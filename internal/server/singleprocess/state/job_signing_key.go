@@ -0,0 +1,36 @@
+package state
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+
+	"github.com/boltdb/bolt"
+)
+
+var (
+	jobSigningKeyKey = []byte("job_signing_key")
+)
+
+// JobSigningKeyGet returns the private key used to sign job assignments
+// sent to runners. The key is generated the first time this is called and
+// persisted so that it is stable across restarts.
+func (s *State) JobSigningKeyGet() (ed25519.PrivateKey, error) {
+	var result ed25519.PrivateKey
+	err := s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		b := dbTxn.Bucket(serverConfigBucket)
+		if v := b.Get(jobSigningKeyKey); v != nil {
+			result = ed25519.NewKeyFromSeed(v)
+			return nil
+		}
+
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return err
+		}
+
+		result = key
+		return dbPutRaw(b, jobSigningKeyKey, key.Seed())
+	})
+
+	return result, err
+}
@@ -0,0 +1,30 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobSigningKey(t *testing.T) {
+	require := require.New(t)
+
+	s := TestState(t)
+	defer s.Close()
+
+	key, err := s.JobSigningKeyGet()
+	require.NoError(err)
+	require.NotEmpty(key)
+
+	// Should be stable
+	key2, err := s.JobSigningKeyGet()
+	require.NoError(err)
+	require.Equal(key, key2)
+
+	// Should persist
+	s = TestStateReinit(t, s)
+	defer s.Close()
+	key2, err = s.JobSigningKeyGet()
+	require.NoError(err)
+	require.Equal(key, key2)
+}
@@ -0,0 +1,74 @@
+package state
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-memdb"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to resource tags. Runners describe
+// the specialized hardware they have with the Runner.ResourceTags field,
+// such as "gpu" or "kvm", and jobs require tags with the Job.RequiredTags
+// field. A job is only assigned to a runner that has all of the tags it
+// requires. Tags are case insensitive. The tags of runners are indexed so
+// that the runners that have a tag can be found without checking every
+// runner. Runners without the tags are woken for jobs like any other
+// runner and wake another waiting runner in their place, so a job
+// requiring tags isn't stranded behind them. See job_notify.go.
+
+// jobTagsMatch returns true if the runner has all the tags the job
+// requires.
+func jobTagsMatch(idx *jobIndex, r *pb.Runner) bool {
+	if len(idx.RequiredTags) == 0 {
+		return true
+	}
+
+	tags := make(map[string]struct{}, len(r.ResourceTags))
+	for _, tag := range r.ResourceTags {
+		tags[strings.ToLower(tag)] = struct{}{}
+	}
+	for _, tag := range idx.RequiredTags {
+		if _, ok := tags[tag]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// runnerCandidates returns the runners that may be able to run the job.
+// If the job requires tags, only the runners that have the first one are
+// returned. Otherwise every runner is returned.
+func (s *State) runnerCandidates(memTxn *memdb.Txn, idx *jobIndex) (memdb.ResultIterator, error) {
+	if len(idx.RequiredTags) > 0 {
+		return memTxn.Get(runnerTableName, runnerTagsIndexName, idx.RequiredTags[0])
+	}
+
+	return memTxn.LowerBound(runnerTableName, runnerIdIndexName, "")
+}
+
+// resourceTags returns the tags lowercased, sorted, and without
+// duplicates or empty tags.
+func resourceTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	seen := map[string]struct{}{}
+	var result []string
+	for _, tag := range tags {
+		tag = strings.ToLower(tag)
+		if _, ok := seen[tag]; ok || tag == "" {
+			continue
+		}
+
+		seen[tag] = struct{}{}
+		result = append(result, tag)
+	}
+	sort.Strings(result)
+
+	return result
+}
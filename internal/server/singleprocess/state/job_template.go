@@ -0,0 +1,86 @@
+package state
+
+import (
+	"strings"
+
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to job templates. Templates are named
+// partial jobs that jobs can be queued from. They're only read when jobs
+// are queued so they're not indexed in memory.
+
+var jobTemplateBucket = []byte("job_template")
+
+func init() {
+	dbBuckets = append(dbBuckets, jobTemplateBucket)
+}
+
+// JobTemplatePut creates or updates a job template.
+func (s *State) JobTemplatePut(t *pb.JobTemplate) error {
+	if t.Name == "" {
+		return status.Errorf(codes.FailedPrecondition, "template name must be set")
+	}
+	if t.Job == nil {
+		return status.Errorf(codes.FailedPrecondition, "template job must be set")
+	}
+
+	// The ID is generated for each job queued from the template.
+	t = proto.Clone(t).(*pb.JobTemplate)
+	t.Job.Id = ""
+
+	return s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		return dbPut(dbTxn.Bucket(jobTemplateBucket), jobTemplateId(t.Name), t)
+	})
+}
+
+// JobTemplateGet gets a job template by name.
+func (s *State) JobTemplateGet(name string) (*pb.JobTemplate, error) {
+	var result pb.JobTemplate
+	err := s.dbView(func(dbTxn *bolt.Tx) error {
+		return dbGet(dbTxn.Bucket(jobTemplateBucket), jobTemplateId(name), &result)
+	})
+	if status.Code(err) == codes.NotFound {
+		return nil, status.Errorf(codes.NotFound, "job template not found: %s", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// JobTemplateList returns all the job templates sorted by name.
+func (s *State) JobTemplateList() ([]*pb.JobTemplate, error) {
+	var result []*pb.JobTemplate
+	err := s.dbView(func(dbTxn *bolt.Tx) error {
+		return dbTxn.Bucket(jobTemplateBucket).ForEach(func(k, v []byte) error {
+			var value pb.JobTemplate
+			if err := proto.Unmarshal(v, &value); err != nil {
+				return err
+			}
+
+			result = append(result, &value)
+			return nil
+		})
+	})
+
+	return result, err
+}
+
+// JobTemplateDelete deletes a job template by name. Deleting a template
+// that doesn't exist does nothing.
+func (s *State) JobTemplateDelete(name string) error {
+	return s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		return dbDelete(dbTxn.Bucket(jobTemplateBucket), jobTemplateId(name))
+	})
+}
+
+func jobTemplateId(name string) []byte {
+	return []byte(strings.ToLower(name))
+}
@@ -0,0 +1,67 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobTemplate(t *testing.T) {
+	t.Run("put, get, list, delete", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		// Not found
+		_, err := s.JobTemplateGet("nightly")
+		require.Error(err)
+		require.Equal(codes.NotFound, status.Code(err))
+
+		// Put
+		require.NoError(s.JobTemplatePut(&pb.JobTemplate{
+			Name: "nightly",
+			Job:  serverptypes.TestJobNew(t, &pb.Job{Id: "A"}),
+		}))
+		require.NoError(s.JobTemplatePut(&pb.JobTemplate{
+			Name: "hourly",
+			Job:  serverptypes.TestJobNew(t, nil),
+		}))
+
+		// Get is case insensitive and the job ID is cleared
+		tmpl, err := s.JobTemplateGet("NIGHTLY")
+		require.NoError(err)
+		require.Equal("nightly", tmpl.Name)
+		require.Empty(tmpl.Job.Id)
+
+		// List is sorted
+		list, err := s.JobTemplateList()
+		require.NoError(err)
+		require.Len(list, 2)
+		require.Equal("hourly", list[0].Name)
+		require.Equal("nightly", list[1].Name)
+
+		// Delete
+		require.NoError(s.JobTemplateDelete("nightly"))
+		_, err = s.JobTemplateGet("nightly")
+		require.Equal(codes.NotFound, status.Code(err))
+		require.NoError(s.JobTemplateDelete("nightly"))
+	})
+
+	t.Run("requires a name and job", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		err := s.JobTemplatePut(&pb.JobTemplate{Job: serverptypes.TestJobNew(t, nil)})
+		require.Equal(codes.FailedPrecondition, status.Code(err))
+		err = s.JobTemplatePut(&pb.JobTemplate{Name: "nightly"})
+		require.Equal(codes.FailedPrecondition, status.Code(err))
+	})
+}
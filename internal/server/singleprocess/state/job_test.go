@@ -3,9 +3,11 @@ package state
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -15,6 +17,254 @@ import (
 	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
 )
 
+func TestJobCreate(t *testing.T) {
+	t.Run("concurrent creates", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		// Create a bunch of jobs concurrently so that the writes are batched.
+		var wg sync.WaitGroup
+		errCh := make(chan error, 50)
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errCh <- s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+					Id: fmt.Sprintf("job-%d", i),
+				}))
+			}(i)
+		}
+		wg.Wait()
+		close(errCh)
+		for err := range errCh {
+			require.NoError(err)
+		}
+
+		// All jobs should be queued
+		jobs, err := s.JobList()
+		require.NoError(err)
+		require.Len(jobs, 50)
+		for _, job := range jobs {
+			require.Equal(pb.Job_QUEUED, job.State)
+			require.NotNil(job.QueueTime)
+		}
+
+		// And they should all survive a restart
+		s = TestStateReinit(t, s)
+		defer s.Close()
+		jobs, err = s.JobList()
+		require.NoError(err)
+		require.Len(jobs, 50)
+	})
+}
+
+func TestJobList(t *testing.T) {
+	t.Run("indexes many jobs on restart", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		// Enough jobs that they're unmarshalled in parallel
+		var jobs []*pb.Job
+		for i := 0; i < jobUnmarshalParallelMin+10; i++ {
+			jobs = append(jobs, serverptypes.TestJobNew(t, &pb.Job{
+				Id: fmt.Sprintf("job-%05d", i),
+			}))
+		}
+		require.NoError(s.JobCreateBatch(jobs))
+
+		s = TestStateReinit(t, s)
+		defer s.Close()
+
+		list, err := s.JobList()
+		require.NoError(err)
+		require.Len(list, len(jobs))
+		for i, job := range list {
+			require.Equal(jobs[i].Id, job.Id)
+			require.Equal(pb.Job_QUEUED, job.State)
+		}
+	})
+}
+
+func TestJobListIter(t *testing.T) {
+	t.Run("yields jobs in batches in order", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		defer func(v int) { jobListBatchSize = v }(jobListBatchSize)
+		jobListBatchSize = 3
+
+		var jobs []*pb.Job
+		for i := 0; i < 10; i++ {
+			jobs = append(jobs, serverptypes.TestJobNew(t, &pb.Job{
+				Id: fmt.Sprintf("job-%02d", i),
+			}))
+		}
+		require.NoError(s.JobCreateBatch(jobs))
+
+		// Reinit so the jobs are read from the database rather than the cache
+		s = TestStateReinit(t, s)
+		defer s.Close()
+
+		var sizes []int
+		var ids []string
+		require.NoError(s.JobListIter(func(batch []*pb.Job) error {
+			sizes = append(sizes, len(batch))
+			for _, job := range batch {
+				ids = append(ids, job.Id)
+			}
+			return nil
+		}))
+		require.Equal([]int{3, 3, 3, 1}, sizes)
+		require.Len(ids, len(jobs))
+		for i, job := range jobs {
+			require.Equal(job.Id, ids[i])
+		}
+	})
+
+	t.Run("stops when the function errors", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		defer func(v int) { jobListBatchSize = v }(jobListBatchSize)
+		jobListBatchSize = 1
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "B"})))
+
+		calls := 0
+		err := s.JobListIter(func(batch []*pb.Job) error {
+			calls++
+			return status.Error(codes.Aborted, "stop")
+		})
+		require.Error(err)
+		require.Equal(codes.Aborted, status.Code(err))
+		require.Equal(1, calls)
+	})
+}
+
+func TestJobContext(t *testing.T) {
+	t.Run("cancelled operations don't write", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		cancelled, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// Create
+		err := s.JobCreate(cancelled, serverptypes.TestJobNew(t, &pb.Job{Id: "A"}))
+		require.Equal(context.Canceled, err)
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.Nil(job)
+
+		// Ack
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		_, err = s.JobAssignForRunner(context.Background(), &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		_, err = s.JobAck(cancelled, "A", true)
+		require.Equal(context.Canceled, err)
+		job, err = s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_WAITING, job.State)
+
+		// Complete
+		_, err = s.JobAck(context.Background(), "A", true)
+		require.NoError(err)
+		require.Equal(context.Canceled, s.JobComplete(cancelled, "A", nil, nil))
+		job, err = s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_RUNNING, job.State)
+		require.NoError(s.JobComplete(context.Background(), "A", nil, nil))
+	})
+}
+
+func TestJobCreateBatch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("creates a chain of jobs", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreateBatch([]*pb.Job{
+			serverptypes.TestJobNew(t, &pb.Job{Id: "build"}),
+			serverptypes.TestJobNew(t, &pb.Job{Id: "deploy", DependsOn: []string{"build"}}),
+			serverptypes.TestJobNew(t, &pb.Job{Id: "release", DependsOn: []string{"deploy"}}),
+		}))
+
+		jobs, err := s.JobList()
+		require.NoError(err)
+		require.Len(jobs, 3)
+
+		job, err := s.JobById("release", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_QUEUED, job.State)
+		require.Equal("deploy", job.BlockedBy)
+
+		// The first job in the chain can be assigned
+		job, err = s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		require.Equal("build", job.Id)
+	})
+
+	t.Run("duplicate IDs", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		err := s.JobCreateBatch([]*pb.Job{
+			serverptypes.TestJobNew(t, &pb.Job{Id: "A"}),
+			serverptypes.TestJobNew(t, &pb.Job{Id: "A"}),
+		})
+		require.Error(err)
+		require.Equal(codes.InvalidArgument, status.Code(err))
+
+		jobs, err := s.JobList()
+		require.NoError(err)
+		require.Empty(jobs)
+	})
+
+	t.Run("creates nothing if a job fails", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		// An invalid expire time fails when the maximum is applied
+		s.JobExpirySet(0, time.Hour)
+		bad := serverptypes.TestJobNew(t, &pb.Job{Id: "B"})
+		bad.ExpireTime = &timestamp.Timestamp{Seconds: -1 << 60}
+
+		require.Error(s.JobCreateBatch([]*pb.Job{
+			serverptypes.TestJobNew(t, &pb.Job{Id: "A"}),
+			bad,
+		}))
+
+		jobs, err := s.JobList()
+		require.NoError(err)
+		require.Empty(jobs)
+
+		// Nothing was persisted either
+		s = TestStateReinit(t, s)
+		defer s.Close()
+		jobs, err = s.JobList()
+		require.NoError(err)
+		require.Empty(jobs)
+	})
+}
+
 func TestJobAssign(t *testing.T) {
 	t.Run("basic assignment with one", func(t *testing.T) {
 		require := require.New(t)
@@ -23,7 +273,7 @@ func TestJobAssign(t *testing.T) {
 		defer s.Close()
 
 		// Create a build
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "A",
 		})))
 
@@ -53,7 +303,7 @@ func TestJobAssign(t *testing.T) {
 		defer s.Close()
 
 		// Create a build
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "A",
 			Workspace: &pb.Ref_Workspace{
 				Workspace: "w1",
@@ -89,7 +339,7 @@ func TestJobAssign(t *testing.T) {
 			}
 
 			// Insert another job
-			require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+			require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 				Id: "B",
 				Workspace: &pb.Ref_Workspace{
 					Workspace: "w2",
@@ -117,7 +367,7 @@ func TestJobAssign(t *testing.T) {
 		defer s.Close()
 
 		// Create two builds for the same app/workspace
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "A",
 			Workspace: &pb.Ref_Workspace{
 				Workspace: "w1",
@@ -126,7 +376,7 @@ func TestJobAssign(t *testing.T) {
 				Deploy: &pb.Job_DeployOp{},
 			},
 		})))
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "B",
 			Workspace: &pb.Ref_Workspace{
 				Workspace: "w1",
@@ -144,6 +394,14 @@ func TestJobAssign(t *testing.T) {
 			require.Equal("A", job.Id)
 		}
 
+		// The other job is blocked by it
+		{
+			job, err := s.JobById("B", nil)
+			require.NoError(err)
+			require.True(job.Blocked)
+			require.Equal("A", job.BlockedBy)
+		}
+
 		// Get the next value in a goroutine
 		{
 			ctx, cancel := context.WithCancel(context.Background())
@@ -165,7 +423,7 @@ func TestJobAssign(t *testing.T) {
 			}
 
 			// Insert another job for a different workspace
-			require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+			require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 				Id: "C",
 				Workspace: &pb.Ref_Workspace{
 					Workspace: "w2",
@@ -196,7 +454,7 @@ func TestJobAssign(t *testing.T) {
 		defer s.Close()
 
 		// Create two builds for the same app/workspace
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "A",
 			Workspace: &pb.Ref_Workspace{
 				Workspace: "w1",
@@ -205,7 +463,7 @@ func TestJobAssign(t *testing.T) {
 				Deploy: &pb.Job_DeployOp{},
 			},
 		})))
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "B",
 			Workspace: &pb.Ref_Workspace{
 				Workspace: "w1",
@@ -242,9 +500,9 @@ func TestJobAssign(t *testing.T) {
 			}
 
 			// Complete the job
-			_, err = s.JobAck(job1.Id, true)
+			_, err = s.JobAck(context.Background(), job1.Id, true)
 			require.NoError(err)
-			require.NoError(s.JobComplete(job1.Id, nil, nil))
+			require.NoError(s.JobComplete(context.Background(), job1.Id, nil, nil))
 
 			// We should get a result
 			select {
@@ -268,11 +526,11 @@ func TestJobAssign(t *testing.T) {
 		defer s.Close()
 
 		// Create two builds slightly apart
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "A",
 		})))
 		time.Sleep(1 * time.Millisecond)
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "B",
 		})))
 
@@ -282,18 +540,57 @@ func TestJobAssign(t *testing.T) {
 			require.NoError(err)
 			require.NotNil(job)
 			require.Equal("A", job.Id)
-			_, err = s.JobAck(job.Id, true)
+			_, err = s.JobAck(context.Background(), job.Id, true)
 			require.NoError(err)
-			require.NoError(s.JobComplete(job.Id, nil, nil))
+			require.NoError(s.JobComplete(context.Background(), job.Id, nil, nil))
 		}
 		{
 			job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
 			require.NoError(err)
 			require.NotNil(job)
 			require.Equal("B", job.Id)
-			_, err = s.JobAck(job.Id, true)
+			_, err = s.JobAck(context.Background(), job.Id, true)
 			require.NoError(err)
-			require.NoError(s.JobComplete(job.Id, nil, nil))
+			require.NoError(s.JobComplete(context.Background(), job.Id, nil, nil))
+		}
+	})
+
+	t.Run("assignment by priority", func(t *testing.T) {
+		require := require.New(t)
+		ctx := context.Background()
+
+		s := TestState(t)
+		defer s.Close()
+
+		// Create builds slightly apart, with the later ones more urgent
+		for _, job := range []*pb.Job{
+			{Id: "A", Priority: -10},
+			{Id: "B"},
+			{Id: "C", Priority: 10},
+			{Id: "D", Priority: 10},
+			{
+				Id:       "E",
+				Priority: 5,
+				TargetRunner: &pb.Ref_Runner{
+					Target: &pb.Ref_Runner_Id{
+						Id: &pb.Ref_RunnerId{Id: "R_A"},
+					},
+				},
+			},
+		} {
+			require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, job)))
+			time.Sleep(1 * time.Millisecond)
+		}
+
+		// We should get the highest priority first, then the oldest
+		for _, id := range []string{"C", "D", "E", "B", "A"} {
+			job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+			require.NoError(err)
+			require.NotNil(job)
+			require.Equal(id, job.Id)
+			_, err = s.JobAck(context.Background(), job.Id, true)
+			require.NoError(err)
+			require.NoError(s.JobComplete(context.Background(), job.Id, nil, nil))
 		}
 	})
 
@@ -304,7 +601,7 @@ func TestJobAssign(t *testing.T) {
 		defer s.Close()
 
 		// Create a build by ID
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "A",
 			TargetRunner: &pb.Ref_Runner{
 				Target: &pb.Ref_Runner_Id{
@@ -315,11 +612,11 @@ func TestJobAssign(t *testing.T) {
 			},
 		})))
 		time.Sleep(1 * time.Millisecond)
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "B",
 		})))
 		time.Sleep(1 * time.Millisecond)
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "C",
 		})))
 
@@ -330,9 +627,9 @@ func TestJobAssign(t *testing.T) {
 			require.NoError(err)
 			require.NotNil(job)
 			require.Equal("B", job.Id)
-			_, err = s.JobAck(job.Id, true)
+			_, err = s.JobAck(context.Background(), job.Id, true)
 			require.NoError(err)
-			require.NoError(s.JobComplete(job.Id, nil, nil))
+			require.NoError(s.JobComplete(context.Background(), job.Id, nil, nil))
 		}
 
 		// Assign for R_A, which should get A since it matches the target.
@@ -341,9 +638,9 @@ func TestJobAssign(t *testing.T) {
 			require.NoError(err)
 			require.NotNil(job)
 			require.Equal("A", job.Id)
-			_, err = s.JobAck(job.Id, true)
+			_, err = s.JobAck(context.Background(), job.Id, true)
 			require.NoError(err)
-			require.NoError(s.JobComplete(job.Id, nil, nil))
+			require.NoError(s.JobComplete(context.Background(), job.Id, nil, nil))
 		}
 	})
 
@@ -354,7 +651,7 @@ func TestJobAssign(t *testing.T) {
 		defer s.Close()
 
 		// Create a build by ID
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "A",
 			TargetRunner: &pb.Ref_Runner{
 				Target: &pb.Ref_Runner_Id{
@@ -396,7 +693,7 @@ func TestJobAssign(t *testing.T) {
 		r := &pb.Runner{Id: "R_A", ByIdOnly: true}
 
 		// Create a build
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "A",
 		})))
 
@@ -409,7 +706,7 @@ func TestJobAssign(t *testing.T) {
 		require.Equal(ctx.Err(), err)
 
 		// Create a target
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "B",
 			TargetRunner: &pb.Ref_Runner{
 				Target: &pb.Ref_Runner_Id{
@@ -436,7 +733,7 @@ func TestJobAck(t *testing.T) {
 		defer s.Close()
 
 		// Create a build
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "A",
 		})))
 
@@ -447,7 +744,7 @@ func TestJobAck(t *testing.T) {
 		require.Equal("A", job.Id)
 
 		// Ack it
-		_, err = s.JobAck(job.Id, true)
+		_, err = s.JobAck(context.Background(), job.Id, true)
 		require.NoError(err)
 
 		// Verify it is changed
@@ -466,7 +763,7 @@ func TestJobAck(t *testing.T) {
 		defer s.Close()
 
 		// Create a build
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "A",
 		})))
 
@@ -477,7 +774,7 @@ func TestJobAck(t *testing.T) {
 		require.Equal("A", job.Id)
 
 		// Ack it
-		_, err = s.JobAck(job.Id, false)
+		_, err = s.JobAck(context.Background(), job.Id, false)
 		require.NoError(err)
 
 		// Verify it is changed
@@ -487,6 +784,26 @@ func TestJobAck(t *testing.T) {
 
 		// We should not have an output buffer
 		require.Nil(job.OutputBuffer)
+
+		// The rejected assignment is recorded
+		require.Len(job.Assignments, 1)
+		require.Equal("R_A", job.Assignments[0].Runner.Id)
+		require.NotNil(job.Assignments[0].NackTime)
+		require.Nil(job.Assignments[0].AckTime)
+
+		// Assign it again and ack
+		_, err = s.JobAssignForRunner(context.Background(), &pb.Runner{Id: "R_B"})
+		require.NoError(err)
+		_, err = s.JobAck(context.Background(), job.Id, true)
+		require.NoError(err)
+
+		job, err = s.JobById(job.Id, nil)
+		require.NoError(err)
+		require.Equal("R_B", job.AssignedRunner.Id)
+		require.Len(job.Assignments, 2)
+		require.Equal("R_B", job.Assignments[1].Runner.Id)
+		require.NotNil(job.Assignments[1].AckTime)
+		require.Nil(job.Assignments[1].NackTime)
 	})
 
 	t.Run("timeout before ack should requeue", func(t *testing.T) {
@@ -501,7 +818,7 @@ func TestJobAck(t *testing.T) {
 		defer s.Close()
 
 		// Create a build
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "A",
 		})))
 
@@ -520,7 +837,7 @@ func TestJobAck(t *testing.T) {
 		require.Equal(pb.Job_QUEUED, job.Job.State)
 
 		// Ack it
-		_, err = s.JobAck(job.Id, true)
+		_, err = s.JobAck(context.Background(), job.Id, true)
 		require.Error(err)
 	})
 }
@@ -533,7 +850,7 @@ func TestJobComplete(t *testing.T) {
 		defer s.Close()
 
 		// Create a build
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "A",
 		})))
 
@@ -544,11 +861,11 @@ func TestJobComplete(t *testing.T) {
 		require.Equal("A", job.Id)
 
 		// Ack it
-		_, err = s.JobAck(job.Id, true)
+		_, err = s.JobAck(context.Background(), job.Id, true)
 		require.NoError(err)
 
 		// Complete it
-		require.NoError(s.JobComplete(job.Id, &pb.Job_Result{
+		require.NoError(s.JobComplete(context.Background(), job.Id, &pb.Job_Result{
 			Build: &pb.Job_BuildResult{},
 		}, nil))
 
@@ -568,7 +885,7 @@ func TestJobComplete(t *testing.T) {
 		defer s.Close()
 
 		// Create a build
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "A",
 		})))
 
@@ -579,11 +896,11 @@ func TestJobComplete(t *testing.T) {
 		require.Equal("A", job.Id)
 
 		// Ack it
-		_, err = s.JobAck(job.Id, true)
+		_, err = s.JobAck(context.Background(), job.Id, true)
 		require.NoError(err)
 
 		// Complete it
-		require.NoError(s.JobComplete(job.Id, nil, fmt.Errorf("bad")))
+		require.NoError(s.JobComplete(context.Background(), job.Id, nil, fmt.Errorf("bad")))
 
 		// Verify it is changed
 		job, err = s.JobById(job.Id, nil)
@@ -595,6 +912,45 @@ func TestJobComplete(t *testing.T) {
 		require.Equal(codes.Unknown, st.Code())
 		require.Contains(st.Message(), "bad")
 	})
+
+	t.Run("redacts sensitive values", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		s.Redactor().SetValues([]string{"hunter22"})
+
+		for _, id := range []string{"A", "B"} {
+			require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+				Id:     id,
+				Labels: map[string]string{"password": "hunter22"},
+			})))
+			job, err := s.JobAssignForRunner(context.Background(), &pb.Runner{Id: "R_A"})
+			require.NoError(err)
+			_, err = s.JobAck(context.Background(), job.Id, true)
+			require.NoError(err)
+		}
+
+		result := &pb.Job_Result{
+			Build: &pb.Job_BuildResult{
+				Build: &pb.Build{Labels: map[string]string{"password": "hunter22"}},
+			},
+		}
+		require.NoError(s.JobComplete(context.Background(), "A", result, nil))
+		require.NoError(s.JobComplete(context.Background(), "B", nil, fmt.Errorf("login hunter22 failed")))
+
+		// The caller's result isn't modified
+		require.Equal("hunter22", result.Build.Build.Labels["password"])
+
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal("<sensitive>", job.Labels["password"])
+		require.Equal("<sensitive>", job.Result.Build.Build.Labels["password"])
+
+		job, err = s.JobById("B", nil)
+		require.NoError(err)
+		require.Equal("login <sensitive> failed", status.FromProto(job.Error).Message())
+	})
 }
 
 func TestJobIsAssignable(t *testing.T) {
@@ -713,6 +1069,85 @@ func TestJobIsAssignable(t *testing.T) {
 	})
 }
 
+func TestJobUpdateRef(t *testing.T) {
+	require := require.New(t)
+
+	s := TestState(t)
+	defer s.Close()
+
+	require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+		Id: "A",
+	})))
+
+	ref := &pb.Job_DataSource_Ref{
+		Ref: &pb.Job_DataSource_Ref_Git{
+			Git: &pb.Job_Git_Ref{Commit: "abc123"},
+		},
+	}
+	job, err := s.JobUpdateRef("A", ref)
+	require.NoError(err)
+	require.Equal("abc123", job.DataSourceRef.GetGit().Commit)
+
+	sj, err := s.JobById("A", nil)
+	require.NoError(err)
+	require.Equal("abc123", sj.DataSourceRef.GetGit().Commit)
+
+	_, err = s.JobUpdateRef("B", ref)
+	require.Error(err)
+}
+
+func TestJobCompleteHook(t *testing.T) {
+	require := require.New(t)
+
+	s := TestState(t)
+	defer s.Close()
+
+	doneCh := make(chan *pb.Job, 4)
+	s.JobCompleteHookSet(func(job *pb.Job) { doneCh <- job })
+
+	// Create two jobs for different apps so neither blocks the other
+	require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+		Id: "A",
+	})))
+	require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+		Id:          "B",
+		Application: &pb.Ref_Application{Project: "p_test", Application: "b"},
+	})))
+
+	// Completing a job calls the hook
+	job, err := s.JobAssignForRunner(context.Background(), &pb.Runner{Id: "R_A"})
+	require.NoError(err)
+	_, err = s.JobAck(context.Background(), job.Id, true)
+	require.NoError(err)
+	require.NoError(s.JobComplete(context.Background(), job.Id, nil, nil))
+
+	select {
+	case job := <-doneCh:
+		require.Equal("A", job.Id)
+		require.Equal(pb.Job_SUCCESS, job.State)
+	case <-time.After(5 * time.Second):
+		t.Fatal("hook not called")
+	}
+
+	// Cancelling a queued job calls the hook once
+	require.NoError(s.JobCancel("B", false, 0))
+	require.NoError(s.JobCancel("B", false, 0))
+
+	select {
+	case job := <-doneCh:
+		require.Equal("B", job.Id)
+		require.Equal(pb.Job_ERROR, job.State)
+	case <-time.After(5 * time.Second):
+		t.Fatal("hook not called")
+	}
+
+	select {
+	case job := <-doneCh:
+		t.Fatalf("hook called again for %s", job.Id)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
 func TestJobCancel(t *testing.T) {
 	t.Run("queued", func(t *testing.T) {
 		require := require.New(t)
@@ -721,12 +1156,12 @@ func TestJobCancel(t *testing.T) {
 		defer s.Close()
 
 		// Create a build
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "A",
 		})))
 
 		// Cancel it
-		require.NoError(s.JobCancel("A", false))
+		require.NoError(s.JobCancel("A", false, 0))
 
 		// Verify it is canceled
 		job, err := s.JobById("A", nil)
@@ -743,7 +1178,7 @@ func TestJobCancel(t *testing.T) {
 		defer s.Close()
 
 		// Create a build
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "A",
 		})))
 
@@ -755,7 +1190,7 @@ func TestJobCancel(t *testing.T) {
 		require.Equal(pb.Job_WAITING, job.State)
 
 		// Cancel it
-		require.NoError(s.JobCancel("A", false))
+		require.NoError(s.JobCancel("A", false, 0))
 
 		// Verify it is canceled
 		job, err = s.JobById("A", nil)
@@ -771,7 +1206,7 @@ func TestJobCancel(t *testing.T) {
 		defer s.Close()
 
 		// Create a build
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "A",
 		})))
 
@@ -783,7 +1218,7 @@ func TestJobCancel(t *testing.T) {
 		require.Equal(pb.Job_WAITING, job.State)
 
 		// Cancel it
-		require.NoError(s.JobCancel("A", true))
+		require.NoError(s.JobCancel("A", true, 0))
 
 		// Verify it is canceled
 		job, err = s.JobById("A", nil)
@@ -799,7 +1234,7 @@ func TestJobCancel(t *testing.T) {
 		defer s.Close()
 
 		// Create a build
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id:        "A",
 			Operation: &pb.Job_Deploy{},
 		})))
@@ -812,7 +1247,7 @@ func TestJobCancel(t *testing.T) {
 		require.Equal(pb.Job_WAITING, job.State)
 
 		// Cancel it
-		require.NoError(s.JobCancel("A", true))
+		require.NoError(s.JobCancel("A", true, 0))
 
 		// Verify it is canceled
 		job, err = s.JobById("A", nil)
@@ -821,7 +1256,7 @@ func TestJobCancel(t *testing.T) {
 		require.NotEmpty(job.CancelTime)
 
 		// Create a another job
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id:        "B",
 			Operation: &pb.Job_Deploy{},
 		})))
@@ -844,7 +1279,7 @@ func TestJobCancel(t *testing.T) {
 		defer s.Close()
 
 		// Create a build
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "A",
 		})))
 
@@ -856,14 +1291,14 @@ func TestJobCancel(t *testing.T) {
 		require.Equal(pb.Job_WAITING, job.State)
 
 		// Ack it
-		_, err = s.JobAck(job.Id, true)
+		_, err = s.JobAck(context.Background(), job.Id, true)
 		require.NoError(err)
 
 		// Complete it
-		require.NoError(s.JobComplete(job.Id, nil, nil))
+		require.NoError(s.JobComplete(context.Background(), job.Id, nil, nil))
 
 		// Cancel it
-		require.NoError(s.JobCancel("A", false))
+		require.NoError(s.JobCancel("A", false, 0))
 
 		// Verify it is not canceled
 		job, err = s.JobById("A", nil)
@@ -873,6 +1308,65 @@ func TestJobCancel(t *testing.T) {
 	})
 }
 
+func TestJobCancelBulk(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	s := TestState(t)
+	defer s.Close()
+
+	app := func(project, app string) *pb.Ref_Application {
+		return &pb.Ref_Application{Project: project, Application: app}
+	}
+
+	// Create jobs in various scopes
+	for _, job := range []*pb.Job{
+		{Id: "A", Application: app("p1", "a1"), Workspace: &pb.Ref_Workspace{Workspace: "w1"}},
+		{Id: "B", Application: app("p1", "a1"), Workspace: &pb.Ref_Workspace{Workspace: "w2"}},
+		{Id: "C", Application: app("p1", "a2"), Workspace: &pb.Ref_Workspace{Workspace: "w1"}},
+		{Id: "D", Application: app("p2", "a1"), Workspace: &pb.Ref_Workspace{Workspace: "w1"}},
+		{Id: "E", Application: app("p1", "a1"), Workspace: &pb.Ref_Workspace{Workspace: "w1"}},
+	} {
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, job)))
+	}
+
+	// Run A and complete E so they're in different states
+	job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+	require.NoError(err)
+	require.Equal("A", job.Id)
+	_, err = s.JobAck(context.Background(), "A", true)
+	require.NoError(err)
+	require.NoError(s.JobCancel("E", false, 0))
+
+	// Cancel by app and workspace
+	ids, err := s.JobCancelBulk(app("P1", "a1"), &pb.Ref_Workspace{Workspace: "w1"})
+	require.NoError(err)
+	require.Equal([]string{"A"}, ids)
+
+	// Running jobs are cancelled by the runner
+	job, err = s.JobById("A", nil)
+	require.NoError(err)
+	require.Equal(pb.Job_RUNNING, job.State)
+	require.NotNil(job.CancelTime)
+
+	// Cancel the project in every workspace
+	ids, err = s.JobCancelBulk(app("p1", ""), nil)
+	require.NoError(err)
+	require.ElementsMatch([]string{"A", "B", "C"}, ids)
+
+	for _, id := range []string{"B", "C"} {
+		job, err := s.JobById(id, nil)
+		require.NoError(err)
+		require.Equal(pb.Job_ERROR, job.State)
+		require.NotNil(job.CancelTime)
+	}
+
+	// Other projects aren't affected
+	job, err = s.JobById("D", nil)
+	require.NoError(err)
+	require.Equal(pb.Job_QUEUED, job.State)
+}
+
 func TestJobHeartbeat(t *testing.T) {
 	t.Run("times out after ack", func(t *testing.T) {
 		require := require.New(t)
@@ -886,7 +1380,7 @@ func TestJobHeartbeat(t *testing.T) {
 		jobHeartbeatTimeout = 5 * time.Millisecond
 
 		// Create a build
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "A",
 		})))
 
@@ -898,7 +1392,7 @@ func TestJobHeartbeat(t *testing.T) {
 		require.Equal(pb.Job_WAITING, job.State)
 
 		// Ack it
-		_, err = s.JobAck(job.Id, true)
+		_, err = s.JobAck(context.Background(), job.Id, true)
 		require.NoError(err)
 
 		// Should time out
@@ -922,7 +1416,7 @@ func TestJobHeartbeat(t *testing.T) {
 		defer s.Close()
 
 		// Create a build
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "A",
 		})))
 
@@ -934,7 +1428,7 @@ func TestJobHeartbeat(t *testing.T) {
 		require.Equal(pb.Job_WAITING, job.State)
 
 		// Ack it
-		_, err = s.JobAck(job.Id, true)
+		_, err = s.JobAck(context.Background(), job.Id, true)
 		require.NoError(err)
 
 		// Start heartbeating
@@ -969,8 +1463,12 @@ func TestJobHeartbeat(t *testing.T) {
 		require.NoError(err)
 		require.Equal(pb.Job_RUNNING, job.Job.State)
 
+		// The heartbeat is recent
+		require.WithinDuration(time.Now(), job.HeartbeatTime, 250*time.Millisecond)
+		require.Equal(job.HeartbeatTime.Add(jobHeartbeatTimeout), job.HeartbeatDeadline())
+
 		// Stop it
-		require.NoError(s.JobComplete(job.Id, nil, nil))
+		require.NoError(s.JobComplete(context.Background(), job.Id, nil, nil))
 	})
 
 	t.Run("times out if heartbeating stops", func(t *testing.T) {
@@ -985,7 +1483,7 @@ func TestJobHeartbeat(t *testing.T) {
 		defer s.Close()
 
 		// Create a build
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "A",
 		})))
 
@@ -997,7 +1495,7 @@ func TestJobHeartbeat(t *testing.T) {
 		require.Equal(pb.Job_WAITING, job.State)
 
 		// Ack it
-		_, err = s.JobAck(job.Id, true)
+		_, err = s.JobAck(context.Background(), job.Id, true)
 		require.NoError(err)
 
 		// Start heartbeating
@@ -1056,7 +1554,7 @@ func TestJobHeartbeat(t *testing.T) {
 		defer s.Close()
 
 		// Create a build
-		require.NoError(s.JobCreate(serverptypes.TestJobNew(t, &pb.Job{
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
 			Id: "A",
 		})))
 
@@ -1068,7 +1566,7 @@ func TestJobHeartbeat(t *testing.T) {
 		require.Equal(pb.Job_WAITING, job.State)
 
 		// Ack it
-		_, err = s.JobAck(job.Id, true)
+		_, err = s.JobAck(context.Background(), job.Id, true)
 		require.NoError(err)
 
 		// Start heartbeating
@@ -1113,3 +1611,37 @@ func TestJobHeartbeat(t *testing.T) {
 		}, 2*time.Second, 10*time.Millisecond)
 	})
 }
+
+func TestJobStates(t *testing.T) {
+	require := require.New(t)
+
+	s := TestState(t)
+	defer s.Close()
+
+	require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+	require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+		Id: "B",
+		Application: &pb.Ref_Application{
+			Application: "a_test",
+			Project:     "other",
+		},
+	})))
+
+	// All jobs
+	ws := memdb.NewWatchSet()
+	states, err := s.JobStates("", ws)
+	require.NoError(err)
+	require.Equal(map[string]pb.Job_State{
+		"A": pb.Job_QUEUED,
+		"B": pb.Job_QUEUED,
+	}, states)
+
+	// The watch fires when a job changes
+	require.NoError(s.JobCancel("A", false, 0))
+	require.False(ws.Watch(time.After(1 * time.Second)))
+
+	// Jobs of a project
+	states, err = s.JobStates("OTHER", nil)
+	require.NoError(err)
+	require.Equal(map[string]pb.Job_State{"B": pb.Job_QUEUED}, states)
+}
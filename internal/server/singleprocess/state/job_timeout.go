@@ -0,0 +1,155 @@
+package state
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+// jobTimeouts are the default timeouts for jobs. Zero values use the
+// package defaults, jobWaitingTimeout and jobHeartbeatTimeout. A zero
+// maxRun means jobs can run for any amount of time.
+type jobTimeouts struct {
+	ack       time.Duration
+	heartbeat time.Duration
+	maxRun    time.Duration
+}
+
+// JobTimeoutsSet sets the default timeouts for jobs. ack is how long a
+// runner has to accept a job, heartbeat is how long a running job can go
+// without a heartbeat, and maxRun is the longest a job can run. Zero values
+// use the defaults. Jobs can override these with their Timeouts field.
+// This should be called once before the state is used. The timers of jobs
+// that were waiting or running when the state was loaded are restarted
+// with the new timeouts.
+func (s *State) JobTimeoutsSet(ack, heartbeat, maxRun time.Duration) error {
+	s.jobTimeouts = jobTimeouts{
+		ack:       ack,
+		heartbeat: heartbeat,
+		maxRun:    maxRun,
+	}
+
+	txn := s.inmemWriteTxn()
+	defer txn.Abort()
+
+	iter, err := txn.Get(jobTableName, jobIdIndexName+"_prefix", "")
+	if err != nil {
+		return err
+	}
+
+	var jobs []*jobIndex
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		if idx := raw.(*jobIndex); idx.State == pb.Job_WAITING || idx.State == pb.Job_RUNNING {
+			jobs = append(jobs, idx.Copy())
+		}
+	}
+
+	for _, idx := range jobs {
+		jobpb, err := s.jobByIdCached(idx.Id)
+		if err != nil {
+			return err
+		}
+		if err := s.jobTimeoutsIndexSet(idx, jobpb); err != nil {
+			return err
+		}
+
+		if idx.StateTimer != nil {
+			if idx.State == pb.Job_WAITING {
+				idx.StateTimer.Reset(idx.AckTimeout)
+			} else {
+				idx.StateTimer.Reset(idx.HeartbeatTimeout)
+			}
+		}
+
+		if idx.State == pb.Job_RUNNING {
+			ackTime, err := ptypes.Timestamp(jobpb.AckTime)
+			if err != nil {
+				ackTime = s.clock.Now()
+			}
+			s.jobMaxRunTimerSet(idx, ackTime)
+		}
+
+		if err := txn.Insert(jobTableName, idx); err != nil {
+			return err
+		}
+	}
+
+	txn.Commit()
+	return nil
+}
+
+// jobTimeoutsIndexSet sets the timeouts of the job index from the job,
+// falling back to the server defaults.
+func (s *State) jobTimeoutsIndexSet(rec *jobIndex, jobpb *pb.Job) error {
+	override := jobpb.Timeouts
+	if override == nil {
+		override = &pb.Job_Timeouts{}
+	}
+
+	fields := []struct {
+		Field    *time.Duration
+		Name     string
+		Override string
+		Defaults []time.Duration
+	}{
+		{&rec.AckTimeout, "ack", override.Ack, []time.Duration{s.jobTimeouts.ack, jobWaitingTimeout}},
+		{&rec.HeartbeatTimeout, "heartbeat", override.Heartbeat, []time.Duration{s.jobTimeouts.heartbeat, jobHeartbeatTimeout}},
+		{&rec.MaxRunDuration, "max run", override.MaxRun, []time.Duration{s.jobTimeouts.maxRun}},
+	}
+	for _, f := range fields {
+		if f.Override != "" {
+			d, err := serverptypes.JobTimeout(f.Override)
+			if err != nil {
+				return fmt.Errorf("invalid %s timeout: %s", f.Name, err)
+			}
+
+			*f.Field = d
+			continue
+		}
+
+		for _, d := range f.Defaults {
+			if d > 0 {
+				*f.Field = d
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// jobMaxRunTimerSet starts the timer that fails the job once it has run
+// for its maximum duration. since is when the job started running.
+func (s *State) jobMaxRunTimerSet(job *jobIndex, since time.Time) {
+	if job.MaxRunTimer != nil {
+		job.MaxRunTimer.Stop()
+		job.MaxRunTimer = nil
+	}
+
+	if job.MaxRunDuration <= 0 {
+		return
+	}
+
+	dur := since.Add(job.MaxRunDuration).Sub(s.clock.Now())
+	if dur <= 0 {
+		dur = 1
+	}
+
+	id, max := job.Id, job.MaxRunDuration
+	job.MaxRunTimer = s.timers.AfterFunc(dur, func() {
+		s.log.Info("canceling job that exceeded its maximum run duration",
+			"job", id, "max_run", max)
+		err := s.jobCancelById(id, true, 0, status.Newf(codes.DeadlineExceeded,
+			"job exceeded its maximum run duration of %s", max))
+		if err != nil {
+			s.log.Error("error canceling job that exceeded its maximum run duration",
+				"error", err, "job", id)
+		}
+	})
+}
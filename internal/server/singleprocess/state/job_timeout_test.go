@@ -0,0 +1,132 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobTimeouts(t *testing.T) {
+	ctx := context.Background()
+
+	// run assigns and acks the job
+	run := func(t *testing.T, s *State, id string) {
+		job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(t, err)
+		require.Equal(t, id, job.Id)
+		_, err = s.JobAck(context.Background(), job.Id, true)
+		require.NoError(t, err)
+	}
+
+	t.Run("server defaults", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		require.NoError(s.JobTimeoutsSet(10*time.Millisecond, time.Minute, 0))
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		require.Equal(pb.Job_WAITING, job.State)
+
+		// Not acking requeues the job
+		require.Eventually(func() bool {
+			job, err := s.JobById("A", nil)
+			require.NoError(err)
+			return job.State == pb.Job_QUEUED
+		}, 2*time.Second, 10*time.Millisecond)
+
+		// Running jobs have the heartbeat timeout
+		run(t, s, "A")
+		job, err = s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(job.HeartbeatTime.Add(time.Minute), job.HeartbeatDeadline())
+	})
+
+	t.Run("job overrides", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		require.NoError(s.JobTimeoutsSet(0, time.Hour, time.Hour))
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:       "A",
+			Timeouts: &pb.Job_Timeouts{Heartbeat: "20ms"},
+		})))
+		run(t, s, "A")
+
+		require.Eventually(func() bool {
+			job, err := s.JobById("A", nil)
+			require.NoError(err)
+			return job.State == pb.Job_ERROR
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("max run", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:       "A",
+			Timeouts: &pb.Job_Timeouts{MaxRun: "50ms"},
+		})))
+		run(t, s, "A")
+
+		var job *Job
+		require.Eventually(func() bool {
+			var err error
+			job, err = s.JobById("A", nil)
+			require.NoError(err)
+			return job.State == pb.Job_ERROR
+		}, 2*time.Second, 10*time.Millisecond)
+		require.Equal(int32(codes.DeadlineExceeded), job.Error.Code)
+		require.Contains(job.Error.Message, "maximum run duration")
+		require.NotNil(job.CancelTime)
+	})
+
+	t.Run("completed jobs stop the max run timer", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:       "A",
+			Timeouts: &pb.Job_Timeouts{MaxRun: "50ms"},
+		})))
+		run(t, s, "A")
+		require.NoError(s.JobComplete(context.Background(), "A", nil, nil))
+
+		time.Sleep(100 * time.Millisecond)
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_SUCCESS, job.State)
+	})
+
+	t.Run("setting defaults updates running jobs", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		run(t, s, "A")
+
+		require.NoError(s.JobTimeoutsSet(0, 0, 10*time.Millisecond))
+		require.Eventually(func() bool {
+			job, err := s.JobById("A", nil)
+			require.NoError(err)
+			return job.State == pb.Job_ERROR
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+}
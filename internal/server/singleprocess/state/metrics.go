@@ -0,0 +1,220 @@
+package state
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// stateMetrics are the counters and histograms of the state so operators
+// can see the health of the scheduler. The zero value is ready to use
+// except that the heartbeat histograms need their buckets set.
+// These are written in the Prometheus text format by WriteMetrics.
+type stateMetrics struct {
+	// The counters are updated atomically.
+	jobsCreated      uint64
+	jobsAssigned     uint64
+	jobsSucceeded    uint64
+	jobsFailed       uint64
+	jobAssignRetries uint64
+
+	// jobHeartbeatsMissed and runnerHeartbeatsMissed count the jobs that
+	// failed and the runners that were deregistered because they didn't
+	// send a heartbeat in time.
+	jobHeartbeatsMissed    uint64
+	runnerHeartbeatsMissed uint64
+
+	// dbRead and dbWrite are the latencies of bolt transactions.
+	dbRead  metricHistogram
+	dbWrite metricHistogram
+
+	// jobHeartbeat and runnerHeartbeat are the intervals between the
+	// heartbeats of running jobs and of runners.
+	jobHeartbeat    metricHistogram
+	runnerHeartbeat metricHistogram
+}
+
+// metricHistogramBuckets are the upper bounds in seconds of the buckets
+// of histograms.
+var metricHistogramBuckets = []float64{
+	0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10,
+}
+
+// metricHeartbeatBuckets are the upper bounds in seconds of the buckets
+// of heartbeat interval histograms. Heartbeats are sent every few seconds
+// and time out after minutes.
+var metricHeartbeatBuckets = []float64{
+	1, 2.5, 5, 10, 15, 30, 45, 60, 90, 120, 180, 300,
+}
+
+// metricHistogram is a histogram of durations. The zero value is ready
+// to use and has the metricHistogramBuckets.
+type metricHistogram struct {
+	lock   sync.Mutex
+	counts []uint64
+	count  uint64
+	sum    float64
+
+	// buckets, if set, are the upper bounds of the buckets instead of
+	// metricHistogramBuckets. This must be set before the histogram is
+	// used.
+	buckets []float64
+}
+
+// observe records the duration since start.
+func (h *metricHistogram) observe(start time.Time) {
+	h.observeDuration(time.Since(start))
+}
+
+// observeDuration records the duration.
+func (h *metricHistogram) observeDuration(d time.Duration) {
+	v := d.Seconds()
+	buckets := h.bucketsGet()
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.counts == nil {
+		h.counts = make([]uint64, len(buckets))
+	}
+	for i, le := range buckets {
+		if v <= le {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// write writes the samples of the histogram with the given labels.
+func (h *metricHistogram) write(w io.Writer, name, labels string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for i, le := range h.bucketsGet() {
+		var count uint64
+		if h.counts != nil {
+			count = h.counts[i]
+		}
+
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n",
+			name, labels, strconv.FormatFloat(le, 'g', -1, 64), count)
+	}
+	fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+	fmt.Fprintf(w, "%s_sum{%s} %s\n", name, labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, h.count)
+}
+
+// bucketsGet returns the upper bounds of the buckets of the histogram.
+func (h *metricHistogram) bucketsGet() []float64 {
+	if h.buckets != nil {
+		return h.buckets
+	}
+
+	return metricHistogramBuckets
+}
+
+// jobCompleted counts a job that completed in the given state.
+func (m *stateMetrics) jobCompleted(state pb.Job_State) {
+	switch state {
+	case pb.Job_SUCCESS:
+		atomic.AddUint64(&m.jobsSucceeded, 1)
+	case pb.Job_ERROR:
+		atomic.AddUint64(&m.jobsFailed, 1)
+	}
+}
+
+// WriteMetrics writes the metrics of the state in the Prometheus text
+// exposition format.
+func (s *State) WriteMetrics(w io.Writer) error {
+	m := &s.metrics
+
+	counter := func(name, help string, samples ...string) {
+		fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+		fmt.Fprintf(w, "# TYPE %s counter\n", name)
+		for _, sample := range samples {
+			fmt.Fprintf(w, "%s%s\n", name, sample)
+		}
+	}
+	value := func(v *uint64) string {
+		return " " + strconv.FormatUint(atomic.LoadUint64(v), 10)
+	}
+
+	counter("waypoint_state_jobs_created_total",
+		"Jobs queued.", value(&m.jobsCreated))
+	counter("waypoint_state_jobs_assigned_total",
+		"Jobs assigned to a runner.", value(&m.jobsAssigned))
+	counter("waypoint_state_jobs_completed_total",
+		"Jobs completed, by whether they succeeded or failed.",
+		`{state="success"}`+value(&m.jobsSucceeded),
+		`{state="error"}`+value(&m.jobsFailed))
+	counter("waypoint_state_job_assign_retries_total",
+		"Job assignments that were retried because every candidate job "+
+			"changed before it could be assigned.", value(&m.jobAssignRetries))
+	counter("waypoint_state_heartbeats_missed_total",
+		"Running jobs that failed and runners that were deregistered because "+
+			"they didn't send a heartbeat in time.",
+		`{type="job"}`+value(&m.jobHeartbeatsMissed),
+		`{type="runner"}`+value(&m.runnerHeartbeatsMissed))
+
+	// The number of jobs in each state is counted from the index.
+	counts, err := s.jobStateCounts()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "# HELP waypoint_state_jobs Jobs in each state.\n")
+	fmt.Fprintf(w, "# TYPE waypoint_state_jobs gauge\n")
+	for i := int32(1); i < int32(len(pb.Job_State_name)); i++ {
+		state := pb.Job_State(i)
+		fmt.Fprintf(w, "waypoint_state_jobs{state=\"%s\"} %d\n",
+			strings.ToLower(state.String()), counts[state])
+	}
+
+	const dbName = "waypoint_state_db_transaction_seconds"
+	fmt.Fprintf(w, "# HELP %s Latency of database transactions.\n", dbName)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", dbName)
+	m.dbRead.write(w, dbName, `type="read"`)
+	m.dbWrite.write(w, dbName, `type="write"`)
+
+	const heartbeatName = "waypoint_state_heartbeat_interval_seconds"
+	fmt.Fprintf(w, "# HELP %s Time between heartbeats of running jobs and of runners.\n", heartbeatName)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", heartbeatName)
+	m.jobHeartbeat.write(w, heartbeatName, `type="job"`)
+	m.runnerHeartbeat.write(w, heartbeatName, `type="runner"`)
+
+	// Running jobs that are late are counted so that operators are warned
+	// before they fail. See job_heartbeat.go.
+	late, err := s.JobHeartbeatLateList(jobHeartbeatLateFraction)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "# HELP waypoint_state_jobs_heartbeat_late Running jobs that "+
+		"haven't sent a heartbeat for over half of their heartbeat timeout.\n")
+	fmt.Fprintf(w, "# TYPE waypoint_state_jobs_heartbeat_late gauge\n")
+	fmt.Fprintf(w, "waypoint_state_jobs_heartbeat_late %d\n", len(late))
+
+	return nil
+}
+
+// jobStateCounts returns the number of jobs in each state.
+func (s *State) jobStateCounts() (map[pb.Job_State]int, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	iter, err := memTxn.Get(jobTableName, jobIdIndexName+"_prefix", "")
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[pb.Job_State]int{}
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		result[raw.(*jobIndex).State]++
+	}
+
+	return result, nil
+}
@@ -0,0 +1,44 @@
+package state
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	require := require.New(t)
+
+	s := TestState(t)
+	defer s.Close()
+
+	// Queue two jobs, run one to completion
+	require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+	require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "B"})))
+
+	job, err := s.JobAssignForRunner(context.Background(), &pb.Runner{Id: "R"})
+	require.NoError(err)
+	_, err = s.JobAck(context.Background(), job.Id, true)
+	require.NoError(err)
+	require.NoError(s.JobComplete(context.Background(), job.Id, nil, nil))
+
+	var buf bytes.Buffer
+	require.NoError(s.WriteMetrics(&buf))
+	out := buf.String()
+
+	require.Contains(out, "# TYPE waypoint_state_jobs_created_total counter\n")
+	require.Contains(out, "waypoint_state_jobs_created_total 2\n")
+	require.Contains(out, "waypoint_state_jobs_assigned_total 1\n")
+	require.Contains(out, `waypoint_state_jobs_completed_total{state="success"} 1`+"\n")
+	require.Contains(out, `waypoint_state_jobs_completed_total{state="error"} 0`+"\n")
+	require.Contains(out, `waypoint_state_jobs{state="queued"} 1`+"\n")
+	require.Contains(out, `waypoint_state_jobs{state="success"} 1`+"\n")
+	require.Contains(out, `waypoint_state_jobs{state="running"} 0`+"\n")
+	require.Contains(out, "# TYPE waypoint_state_db_transaction_seconds histogram\n")
+	require.Regexp(`waypoint_state_db_transaction_seconds_count\{type="write"\} [1-9]`, out)
+}
@@ -0,0 +1,120 @@
+package state
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/boltdb/bolt"
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// This file has the migrations of the persisted data. The version of the
+// data is stored in the system bucket. When the server starts, or a
+// snapshot is restored, data that is older than dbVersion is upgraded by
+// running each migration after its version in order. All the migrations
+// run in the same transaction as the version is written, so if any fails
+// the data is left as it was.
+
+// dbMigrations are the migrations of the persisted data in order. The
+// migration at index i upgrades data from version i+1 to version i+2, so
+// there must always be dbVersion-1 migrations. Migrations must only be
+// appended and must write with dbPut, dbPutRaw, or dbDelete so that the
+// writes are replicated when a snapshot is restored.
+var dbMigrations = []dbMigration{
+	{
+		Name:    "persist job expiries",
+		Migrate: jobExpiryMigrate,
+	},
+}
+
+// dbMigration is a single migration of the persisted data.
+type dbMigration struct {
+	// Name describes what the migration does. This is logged.
+	Name string
+
+	// Migrate upgrades the data in the transaction.
+	Migrate func(log hclog.Logger, dbTxn *bolt.Tx) error
+}
+
+func init() {
+	if int64(len(dbMigrations)) != dbVersion-1 {
+		panic(fmt.Sprintf(
+			"there are %d data migrations but the data version is %d",
+			len(dbMigrations), dbVersion))
+	}
+}
+
+// dbVersionUpgrade checks the version of the data in the transaction and
+// migrates it to dbVersion if it is older. Data without a version is new
+// and is set to dbVersion. This errors if the data is newer than this
+// server can read.
+func dbVersionUpgrade(log hclog.Logger, dbTxn *bolt.Tx) error {
+	return dbMigrate(log, dbTxn, dbMigrations, dbVersion)
+}
+
+// dbMigrate migrates the data in the transaction to the target version
+// with the given migrations. See dbMigrations.
+func dbMigrate(
+	log hclog.Logger,
+	dbTxn *bolt.Tx,
+	migrations []dbMigration,
+	target int64,
+) error {
+	sys := dbTxn.Bucket(sysBucket)
+	vsnRaw := sys.Get(sysVersionKey)
+
+	// Initialize the version with our current version if it isn't set.
+	vsn := target
+	if len(vsnRaw) > 0 {
+		var err error
+		vsn, err = strconv.ParseInt(string(vsnRaw), 10, 64)
+		if err != nil || vsn < 1 {
+			return status.Errorf(codes.Internal,
+				"failed to read database version: %q", vsnRaw)
+		}
+	}
+
+	if vsn > target {
+		return status.Errorf(codes.FailedPrecondition, strings.TrimSpace(`
+The database version on disk is newer than the server version.
+
+The server cannot safely read this data. Please upgrade your server to a
+version that is capable of reading this data version. You can find this
+information on the Waypoint website.
+
+On-disk data version: %d
+ Server data version: %d
+
+`), vsn, target)
+	}
+
+	if vsn < target {
+		log.Warn("migrating data to a new version", "from", vsn, "to", target)
+	}
+	for ; vsn < target; vsn++ {
+		if vsn > int64(len(migrations)) {
+			return status.Errorf(codes.Internal,
+				"no migration from data version %d", vsn)
+		}
+
+		m := migrations[vsn-1]
+		log.Info("running data migration", "from", vsn, "to", vsn+1, "name", m.Name)
+		if err := m.Migrate(log, dbTxn); err != nil {
+			return status.Errorf(codes.Internal,
+				"failed to migrate data from version %d (%s): %s", vsn, m.Name, err)
+		}
+	}
+
+	// Write the version if it changed.
+	if v := strconv.FormatInt(target, 10); string(vsnRaw) != v {
+		if err := dbPutRaw(sys, sysVersionKey, []byte(v)); err != nil {
+			return status.Errorf(codes.Internal,
+				"failed to write database version: %s", err)
+		}
+	}
+
+	return nil
+}
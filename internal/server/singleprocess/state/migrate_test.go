@@ -0,0 +1,154 @@
+package state
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/boltdb/bolt"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDBMigrate(t *testing.T) {
+	testBucket := []byte("test")
+
+	// Migrations that record that they ran
+	migrations := []dbMigration{
+		{
+			Name: "one",
+			Migrate: func(log hclog.Logger, dbTxn *bolt.Tx) error {
+				b, err := dbTxn.CreateBucketIfNotExists(testBucket)
+				if err != nil {
+					return err
+				}
+
+				return dbPutRaw(b, []byte("one"), []byte("1"))
+			},
+		},
+		{
+			Name: "two",
+			Migrate: func(log hclog.Logger, dbTxn *bolt.Tx) error {
+				return dbPutRaw(dbTxn.Bucket(testBucket), []byte("two"), []byte("2"))
+			},
+		},
+	}
+
+	// setVersion creates a database with the given data version.
+	setVersion := func(t *testing.T, vsn string) *bolt.DB {
+		db := testDB(t)
+		require.NoError(t, db.Update(func(dbTxn *bolt.Tx) error {
+			b, err := dbTxn.CreateBucketIfNotExists(sysBucket)
+			if err != nil {
+				return err
+			}
+			if vsn == "" {
+				return nil
+			}
+
+			return b.Put(sysVersionKey, []byte(vsn))
+		}))
+
+		return db
+	}
+
+	// version returns the data version of the database.
+	version := func(t *testing.T, db *bolt.DB) string {
+		var result string
+		require.NoError(t, db.View(func(dbTxn *bolt.Tx) error {
+			result = string(dbTxn.Bucket(sysBucket).Get(sysVersionKey))
+			return nil
+		}))
+
+		return result
+	}
+
+	t.Run("new data is set to the latest version", func(t *testing.T) {
+		require := require.New(t)
+
+		db := setVersion(t, "")
+		require.NoError(db.Update(func(dbTxn *bolt.Tx) error {
+			return dbMigrate(hclog.L(), dbTxn, migrations, 3)
+		}))
+		require.Equal("3", version(t, db))
+
+		// No migrations ran
+		require.NoError(db.View(func(dbTxn *bolt.Tx) error {
+			require.Nil(dbTxn.Bucket(testBucket))
+			return nil
+		}))
+	})
+
+	t.Run("older data is migrated in order", func(t *testing.T) {
+		require := require.New(t)
+
+		db := setVersion(t, "1")
+		require.NoError(db.Update(func(dbTxn *bolt.Tx) error {
+			return dbMigrate(hclog.L(), dbTxn, migrations, 3)
+		}))
+		require.Equal("3", version(t, db))
+
+		require.NoError(db.View(func(dbTxn *bolt.Tx) error {
+			b := dbTxn.Bucket(testBucket)
+			require.Equal("1", string(b.Get([]byte("one"))))
+			require.Equal("2", string(b.Get([]byte("two"))))
+			return nil
+		}))
+	})
+
+	t.Run("only later migrations run", func(t *testing.T) {
+		require := require.New(t)
+
+		db := setVersion(t, "2")
+		require.NoError(db.Update(func(dbTxn *bolt.Tx) error {
+			_, err := dbTxn.CreateBucket(testBucket)
+			return err
+		}))
+		require.NoError(db.Update(func(dbTxn *bolt.Tx) error {
+			return dbMigrate(hclog.L(), dbTxn, migrations, 3)
+		}))
+		require.Equal("3", version(t, db))
+
+		require.NoError(db.View(func(dbTxn *bolt.Tx) error {
+			b := dbTxn.Bucket(testBucket)
+			require.Nil(b.Get([]byte("one")))
+			require.Equal("2", string(b.Get([]byte("two"))))
+			return nil
+		}))
+	})
+
+	t.Run("failed migrations leave the data unchanged", func(t *testing.T) {
+		require := require.New(t)
+
+		failing := append([]dbMigration{}, migrations...)
+		failing[1].Migrate = func(log hclog.Logger, dbTxn *bolt.Tx) error {
+			return errors.New("nope")
+		}
+
+		db := setVersion(t, "1")
+		err := db.Update(func(dbTxn *bolt.Tx) error {
+			return dbMigrate(hclog.L(), dbTxn, failing, 3)
+		})
+		require.Error(err)
+		require.Contains(err.Error(), "two")
+		require.Equal("1", version(t, db))
+
+		require.NoError(db.View(func(dbTxn *bolt.Tx) error {
+			require.Nil(dbTxn.Bucket(testBucket))
+			return nil
+		}))
+	})
+
+	t.Run("newer data can't be read", func(t *testing.T) {
+		require := require.New(t)
+
+		db := setVersion(t, "4")
+		err := db.Update(func(dbTxn *bolt.Tx) error {
+			return dbMigrate(hclog.L(), dbTxn, migrations, 3)
+		})
+		require.Error(err)
+		require.Equal(codes.FailedPrecondition, status.Code(err))
+		require.Equal("4", version(t, db))
+	})
+}
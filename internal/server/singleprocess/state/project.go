@@ -22,10 +22,10 @@ func init() {
 
 // ProjectPut creates or updates the given project.
 func (s *State) ProjectPut(p *pb.Project) error {
-	memTxn := s.inmem.Txn(true)
+	memTxn := s.inmemWriteTxn()
 	defer memTxn.Abort()
 
-	err := s.db.Update(func(dbTxn *bolt.Tx) error {
+	err := s.dbUpdate(func(dbTxn *bolt.Tx) error {
 		return s.projectPut(dbTxn, memTxn, p)
 	})
 	if err == nil {
@@ -41,7 +41,7 @@ func (s *State) ProjectGet(ref *pb.Ref_Project) (*pb.Project, error) {
 	defer memTxn.Abort()
 
 	var result *pb.Project
-	err := s.db.View(func(dbTxn *bolt.Tx) error {
+	err := s.dbView(func(dbTxn *bolt.Tx) error {
 		var err error
 		result, err = s.projectGet(dbTxn, memTxn, ref)
 		return err
@@ -50,21 +50,54 @@ func (s *State) ProjectGet(ref *pb.Ref_Project) (*pb.Project, error) {
 	return result, err
 }
 
+// projectDeleteHooks delete the records of a project when it is deleted,
+// in the same transactions as the project. These are registered in init.
+// A hook can return a function to call once the transactions commit, such
+// as to notify waiters or release memory, or nil.
+var projectDeleteHooks []func(
+	s *State,
+	dbTxn *bolt.Tx,
+	memTxn *memdb.Txn,
+	ref *pb.Ref_Project,
+) (func(), error)
+
 // ProjectDelete deletes a project by reference. This is a complete data
 // delete. This will delete all operations associated with this project
-// as well.
+// as well, and the records of the project such as its jobs are deleted
+// atomically with it. See projectDeleteHooks.
 func (s *State) ProjectDelete(ref *pb.Ref_Project) error {
-	memTxn := s.inmem.Txn(true)
+	memTxn := s.inmemWriteTxn()
 	defer memTxn.Abort()
 
-	err := s.db.Update(func(dbTxn *bolt.Tx) error {
-		return s.projectDelete(dbTxn, memTxn, ref)
+	var after []func()
+	err := s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		after = nil
+		if err := s.projectDelete(dbTxn, memTxn, ref); err != nil {
+			return err
+		}
+
+		for _, hook := range projectDeleteHooks {
+			fn, err := hook(s, dbTxn, memTxn, ref)
+			if err != nil {
+				return err
+			}
+			if fn != nil {
+				after = append(after, fn)
+			}
+		}
+
+		return nil
 	})
-	if err == nil {
-		memTxn.Commit()
+	if err != nil {
+		return err
 	}
 
-	return err
+	memTxn.Commit()
+	for _, fn := range after {
+		fn()
+	}
+
+	return nil
 }
 
 // ProjectList returns the list of projects.
@@ -173,7 +206,7 @@ func (s *State) projectDelete(
 
 	// Delete from bolt
 	id := s.projectIdByRef(ref)
-	if err := dbTxn.Bucket(projectBucket).Delete(id); err != nil {
+	if err := dbDelete(dbTxn.Bucket(projectBucket), id); err != nil {
 		return err
 	}
 
@@ -0,0 +1,117 @@
+package state
+
+import (
+	"github.com/boltdb/bolt"
+	"github.com/hashicorp/go-memdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// ProjectImport writes the records of a project exported from another
+// server in a single transaction. The first record must be the project,
+// which must not already exist. Operations keep their sequence numbers,
+// so IDs must already be unique to this server and references between
+// records must use those IDs.
+//
+// Imported jobs must be complete, since they're only kept as history.
+func (s *State) ProjectImport(records []*pb.ProjectExport_Record) error {
+	if len(records) == 0 || records[0].GetProject() == nil {
+		return status.Errorf(codes.InvalidArgument,
+			"the first record of a project import must be the project")
+	}
+	project := records[0].GetProject()
+
+	memTxn := s.inmemWriteTxn()
+	defer memTxn.Abort()
+
+	var jobs []*pb.Job
+	sensitive := false
+	err := s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		_, err := s.projectGet(dbTxn, memTxn, &pb.Ref_Project{Project: project.Name})
+		if err == nil {
+			return status.Errorf(codes.AlreadyExists,
+				"project %q already exists", project.Name)
+		}
+		if status.Code(err) != codes.NotFound {
+			return err
+		}
+
+		for _, rec := range records {
+			switch v := rec.Record.(type) {
+			case *pb.ProjectExport_Record_Project:
+				if v.Project != project {
+					return status.Errorf(codes.InvalidArgument,
+						"a project import must contain exactly one project")
+				}
+				err = s.projectPut(dbTxn, memTxn, v.Project)
+
+			case *pb.ProjectExport_Record_ConfigVar:
+				sensitive = sensitive || v.ConfigVar.Sensitive
+				err = s.configSet(dbTxn, memTxn, v.ConfigVar)
+
+			case *pb.ProjectExport_Record_Job:
+				err = s.jobImport(dbTxn, memTxn, v.Job)
+				jobs = append(jobs, v.Job)
+
+			case *pb.ProjectExport_Record_Build:
+				err = buildOp.dbImport(s, dbTxn, memTxn, v.Build)
+
+			case *pb.ProjectExport_Record_Artifact:
+				err = artifactOp.dbImport(s, dbTxn, memTxn, v.Artifact)
+
+			case *pb.ProjectExport_Record_Deployment:
+				err = deploymentOp.dbImport(s, dbTxn, memTxn, v.Deployment)
+
+			case *pb.ProjectExport_Record_Release:
+				err = releaseOp.dbImport(s, dbTxn, memTxn, v.Release)
+
+			default:
+				err = status.Errorf(codes.InvalidArgument,
+					"unknown project import record type %T", rec.Record)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	memTxn.Commit()
+
+	for _, job := range jobs {
+		s.jobCacheSet(job)
+	}
+
+	if sensitive {
+		return s.redactRefresh()
+	}
+
+	return nil
+}
+
+// jobImport writes a completed job imported from another server.
+func (s *State) jobImport(dbTxn *bolt.Tx, memTxn *memdb.Txn, jobpb *pb.Job) error {
+	switch jobpb.State {
+	case pb.Job_SUCCESS, pb.Job_ERROR:
+	default:
+		return status.Errorf(codes.InvalidArgument,
+			"job %q can't be imported because it isn't complete", jobpb.Id)
+	}
+
+	id := []byte(jobpb.Id)
+	b := dbTxn.Bucket(jobBucket)
+	if b.Get(id) != nil {
+		return status.Errorf(codes.AlreadyExists, "job %q already exists", jobpb.Id)
+	}
+	if err := dbPut(b, id, jobpb); err != nil {
+		return err
+	}
+
+	_, err := s.jobIndexSet(memTxn, id, jobpb)
+	return err
+}
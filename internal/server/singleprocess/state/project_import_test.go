@@ -0,0 +1,111 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestProjectImport(t *testing.T) {
+	// Sequence numbers are shared across states in the same process so we
+	// use a project that no other test uses.
+	appRef := &pb.Ref_Application{Project: "p_import", Application: "a_import"}
+	project := func() *pb.ProjectExport_Record {
+		return &pb.ProjectExport_Record{
+			Record: &pb.ProjectExport_Record_Project{
+				Project: serverptypes.TestProject(t, &pb.Project{Name: appRef.Project}),
+			},
+		}
+	}
+
+	t.Run("keeps sequence numbers", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.ProjectImport([]*pb.ProjectExport_Record{
+			project(),
+			{
+				Record: &pb.ProjectExport_Record_Build{
+					Build: serverptypes.TestValidBuild(t, &pb.Build{
+						Id:          "A",
+						Application: appRef,
+						Sequence:    7,
+					}),
+				},
+			},
+		}))
+
+		b, err := s.BuildGet(&pb.Ref_Operation{
+			Target: &pb.Ref_Operation_Id{Id: "A"},
+		})
+		require.NoError(err)
+		require.Equal(uint64(7), b.Sequence)
+
+		// New builds are after the imported ones
+		next := serverptypes.TestValidBuild(t, &pb.Build{Id: "B", Application: appRef})
+		require.NoError(s.BuildPut(false, next))
+		require.Equal(uint64(8), next.Sequence)
+	})
+
+	t.Run("requires the project first", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		err := s.ProjectImport([]*pb.ProjectExport_Record{{
+			Record: &pb.ProjectExport_Record_Build{
+				Build: serverptypes.TestValidBuild(t, &pb.Build{Id: "A"}),
+			},
+		}})
+		require.Error(err)
+		require.Equal(codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("existing project", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.ProjectPut(serverptypes.TestProject(t, &pb.Project{Name: appRef.Project})))
+		err := s.ProjectImport([]*pb.ProjectExport_Record{project()})
+		require.Error(err)
+		require.Equal(codes.AlreadyExists, status.Code(err))
+	})
+
+	t.Run("incomplete jobs abort the import", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		err := s.ProjectImport([]*pb.ProjectExport_Record{
+			project(),
+			{
+				Record: &pb.ProjectExport_Record_Job{
+					Job: serverptypes.TestJobNew(t, &pb.Job{
+						Id:    "A",
+						State: pb.Job_RUNNING,
+					}),
+				},
+			},
+		})
+		require.Error(err)
+		require.Equal(codes.InvalidArgument, status.Code(err))
+
+		// Nothing was written
+		_, err = s.ProjectGet(&pb.Ref_Project{Project: appRef.Project})
+		require.Equal(codes.NotFound, status.Code(err))
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.Nil(job)
+	})
+}
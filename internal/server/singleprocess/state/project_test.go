@@ -1,7 +1,9 @@
 package state
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
@@ -107,4 +109,79 @@ func TestProject(t *testing.T) {
 			require.Len(resp, 0)
 		}
 	})
+
+	t.Run("Delete cascades to jobs", func(t *testing.T) {
+		require := require.New(t)
+		ctx := context.Background()
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.ProjectPut(serverptypes.TestProject(t, &pb.Project{
+			Name: "p_test",
+		})))
+
+		other := &pb.Ref_Application{Project: "other", Application: "a"}
+
+		// A running job with output, a queued job, a completed job, and a
+		// job of another project that depends on the queued job
+		for _, job := range []*pb.Job{
+			{Id: "A"},
+			{Id: "B"},
+			{Id: "C"},
+			{Id: "D", Application: other, DependsOn: []string{"B"}},
+		} {
+			require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, job)))
+		}
+		job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		require.Equal("A", job.Id)
+		job, err = s.JobAck(context.Background(), "A", true)
+		require.NoError(err)
+		require.NotNil(job.OutputBuffer)
+		reader := job.OutputBuffer.Reader(-1)
+		require.NoError(s.JobCancel("C", true, 0))
+		require.NoError(s.JobQueuePause(&pb.JobQueuePause{Project: "p_test"}))
+
+		require.NoError(s.ProjectDelete(&pb.Ref_Project{Project: "p_test"}))
+
+		// The jobs of the project are gone
+		for _, id := range []string{"A", "B", "C"} {
+			job, err := s.JobById(id, nil)
+			require.NoError(err)
+			require.Nil(job, id)
+		}
+
+		// The output buffer is closed, which ends its readers
+		require.Nil(reader.Read(1, true))
+
+		// The dependent job of the other project fails
+		require.Eventually(func() bool {
+			job, err := s.JobById("D", nil)
+			require.NoError(err)
+			return job.State == pb.Job_ERROR
+		}, 2*time.Second, 10*time.Millisecond)
+
+		// The paused queue of the project is deleted
+		pauses, err := s.JobQueuePauses()
+		require.NoError(err)
+		require.Empty(pauses)
+
+		// The running job no longer blocks the application
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "E"})))
+		job, err = s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		require.Equal("E", job.Id)
+
+		// Nothing is left behind
+		issues, err := s.Verify(false)
+		require.NoError(err)
+		require.Empty(issues)
+
+		s = TestStateReinit(t, s)
+		defer s.Close()
+		jobs, err := s.JobList()
+		require.NoError(err)
+		require.Len(jobs, 2)
+	})
 }
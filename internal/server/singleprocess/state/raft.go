@@ -0,0 +1,512 @@
+package state
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to replicating the state across
+// servers with raft. Only the leader writes. A write runs in a bolt
+// transaction that records the puts and deletes and is then rolled back.
+// The recorded writes are appended to the raft log and applied to the
+// database of every server once they're committed, including the leader.
+//
+// The in-memory indexes are only built once the server is the leader and
+// has applied the whole log, so the other servers are standbys that take
+// over if the leader fails. Servers don't serve requests until they're
+// the leader and stop serving if they lose leadership, except for the
+// reads in raft_follower.go.
+
+var (
+	// raftApplyTimeout is how long a write waits to be replicated.
+	raftApplyTimeout = 30 * time.Second
+
+	// raftRecorders maps a database to the recorder of the writes to it if
+	// the state is replicated. bolt has a single write transaction at a time
+	// so a recorder only records one transaction at a time.
+	raftRecorders sync.Map
+
+	// errRaftRollback rolls back the transaction of a replicated write once
+	// the writes are recorded.
+	errRaftRollback = errors.New("rollback replicated write")
+)
+
+// RaftConfig configures replicating the state across servers with raft.
+type RaftConfig struct {
+	// ID is the unique ID of this server in the cluster.
+	ID string
+
+	// Addr is the address to listen on for raft traffic. This is also the
+	// address advertised to the other servers so it must be reachable by
+	// them.
+	Addr string
+
+	// Dir is the directory to store the raft log and snapshots in.
+	Dir string
+
+	// Peers are the addresses of the other servers keyed by ID. These are
+	// only used to bootstrap the cluster the first time it starts.
+	Peers map[string]string
+
+	// LeaderLost is called if this server loses leadership. Writes fail
+	// once this happens so the server should stop serving.
+	LeaderLost func()
+
+	// Follower, if set, is called once raft has started and before this
+	// server waits to become the leader. The state can serve the reads in
+	// raft_follower.go from then until NewRaft returns. This must not
+	// block.
+	Follower func(*State)
+}
+
+type stateRaft struct {
+	raft      *raft.Raft
+	store     *raftStore
+	transport *raft.NetworkTransport
+	recorder  *raftRecorder
+
+	// lock is held by writes from when they're recorded until they're
+	// applied so that writes are applied in the order they're recorded.
+	lock sync.Mutex
+}
+
+type raftRecorder struct {
+	ops []*pb.RaftEntry_Op
+}
+
+// NewRaft initializes a new State store that is replicated across servers
+// with raft. This blocks until this server is the leader and has applied
+// the whole log, or the context is cancelled.
+func NewRaft(
+	ctx context.Context,
+	log hclog.Logger,
+	db *bolt.DB,
+	cfg *RaftConfig,
+	opts ...Option,
+) (*State, error) {
+	s, err := newState(log, db, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.raftStart(ctx, cfg); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	if err := s.index(); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// RaftLeader returns the address of the current leader if the state is
+// replicated. This is empty if there is no leader or the state isn't
+// replicated.
+func (s *State) RaftLeader() string {
+	if s.raft == nil {
+		return ""
+	}
+
+	return string(s.raft.raft.Leader())
+}
+
+// raftStart starts raft and waits until this server is the leader.
+func (s *State) raftStart(ctx context.Context, cfg *RaftConfig) error {
+	log := s.log.Named("raft")
+	if cfg.ID == "" || cfg.Addr == "" || cfg.Dir == "" {
+		return fmt.Errorf("raft requires an ID, address, and directory")
+	}
+	if err := os.MkdirAll(cfg.Dir, 0700); err != nil {
+		return err
+	}
+
+	store, err := newRaftStore(filepath.Join(cfg.Dir, "raft.db"))
+	if err != nil {
+		return err
+	}
+	snaps, err := raft.NewFileSnapshotStore(cfg.Dir, 2,
+		log.StandardWriter(&hclog.StandardLoggerOptions{InferLevels: true}))
+	if err != nil {
+		store.Close()
+		return err
+	}
+
+	advertise, err := net.ResolveTCPAddr("tcp", cfg.Addr)
+	if err != nil {
+		store.Close()
+		return err
+	}
+	transport, err := raft.NewTCPTransport(cfg.Addr, advertise, 3, 10*time.Second,
+		log.StandardWriter(&hclog.StandardLoggerOptions{InferLevels: true}))
+	if err != nil {
+		store.Close()
+		return err
+	}
+
+	notifyCh := make(chan bool, 10)
+	conf := raft.DefaultConfig()
+	conf.LocalID = raft.ServerID(cfg.ID)
+	conf.Logger = log
+	conf.NotifyCh = notifyCh
+
+	// Bootstrap the cluster the first time it starts.
+	existing, err := raft.HasExistingState(store, store, snaps)
+	if err != nil {
+		transport.Close()
+		store.Close()
+		return err
+	}
+	if !existing {
+		servers := []raft.Server{{
+			ID:      conf.LocalID,
+			Address: transport.LocalAddr(),
+		}}
+		for id, addr := range cfg.Peers {
+			servers = append(servers, raft.Server{
+				ID:      raft.ServerID(id),
+				Address: raft.ServerAddress(addr),
+			})
+		}
+
+		log.Info("bootstrapping raft cluster", "servers", len(servers))
+		err := raft.BootstrapCluster(conf, store, store, snaps, transport,
+			raft.Configuration{Servers: servers})
+		if err != nil {
+			transport.Close()
+			store.Close()
+			return err
+		}
+	}
+
+	recorder := &raftRecorder{}
+	raftRecorders.Store(s.db, recorder)
+	r, err := raft.NewRaft(conf, (*raftFSM)(s), store, store, snaps, transport)
+	if err != nil {
+		raftRecorders.Delete(s.db)
+		transport.Close()
+		store.Close()
+		return err
+	}
+	s.raft = &stateRaft{
+		raft:      r,
+		store:     store,
+		transport: transport,
+		recorder:  recorder,
+	}
+
+	if cfg.Follower != nil {
+		cfg.Follower(s)
+	}
+
+	// Wait until we're the leader
+	log.Info("waiting to become the raft leader")
+	for leader := false; !leader; {
+		select {
+		case leader = <-notifyCh:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	// Apply the whole log before we index it.
+	if err := r.Barrier(0).Error(); err != nil {
+		return err
+	}
+	log.Info("this server is the raft leader")
+
+	go func() {
+		for leader := range notifyCh {
+			if !leader {
+				log.Error("this server lost raft leadership")
+				if cfg.LeaderLost != nil {
+					cfg.LeaderLost()
+				}
+
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// raftStop stops raft if the state is replicated.
+func (s *State) raftStop() error {
+	if s.raft == nil {
+		return nil
+	}
+
+	if err := s.raft.raft.Shutdown().Error(); err != nil {
+		return err
+	}
+	raftRecorders.Delete(s.db)
+	if err := s.raft.transport.Close(); err != nil {
+		return err
+	}
+
+	return s.raft.store.Close()
+}
+
+// dbUpdate runs fn in a read-write transaction. If the state is replicated
+// the writes of fn are replicated rather than written directly.
+func (s *State) dbUpdate(fn func(*bolt.Tx) error) error {
+	return s.dbUpdateCtx(context.Background(), fn)
+}
+
+// dbUpdateCtx is like dbUpdate but fn isn't run if the context is done
+// before the transaction starts, such as while waiting for other writes.
+// Once fn runs the write is made even if the context is done later, since
+// the in-memory indexes must match it.
+func (s *State) dbUpdateCtx(ctx context.Context, fn func(*bolt.Tx) error) error {
+	defer s.metrics.dbWrite.observe(time.Now())
+	fn = dbCtxCheck(ctx, fn)
+	if s.raft == nil {
+		s.dbLock.RLock()
+		defer s.dbLock.RUnlock()
+		return s.db.Update(fn)
+	}
+
+	return s.raftUpdate(ctx, fn)
+}
+
+// dbBatch is like dbUpdate but the transaction may be batched with other
+// transactions if the state isn't replicated. See bolt.DB.Batch.
+func (s *State) dbBatch(fn func(*bolt.Tx) error) error {
+	return s.dbBatchCtx(context.Background(), fn)
+}
+
+// dbBatchCtx is like dbBatch but fn isn't run if the context is done. See
+// dbUpdateCtx.
+func (s *State) dbBatchCtx(ctx context.Context, fn func(*bolt.Tx) error) error {
+	defer s.metrics.dbWrite.observe(time.Now())
+	fn = dbCtxCheck(ctx, fn)
+	if s.raft == nil {
+		s.dbLock.RLock()
+		defer s.dbLock.RUnlock()
+		return s.db.Batch(fn)
+	}
+
+	return s.raftUpdate(ctx, fn)
+}
+
+// dbCtxCheck returns fn wrapped to return the error of the context instead
+// of running if the context is done.
+func dbCtxCheck(ctx context.Context, fn func(*bolt.Tx) error) func(*bolt.Tx) error {
+	if ctx.Done() == nil {
+		return fn
+	}
+
+	return func(dbTxn *bolt.Tx) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		return fn(dbTxn)
+	}
+}
+
+// raftUpdate records the writes of fn and replicates them. This returns
+// once the writes are applied to the database of this server. The time to
+// wait for the writes to be accepted by raft is bounded by the deadline of
+// the context.
+func (s *State) raftUpdate(ctx context.Context, fn func(*bolt.Tx) error) error {
+	r := s.raft
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.raft.State() != raft.Leader {
+		return status.Errorf(codes.Unavailable,
+			"this server is not the leader, the leader is %q", r.raft.Leader())
+	}
+
+	r.recorder.ops = nil
+	s.dbLock.RLock()
+	err := s.db.Update(func(dbTxn *bolt.Tx) error {
+		if err := fn(dbTxn); err != nil {
+			return err
+		}
+
+		return errRaftRollback
+	})
+	s.dbLock.RUnlock()
+	ops := r.recorder.ops
+	r.recorder.ops = nil
+	if err != errRaftRollback {
+		return err
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+
+	data, err := proto.Marshal(&pb.RaftEntry{Ops: ops})
+	if err != nil {
+		return err
+	}
+
+	timeout := raftApplyTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		if d := time.Until(deadline); d <= 0 {
+			return context.DeadlineExceeded
+		} else if d < timeout {
+			timeout = d
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	f := r.raft.Apply(data, timeout)
+	if err := f.Error(); err != nil {
+		return status.Errorf(codes.Unavailable, "error replicating write: %s", err)
+	}
+	if err, ok := f.Response().(error); ok {
+		return err
+	}
+
+	return nil
+}
+
+// raftRecord records a put or delete in the bucket if the state is
+// replicated.
+func raftRecord(b *bolt.Bucket, key, value []byte, delete bool) {
+	raw, ok := raftRecorders.Load(b.Tx().DB())
+	if !ok {
+		return
+	}
+	r := raw.(*raftRecorder)
+
+	// Buckets don't know their name but a transaction returns the same
+	// bucket every time it is opened.
+	var name []byte
+	for _, n := range dbBuckets {
+		if b.Tx().Bucket(n) == b {
+			name = n
+			break
+		}
+	}
+	if name == nil {
+		panic("write to a bucket that isn't in dbBuckets")
+	}
+
+	// The key and value are only valid for the transaction.
+	r.ops = append(r.ops, &pb.RaftEntry_Op{
+		Bucket: string(name),
+		Key:    append([]byte(nil), key...),
+		Value:  append([]byte(nil), value...),
+		Delete: delete,
+	})
+}
+
+// raftFSM applies the replicated writes to the database.
+type raftFSM State
+
+// Apply implements raft.FSM
+func (f *raftFSM) Apply(l *raft.Log) interface{} {
+	var entry pb.RaftEntry
+	if err := proto.Unmarshal(l.Data, &entry); err != nil {
+		return err
+	}
+
+	f.dbLock.RLock()
+	defer f.dbLock.RUnlock()
+	return f.db.Update(func(dbTxn *bolt.Tx) error {
+		for _, op := range entry.Ops {
+			b, err := dbTxn.CreateBucketIfNotExists([]byte(op.Bucket))
+			if err != nil {
+				return err
+			}
+
+			if op.Delete {
+				err = b.Delete(op.Key)
+			} else {
+				err = b.Put(op.Key, op.Value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Snapshot implements raft.FSM
+func (f *raftFSM) Snapshot() (raft.FSMSnapshot, error) {
+	return &raftSnapshot{s: (*State)(f)}, nil
+}
+
+// Restore implements raft.FSM
+func (f *raftFSM) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+
+	checksum := sha256.New()
+	sr, closer, err := snapshotStreamReader(rc, checksum)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	var header pb.Snapshot_Header
+	if err := sr.ReadMsg(&header); err != nil {
+		return fmt.Errorf("error reading snapshot header: %s", err)
+	}
+	if header.Format != pb.Snapshot_Header_BOLT {
+		return fmt.Errorf("invalid snapshot format (got code: %d)", header.Format)
+	}
+
+	// Replace all the data with the data of the snapshot.
+	f.dbLock.RLock()
+	defer f.dbLock.RUnlock()
+	return f.db.Update(func(dbTxn *bolt.Tx) error {
+		for _, name := range dbBuckets {
+			if err := dbTxn.DeleteBucket(name); err != nil && err != bolt.ErrBucketNotFound {
+				return err
+			}
+			if _, err := dbTxn.CreateBucket(name); err != nil {
+				return err
+			}
+		}
+
+		return snapshotRestoreChunks(f.log, sr, checksum, snapshotPutFunc(dbTxn))
+	})
+}
+
+// raftSnapshot writes a snapshot of the database for raft. The snapshot
+// may include writes applied after it was requested but writes are
+// absolute puts and deletes so applying them again is safe.
+type raftSnapshot struct {
+	s *State
+}
+
+// Persist implements raft.FSMSnapshot
+func (r *raftSnapshot) Persist(sink raft.SnapshotSink) error {
+	if err := r.s.CreateSnapshot(sink); err != nil {
+		sink.Cancel()
+		return err
+	}
+
+	return sink.Close()
+}
+
+// Release implements raft.FSMSnapshot
+func (r *raftSnapshot) Release() {}
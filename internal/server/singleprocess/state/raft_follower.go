@@ -0,0 +1,178 @@
+package state
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hashicorp/raft"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the reads that raft followers serve. Followers don't build
+// the in-memory indexes, so these read the replicated database directly.
+// The database of a follower is behind the leader by the writes it hasn't
+// applied yet, so callers bound that with RaftFollowerCheck first.
+
+// RaftFollowerCheck returns an error if the reads of this server may be
+// more than maxStale behind the leader. Followers are only as current as
+// the last time they heard from the leader, so a follower that hasn't
+// heard from the leader within maxStale returns Unavailable. The leader is
+// always current.
+func (s *State) RaftFollowerCheck(maxStale time.Duration) error {
+	if s.raft == nil {
+		return status.Errorf(codes.FailedPrecondition,
+			"the state is not replicated")
+	}
+
+	r := s.raft.raft
+	if r.State() == raft.Leader {
+		return nil
+	}
+	if maxStale <= 0 {
+		return status.Errorf(codes.FailedPrecondition,
+			"this server is not the leader, the leader is %q. Set a maximum "+
+				"staleness to read from this server.", r.Leader())
+	}
+
+	// LastContact is the wall clock time, not the time of the state clock.
+	last := r.LastContact()
+	if last.IsZero() || time.Since(last) > maxStale {
+		return status.Errorf(codes.Unavailable,
+			"this server hasn't heard from the leader within %s", maxStale)
+	}
+
+	return nil
+}
+
+// FollowerJobById returns the job with the given ID. The fields that the
+// leader computes from the in-memory indexes, such as Blocked, aren't set.
+func (s *State) FollowerJobById(id string) (*pb.Job, error) {
+	if err := s.redactRefresh(); err != nil {
+		return nil, err
+	}
+
+	var result *pb.Job
+	err := s.dbView(func(dbTxn *bolt.Tx) error {
+		var err error
+		result, err = s.jobById(dbTxn, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FollowerJobListIter calls fn with the jobs in the order of their IDs, in
+// batches of at most jobListBatchSize, like JobListIter. Each batch is read
+// in its own transaction so the database isn't held open while fn runs.
+func (s *State) FollowerJobListIter(fn func([]*pb.Job) error) error {
+	if err := s.redactRefresh(); err != nil {
+		return err
+	}
+
+	var last []byte
+	for {
+		var jobs []*pb.Job
+		err := s.dbView(func(dbTxn *bolt.Tx) error {
+			c := dbTxn.Bucket(jobBucket).Cursor()
+			k, v := c.First()
+			if last != nil {
+				// Resume after the last job of the previous batch.
+				k, v = c.Seek(last)
+				if k != nil && bytes.Equal(k, last) {
+					k, v = c.Next()
+				}
+			}
+
+			for ; k != nil && len(jobs) < jobListBatchSize; k, v = c.Next() {
+				last = append(last[:0], k...)
+
+				var job pb.Job
+				if err := proto.Unmarshal(v, &job); err != nil {
+					return err
+				}
+
+				// Deleted jobs are kept until they're purged. See job_delete.go.
+				if job.DeleteTime == nil {
+					jobs = append(jobs, &job)
+				}
+			}
+			if k == nil {
+				last = nil
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(jobs) > 0 {
+			if err := fn(jobs); err != nil {
+				return err
+			}
+		}
+		if last == nil {
+			return nil
+		}
+	}
+}
+
+// FollowerHMACKeyGet returns the HMAC key with the given ID. This returns
+// nil if the key doesn't exist, like HMACKeyGet.
+func (s *State) FollowerHMACKeyGet(id string) (*pb.HMACKey, error) {
+	var result pb.HMACKey
+	err := s.followerGet(hmacKeyBucket, id, &result)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// FollowerAPIKeyGet returns the API key with the given ID. This doesn't
+// include usage that the leader hasn't persisted.
+func (s *State) FollowerAPIKeyGet(id string) (*pb.APIKey, error) {
+	var result pb.APIKey
+	if err := s.followerGet(apiKeyBucket, id, &result); err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, status.Errorf(codes.NotFound, "API key not found: %s", id)
+		}
+
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// FollowerRunnerTokenGet returns the token of the runner with the given ID.
+func (s *State) FollowerRunnerTokenGet(runnerId string) (*pb.RunnerToken, error) {
+	var result pb.RunnerToken
+	if err := s.followerGet(runnerTokenBucket, runnerId, &result); err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, status.Errorf(codes.NotFound,
+				"runner token not found: %s", runnerId)
+		}
+
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// followerGet reads the record with the given ID from the bucket.
+func (s *State) followerGet(bucket []byte, id string, msg proto.Message) error {
+	return s.dbView(func(dbTxn *bolt.Tx) error {
+		return dbGet(dbTxn.Bucket(bucket), []byte(id), msg)
+	})
+}
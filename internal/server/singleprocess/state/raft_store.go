@@ -0,0 +1,195 @@
+package state
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/boltdb/bolt"
+	"github.com/hashicorp/go-msgpack/codec"
+	"github.com/hashicorp/raft"
+)
+
+// This file has the raft log and stable stores. These are stored in a
+// separate bolt database from the state since the state database is
+// replaced when a snapshot is restored.
+
+var (
+	raftLogBucket    = []byte("logs")
+	raftStableBucket = []byte("conf")
+
+	// errRaftKeyNotFound is the error for stable store keys that aren't
+	// set. raft expects the message to be exactly "not found".
+	errRaftKeyNotFound = errors.New("not found")
+)
+
+// raftStore implements raft.LogStore and raft.StableStore with bolt.
+type raftStore struct {
+	db *bolt.DB
+}
+
+func newRaftStore(path string) (*raftStore, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{raftLogBucket, raftStableBucket} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &raftStore{db: db}, nil
+}
+
+func (s *raftStore) Close() error {
+	return s.db.Close()
+}
+
+// FirstIndex implements raft.LogStore
+func (s *raftStore) FirstIndex() (uint64, error) {
+	var result uint64
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if k, _ := tx.Bucket(raftLogBucket).Cursor().First(); k != nil {
+			result = binary.BigEndian.Uint64(k)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+// LastIndex implements raft.LogStore
+func (s *raftStore) LastIndex() (uint64, error) {
+	var result uint64
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if k, _ := tx.Bucket(raftLogBucket).Cursor().Last(); k != nil {
+			result = binary.BigEndian.Uint64(k)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+// GetLog implements raft.LogStore
+func (s *raftStore) GetLog(index uint64, log *raft.Log) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(raftLogBucket).Get(raftUint64Key(index))
+		if v == nil {
+			return raft.ErrLogNotFound
+		}
+
+		return codec.NewDecoderBytes(v, &codec.MsgpackHandle{}).Decode(log)
+	})
+}
+
+// StoreLog implements raft.LogStore
+func (s *raftStore) StoreLog(log *raft.Log) error {
+	return s.StoreLogs([]*raft.Log{log})
+}
+
+// StoreLogs implements raft.LogStore
+func (s *raftStore) StoreLogs(logs []*raft.Log) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(raftLogBucket)
+		for _, log := range logs {
+			var v []byte
+			if err := codec.NewEncoderBytes(&v, &codec.MsgpackHandle{}).Encode(log); err != nil {
+				return err
+			}
+
+			if err := b.Put(raftUint64Key(log.Index), v); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// DeleteRange implements raft.LogStore
+func (s *raftStore) DeleteRange(min, max uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		// Deleting with the cursor while iterating can skip keys so we
+		// collect the keys first.
+		b := tx.Bucket(raftLogBucket)
+		var keys [][]byte
+		c := b.Cursor()
+		for k, _ := c.Seek(raftUint64Key(min)); k != nil; k, _ = c.Next() {
+			if binary.BigEndian.Uint64(k) > max {
+				break
+			}
+
+			keys = append(keys, append([]byte(nil), k...))
+		}
+
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Set implements raft.StableStore
+func (s *raftStore) Set(k, v []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(raftStableBucket).Put(k, v)
+	})
+}
+
+// Get implements raft.StableStore
+func (s *raftStore) Get(k []byte) ([]byte, error) {
+	var result []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(raftStableBucket).Get(k)
+		if v == nil {
+			return errRaftKeyNotFound
+		}
+
+		// The value is only valid for the transaction so we copy it.
+		result = append([]byte(nil), v...)
+		return nil
+	})
+
+	return result, err
+}
+
+// SetUint64 implements raft.StableStore
+func (s *raftStore) SetUint64(k []byte, v uint64) error {
+	return s.Set(k, raftUint64Key(v))
+}
+
+// GetUint64 implements raft.StableStore
+func (s *raftStore) GetUint64(k []byte) (uint64, error) {
+	v, err := s.Get(k)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint64(v), nil
+}
+
+func raftUint64Key(v uint64) []byte {
+	result := make([]byte, 8)
+	binary.BigEndian.PutUint64(result, v)
+	return result
+}
+
+var (
+	_ raft.LogStore    = (*raftStore)(nil)
+	_ raft.StableStore = (*raftStore)(nil)
+)
@@ -0,0 +1,228 @@
+package state
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestRaft(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Pick free addresses for three servers
+	addrs := map[string]string{}
+	for _, id := range []string{"a", "b", "c"} {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(err)
+		addrs[id] = ln.Addr().String()
+		ln.Close()
+	}
+
+	// Start the servers. NewRaft returns once a server is the leader.
+	type result struct {
+		id string
+		s  *State
+	}
+	leaderCh := make(chan result, len(addrs))
+	for id := range addrs {
+		td, err := ioutil.TempDir("", "test")
+		require.NoError(err)
+		defer os.RemoveAll(td)
+
+		peers := map[string]string{}
+		for peer, addr := range addrs {
+			if peer != id {
+				peers[peer] = addr
+			}
+		}
+
+		cfg := &RaftConfig{
+			ID:    id,
+			Addr:  addrs[id],
+			Dir:   td,
+			Peers: peers,
+		}
+
+		db := testDB(t)
+		go func(id string) {
+			s, err := NewRaft(ctx, hclog.L().Named(id), db, cfg)
+			if err != nil {
+				return
+			}
+
+			leaderCh <- result{id: id, s: s}
+		}(id)
+	}
+
+	var leader result
+	select {
+	case leader = <-leaderCh:
+	case <-time.After(10 * time.Second):
+		t.Fatal("no server became the leader")
+	}
+	require.Equal(addrs[leader.id], leader.s.RaftLeader())
+
+	// Write on the leader
+	require.NoError(leader.s.ProjectPut(serverptypes.TestProject(t, &pb.Project{
+		Name: "A",
+	})))
+	require.NoError(leader.s.Close())
+
+	// Another server takes over with the data written by the old leader
+	var next result
+	select {
+	case next = <-leaderCh:
+	case <-time.After(10 * time.Second):
+		t.Fatal("no server took over from the leader")
+	}
+	defer next.s.Close()
+	require.NotEqual(leader.id, next.id)
+
+	project, err := next.s.ProjectGet(&pb.Ref_Project{Project: "A"})
+	require.NoError(err)
+	require.Equal("A", project.Name)
+
+	// Writes on the new leader still work with one server down
+	require.NoError(next.s.ProjectPut(serverptypes.TestProject(t, &pb.Project{
+		Name: "B",
+	})))
+	_, err = next.s.ProjectGet(&pb.Ref_Project{Project: "B"})
+	require.NoError(err)
+}
+
+func TestRaft_notLeader(t *testing.T) {
+	require := require.New(t)
+
+	td, err := ioutil.TempDir("", "test")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	// A single server becomes the leader by itself
+	s, err := NewRaft(context.Background(), hclog.L(), testDB(t), &RaftConfig{
+		ID:   "a",
+		Addr: addr,
+		Dir:  td,
+	})
+	require.NoError(err)
+	defer s.Close()
+
+	require.NoError(s.ProjectPut(serverptypes.TestProject(t, &pb.Project{Name: "A"})))
+
+	// Writes fail once it isn't the leader
+	require.NoError(s.raft.raft.Shutdown().Error())
+	err = s.ProjectPut(serverptypes.TestProject(t, &pb.Project{Name: "B"}))
+	require.Error(err)
+	require.Equal(codes.Unavailable, status.Code(err))
+}
+
+func TestRaft_follower(t *testing.T) {
+	leader, follower := TestRaftFollower(t)
+
+	t.Run("reads replicated jobs", func(t *testing.T) {
+		require := require.New(t)
+
+		require.NoError(leader.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id: "A",
+		})))
+
+		require.Eventually(func() bool {
+			job, err := follower.FollowerJobById("A")
+			return err == nil && job.Id == "A"
+		}, 5*time.Second, 10*time.Millisecond)
+
+		_, err := follower.FollowerJobById("B")
+		require.Error(err)
+		require.Equal(codes.NotFound, status.Code(err))
+	})
+
+	t.Run("lists jobs in batches without deleted jobs", func(t *testing.T) {
+		require := require.New(t)
+
+		defer func(v int) { jobListBatchSize = v }(jobListBatchSize)
+		jobListBatchSize = 2
+
+		for _, id := range []string{"B", "C", "D", "E"} {
+			require.NoError(leader.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+				Id: id,
+			})))
+		}
+		require.NoError(leader.JobCancel("C", false, 0))
+		require.NoError(leader.JobDelete("C"))
+
+		expected := [][]string{{"A", "B"}, {"D", "E"}}
+		require.Eventually(func() bool {
+			var batches [][]string
+			err := follower.FollowerJobListIter(func(jobs []*pb.Job) error {
+				var ids []string
+				for _, job := range jobs {
+					ids = append(ids, job.Id)
+				}
+
+				batches = append(batches, ids)
+				return nil
+			})
+			return err == nil && reflect.DeepEqual(batches, expected)
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("reads keys and tokens", func(t *testing.T) {
+		require := require.New(t)
+
+		_, err := leader.HMACKeyCreateIfNotExist("k1", 32)
+		require.NoError(err)
+		require.Eventually(func() bool {
+			key, err := follower.FollowerHMACKeyGet("k1")
+			return err == nil && key != nil
+		}, 5*time.Second, 10*time.Millisecond)
+
+		key, err := follower.FollowerHMACKeyGet("k2")
+		require.NoError(err)
+		require.Nil(key)
+
+		_, err = follower.FollowerRunnerTokenGet("R")
+		require.Equal(codes.NotFound, status.Code(err))
+		_, err = follower.FollowerAPIKeyGet("K")
+		require.Equal(codes.NotFound, status.Code(err))
+	})
+
+	t.Run("checks the staleness", func(t *testing.T) {
+		require := require.New(t)
+
+		require.NoError(leader.RaftFollowerCheck(0))
+
+		err := follower.RaftFollowerCheck(0)
+		require.Error(err)
+		require.Equal(codes.FailedPrecondition, status.Code(err))
+
+		require.Eventually(func() bool {
+			return follower.RaftFollowerCheck(time.Second) == nil
+		}, 5*time.Second, 10*time.Millisecond)
+
+		// Once the leader is gone the follower is too stale
+		require.NoError(leader.raft.raft.Shutdown().Error())
+		require.Eventually(func() bool {
+			err := follower.RaftFollowerCheck(100 * time.Millisecond)
+			return status.Code(err) == codes.Unavailable
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
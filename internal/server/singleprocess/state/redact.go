@@ -0,0 +1,56 @@
+package state
+
+import (
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+
+	"github.com/hashicorp/waypoint/internal/pkg/redact"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// Redactor returns the redactor that masks the values of sensitive config
+// variables. This is kept up to date as config variables are set. Sensitive
+// values can only be registered once config encryption is set, since they
+// are stored encrypted.
+func (s *State) Redactor() *redact.Redactor {
+	return &s.redactor
+}
+
+// redactRefresh registers the values of all sensitive config variables
+// with the redactor. Variables that can't be decrypted are skipped.
+func (s *State) redactRefresh() error {
+	if _, err := s.configAEAD(); err != nil {
+		// Without encryption there can't be any sensitive values.
+		s.redactor.SetValues(nil)
+		return nil
+	}
+
+	var values []string
+	err := s.dbView(func(dbTxn *bolt.Tx) error {
+		return dbTxn.Bucket(configBucket).ForEach(func(k, v []byte) error {
+			var cv pb.ConfigVar
+			if err := proto.Unmarshal(v, &cv); err != nil {
+				return err
+			}
+			if !cv.Sensitive || len(cv.EncryptedValue) == 0 {
+				return nil
+			}
+
+			value, err := s.configDecrypt(cv.EncryptedValue)
+			if err != nil {
+				s.log.Warn("error decrypting sensitive config value for redaction",
+					"name", cv.Name, "err", err)
+				return nil
+			}
+
+			values = append(values, value)
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	s.redactor.SetValues(values)
+	return nil
+}
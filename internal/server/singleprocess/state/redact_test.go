@@ -0,0 +1,63 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/keywrap"
+)
+
+func TestRedactor(t *testing.T) {
+	require := require.New(t)
+
+	s := TestState(t)
+	defer s.Close()
+
+	// Without encryption nothing is redacted
+	require.True(s.Redactor().Empty())
+
+	w := keywrap.TestWrapper(t)
+	require.NoError(s.ConfigEncryptionSet(context.Background(), w))
+	require.True(s.Redactor().Empty())
+
+	// Setting a sensitive variable registers it
+	require.NoError(s.ConfigSet(&pb.ConfigVar{
+		Scope: &pb.ConfigVar_Project{
+			Project: &pb.Ref_Project{Project: "foo"},
+		},
+		Name:      "password",
+		Value:     "hunter22",
+		Sensitive: true,
+	}))
+	require.Equal("pw=<sensitive>", s.Redactor().String("pw=hunter22"))
+
+	// Non-sensitive variables are not redacted
+	require.NoError(s.ConfigSet(&pb.ConfigVar{
+		Scope: &pb.ConfigVar_Project{
+			Project: &pb.Ref_Project{Project: "foo"},
+		},
+		Name:  "region",
+		Value: "us-east-1",
+	}))
+	require.Equal("us-east-1", s.Redactor().String("us-east-1"))
+
+	// Sensitive values are registered again after a restart once
+	// encryption is set.
+	s = TestStateReinit(t, s)
+	defer s.Close()
+	require.True(s.Redactor().Empty())
+	require.NoError(s.ConfigEncryptionSet(context.Background(), w))
+	require.Equal("pw=<sensitive>", s.Redactor().String("pw=hunter22"))
+
+	// Unsetting the variable unregisters it
+	require.NoError(s.ConfigSet(&pb.ConfigVar{
+		Scope: &pb.ConfigVar_Project{
+			Project: &pb.Ref_Project{Project: "foo"},
+		},
+		Name: "password",
+	}))
+	require.True(s.Redactor().Empty())
+}
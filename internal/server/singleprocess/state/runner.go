@@ -1,6 +1,11 @@
 package state
 
 import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
 	"github.com/hashicorp/go-memdb"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -9,12 +14,21 @@ import (
 )
 
 const (
-	runnerTableName   = "runners"
-	runnerIdIndexName = "id"
+	runnerTableName         = "runners"
+	runnerIdIndexName       = "id"
+	runnerLabelsIndexName   = "labels"
+	runnerPlatformIndexName = "platform"
+	runnerPluginIndexName   = "plugin"
+	runnerPoolIndexName     = "pool"
+	runnerTagsIndexName     = "tags"
 )
 
 func init() {
 	schemas = append(schemas, runnerSchema)
+	inmemOnlyTables[runnerTableName] = struct{}{}
+	eventTables[runnerTableName] = func(obj interface{}) (EventTopic, string, string) {
+		return EventTopicRunner, obj.(*runnerRecord).Id, ""
+	}
 }
 
 func runnerSchema() *memdb.TableSchema {
@@ -30,6 +44,62 @@ func runnerSchema() *memdb.TableSchema {
 					Lowercase: true,
 				},
 			},
+
+			runnerLabelsIndexName: {
+				Name:         runnerLabelsIndexName,
+				AllowMissing: true,
+				Unique:       false,
+				Indexer: &memdb.StringMapFieldIndex{
+					Field: "Labels",
+				},
+			},
+
+			runnerPlatformIndexName: {
+				Name:         runnerPlatformIndexName,
+				AllowMissing: true,
+				Unique:       false,
+				Indexer: &memdb.CompoundIndex{
+					Indexes: []memdb.Indexer{
+						&memdb.StringFieldIndex{
+							Field:     "OS",
+							Lowercase: true,
+						},
+						&memdb.StringFieldIndex{
+							Field:     "Arch",
+							Lowercase: true,
+						},
+					},
+					AllowMissing: true,
+				},
+			},
+
+			runnerPluginIndexName: {
+				Name:         runnerPluginIndexName,
+				AllowMissing: true,
+				Unique:       false,
+				Indexer: &memdb.StringSliceFieldIndex{
+					Field: "Plugins",
+				},
+			},
+
+			runnerPoolIndexName: {
+				Name:         runnerPoolIndexName,
+				AllowMissing: true,
+				Unique:       false,
+				Indexer: &memdb.StringFieldIndex{
+					Field: "Pool",
+				},
+			},
+
+			runnerTagsIndexName: {
+				Name:         runnerTagsIndexName,
+				AllowMissing: true,
+				Unique:       false,
+				Indexer: &memdb.StringSliceFieldIndex{
+					Field:     "Tags",
+					Lowercase: true,
+				},
+			},
 		},
 	}
 }
@@ -40,33 +110,106 @@ type runnerRecord struct {
 
 	// Id of the runner
 	Id string
+
+	// Labels, OS, and Arch describe the runner and Plugins are the names
+	// of the plugins it has. These are indexed so runners can be found by
+	// them with RunnerFind.
+	Labels  map[string]string
+	OS      string
+	Arch    string
+	Plugins []string
+
+	// Pool is the name of the runner pool the runner joined, if any. See
+	// job_pool.go.
+	Pool string
+
+	// Tags are the resource tags of the runner, lowercased and sorted.
+	// See job_tags.go.
+	Tags []string
+
+	// LastSeen is the time of the last heartbeat from the runner and
+	// LivenessTimer deregisters the runner if it doesn't send another in
+	// time. These are unset until the runner's first heartbeat. See
+	// runner_liveness.go.
+	LastSeen      time.Time
+	LivenessTimer *wheelTimer
 }
 
 func (s *State) RunnerCreate(r *pb.Runner) error {
-	txn := s.inmem.Txn(true)
+	txn := s.inmemWriteTxn()
 	defer txn.Abort()
 
+	// If the runner is registering again, its old record no longer
+	// needs its liveness timer.
+	if raw, err := txn.First(runnerTableName, runnerIdIndexName, r.Id); err != nil {
+		return err
+	} else if raw != nil {
+		if t := raw.(*runnerRecord).LivenessTimer; t != nil {
+			t.Stop()
+		}
+	}
+
+	// Runners that must be approved are pending until they are.
+	pending, err := s.runnerPending(txn, r)
+	if err != nil {
+		return err
+	}
+	if r.Pending != pending {
+		r = proto.Clone(r).(*pb.Runner)
+		r.Pending = pending
+	}
+
 	// Create our runner
 	if err := txn.Insert(runnerTableName, newRunnerRecord(r)); err != nil {
 		return status.Errorf(codes.Aborted, err.Error())
 	}
 
+	// If the runner was launched on demand, it is now registered.
+	// See runner_launch.go.
+	if err := s.runnerLaunchStatusSet(txn, r.Id, RunnerLaunchRegistered); err != nil {
+		return status.Errorf(codes.Aborted, err.Error())
+	}
+
 	txn.Commit()
 
 	return nil
 }
 
 func (s *State) RunnerDelete(id string) error {
-	txn := s.inmem.Txn(true)
+	txn := s.inmemWriteTxn()
 	defer txn.Abort()
-	if _, err := txn.DeleteAll(runnerTableName, runnerIdIndexName, id); err != nil {
-		return status.Errorf(codes.Aborted, err.Error())
+	if err := s.runnerDelete(txn, id); err != nil {
+		return err
 	}
 	txn.Commit()
 
 	return nil
 }
 
+func (s *State) runnerDelete(memTxn *memdb.Txn, id string) error {
+	raw, err := memTxn.First(runnerTableName, runnerIdIndexName, id)
+	if err != nil {
+		return err
+	}
+	if raw != nil {
+		if t := raw.(*runnerRecord).LivenessTimer; t != nil {
+			t.Stop()
+		}
+	}
+
+	if _, err := memTxn.DeleteAll(runnerTableName, runnerIdIndexName, id); err != nil {
+		return status.Errorf(codes.Aborted, err.Error())
+	}
+	if err := s.runnerLaunchStatusSet(memTxn, id, RunnerLaunchExited); err != nil {
+		return status.Errorf(codes.Aborted, err.Error())
+	}
+	if err := s.runnerAssignDelete(memTxn, id); err != nil {
+		return status.Errorf(codes.Aborted, err.Error())
+	}
+
+	return nil
+}
+
 func (s *State) RunnerById(id string) (*pb.Runner, error) {
 	txn := s.inmem.Txn(false)
 	raw, err := txn.First(runnerTableName, runnerIdIndexName, id)
@@ -81,6 +224,202 @@ func (s *State) RunnerById(id string) (*pb.Runner, error) {
 	return raw.(*runnerRecord).Runner, nil
 }
 
+// RunnerByIdWatch returns the runner with the given ID and adds a watch
+// to ws that is notified when the runner changes or is deregistered.
+func (s *State) RunnerByIdWatch(id string, ws memdb.WatchSet) (*pb.Runner, error) {
+	txn := s.inmem.Txn(false)
+	defer txn.Abort()
+
+	ch, raw, err := txn.FirstWatch(runnerTableName, runnerIdIndexName, id)
+	if err != nil {
+		return nil, err
+	}
+	ws.Add(ch)
+	if raw == nil {
+		return nil, status.Errorf(codes.NotFound, "runner ID not found")
+	}
+
+	return raw.(*runnerRecord).Runner, nil
+}
+
+// RunnerDrain stops assigning new jobs to the runner with the given ID.
+// Jobs that are already assigned to the runner aren't affected, so the
+// runner finishes the jobs it's running. The runner is drained until
+// RunnerUndrain is called or the runner registers again.
+func (s *State) RunnerDrain(id string) (*pb.Runner, error) {
+	return s.runnerDrainSet(id, true)
+}
+
+// RunnerUndrain resumes assigning jobs to a runner drained with RunnerDrain.
+func (s *State) RunnerUndrain(id string) (*pb.Runner, error) {
+	return s.runnerDrainSet(id, false)
+}
+
+func (s *State) runnerDrainSet(id string, draining bool) (*pb.Runner, error) {
+	txn := s.inmemWriteTxn()
+	defer txn.Abort()
+
+	r, err := s.runnerDrainSetTxn(txn, id, draining)
+	if err != nil {
+		return nil, err
+	}
+
+	txn.Commit()
+	return r, nil
+}
+
+func (s *State) runnerDrainSetTxn(txn *memdb.Txn, id string, draining bool) (*pb.Runner, error) {
+	raw, err := txn.First(runnerTableName, runnerIdIndexName, id)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, status.Errorf(codes.NotFound, "runner ID not found")
+	}
+
+	// Runners are shared with callers so we never modify them.
+	old := raw.(*runnerRecord)
+	r := proto.Clone(old.Runner).(*pb.Runner)
+	r.Draining = draining
+	rec := newRunnerRecord(r)
+	rec.LastSeen = old.LastSeen
+	rec.LivenessTimer = old.LivenessTimer
+	if err := txn.Insert(runnerTableName, rec); err != nil {
+		return nil, status.Errorf(codes.Aborted, err.Error())
+	}
+
+	return r, nil
+}
+
+// runnerDraining returns true if the runner with the given ID is drained
+// or is pending approval. Neither are assigned new jobs. The returned
+// channel is closed when the runner changes. Runners that aren't
+// registered aren't drained.
+func (s *State) runnerDraining(memTxn *memdb.Txn, id string) (<-chan struct{}, bool, error) {
+	ch, raw, err := memTxn.FirstWatch(runnerTableName, runnerIdIndexName, id)
+	if err != nil || raw == nil {
+		return ch, false, err
+	}
+
+	r := raw.(*runnerRecord).Runner
+	return ch, r.Draining || r.Pending, nil
+}
+
+// RunnerQuery selects registered runners by what they advertise. Fields
+// that aren't set match any runner.
+type RunnerQuery struct {
+	// Labels are labels the runner must have with the same values.
+	Labels map[string]string
+
+	// OS and Arch are the operating system and architecture of the runner.
+	// Arch is only used if OS is set.
+	OS   string
+	Arch string
+
+	// Plugin is the name of a plugin the runner must have.
+	Plugin string
+
+	// Pool is the name of the runner pool the runner must be in.
+	Pool string
+
+	// Tags are resource tags the runner must have.
+	Tags []string
+}
+
+// RunnerFind returns the registered runners that match the query, sorted
+// by ID.
+func (s *State) RunnerFind(q *RunnerQuery) ([]*pb.Runner, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	recs, err := s.runnerFind(memTxn, q)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*pb.Runner, len(recs))
+	for i, rec := range recs {
+		result[i] = rec.Runner
+	}
+
+	return result, nil
+}
+
+// runnerFind returns the records of the runners that match the query. The
+// most selective index for the query is used and the rest of the query is
+// checked against each runner.
+func (s *State) runnerFind(memTxn *memdb.Txn, q *RunnerQuery) ([]*runnerRecord, error) {
+	var iter memdb.ResultIterator
+	var err error
+	switch {
+	case q.Pool != "":
+		iter, err = memTxn.Get(runnerTableName, runnerPoolIndexName, q.Pool)
+
+	case q.OS != "" && q.Arch != "":
+		iter, err = memTxn.Get(runnerTableName, runnerPlatformIndexName, q.OS, q.Arch)
+
+	case q.OS != "":
+		iter, err = memTxn.Get(runnerTableName, runnerPlatformIndexName+"_prefix", q.OS)
+
+	case q.Plugin != "":
+		iter, err = memTxn.Get(runnerTableName, runnerPluginIndexName, q.Plugin)
+
+	case len(resourceTags(q.Tags)) > 0:
+		// Any tag will do since the runner must have all of them.
+		iter, err = memTxn.Get(runnerTableName, runnerTagsIndexName, resourceTags(q.Tags)[0])
+
+	case len(q.Labels) > 0:
+		// Any label will do since the runner must have all of them.
+		for k, v := range q.Labels {
+			iter, err = memTxn.Get(runnerTableName, runnerLabelsIndexName, k, v)
+			break
+		}
+
+	default:
+		iter, err = memTxn.Get(runnerTableName, runnerIdIndexName+"_prefix", "")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*runnerRecord
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		rec := raw.(*runnerRecord)
+		if rec.matches(q) {
+			result = append(result, rec)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Id < result[j].Id
+	})
+
+	return result, nil
+}
+
+// matches returns true if the runner matches the query.
+func (rec *runnerRecord) matches(q *RunnerQuery) bool {
+	if q.Pool != "" && rec.Pool != q.Pool {
+		return false
+	}
+	if q.OS != "" && !strings.EqualFold(rec.OS, q.OS) {
+		return false
+	}
+	if q.OS != "" && q.Arch != "" && !strings.EqualFold(rec.Arch, q.Arch) {
+		return false
+	}
+	if q.Plugin != "" {
+		if _, ok := rec.Runner.PluginVersions[q.Plugin]; !ok {
+			return false
+		}
+	}
+	if !jobTagsMatch(&jobIndex{RequiredTags: resourceTags(q.Tags)}, rec.Runner) {
+		return false
+	}
+
+	return runnerLabelsMatch(rec.Runner, q.Labels)
+}
+
 // runnerEmpty returns true if there are no runners registered.
 func (s *State) runnerEmpty(memTxn *memdb.Txn) (bool, error) {
 	iter, err := memTxn.LowerBound(runnerTableName, runnerIdIndexName, "")
@@ -96,7 +435,16 @@ func newRunnerRecord(r *pb.Runner) *runnerRecord {
 	rec := &runnerRecord{
 		Runner: r,
 		Id:     r.Id,
+		Labels: r.Labels,
+		OS:     r.Os,
+		Arch:   r.Arch,
+		Pool:   r.Pool,
+		Tags:   resourceTags(r.ResourceTags),
+	}
+	for name := range r.PluginVersions {
+		rec.Plugins = append(rec.Plugins, name)
 	}
+	sort.Strings(rec.Plugins)
 
 	return rec
 }
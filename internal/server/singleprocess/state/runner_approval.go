@@ -0,0 +1,146 @@
+package state
+
+import (
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hashicorp/go-memdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to approving runners. If approval is
+// required, runners that register are pending and aren't assigned jobs
+// until they're approved. Approvals are persisted by runner ID so that a
+// runner that registers again, or a runner that was approved before it
+// registered, isn't pending. Runners that only run jobs that target them
+// by ID, such as the runner of a local operation, are never pending.
+
+var runnerApprovalBucket = []byte("runner_approvals")
+
+func init() {
+	dbBuckets = append(dbBuckets, runnerApprovalBucket)
+	dbIndexers = append(dbIndexers, (*State).runnerApprovalIndexInit)
+	schemas = append(schemas, runnerApprovalIndexSchema)
+}
+
+// RunnerApprovalSet sets whether runners must be approved before they are
+// assigned jobs. This only affects runners that register after it's set.
+func (s *State) RunnerApprovalSet(required bool) {
+	s.runnerApproval = required
+}
+
+// RunnerApprove approves the runner with the given ID. If the runner is
+// registered and pending, it can be assigned jobs immediately.
+func (s *State) RunnerApprove(id string) error {
+	if id == "" {
+		return status.Errorf(codes.InvalidArgument, "runner ID is required")
+	}
+
+	now, err := ptypes.TimestampProto(s.clock.Now())
+	if err != nil {
+		return err
+	}
+	approval := &pb.RunnerApproval{RunnerId: id, ApprovedAt: now}
+
+	memTxn := s.inmemWriteTxn()
+	defer memTxn.Abort()
+
+	err = s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		b := dbTxn.Bucket(runnerApprovalBucket)
+		if err := dbPut(b, []byte(id), approval); err != nil {
+			return err
+		}
+
+		return s.runnerApprovalIndexSet(memTxn, approval)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Runners are shared with callers so we never modify them.
+	raw, err := memTxn.First(runnerTableName, runnerIdIndexName, id)
+	if err != nil {
+		return err
+	}
+	if raw != nil && raw.(*runnerRecord).Runner.Pending {
+		old := raw.(*runnerRecord)
+		r := proto.Clone(old.Runner).(*pb.Runner)
+		r.Pending = false
+		rec := newRunnerRecord(r)
+		rec.LastSeen = old.LastSeen
+		rec.LivenessTimer = old.LivenessTimer
+		if err := memTxn.Insert(runnerTableName, rec); err != nil {
+			return status.Errorf(codes.Aborted, err.Error())
+		}
+	}
+
+	memTxn.Commit()
+	return nil
+}
+
+// runnerPending returns true if the runner must be approved before it is
+// assigned jobs.
+func (s *State) runnerPending(memTxn *memdb.Txn, r *pb.Runner) (bool, error) {
+	if !s.runnerApproval || r.ByIdOnly {
+		return false, nil
+	}
+
+	raw, err := memTxn.First(runnerApprovalIndexTableName, runnerApprovalIndexIdIndexName, r.Id)
+	if err != nil {
+		return false, err
+	}
+
+	return raw == nil, nil
+}
+
+// runnerApprovalIndexSet writes an index record for a runner approval.
+func (s *State) runnerApprovalIndexSet(txn *memdb.Txn, value *pb.RunnerApproval) error {
+	return txn.Insert(runnerApprovalIndexTableName, &runnerApprovalIndexRecord{
+		Id:       value.RunnerId,
+		Approval: value,
+	})
+}
+
+// runnerApprovalIndexInit initializes the runner approval index from
+// persisted data.
+func (s *State) runnerApprovalIndexInit(dbTxn *bolt.Tx, memTxn *memdb.Txn) error {
+	bucket := dbTxn.Bucket(runnerApprovalBucket)
+	return bucket.ForEach(func(k, v []byte) error {
+		var value pb.RunnerApproval
+		if err := proto.Unmarshal(v, &value); err != nil {
+			return err
+		}
+
+		return s.runnerApprovalIndexSet(memTxn, &value)
+	})
+}
+
+func runnerApprovalIndexSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: runnerApprovalIndexTableName,
+		Indexes: map[string]*memdb.IndexSchema{
+			runnerApprovalIndexIdIndexName: {
+				Name:         runnerApprovalIndexIdIndexName,
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "Id",
+					Lowercase: true,
+				},
+			},
+		},
+	}
+}
+
+const (
+	runnerApprovalIndexTableName   = "runner-approval-index"
+	runnerApprovalIndexIdIndexName = "id"
+)
+
+type runnerApprovalIndexRecord struct {
+	Id       string
+	Approval *pb.RunnerApproval
+}
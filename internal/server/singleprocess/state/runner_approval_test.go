@@ -0,0 +1,123 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestRunnerApproval(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("not required", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.RunnerCreate(serverptypes.TestRunner(t, &pb.Runner{Id: "A"})))
+		r, err := s.RunnerById("A")
+		require.NoError(err)
+		require.False(r.Pending)
+	})
+
+	t.Run("pending until approved", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		s.RunnerApprovalSet(true)
+
+		r := serverptypes.TestRunner(t, &pb.Runner{Id: "A"})
+		require.NoError(s.RunnerCreate(r))
+		{
+			r, err := s.RunnerById("A")
+			require.NoError(err)
+			require.True(r.Pending)
+		}
+
+		// Pending runners aren't assigned jobs
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "J"})))
+		resultCh := make(chan *Job, 1)
+		go func() {
+			job, err := s.JobAssignForRunner(ctx, r)
+			if err == nil {
+				resultCh <- job
+			}
+		}()
+
+		select {
+		case <-resultCh:
+			t.Fatal("should not be assigned")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		// Once approved, the runner is assigned the job
+		require.NoError(s.RunnerApprove("A"))
+		select {
+		case job := <-resultCh:
+			require.Equal("J", job.Id)
+		case <-time.After(5 * time.Second):
+			t.Fatal("should be assigned")
+		}
+
+		// The runner stays approved when it registers again
+		require.NoError(s.RunnerDelete("A"))
+		require.NoError(s.RunnerCreate(r))
+		{
+			r, err := s.RunnerById("A")
+			require.NoError(err)
+			require.False(r.Pending)
+		}
+	})
+
+	t.Run("approved before registering", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		s.RunnerApprovalSet(true)
+
+		require.NoError(s.RunnerApprove("A"))
+		require.NoError(s.RunnerCreate(serverptypes.TestRunner(t, &pb.Runner{Id: "A"})))
+		r, err := s.RunnerById("A")
+		require.NoError(err)
+		require.False(r.Pending)
+	})
+
+	t.Run("runners that only run jobs for them aren't pending", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		s.RunnerApprovalSet(true)
+
+		require.NoError(s.RunnerCreate(serverptypes.TestRunner(t, &pb.Runner{
+			Id:       "A",
+			ByIdOnly: true,
+		})))
+		r, err := s.RunnerById("A")
+		require.NoError(err)
+		require.False(r.Pending)
+	})
+
+	t.Run("pending runners are listed as pending", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		s.RunnerApprovalSet(true)
+
+		require.NoError(s.RunnerCreate(serverptypes.TestRunner(t, &pb.Runner{Id: "A"})))
+		result, _, err := s.RunnerList(&RunnerListQuery{
+			States: []pb.RunnerStatus_State{pb.RunnerStatus_PENDING},
+		})
+		require.NoError(err)
+		require.Len(result, 1)
+	})
+}
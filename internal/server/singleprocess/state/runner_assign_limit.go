@@ -0,0 +1,144 @@
+package state
+
+import (
+	"time"
+
+	"github.com/hashicorp/go-memdb"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to limiting the rate that jobs are
+// assigned to each runner. If a limit is set, a runner is assigned at most
+// that many jobs in any interval, so a misbehaving runner that accepts and
+// immediately fails jobs can't fail the entire queue. A runner that reaches
+// the limit isn't assigned more jobs until its oldest assignment in the
+// interval is older than the interval. Runners that only run jobs that
+// target them by ID, such as the runner of a local operation, aren't
+// limited.
+//
+// The recent assignments of each runner are only kept in memory like the
+// runners themselves.
+
+const (
+	runnerAssignTableName   = "runner-assign"
+	runnerAssignIdIndexName = "id"
+)
+
+// runnerAssignIntervalDefault is the interval of the assignment limit if
+// it isn't set.
+const runnerAssignIntervalDefault = time.Minute
+
+func init() {
+	schemas = append(schemas, runnerAssignSchema)
+	inmemOnlyTables[runnerAssignTableName] = struct{}{}
+}
+
+func runnerAssignSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: runnerAssignTableName,
+		Indexes: map[string]*memdb.IndexSchema{
+			runnerAssignIdIndexName: {
+				Name:         runnerAssignIdIndexName,
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "Id",
+					Lowercase: true,
+				},
+			},
+		},
+	}
+}
+
+// runnerAssignRecord is the recent assignments of a runner.
+type runnerAssignRecord struct {
+	// Id is the ID of the runner.
+	Id string
+
+	// Times are the times jobs were assigned to the runner in the last
+	// interval, oldest first.
+	Times []time.Time
+}
+
+// RunnerAssignLimitSet sets the most jobs that are assigned to each runner
+// in the given interval. A zero limit disables the limit, and a zero
+// interval uses the default of one minute. This should be called once
+// before the state is used.
+func (s *State) RunnerAssignLimitSet(limit int, interval time.Duration) {
+	if interval <= 0 {
+		interval = runnerAssignIntervalDefault
+	}
+
+	s.runnerAssignLimit = limit
+	s.runnerAssignInterval = interval
+}
+
+// runnerAssignLimited returns how long until the runner can be assigned
+// another job. This is zero if the runner isn't limited.
+func (s *State) runnerAssignLimited(memTxn *memdb.Txn, r *pb.Runner) (time.Duration, error) {
+	if s.runnerAssignLimit <= 0 || r.ByIdOnly {
+		return 0, nil
+	}
+
+	raw, err := memTxn.First(runnerAssignTableName, runnerAssignIdIndexName, r.Id)
+	if err != nil || raw == nil {
+		return 0, err
+	}
+
+	times := s.runnerAssignRecent(raw.(*runnerAssignRecord).Times)
+	if len(times) < s.runnerAssignLimit {
+		return 0, nil
+	}
+
+	// The runner can be assigned a job once enough of its assignments
+	// are older than the interval.
+	next := times[len(times)-s.runnerAssignLimit].Add(s.runnerAssignInterval)
+	if d := next.Sub(s.clock.Now()); d > 0 {
+		return d, nil
+	}
+
+	return 0, nil
+}
+
+// runnerAssignAdd records that a job was assigned to the runner now.
+func (s *State) runnerAssignAdd(memTxn *memdb.Txn, r *pb.Runner) error {
+	if s.runnerAssignLimit <= 0 || r.ByIdOnly {
+		return nil
+	}
+
+	raw, err := memTxn.First(runnerAssignTableName, runnerAssignIdIndexName, r.Id)
+	if err != nil {
+		return err
+	}
+
+	// Records are shared with readers so we never modify them.
+	var times []time.Time
+	if raw != nil {
+		times = s.runnerAssignRecent(raw.(*runnerAssignRecord).Times)
+	}
+
+	return memTxn.Insert(runnerAssignTableName, &runnerAssignRecord{
+		Id:    r.Id,
+		Times: append(append([]time.Time(nil), times...), s.clock.Now()),
+	})
+}
+
+// runnerAssignDelete deletes the recent assignments of the runner with the
+// given ID. This is called when the runner is deregistered.
+func (s *State) runnerAssignDelete(memTxn *memdb.Txn, id string) error {
+	_, err := memTxn.DeleteAll(runnerAssignTableName, runnerAssignIdIndexName, id)
+	return err
+}
+
+// runnerAssignRecent returns the times that are within the interval.
+func (s *State) runnerAssignRecent(times []time.Time) []time.Time {
+	cutoff := s.clock.Now().Add(-s.runnerAssignInterval)
+	for i, t := range times {
+		if t.After(cutoff) {
+			return times[i:]
+		}
+	}
+
+	return nil
+}
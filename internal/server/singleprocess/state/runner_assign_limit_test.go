@@ -0,0 +1,103 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestRunnerAssignLimit(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("runners are assigned at most the limit in the interval", func(t *testing.T) {
+		require := require.New(t)
+
+		clock := TestClockNew()
+		s := TestState(t, WithClock(clock))
+		defer s.Close()
+		s.RunnerAssignLimitSet(2, time.Minute)
+
+		r := serverptypes.TestRunner(t, &pb.Runner{Id: "R_A"})
+		require.NoError(s.RunnerCreate(r))
+
+		for _, id := range []string{"A", "B", "C"} {
+			require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+				Id:        id,
+				Workspace: &pb.Ref_Workspace{Workspace: id},
+			})))
+		}
+
+		// The runner fails the first two jobs immediately
+		for _, id := range []string{"A", "B"} {
+			job, err := s.JobAssignForRunner(ctx, r)
+			require.NoError(err)
+			require.Equal(id, job.Id)
+			_, err = s.JobAck(ctx, job.Id, true)
+			require.NoError(err)
+			require.NoError(s.JobComplete(ctx, job.Id, nil, context.Canceled))
+		}
+
+		// It isn't assigned another job until the interval passes
+		resultCh := make(chan *Job, 1)
+		go func() {
+			job, err := s.JobAssignForRunner(ctx, r)
+			if err == nil {
+				resultCh <- job
+			}
+		}()
+
+		select {
+		case <-resultCh:
+			t.Fatal("should not be assigned")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		// Other runners are still assigned it
+		rB := serverptypes.TestRunner(t, &pb.Runner{Id: "R_B"})
+		require.NoError(s.RunnerCreate(rB))
+		job, err := s.JobAssignForRunner(ctx, rB)
+		require.NoError(err)
+		require.Equal("C", job.Id)
+
+		// The runner is assigned jobs again once the interval passes
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "D",
+			Workspace: &pb.Ref_Workspace{Workspace: "D"},
+		})))
+		clock.Advance(time.Minute)
+		select {
+		case job := <-resultCh:
+			require.Equal("D", job.Id)
+		case <-time.After(5 * time.Second):
+			t.Fatal("should be assigned")
+		}
+	})
+
+	t.Run("runners that only run jobs by ID aren't limited", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		s.RunnerAssignLimitSet(1, time.Hour)
+
+		r := serverptypes.TestRunner(t, &pb.Runner{Id: "R_A", ByIdOnly: true})
+		require.NoError(s.RunnerCreate(r))
+
+		for _, id := range []string{"A", "B"} {
+			require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+				Id:           id,
+				Workspace:    &pb.Ref_Workspace{Workspace: id},
+				TargetRunner: &pb.Ref_Runner{Target: &pb.Ref_Runner_Id{Id: &pb.Ref_RunnerId{Id: "R_A"}}},
+			})))
+
+			job, err := s.JobAssignForRunner(ctx, r)
+			require.NoError(err)
+			require.Equal(id, job.Id)
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package state
+
+import (
+	"github.com/hashicorp/go-memdb"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to the number of jobs a runner runs at
+// once. Runners set the most jobs they run at once with the Runner.MaxJobs
+// field. The jobs assigned to each runner are recorded in the jobs-assigned
+// table along with the job's project, app, and workspace, and a runner
+// that has MaxJobs jobs waiting or running isn't assigned more until one of
+// them ends. Runners that don't set MaxJobs are assigned any number of jobs.
+
+// RunnerJobsAssigned returns the number of jobs that are waiting or running
+// on the runner with the given ID.
+func (s *State) RunnerJobsAssigned(id string) (int, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	_, ids, err := s.runnerJobsAssigned(memTxn, id)
+	return len(ids), err
+}
+
+// runnerFull returns true if the runner has its maximum number of jobs
+// assigned. The returned channel is closed when the jobs assigned to the
+// runner change. It is nil if the runner has no maximum.
+func (s *State) runnerFull(memTxn *memdb.Txn, r *pb.Runner) (<-chan struct{}, bool, error) {
+	if r.MaxJobs == 0 {
+		return nil, false, nil
+	}
+
+	ch, ids, err := s.runnerJobsAssigned(memTxn, r.Id)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return ch, uint32(len(ids)) >= r.MaxJobs, nil
+}
+
+// runnerJobsAssigned returns the IDs of the jobs assigned to the runner
+// with the given ID and a channel that is closed when they change.
+func (s *State) runnerJobsAssigned(memTxn *memdb.Txn, id string) (<-chan struct{}, []string, error) {
+	iter, err := memTxn.Get(jobAssignedTableName, jobAssignedRunnerIndexName, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var result []string
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		result = append(result, raw.(*jobAssignedIndex).JobId)
+	}
+
+	return iter.WatchCh(), result, nil
+}
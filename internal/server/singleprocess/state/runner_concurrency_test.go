@@ -0,0 +1,73 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestJobAssignForRunner_maxJobs(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	s := TestState(t)
+	defer s.Close()
+
+	require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+	require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "B"})))
+	require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "C"})))
+
+	r := &pb.Runner{Id: "R_A", MaxJobs: 2}
+	job, err := s.JobAssignForRunner(ctx, r)
+	require.NoError(err)
+	require.Equal("A", job.Id)
+	job, err = s.JobAssignForRunner(ctx, r)
+	require.NoError(err)
+	require.Equal("B", job.Id)
+	_, err = s.JobAck(ctx, "B", true)
+	require.NoError(err)
+
+	n, err := s.RunnerJobsAssigned("R_A")
+	require.NoError(err)
+	require.Equal(2, n)
+
+	// The runner is full so it isn't assigned the third job
+	actx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	_, err = s.JobAssignForRunner(actx, r)
+	cancel()
+	require.Equal(context.DeadlineExceeded, err)
+
+	// Other runners are
+	job, err = s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_B"})
+	require.NoError(err)
+	require.Equal("C", job.Id)
+	_, err = s.JobAck(ctx, "C", false)
+	require.NoError(err)
+
+	// Once one of its jobs ends, a waiting runner is assigned another
+	doneCh := make(chan *Job, 1)
+	go func() {
+		job, _ := s.JobAssignForRunner(ctx, r)
+		doneCh <- job
+	}()
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(s.JobComplete(ctx, "B", nil, nil))
+
+	select {
+	case job := <-doneCh:
+		require.NotNil(job)
+		require.Equal("C", job.Id)
+
+	case <-time.After(1 * time.Second):
+		t.Fatal("runner should be assigned a job once it has capacity")
+	}
+
+	n, err = s.RunnerJobsAssigned("R_A")
+	require.NoError(err)
+	require.Equal(2, n)
+}
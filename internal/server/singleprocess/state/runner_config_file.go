@@ -0,0 +1,342 @@
+package state
+
+import (
+	"encoding/binary"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hashicorp/go-memdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to runner config files. These are
+// files that the server writes on runners, such as cloud credentials or
+// docker auth, delivered with the rest of the runner config over the config
+// stream of each runner. Every change to the files increases the runner
+// config version, which is persisted so that it never goes backwards, and
+// each file records the version it was last set at.
+//
+// Files are indexed in memory so that the config streams of runners can
+// watch them for changes.
+
+var (
+	runnerConfigFileBucket    = []byte("runner_config_file")
+	runnerConfigVersionBucket = []byte("runner_config_version")
+	runnerConfigVersionId     = []byte("version")
+)
+
+func init() {
+	dbBuckets = append(dbBuckets, runnerConfigFileBucket, runnerConfigVersionBucket)
+	dbIndexers = append(dbIndexers, (*State).runnerConfigFileIndexInit)
+	schemas = append(schemas, runnerConfigFileIndexSchema)
+}
+
+// RunnerConfigFileSet creates or updates a runner config file. This returns
+// the file without its contents with its new version set.
+func (s *State) RunnerConfigFileSet(f *pb.RunnerConfigFile) (*pb.RunnerConfigFile, error) {
+	runnerId, err := runnerConfigFileTarget(f.Target)
+	if err != nil {
+		return nil, err
+	}
+	if err := runnerConfigFilePathValidate(f.Path); err != nil {
+		return nil, err
+	}
+
+	// Sensitive contents are only stored encrypted. The encrypted contents
+	// are never accepted from the caller.
+	f = proto.Clone(f).(*pb.RunnerConfigFile)
+	f.EncryptedData = nil
+	if f.Sensitive {
+		ciphertext, err := s.configEncrypt(string(f.Data))
+		if err != nil {
+			return nil, err
+		}
+
+		f.Data = nil
+		f.EncryptedData = ciphertext
+	}
+
+	memTxn := s.inmemWriteTxn()
+	defer memTxn.Abort()
+
+	err = s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		f.Version, err = runnerConfigVersionIncr(dbTxn)
+		if err != nil {
+			return err
+		}
+
+		id := runnerConfigFileId(runnerId, f.Path)
+		if err := dbPut(dbTxn.Bucket(runnerConfigFileBucket), id, f); err != nil {
+			return err
+		}
+
+		return s.runnerConfigFileIndexSet(memTxn, id, f)
+	})
+	if err != nil {
+		return nil, err
+	}
+	memTxn.Commit()
+
+	return runnerConfigFileNoData(f), nil
+}
+
+// RunnerConfigFileList returns the runner config files without their
+// contents sorted by path.
+func (s *State) RunnerConfigFileList() ([]*pb.RunnerConfigFile, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	iter, err := memTxn.Get(runnerConfigFileIndexTableName, runnerConfigFileIndexIdIndexName+"_prefix", "")
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*pb.RunnerConfigFile
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		result = append(result, runnerConfigFileNoData(raw.(*runnerConfigFileIndexRecord).File))
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+
+	return result, nil
+}
+
+// RunnerConfigFileDelete deletes the runner config file with the given
+// path and target. Deleting a file that doesn't exist does nothing.
+func (s *State) RunnerConfigFileDelete(p string, target *pb.Ref_Runner) error {
+	runnerId, err := runnerConfigFileTarget(target)
+	if err != nil {
+		return err
+	}
+
+	memTxn := s.inmemWriteTxn()
+	defer memTxn.Abort()
+
+	id := runnerConfigFileId(runnerId, p)
+	raw, err := memTxn.First(runnerConfigFileIndexTableName, runnerConfigFileIndexIdIndexName, string(id))
+	if err != nil {
+		return err
+	}
+	if raw == nil {
+		return nil
+	}
+
+	err = s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		if _, err := runnerConfigVersionIncr(dbTxn); err != nil {
+			return err
+		}
+
+		if err := dbDelete(dbTxn.Bucket(runnerConfigFileBucket), id); err != nil {
+			return err
+		}
+
+		return memTxn.Delete(runnerConfigFileIndexTableName, raw)
+	})
+	if err != nil {
+		return err
+	}
+	memTxn.Commit()
+
+	return nil
+}
+
+// RunnerConfigFilesWatch returns the decrypted runner config files for the
+// runner with the given ID sorted by path, along with the runner config
+// version. A file that targets the runner by ID replaces a file with the
+// same path that targets any runner. The watch set is notified when the
+// files change.
+func (s *State) RunnerConfigFilesWatch(runnerId string, ws memdb.WatchSet) ([]*pb.RunnerConfigFile, uint64, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	iter, err := memTxn.Get(runnerConfigFileIndexTableName, runnerConfigFileIndexIdIndexName+"_prefix", "")
+	if err != nil {
+		return nil, 0, err
+	}
+	ws.Add(iter.WatchCh())
+
+	files := map[string]*runnerConfigFileIndexRecord{}
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		rec := raw.(*runnerConfigFileIndexRecord)
+		if rec.RunnerId != "" && rec.RunnerId != runnerId {
+			continue
+		}
+		if old, ok := files[rec.File.Path]; ok && old.RunnerId != "" {
+			continue
+		}
+
+		files[rec.File.Path] = rec
+	}
+
+	result := make([]*pb.RunnerConfigFile, 0, len(files))
+	for _, rec := range files {
+		f := proto.Clone(rec.File).(*pb.RunnerConfigFile)
+		if len(f.EncryptedData) > 0 {
+			plaintext, err := s.configDecrypt(f.EncryptedData)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			f.Data = []byte(plaintext)
+			f.EncryptedData = nil
+		}
+
+		result = append(result, f)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+
+	var version uint64
+	err = s.dbView(func(dbTxn *bolt.Tx) error {
+		version = runnerConfigVersionGet(dbTxn)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return result, version, nil
+}
+
+// runnerConfigVersionIncr increases the runner config version and returns
+// the new version.
+func runnerConfigVersionIncr(dbTxn *bolt.Tx) (uint64, error) {
+	version := runnerConfigVersionGet(dbTxn) + 1
+
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], version)
+	if err := dbPutRaw(dbTxn.Bucket(runnerConfigVersionBucket), runnerConfigVersionId, buf[:]); err != nil {
+		return 0, status.Errorf(codes.Aborted, "failed to write data: %s", err)
+	}
+
+	return version, nil
+}
+
+// runnerConfigVersionGet returns the runner config version. This is zero
+// if no runner config file was ever set.
+func runnerConfigVersionGet(dbTxn *bolt.Tx) uint64 {
+	v := dbTxn.Bucket(runnerConfigVersionBucket).Get(runnerConfigVersionId)
+	if len(v) != 8 {
+		return 0
+	}
+
+	return binary.BigEndian.Uint64(v)
+}
+
+// runnerConfigFileTarget returns the ID of the runner the file targets or
+// an empty string if it targets any runner.
+func runnerConfigFileTarget(target *pb.Ref_Runner) (string, error) {
+	switch t := target.GetTarget().(type) {
+	case nil, *pb.Ref_Runner_Any:
+		return "", nil
+
+	case *pb.Ref_Runner_Id:
+		if t.Id.GetId() == "" {
+			return "", status.Errorf(codes.InvalidArgument,
+				"runner config file target runner ID must be set")
+		}
+
+		return t.Id.Id, nil
+
+	default:
+		return "", status.Errorf(codes.InvalidArgument,
+			"runner config files can only target any runner or a runner by ID")
+	}
+}
+
+// runnerConfigFilePathValidate validates that the path of a runner config
+// file is relative and stays in the runner's config directory. Paths use
+// forward slashes regardless of the runner's OS.
+func runnerConfigFilePathValidate(p string) error {
+	switch {
+	case p == "":
+		return status.Errorf(codes.InvalidArgument, "runner config file path must be set")
+
+	case strings.HasPrefix(p, "/"), strings.Contains(p, "\\"), strings.Contains(p, ":"):
+		return status.Errorf(codes.InvalidArgument,
+			"runner config file path must be a relative path with forward slashes: %s", p)
+
+	case path.Clean(p) != p, p == ".", p == "..", strings.HasPrefix(p, "../"):
+		return status.Errorf(codes.InvalidArgument,
+			"runner config file path must be clean and stay in the config directory: %s", p)
+	}
+
+	return nil
+}
+
+// runnerConfigFileNoData returns a copy of the file without its contents.
+func runnerConfigFileNoData(f *pb.RunnerConfigFile) *pb.RunnerConfigFile {
+	f = proto.Clone(f).(*pb.RunnerConfigFile)
+	f.Data = nil
+	f.EncryptedData = nil
+	return f
+}
+
+func runnerConfigFileId(runnerId, p string) []byte {
+	return []byte(runnerId + ":" + p)
+}
+
+// runnerConfigFileIndexSet writes an index record for a runner config file.
+func (s *State) runnerConfigFileIndexSet(txn *memdb.Txn, id []byte, value *pb.RunnerConfigFile) error {
+	runnerId, err := runnerConfigFileTarget(value.Target)
+	if err != nil {
+		return err
+	}
+
+	return txn.Insert(runnerConfigFileIndexTableName, &runnerConfigFileIndexRecord{
+		Id:       string(id),
+		RunnerId: runnerId,
+		File:     value,
+	})
+}
+
+// runnerConfigFileIndexInit initializes the runner config file index from
+// persisted data.
+func (s *State) runnerConfigFileIndexInit(dbTxn *bolt.Tx, memTxn *memdb.Txn) error {
+	bucket := dbTxn.Bucket(runnerConfigFileBucket)
+	return bucket.ForEach(func(k, v []byte) error {
+		var value pb.RunnerConfigFile
+		if err := proto.Unmarshal(v, &value); err != nil {
+			return err
+		}
+
+		return s.runnerConfigFileIndexSet(memTxn, k, &value)
+	})
+}
+
+func runnerConfigFileIndexSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: runnerConfigFileIndexTableName,
+		Indexes: map[string]*memdb.IndexSchema{
+			runnerConfigFileIndexIdIndexName: {
+				Name:         runnerConfigFileIndexIdIndexName,
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field: "Id",
+				},
+			},
+		},
+	}
+}
+
+const (
+	runnerConfigFileIndexTableName   = "runner-config-file-index"
+	runnerConfigFileIndexIdIndexName = "id"
+)
+
+type runnerConfigFileIndexRecord struct {
+	Id       string
+	RunnerId string
+	File     *pb.RunnerConfigFile
+}
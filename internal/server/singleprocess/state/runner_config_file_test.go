@@ -0,0 +1,190 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/hashicorp/go-memdb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/keywrap"
+)
+
+func TestRunnerConfigFile(t *testing.T) {
+	runnerId := func(id string) *pb.Ref_Runner {
+		return &pb.Ref_Runner{
+			Target: &pb.Ref_Runner_Id{
+				Id: &pb.Ref_RunnerId{Id: id},
+			},
+		}
+	}
+
+	t.Run("set, list, and delete", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		f, err := s.RunnerConfigFileSet(&pb.RunnerConfigFile{
+			Path: "docker/config.json",
+			Data: []byte("{}"),
+		})
+		require.NoError(err)
+		require.Equal(uint64(1), f.Version)
+		require.Empty(f.Data)
+
+		f, err = s.RunnerConfigFileSet(&pb.RunnerConfigFile{
+			Path: "aws/credentials",
+			Data: []byte("key"),
+		})
+		require.NoError(err)
+		require.Equal(uint64(2), f.Version)
+
+		// Lists are sorted and have no contents
+		files, err := s.RunnerConfigFileList()
+		require.NoError(err)
+		require.Len(files, 2)
+		require.Equal("aws/credentials", files[0].Path)
+		require.Equal("docker/config.json", files[1].Path)
+		require.Empty(files[0].Data)
+
+		// Deleting increases the version
+		require.NoError(s.RunnerConfigFileDelete("aws/credentials", nil))
+		require.NoError(s.RunnerConfigFileDelete("aws/credentials", nil))
+		files, version, err := s.RunnerConfigFilesWatch("R_A", nil)
+		require.NoError(err)
+		require.Len(files, 1)
+		require.Equal(uint64(3), version)
+
+		// The version persists
+		s = TestStateReinit(t, s)
+		defer s.Close()
+		f, err = s.RunnerConfigFileSet(&pb.RunnerConfigFile{
+			Path: "aws/credentials",
+			Data: []byte("key"),
+		})
+		require.NoError(err)
+		require.Equal(uint64(4), f.Version)
+
+		files, err = s.RunnerConfigFileList()
+		require.NoError(err)
+		require.Len(files, 2)
+	})
+
+	t.Run("files for a runner by ID", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		_, err := s.RunnerConfigFileSet(&pb.RunnerConfigFile{
+			Path: "a",
+			Data: []byte("any"),
+		})
+		require.NoError(err)
+		_, err = s.RunnerConfigFileSet(&pb.RunnerConfigFile{
+			Path:   "a",
+			Target: runnerId("R_A"),
+			Data:   []byte("A"),
+		})
+		require.NoError(err)
+		_, err = s.RunnerConfigFileSet(&pb.RunnerConfigFile{
+			Path:   "b",
+			Target: runnerId("R_B"),
+			Data:   []byte("B"),
+		})
+		require.NoError(err)
+
+		files, _, err := s.RunnerConfigFilesWatch("R_A", nil)
+		require.NoError(err)
+		require.Len(files, 1)
+		require.Equal("A", string(files[0].Data))
+
+		files, _, err = s.RunnerConfigFilesWatch("R_B", nil)
+		require.NoError(err)
+		require.Len(files, 2)
+		require.Equal("any", string(files[0].Data))
+		require.Equal("B", string(files[1].Data))
+
+		// Labels aren't supported
+		_, err = s.RunnerConfigFileSet(&pb.RunnerConfigFile{
+			Path: "c",
+			Target: &pb.Ref_Runner{
+				Target: &pb.Ref_Runner_Labels{
+					Labels: &pb.Ref_RunnerLabels{Labels: map[string]string{"a": "b"}},
+				},
+			},
+		})
+		require.Error(err)
+		require.Equal(codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("watch", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		ws := memdb.NewWatchSet()
+		files, version, err := s.RunnerConfigFilesWatch("R_A", ws)
+		require.NoError(err)
+		require.Empty(files)
+		require.Equal(uint64(0), version)
+		require.True(ws.Watch(time.After(50 * time.Millisecond)))
+
+		_, err = s.RunnerConfigFileSet(&pb.RunnerConfigFile{Path: "a"})
+		require.NoError(err)
+		require.False(ws.Watch(time.After(3 * time.Second)))
+	})
+
+	t.Run("invalid paths", func(t *testing.T) {
+		s := TestState(t)
+		defer s.Close()
+
+		for _, p := range []string{"", "/etc/passwd", "..", "../a", "a/../../b", "a//b", "./a", `a\b`, "C:/a"} {
+			_, err := s.RunnerConfigFileSet(&pb.RunnerConfigFile{Path: p})
+			require.Error(t, err, p)
+			require.Equal(t, codes.InvalidArgument, status.Code(err), p)
+		}
+	})
+
+	t.Run("sensitive files are encrypted", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		// Encryption must be configured
+		f := &pb.RunnerConfigFile{
+			Path:      "aws/credentials",
+			Data:      []byte("hunter2"),
+			Sensitive: true,
+		}
+		_, err := s.RunnerConfigFileSet(f)
+		require.Error(err)
+
+		require.NoError(s.ConfigEncryptionSet(
+			context.Background(), keywrap.TestWrapper(t)))
+		_, err = s.RunnerConfigFileSet(f)
+		require.NoError(err)
+
+		// The plaintext should not be on disk
+		require.NoError(s.db.View(func(tx *bolt.Tx) error {
+			return tx.Bucket(runnerConfigFileBucket).ForEach(func(k, v []byte) error {
+				require.NotContains(string(v), "hunter2")
+				return nil
+			})
+		}))
+
+		files, _, err := s.RunnerConfigFilesWatch("R_A", nil)
+		require.NoError(err)
+		require.Len(files, 1)
+		require.Equal("hunter2", string(files[0].Data))
+		require.Empty(files[0].EncryptedData)
+	})
+}
@@ -0,0 +1,169 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-memdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to runners that disconnect. When the
+// config stream of a runner ends the runner is deregistered right away and
+// the jobs assigned to it that it hasn't accepted are queued again, rather
+// than waiting for the runner's heartbeat timeout. What happens to the
+// jobs the runner was running depends on the disconnect policy:
+//
+//   - RunnerDisconnectWait leaves them running. They fail if the runner
+//     doesn't send a heartbeat for them within their heartbeat timeout,
+//     so a runner that reconnects quickly can still complete them. This
+//     is the default.
+//   - RunnerDisconnectRequeue queues them again to run on another runner.
+//   - RunnerDisconnectFail fails them right away.
+//
+// Running jobs that target the disconnected runner by ID can't run
+// anywhere else so they fail rather than being queued again.
+
+// RunnerDisconnectPolicy is what happens to the running jobs of a runner
+// that disconnects.
+type RunnerDisconnectPolicy string
+
+const (
+	RunnerDisconnectWait    RunnerDisconnectPolicy = "wait"
+	RunnerDisconnectRequeue RunnerDisconnectPolicy = "requeue"
+	RunnerDisconnectFail    RunnerDisconnectPolicy = "fail"
+)
+
+// RunnerDisconnectPolicies are the valid disconnect policies.
+var RunnerDisconnectPolicies = []RunnerDisconnectPolicy{
+	RunnerDisconnectWait,
+	RunnerDisconnectRequeue,
+	RunnerDisconnectFail,
+}
+
+// RunnerDisconnectPolicySet sets what happens to the running jobs of a
+// runner that disconnects. Empty uses RunnerDisconnectWait. This should
+// be called once before the state is used.
+func (s *State) RunnerDisconnectPolicySet(p RunnerDisconnectPolicy) error {
+	switch p {
+	case "":
+		p = RunnerDisconnectWait
+
+	case RunnerDisconnectWait, RunnerDisconnectRequeue, RunnerDisconnectFail:
+
+	default:
+		return status.Errorf(codes.InvalidArgument,
+			"unknown runner disconnect policy: %s", p)
+	}
+
+	s.runnerDisconnectPolicy = p
+	return nil
+}
+
+// RunnerDisconnect deregisters the runner with the given ID because its
+// config stream ended. The jobs assigned to it that it hasn't accepted are
+// queued again and its running jobs are handled by the disconnect policy.
+func (s *State) RunnerDisconnect(id string) error {
+	txn := s.inmemWriteTxn()
+	defer txn.Abort()
+
+	waiting, err := s.runnerJobsWaiting(txn, id)
+	if err != nil {
+		return err
+	}
+
+	var queued []*jobIndex
+	var completed []string
+	if p := s.runnerDisconnectPolicy; p == RunnerDisconnectRequeue || p == RunnerDisconnectFail {
+		running, err := s.runnerJobsRunning(txn, id)
+		if err != nil {
+			return err
+		}
+
+		st := status.New(codes.Unavailable, jobRunnerDisconnectedReason(id))
+		for _, job := range running {
+			job = job.Copy()
+			if p == RunnerDisconnectRequeue && job.TargetRunnerId == "" {
+				if err := s.jobAssignedSet(txn, job, false); err != nil {
+					return err
+				}
+				if err := s.jobPreemptRequeue(txn, job); err != nil {
+					return err
+				}
+
+				queued = append(queued, job)
+				continue
+			}
+
+			if err := s.jobCancel(txn, job, true, st); err != nil {
+				return err
+			}
+
+			completed = append(completed, job.Id)
+		}
+	}
+
+	if err := s.runnerDelete(txn, id); err != nil {
+		return err
+	}
+	txn.Commit()
+
+	if len(waiting) > 0 || len(queued) > 0 || len(completed) > 0 {
+		s.log.Info("runner disconnected, handled its jobs",
+			"runner", id,
+			"policy", string(s.runnerDisconnectPolicy),
+			"waiting", len(waiting),
+			"requeued", len(queued),
+			"failed", len(completed))
+	}
+
+	for _, job := range queued {
+		s.jobNotifyQueued(job)
+	}
+	for _, jobId := range completed {
+		s.jobCompleted(jobId)
+	}
+	for _, jobId := range waiting {
+		if _, err := s.JobNack(jobId, jobRunnerDisconnectedReason(id)); err != nil &&
+			status.Code(err) != codes.FailedPrecondition {
+			s.log.Warn("error queueing job of disconnected runner",
+				"runner", id, "job", jobId, "err", err)
+		}
+	}
+
+	return nil
+}
+
+// runnerJobsRunning returns the jobs assigned to the runner with the given
+// ID that it is running.
+func (s *State) runnerJobsRunning(memTxn *memdb.Txn, id string) ([]*jobIndex, error) {
+	_, ids, err := s.runnerJobsAssigned(memTxn, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*jobIndex
+	for _, jobId := range ids {
+		raw, err := memTxn.First(jobTableName, jobIdIndexName, jobId)
+		if err != nil {
+			return nil, err
+		}
+		if raw == nil {
+			continue
+		}
+
+		if idx := raw.(*jobIndex); idx.State == pb.Job_RUNNING {
+			result = append(result, idx)
+		}
+	}
+
+	return result, nil
+}
+
+// jobRunnerDisconnectedReason is the reason a job wasn't accepted or
+// didn't complete if the runner it was assigned to disconnected.
+func jobRunnerDisconnectedReason(id string) string {
+	return fmt.Sprintf("runner %s disconnected", id)
+}
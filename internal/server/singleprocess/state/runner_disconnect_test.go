@@ -0,0 +1,147 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestRunnerDisconnect(t *testing.T) {
+	ctx := context.Background()
+
+	// setup registers runner R_A and assigns it job A, which it runs, and
+	// job B, which it hasn't accepted.
+	setup := func(t *testing.T, s *State, a *pb.Job) {
+		require := require.New(t)
+
+		r := serverptypes.TestRunner(t, &pb.Runner{Id: "R_A"})
+		require.NoError(s.RunnerCreate(r))
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, a)))
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "B"})))
+		job, err := s.JobAssignForRunner(ctx, r)
+		require.NoError(err)
+		require.Equal("A", job.Id)
+		_, err = s.JobAck(ctx, "A", true)
+		require.NoError(err)
+		job, err = s.JobAssignForRunner(ctx, r)
+		require.NoError(err)
+		require.Equal("B", job.Id)
+	}
+
+	// checkWaiting checks that job B was queued again.
+	checkWaiting := func(t *testing.T, s *State) {
+		require := require.New(t)
+
+		job, err := s.JobById("B", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_QUEUED, job.State)
+		require.Len(job.Assignments, 1)
+		require.Equal(jobRunnerDisconnectedReason("R_A"), job.Assignments[0].NackReason)
+
+		_, err = s.RunnerById("R_A")
+		require.Equal(codes.NotFound, status.Code(err))
+	}
+
+	t.Run("wait leaves running jobs", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		setup(t, s, &pb.Job{Id: "A"})
+
+		require.NoError(s.RunnerDisconnect("R_A"))
+		checkWaiting(t, s)
+
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_RUNNING, job.State)
+	})
+
+	t.Run("requeue queues running jobs again", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		require.NoError(s.RunnerDisconnectPolicySet(RunnerDisconnectRequeue))
+		setup(t, s, &pb.Job{Id: "A"})
+
+		require.NoError(s.RunnerDisconnect("R_A"))
+		checkWaiting(t, s)
+
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_QUEUED, job.State)
+		require.Nil(job.AckTime)
+
+		n, err := s.RunnerJobsAssigned("R_A")
+		require.NoError(err)
+		require.Equal(0, n)
+
+		// Another runner is assigned the job
+		r := serverptypes.TestRunner(t, &pb.Runner{Id: "R_B"})
+		require.NoError(s.RunnerCreate(r))
+		job, err = s.JobAssignForRunner(ctx, r)
+		require.NoError(err)
+		require.Equal("A", job.Id)
+	})
+
+	t.Run("requeue fails running jobs that target the runner", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		require.NoError(s.RunnerDisconnectPolicySet(RunnerDisconnectRequeue))
+		setup(t, s, &pb.Job{
+			Id: "A",
+			TargetRunner: &pb.Ref_Runner{
+				Target: &pb.Ref_Runner_Id{
+					Id: &pb.Ref_RunnerId{Id: "R_A"},
+				},
+			},
+		})
+
+		require.NoError(s.RunnerDisconnect("R_A"))
+		checkWaiting(t, s)
+
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_ERROR, job.State)
+		require.Equal(int32(codes.Unavailable), job.Error.Code)
+	})
+
+	t.Run("fail fails running jobs", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		require.NoError(s.RunnerDisconnectPolicySet(RunnerDisconnectFail))
+		setup(t, s, &pb.Job{Id: "A"})
+
+		require.NoError(s.RunnerDisconnect("R_A"))
+		checkWaiting(t, s)
+
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_ERROR, job.State)
+		require.Equal(int32(codes.Unavailable), job.Error.Code)
+		require.Equal(jobRunnerDisconnectedReason("R_A"), job.Error.Message)
+	})
+
+	t.Run("unknown policy", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		err := s.RunnerDisconnectPolicySet("nope")
+		require.Error(err)
+		require.Equal(codes.InvalidArgument, status.Code(err))
+	})
+}
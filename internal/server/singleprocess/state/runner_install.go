@@ -0,0 +1,115 @@
+package state
+
+import (
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to runner installations. These record
+// the static runners that the CLI installed into a platform so that they
+// can be upgraded or uninstalled later. They're only read by the CLI so
+// they're not indexed in memory.
+
+var runnerInstallBucket = []byte("runner_install")
+
+func init() {
+	dbBuckets = append(dbBuckets, runnerInstallBucket)
+}
+
+// RunnerInstallationSet creates or updates the installation of a runner.
+// The install time of an existing installation is kept and the update time
+// is set to now.
+func (s *State) RunnerInstallationSet(inst *pb.RunnerInstallation) (*pb.RunnerInstallation, error) {
+	if inst.RunnerId == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "runner installation ID must be set")
+	}
+	if inst.Platform == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "runner installation platform must be set")
+	}
+
+	now, err := ptypes.TimestampProto(s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	inst = proto.Clone(inst).(*pb.RunnerInstallation)
+	inst.InstallTime = now
+	inst.UpdateTime = now
+
+	err = s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		b := dbTxn.Bucket(runnerInstallBucket)
+		id := runnerInstallId(inst.RunnerId)
+
+		var old pb.RunnerInstallation
+		err := dbGet(b, id, &old)
+		switch status.Code(err) {
+		case codes.OK:
+			inst.InstallTime = old.InstallTime
+
+		case codes.NotFound:
+
+		default:
+			return err
+		}
+
+		return dbPut(b, id, inst)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return inst, nil
+}
+
+// RunnerInstallationGet gets the installation of the runner with the
+// given ID.
+func (s *State) RunnerInstallationGet(id string) (*pb.RunnerInstallation, error) {
+	var result pb.RunnerInstallation
+	err := s.dbView(func(dbTxn *bolt.Tx) error {
+		return dbGet(dbTxn.Bucket(runnerInstallBucket), runnerInstallId(id), &result)
+	})
+	if status.Code(err) == codes.NotFound {
+		return nil, status.Errorf(codes.NotFound, "runner installation not found: %s", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// RunnerInstallationList returns all the runner installations sorted by
+// runner ID.
+func (s *State) RunnerInstallationList() ([]*pb.RunnerInstallation, error) {
+	var result []*pb.RunnerInstallation
+	err := s.dbView(func(dbTxn *bolt.Tx) error {
+		return dbTxn.Bucket(runnerInstallBucket).ForEach(func(k, v []byte) error {
+			var value pb.RunnerInstallation
+			if err := proto.Unmarshal(v, &value); err != nil {
+				return err
+			}
+
+			result = append(result, &value)
+			return nil
+		})
+	})
+
+	return result, err
+}
+
+// RunnerInstallationDelete deletes the installation of the runner with the
+// given ID. Deleting an installation that doesn't exist does nothing.
+func (s *State) RunnerInstallationDelete(id string) error {
+	return s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		return dbDelete(dbTxn.Bucket(runnerInstallBucket), runnerInstallId(id))
+	})
+}
+
+func runnerInstallId(id string) []byte {
+	return []byte(id)
+}
@@ -0,0 +1,81 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func TestRunnerInstallation(t *testing.T) {
+	t.Run("set, get, list, and delete", func(t *testing.T) {
+		require := require.New(t)
+
+		clock := TestClockNew()
+		s := TestState(t, WithClock(clock))
+		defer s.Close()
+
+		inst, err := s.RunnerInstallationSet(&pb.RunnerInstallation{
+			RunnerId: "R_A",
+			Platform: "kubernetes",
+			Image:    "hashicorp/waypoint:0.2.0",
+			Config:   map[string]string{"namespace": "runners"},
+		})
+		require.NoError(err)
+		require.NotNil(inst.InstallTime)
+		require.Equal(inst.InstallTime, inst.UpdateTime)
+
+		// Upgrading keeps the install time
+		clock.Advance(time.Minute)
+		_, err = s.RunnerInstallationSet(&pb.RunnerInstallation{
+			RunnerId: "R_A",
+			Platform: "kubernetes",
+			Image:    "hashicorp/waypoint:0.3.0",
+		})
+		require.NoError(err)
+
+		actual, err := s.RunnerInstallationGet("R_A")
+		require.NoError(err)
+		require.Equal("hashicorp/waypoint:0.3.0", actual.Image)
+		require.Equal(inst.InstallTime.Seconds, actual.InstallTime.Seconds)
+		require.Equal(inst.InstallTime.Seconds+60, actual.UpdateTime.Seconds)
+
+		_, err = s.RunnerInstallationSet(&pb.RunnerInstallation{
+			RunnerId: "R_B",
+			Platform: "nomad",
+		})
+		require.NoError(err)
+
+		list, err := s.RunnerInstallationList()
+		require.NoError(err)
+		require.Len(list, 2)
+		require.Equal("R_A", list[0].RunnerId)
+		require.Equal("R_B", list[1].RunnerId)
+
+		require.NoError(s.RunnerInstallationDelete("R_A"))
+		require.NoError(s.RunnerInstallationDelete("R_A"))
+		_, err = s.RunnerInstallationGet("R_A")
+		require.Error(err)
+		require.Equal(codes.NotFound, status.Code(err))
+
+		list, err = s.RunnerInstallationList()
+		require.NoError(err)
+		require.Len(list, 1)
+	})
+
+	t.Run("requires ID and platform", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		_, err := s.RunnerInstallationSet(&pb.RunnerInstallation{Platform: "ecs"})
+		require.Error(err)
+		_, err = s.RunnerInstallationSet(&pb.RunnerInstallation{RunnerId: "R_A"})
+		require.Error(err)
+	})
+}
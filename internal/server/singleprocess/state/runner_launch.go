@@ -0,0 +1,301 @@
+package state
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-memdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file tracks the runners that are launched on demand. When queued
+// jobs have no runner that can be assigned them, the server launches a
+// runner for each of them in an external scheduler. RunnerLaunchDemand
+// returns how many runners to launch. Each launched runner is recorded so
+// that it counts towards the demand it was launched for until it's
+// assigned a job, and so the server can clean up its task once it exits.
+// Launches are only in memory like the runners themselves.
+
+const (
+	runnerLaunchTableName   = "runner-launches"
+	runnerLaunchIdIndexName = "id"
+)
+
+// RunnerLaunchStatus is the stage in the lifecycle of a launched runner.
+type RunnerLaunchStatus string
+
+const (
+	// RunnerLaunchPending is a runner that was launched but hasn't
+	// registered yet.
+	RunnerLaunchPending RunnerLaunchStatus = "pending"
+
+	// RunnerLaunchRegistered is a runner that registered and is waiting
+	// to be assigned a job.
+	RunnerLaunchRegistered RunnerLaunchStatus = "registered"
+
+	// RunnerLaunchRunning is a runner that was assigned a job.
+	RunnerLaunchRunning RunnerLaunchStatus = "running"
+
+	// RunnerLaunchExited is a runner that registered and then deregistered.
+	RunnerLaunchExited RunnerLaunchStatus = "exited"
+)
+
+func init() {
+	schemas = append(schemas, runnerLaunchSchema)
+	inmemOnlyTables[runnerLaunchTableName] = struct{}{}
+}
+
+func runnerLaunchSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: runnerLaunchTableName,
+		Indexes: map[string]*memdb.IndexSchema{
+			runnerLaunchIdIndexName: {
+				Name:         runnerLaunchIdIndexName,
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "Id",
+					Lowercase: true,
+				},
+			},
+		},
+	}
+}
+
+// RunnerLaunch is a runner that was launched on demand.
+type RunnerLaunch struct {
+	// Id is the ID that the runner registers with.
+	Id string
+
+	// Pool is the runner pool the runner joins. This is empty for runners
+	// launched for jobs that target any runner.
+	Pool string
+
+	// Profile is the name of the runner profile the runner was launched
+	// with, if any. See runner_profile.go.
+	Profile string
+
+	// Platform is the platform of the jobs the runner was launched for as
+	// "os/arch" or "os", if they require one. See job_capabilities.go.
+	Platform string
+
+	// TaskId is the ID of the task running the runner in the external
+	// scheduler. This is empty until the task is submitted.
+	TaskId string
+
+	// Status is the stage of the runner's lifecycle.
+	Status RunnerLaunchStatus
+
+	// CreateTime is when the runner was launched.
+	CreateTime time.Time
+}
+
+// RunnerLaunchTarget is the runners that are launched for a demand. Only
+// jobs for the same pool, runner profile, and platform can be assigned to
+// them.
+type RunnerLaunchTarget struct {
+	Pool     string
+	Profile  string
+	Platform string
+}
+
+// Target returns the demand that the runner was launched for.
+func (l *RunnerLaunch) Target() RunnerLaunchTarget {
+	return RunnerLaunchTarget{
+		Pool:     l.Pool,
+		Profile:  strings.ToLower(l.Profile),
+		Platform: strings.ToLower(l.Platform),
+	}
+}
+
+// available returns true if the launched runner can still be assigned a
+// job.
+func (l *RunnerLaunch) available() bool {
+	return l.Status == RunnerLaunchPending || l.Status == RunnerLaunchRegistered
+}
+
+// RunnerLaunchCreate records a runner that is being launched. The runner
+// is pending until it registers.
+func (s *State) RunnerLaunchCreate(l *RunnerLaunch) error {
+	txn := s.inmemWriteTxn()
+	defer txn.Abort()
+
+	l = &RunnerLaunch{
+		Id:         l.Id,
+		Pool:       l.Pool,
+		Profile:    l.Profile,
+		Platform:   l.Platform,
+		TaskId:     l.TaskId,
+		Status:     RunnerLaunchPending,
+		CreateTime: s.clock.Now(),
+	}
+	if err := txn.Insert(runnerLaunchTableName, l); err != nil {
+		return status.Errorf(codes.Aborted, err.Error())
+	}
+
+	txn.Commit()
+	return nil
+}
+
+// RunnerLaunchTaskSet sets the ID of the task running the launched runner
+// with the given ID.
+func (s *State) RunnerLaunchTaskSet(id, taskId string) error {
+	txn := s.inmemWriteTxn()
+	defer txn.Abort()
+
+	raw, err := txn.First(runnerLaunchTableName, runnerLaunchIdIndexName, id)
+	if err != nil {
+		return err
+	}
+	if raw == nil {
+		return status.Errorf(codes.NotFound, "runner launch not found: %s", id)
+	}
+
+	l := *raw.(*RunnerLaunch)
+	l.TaskId = taskId
+	if err := txn.Insert(runnerLaunchTableName, &l); err != nil {
+		return status.Errorf(codes.Aborted, err.Error())
+	}
+
+	txn.Commit()
+	return nil
+}
+
+// RunnerLaunchDelete removes the record of a launched runner. This is
+// called once its task is stopped or has exited.
+func (s *State) RunnerLaunchDelete(id string) error {
+	txn := s.inmemWriteTxn()
+	defer txn.Abort()
+	if _, err := txn.DeleteAll(runnerLaunchTableName, runnerLaunchIdIndexName, id); err != nil {
+		return status.Errorf(codes.Aborted, err.Error())
+	}
+	txn.Commit()
+
+	return nil
+}
+
+// RunnerLaunchList returns all the launched runners that haven't been
+// deleted.
+func (s *State) RunnerLaunchList() ([]*RunnerLaunch, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	iter, err := memTxn.Get(runnerLaunchTableName, runnerLaunchIdIndexName+"_prefix", "")
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*RunnerLaunch
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		l := *raw.(*RunnerLaunch)
+		result = append(result, &l)
+	}
+
+	return result, nil
+}
+
+// RunnerLaunchDemand returns the number of runners to launch by pool and
+// runner profile. The empty pool is for jobs that target any runner, and
+// the empty profile for jobs that don't name one. This is the number of
+// queued jobs that can be assigned now less the launched runners that
+// haven't been assigned a job yet. Jobs that target a runner by ID or by
+// label aren't counted since a launched runner can't be assigned them.
+//
+// If ws is not nil, it is notified when jobs are queued or assigned or
+// launched runners change.
+func (s *State) RunnerLaunchDemand(ws memdb.WatchSet) (map[RunnerLaunchTarget]int, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	iter, err := memTxn.LowerBound(
+		jobTableName,
+		jobQueueTimeIndexName,
+		pb.Job_QUEUED,
+		int32(math.MaxInt32),
+		time.Unix(0, 0),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if ws != nil {
+		ws.Add(iter.WatchCh())
+	}
+
+	result := map[RunnerLaunchTarget]int{}
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		job := raw.(*jobIndex)
+		if job.State != pb.Job_QUEUED || job.ApprovalPending {
+			continue
+		}
+
+		var pool string
+		switch {
+		case job.TargetAny:
+		case job.TargetPool != "":
+			pool = job.TargetPool
+		default:
+			continue
+		}
+
+		// Paused and blocked jobs can't be assigned to a new runner either.
+		if paused, err := s.jobPaused(memTxn, job); err != nil {
+			return nil, err
+		} else if paused {
+			continue
+		}
+		if blockedBy, err := s.jobBlockedBy(memTxn, job, nil); err != nil {
+			return nil, err
+		} else if blockedBy != "" {
+			continue
+		}
+
+		platform := job.PlatformOS
+		if job.PlatformArch != "" {
+			platform += "/" + job.PlatformArch
+		}
+
+		result[RunnerLaunchTarget{
+			Pool:     pool,
+			Profile:  strings.ToLower(job.RunnerProfile),
+			Platform: strings.ToLower(platform),
+		}]++
+	}
+
+	launchIter, err := memTxn.Get(runnerLaunchTableName, runnerLaunchIdIndexName+"_prefix", "")
+	if err != nil {
+		return nil, err
+	}
+	if ws != nil {
+		ws.Add(launchIter.WatchCh())
+	}
+
+	for raw := launchIter.Next(); raw != nil; raw = launchIter.Next() {
+		if l := raw.(*RunnerLaunch); l.available() && result[l.Target()] > 0 {
+			result[l.Target()]--
+		}
+	}
+	for target, n := range result {
+		if n == 0 {
+			delete(result, target)
+		}
+	}
+
+	return result, nil
+}
+
+// runnerLaunchStatusSet sets the status of the launched runner with the
+// given ID. This does nothing if the runner wasn't launched on demand.
+func (s *State) runnerLaunchStatusSet(memTxn *memdb.Txn, id string, st RunnerLaunchStatus) error {
+	raw, err := memTxn.First(runnerLaunchTableName, runnerLaunchIdIndexName, id)
+	if err != nil || raw == nil {
+		return err
+	}
+
+	l := *raw.(*RunnerLaunch)
+	l.Status = st
+	return memTxn.Insert(runnerLaunchTableName, &l)
+}
@@ -0,0 +1,129 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-memdb"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestRunnerLaunch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("demand is queued jobs less available launches", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		// Jobs for any runner and for a pool count, jobs by ID don't
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "B"})))
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id: "C",
+			TargetRunner: &pb.Ref_Runner{
+				Target: &pb.Ref_Runner_Pool{Pool: &pb.Ref_RunnerPool{Name: "deploy"}},
+			},
+		})))
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id: "D",
+			TargetRunner: &pb.Ref_Runner{
+				Target: &pb.Ref_Runner_Id{Id: &pb.Ref_RunnerId{Id: "R"}},
+			},
+		})))
+
+		demand, err := s.RunnerLaunchDemand(nil)
+		require.NoError(err)
+		require.Equal(map[RunnerLaunchTarget]int{{}: 2, {Pool: "deploy"}: 1}, demand)
+
+		// Launching a runner reduces the demand and notifies watchers
+		ws := memdb.NewWatchSet()
+		_, err = s.RunnerLaunchDemand(ws)
+		require.NoError(err)
+		require.NoError(s.RunnerLaunchCreate(&RunnerLaunch{Id: "L1", Pool: "deploy"}))
+		require.False(ws.Watch(make(chan time.Time)))
+
+		demand, err = s.RunnerLaunchDemand(nil)
+		require.NoError(err)
+		require.Equal(map[RunnerLaunchTarget]int{{}: 2}, demand)
+	})
+
+	t.Run("demand is by runner profile", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+			Id:            "B",
+			Workspace:     &pb.Ref_Workspace{Workspace: "B"},
+			RunnerProfile: "GPU",
+		})))
+
+		demand, err := s.RunnerLaunchDemand(nil)
+		require.NoError(err)
+		require.Equal(map[RunnerLaunchTarget]int{{}: 1, {Profile: "gpu"}: 1}, demand)
+
+		// A runner launched with the profile only counts for its jobs
+		require.NoError(s.RunnerLaunchCreate(&RunnerLaunch{Id: "L1", Profile: "gpu"}))
+		demand, err = s.RunnerLaunchDemand(nil)
+		require.NoError(err)
+		require.Equal(map[RunnerLaunchTarget]int{{}: 1}, demand)
+
+		// and is only assigned jobs for the profile
+		job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "L1", Profile: "gpu"})
+		require.NoError(err)
+		require.Equal("B", job.Id)
+	})
+
+	t.Run("lifecycle", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		status := func(id string) RunnerLaunchStatus {
+			ls, err := s.RunnerLaunchList()
+			require.NoError(err)
+			for _, l := range ls {
+				if l.Id == id {
+					return l.Status
+				}
+			}
+			return ""
+		}
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		require.NoError(s.RunnerLaunchCreate(&RunnerLaunch{Id: "L1"}))
+		require.NoError(s.RunnerLaunchTaskSet("L1", "task-1"))
+		require.Equal(RunnerLaunchPending, status("L1"))
+
+		r := &pb.Runner{Id: "L1"}
+		require.NoError(s.RunnerCreate(r))
+		require.Equal(RunnerLaunchRegistered, status("L1"))
+
+		// Once the runner is assigned the job, it no longer counts towards
+		// the demand.
+		job, err := s.JobAssignForRunner(ctx, r)
+		require.NoError(err)
+		require.Equal("A", job.Id)
+		require.Equal(RunnerLaunchRunning, status("L1"))
+
+		require.NoError(s.RunnerDelete("L1"))
+		require.Equal(RunnerLaunchExited, status("L1"))
+
+		ls, err := s.RunnerLaunchList()
+		require.NoError(err)
+		require.Len(ls, 1)
+		require.Equal("task-1", ls[0].TaskId)
+
+		require.NoError(s.RunnerLaunchDelete("L1"))
+		require.Equal(RunnerLaunchStatus(""), status("L1"))
+	})
+}
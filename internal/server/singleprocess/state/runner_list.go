@@ -0,0 +1,106 @@
+package state
+
+import (
+	"sort"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hashicorp/go-memdb"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// RunnerListQuery selects the runners listed by RunnerList.
+type RunnerListQuery struct {
+	// RunnerQuery filters the runners by what they advertise.
+	RunnerQuery
+
+	// States only lists the runners in these states. If this is empty,
+	// runners in any state are listed.
+	States []pb.RunnerStatus_State
+
+	// After only lists the runners with IDs after this, such as the last
+	// runner of the previous page.
+	After string
+
+	// Limit is the most runners to list. Zero is unlimited.
+	Limit int
+}
+
+// RunnerList returns the status of the registered runners that match the
+// query, sorted by ID. more is true if there are more runners that match
+// after the last one returned.
+func (s *State) RunnerList(q *RunnerListQuery) (result []*pb.RunnerStatus, more bool, err error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	recs, err := s.runnerFind(memTxn, &q.RunnerQuery)
+	if err != nil {
+		return nil, false, err
+	}
+
+	states := map[pb.RunnerStatus_State]struct{}{}
+	for _, st := range q.States {
+		states[st] = struct{}{}
+	}
+
+	for _, rec := range recs {
+		if rec.Id <= q.After {
+			continue
+		}
+
+		st, err := s.runnerStatus(memTxn, rec)
+		if err != nil {
+			return nil, false, err
+		}
+		if _, ok := states[st.State]; len(states) > 0 && !ok {
+			continue
+		}
+
+		if q.Limit > 0 && len(result) >= q.Limit {
+			return result, true, nil
+		}
+
+		result = append(result, st)
+	}
+
+	return result, false, nil
+}
+
+// runnerStatus returns the status of the runner.
+func (s *State) runnerStatus(memTxn *memdb.Txn, rec *runnerRecord) (*pb.RunnerStatus, error) {
+	_, ids, err := s.runnerJobsAssigned(memTxn, rec.Id)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(ids)
+
+	result := &pb.RunnerStatus{
+		Runner: rec.Runner,
+		JobIds: ids,
+	}
+	switch {
+	case rec.Runner.Pending:
+		result.State = pb.RunnerStatus_PENDING
+
+	case rec.Runner.Draining:
+		result.State = pb.RunnerStatus_DRAINING
+
+	case rec.Runner.MaxJobs > 0 && uint32(len(ids)) >= rec.Runner.MaxJobs:
+		result.State = pb.RunnerStatus_FULL
+
+	case len(ids) > 0:
+		result.State = pb.RunnerStatus_BUSY
+
+	default:
+		result.State = pb.RunnerStatus_IDLE
+	}
+
+	if !rec.LastSeen.IsZero() {
+		result.LastHeartbeatTime, err = ptypes.TimestampProto(rec.LastSeen)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
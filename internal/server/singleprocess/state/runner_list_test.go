@@ -0,0 +1,79 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestRunnerList(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	s := TestState(t)
+	defer s.Close()
+
+	// ids returns the IDs of the runners
+	ids := func(rs []*pb.RunnerStatus) []string {
+		var result []string
+		for _, r := range rs {
+			result = append(result, r.Runner.Id)
+		}
+		return result
+	}
+
+	require.NoError(s.RunnerCreate(&pb.Runner{Id: "A", Pool: "build"}))
+	require.NoError(s.RunnerCreate(&pb.Runner{Id: "B", MaxJobs: 1}))
+	require.NoError(s.RunnerCreate(&pb.Runner{Id: "C", MaxJobs: 2}))
+	require.NoError(s.RunnerCreate(&pb.Runner{Id: "D", Pool: "build"}))
+	_, err := s.RunnerDrain("D")
+	require.NoError(err)
+	require.NoError(s.RunnerHeartbeat("A"))
+
+	require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "J1"})))
+	require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "J2"})))
+	_, err = s.JobAssignForRunner(ctx, &pb.Runner{Id: "B", MaxJobs: 1})
+	require.NoError(err)
+	_, err = s.JobAssignForRunner(ctx, &pb.Runner{Id: "C", MaxJobs: 2})
+	require.NoError(err)
+
+	// Everything
+	rs, more, err := s.RunnerList(&RunnerListQuery{})
+	require.NoError(err)
+	require.False(more)
+	require.Equal([]string{"A", "B", "C", "D"}, ids(rs))
+	require.Equal(pb.RunnerStatus_IDLE, rs[0].State)
+	require.NotNil(rs[0].LastHeartbeatTime)
+	require.Equal(pb.RunnerStatus_FULL, rs[1].State)
+	require.Equal([]string{"J1"}, rs[1].JobIds)
+	require.Equal(pb.RunnerStatus_BUSY, rs[2].State)
+	require.Equal([]string{"J2"}, rs[2].JobIds)
+	require.Equal(pb.RunnerStatus_DRAINING, rs[3].State)
+	require.Nil(rs[3].LastHeartbeatTime)
+
+	// Filters
+	rs, _, err = s.RunnerList(&RunnerListQuery{RunnerQuery: RunnerQuery{Pool: "build"}})
+	require.NoError(err)
+	require.Equal([]string{"A", "D"}, ids(rs))
+
+	rs, _, err = s.RunnerList(&RunnerListQuery{
+		States: []pb.RunnerStatus_State{pb.RunnerStatus_BUSY, pb.RunnerStatus_FULL},
+	})
+	require.NoError(err)
+	require.Equal([]string{"B", "C"}, ids(rs))
+
+	// Pages
+	rs, more, err = s.RunnerList(&RunnerListQuery{Limit: 2})
+	require.NoError(err)
+	require.True(more)
+	require.Equal([]string{"A", "B"}, ids(rs))
+
+	rs, more, err = s.RunnerList(&RunnerListQuery{After: "B", Limit: 2})
+	require.NoError(err)
+	require.False(more)
+	require.Equal([]string{"C", "D"}, ids(rs))
+}
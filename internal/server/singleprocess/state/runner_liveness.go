@@ -0,0 +1,182 @@
+package state
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-memdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file tracks whether registered runners are still alive. Runners
+// are deregistered when their config stream closes, but a runner that
+// disappears without closing it, such as after a network partition, would
+// otherwise stay registered forever. Runners send heartbeats and each one
+// resets a timer for the runner. If the timer expires, the runner is
+// deregistered and the jobs assigned to it that it hasn't accepted are
+// queued again rather than waiting for their ack timeout.
+//
+// The timer only starts with the first heartbeat so that runners that
+// don't send heartbeats stay registered for as long as their stream is
+// open.
+
+// runnerHeartbeatTimeout is the default time a runner can go without a
+// heartbeat before it is deregistered.
+const runnerHeartbeatTimeout = 1 * time.Minute
+
+// RunnerHeartbeatTimeoutSet sets how long a runner can go without a
+// heartbeat before it is deregistered. Zero uses the default of one
+// minute. This should be called once before the state is used.
+func (s *State) RunnerHeartbeatTimeoutSet(d time.Duration) {
+	s.runnerHeartbeatTimeout = d
+}
+
+// runnerHeartbeatTimeoutGet returns the runner heartbeat timeout.
+func (s *State) runnerHeartbeatTimeoutGet() time.Duration {
+	if s.runnerHeartbeatTimeout > 0 {
+		return s.runnerHeartbeatTimeout
+	}
+
+	return runnerHeartbeatTimeout
+}
+
+// RunnerHeartbeat records that the runner with the given ID is alive and
+// resets its timer. This returns a NotFound error if the runner isn't
+// registered, such as if it was deregistered because its heartbeats
+// stopped.
+func (s *State) RunnerHeartbeat(id string) error {
+	txn := s.inmemWriteTxn()
+	defer txn.Abort()
+
+	raw, err := txn.First(runnerTableName, runnerIdIndexName, id)
+	if err != nil {
+		return err
+	}
+	if raw == nil {
+		return status.Errorf(codes.NotFound, "runner ID not found")
+	}
+
+	// Records are shared with readers so we update a copy. The timer is
+	// kept by the copy.
+	rec := *raw.(*runnerRecord)
+	now := s.clock.Now()
+	if !rec.LastSeen.IsZero() {
+		s.metrics.runnerHeartbeat.observeDuration(now.Sub(rec.LastSeen))
+	}
+	rec.LastSeen = now
+	timeout := s.runnerHeartbeatTimeoutGet()
+	if rec.LivenessTimer == nil {
+		rec.LivenessTimer = s.timers.AfterFunc(timeout, func() {
+			s.runnerExpire(id)
+		})
+	} else {
+		rec.LivenessTimer.Reset(timeout)
+	}
+
+	if err := txn.Insert(runnerTableName, &rec); err != nil {
+		rec.LivenessTimer.Stop()
+		return status.Errorf(codes.Aborted, err.Error())
+	}
+
+	txn.Commit()
+	return nil
+}
+
+// RunnerLastSeen returns the time of the last heartbeat from the runner
+// with the given ID. This is zero if the runner hasn't sent a heartbeat.
+func (s *State) RunnerLastSeen(id string) (time.Time, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	raw, err := memTxn.First(runnerTableName, runnerIdIndexName, id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if raw == nil {
+		return time.Time{}, status.Errorf(codes.NotFound, "runner ID not found")
+	}
+
+	return raw.(*runnerRecord).LastSeen, nil
+}
+
+// runnerExpire deregisters the runner with the given ID if it hasn't sent
+// a heartbeat within the timeout and queues again the jobs assigned to it
+// that it hasn't accepted. This is called by the runner's liveness timer.
+func (s *State) runnerExpire(id string) {
+	txn := s.inmemWriteTxn()
+	defer txn.Abort()
+
+	raw, err := txn.First(runnerTableName, runnerIdIndexName, id)
+	if err != nil {
+		s.log.Warn("error reading runner to expire", "runner", id, "err", err)
+		return
+	}
+	if raw == nil {
+		return
+	}
+	rec := raw.(*runnerRecord)
+
+	// A heartbeat may have raced with the timer. If so we wait again.
+	timeout := s.runnerHeartbeatTimeoutGet()
+	if d := rec.LastSeen.Add(timeout).Sub(s.clock.Now()); d > 0 {
+		rec.LivenessTimer.Reset(d)
+		return
+	}
+
+	waiting, err := s.runnerJobsWaiting(txn, id)
+	if err != nil {
+		s.log.Warn("error finding jobs of runner to expire", "runner", id, "err", err)
+		return
+	}
+
+	if err := s.runnerDelete(txn, id); err != nil {
+		s.log.Warn("error deregistering expired runner", "runner", id, "err", err)
+		return
+	}
+	txn.Commit()
+	atomic.AddUint64(&s.metrics.runnerHeartbeatsMissed, 1)
+
+	s.log.Info("runner heartbeat timer expired, deregistered runner",
+		"runner", id, "timeout", timeout)
+	for _, jobId := range waiting {
+		if _, err := s.JobNack(jobId, jobRunnerExpiredReason(id)); err != nil &&
+			status.Code(err) != codes.FailedPrecondition {
+			s.log.Warn("error queueing job of expired runner",
+				"runner", id, "job", jobId, "err", err)
+		}
+	}
+}
+
+// runnerJobsWaiting returns the IDs of the jobs assigned to the runner with
+// the given ID that it hasn't accepted yet.
+func (s *State) runnerJobsWaiting(memTxn *memdb.Txn, id string) ([]string, error) {
+	jobs, err := jobsByState(memTxn, pb.Job_WAITING)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, idx := range jobs {
+		jobpb, err := s.jobByIdCached(idx.Id)
+		if err != nil {
+			return nil, err
+		}
+		if jobpb.AssignedRunner == nil || jobpb.AssignedRunner.Id != id {
+			continue
+		}
+
+		result = append(result, idx.Id)
+	}
+
+	return result, nil
+}
+
+// jobRunnerExpiredReason is the reason a job wasn't accepted if the runner
+// it was assigned to stopped sending heartbeats.
+func jobRunnerExpiredReason(id string) string {
+	return fmt.Sprintf("runner %s stopped sending heartbeats", id)
+}
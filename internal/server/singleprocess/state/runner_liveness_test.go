@@ -0,0 +1,125 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestRunnerHeartbeat(t *testing.T) {
+	ctx := context.Background()
+
+	// registered returns true if the runner is registered
+	registered := func(t *testing.T, s *State, id string) func() bool {
+		return func() bool {
+			_, err := s.RunnerById(id)
+			if status.Code(err) == codes.NotFound {
+				return false
+			}
+			require.NoError(t, err)
+			return true
+		}
+	}
+
+	t.Run("runners without heartbeats don't expire", func(t *testing.T) {
+		require := require.New(t)
+
+		clock := TestClockNew()
+		s := TestState(t, WithClock(clock))
+		defer s.Close()
+
+		require.NoError(s.RunnerCreate(serverptypes.TestRunner(t, &pb.Runner{Id: "R_A"})))
+		clock.Advance(time.Hour)
+		time.Sleep(50 * time.Millisecond)
+		require.True(registered(t, s, "R_A")())
+
+		lastSeen, err := s.RunnerLastSeen("R_A")
+		require.NoError(err)
+		require.True(lastSeen.IsZero())
+	})
+
+	t.Run("runners are deregistered when heartbeats stop", func(t *testing.T) {
+		require := require.New(t)
+
+		clock := TestClockNew()
+		s := TestState(t, WithClock(clock))
+		defer s.Close()
+
+		require.NoError(s.RunnerCreate(serverptypes.TestRunner(t, &pb.Runner{Id: "R_A"})))
+		require.NoError(s.RunnerHeartbeat("R_A"))
+
+		lastSeen, err := s.RunnerLastSeen("R_A")
+		require.NoError(err)
+		require.Equal(clock.Now(), lastSeen)
+
+		// Heartbeats keep it registered
+		clock.Advance(40 * time.Second)
+		require.NoError(s.RunnerHeartbeat("R_A"))
+		clock.Advance(40 * time.Second)
+		time.Sleep(50 * time.Millisecond)
+		require.True(registered(t, s, "R_A")())
+
+		// Draining doesn't reset the timer
+		_, err = s.RunnerDrain("R_A")
+		require.NoError(err)
+
+		clock.Advance(40 * time.Second)
+		require.Eventually(func() bool {
+			return !registered(t, s, "R_A")()
+		}, 5*time.Second, 10*time.Millisecond)
+
+		err = s.RunnerHeartbeat("R_A")
+		require.Error(err)
+		require.Equal(codes.NotFound, status.Code(err))
+	})
+
+	t.Run("waiting jobs are queued again", func(t *testing.T) {
+		require := require.New(t)
+
+		clock := TestClockNew()
+		s := TestState(t, WithClock(clock))
+		defer s.Close()
+		s.RunnerHeartbeatTimeoutSet(10 * time.Second)
+
+		r := serverptypes.TestRunner(t, &pb.Runner{Id: "R_A"})
+		require.NoError(s.RunnerCreate(r))
+		require.NoError(s.RunnerHeartbeat("R_A"))
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "B"})))
+		job, err := s.JobAssignForRunner(ctx, r)
+		require.NoError(err)
+		require.Equal("A", job.Id)
+
+		// Running jobs are left to their heartbeat timeout
+		_, err = s.JobAck(ctx, "A", true)
+		require.NoError(err)
+		job, err = s.JobAssignForRunner(ctx, r)
+		require.NoError(err)
+		require.Equal("B", job.Id)
+
+		clock.Advance(10 * time.Second)
+		require.Eventually(func() bool {
+			job, err := s.JobById("B", nil)
+			require.NoError(err)
+			return job.State == pb.Job_QUEUED
+		}, 5*time.Second, 10*time.Millisecond)
+		require.False(registered(t, s, "R_A")())
+
+		job, err = s.JobById("B", nil)
+		require.NoError(err)
+		require.Len(job.Assignments, 1)
+		require.Equal(jobRunnerExpiredReason("R_A"), job.Assignments[0].NackReason)
+
+		job, err = s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_RUNNING, job.State)
+	})
+}
@@ -0,0 +1,81 @@
+package state
+
+import (
+	"strings"
+
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to runner profiles. Profiles are named
+// configuration for runners launched on demand. They're only read when
+// jobs are queued and runners are launched so they're not indexed in
+// memory.
+
+var runnerProfileBucket = []byte("runner_profile")
+
+func init() {
+	dbBuckets = append(dbBuckets, runnerProfileBucket)
+}
+
+// RunnerProfilePut creates or updates a runner profile.
+func (s *State) RunnerProfilePut(p *pb.RunnerProfile) error {
+	if p.Name == "" {
+		return status.Errorf(codes.FailedPrecondition, "profile name must be set")
+	}
+
+	return s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		return dbPut(dbTxn.Bucket(runnerProfileBucket), runnerProfileId(p.Name), p)
+	})
+}
+
+// RunnerProfileGet gets a runner profile by name.
+func (s *State) RunnerProfileGet(name string) (*pb.RunnerProfile, error) {
+	var result pb.RunnerProfile
+	err := s.dbView(func(dbTxn *bolt.Tx) error {
+		return dbGet(dbTxn.Bucket(runnerProfileBucket), runnerProfileId(name), &result)
+	})
+	if status.Code(err) == codes.NotFound {
+		return nil, status.Errorf(codes.NotFound, "runner profile not found: %s", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// RunnerProfileList returns all the runner profiles sorted by name.
+func (s *State) RunnerProfileList() ([]*pb.RunnerProfile, error) {
+	var result []*pb.RunnerProfile
+	err := s.dbView(func(dbTxn *bolt.Tx) error {
+		return dbTxn.Bucket(runnerProfileBucket).ForEach(func(k, v []byte) error {
+			var value pb.RunnerProfile
+			if err := proto.Unmarshal(v, &value); err != nil {
+				return err
+			}
+
+			result = append(result, &value)
+			return nil
+		})
+	})
+
+	return result, err
+}
+
+// RunnerProfileDelete deletes a runner profile by name. Deleting a profile
+// that doesn't exist does nothing. Jobs that name the profile stay queued
+// until a runner with the profile is available.
+func (s *State) RunnerProfileDelete(name string) error {
+	return s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		return dbDelete(dbTxn.Bucket(runnerProfileBucket), runnerProfileId(name))
+	})
+}
+
+func runnerProfileId(name string) []byte {
+	return []byte(strings.ToLower(name))
+}
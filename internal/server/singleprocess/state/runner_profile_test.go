@@ -0,0 +1,50 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func TestRunnerProfile(t *testing.T) {
+	require := require.New(t)
+
+	s := TestState(t)
+	defer s.Close()
+
+	// Get non-existent
+	_, err := s.RunnerProfileGet("gpu")
+	require.Error(err)
+	require.Equal(codes.NotFound, status.Code(err))
+
+	// Name is required
+	require.Error(s.RunnerProfilePut(&pb.RunnerProfile{}))
+
+	// Create
+	require.NoError(s.RunnerProfilePut(&pb.RunnerProfile{
+		Name:   "GPU",
+		OciUrl: "example.com/runner:gpu",
+		Arch:   "amd64",
+	}))
+	require.NoError(s.RunnerProfilePut(&pb.RunnerProfile{Name: "arm", Arch: "arm64"}))
+
+	// Names are case insensitive
+	p, err := s.RunnerProfileGet("gpu")
+	require.NoError(err)
+	require.Equal("example.com/runner:gpu", p.OciUrl)
+
+	// List
+	list, err := s.RunnerProfileList()
+	require.NoError(err)
+	require.Len(list, 2)
+	require.Equal("arm", list[0].Name)
+
+	// Delete
+	require.NoError(s.RunnerProfileDelete("gpu"))
+	_, err = s.RunnerProfileGet("GPU")
+	require.Equal(codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,219 @@
+package state
+
+import (
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hashicorp/go-memdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to the stats of the jobs that each
+// runner ran. Runners are only kept in memory, but their stats are
+// persisted so that runners that fail often can be found even after they
+// are restarted or the server is.
+
+var runnerStatsBucket = []byte("runner_stats")
+
+// runnerStatsRecentJobs is the number of recent jobs that are kept in the
+// stats of a runner.
+const runnerStatsRecentJobs = 10
+
+func init() {
+	dbBuckets = append(dbBuckets, runnerStatsBucket)
+	dbIndexers = append(dbIndexers, (*State).runnerStatsIndexInit)
+	schemas = append(schemas, runnerStatsIndexSchema)
+}
+
+// RunnerStatsGet gets the stats of the runner with the given ID. This will
+// return a NotFound error if the runner hasn't run any jobs.
+func (s *State) RunnerStatsGet(runnerId string) (*pb.RunnerStats, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	raw, err := memTxn.First(runnerStatsIndexTableName, runnerStatsIndexIdIndexName, runnerId)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, status.Errorf(codes.NotFound, "runner stats not found: %s", runnerId)
+	}
+
+	return runnerStatsResult(raw.(*runnerStatsIndexRecord).Stats), nil
+}
+
+// RunnerStatsList lists the stats of every runner that ran a job, sorted
+// by runner ID.
+func (s *State) RunnerStatsList() ([]*pb.RunnerStats, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	iter, err := memTxn.Get(runnerStatsIndexTableName, runnerStatsIndexIdIndexName)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*pb.RunnerStats
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		result = append(result, runnerStatsResult(raw.(*runnerStatsIndexRecord).Stats))
+	}
+
+	return result, nil
+}
+
+// runnerStatsJobCompleted adds the completed job with the given ID to the
+// stats of the runner it was assigned to. Jobs that never ran on a runner,
+// such as queued jobs that were canceled, are ignored. This must be called
+// after the transaction completing the job is committed.
+func (s *State) runnerStatsJobCompleted(id string) {
+	job, err := s.jobByIdCached(id)
+	if err != nil {
+		s.log.Warn("error reading completed job for runner stats", "job", id, "err", err)
+		return
+	}
+
+	// Jobs that weren't accepted have their assign time cleared when they
+	// are queued again.
+	if job.AssignedRunner == nil || job.AssignTime == nil {
+		return
+	}
+
+	if err := s.runnerStatsAdd(job); err != nil {
+		s.log.Warn("error updating runner stats", "job", id, "err", err)
+	}
+}
+
+// runnerStatsAdd adds the completed job to the stats of its runner.
+func (s *State) runnerStatsAdd(job *pb.Job) error {
+	// Jobs that are force canceled have no complete time.
+	end := s.clock.Now()
+	if t, err := ptypes.Timestamp(job.CompleteTime); err == nil {
+		end = t
+	} else if t, err := ptypes.Timestamp(job.CancelTime); err == nil {
+		end = t
+	}
+
+	start, err := ptypes.Timestamp(job.AckTime)
+	if err != nil {
+		if start, err = ptypes.Timestamp(job.AssignTime); err != nil {
+			return err
+		}
+	}
+
+	var duration time.Duration
+	if end.After(start) {
+		duration = end.Sub(start)
+	}
+
+	endpb, err := ptypes.TimestampProto(end)
+	if err != nil {
+		return err
+	}
+
+	memTxn := s.inmemWriteTxn()
+	defer memTxn.Abort()
+
+	err = s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		b := dbTxn.Bucket(runnerStatsBucket)
+		id := []byte(job.AssignedRunner.Id)
+
+		stats := &pb.RunnerStats{RunnerId: job.AssignedRunner.Id}
+		if v := b.Get(id); v != nil {
+			if err := proto.Unmarshal(v, stats); err != nil {
+				return err
+			}
+		}
+
+		stats.JobsRun++
+		stats.TotalDurationSeconds += duration.Seconds()
+		stats.LastJobTime = endpb
+		if job.State == pb.Job_ERROR {
+			if status.FromProto(job.Error).Code() == codes.Canceled {
+				stats.JobsCanceled++
+			} else {
+				stats.JobsFailed++
+				stats.LastFailureTime = endpb
+			}
+		}
+
+		stats.RecentJobIds = append([]string{job.Id}, stats.RecentJobIds...)
+		if len(stats.RecentJobIds) > runnerStatsRecentJobs {
+			stats.RecentJobIds = stats.RecentJobIds[:runnerStatsRecentJobs]
+		}
+
+		if err := dbPut(b, id, stats); err != nil {
+			return err
+		}
+
+		return s.runnerStatsIndexSet(memTxn, stats)
+	})
+	if err == nil {
+		memTxn.Commit()
+	}
+
+	return err
+}
+
+// runnerStatsResult returns a copy of the stats with the computed fields
+// set.
+func runnerStatsResult(stats *pb.RunnerStats) *pb.RunnerStats {
+	result := proto.Clone(stats).(*pb.RunnerStats)
+	if result.JobsRun > 0 {
+		result.AverageDurationSeconds = result.TotalDurationSeconds / float64(result.JobsRun)
+	}
+
+	return result
+}
+
+// runnerStatsIndexSet writes an index record for the stats of a runner.
+func (s *State) runnerStatsIndexSet(txn *memdb.Txn, value *pb.RunnerStats) error {
+	return txn.Insert(runnerStatsIndexTableName, &runnerStatsIndexRecord{
+		Id:    value.RunnerId,
+		Stats: value,
+	})
+}
+
+// runnerStatsIndexInit initializes the runner stats index from persisted
+// data.
+func (s *State) runnerStatsIndexInit(dbTxn *bolt.Tx, memTxn *memdb.Txn) error {
+	bucket := dbTxn.Bucket(runnerStatsBucket)
+	return bucket.ForEach(func(k, v []byte) error {
+		var value pb.RunnerStats
+		if err := proto.Unmarshal(v, &value); err != nil {
+			return err
+		}
+
+		return s.runnerStatsIndexSet(memTxn, &value)
+	})
+}
+
+func runnerStatsIndexSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: runnerStatsIndexTableName,
+		Indexes: map[string]*memdb.IndexSchema{
+			runnerStatsIndexIdIndexName: {
+				Name:         runnerStatsIndexIdIndexName,
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field: "Id",
+				},
+			},
+		},
+	}
+}
+
+const (
+	runnerStatsIndexTableName   = "runner-stats-index"
+	runnerStatsIndexIdIndexName = "id"
+)
+
+type runnerStatsIndexRecord struct {
+	Id    string
+	Stats *pb.RunnerStats
+}
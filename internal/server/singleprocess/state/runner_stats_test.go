@@ -0,0 +1,72 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestRunnerStats(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	clock := TestClockNew()
+	s := TestState(t, WithClock(clock))
+	defer s.Close()
+
+	r := serverptypes.TestRunner(t, &pb.Runner{Id: "R_A"})
+	require.NoError(s.RunnerCreate(r))
+
+	// No stats until the runner runs a job
+	_, err := s.RunnerStatsGet("R_A")
+	require.Error(err)
+	require.Equal(codes.NotFound, status.Code(err))
+
+	// run runs the next job on the runner for the duration.
+	run := func(id string, d time.Duration, cerr error) {
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: id})))
+		job, err := s.JobAssignForRunner(ctx, r)
+		require.NoError(err)
+		require.Equal(id, job.Id)
+		_, err = s.JobAck(ctx, job.Id, true)
+		require.NoError(err)
+		clock.Advance(d)
+		require.NoError(s.JobComplete(ctx, job.Id, nil, cerr))
+	}
+
+	run("A", 10*time.Second, nil)
+	run("B", 20*time.Second, errors.New("failed"))
+	run("C", 30*time.Second, status.Error(codes.Canceled, "canceled"))
+
+	// A queued job that is canceled never ran on the runner
+	require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "D"})))
+	require.NoError(s.JobCancel("D", false, 0))
+
+	stats, err := s.RunnerStatsGet("R_A")
+	require.NoError(err)
+	require.Equal(uint64(3), stats.JobsRun)
+	require.Equal(uint64(1), stats.JobsFailed)
+	require.Equal(uint64(1), stats.JobsCanceled)
+	require.Equal(float64(60), stats.TotalDurationSeconds)
+	require.Equal(float64(20), stats.AverageDurationSeconds)
+	require.Equal([]string{"C", "B", "A"}, stats.RecentJobIds)
+	require.NotNil(stats.LastFailureTime)
+
+	list, err := s.RunnerStatsList()
+	require.NoError(err)
+	require.Len(list, 1)
+	require.Equal("R_A", list[0].RunnerId)
+
+	// Stats are kept after the runner is deleted
+	require.NoError(s.RunnerDelete("R_A"))
+	_, err = s.RunnerStatsGet("R_A")
+	require.NoError(err)
+}
@@ -1,13 +1,16 @@
 package state
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
 )
 
 func TestRunner_crud(t *testing.T) {
@@ -50,3 +53,170 @@ func TestRunnerById_notFound(t *testing.T) {
 	require.Nil(found)
 	require.Equal(codes.NotFound, status.Code(err))
 }
+
+func TestRunnerFind(t *testing.T) {
+	require := require.New(t)
+
+	s := TestState(t)
+	defer s.Close()
+
+	require.NoError(s.RunnerCreate(&pb.Runner{
+		Id:             "A",
+		Labels:         map[string]string{"env": "prod"},
+		Os:             "linux",
+		Arch:           "amd64",
+		PluginVersions: map[string]string{"docker": "0.1.0"},
+	}))
+	require.NoError(s.RunnerCreate(&pb.Runner{
+		Id:     "B",
+		Labels: map[string]string{"env": "prod", "gpu": "true"},
+		Os:     "linux",
+		Arch:   "arm64",
+		Pool:   "deploy",
+
+		ResourceTags: []string{"GPU", "kvm"},
+	}))
+	require.NoError(s.RunnerCreate(&pb.Runner{
+		Id:   "C",
+		Os:   "darwin",
+		Arch: "amd64",
+
+		ResourceTags: []string{"gpu"},
+	}))
+
+	ids := func(q *RunnerQuery) []string {
+		rs, err := s.RunnerFind(q)
+		require.NoError(err)
+
+		var result []string
+		for _, r := range rs {
+			result = append(result, r.Id)
+		}
+		return result
+	}
+
+	require.Equal([]string{"A", "B", "C"}, ids(&RunnerQuery{}))
+	require.Equal([]string{"A", "B"}, ids(&RunnerQuery{OS: "linux"}))
+	require.Equal([]string{"B"}, ids(&RunnerQuery{OS: "Linux", Arch: "arm64"}))
+	require.Equal([]string{"A", "B"}, ids(&RunnerQuery{
+		Labels: map[string]string{"env": "prod"},
+	}))
+	require.Equal([]string{"B"}, ids(&RunnerQuery{
+		Labels: map[string]string{"env": "prod", "gpu": "true"},
+	}))
+	require.Equal([]string{"A"}, ids(&RunnerQuery{Plugin: "docker"}))
+	require.Equal([]string{"B"}, ids(&RunnerQuery{Pool: "deploy"}))
+	require.Empty(ids(&RunnerQuery{Pool: "deploy", Arch: "amd64", OS: "linux"}))
+	require.Empty(ids(&RunnerQuery{OS: "darwin", Plugin: "docker"}))
+	require.Equal([]string{"B", "C"}, ids(&RunnerQuery{Tags: []string{"gpu"}}))
+	require.Equal([]string{"B"}, ids(&RunnerQuery{Tags: []string{"gpu", "KVM"}}))
+	require.Equal([]string{"C"}, ids(&RunnerQuery{Tags: []string{"gpu"}, OS: "darwin"}))
+	require.Empty(ids(&RunnerQuery{Tags: []string{"large-disk"}}))
+}
+
+func TestRunnerDrain(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("not found", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		_, err := s.RunnerDrain("nope")
+		require.Error(err)
+		require.Equal(codes.NotFound, status.Code(err))
+	})
+
+	t.Run("drained runners aren't assigned new jobs", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		r := &pb.Runner{Id: "R_A"}
+		require.NoError(s.RunnerCreate(r))
+
+		// A job assigned before the drain is finished
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		job, err := s.JobAssignForRunner(ctx, r)
+		require.NoError(err)
+		require.Equal("A", job.Id)
+
+		drained, err := s.RunnerDrain(r.Id)
+		require.NoError(err)
+		require.True(drained.Draining)
+		require.False(r.Draining)
+
+		_, err = s.JobAck(ctx, "A", true)
+		require.NoError(err)
+		require.NoError(s.JobComplete(ctx, "A", nil, nil))
+
+		// New jobs aren't assigned and the runner isn't a candidate
+		jobB := serverptypes.TestJobNew(t, &pb.Job{Id: "B"})
+		require.NoError(s.JobCreate(ctx, jobB))
+
+		assignable, err := s.JobIsAssignable(ctx, jobB)
+		require.NoError(err)
+		require.False(assignable)
+
+		actx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		_, err = s.JobAssignForRunner(actx, drained)
+		cancel()
+		require.Equal(context.DeadlineExceeded, err)
+
+		// A runner waiting for jobs is assigned once it's undrained
+		doneCh := make(chan *Job, 1)
+		go func() {
+			job, _ := s.JobAssignForRunner(ctx, drained)
+			doneCh <- job
+		}()
+
+		time.Sleep(50 * time.Millisecond)
+		undrained, err := s.RunnerUndrain(r.Id)
+		require.NoError(err)
+		require.False(undrained.Draining)
+
+		select {
+		case job := <-doneCh:
+			require.NotNil(job)
+			require.Equal("B", job.Id)
+
+		case <-time.After(1 * time.Second):
+			t.Fatal("undrained runner should be assigned the job")
+		}
+	})
+
+	t.Run("waiting runners stop waiting when drained", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		r := &pb.Runner{Id: "R_A"}
+		require.NoError(s.RunnerCreate(r))
+		require.NoError(s.RunnerCreate(&pb.Runner{Id: "R_B"}))
+
+		// R_A waits for a job and is drained while waiting
+		doneCh := make(chan *Job, 1)
+		actx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+		defer cancel()
+		go func() {
+			job, _ := s.JobAssignForRunner(actx, r)
+			doneCh <- job
+		}()
+
+		time.Sleep(50 * time.Millisecond)
+		_, err := s.RunnerDrain(r.Id)
+		require.NoError(err)
+		time.Sleep(50 * time.Millisecond)
+
+		// The job is assigned to the other runner instead
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_B"})
+		require.NoError(err)
+		require.Equal("A", job.Id)
+
+		require.Nil(<-doneCh)
+	})
+}
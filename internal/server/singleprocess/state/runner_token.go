@@ -0,0 +1,151 @@
+package state
+
+import (
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hashicorp/go-memdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+var runnerTokenBucket = []byte("runner_tokens")
+
+func init() {
+	dbBuckets = append(dbBuckets, runnerTokenBucket)
+	dbIndexers = append(dbIndexers, (*State).runnerTokenIndexInit)
+	schemas = append(schemas, runnerTokenIndexSchema)
+}
+
+// RunnerTokenPut creates or updates the token of a runner.
+func (s *State) RunnerTokenPut(t *pb.RunnerToken) error {
+	memTxn := s.inmemWriteTxn()
+	defer memTxn.Abort()
+
+	err := s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		b := dbTxn.Bucket(runnerTokenBucket)
+
+		t = proto.Clone(t).(*pb.RunnerToken)
+		if err := dbPut(b, []byte(t.RunnerId), t); err != nil {
+			return err
+		}
+
+		return s.runnerTokenIndexSet(memTxn, t)
+	})
+	if err == nil {
+		memTxn.Commit()
+	}
+
+	return err
+}
+
+// RunnerTokenGet gets the token of the runner with the given ID. This will
+// return a NotFound error if the runner has no token.
+func (s *State) RunnerTokenGet(runnerId string) (*pb.RunnerToken, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	raw, err := memTxn.First(runnerTokenIndexTableName, runnerTokenIndexIdIndexName, runnerId)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, status.Errorf(codes.NotFound, "runner token not found: %s", runnerId)
+	}
+
+	return raw.(*runnerTokenIndexRecord).Token, nil
+}
+
+// RunnerTokenList lists the runner tokens sorted by runner ID.
+func (s *State) RunnerTokenList() ([]*pb.RunnerToken, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	iter, err := memTxn.Get(runnerTokenIndexTableName, runnerTokenIndexIdIndexName)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*pb.RunnerToken
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		result = append(result, raw.(*runnerTokenIndexRecord).Token)
+	}
+
+	return result, nil
+}
+
+// RunnerTokenDelete deletes the token of a runner. This returns a NotFound
+// error if the runner has no token.
+func (s *State) RunnerTokenDelete(runnerId string) error {
+	memTxn := s.inmemWriteTxn()
+	defer memTxn.Abort()
+
+	err := s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		b := dbTxn.Bucket(runnerTokenBucket)
+		if b.Get([]byte(runnerId)) == nil {
+			return status.Errorf(codes.NotFound, "runner token not found: %s", runnerId)
+		}
+
+		if err := dbDelete(b, []byte(runnerId)); err != nil {
+			return err
+		}
+
+		_, err := memTxn.DeleteAll(runnerTokenIndexTableName, runnerTokenIndexIdIndexName, runnerId)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	memTxn.Commit()
+
+	return nil
+}
+
+// runnerTokenIndexSet writes an index record for a single runner token.
+func (s *State) runnerTokenIndexSet(txn *memdb.Txn, value *pb.RunnerToken) error {
+	return txn.Insert(runnerTokenIndexTableName, &runnerTokenIndexRecord{
+		Id:    value.RunnerId,
+		Token: value,
+	})
+}
+
+// runnerTokenIndexInit initializes the runner token index from persisted
+// data.
+func (s *State) runnerTokenIndexInit(dbTxn *bolt.Tx, memTxn *memdb.Txn) error {
+	bucket := dbTxn.Bucket(runnerTokenBucket)
+	return bucket.ForEach(func(k, v []byte) error {
+		var value pb.RunnerToken
+		if err := proto.Unmarshal(v, &value); err != nil {
+			return err
+		}
+
+		return s.runnerTokenIndexSet(memTxn, &value)
+	})
+}
+
+func runnerTokenIndexSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: runnerTokenIndexTableName,
+		Indexes: map[string]*memdb.IndexSchema{
+			runnerTokenIndexIdIndexName: {
+				Name:         runnerTokenIndexIdIndexName,
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field: "Id",
+				},
+			},
+		},
+	}
+}
+
+const (
+	runnerTokenIndexTableName   = "runner-token-index"
+	runnerTokenIndexIdIndexName = "id"
+)
+
+type runnerTokenIndexRecord struct {
+	Id    string
+	Token *pb.RunnerToken
+}
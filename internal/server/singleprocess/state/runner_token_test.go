@@ -0,0 +1,65 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func TestRunnerToken(t *testing.T) {
+	t.Run("Put, Get, List, and Delete", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		// Get non-existent
+		_, err := s.RunnerTokenGet("B")
+		require.Error(err)
+		require.Equal(codes.NotFound, status.Code(err))
+
+		// Create
+		require.NoError(s.RunnerTokenPut(&pb.RunnerToken{
+			RunnerId: "B", TokenId: []byte("1"), CreatedAt: ptypes.TimestampNow()}))
+		require.NoError(s.RunnerTokenPut(&pb.RunnerToken{
+			RunnerId: "A", TokenId: []byte("2"), CreatedAt: ptypes.TimestampNow()}))
+
+		{
+			resp, err := s.RunnerTokenGet("B")
+			require.NoError(err)
+			require.Equal([]byte("1"), resp.TokenId)
+		}
+
+		// Update
+		require.NoError(s.RunnerTokenPut(&pb.RunnerToken{RunnerId: "B", TokenId: []byte("3")}))
+		{
+			resp, err := s.RunnerTokenGet("B")
+			require.NoError(err)
+			require.Equal([]byte("3"), resp.TokenId)
+		}
+
+		// List
+		{
+			resp, err := s.RunnerTokenList()
+			require.NoError(err)
+			require.Len(resp, 2)
+			require.Equal("A", resp[0].RunnerId)
+			require.Equal("B", resp[1].RunnerId)
+		}
+
+		// Delete
+		require.NoError(s.RunnerTokenDelete("B"))
+		_, err = s.RunnerTokenGet("B")
+		require.Error(err)
+		require.Equal(codes.NotFound, status.Code(err))
+
+		err = s.RunnerTokenDelete("B")
+		require.Error(err)
+		require.Equal(codes.NotFound, status.Code(err))
+	})
+}
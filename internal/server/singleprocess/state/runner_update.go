@@ -0,0 +1,370 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hashicorp/go-memdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the methods related to runner self-updates. Operators
+// publish the version that runners should run, which is sent to runners
+// with their config. Runners of another version that can update
+// themselves report that they're updating, which drains them, and then
+// register again with the new version.
+//
+// The rollout state of each runner is persisted by runner ID so that it
+// survives the runner reconnecting after its update. The states are
+// reset whenever the published version changes.
+
+var (
+	runnerVersionBucket = []byte("runner_version")
+	runnerVersionKey    = []byte("desired")
+	runnerUpdateBucket  = []byte("runner_update")
+)
+
+func init() {
+	dbBuckets = append(dbBuckets, runnerVersionBucket, runnerUpdateBucket)
+	dbIndexers = append(dbIndexers, (*State).runnerVersionIndexInit)
+	schemas = append(schemas, runnerVersionIndexSchema)
+}
+
+// RunnerVersionSet publishes the version that runners should run. The
+// rollout state of every registered runner is reset: runners that have
+// the version are updated and the rest are pending. An empty version
+// stops the rollout.
+func (s *State) RunnerVersionSet(version string) error {
+	version = strings.TrimSpace(version)
+
+	memTxn := s.inmemWriteTxn()
+	defer memTxn.Abort()
+
+	now, err := ptypes.TimestampProto(s.clock.Now())
+	if err != nil {
+		return err
+	}
+
+	err = s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		vb := dbTxn.Bucket(runnerVersionBucket)
+		if version == "" {
+			if err := dbDelete(vb, runnerVersionKey); err != nil {
+				return err
+			}
+		} else if err := dbPutRaw(vb, runnerVersionKey, []byte(version)); err != nil {
+			return status.Errorf(codes.Aborted, "failed to write data: %s", err)
+		}
+
+		// Runners that were drained for the previous rollout can take
+		// jobs again.
+		old, err := runnerUpdateList(dbTxn)
+		if err != nil {
+			return err
+		}
+		b := dbTxn.Bucket(runnerUpdateBucket)
+		for _, u := range old {
+			if u.Drained {
+				if _, err := s.runnerDrainSetTxn(memTxn, u.RunnerId, false); err != nil &&
+					status.Code(err) != codes.NotFound {
+					return err
+				}
+			}
+
+			if err := dbDelete(b, []byte(u.RunnerId)); err != nil {
+				return err
+			}
+		}
+
+		if version != "" {
+			iter, err := memTxn.Get(runnerTableName, runnerIdIndexName+"_prefix", "")
+			if err != nil {
+				return err
+			}
+			for raw := iter.Next(); raw != nil; raw = iter.Next() {
+				r := raw.(*runnerRecord).Runner
+				u := &pb.RunnerUpdate{
+					RunnerId:    r.Id,
+					State:       pb.RunnerUpdate_PENDING,
+					FromVersion: r.Version,
+					ToVersion:   version,
+					UpdateTime:  now,
+				}
+				if runnerVersionEqual(r.Version, version) {
+					u.State = pb.RunnerUpdate_UPDATED
+				}
+
+				if err := dbPut(b, []byte(u.RunnerId), u); err != nil {
+					return err
+				}
+			}
+		}
+
+		return memTxn.Insert(runnerVersionIndexTableName, &runnerVersionIndexRecord{
+			Id:      string(runnerVersionKey),
+			Version: version,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	memTxn.Commit()
+
+	return nil
+}
+
+// RunnerVersionGet returns the published runner version and the rollout
+// state of each runner sorted by runner ID.
+func (s *State) RunnerVersionGet() (string, []*pb.RunnerUpdate, error) {
+	version, err := s.RunnerVersionWatch(nil)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var result []*pb.RunnerUpdate
+	err = s.dbView(func(dbTxn *bolt.Tx) error {
+		result, err = runnerUpdateList(dbTxn)
+		return err
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return version, result, nil
+}
+
+// RunnerVersionWatch returns the published runner version. The watch set
+// is notified when it changes.
+func (s *State) RunnerVersionWatch(ws memdb.WatchSet) (string, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	ch, raw, err := memTxn.FirstWatch(
+		runnerVersionIndexTableName, runnerVersionIndexIdIndexName, string(runnerVersionKey))
+	if err != nil {
+		return "", err
+	}
+	ws.Add(ch)
+
+	if raw == nil {
+		return "", nil
+	}
+
+	return raw.(*runnerVersionIndexRecord).Version, nil
+}
+
+// RunnerUpdateRegister updates the rollout state of a runner that just
+// registered. A runner with the published version is updated. A runner
+// that registers again with another version after it started updating
+// failed to update.
+func (s *State) RunnerUpdateRegister(r *pb.Runner) error {
+	version, err := s.RunnerVersionWatch(nil)
+	if err != nil || version == "" {
+		return err
+	}
+
+	now, err := ptypes.TimestampProto(s.clock.Now())
+	if err != nil {
+		return err
+	}
+
+	return s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		b := dbTxn.Bucket(runnerUpdateBucket)
+
+		var old pb.RunnerUpdate
+		err := dbGet(b, []byte(r.Id), &old)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		found := err == nil
+
+		u := &pb.RunnerUpdate{
+			RunnerId:    r.Id,
+			State:       pb.RunnerUpdate_PENDING,
+			FromVersion: r.Version,
+			ToVersion:   version,
+			UpdateTime:  now,
+		}
+		if found {
+			u.FromVersion = old.FromVersion
+		}
+
+		switch {
+		case runnerVersionEqual(r.Version, version):
+			u.State = pb.RunnerUpdate_UPDATED
+
+		case found && old.State == pb.RunnerUpdate_UPDATING:
+			u.State = pb.RunnerUpdate_FAILED
+			u.Error = fmt.Sprintf("runner registered again with version %s", r.Version)
+
+		case found && old.State == pb.RunnerUpdate_FAILED:
+			// The runner stays failed until it tries to update again.
+			return nil
+		}
+
+		return dbPut(b, []byte(u.RunnerId), u)
+	})
+}
+
+// RunnerUpdateStateSet records the rollout state a runner reported. A
+// runner that is updating is drained so that it can finish its jobs, and
+// is undrained if its update fails.
+func (s *State) RunnerUpdateStateSet(id string, st pb.RunnerUpdate_State, errMsg string) error {
+	if st != pb.RunnerUpdate_UPDATING && st != pb.RunnerUpdate_FAILED {
+		return status.Errorf(codes.InvalidArgument,
+			"runners can only report the updating or failed update states")
+	}
+
+	version, err := s.RunnerVersionWatch(nil)
+	if err != nil {
+		return err
+	}
+	if version == "" {
+		return status.Errorf(codes.FailedPrecondition, "no runner version is published")
+	}
+
+	now, err := ptypes.TimestampProto(s.clock.Now())
+	if err != nil {
+		return err
+	}
+
+	memTxn := s.inmemWriteTxn()
+	defer memTxn.Abort()
+
+	raw, err := memTxn.First(runnerTableName, runnerIdIndexName, id)
+	if err != nil {
+		return err
+	}
+	if raw == nil {
+		return status.Errorf(codes.NotFound, "runner ID not found")
+	}
+	r := raw.(*runnerRecord).Runner
+
+	err = s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		b := dbTxn.Bucket(runnerUpdateBucket)
+
+		var old pb.RunnerUpdate
+		if err := dbGet(b, []byte(id), &old); err != nil {
+			if status.Code(err) != codes.NotFound {
+				return err
+			}
+
+			old.FromVersion = r.Version
+		}
+
+		u := &pb.RunnerUpdate{
+			RunnerId:    id,
+			State:       st,
+			FromVersion: old.FromVersion,
+			ToVersion:   version,
+			UpdateTime:  now,
+			Drained:     old.Drained,
+		}
+
+		switch st {
+		case pb.RunnerUpdate_UPDATING:
+			// We only undrain the runner later if we drained it.
+			if !r.Draining {
+				if _, err := s.runnerDrainSetTxn(memTxn, id, true); err != nil {
+					return err
+				}
+
+				u.Drained = true
+			}
+
+		case pb.RunnerUpdate_FAILED:
+			u.Error = errMsg
+			if old.Drained {
+				if _, err := s.runnerDrainSetTxn(memTxn, id, false); err != nil {
+					return err
+				}
+
+				u.Drained = false
+			}
+		}
+
+		return dbPut(b, []byte(id), u)
+	})
+	if err != nil {
+		return err
+	}
+	memTxn.Commit()
+
+	return nil
+}
+
+// runnerUpdateList returns the rollout states of the runners sorted by
+// runner ID.
+func runnerUpdateList(dbTxn *bolt.Tx) ([]*pb.RunnerUpdate, error) {
+	var result []*pb.RunnerUpdate
+	err := dbTxn.Bucket(runnerUpdateBucket).ForEach(func(k, v []byte) error {
+		var value pb.RunnerUpdate
+		if err := proto.Unmarshal(v, &value); err != nil {
+			return err
+		}
+
+		result = append(result, &value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].RunnerId < result[j].RunnerId
+	})
+
+	return result, nil
+}
+
+// runnerVersionEqual returns true if the versions are the same, ignoring
+// a "v" prefix.
+func runnerVersionEqual(a, b string) bool {
+	return strings.TrimPrefix(a, "v") == strings.TrimPrefix(b, "v")
+}
+
+// runnerVersionIndexInit initializes the runner version index from
+// persisted data.
+func (s *State) runnerVersionIndexInit(dbTxn *bolt.Tx, memTxn *memdb.Txn) error {
+	data := dbTxn.Bucket(runnerVersionBucket).Get(runnerVersionKey)
+	if data == nil {
+		return nil
+	}
+
+	return memTxn.Insert(runnerVersionIndexTableName, &runnerVersionIndexRecord{
+		Id:      string(runnerVersionKey),
+		Version: string(data),
+	})
+}
+
+func runnerVersionIndexSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: runnerVersionIndexTableName,
+		Indexes: map[string]*memdb.IndexSchema{
+			runnerVersionIndexIdIndexName: {
+				Name:         runnerVersionIndexIdIndexName,
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field: "Id",
+				},
+			},
+		},
+	}
+}
+
+const (
+	runnerVersionIndexTableName   = "runner-version-index"
+	runnerVersionIndexIdIndexName = "id"
+)
+
+type runnerVersionIndexRecord struct {
+	Id      string
+	Version string
+}
@@ -0,0 +1,174 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-memdb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestRunnerVersion(t *testing.T) {
+	// updates returns the rollout state of each runner by ID.
+	updates := func(t *testing.T, s *State) map[string]*pb.RunnerUpdate {
+		version, list, err := s.RunnerVersionGet()
+		require.NoError(t, err)
+		require.Equal(t, "0.3.0", version)
+
+		result := map[string]*pb.RunnerUpdate{}
+		for _, u := range list {
+			result[u.RunnerId] = u
+		}
+
+		return result
+	}
+
+	t.Run("set resets the rollout", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.RunnerCreate(serverptypes.TestRunner(t, &pb.Runner{Id: "R_A", Version: "0.2.0"})))
+		require.NoError(s.RunnerCreate(serverptypes.TestRunner(t, &pb.Runner{Id: "R_B", Version: "v0.3.0"})))
+
+		ws := memdb.NewWatchSet()
+		version, err := s.RunnerVersionWatch(ws)
+		require.NoError(err)
+		require.Empty(version)
+
+		require.NoError(s.RunnerVersionSet("0.3.0"))
+		require.False(ws.Watch(time.After(3 * time.Second)))
+
+		u := updates(t, s)
+		require.Len(u, 2)
+		require.Equal(pb.RunnerUpdate_PENDING, u["R_A"].State)
+		require.Equal("0.2.0", u["R_A"].FromVersion)
+		require.Equal(pb.RunnerUpdate_UPDATED, u["R_B"].State)
+
+		// The version persists
+		s = TestStateReinit(t, s)
+		defer s.Close()
+		version, err = s.RunnerVersionWatch(nil)
+		require.NoError(err)
+		require.Equal("0.3.0", version)
+
+		// Clearing the version stops the rollout
+		require.NoError(s.RunnerVersionSet(""))
+		version, list, err := s.RunnerVersionGet()
+		require.NoError(err)
+		require.Empty(version)
+		require.Empty(list)
+	})
+
+	t.Run("runner updates", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		r := serverptypes.TestRunner(t, &pb.Runner{Id: "R_A", Version: "0.2.0"})
+		require.NoError(s.RunnerCreate(r))
+		require.NoError(s.RunnerVersionSet("0.3.0"))
+
+		// Updating drains the runner
+		require.NoError(s.RunnerUpdateStateSet("R_A", pb.RunnerUpdate_UPDATING, ""))
+		actual, err := s.RunnerById("R_A")
+		require.NoError(err)
+		require.True(actual.Draining)
+		require.Equal(pb.RunnerUpdate_UPDATING, updates(t, s)["R_A"].State)
+
+		// It registers again with the new version
+		require.NoError(s.RunnerDisconnect("R_A"))
+		r = serverptypes.TestRunner(t, &pb.Runner{Id: "R_A", Version: "0.3.0"})
+		require.NoError(s.RunnerCreate(r))
+		require.NoError(s.RunnerUpdateRegister(r))
+
+		u := updates(t, s)["R_A"]
+		require.Equal(pb.RunnerUpdate_UPDATED, u.State)
+		require.Equal("0.2.0", u.FromVersion)
+		require.Equal("0.3.0", u.ToVersion)
+	})
+
+	t.Run("runner registers with its old version after updating", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		r := serverptypes.TestRunner(t, &pb.Runner{Id: "R_A", Version: "0.2.0"})
+		require.NoError(s.RunnerCreate(r))
+		require.NoError(s.RunnerVersionSet("0.3.0"))
+		require.NoError(s.RunnerUpdateStateSet("R_A", pb.RunnerUpdate_UPDATING, ""))
+
+		require.NoError(s.RunnerDisconnect("R_A"))
+		require.NoError(s.RunnerCreate(r))
+		require.NoError(s.RunnerUpdateRegister(r))
+
+		u := updates(t, s)["R_A"]
+		require.Equal(pb.RunnerUpdate_FAILED, u.State)
+		require.NotEmpty(u.Error)
+	})
+
+	t.Run("failed update undrains the runner", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.RunnerCreate(serverptypes.TestRunner(t, &pb.Runner{Id: "R_A", Version: "0.2.0"})))
+		require.NoError(s.RunnerVersionSet("0.3.0"))
+		require.NoError(s.RunnerUpdateStateSet("R_A", pb.RunnerUpdate_UPDATING, ""))
+		require.NoError(s.RunnerUpdateStateSet("R_A", pb.RunnerUpdate_FAILED, "exit status 1"))
+
+		actual, err := s.RunnerById("R_A")
+		require.NoError(err)
+		require.False(actual.Draining)
+
+		u := updates(t, s)["R_A"]
+		require.Equal(pb.RunnerUpdate_FAILED, u.State)
+		require.Equal("exit status 1", u.Error)
+	})
+
+	t.Run("operator drain is kept", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.RunnerCreate(serverptypes.TestRunner(t, &pb.Runner{Id: "R_A", Version: "0.2.0"})))
+		require.NoError(s.RunnerVersionSet("0.3.0"))
+		_, err := s.RunnerDrain("R_A")
+		require.NoError(err)
+		require.NoError(s.RunnerUpdateStateSet("R_A", pb.RunnerUpdate_UPDATING, ""))
+		require.NoError(s.RunnerUpdateStateSet("R_A", pb.RunnerUpdate_FAILED, "exit status 1"))
+
+		actual, err := s.RunnerById("R_A")
+		require.NoError(err)
+		require.True(actual.Draining)
+	})
+
+	t.Run("invalid reports", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.RunnerCreate(serverptypes.TestRunner(t, &pb.Runner{Id: "R_A"})))
+
+		// No version is published
+		err := s.RunnerUpdateStateSet("R_A", pb.RunnerUpdate_UPDATING, "")
+		require.Equal(codes.FailedPrecondition, status.Code(err))
+
+		require.NoError(s.RunnerVersionSet("0.3.0"))
+		err = s.RunnerUpdateStateSet("R_A", pb.RunnerUpdate_UPDATED, "")
+		require.Equal(codes.InvalidArgument, status.Code(err))
+		err = s.RunnerUpdateStateSet("R_B", pb.RunnerUpdate_UPDATING, "")
+		require.Equal(codes.NotFound, status.Code(err))
+	})
+}
@@ -0,0 +1,272 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-memdb"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the schedulers that choose which job a runner is assigned.
+// When a runner asks for a job, the queued jobs it can be assigned right
+// now are found for each way a job can target it: by ID, any runner, and by
+// labels. These are the candidates. The scheduler orders the candidates
+// and the runner is assigned the first one that is still valid. The
+// scheduler is set with SchedulerSet and defaults to PriorityScheduler.
+
+// Scheduler chooses the order that candidate jobs are assigned to a runner.
+type Scheduler interface {
+	// CandidateLimit is the most candidates to find for each way a job
+	// can target the runner, or zero to find all of them. Candidates are
+	// found in order of priority, highest first, and then queue time.
+	CandidateLimit() int
+
+	// Order sorts the candidates in the order the runner should be
+	// assigned them.
+	Order(r *SchedulerRunner, candidates []*SchedulerJob)
+}
+
+// SchedulerRunner is the runner that a Scheduler orders candidates for.
+type SchedulerRunner struct {
+	Runner *pb.Runner
+
+	// Available are the resources of the runner's capacity that aren't
+	// required by the jobs already assigned to it. This is nil if the
+	// runner doesn't advertise its capacity.
+	Available *pb.Resources
+}
+
+// SchedulerJob is a candidate job that a Scheduler orders.
+type SchedulerJob struct {
+	Id        string
+	Project   string
+	Priority  int32
+	QueueTime time.Time
+
+	// Resources are the resources the job requires or nil if it doesn't
+	// require any.
+	Resources *pb.Resources
+
+	// ProjectAssigned is the number of jobs of the same project that are
+	// assigned to any runner.
+	ProjectAssigned int
+
+	// Group is the runner group the job is reserved for by the
+	// WeightedScheduler, if any.
+	Group string
+
+	idx *jobIndex
+}
+
+// schedulerCandidateLimit is the number of candidates that the schedulers
+// which don't only consider the first candidates find for each way a job
+// can target a runner.
+const schedulerCandidateLimit = 64
+
+// SchedulerSet sets the scheduler used to assign jobs to runners. If sch
+// is nil the default, PriorityScheduler, is used. This should be called
+// once before the state is used.
+func (s *State) SchedulerSet(sch Scheduler) {
+	s.scheduler = sch
+}
+
+// schedulerGet returns the scheduler to use.
+func (s *State) schedulerGet() Scheduler {
+	if s.scheduler != nil {
+		return s.scheduler
+	}
+
+	return PriorityScheduler{}
+}
+
+// SchedulerByName returns the scheduler with the given name: "priority",
+// "fifo", "fair-share", or "bin-packing". An empty name is the default.
+// The "weighted" scheduler requires weights so it is created with
+// NewWeightedScheduler instead.
+func SchedulerByName(name string) (Scheduler, error) {
+	switch name {
+	case "", "priority":
+		return PriorityScheduler{}, nil
+	case "fifo":
+		return FIFOScheduler{}, nil
+	case "fair-share":
+		return FairShareScheduler{}, nil
+	case "bin-packing":
+		return BinPackingScheduler{}, nil
+	case "weighted":
+		return nil, fmt.Errorf("the weighted scheduler requires runner group weights")
+	default:
+		return nil, fmt.Errorf("unknown scheduler %q", name)
+	}
+}
+
+// schedulerOrder orders the candidates for the runner with the scheduler.
+// Candidates that are found by more than one query are only returned once.
+func (s *State) schedulerOrder(
+	memTxn *memdb.Txn,
+	sch Scheduler,
+	r *pb.Runner,
+	candidates []*jobIndex,
+) ([]*jobIndex, error) {
+	seen := map[string]struct{}{}
+	var jobs []*SchedulerJob
+	for _, idx := range candidates {
+		if _, ok := seen[idx.Id]; ok {
+			continue
+		}
+		seen[idx.Id] = struct{}{}
+
+		jobs = append(jobs, &SchedulerJob{
+			Id:        idx.Id,
+			Project:   idx.Application.Project,
+			Priority:  idx.Priority,
+			QueueTime: idx.QueueTime,
+			Resources: idx.Resources,
+			Group:     idx.WeightGroup,
+			idx:       idx,
+		})
+	}
+	if len(jobs) == 1 {
+		return []*jobIndex{jobs[0].idx}, nil
+	}
+
+	assigned, err := s.schedulerProjectAssigned(memTxn)
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		job.ProjectAssigned = assigned[job.Project]
+	}
+
+	sr := &SchedulerRunner{Runner: r}
+	if r.Capacity != nil {
+		used, err := s.runnerResourcesUsed(memTxn, r.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		sr.Available = &pb.Resources{
+			Cpu:    r.Capacity.Cpu - used.Cpu,
+			Memory: r.Capacity.Memory - used.Memory,
+		}
+	}
+
+	sch.Order(sr, jobs)
+
+	result := make([]*jobIndex, len(jobs))
+	for i, job := range jobs {
+		result[i] = job.idx
+	}
+
+	return result, nil
+}
+
+// schedulerProjectAssigned returns the number of jobs that are assigned
+// to runners by project.
+func (s *State) schedulerProjectAssigned(memTxn *memdb.Txn) (map[string]int, error) {
+	jobs, err := jobsByState(memTxn, pb.Job_WAITING, pb.Job_RUNNING)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]int{}
+	for _, idx := range jobs {
+		result[idx.Application.Project]++
+	}
+
+	return result, nil
+}
+
+// schedulerBefore returns true if a should be assigned before b by
+// priority, highest first, and then queue time. This is the order of
+// PriorityScheduler.
+func schedulerBefore(a, b *SchedulerJob) bool {
+	return a.idx.before(b.idx)
+}
+
+// PriorityScheduler assigns the job with the highest priority and then the
+// earliest queue time. This is the default.
+type PriorityScheduler struct{}
+
+func (PriorityScheduler) CandidateLimit() int { return 1 }
+
+func (PriorityScheduler) Order(r *SchedulerRunner, candidates []*SchedulerJob) {
+	sort.Slice(candidates, func(i, j int) bool {
+		return schedulerBefore(candidates[i], candidates[j])
+	})
+}
+
+// FIFOScheduler assigns the job that was queued first regardless of its
+// priority.
+type FIFOScheduler struct{}
+
+func (FIFOScheduler) CandidateLimit() int { return 0 }
+
+func (FIFOScheduler) Order(r *SchedulerRunner, candidates []*SchedulerJob) {
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if !a.QueueTime.Equal(b.QueueTime) {
+			return a.QueueTime.Before(b.QueueTime)
+		}
+
+		return a.Id < b.Id
+	})
+}
+
+// FairShareScheduler assigns the job of the project with the fewest jobs
+// assigned to runners, so that a project that queues many jobs doesn't
+// starve the others. Jobs of projects with the same number of jobs
+// assigned are ordered like PriorityScheduler.
+type FairShareScheduler struct{}
+
+func (FairShareScheduler) CandidateLimit() int { return schedulerCandidateLimit }
+
+func (FairShareScheduler) Order(r *SchedulerRunner, candidates []*SchedulerJob) {
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.ProjectAssigned != b.ProjectAssigned {
+			return a.ProjectAssigned < b.ProjectAssigned
+		}
+
+		return schedulerBefore(a, b)
+	})
+}
+
+// BinPackingScheduler assigns the job that uses the largest share of the
+// runner's available resources, so that runners are filled before jobs are
+// spread across more of them. Jobs that use the same share are ordered like
+// PriorityScheduler. If the runner doesn't advertise its capacity this is
+// the same as PriorityScheduler.
+type BinPackingScheduler struct{}
+
+func (BinPackingScheduler) CandidateLimit() int { return schedulerCandidateLimit }
+
+func (BinPackingScheduler) Order(r *SchedulerRunner, candidates []*SchedulerJob) {
+	share := func(job *SchedulerJob) float64 {
+		if r.Available == nil || job.Resources == nil {
+			return 0
+		}
+
+		var result float64
+		if r.Available.Cpu > 0 {
+			result += float64(job.Resources.Cpu) / float64(r.Available.Cpu)
+		}
+		if r.Available.Memory > 0 {
+			result += float64(job.Resources.Memory) / float64(r.Available.Memory)
+		}
+
+		return result
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if sa, sb := share(a), share(b); sa != sb {
+			return sa > sb
+		}
+
+		return schedulerBefore(a, b)
+	})
+}
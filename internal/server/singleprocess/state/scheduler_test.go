@@ -0,0 +1,216 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestScheduler(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("priority is the default", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "B", Priority: 10})))
+
+		job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		require.Equal("B", job.Id)
+	})
+
+	t.Run("fifo ignores priority", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		s.SchedulerSet(FIFOScheduler{})
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "B", Priority: 10})))
+
+		job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		require.Equal("A", job.Id)
+	})
+
+	t.Run("fair-share prefers projects with fewer assigned jobs", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		s.SchedulerSet(FairShareScheduler{})
+
+		for _, id := range []string{"A1", "A2"} {
+			require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: id})))
+		}
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id: "B1",
+			Application: &pb.Ref_Application{
+				Project:     "other",
+				Application: "app",
+			},
+		})))
+
+		job, err := s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		require.Equal("A1", job.Id)
+
+		// A1 is assigned so the other project goes next even though A2
+		// was queued first.
+		job, err = s.JobAssignForRunner(ctx, &pb.Runner{Id: "R_B"})
+		require.NoError(err)
+		require.Equal("B1", job.Id)
+	})
+
+	t.Run("bin-packing prefers the largest job that fits", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+		s.SchedulerSet(BinPackingScheduler{})
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "small",
+			Resources: &pb.Resources{Cpu: 500},
+		})))
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "large",
+			Resources: &pb.Resources{Cpu: 1500},
+		})))
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id:        "too-large",
+			Resources: &pb.Resources{Cpu: 4000},
+		})))
+
+		job, err := s.JobAssignForRunner(ctx, &pb.Runner{
+			Id:       "R_A",
+			Capacity: &pb.Resources{Cpu: 2000},
+		})
+		require.NoError(err)
+		require.Equal("large", job.Id)
+	})
+
+	t.Run("by name", func(t *testing.T) {
+		require := require.New(t)
+
+		for name, expected := range map[string]Scheduler{
+			"":            PriorityScheduler{},
+			"priority":    PriorityScheduler{},
+			"fifo":        FIFOScheduler{},
+			"fair-share":  FairShareScheduler{},
+			"bin-packing": BinPackingScheduler{},
+		} {
+			sch, err := SchedulerByName(name)
+			require.NoError(err)
+			require.Equal(expected, sch)
+		}
+
+		_, err := SchedulerByName("random")
+		require.Error(err)
+	})
+}
+
+func TestWeightedScheduler(t *testing.T) {
+	ctx := context.Background()
+
+	// assignTimeout tries to assign a job to the runner, giving up quickly.
+	assignTimeout := func(t *testing.T, s *State, r *pb.Runner) (*Job, error) {
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		return s.JobAssignForRunner(ctx, r)
+	}
+
+	init := func(t *testing.T, opts ...Option) (*State, *pb.Runner, *pb.Runner) {
+		s := TestState(t, opts...)
+		sch, err := NewWeightedScheduler("", map[string]int{"spot": 2, "on-demand": 1}, 0)
+		require.NoError(t, err)
+		s.SchedulerSet(sch)
+
+		spot := serverptypes.TestRunner(t, &pb.Runner{
+			Id:     "R_SPOT",
+			Labels: map[string]string{"group": "spot"},
+		})
+		od := serverptypes.TestRunner(t, &pb.Runner{
+			Id:     "R_OD",
+			Labels: map[string]string{"group": "on-demand"},
+		})
+		require.NoError(t, s.RunnerCreate(spot))
+		require.NoError(t, s.RunnerCreate(od))
+		return s, spot, od
+	}
+
+	t.Run("jobs are distributed by weight", func(t *testing.T) {
+		require := require.New(t)
+
+		s, spot, od := init(t)
+		defer s.Close()
+
+		for _, id := range []string{"A", "B", "C"} {
+			require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{
+				Id:        id,
+				Workspace: &pb.Ref_Workspace{Workspace: id},
+			})))
+		}
+
+		// The on-demand runner is only assigned the job reserved for it
+		job, err := assignTimeout(t, s, od)
+		require.NoError(err)
+		require.Equal("B", job.Id)
+		_, err = assignTimeout(t, s, od)
+		require.Equal(context.DeadlineExceeded, err)
+
+		for _, id := range []string{"A", "C"} {
+			job, err := assignTimeout(t, s, spot)
+			require.NoError(err)
+			require.Equal(id, job.Id)
+		}
+	})
+
+	t.Run("jobs can run on any group once the wait ends", func(t *testing.T) {
+		require := require.New(t)
+
+		clock := TestClockNew()
+		s, _, od := init(t, WithClock(clock))
+		defer s.Close()
+
+		// The first job is reserved for spot runners
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		_, err := assignTimeout(t, s, od)
+		require.Equal(context.DeadlineExceeded, err)
+
+		clock.Advance(weightedSchedulerWait)
+		job, err := assignTimeout(t, s, od)
+		require.NoError(err)
+		require.Equal("A", job.Id)
+	})
+
+	t.Run("jobs don't wait for a group without runners", func(t *testing.T) {
+		require := require.New(t)
+
+		s, _, od := init(t)
+		defer s.Close()
+		require.NoError(s.RunnerDelete("R_SPOT"))
+
+		require.NoError(s.JobCreate(ctx, serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		job, err := assignTimeout(t, s, od)
+		require.NoError(err)
+		require.Equal("A", job.Id)
+	})
+
+	t.Run("invalid weights", func(t *testing.T) {
+		_, err := NewWeightedScheduler("", map[string]int{"spot": -1}, 0)
+		require.Error(t, err)
+		_, err = NewWeightedScheduler("", map[string]int{"spot": 0}, 0)
+		require.Error(t, err)
+	})
+}
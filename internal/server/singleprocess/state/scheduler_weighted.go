@@ -0,0 +1,216 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-memdb"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the weighted scheduler. Runners are in groups by the value
+// of a runner label, such as "group=spot" and "group=on-demand", and each
+// group has a weight. When a job that targets any runner is queued it is
+// reserved for a group so that the jobs are reserved in proportion to the
+// weights of the groups, such as 80% for spot runners and 20% for
+// on-demand runners. A job isn't a candidate for runners of other groups
+// until it has been queued for the scheduler's wait, so the jobs are
+// distributed in proportion when every group has runners free to run
+// them, but no job waits long for a busy group. A job only waits while its
+// group has a registered runner that can be assigned jobs.
+//
+// The groups of queued jobs are only kept in memory. Jobs that are queued
+// when the server starts are reserved again.
+
+// weightedSchedulerWait is the default time a job waits for a runner of
+// the group it is reserved for.
+const weightedSchedulerWait = 30 * time.Second
+
+// WeightedScheduler distributes jobs that target any runner across groups
+// of runners in proportion to the weights of the groups. Candidates that
+// are reserved for the runner's group are assigned first and then ordered
+// like PriorityScheduler. Use NewWeightedScheduler to create one.
+type WeightedScheduler struct {
+	// Label is the runner label whose value is the group of a runner.
+	Label string
+
+	// Weights are the weights of each group. Groups with a zero weight
+	// aren't reserved jobs, but can still run jobs once their wait ends.
+	Weights map[string]int
+
+	// Wait is how long a job waits for a runner of the group it is
+	// reserved for.
+	Wait time.Duration
+
+	// groups are the groups with a positive weight and current is the
+	// state of the smooth weighted round-robin that reserves jobs for
+	// them.
+	lock    sync.Mutex
+	groups  []string
+	current map[string]int
+}
+
+// NewWeightedScheduler returns a WeightedScheduler for the groups of
+// runners with the given label. An empty label defaults to "group" and a
+// zero wait to 30 seconds.
+func NewWeightedScheduler(label string, weights map[string]int, wait time.Duration) (*WeightedScheduler, error) {
+	if label == "" {
+		label = "group"
+	}
+	if wait <= 0 {
+		wait = weightedSchedulerWait
+	}
+
+	var groups []string
+	for group, w := range weights {
+		if w < 0 {
+			return nil, fmt.Errorf("weight of runner group %q must not be negative", group)
+		}
+		if w > 0 {
+			groups = append(groups, group)
+		}
+	}
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("the weighted scheduler requires a runner group with a positive weight")
+	}
+	sort.Strings(groups)
+
+	return &WeightedScheduler{
+		Label:   label,
+		Weights: weights,
+		Wait:    wait,
+		groups:  groups,
+		current: map[string]int{},
+	}, nil
+}
+
+func (*WeightedScheduler) CandidateLimit() int { return schedulerCandidateLimit }
+
+func (w *WeightedScheduler) Order(r *SchedulerRunner, candidates []*SchedulerJob) {
+	group := w.group(r.Runner)
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if ma, mb := a.Group == group, b.Group == group; ma != mb {
+			return ma
+		}
+
+		return schedulerBefore(a, b)
+	})
+}
+
+// group returns the group of the runner or "" if it isn't in one.
+func (w *WeightedScheduler) group(r *pb.Runner) string {
+	return r.Labels[w.Label]
+}
+
+// next returns the group to reserve the next job for. Groups are chosen
+// with a smooth weighted round-robin so that the groups are interleaved
+// rather than each reserved a run of jobs.
+func (w *WeightedScheduler) next() string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	total := 0
+	var best string
+	for _, group := range w.groups {
+		weight := w.Weights[group]
+		total += weight
+		w.current[group] += weight
+		if best == "" || w.current[group] > w.current[best] {
+			best = group
+		}
+	}
+	w.current[best] -= total
+
+	return best
+}
+
+// jobWeightIndexSet reserves the queued job for a runner group if the
+// weighted scheduler is used. A job keeps the group it was reserved for
+// when it is indexed again.
+func (s *State) jobWeightIndexSet(txn *memdb.Txn, rec *jobIndex) error {
+	sch, ok := s.schedulerGet().(*WeightedScheduler)
+	if !ok || !rec.TargetAny || rec.State != pb.Job_QUEUED {
+		return nil
+	}
+
+	raw, err := txn.First(jobTableName, jobIdIndexName, rec.Id)
+	if err != nil {
+		return err
+	}
+	if raw != nil {
+		rec.WeightGroup = raw.(*jobIndex).WeightGroup
+	}
+	if rec.WeightGroup != "" {
+		return nil
+	}
+	rec.WeightGroup = sch.next()
+
+	// Runners that skipped the job are woken once it can be assigned to
+	// any runner.
+	if d := rec.QueueTime.Add(sch.Wait).Sub(s.clock.Now()); d > 0 {
+		id := rec.Id
+		s.timers.AfterFunc(d, func() {
+			s.jobNotify.notifyKey(jobNotifyWeightKey(id))
+		})
+	}
+
+	return nil
+}
+
+// jobWeightHeld returns true if the job isn't a candidate for the runner
+// yet because it is reserved for another runner group. If so and w is not
+// nil, w is notified once the job can be assigned to any runner.
+func (s *State) jobWeightHeld(
+	memTxn *memdb.Txn,
+	w *jobWaiter,
+	job *jobIndex,
+	r *pb.Runner,
+) (bool, error) {
+	sch, ok := s.schedulerGet().(*WeightedScheduler)
+	if !ok || job.WeightGroup == "" || sch.group(r) == job.WeightGroup {
+		return false, nil
+	}
+	if !s.clock.Now().Before(job.QueueTime.Add(sch.Wait)) {
+		return false, nil
+	}
+
+	iter, err := memTxn.Get(runnerTableName, runnerIdIndexName+"_prefix", "")
+	if err != nil {
+		return false, err
+	}
+
+	held := false
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		other := raw.(*runnerRecord).Runner
+		if sch.group(other) == job.WeightGroup &&
+			!other.ByIdOnly && !other.Draining && !other.Pending {
+			held = true
+			break
+		}
+	}
+
+	if held && w != nil {
+		w.watch(jobNotifyWeightKey(job.Id))
+	}
+
+	return held, nil
+}
+
+// jobNotifyWeightQueued wakes the runners of the group that the queued job
+// is reserved for, since the runner that is woken for any runner may be in
+// another group.
+func (s *State) jobNotifyWeightQueued(idx *jobIndex) {
+	if sch, ok := s.schedulerGet().(*WeightedScheduler); ok && idx.WeightGroup != "" {
+		s.jobNotify.notifyKey(jobNotifyLabelKey(sch.Label, idx.WeightGroup))
+	}
+}
+
+// jobNotifyWeightKey is the notification key for the job with the given
+// ID to be assignable to runners of any group.
+func jobNotifyWeightKey(id string) string {
+	return "weight:" + id
+}
@@ -21,10 +21,10 @@ func init() {
 
 // ServerConfigSet writes the server configuration.
 func (s *State) ServerConfigSet(c *pb.ServerConfig) error {
-	memTxn := s.inmem.Txn(true)
+	memTxn := s.inmemWriteTxn()
 	defer memTxn.Abort()
 
-	err := s.db.Update(func(dbTxn *bolt.Tx) error {
+	err := s.dbUpdate(func(dbTxn *bolt.Tx) error {
 		return s.serverConfigSet(dbTxn, memTxn, c)
 	})
 	if err == nil {
@@ -64,7 +64,7 @@ func (s *State) serverConfigSet(
 	// Get the global bucket and write the value to it.
 	b := dbTxn.Bucket(serverConfigBucket)
 	if value == nil {
-		if err := b.Delete(id); err != nil {
+		if err := dbDelete(b, id); err != nil {
 			return err
 		}
 	} else {
@@ -48,7 +48,7 @@ func (s *State) CreateSnapshot(w io.Writer) error {
 		return err
 	}
 
-	return s.db.View(func(dbTxn *bolt.Tx) error {
+	return s.dbView(func(dbTxn *bolt.Tx) error {
 		if err := dbTxn.ForEach(func(name []byte, b *bolt.Bucket) error {
 			const chunkLenMax = 1024 * 1024 // 1 MB
 			chunkLen := 0
@@ -114,6 +114,10 @@ func (s *State) StageRestoreSnapshot(r io.Reader) error {
 	log := s.log.Named("restore")
 	log.Warn("beginning to stage snapshot restore")
 
+	// Hold the database so it isn't replaced by compaction while we stage.
+	s.dbLock.RLock()
+	defer s.dbLock.RUnlock()
+
 	ri := newRestoreInfo(log, s.db)
 	if err := ri.Lock(); err != nil {
 		return err
@@ -172,6 +176,102 @@ func (s *State) StageRestoreSnapshot(r io.Reader) error {
 	return nil
 }
 
+// RestoreSnapshot restores a snapshot created with CreateSnapshot while
+// the server is running. The data is replaced in a single transaction and
+// the in-memory indexes are rebuilt, so the restore either succeeds or
+// changes nothing. Connected runners and instances stay connected, but
+// the output of running jobs that isn't in the snapshot is lost.
+func (s *State) RestoreSnapshot(r io.Reader) error {
+	log := s.log.Named("restore")
+	log.Warn("beginning online snapshot restore")
+
+	checksum := sha256.New()
+	sr, closer, err := snapshotStreamReader(r, checksum)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument,
+			"error reading restore data: %s", err)
+	}
+	defer closer()
+
+	var header pb.Snapshot_Header
+	if err := sr.ReadMsg(&header); err != nil {
+		return status.Errorf(codes.InvalidArgument,
+			"error reading restore header data: %s", err)
+	}
+	if header.Format != pb.Snapshot_Header_BOLT {
+		return status.Errorf(codes.InvalidArgument,
+			"invalid snapshot format (got code: %d)", header.Format)
+	}
+	log.Info("snapshot header info",
+		"created_by", header.Version.GetVersion(),
+		"format", header.Format.String(),
+	)
+
+	// We hold the index write transaction while we replace the data so
+	// that no writes happen between replacing the data and the indexes.
+	memTxn := s.inmem.Txn(true)
+	defer memTxn.Abort()
+
+	err = s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		// Delete all the existing data. We delete each key rather than the
+		// buckets so that the deletes are replicated.
+		for _, name := range dbBuckets {
+			b := dbTxn.Bucket(name)
+
+			var keys [][]byte
+			if err := b.ForEach(func(k, v []byte) error {
+				keys = append(keys, append([]byte(nil), k...))
+				return nil
+			}); err != nil {
+				return err
+			}
+
+			for _, k := range keys {
+				if err := dbDelete(b, k); err != nil {
+					return err
+				}
+			}
+		}
+
+		err := snapshotRestoreChunks(log, sr, checksum, func(bucket string, items map[string][]byte) error {
+			b := dbTxn.Bucket([]byte(bucket))
+			if b == nil {
+				log.Warn("ignoring snapshot data for an unknown bucket", "bucket", bucket)
+				return nil
+			}
+
+			for k, v := range items {
+				if err := dbPutRaw(b, []byte(k), v); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument,
+				"error reading restore data: %s", err)
+		}
+
+		// The data must be a version we can read. Older data is migrated.
+		return dbVersionUpgrade(log, dbTxn)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Rebuild the indexes from the restored data.
+	if err := s.indexReset(memTxn); err != nil {
+		log.Error("error rebuilding indexes after restore, the server must be restarted", "err", err)
+		return err
+	}
+	memTxn.Commit()
+	s.jobCachePurge()
+
+	log.Warn("online snapshot restore successful")
+	return nil
+}
+
 // finalizeRestore checks for any staged restore and moves it into place.
 // This will error if it fails for any reason which may prevent startup
 // but we have to prevent startup because the user wanted a restore.
@@ -229,6 +329,66 @@ func finalizeRestore(log hclog.Logger, db *bolt.DB) (*bolt.DB, error) {
 	if err != nil {
 		return db, err
 	}
+	err = tempDb.Update(func(dbTxn *bolt.Tx) error {
+		return snapshotRestoreChunks(log, sr, checksum, snapshotPutFunc(dbTxn))
+	})
+	tempDb.Close()
+	if err != nil {
+		return db, err
+	}
+
+	// Close our DB, we will reopen with the new one
+	if err := db.Close(); err != nil {
+		log.Error("failed to close db for restore", "err", err)
+		return db, err
+	}
+
+	// Get our file info
+	var mode os.FileMode = 0666
+	if fi, err := os.Stat(ri.DBPath); err == nil {
+		mode = fi.Mode()
+	} else if !os.IsNotExist(err) {
+		return db, err
+	}
+
+	// Replace our file
+	log.Info("atomically replacing db file", "src", ri.StagePath, "dest", ri.DBPath)
+	if err := atomic.ReplaceFile(ri.StageTempPath, ri.DBPath); err != nil {
+		log.Error("error replacing file", "err", err)
+		return db, err
+	}
+
+	// Delete our restore data
+	log.Info("finished reading restore data, removing now")
+	if err := os.Remove(ri.StagePath); err != nil {
+		log.Error("error removing restore data", "err", err)
+		return db, err
+	}
+
+	// Reopen the DB
+	log.Info("reopening database", "path", ri.DBPath)
+	db, err = bolt.Open(ri.DBPath, mode, &bolt.Options{
+		Timeout: 2 * time.Second,
+	})
+	if err != nil {
+		log.Error("error reopening db", "err", err)
+		return db, err
+	}
+
+	log.Warn("database restore successful")
+	return db, nil
+}
+
+// snapshotRestoreChunks reads the bolt chunks and the trailer of a
+// snapshot and calls put with the items of each chunk. This must be
+// called after the header is read. The checksum must be the hash given
+// to the snapshot reader.
+func snapshotRestoreChunks(
+	log hclog.Logger,
+	sr protowriter.Reader,
+	checksum hash.Hash,
+	put func(bucket string, items map[string][]byte) error,
+) error {
 	for {
 		var chunk pb.Snapshot_BoltChunk
 		err := sr.ReadMsg(&chunk)
@@ -236,31 +396,16 @@ func finalizeRestore(log hclog.Logger, db *bolt.DB) (*bolt.DB, error) {
 			break
 		}
 		if err != nil {
-			tempDb.Close()
-			return db, err
+			return err
 		}
 
 		if len(chunk.Bucket) > 0 && len(chunk.Items) > 0 {
-			if err := tempDb.Update(func(dbTxn *bolt.Tx) error {
-				b, err := dbTxn.CreateBucketIfNotExists([]byte(chunk.Bucket))
-				if err != nil {
-					return err
-				}
-				for k, v := range chunk.Items {
-					if err := b.Put([]byte(k), v); err != nil {
-						return err
-					}
-				}
-
-				return nil
-			}); err != nil {
-				tempDb.Close()
-				return db, err
+			if err := put(chunk.Bucket, chunk.Items); err != nil {
+				return err
 			}
 		}
 
 		if chunk.Final {
-			tempDb.Close()
 			break
 		}
 	}
@@ -274,7 +419,7 @@ func finalizeRestore(log hclog.Logger, db *bolt.DB) (*bolt.DB, error) {
 	var trailer pb.Snapshot_Trailer
 	if err := sr.ReadMsg(&trailer); err != nil {
 		log.Error("error while parsing restore trailer", "err", err)
-		return db, fmt.Errorf("error reading restore trailer data: %s", err)
+		return fmt.Errorf("error reading restore trailer data: %s", err)
 	}
 
 	// Validate the checksum
@@ -285,54 +430,33 @@ func finalizeRestore(log hclog.Logger, db *bolt.DB) (*bolt.DB, error) {
 				"expected", v.Sha256,
 				"actual", finalChecksum,
 			)
-			return db, fmt.Errorf("checksum mismatch, expected %s got %s", v.Sha256, finalChecksum)
+			return fmt.Errorf("checksum mismatch, expected %s got %s", v.Sha256, finalChecksum)
 		}
 
 	default:
 		log.Error("unknown checksum type", "type", fmt.Sprintf("%T", trailer.Checksum))
-		return db, fmt.Errorf("error reading restore trailer data: unknown checksum type")
+		return fmt.Errorf("error reading restore trailer data: unknown checksum type")
 	}
 
-	// Close our DB, we will reopen with the new one
-	if err := db.Close(); err != nil {
-		log.Error("failed to close db for restore", "err", err)
-		return db, err
-	}
-
-	// Get our file info
-	var mode os.FileMode = 0666
-	if fi, err := os.Stat(ri.DBPath); err == nil {
-		mode = fi.Mode()
-	} else if !os.IsNotExist(err) {
-		return db, err
-	}
-
-	// Replace our file
-	log.Info("atomically replacing db file", "src", ri.StagePath, "dest", ri.DBPath)
-	if err := atomic.ReplaceFile(ri.StageTempPath, ri.DBPath); err != nil {
-		log.Error("error replacing file", "err", err)
-		return db, err
-	}
+	return nil
+}
 
-	// Delete our restore data
-	log.Info("finished reading restore data, removing now")
-	if err := os.Remove(ri.StagePath); err != nil {
-		log.Error("error removing restore data", "err", err)
-		return db, err
-	}
+// snapshotPutFunc returns a function for snapshotRestoreChunks that puts
+// the items directly into the transaction, creating buckets as needed.
+func snapshotPutFunc(dbTxn *bolt.Tx) func(string, map[string][]byte) error {
+	return func(bucket string, items map[string][]byte) error {
+		b, err := dbTxn.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		for k, v := range items {
+			if err := b.Put([]byte(k), v); err != nil {
+				return err
+			}
+		}
 
-	// Reopen the DB
-	log.Info("reopening database", "path", ri.DBPath)
-	db, err = bolt.Open(ri.DBPath, mode, &bolt.Options{
-		Timeout: 2 * time.Second,
-	})
-	if err != nil {
-		log.Error("error reopening db", "err", err)
-		return db, err
+		return nil
 	}
-
-	log.Warn("database restore successful")
-	return db, nil
 }
 
 // snapshotReader opens the delimited reader for a snapshot.
@@ -342,12 +466,29 @@ func snapshotReader(path string, h hash.Hash) (protowriter.Reader, func() error,
 		return nil, nil, err
 	}
 
-	gzr, err := gzip.NewReader(f)
+	dr, closer, err := snapshotStreamReader(f, h)
 	if err != nil {
 		f.Close()
 		return nil, nil, err
 	}
 
+	return dr, func() error {
+		if err := closer(); err != nil {
+			return err
+		}
+
+		return f.Close()
+	}, nil
+}
+
+// snapshotStreamReader opens the delimited reader for a snapshot read
+// from r. The closer doesn't close r.
+func snapshotStreamReader(r io.Reader, h hash.Hash) (protowriter.Reader, func() error, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	if h == nil {
 		h = sha256.New()
 	}
@@ -365,11 +506,7 @@ func snapshotReader(path string, h hash.Hash) (protowriter.Reader, func() error,
 			return err
 		}
 
-		if err := gzr.Close(); err != nil {
-			return err
-		}
-
-		return f.Close()
+		return gzr.Close()
 	}, nil
 }
 
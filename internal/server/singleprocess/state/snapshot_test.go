@@ -2,6 +2,7 @@ package state
 
 import (
 	"bytes"
+	"context"
 	"strings"
 	"testing"
 
@@ -104,3 +105,80 @@ func TestSnapshotRestore_corrupt(t *testing.T) {
 	s, err := TestStateRestart(t, s)
 	require.NoError(err)
 }
+
+func TestRestoreSnapshot_online(t *testing.T) {
+	require := require.New(t)
+
+	s := TestState(t)
+	defer s.Close()
+
+	// Create some data
+	require.NoError(s.ProjectPut(serverptypes.TestProject(t, &pb.Project{
+		Name: "A",
+	})))
+	require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+
+	// Snapshot
+	var buf bytes.Buffer
+	require.NoError(s.CreateSnapshot(&buf))
+
+	// Create more data that isn't in the snapshot
+	require.NoError(s.ProjectPut(serverptypes.TestProject(t, &pb.Project{
+		Name: "B",
+	})))
+	require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "B"})))
+
+	// A connected runner
+	r := serverptypes.TestRunner(t, nil)
+	require.NoError(s.RunnerCreate(r))
+
+	// Restore without restarting
+	require.NoError(s.RestoreSnapshot(bytes.NewReader(buf.Bytes())))
+
+	// Should find the first records and not the second
+	_, err := s.ProjectGet(&pb.Ref_Project{Project: "A"})
+	require.NoError(err)
+	_, err = s.ProjectGet(&pb.Ref_Project{Project: "B"})
+	require.Error(err)
+	require.Equal(codes.NotFound, status.Code(err))
+
+	job, err := s.JobById("A", nil)
+	require.NoError(err)
+	require.NotNil(job)
+	job, err = s.JobById("B", nil)
+	require.NoError(err)
+	require.Nil(job)
+
+	// The runner is still connected
+	found, err := s.RunnerById(r.Id)
+	require.NoError(err)
+	require.NotNil(found)
+
+	// The restored job can be assigned
+	job, err = s.JobAssignForRunner(context.Background(), r)
+	require.NoError(err)
+	require.Equal("A", job.Id)
+}
+
+func TestRestoreSnapshot_onlineCorrupt(t *testing.T) {
+	require := require.New(t)
+
+	s := TestState(t)
+	defer s.Close()
+
+	require.NoError(s.ProjectPut(serverptypes.TestProject(t, &pb.Project{
+		Name: "A",
+	})))
+
+	// Snapshot and corrupt the end of it
+	var buf bytes.Buffer
+	require.NoError(s.CreateSnapshot(&buf))
+	data := buf.Bytes()[:buf.Len()-16]
+
+	err := s.RestoreSnapshot(bytes.NewReader(data))
+	require.Error(err)
+
+	// Nothing changed
+	_, err = s.ProjectGet(&pb.Ref_Project{Project: "A"})
+	require.NoError(err)
+}
@@ -3,12 +3,20 @@
 package state
 
 import (
+	"crypto/cipher"
 	"fmt"
 	"reflect"
+	"sync"
+	"time"
 
 	"github.com/boltdb/bolt"
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-memdb"
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/hashicorp/waypoint/internal/pkg/redact"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/logbuffer"
 )
 
 // The global variables below can be set by init() functions of other
@@ -25,6 +33,11 @@ var (
 	// dbIndexers is the list of functions to call to initialize the
 	// in-memory indexes from the persisted db.
 	dbIndexers []indexFn
+
+	// inmemOnlyTables are the in-memory tables that aren't built from
+	// the persisted db, such as connected runners. These are kept when
+	// the indexes are rebuilt.
+	inmemOnlyTables = map[string]struct{}{}
 )
 
 // State is the primary API for state mutation for the server.
@@ -39,13 +52,120 @@ type State struct {
 	// inmem is used alongside db to store in-memory indexing information
 	// for more efficient lookups into db. This index is built online at
 	// boot.
-	db *bolt.DB
+	//
+	// dbLock is held for reading to access db and for writing while db
+	// is replaced by compaction. See compact.go.
+	db     *bolt.DB
+	dbLock sync.RWMutex
 
 	// hmacKeyNotEmpty is flipped to 1 when an hmac entry is set. This is
 	// used to determine if we're in a bootstrap state and can create a
 	// bootstrap token.
 	hmacKeyNotEmpty uint32
 
+	// clock is the source of time for timeouts and expiries. See clock.go.
+	clock Clock
+
+	// timers is the timer wheel used for all job state timers.
+	timers *timerWheel
+
+	// expiry processes the expiries of records. See expiry.go.
+	expiry expiryManager
+
+	// configKey encrypts sensitive config variables. This is nil until
+	// ConfigEncryptionSet is called. See config_key.go.
+	configKey     cipher.AEAD
+	configKeyLock sync.RWMutex
+
+	// redactor masks the values of sensitive config variables. See
+	// redact.go.
+	redactor redact.Redactor
+
+	// jobNotify is used to wake runners waiting for job assignment.
+	jobNotify jobNotifier
+
+	// metrics are the counters and histograms written by WriteMetrics.
+	// See metrics.go.
+	metrics stateMetrics
+
+	// events delivers the changes to the in-memory database to
+	// subscribers. See event.go.
+	events eventBus
+
+	// jobCompleteHook is called when a job completes. See
+	// JobCompleteHookSet.
+	jobCompleteHook func(*pb.Job)
+
+	// jobCache is an LRU cache of unmarshalled jobs for the read APIs.
+	// jobCacheGen is incremented on every write and is protected by
+	// jobCacheLock. See job_cache.go.
+	jobCache     *lru.Cache
+	jobCacheLock sync.Mutex
+	jobCacheGen  uint64
+
+	// jobOutputTracker tracks the memory used by job output buffers if
+	// a limit is set. jobOutputEvicting is 1 while an eviction is running.
+	jobOutputTracker  *logbuffer.Tracker
+	jobOutputEvicting uint32
+
+	// jobOutputCompress is true if job output buffers store their events
+	// compressed. See JobOutputCompressSet.
+	jobOutputCompress bool
+
+	// jobOutputBufferMax and jobOutputBufferMode limit the size of each
+	// job output buffer. See JobOutputBufferLimitSet.
+	jobOutputBufferMax  int64
+	jobOutputBufferMode logbuffer.LimitMode
+
+	// jobTimeouts are the default job timeouts. See job_timeout.go.
+	jobTimeouts jobTimeouts
+
+	// runnerHeartbeatTimeout is how long a runner can go without a
+	// heartbeat before it is deregistered. See runner_liveness.go.
+	runnerHeartbeatTimeout time.Duration
+
+	// jobExpiry is the default and maximum expiry for queued jobs. See
+	// job_expiry.go.
+	jobExpiry jobExpiry
+
+	// jobMaxNacks is the number of times a job can be not accepted before
+	// it is dead-lettered. See job_dead_letter.go.
+	jobMaxNacks int
+
+	// jobPreemption is true if queued jobs can preempt running jobs with
+	// a lower priority. See job_preempt.go.
+	jobPreemption bool
+
+	// jobPreferCheap is true if jobs are assigned to the cheapest waiting
+	// runner that can run them. See job_cost.go.
+	jobPreferCheap bool
+
+	// runnerApproval is true if new runners must be approved before they
+	// are assigned jobs. See runner_approval.go.
+	runnerApproval bool
+
+	// runnerAssignLimit is the most jobs assigned to each runner in
+	// runnerAssignInterval. Zero is unlimited. See runner_assign_limit.go.
+	runnerAssignLimit    int
+	runnerAssignInterval time.Duration
+
+	// runnerDisconnectPolicy is what happens to the running jobs of a
+	// runner that disconnects. See runner_disconnect.go.
+	runnerDisconnectPolicy RunnerDisconnectPolicy
+
+	// scheduler orders the candidate jobs for runners. If this is nil
+	// the default is used. See scheduler.go.
+	scheduler Scheduler
+
+	// apiKeyUsage is the usage of API keys that hasn't been persisted
+	// yet. See api_key.go.
+	apiKeyUsage     map[string]*apiKeyUsage
+	apiKeyUsageLock sync.Mutex
+
+	// raft replicates the writes to the database if the state is
+	// replicated. This is nil otherwise. See raft.go.
+	raft *stateRaft
+
 	// indexers is used to track whether an indexer was called. This is
 	// initialized during New and set to nil at the end of New.
 	indexers map[uintptr]struct{}
@@ -55,7 +175,21 @@ type State struct {
 }
 
 // New initializes a new State store.
-func New(log hclog.Logger, db *bolt.DB) (*State, error) {
+func New(log hclog.Logger, db *bolt.DB, opts ...Option) (*State, error) {
+	s, err := newState(log, db, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.index(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// newState creates a State with empty in-memory indexes.
+func newState(log hclog.Logger, db *bolt.DB, opts ...Option) (*State, error) {
 	// Restore DB if necessary
 	db, err := finalizeRestore(log, db)
 	if err != nil {
@@ -69,12 +203,73 @@ func New(log hclog.Logger, db *bolt.DB) (*State, error) {
 	}
 
 	// Initialize and validate our on-disk format.
-	if err := dbInit(db); err != nil {
+	if err := dbInit(log, db); err != nil {
 		return nil, err
 	}
 
-	s := &State{inmem: inmem, db: db, log: log}
+	s := &State{
+		inmem: inmem,
+		db:    db,
+		log:   log,
+		clock: systemClock{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.timers = newTimerWheel(s.clock, timerWheelTick)
+	s.metrics.jobHeartbeat.buckets = metricHeartbeatBuckets
+	s.metrics.runnerHeartbeat.buckets = metricHeartbeatBuckets
+
+	// Create our job cache
+	s.jobCache, err = lru.New(jobCacheSize)
+	if err != nil {
+		return nil, err
+	}
 
+	return s, nil
+}
+
+// index initializes the in-memory indexes from the persisted data.
+func (s *State) index() error {
+	memTxn := s.inmem.Txn(true)
+	defer memTxn.Abort()
+	if err := s.indexTxn(memTxn); err != nil {
+		return err
+	}
+	memTxn.Commit()
+
+	return nil
+}
+
+// indexReset replaces the in-memory indexes built from the persisted data
+// with new indexes of the current data. This is used when the data is
+// replaced while the server is running.
+func (s *State) indexReset(memTxn *memdb.Txn) error {
+	// Stop the timers of the jobs we're replacing.
+	iter, err := memTxn.Get(jobTableName, jobIdIndexName+"_prefix", "")
+	if err != nil {
+		return err
+	}
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		raw.(*jobIndex).stopTimers()
+	}
+
+	for name := range stateStoreSchema().Tables {
+		if _, ok := inmemOnlyTables[name]; ok {
+			continue
+		}
+
+		if _, err := memTxn.DeleteAll(name, "id"); err != nil {
+			return err
+		}
+	}
+
+	return s.indexTxn(memTxn)
+}
+
+// indexTxn builds the in-memory indexes from the persisted data in the
+// given transaction.
+func (s *State) indexTxn(memTxn *memdb.Txn) error {
 	// Initialize our set that'll track what memdb indexers we call.
 	// When we're done we always clear this out since it is never used
 	// again.
@@ -82,9 +277,7 @@ func New(log hclog.Logger, db *bolt.DB) (*State, error) {
 	defer func() { s.indexers = nil }()
 
 	// Initialize our in-memory indexes
-	memTxn := s.inmem.Txn(true)
-	defer memTxn.Abort()
-	err = s.db.View(func(dbTxn *bolt.Tx) error {
+	return s.dbView(func(dbTxn *bolt.Tx) error {
 		for _, indexer := range dbIndexers {
 			// TODO: this should use callIndexer but it's broken as it prevents the multiple op indexers
 			// from properly running.
@@ -95,12 +288,6 @@ func New(log hclog.Logger, db *bolt.DB) (*State, error) {
 
 		return nil
 	})
-	if err != nil {
-		return nil, err
-	}
-	memTxn.Commit()
-
-	return s, nil
 }
 
 // callIndexer calls the specified indexer exactly once. If it has been called
@@ -118,6 +305,13 @@ func (s *State) callIndexer(fn indexFn, dbTxn *bolt.Tx, memTxn *memdb.Txn) error
 
 // Close should be called to gracefully close any resources.
 func (s *State) Close() error {
+	s.timers.Stop()
+	if err := s.raftStop(); err != nil {
+		return err
+	}
+
+	s.dbLock.Lock()
+	defer s.dbLock.Unlock()
 	return s.db.Close()
 }
 
@@ -1,9 +1,13 @@
 package state
 
 import (
+	"context"
 	"io/ioutil"
+	"net"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/boltdb/bolt"
 	"github.com/hashicorp/go-hclog"
@@ -12,12 +16,90 @@ import (
 )
 
 // TestState returns an initialized State for testing.
-func TestState(t testing.T) *State {
-	result, err := New(hclog.L(), testDB(t))
+func TestState(t testing.T, opts ...Option) *State {
+	result, err := New(hclog.L(), testDB(t), opts...)
 	require.NoError(t, err)
 	return result
 }
 
+// TestClock is a Clock for tests that only moves forward when Advance is
+// called, so timeouts can be triggered without waiting for them. Timers
+// still fire in their own goroutines after Advance returns.
+type TestClock struct {
+	lock    sync.Mutex
+	now     time.Time
+	tickers map[*testTicker]struct{}
+}
+
+// TestClockNew returns a TestClock set to the current time.
+func TestClockNew() *TestClock {
+	return &TestClock{
+		now:     time.Now(),
+		tickers: map[*testTicker]struct{}{},
+	}
+}
+
+// Now returns the time of the clock.
+func (c *TestClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+// NewTicker returns a ticker that ticks when the clock is advanced.
+func (c *TestClock) NewTicker(d time.Duration) Ticker {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	t := &testTicker{
+		clock: c,
+		d:     d,
+		next:  c.now.Add(d),
+		ch:    make(chan time.Time, 1),
+	}
+	c.tickers[t] = struct{}{}
+	return t
+}
+
+// Advance moves the clock forward by d and ticks the tickers that are
+// due.
+func (c *TestClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.now = c.now.Add(d)
+	for t := range c.tickers {
+		if t.next.After(c.now) {
+			continue
+		}
+
+		// Like time.Ticker, ticks are dropped if the receiver is behind.
+		select {
+		case t.ch <- c.now:
+		default:
+		}
+
+		for !t.next.After(c.now) {
+			t.next = t.next.Add(t.d)
+		}
+	}
+}
+
+type testTicker struct {
+	clock *TestClock
+	d     time.Duration
+	next  time.Time
+	ch    chan time.Time
+}
+
+func (t *testTicker) C() <-chan time.Time { return t.ch }
+
+func (t *testTicker) Stop() {
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+	delete(t.clock.tickers, t)
+}
+
 // TestStateReinit reinitializes the state by pretending to restart
 // the server with the database associated with this state. This can be
 // used to test index init logic.
@@ -42,7 +124,7 @@ func TestStateReinit(t testing.T, s *State) *State {
 	t.Cleanup(func() { db.Close() })
 
 	// Init new state
-	result, err := New(hclog.L(), db)
+	result, err := New(hclog.L(), db, WithClock(s.clock))
 	require.NoError(t, err)
 	return result
 }
@@ -60,7 +142,84 @@ func TestStateRestart(t testing.T, s *State) (*State, error) {
 	t.Cleanup(func() { db.Close() })
 
 	// Init new state
-	return New(hclog.L(), db)
+	return New(hclog.L(), db, WithClock(s.clock))
+}
+
+// TestRaftFollower starts two servers that replicate their state with raft
+// and returns the state of the leader and of the follower. The follower can
+// only serve the reads in raft_follower.go.
+func TestRaftFollower(t testing.T) (*State, *State) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	// Pick free addresses for the servers
+	addrs := map[string]string{}
+	for _, id := range []string{"a", "b"} {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addrs[id] = ln.Addr().String()
+		ln.Close()
+	}
+
+	// Every server starts as a follower, so we record the state of each.
+	var lock sync.Mutex
+	states := map[string]*State{}
+	leaderCh := make(chan string, len(addrs))
+	for id := range addrs {
+		td, err := ioutil.TempDir("", "test")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(td) })
+
+		peers := map[string]string{}
+		for peer, addr := range addrs {
+			if peer != id {
+				peers[peer] = addr
+			}
+		}
+
+		id := id
+		cfg := &RaftConfig{
+			ID:    id,
+			Addr:  addrs[id],
+			Dir:   td,
+			Peers: peers,
+			Follower: func(s *State) {
+				lock.Lock()
+				defer lock.Unlock()
+				states[id] = s
+			},
+		}
+
+		db := testDB(t)
+		go func() {
+			if _, err := NewRaft(ctx, hclog.L().Named(id), db, cfg); err == nil {
+				leaderCh <- id
+			}
+		}()
+	}
+
+	var leader string
+	select {
+	case leader = <-leaderCh:
+	case <-time.After(10 * time.Second):
+		t.Fatal("no server became the leader")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	var leaderState, followerState *State
+	for id, s := range states {
+		if id == leader {
+			leaderState = s
+		} else {
+			followerState = s
+		}
+	}
+	t.Cleanup(func() { leaderState.Close() })
+
+	return leaderState, followerState
 }
 
 func testDB(t testing.T) *bolt.DB {
@@ -0,0 +1,278 @@
+package state
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// timerWheelTick is the resolution of the state timer wheel. Timers
+	// fire on the first tick at or after their deadline.
+	timerWheelTick = 10 * time.Millisecond
+
+	// The wheel has timerWheelLevels levels of timerWheelSlots slots each.
+	// Each level covers timerWheelSlots times the range of the level
+	// below it. With a 10ms tick, five levels of 64 slots covers about
+	// 124 days. Timers further out than that are parked in the last level
+	// and cascaded down as time passes.
+	timerWheelBits   = 6
+	timerWheelSlots  = 1 << timerWheelBits
+	timerWheelMask   = timerWheelSlots - 1
+	timerWheelLevels = 5
+)
+
+// timerWheel is a hierarchical timer wheel used for all the timers owned
+// by the state store, such as job ack, heartbeat, and expiry timeouts.
+//
+// Go's runtime timers are efficient but each time.AfterFunc allocates a
+// timer and, when it fires, a goroutine. With tens of thousands of jobs the
+// timer heap churns on every heartbeat reset. The wheel instead keeps all
+// timers in fixed slots that are processed by a single goroutine. Adding,
+// resetting, and stopping a timer is O(1) and the wheel only ticks while
+// it has timers scheduled.
+//
+// Timer functions are called in their own goroutine, like time.AfterFunc,
+// so they may safely call back into the state store and the wheel.
+type timerWheel struct {
+	clock Clock
+	tick  time.Duration
+	start time.Time
+
+	lock    sync.Mutex
+	current uint64 // current tick, all slots up to this are processed
+	count   int    // number of scheduled timers
+	slots   [timerWheelLevels][timerWheelSlots]map[*wheelTimer]struct{}
+
+	kickCh   chan struct{}
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// wheelTimer is a single timer in a timerWheel. It mimics the API of
+// time.Timer that was created with time.AfterFunc.
+type wheelTimer struct {
+	w        *timerWheel
+	f        func()
+	deadline uint64
+
+	// slot is the slot this timer is in, or nil if it isn't scheduled.
+	slot map[*wheelTimer]struct{}
+}
+
+// newTimerWheel creates a timer wheel with the given tick resolution that
+// is driven by the clock and starts it. Stop must be called to release the
+// resources.
+func newTimerWheel(clock Clock, tick time.Duration) *timerWheel {
+	w := &timerWheel{
+		clock:  clock,
+		tick:   tick,
+		start:  clock.Now(),
+		kickCh: make(chan struct{}, 1),
+		stopCh: make(chan struct{}),
+	}
+
+	go w.run()
+	return w
+}
+
+// AfterFunc waits for the duration to elapse and then calls f in its own
+// goroutine. It returns a timer that can be used to cancel the call
+// using its Stop method.
+func (w *timerWheel) AfterFunc(d time.Duration, f func()) *wheelTimer {
+	t := &wheelTimer{w: w, f: f}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.schedule(t, d)
+
+	return t
+}
+
+// Stop stops the wheel. Any timers that haven't fired will never fire.
+func (w *timerWheel) Stop() {
+	w.stopOnce.Do(func() { close(w.stopCh) })
+}
+
+// Stop prevents the timer from firing. It returns true if the call stops
+// the timer, false if the timer has already fired or been stopped.
+func (t *wheelTimer) Stop() bool {
+	t.w.lock.Lock()
+	defer t.w.lock.Unlock()
+	return t.w.remove(t)
+}
+
+// Reset changes the timer to expire after duration d. It returns true if
+// the timer had been active, false if the timer had expired or been stopped.
+// Unlike time.Timer, it is safe to call Reset on an active timer.
+func (t *wheelTimer) Reset(d time.Duration) bool {
+	t.w.lock.Lock()
+	defer t.w.lock.Unlock()
+
+	active := t.w.remove(t)
+	t.w.schedule(t, d)
+	return active
+}
+
+// schedule schedules the timer d from now. This must be called with the
+// lock held.
+func (w *timerWheel) schedule(t *wheelTimer, d time.Duration) {
+	now := w.ticks(w.clock.Now())
+
+	// If we have no timers then the run loop is idle and current may be
+	// far behind. Move it forward, there is nothing to fire in between.
+	if w.count == 0 && now > w.current {
+		w.current = now
+	}
+
+	// The deadline is the first tick at or after the requested time. It
+	// must always be in the future since the current tick is processed.
+	t.deadline = now + uint64((d+w.tick-1)/w.tick)
+	if t.deadline <= w.current {
+		t.deadline = w.current + 1
+	}
+
+	w.add(t)
+	w.count++
+	if w.count == 1 {
+		select {
+		case w.kickCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// add places the timer into the correct slot for its deadline. This
+// must be called with the lock held.
+func (w *timerWheel) add(t *wheelTimer) {
+	pos := t.deadline
+	if pos < w.current {
+		pos = w.current
+	}
+
+	// Find the lowest level whose range covers the deadline. If the
+	// deadline is beyond all levels, park it at the furthest slot of
+	// the last level and it will be cascaded down when it is reached.
+	delta := pos - w.current
+	level := 0
+	for ; level < timerWheelLevels-1; level++ {
+		if delta < 1<<(timerWheelBits*(level+1)) {
+			break
+		}
+	}
+	if max := uint64(1) << (timerWheelBits * timerWheelLevels); delta >= max {
+		pos = w.current + max - 1
+	}
+
+	idx := (pos >> (timerWheelBits * level)) & timerWheelMask
+	slot := w.slots[level][idx]
+	if slot == nil {
+		slot = make(map[*wheelTimer]struct{})
+		w.slots[level][idx] = slot
+	}
+
+	slot[t] = struct{}{}
+	t.slot = slot
+}
+
+// remove removes the timer if it is scheduled. This must be called with
+// the lock held.
+func (w *timerWheel) remove(t *wheelTimer) bool {
+	if t.slot == nil {
+		return false
+	}
+
+	delete(t.slot, t)
+	t.slot = nil
+	w.count--
+	return true
+}
+
+// ticks returns the tick for the given time.
+func (w *timerWheel) ticks(now time.Time) uint64 {
+	d := now.Sub(w.start)
+	if d < 0 {
+		return 0
+	}
+
+	return uint64(d / w.tick)
+}
+
+func (w *timerWheel) run() {
+	for {
+		// Wait until we have timers so we don't tick while idle.
+		select {
+		case <-w.kickCh:
+		case <-w.stopCh:
+			return
+		}
+
+		ticker := w.clock.NewTicker(w.tick)
+		for w.advance(w.clock.Now()) {
+			select {
+			case <-ticker.C():
+			case <-w.stopCh:
+				ticker.Stop()
+				return
+			}
+		}
+		ticker.Stop()
+	}
+}
+
+// advance processes all the ticks up to now and fires any expired timers.
+// This returns true if there are still timers scheduled.
+func (w *timerWheel) advance(now time.Time) bool {
+	var fire []func()
+
+	w.lock.Lock()
+	target := w.ticks(now)
+	for w.current < target && w.count > 0 {
+		w.current++
+
+		// Cascade timers from higher levels when we enter a new slot
+		// at that level. They'll be placed into lower levels, including
+		// possibly the current level 0 slot which we process next.
+		for level := 1; level < timerWheelLevels; level++ {
+			if w.current&(1<<(timerWheelBits*level)-1) != 0 {
+				break
+			}
+
+			idx := (w.current >> (timerWheelBits * level)) & timerWheelMask
+			slot := w.slots[level][idx]
+			w.slots[level][idx] = nil
+			for t := range slot {
+				t.slot = nil
+				w.add(t)
+			}
+		}
+
+		// Fire all the timers in the current slot.
+		idx := w.current & timerWheelMask
+		for t := range w.slots[0][idx] {
+			if t.deadline > w.current {
+				// This can happen for timers parked beyond the range
+				// of the wheel. Place it back where it belongs.
+				delete(t.slot, t)
+				w.add(t)
+				continue
+			}
+
+			w.remove(t)
+			fire = append(fire, t.f)
+		}
+	}
+
+	// If we have no more timers, we can skip straight to the target.
+	if w.count == 0 && w.current < target {
+		w.current = target
+	}
+
+	active := w.count > 0
+	w.lock.Unlock()
+
+	for _, f := range fire {
+		go f()
+	}
+
+	return active
+}
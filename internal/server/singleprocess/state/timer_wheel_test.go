@@ -0,0 +1,118 @@
+package state
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimerWheel(t *testing.T) {
+	t.Run("fires after the duration", func(t *testing.T) {
+		require := require.New(t)
+
+		w := newTimerWheel(systemClock{}, time.Millisecond)
+		defer w.Stop()
+
+		start := time.Now()
+		ch := make(chan time.Time, 1)
+		w.AfterFunc(20*time.Millisecond, func() { ch <- time.Now() })
+
+		select {
+		case fired := <-ch:
+			require.True(fired.Sub(start) >= 19*time.Millisecond)
+		case <-time.After(time.Second):
+			t.Fatal("timer didn't fire")
+		}
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		require := require.New(t)
+
+		w := newTimerWheel(systemClock{}, time.Millisecond)
+		defer w.Stop()
+
+		var fired int32
+		timer := w.AfterFunc(10*time.Millisecond, func() { atomic.StoreInt32(&fired, 1) })
+		require.True(timer.Stop())
+		require.False(timer.Stop())
+
+		time.Sleep(50 * time.Millisecond)
+		require.Equal(int32(0), atomic.LoadInt32(&fired))
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		require := require.New(t)
+
+		w := newTimerWheel(systemClock{}, time.Millisecond)
+		defer w.Stop()
+
+		var fired int32
+		timer := w.AfterFunc(30*time.Millisecond, func() { atomic.AddInt32(&fired, 1) })
+
+		// Keep resetting, it should never fire
+		for i := 0; i < 5; i++ {
+			time.Sleep(10 * time.Millisecond)
+			require.True(timer.Reset(30 * time.Millisecond))
+		}
+		require.Equal(int32(0), atomic.LoadInt32(&fired))
+
+		// Let it fire, then reset should reschedule it
+		require.Eventually(func() bool {
+			return atomic.LoadInt32(&fired) == 1
+		}, time.Second, 5*time.Millisecond)
+		require.False(timer.Reset(time.Millisecond))
+		require.Eventually(func() bool {
+			return atomic.LoadInt32(&fired) == 2
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("cascades from higher levels", func(t *testing.T) {
+		require := require.New(t)
+
+		// Drive the wheel manually so we can test long durations.
+		w := &timerWheel{
+			clock:  systemClock{},
+			tick:   time.Millisecond,
+			start:  time.Now(),
+			kickCh: make(chan struct{}, 1),
+		}
+
+		var fired int32
+		w.AfterFunc(5000*time.Millisecond, func() { atomic.AddInt32(&fired, 1) })
+		w.AfterFunc(200*time.Millisecond, func() { atomic.AddInt32(&fired, 1) })
+		require.Equal(2, w.count)
+
+		require.True(w.advance(w.start.Add(4999 * time.Millisecond)))
+		require.Eventually(func() bool {
+			return atomic.LoadInt32(&fired) == 1
+		}, time.Second, time.Millisecond)
+
+		require.False(w.advance(w.start.Add(5001 * time.Millisecond)))
+		require.Eventually(func() bool {
+			return atomic.LoadInt32(&fired) == 2
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("idle wheel catches up", func(t *testing.T) {
+		require := require.New(t)
+
+		w := newTimerWheel(systemClock{}, time.Millisecond)
+		defer w.Stop()
+
+		// Let the wheel sit idle, then schedule a timer. It should not
+		// fire early from processing ticks that passed while idle.
+		time.Sleep(20 * time.Millisecond)
+		start := time.Now()
+		ch := make(chan time.Time, 1)
+		w.AfterFunc(10*time.Millisecond, func() { ch <- time.Now() })
+
+		select {
+		case fired := <-ch:
+			require.True(fired.Sub(start) >= 9*time.Millisecond)
+		case <-time.After(time.Second):
+			t.Fatal("timer didn't fire")
+		}
+	})
+}
@@ -0,0 +1,244 @@
+package state
+
+import (
+	"context"
+
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// This file has the integrity checker of the state. The persisted jobs and
+// their in-memory indexes are written separately so a bug or crash can
+// leave them disagreeing, which makes jobs invisible or unschedulable.
+// Verify finds these issues and can repair the ones that have a safe fix.
+
+// verifyGracePeriod is how old a job must be before it is checked for
+// issues that are expected while it is being created, such as being
+// persisted before it is indexed.
+var verifyGracePeriod = 1 * time.Minute
+
+// Verify checks the jobs for issues and returns them sorted by job ID. If
+// repair is true, the issues that can be repaired are. Scheduling waits
+// until this is complete.
+func (s *State) Verify(repair bool) ([]*pb.VerifyStateResponse_Issue, error) {
+	txn := s.inmemWriteTxn()
+	defer txn.Abort()
+
+	var persisted []*pb.Job
+	err := s.dbView(func(dbTxn *bolt.Tx) error {
+		var err error
+		persisted, err = jobUnmarshalAll(dbTxn.Bucket(jobBucket))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Deleted jobs aren't indexed and are purged later so they aren't
+	// checked.
+	jobs := map[string]*pb.Job{}
+	for _, job := range persisted {
+		if job.DeleteTime == nil {
+			jobs[job.Id] = job
+		}
+	}
+
+	var issues []*pb.VerifyStateResponse_Issue
+	issue := func(typ pb.VerifyStateResponse_Issue_Type, id, detail string, args ...interface{}) *pb.VerifyStateResponse_Issue {
+		result := &pb.VerifyStateResponse_Issue{
+			Type:   typ,
+			JobId:  id,
+			Detail: fmt.Sprintf(detail, args...),
+		}
+		issues = append(issues, result)
+		return result
+	}
+
+	// Check the indexes against the persisted jobs.
+	iter, err := txn.Get(jobTableName, jobIdIndexName+"_prefix", "")
+	if err != nil {
+		return nil, err
+	}
+	var indexed []*jobIndex
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		indexed = append(indexed, raw.(*jobIndex))
+	}
+
+	now := s.clock.Now()
+	var completed []string
+	for _, idx := range indexed {
+		job, ok := jobs[idx.Id]
+		if !ok {
+			is := issue(pb.VerifyStateResponse_Issue_ORPHANED_INDEX, idx.Id,
+				"job is indexed in state %s but isn't persisted", idx.State)
+			if repair {
+				idx.stopTimers()
+				if idx.State == pb.Job_RUNNING || idx.State == pb.Job_WAITING {
+					if err := s.jobAssignedSet(txn, idx, false); err != nil {
+						return nil, err
+					}
+				}
+				if err := txn.Delete(jobTableName, idx); err != nil {
+					return nil, err
+				}
+				is.Repaired = true
+			}
+
+			continue
+		}
+
+		if idx.State != job.State {
+			issue(pb.VerifyStateResponse_Issue_STATE_MISMATCH, idx.Id,
+				"job is indexed in state %s but persisted in state %s", idx.State, job.State)
+		}
+
+		// Runners are only registered in memory, so a queued job that
+		// targets a runner can outlive it, such as the local runner of a
+		// CLI that exited.
+		if idx.State == pb.Job_QUEUED && idx.TargetRunnerId != "" &&
+			now.Sub(idx.QueueTime) > verifyGracePeriod {
+			raw, err := txn.First(runnerTableName, runnerIdIndexName, idx.TargetRunnerId)
+			if err != nil {
+				return nil, err
+			}
+			if raw == nil {
+				is := issue(pb.VerifyStateResponse_Issue_DANGLING_RUNNER, idx.Id,
+					"job targets runner %q which isn't registered", idx.TargetRunnerId)
+				if repair {
+					if err := s.jobCancel(txn, idx.Copy(), true, status.Newf(codes.FailedPrecondition,
+						"job targets runner %q which isn't registered", idx.TargetRunnerId)); err != nil {
+						return nil, err
+					}
+					completed = append(completed, idx.Id)
+					is.Repaired = true
+				}
+			}
+		}
+	}
+
+	// Check the persisted jobs.
+	for _, job := range persisted {
+		if job.DeleteTime != nil {
+			continue
+		}
+
+		if paths := verifyTimestamps(proto.MessageReflect(job), "", false); len(paths) > 0 {
+			is := issue(pb.VerifyStateResponse_Issue_INVALID_TIMESTAMP, job.Id,
+				"job has invalid timestamps: %v", paths)
+			if repair {
+				_, err := s.jobReadAndUpdate(context.Background(), job.Id, func(jobpb *pb.Job) error {
+					verifyTimestamps(proto.MessageReflect(jobpb), "", true)
+					return nil
+				})
+				if err != nil {
+					return nil, err
+				}
+				is.Repaired = true
+			}
+		}
+
+		raw, err := txn.First(jobTableName, jobIdIndexName, job.Id)
+		if err != nil {
+			return nil, err
+		}
+		if raw != nil {
+			continue
+		}
+
+		// Jobs are persisted before they're indexed, so a job that was just
+		// created may not be indexed yet.
+		if t, err := ptypes.Timestamp(job.QueueTime); err == nil && now.Sub(t) < verifyGracePeriod {
+			continue
+		}
+
+		is := issue(pb.VerifyStateResponse_Issue_MISSING_INDEX, job.Id,
+			"job is persisted in state %s but isn't indexed", job.State)
+		if repair {
+			idx, err := s.jobIndexSet(txn, []byte(job.Id), job)
+			if err != nil {
+				return nil, err
+			}
+			if job.State == pb.Job_RUNNING || job.State == pb.Job_WAITING {
+				if err := s.jobAssignedSet(txn, idx, true); err != nil {
+					return nil, err
+				}
+			}
+			is.Repaired = true
+		}
+	}
+
+	txn.Commit()
+	for _, id := range completed {
+		s.jobCompleted(id)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].JobId < issues[j].JobId
+	})
+
+	return issues, nil
+}
+
+// verifyTimestamps returns the paths of the timestamps in the message and
+// its nested messages that are out of range. If clear is true, they're
+// cleared.
+func verifyTimestamps(m protoreflect.Message, path string, clear bool) []string {
+	var result []string
+	var invalid []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Message() == nil {
+			return true
+		}
+
+		name := path + string(fd.Name())
+		switch {
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				result = append(result, verifyTimestamps(
+					list.Get(i).Message(), fmt.Sprintf("%s[%d].", name, i), clear)...)
+			}
+
+		case fd.IsMap():
+			if fd.MapValue().Message() == nil {
+				return true
+			}
+
+			v.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+				result = append(result, verifyTimestamps(
+					v.Message(), fmt.Sprintf("%s[%s].", name, k.String()), clear)...)
+				return true
+			})
+
+		case fd.Message().FullName() == "google.protobuf.Timestamp":
+			ts, ok := v.Message().Interface().(*timestamp.Timestamp)
+			if _, err := ptypes.Timestamp(ts); !ok || err != nil {
+				result = append(result, name)
+				invalid = append(invalid, fd)
+			}
+
+		default:
+			result = append(result, verifyTimestamps(v.Message(), name+".", clear)...)
+		}
+
+		return true
+	})
+
+	if clear {
+		for _, fd := range invalid {
+			m.Clear(fd)
+		}
+	}
+
+	return result
+}
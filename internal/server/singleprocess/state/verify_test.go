@@ -0,0 +1,140 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+func TestVerify(t *testing.T) {
+	// Check jobs created by the tests right away
+	defer func(d time.Duration) { verifyGracePeriod = d }(verifyGracePeriod)
+	verifyGracePeriod = 0
+
+	t.Run("no issues", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+
+		issues, err := s.Verify(true)
+		require.NoError(err)
+		require.Empty(issues)
+	})
+
+	t.Run("orphaned index", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		require.NoError(s.db.Update(func(dbTxn *bolt.Tx) error {
+			return dbTxn.Bucket(jobBucket).Delete([]byte("A"))
+		}))
+
+		issues, err := s.Verify(false)
+		require.NoError(err)
+		require.Len(issues, 1)
+		require.Equal(pb.VerifyStateResponse_Issue_ORPHANED_INDEX, issues[0].Type)
+		require.Equal("A", issues[0].JobId)
+		require.False(issues[0].Repaired)
+
+		issues, err = s.Verify(true)
+		require.NoError(err)
+		require.Len(issues, 1)
+		require.True(issues[0].Repaired)
+
+		issues, err = s.Verify(false)
+		require.NoError(err)
+		require.Empty(issues)
+	})
+
+	t.Run("missing index", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		txn := s.inmemWriteTxn()
+		raw, err := txn.First(jobTableName, jobIdIndexName, "A")
+		require.NoError(err)
+		require.NoError(txn.Delete(jobTableName, raw))
+		txn.Commit()
+
+		issues, err := s.Verify(true)
+		require.NoError(err)
+		require.Len(issues, 1)
+		require.Equal(pb.VerifyStateResponse_Issue_MISSING_INDEX, issues[0].Type)
+		require.True(issues[0].Repaired)
+
+		// The job is queued again
+		job, err := s.JobAssignForRunner(context.Background(), &pb.Runner{Id: "R_A"})
+		require.NoError(err)
+		require.NotNil(job)
+		require.Equal("A", job.Id)
+	})
+
+	t.Run("invalid timestamp", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{Id: "A"})))
+		require.NoError(s.db.Update(func(dbTxn *bolt.Tx) error {
+			job := serverptypes.TestJobNew(t, &pb.Job{Id: "A"})
+			job.State = pb.Job_QUEUED
+			job.QueueTime = &timestamp.Timestamp{}
+			job.AckTime = &timestamp.Timestamp{Seconds: 1 << 62}
+			return dbPut(dbTxn.Bucket(jobBucket), []byte("A"), job)
+		}))
+
+		issues, err := s.Verify(true)
+		require.NoError(err)
+		require.Len(issues, 1)
+		require.Equal(pb.VerifyStateResponse_Issue_INVALID_TIMESTAMP, issues[0].Type)
+		require.Contains(issues[0].Detail, "ack_time")
+		require.True(issues[0].Repaired)
+
+		issues, err = s.Verify(false)
+		require.NoError(err)
+		require.Empty(issues)
+	})
+
+	t.Run("dangling runner", func(t *testing.T) {
+		require := require.New(t)
+
+		s := TestState(t)
+		defer s.Close()
+
+		require.NoError(s.JobCreate(context.Background(), serverptypes.TestJobNew(t, &pb.Job{
+			Id: "A",
+			TargetRunner: &pb.Ref_Runner{
+				Target: &pb.Ref_Runner_Id{
+					Id: &pb.Ref_RunnerId{Id: "R_A"},
+				},
+			},
+		})))
+
+		issues, err := s.Verify(true)
+		require.NoError(err)
+		require.Len(issues, 1)
+		require.Equal(pb.VerifyStateResponse_Issue_DANGLING_RUNNER, issues[0].Type)
+		require.True(issues[0].Repaired)
+
+		job, err := s.JobById("A", nil)
+		require.NoError(err)
+		require.Equal(pb.Job_ERROR, job.State)
+	})
+}
@@ -0,0 +1,137 @@
+package state
+
+import (
+	"strings"
+
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hashicorp/go-memdb"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+var workspacePolicyBucket = []byte("workspace_policy")
+
+func init() {
+	dbBuckets = append(dbBuckets, workspacePolicyBucket)
+	dbIndexers = append(dbIndexers, (*State).workspacePolicyIndexInit)
+	schemas = append(schemas, workspacePolicyIndexSchema)
+}
+
+// WorkspacePolicySet writes the policy for a workspace. A policy that
+// requires no approvals is deleted.
+func (s *State) WorkspacePolicySet(p *pb.WorkspacePolicy) error {
+	memTxn := s.inmemWriteTxn()
+	defer memTxn.Abort()
+
+	err := s.dbUpdate(func(dbTxn *bolt.Tx) error {
+		return s.workspacePolicySet(dbTxn, memTxn, p)
+	})
+	if err == nil {
+		memTxn.Commit()
+	}
+
+	return err
+}
+
+// WorkspacePolicyGet gets the policy for a workspace. If the workspace
+// has no policy, an empty policy is returned.
+func (s *State) WorkspacePolicyGet(workspace string) (*pb.WorkspacePolicy, error) {
+	memTxn := s.inmem.Txn(false)
+	defer memTxn.Abort()
+
+	v, err := memTxn.First(
+		workspacePolicyIndexTableName,
+		workspacePolicyIndexIdIndexName,
+		workspace,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return &pb.WorkspacePolicy{Workspace: workspace}, nil
+	}
+
+	return proto.Clone(v.(*workspacePolicyIndexRecord).Policy).(*pb.WorkspacePolicy), nil
+}
+
+func (s *State) workspacePolicySet(
+	dbTxn *bolt.Tx,
+	memTxn *memdb.Txn,
+	value *pb.WorkspacePolicy,
+) error {
+	id := workspacePolicyId(value.Workspace)
+
+	b := dbTxn.Bucket(workspacePolicyBucket)
+	if value.RequiredApprovals == 0 {
+		if err := dbDelete(b, id); err != nil {
+			return err
+		}
+	} else {
+		if err := dbPut(b, id, value); err != nil {
+			return err
+		}
+	}
+
+	return s.workspacePolicyIndexSet(memTxn, id, value)
+}
+
+// workspacePolicyIndexSet writes an index record for a workspace policy.
+func (s *State) workspacePolicyIndexSet(txn *memdb.Txn, id []byte, value *pb.WorkspacePolicy) error {
+	record := &workspacePolicyIndexRecord{
+		Id:     string(id),
+		Policy: value,
+	}
+
+	// If the workspace isn't protected, we delete from the memdb index
+	if value.RequiredApprovals == 0 {
+		return txn.Delete(workspacePolicyIndexTableName, record)
+	}
+
+	return txn.Insert(workspacePolicyIndexTableName, record)
+}
+
+// workspacePolicyIndexInit initializes the workspace policy index from
+// persisted data.
+func (s *State) workspacePolicyIndexInit(dbTxn *bolt.Tx, memTxn *memdb.Txn) error {
+	bucket := dbTxn.Bucket(workspacePolicyBucket)
+	return bucket.ForEach(func(k, v []byte) error {
+		var value pb.WorkspacePolicy
+		if err := proto.Unmarshal(v, &value); err != nil {
+			return err
+		}
+
+		return s.workspacePolicyIndexSet(memTxn, k, &value)
+	})
+}
+
+func workspacePolicyId(workspace string) []byte {
+	return []byte(strings.ToLower(workspace))
+}
+
+func workspacePolicyIndexSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: workspacePolicyIndexTableName,
+		Indexes: map[string]*memdb.IndexSchema{
+			workspacePolicyIndexIdIndexName: {
+				Name:         workspacePolicyIndexIdIndexName,
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "Id",
+					Lowercase: true,
+				},
+			},
+		},
+	}
+}
+
+const (
+	workspacePolicyIndexTableName   = "workspace-policy-index"
+	workspacePolicyIndexIdIndexName = "id"
+)
+
+type workspacePolicyIndexRecord struct {
+	Id     string
+	Policy *pb.WorkspacePolicy
+}
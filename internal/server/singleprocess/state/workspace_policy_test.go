@@ -0,0 +1,47 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func TestWorkspacePolicy(t *testing.T) {
+	require := require.New(t)
+
+	s := TestState(t)
+	defer s.Close()
+
+	// Unprotected by default
+	p, err := s.WorkspacePolicyGet("prod")
+	require.NoError(err)
+	require.Equal("prod", p.Workspace)
+	require.Equal(uint32(0), p.RequiredApprovals)
+
+	// Set
+	require.NoError(s.WorkspacePolicySet(&pb.WorkspacePolicy{
+		Workspace:         "prod",
+		RequiredApprovals: 2,
+		ApproverRoles:     []string{"sre"},
+	}))
+	p, err = s.WorkspacePolicyGet("PROD")
+	require.NoError(err)
+	require.Equal(uint32(2), p.RequiredApprovals)
+	require.Equal([]string{"sre"}, p.ApproverRoles)
+
+	// Persisted
+	s = TestStateReinit(t, s)
+	defer s.Close()
+	p, err = s.WorkspacePolicyGet("prod")
+	require.NoError(err)
+	require.Equal(uint32(2), p.RequiredApprovals)
+
+	// Requiring no approvals removes the policy
+	require.NoError(s.WorkspacePolicySet(&pb.WorkspacePolicy{Workspace: "prod"}))
+	p, err = s.WorkspacePolicyGet("prod")
+	require.NoError(err)
+	require.Equal(uint32(0), p.RequiredApprovals)
+	require.Empty(p.ApproverRoles)
+}
@@ -61,7 +61,9 @@ func Connect(ctx context.Context, opts ...ConnectOption) (*grpc.ClientConn, erro
 		))
 	}
 
-	if cfg.Auth {
+	if cfg.Credentials != nil {
+		grpcOpts = append(grpcOpts, grpc.WithPerRPCCredentials(cfg.Credentials))
+	} else if cfg.Auth {
 		token := cfg.Token
 		if v := os.Getenv(EnvServerToken); v != "" {
 			token = v
@@ -109,6 +111,7 @@ type connectConfig struct {
 	Token         string
 	Optional      bool // See Optional func
 	Timeout       time.Duration
+	Credentials   credentials.PerRPCCredentials
 }
 
 // FromEnv sources the connection information from the environment
@@ -190,6 +193,15 @@ func Auth() ConnectOption {
 	}
 }
 
+// Credentials specifies the credentials to send with each request. This
+// takes precedence over any token from the environment or context.
+func Credentials(creds credentials.PerRPCCredentials) ConnectOption {
+	return func(c *connectConfig) error {
+		c.Credentials = creds
+		return nil
+	}
+}
+
 // Optional specifies that getting server connection information is
 // optional. If this is specified and no credentials are found, Connect
 // will return (nil, nil). If this is NOT specified and no credentials are
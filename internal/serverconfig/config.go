@@ -1,5 +1,9 @@
 package serverconfig
 
+import (
+	"time"
+)
+
 // Client configures a client to connect to a server.
 type Client struct {
 	Address string `hcl:"address,attr"`
@@ -34,11 +38,416 @@ type Config struct {
 	// HTTP is the listening configuration for the HTTP service for grpc-web.
 	HTTP Listener `hcl:"http,block"`
 
+	// FIPS restricts the listeners to FIPS-approved TLS algorithms and
+	// requires TLS to be enabled. This is always enabled for binaries
+	// built with the "fips" tag.
+	FIPS bool `hcl:"fips,optional"`
+
 	// URL configures a server to use a URL service.
 	URL *URL `hcl:"url,block"`
 
 	// CEBConfig configures the entrypoint binary for deployments
 	CEBConfig *CEBConfig `hcl:"entrypoint_config,block"`
+
+	// JobOutputMaxBytes is the maximum total size in bytes of job output
+	// buffered in memory across all jobs. When this is exceeded, the output
+	// of completed jobs is dropped, oldest first. Zero means no limit.
+	JobOutputMaxBytes int64 `hcl:"job_output_max_bytes,optional"`
+
+	// JobOutputBufferMaxBytes is the maximum size in bytes of the output
+	// buffered in memory for a single job. When this is exceeded, the
+	// output buffered so far is dropped and replaced by a warning, or if
+	// JobOutputBufferBlock is true, the runner is slowed down until the
+	// job's streams read the output. Zero means no limit.
+	JobOutputBufferMaxBytes int64 `hcl:"job_output_buffer_max_bytes,optional"`
+	JobOutputBufferBlock    bool  `hcl:"job_output_buffer_block,optional"`
+
+	// JobOutputCompress if true stores the job output buffered in memory
+	// compressed, which reduces the memory used by verbose output such as
+	// container builds. Persisted job output is always compressed.
+	JobOutputCompress bool `hcl:"job_output_compress,optional"`
+
+	// JobOutputRetention configures pruning the persisted output of
+	// completed jobs in the background. The job records are kept.
+	JobOutputRetention *JobOutputRetention `hcl:"job_output_retention,block"`
+
+	// JobTimeouts are the default timeouts for jobs. Jobs can override
+	// these when they're queued.
+	JobTimeouts *JobTimeouts `hcl:"job_timeouts,block"`
+
+	// JobDefaultExpiry is how long jobs queued without an expiry can stay
+	// queued before they expire. Zero means they never expire.
+	JobDefaultExpiry time.Duration `hcl:"job_default_expiry,optional"`
+
+	// JobMaxExpiry is the longest jobs can stay queued before they expire.
+	// Jobs with a later expiry expire after this. Zero means no limit.
+	JobMaxExpiry time.Duration `hcl:"job_max_expiry,optional"`
+
+	// JobMaxNacks is the number of times a job can be not accepted by
+	// runners before it moves to the dead-letter queue rather than being
+	// queued again. Zero uses the default of 5.
+	JobMaxNacks int `hcl:"job_max_nacks,optional"`
+
+	// JobPreemption if true allows a queued job to cancel a running job
+	// with a lower priority if no runner is free to run it. The cancelled
+	// job is queued again.
+	JobPreemption bool `hcl:"job_preemption,optional"`
+
+	// JobScheduler is the strategy for choosing which queued job a runner
+	// is assigned: "priority", "fifo", "fair-share", or "bin-packing".
+	// This defaults to "priority".
+	JobScheduler string `hcl:"job_scheduler,optional"`
+
+	// JobPreferCheapRunners if true assigns jobs to the cheapest waiting
+	// runner that can run them, by the cost that runners advertise, to
+	// bias jobs toward cheap capacity such as spot instances.
+	JobPreferCheapRunners bool `hcl:"job_prefer_cheap_runners,optional"`
+
+	// RunnerGroups are the groups of runners and their weights for the
+	// "weighted" JobScheduler.
+	RunnerGroups *RunnerGroups `hcl:"runner_groups,block"`
+
+	// RunnerHeartbeatTimeout is how long a runner can go without a
+	// heartbeat before it is deregistered and the jobs it hasn't accepted
+	// are queued again. This defaults to 1 minute.
+	RunnerHeartbeatTimeout time.Duration `hcl:"runner_heartbeat_timeout,optional"`
+
+	// RunnerApproval if true requires new runners to be approved with
+	// "waypoint runner approve" before they're assigned jobs. Runners
+	// that register with a token created for them are approved
+	// automatically.
+	RunnerApproval bool `hcl:"runner_approval,optional"`
+
+	// RunnerAssignLimit is the most jobs that are assigned to each runner
+	// in RunnerAssignInterval, so a runner that fails jobs immediately
+	// can't fail the entire queue. Zero means no limit.
+	RunnerAssignLimit int `hcl:"runner_assign_limit,optional"`
+
+	// RunnerAssignInterval is the interval of RunnerAssignLimit. This
+	// defaults to 1 minute.
+	RunnerAssignInterval time.Duration `hcl:"runner_assign_interval,optional"`
+
+	// RunnerDisconnectPolicy is what happens to the running jobs of a
+	// runner whose connection to the server ends: "wait" for their
+	// heartbeat timeout, "requeue" them, or "fail" them. This defaults
+	// to "wait".
+	RunnerDisconnectPolicy string `hcl:"runner_disconnect_policy,optional"`
+
+	// ConfigEncryption configures encryption for sensitive config variables.
+	// If this isn't set, sensitive config variables can't be used.
+	ConfigEncryption *ConfigEncryption `hcl:"config_encryption,block"`
+
+	// RunnerAuth configures runners to login with cloud identities instead
+	// of a static token. If this isn't set, runner login is disabled.
+	RunnerAuth *RunnerAuth `hcl:"runner_auth,block"`
+
+	// Telemetry configures sending anonymous usage telemetry. Telemetry
+	// is only sent if this is set and enabled.
+	Telemetry *Telemetry `hcl:"telemetry,block"`
+
+	// Audit configures exporting an audit event for every API call. Events
+	// are only exported if an address or path is set.
+	Audit *Audit `hcl:"audit,block"`
+
+	// Dispatch configures running jobs in an external scheduler such as
+	// Nomad or Kubernetes instead of on registered runners. Jobs are only
+	// dispatched if this is set with a scheduler.
+	Dispatch *Dispatch `hcl:"dispatch,block"`
+
+	// CommitStatus configures reporting the status of jobs to GitHub or
+	// GitLab for the commit they ran. Statuses are only reported for the
+	// services that credentials are set for.
+	CommitStatus *CommitStatus `hcl:"commit_status,block"`
+
+	// Federation configures forwarding jobs to downstream servers, such
+	// as one server per region. Jobs are only forwarded if they name a
+	// configured server.
+	Federation *Federation `hcl:"federation,block"`
+
+	// Snapshot configures taking snapshots of the server state on a
+	// schedule. Snapshots are only taken if a schedule is set.
+	Snapshot *Snapshot `hcl:"snapshot,block"`
+
+	// Backup configures uploading snapshots of the server state to remote
+	// object storage on a schedule. Backups are only taken if a store and
+	// schedule are set.
+	Backup *Backup `hcl:"backup,block"`
+
+	// Compact configures compacting the database in the background to
+	// reclaim the space freed by deleted data. The database is only
+	// compacted in the background if an interval is set.
+	Compact *Compact `hcl:"compact,block"`
+
+	// Raft configures replicating the server state across servers with
+	// raft for high availability. The state is only replicated if an ID
+	// is set.
+	Raft *Raft `hcl:"raft,block"`
+}
+
+// Raft configures replicating the server state with raft. Only the leader
+// serves requests, except for reads if FollowerReads is set. The other
+// servers wait to take over if it fails.
+type Raft struct {
+	// ID is the unique ID of this server in the cluster.
+	ID string `hcl:"id,optional"`
+
+	// Address is the address to listen on for raft traffic. This is also
+	// advertised to the other servers so it must be reachable by them.
+	Address string `hcl:"address,optional"`
+
+	// Dir is the directory to store the raft log and snapshots in.
+	Dir string `hcl:"dir,optional"`
+
+	// Peers are the other servers in the format "ID=ADDRESS". These are
+	// only used to bootstrap the cluster the first time it starts.
+	Peers []string `hcl:"peers,optional"`
+
+	// FollowerReads serves the reads of jobs that set a maximum staleness
+	// while this server is a follower.
+	FollowerReads bool `hcl:"follower_reads,optional"`
+}
+
+// JobTimeouts configures the default timeouts for jobs.
+type JobTimeouts struct {
+	// Ack is how long a runner has to accept a job once it is assigned
+	// before the job is queued again. This defaults to 2 minutes.
+	Ack time.Duration `hcl:"ack,optional"`
+
+	// Heartbeat is how long a job can run without a heartbeat from its
+	// runner before it fails. This defaults to 2 minutes.
+	Heartbeat time.Duration `hcl:"heartbeat,optional"`
+
+	// MaxRun is the longest a job can run once it is accepted before it
+	// is cancelled. Zero means there is no limit.
+	MaxRun time.Duration `hcl:"max_run,optional"`
+}
+
+// RunnerGroups configures the groups of runners that the "weighted" job
+// scheduler distributes jobs that target any runner across.
+type RunnerGroups struct {
+	// Label is the runner label whose value is the group of a runner.
+	// This defaults to "group".
+	Label string `hcl:"label,optional"`
+
+	// Weights are the weights of each group, such as 80 for "spot" and
+	// 20 for "on-demand".
+	Weights map[string]int `hcl:"weights,optional"`
+
+	// Wait is how long a job waits for a runner of the group it is
+	// reserved for before any runner can run it. This defaults to 30
+	// seconds.
+	Wait time.Duration `hcl:"wait,optional"`
+}
+
+// Federation configures the downstream servers that jobs can be
+// forwarded to.
+type Federation struct {
+	// Servers are the downstream servers in the format "NAME=ADDRESS".
+	// Jobs name the server they're forwarded to.
+	Servers []string `hcl:"servers,optional"`
+
+	// Token is the token used to queue jobs on the downstream servers.
+	// The servers use TLS unless TLSDisabled is true.
+	Token         string `hcl:"token,optional"`
+	TLSDisabled   bool   `hcl:"tls_disabled,optional"`
+	TLSSkipVerify bool   `hcl:"tls_skip_verify,optional"`
+
+	// PollInterval is the time between checking the status of forwarded
+	// jobs. This defaults to 10 seconds.
+	PollInterval time.Duration `hcl:"poll_interval,optional"`
+}
+
+// Snapshot configures scheduled snapshots.
+type Snapshot struct {
+	// Schedule is when to take snapshots in cron format, such as
+	// "0 * * * *" or "@daily". Times are in UTC.
+	Schedule string `hcl:"schedule,optional"`
+
+	// Path is the directory that snapshots are written to.
+	Path string `hcl:"path,optional"`
+
+	// Retain is the number of snapshots to keep. Older snapshots are
+	// deleted after each new snapshot. This defaults to 7.
+	Retain int `hcl:"retain,optional"`
+}
+
+// Backup configures scheduled backups to remote object storage.
+type Backup struct {
+	// Store is the object storage to upload backups to: "s3", "gcs", or
+	// "azure". Credentials are read from the environment in the standard
+	// way for each store.
+	Store string `hcl:"store,optional"`
+
+	// Schedule is when to take backups in cron format, such as
+	// "0 * * * *" or "@daily". Times are in UTC.
+	Schedule string `hcl:"schedule,optional"`
+
+	// Bucket is the bucket, or the container for Azure, to upload to.
+	Bucket string `hcl:"bucket,optional"`
+
+	// Prefix is prepended to the names of the backups, such as "waypoint/".
+	Prefix string `hcl:"prefix,optional"`
+
+	// Retain is the number of backups to keep. Older backups are deleted
+	// after each new backup. This defaults to 7.
+	Retain int `hcl:"retain,optional"`
+
+	// Region and Endpoint configure S3. Endpoint is the address of
+	// S3-compatible storage to use instead of AWS.
+	Region   string `hcl:"region,optional"`
+	Endpoint string `hcl:"endpoint,optional"`
+
+	// Account is the Azure storage account.
+	Account string `hcl:"account,optional"`
+}
+
+// Compact configures background database compaction.
+type Compact struct {
+	// Interval is the time between checking if the database should be
+	// compacted.
+	Interval time.Duration `hcl:"interval,optional"`
+
+	// Threshold is the fraction of the database file that must be free
+	// for it to be compacted. This defaults to 0.5.
+	Threshold float64 `hcl:"threshold,optional"`
+}
+
+// JobOutputRetention configures pruning persisted job output. Output is
+// only pruned if a maximum age or size is set.
+type JobOutputRetention struct {
+	// MaxAge is how long the output of a job is kept after it completes.
+	MaxAge time.Duration `hcl:"max_age,optional"`
+
+	// MaxBytes is the maximum total size in bytes of persisted job
+	// output. The output of the jobs that completed longest ago is pruned
+	// to stay under it.
+	MaxBytes int64 `hcl:"max_bytes,optional"`
+
+	// Interval is the time between pruning job output. This defaults to
+	// one hour.
+	Interval time.Duration `hcl:"interval,optional"`
+}
+
+// CommitStatus configures reporting job statuses for commits. See the
+// commitstatus package.
+type CommitStatus struct {
+	// GitHubAddress is the address of GitHub Enterprise. This defaults
+	// to GitHub.
+	GitHubAddress string `hcl:"github_address,optional"`
+
+	// GitHubToken reports commit statuses with a token.
+	GitHubToken string `hcl:"github_token,optional"`
+
+	// GitHubApp* report check runs as a GitHub App installation. The key
+	// file is the PEM-encoded private key of the App. These take priority
+	// over GitHubToken.
+	GitHubAppID             int64  `hcl:"github_app_id,optional"`
+	GitHubAppInstallationID int64  `hcl:"github_app_installation_id,optional"`
+	GitHubAppKeyFile        string `hcl:"github_app_key_file,optional"`
+
+	// GitLabAddress is the address of a self-managed GitLab. This
+	// defaults to GitLab.com.
+	GitLabAddress string `hcl:"gitlab_address,optional"`
+
+	// GitLabToken reports commit statuses with a token.
+	GitLabToken string `hcl:"gitlab_token,optional"`
+}
+
+// Dispatch configures dispatching jobs to an external scheduler. See the
+// dispatch package.
+type Dispatch struct {
+	// Scheduler is the scheduler to use: "nomad" or "kubernetes".
+	Scheduler string `hcl:"scheduler,optional"`
+
+	// Namespace is the Nomad or Kubernetes namespace to run jobs in.
+	Namespace string `hcl:"namespace,optional"`
+
+	// Image is the Waypoint image that is run for each job.
+	Image string `hcl:"image,optional"`
+
+	// Addr is the address that runners connect to the server on. The
+	// runners use TLS unless TLSDisabled is true.
+	Addr          string `hcl:"addr,optional"`
+	TLSDisabled   bool   `hcl:"tls_disabled,optional"`
+	TLSSkipVerify bool   `hcl:"tls_skip_verify,optional"`
+
+	// TokenTTL is how long the token given to each runner is valid for.
+	// This should be longer than the longest job. This defaults to 24 hours.
+	TokenTTL time.Duration `hcl:"token_ttl,optional"`
+
+	// PollInterval is the time between checking the status of dispatched
+	// jobs with the scheduler. This defaults to 10 seconds.
+	PollInterval time.Duration `hcl:"poll_interval,optional"`
+
+	// OnDemand launches runners in the scheduler when queued jobs have no
+	// runner that can be assigned them, rather than starting a runner for
+	// each job. Jobs still run on registered runners when they can.
+	OnDemand bool `hcl:"on_demand,optional"`
+
+	// MaxRunners is the most runners launched on demand that can be running
+	// at once. This defaults to 10.
+	MaxRunners int `hcl:"max_runners,optional"`
+}
+
+// Audit configures exporting audit events. See the audit package.
+type Audit struct {
+	// Format is the format of events: "json", "cef", or "syslog".
+	Format string `hcl:"format,optional"`
+
+	// Address is the address to send events to in the format
+	// "tcp://host:port" or "udp://host:port".
+	Address string `hcl:"address,optional"`
+
+	// Path is the path of a file to write events to. The file is rotated
+	// when it exceeds MaxBytes, keeping MaxBackups previous files.
+	Path       string `hcl:"path,optional"`
+	MaxBytes   int64  `hcl:"max_bytes,optional"`
+	MaxBackups int    `hcl:"max_backups,optional"`
+}
+
+// Telemetry configures anonymous usage telemetry.
+type Telemetry struct {
+	// Enabled must be true to send telemetry.
+	Enabled bool `hcl:"enabled,optional"`
+
+	// Endpoint is the URL that telemetry reports are sent to.
+	Endpoint string `hcl:"endpoint,optional"`
+
+	// Interval is the time between reports. This defaults to 24 hours.
+	Interval time.Duration `hcl:"interval,optional"`
+}
+
+// RunnerAuth configures the cloud identities that runners can login with.
+type RunnerAuth struct {
+	// Audience is the audience identity documents must be issued for.
+	// This should be unique to this server.
+	Audience string `hcl:"audience,optional"`
+
+	// AWSIAM and GCP are the allowed identities for each cloud in the
+	// format "PATTERN" or "PATTERN=POOL". PATTERN may use "*" wildcards
+	// and is matched against the caller ARN for AWS or the service account
+	// email for GCP. POOL is the runner pool the identity is mapped to.
+	AWSIAM []string `hcl:"aws_iam,optional"`
+	GCP    []string `hcl:"gcp,optional"`
+
+	// TokenTTL is how long runner tokens are valid for.
+	TokenTTL time.Duration `hcl:"token_ttl,optional"`
+}
+
+// ConfigEncryption configures the key management service used to protect
+// the key that encrypts sensitive config variables. Exactly one of AWS KMS
+// or Vault transit should be configured.
+type ConfigEncryption struct {
+	// AWSKMSKeyId is the ID, ARN, or alias of the AWS KMS key to use.
+	AWSKMSKeyId string `hcl:"aws_kms_key_id,optional"`
+
+	// VaultAddress, VaultToken, VaultMount, and VaultKey configure the
+	// Vault transit secrets engine to use. VaultToken defaults to the
+	// VAULT_TOKEN environment variable.
+	VaultAddress string `hcl:"vault_address,optional"`
+	VaultToken   string `hcl:"vault_token,optional"`
+	VaultMount   string `hcl:"vault_mount,optional"`
+	VaultKey     string `hcl:"vault_key,optional"`
 }
 
 // CEBConfig is specific configuration for the entrypoint binaries
@@ -54,6 +463,16 @@ type Listener struct {
 	TLSDisable  bool   `hcl:"tls_disable,optional"`
 	TLSCertFile string `hcl:"tls_cert_file,optional"`
 	TLSKeyFile  string `hcl:"tls_key_file,optional"`
+
+	// TLSMinVersion and TLSCipherSuites restrict the TLS versions and
+	// cipher suites that clients can use. See the tlsconfig package.
+	TLSMinVersion   string   `hcl:"tls_min_version,optional"`
+	TLSCipherSuites []string `hcl:"tls_cipher_suites,optional"`
+
+	// TLSReloadInterval is how often the cert and key files are checked
+	// for changes and reloaded. If this is zero, they're only reloaded
+	// when the server receives SIGHUP.
+	TLSReloadInterval time.Duration `hcl:"tls_reload_interval,optional"`
 }
 
 // URL is the configuration for the URL service.
@@ -0,0 +1,96 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// DefaultInterval is the default interval between reports.
+const DefaultInterval = 24 * time.Hour
+
+// Sender periodically sends the report from a Collector to an endpoint.
+type Sender struct {
+	// Endpoint is the URL that reports are sent to with a POST request.
+	Endpoint string
+
+	// Interval is the time between reports. This defaults to
+	// DefaultInterval.
+	Interval time.Duration
+
+	// Collector is the collector to send reports for.
+	Collector *Collector
+
+	// HTTPClient is used to send reports. This defaults to a client with
+	// a 30 second timeout.
+	HTTPClient *http.Client
+
+	// Logger is used to log send failures. This defaults to hclog.L().
+	Logger hclog.Logger
+}
+
+// Run sends a report every interval until the context is cancelled.
+// Reports that fail to send are dropped rather than retried.
+func (s *Sender) Run(ctx context.Context) {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	log := s.Logger
+	if log == nil {
+		log = hclog.L()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+		}
+
+		if err := s.Send(ctx, s.Collector.Flush()); err != nil {
+			log.Warn("error sending telemetry report", "err", err)
+		}
+	}
+}
+
+// Send sends a single report.
+func (s *Sender) Send(ctx context.Context, r *Report) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", s.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := s.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("telemetry endpoint returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
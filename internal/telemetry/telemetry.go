@@ -0,0 +1,153 @@
+// Package telemetry implements the opt-in anonymous usage telemetry for the
+// Waypoint server.
+//
+// The server aggregates counts of the jobs it runs by operation and the
+// builtin plugins that are used. Nothing that identifies the server or its
+// users is collected: there are no IDs, project names, or addresses, and
+// plugins that aren't builtin are counted together as "custom". Telemetry
+// is disabled by default. When it is enabled, the aggregated report is sent
+// periodically to a configured endpoint and the counts are reset. The
+// current report can always be inspected with "waypoint server telemetry".
+package telemetry
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+)
+
+// BuiltinPlugins are the plugin names that are reported as-is. All other
+// plugins are reported as CustomPlugin so that the names of private
+// plugins are never sent. This must be kept in sync with the builtin
+// plugins in internal/plugin.
+var BuiltinPlugins = []string{
+	"aws-alb",
+	"aws-ami",
+	"aws-ec2",
+	"aws-ecr",
+	"aws-ecs",
+	"azure-container-instance",
+	"docker",
+	"docker-pull",
+	"exec",
+	"files",
+	"google-cloud-run",
+	"kubernetes",
+	"netlify",
+	"nomad",
+	"pack",
+}
+
+// CustomPlugin is the name that non-builtin plugins are reported as.
+const CustomPlugin = "custom"
+
+// Report is the telemetry that is sent. This is encoded as JSON.
+type Report struct {
+	// Version is the version of the Waypoint server.
+	Version string `json:"version"`
+
+	// PeriodStart and PeriodEnd are the time range the counts are for.
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+
+	// Jobs is the number of jobs queued by operation, such as "build".
+	Jobs map[string]uint64 `json:"jobs"`
+
+	// Plugins is the number of operations that used each plugin, keyed by
+	// component type and plugin name, such as "builder/docker".
+	Plugins map[string]uint64 `json:"plugins"`
+}
+
+// Collector aggregates telemetry counts. It is safe for concurrent use.
+// The zero value is not usable, use NewCollector.
+type Collector struct {
+	version string
+
+	lock    sync.Mutex
+	start   time.Time
+	jobs    map[string]uint64
+	plugins map[string]uint64
+}
+
+// NewCollector returns a collector for a server with the given version.
+func NewCollector(version string) *Collector {
+	return &Collector{
+		version: version,
+		start:   time.Now(),
+		jobs:    make(map[string]uint64),
+		plugins: make(map[string]uint64),
+	}
+}
+
+// Job records that a job was queued.
+func (c *Collector) Job(job *pb.Job) {
+	op := serverptypes.JobOperation(job)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.jobs[op]++
+}
+
+// Component records that an operation used the given plugin.
+func (c *Collector) Component(comp *pb.Component) {
+	if comp == nil {
+		return
+	}
+
+	name := CustomPlugin
+	idx := sort.SearchStrings(BuiltinPlugins, comp.Name)
+	if idx < len(BuiltinPlugins) && BuiltinPlugins[idx] == comp.Name {
+		name = comp.Name
+	}
+
+	key := strings.ToLower(comp.Type.String()) + "/" + name
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.plugins[key]++
+}
+
+// Report returns the report for the counts collected so far without
+// resetting them.
+func (c *Collector) Report() *Report {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.report(time.Now())
+}
+
+// Flush returns the report for the counts collected so far and resets
+// them so that the next report starts a new period.
+func (c *Collector) Flush() *Report {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := time.Now()
+	r := c.report(now)
+	c.start = now
+	c.jobs = make(map[string]uint64)
+	c.plugins = make(map[string]uint64)
+	return r
+}
+
+// report builds the report. This must be called with the lock held.
+func (c *Collector) report(now time.Time) *Report {
+	r := &Report{
+		Version:     c.version,
+		PeriodStart: c.start.UTC(),
+		PeriodEnd:   now.UTC(),
+		Jobs:        make(map[string]uint64, len(c.jobs)),
+		Plugins:     make(map[string]uint64, len(c.plugins)),
+	}
+	for k, v := range c.jobs {
+		r.Jobs[k] = v
+	}
+	for k, v := range c.plugins {
+		r.Plugins[k] = v
+	}
+
+	return r
+}
@@ -0,0 +1,75 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func TestBuiltinPluginsSorted(t *testing.T) {
+	require.True(t, sort.StringsAreSorted(BuiltinPlugins))
+}
+
+func TestCollector(t *testing.T) {
+	require := require.New(t)
+
+	c := NewCollector("v1.2.3")
+	c.Job(&pb.Job{Operation: &pb.Job_Build{}})
+	c.Job(&pb.Job{Operation: &pb.Job_Build{}})
+	c.Job(&pb.Job{Operation: &pb.Job_Deploy{}})
+	c.Component(&pb.Component{Type: pb.Component_BUILDER, Name: "docker"})
+	c.Component(&pb.Component{Type: pb.Component_PLATFORM, Name: "my-secret-platform"})
+	c.Component(nil)
+
+	r := c.Report()
+	require.Equal("v1.2.3", r.Version)
+	require.Equal(map[string]uint64{"build": 2, "deploy": 1}, r.Jobs)
+	require.Equal(map[string]uint64{
+		"builder/docker":  1,
+		"platform/custom": 1,
+	}, r.Plugins)
+
+	// Report doesn't reset
+	require.Equal(r.Jobs, c.Report().Jobs)
+
+	// Flush does
+	flushed := c.Flush()
+	require.Equal(r.Jobs, flushed.Jobs)
+	r = c.Report()
+	require.Empty(r.Jobs)
+	require.Empty(r.Plugins)
+	require.False(r.PeriodStart.Before(flushed.PeriodEnd))
+}
+
+func TestSender(t *testing.T) {
+	require := require.New(t)
+
+	var got Report
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal("POST", r.Method)
+		require.Equal("application/json", r.Header.Get("Content-Type"))
+		require.NoError(json.NewDecoder(r.Body).Decode(&got))
+	}))
+	defer srv.Close()
+
+	c := NewCollector("v1.2.3")
+	c.Job(&pb.Job{Operation: &pb.Job_Release{}})
+
+	s := &Sender{Endpoint: srv.URL, Collector: c}
+	require.NoError(s.Send(context.Background(), c.Flush()))
+	require.Equal("v1.2.3", got.Version)
+	require.Equal(map[string]uint64{"release": 1}, got.Jobs)
+
+	// Errors from the endpoint are returned
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	require.Error(s.Send(context.Background(), c.Flush()))
+}
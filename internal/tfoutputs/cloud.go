@@ -0,0 +1,96 @@
+package tfoutputs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// DefaultCloudAddress is the address of Terraform Cloud.
+const DefaultCloudAddress = "https://app.terraform.io"
+
+// cloudOutputs reads the current state outputs of a Terraform Cloud or
+// Terraform Enterprise workspace.
+func (r *Resolver) cloudOutputs(ctx context.Context, c *pb.Project_TerraformOutputs_Cloud) ([]*Output, error) {
+	if c.Organization == "" || c.Workspace == "" {
+		return nil, fmt.Errorf("organization and workspace are required")
+	}
+
+	token := r.token()
+	if token == "" {
+		return nil, fmt.Errorf(
+			"a Terraform Cloud API token must be set with the %s environment "+
+				"variable on the server", TokenEnvVar)
+	}
+
+	addr := c.Address
+	if addr == "" {
+		addr = DefaultCloudAddress
+	}
+	addr = strings.TrimSuffix(addr, "/") + "/api/v2"
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+token)
+	header.Set("Content-Type", "application/vnd.api+json")
+
+	// Look up the workspace ID. The outputs API requires it.
+	data, err := r.get(ctx, fmt.Sprintf("%s/organizations/%s/workspaces/%s",
+		addr, url.PathEscape(c.Organization), url.PathEscape(c.Workspace)), header)
+	if err != nil {
+		return nil, err
+	}
+
+	var ws struct {
+		Data struct {
+			Id string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &ws); err != nil {
+		return nil, fmt.Errorf("error decoding workspace: %w", err)
+	}
+
+	// Outputs are paginated, so we follow the next links until we have
+	// them all.
+	var result []*Output
+	next := fmt.Sprintf("%s/workspaces/%s/current-state-version-outputs?page%%5Bsize%%5D=100",
+		addr, url.PathEscape(ws.Data.Id))
+	for next != "" {
+		data, err := r.get(ctx, next, header)
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Data []struct {
+				Attributes struct {
+					Name      string          `json:"name"`
+					Value     json.RawMessage `json:"value"`
+					Sensitive bool            `json:"sensitive"`
+				} `json:"attributes"`
+			} `json:"data"`
+			Links struct {
+				Next string `json:"next"`
+			} `json:"links"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("error decoding outputs: %w", err)
+		}
+
+		for _, out := range page.Data {
+			result = append(result, &Output{
+				Name:      out.Attributes.Name,
+				Value:     out.Attributes.Value,
+				Sensitive: out.Attributes.Sensitive,
+			})
+		}
+
+		next = page.Links.Next
+	}
+
+	return result, nil
+}
@@ -0,0 +1,67 @@
+package tfoutputs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// stateOutputs reads the outputs from a state file at a URL.
+func (r *Resolver) stateOutputs(ctx context.Context, s *pb.Project_TerraformOutputs_State) ([]*Output, error) {
+	// We only allow HTTP URLs so that project configuration can't be used
+	// to read files from the server.
+	u, err := url.Parse(s.Url)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("state URL must be http or https, got %q", u.Scheme)
+	}
+
+	data, err := r.get(ctx, s.Url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseState(data)
+}
+
+// ParseState returns the root module outputs of a Terraform state file.
+// Only the state format of Terraform 0.12 and later is supported. The
+// outputs are sorted by name.
+func ParseState(data []byte) ([]*Output, error) {
+	var state struct {
+		Version int `json:"version"`
+		Outputs map[string]struct {
+			Value     json.RawMessage `json:"value"`
+			Sensitive bool            `json:"sensitive"`
+		} `json:"outputs"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("error decoding state: %w", err)
+	}
+	if state.Version != 4 {
+		return nil, fmt.Errorf(
+			"unsupported state version %d, state from Terraform 0.12 or later is required",
+			state.Version)
+	}
+
+	result := make([]*Output, 0, len(state.Outputs))
+	for name, out := range state.Outputs {
+		result = append(result, &Output{
+			Name:      name,
+			Value:     out.Value,
+			Sensitive: out.Sensitive,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
@@ -0,0 +1,143 @@
+// Package tfoutputs reads the outputs of Terraform workspaces and state
+// files so that they can be used as variables for jobs. This lets
+// infrastructure managed by Terraform, such as network or database
+// endpoints, be referenced from the Waypoint configuration without
+// copying the values by hand.
+package tfoutputs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// TokenEnvVar is the environment variable that the Terraform Cloud API
+// token is read from if Resolver.Token isn't set.
+const TokenEnvVar = "TFE_TOKEN"
+
+// maxResponseSize is the maximum size of a response we'll read. State
+// files are read fully into memory so we limit them to something sane.
+const maxResponseSize = 64 << 20
+
+// Output is a single Terraform output.
+type Output struct {
+	Name      string
+	Value     json.RawMessage
+	Sensitive bool
+}
+
+// Resolver reads Terraform outputs. The zero value is ready to use.
+type Resolver struct {
+	// HTTPClient is used for all requests. This defaults to a client with
+	// a 30 second timeout.
+	HTTPClient *http.Client
+
+	// Token is the API token for Terraform Cloud and Enterprise. This
+	// defaults to the value of the TFE_TOKEN environment variable.
+	Token string
+}
+
+// Variables resolves the outputs of all the sources and returns them as
+// variables. Variables are named by the source prefix and output name and
+// the values are JSON-encoded. Sensitive outputs are skipped so that their
+// values aren't stored with jobs. Sensitive config variables should be used
+// for those instead.
+func (r *Resolver) Variables(
+	ctx context.Context,
+	sources []*pb.Project_TerraformOutputs,
+) (map[string]string, error) {
+	result := map[string]string{}
+	owner := map[string]string{}
+	for _, src := range sources {
+		outputs, err := r.Outputs(ctx, src)
+		if err != nil {
+			return nil, fmt.Errorf("error reading Terraform outputs for %q: %w", src.Name, err)
+		}
+
+		for _, out := range outputs {
+			if out.Sensitive {
+				continue
+			}
+
+			name := src.Prefix + out.Name
+			if prev, ok := owner[name]; ok {
+				return nil, fmt.Errorf(
+					"variable %q is set by Terraform outputs for both %q and %q, "+
+						"set a prefix to avoid conflicts", name, prev, src.Name)
+			}
+
+			var buf bytes.Buffer
+			if err := json.Compact(&buf, out.Value); err != nil {
+				return nil, fmt.Errorf(
+					"error decoding Terraform output %q for %q: %w", out.Name, src.Name, err)
+			}
+
+			result[name] = buf.String()
+			owner[name] = src.Name
+		}
+	}
+
+	return result, nil
+}
+
+// Outputs reads the outputs of a single source.
+func (r *Resolver) Outputs(ctx context.Context, src *pb.Project_TerraformOutputs) ([]*Output, error) {
+	switch s := src.Source.(type) {
+	case *pb.Project_TerraformOutputs_Cloud_:
+		return r.cloudOutputs(ctx, s.Cloud)
+
+	case *pb.Project_TerraformOutputs_State_:
+		return r.stateOutputs(ctx, s.State)
+
+	default:
+		return nil, fmt.Errorf("no Terraform workspace or state configured")
+	}
+}
+
+func (r *Resolver) token() string {
+	if r.Token != "" {
+		return r.Token
+	}
+
+	return os.Getenv(TokenEnvVar)
+}
+
+// get performs a GET request and returns the response body. Any response
+// other than a 200 is an error.
+func (r *Resolver) get(ctx context.Context, url string, header http.Header) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	client := r.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// We don't include the full URL since state URLs are often signed.
+		return nil, fmt.Errorf("request to %s%s returned status %d",
+			req.URL.Host, req.URL.Path, resp.StatusCode)
+	}
+
+	return ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+}
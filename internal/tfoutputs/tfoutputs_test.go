@@ -0,0 +1,139 @@
+package tfoutputs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+const testState = `{
+  "version": 4,
+  "terraform_version": "0.14.0",
+  "outputs": {
+    "vpc_id": {"value": "vpc-1234", "type": "string"},
+    "subnets": {"value": ["a", "b"], "type": ["list", "string"]},
+    "db_password": {"value": "hunter2", "type": "string", "sensitive": true}
+  }
+}`
+
+func TestParseState(t *testing.T) {
+	require := require.New(t)
+
+	outputs, err := ParseState([]byte(testState))
+	require.NoError(err)
+	require.Len(outputs, 3)
+	require.Equal("db_password", outputs[0].Name)
+	require.True(outputs[0].Sensitive)
+	require.Equal("subnets", outputs[1].Name)
+	require.JSONEq(`["a", "b"]`, string(outputs[1].Value))
+
+	_, err = ParseState([]byte(`{"version": 3, "modules": []}`))
+	require.Error(err)
+	require.Contains(err.Error(), "unsupported state version")
+}
+
+func TestResolverVariables_state(t *testing.T) {
+	require := require.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testState)
+	}))
+	defer srv.Close()
+
+	var r Resolver
+	vars, err := r.Variables(context.Background(), []*pb.Project_TerraformOutputs{
+		{
+			Name:   "network",
+			Prefix: "network_",
+			Source: &pb.Project_TerraformOutputs_State_{
+				State: &pb.Project_TerraformOutputs_State{Url: srv.URL},
+			},
+		},
+	})
+	require.NoError(err)
+	require.Equal(map[string]string{
+		"network_vpc_id":  `"vpc-1234"`,
+		"network_subnets": `["a","b"]`,
+	}, vars)
+
+	// Conflicting names are an error
+	src := &pb.Project_TerraformOutputs{
+		Name: "a",
+		Source: &pb.Project_TerraformOutputs_State_{
+			State: &pb.Project_TerraformOutputs_State{Url: srv.URL},
+		},
+	}
+	_, err = r.Variables(context.Background(), []*pb.Project_TerraformOutputs{src, src})
+	require.Error(err)
+	require.Contains(err.Error(), "set a prefix")
+
+	// Files can't be read
+	_, err = r.Outputs(context.Background(), &pb.Project_TerraformOutputs{
+		Source: &pb.Project_TerraformOutputs_State_{
+			State: &pb.Project_TerraformOutputs_State{Url: "file:///etc/passwd"},
+		},
+	})
+	require.Error(err)
+}
+
+func TestResolverOutputs_cloud(t *testing.T) {
+	require := require.New(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/organizations/acme/workspaces/network", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		fmt.Fprint(w, `{"data": {"id": "ws-123", "type": "workspaces"}}`)
+	})
+	mux.HandleFunc("/api/v2/workspaces/ws-123/current-state-version-outputs", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page[number]") == "2" {
+			fmt.Fprint(w, `{"data": [
+				{"attributes": {"name": "db_password", "value": null, "sensitive": true}}
+			]}`)
+			return
+		}
+
+		fmt.Fprintf(w, `{"data": [
+			{"attributes": {"name": "vpc_id", "value": "vpc-1234", "sensitive": false}}
+		], "links": {"next": "http://%s%s?page%%5Bnumber%%5D=2"}}`, r.Host, r.URL.Path)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	src := &pb.Project_TerraformOutputs{
+		Name: "network",
+		Source: &pb.Project_TerraformOutputs_Cloud_{
+			Cloud: &pb.Project_TerraformOutputs_Cloud{
+				Address:      srv.URL,
+				Organization: "acme",
+				Workspace:    "network",
+			},
+		},
+	}
+
+	r := Resolver{Token: "secret"}
+	outputs, err := r.Outputs(context.Background(), src)
+	require.NoError(err)
+	require.Len(outputs, 2)
+	require.Equal("vpc_id", outputs[0].Name)
+	require.True(outputs[1].Sensitive)
+
+	vars, err := r.Variables(context.Background(), []*pb.Project_TerraformOutputs{src})
+	require.NoError(err)
+	require.Equal(map[string]string{"vpc_id": `"vpc-1234"`}, vars)
+
+	// Bad token
+	r.Token = "nope"
+	_, err = r.Outputs(context.Background(), src)
+	require.Error(err)
+	require.Contains(err.Error(), "401")
+}
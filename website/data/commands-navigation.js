@@ -10,6 +10,10 @@ export default [
   'ui',
   'up',
   '---------',
+  'api-key-create',
+  'api-key-delete',
+  'api-key-list',
+  'api-key-rotate',
   'artifact-build',
   'artifact-list-builds',
   'artifact-list',
@@ -26,17 +30,45 @@ export default [
   'deployment-deploy',
   'deployment-destroy',
   'deployment-list',
+  'deployment-logs',
   'hostname-delete',
   'hostname-list',
   'hostname-register',
+  'job-approve',
+  'job-cancel-all',
+  'job-export-logs',
+  'job-pause',
+  'job-resume',
+  'job-search-logs',
   'plugin',
+  'project-export',
+  'project-import',
   'runner-agent',
+  'runner-approve',
+  'runner-drain',
+  'runner-install',
+  'runner-list',
+  'runner-token-create',
+  'runner-token-list',
+  'runner-token-revoke',
+  'runner-token-rotate',
+  'runner-undrain',
+  'runner-uninstall',
+  'runner-upgrade',
+  'runner-version-set',
+  'runner-version-status',
   'server-bootstrap',
+  'server-compact',
   'server-config-set',
+  'server-fsck',
   'server-install',
+  'server-load-test',
   'server-run',
+  'server-telemetry',
   'token-exchange',
   'token-invite',
   'token-new',
   'version',
+  'workspace-policy',
+  'workspace-protect',
 ]
@@ -33,9 +33,11 @@ export default [
       'build',
       'deploy',
       'hook',
+      'notification',
       'plugin',
       'registry',
       'release',
+      'terraform_outputs',
       'url',
       'use',
     ],